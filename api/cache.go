@@ -0,0 +1,70 @@
+//go:build windows
+
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// userCacheTTL bounds how stale the cached current user may be. It is short
+	// as fetching the user is also how the UI notices an expired session.
+	userCacheTTL = 30 * time.Second
+	// orgCacheTTL bounds how stale a cached organization may be
+	orgCacheTTL = 5 * time.Minute
+)
+
+// ttlCache is a small in-memory cache whose entries expire after a fixed time
+type ttlCache[V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]ttlCacheEntry[V]
+}
+
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]ttlCacheEntry[V]),
+	}
+}
+
+// get returns the value stored for key if it hasn't expired
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache[V]) set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ttlCacheEntry[V]{value: value, expiresAt: c.now().Add(c.ttl)}
+}
+
+func (c *ttlCache[V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// InvalidateCache drops cached users and organizations, e.g. on logout or when
+// switching organizations
+func (c *APIClient) InvalidateCache() {
+	c.userCache.clear()
+	c.orgCache.clear()
+}
@@ -0,0 +1,132 @@
+//go:build windows
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for ttlCache that only moves when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestCache(ttl time.Duration) (*ttlCache[string], *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	cache := newTTLCache[string](ttl)
+	cache.now = clock.Now
+	return cache, clock
+}
+
+func TestTTLCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     bool          // Whether "key" is stored before the lookup
+		advance time.Duration // How long after storing it is looked up
+		wantOK  bool
+	}{
+		{"miss", false, 0, false},
+		{"hit", true, 0, true},
+		{"hit just before expiry", true, time.Minute - time.Nanosecond, true},
+		{"expired at the TTL", true, time.Minute, false},
+		{"expired after the TTL", true, time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, clock := newTestCache(time.Minute)
+			if tt.set {
+				cache.set("key", "value")
+			}
+			clock.Advance(tt.advance)
+
+			got, ok := cache.get("key")
+			if ok != tt.wantOK {
+				t.Fatalf("get ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != "value" {
+				t.Errorf("get = %q, want %q", got, "value")
+			}
+			if !ok && got != "" {
+				t.Errorf("get on a miss = %q, want the zero value", got)
+			}
+		})
+	}
+}
+
+func TestTTLCacheSetRestartsTTL(t *testing.T) {
+	cache, clock := newTestCache(time.Minute)
+	cache.set("key", "old")
+	clock.Advance(45 * time.Second)
+	cache.set("key", "new")
+	clock.Advance(45 * time.Second)
+
+	if got, ok := cache.get("key"); !ok || got != "new" {
+		t.Errorf("get = %q, %v, want %q, true", got, ok, "new")
+	}
+}
+
+func TestTTLCacheClear(t *testing.T) {
+	cache, _ := newTestCache(time.Minute)
+	cache.set("a", "1")
+	cache.set("b", "2")
+	cache.clear()
+
+	for _, key := range []string{"a", "b"} {
+		if _, ok := cache.get(key); ok {
+			t.Errorf("get(%q) hit after clear", key)
+		}
+	}
+}
+
+func TestGetUserIsCached(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		fmt.Fprintf(w, `{"success":true,"data":{"id":"user-%d","email":"user@example.com"}}`, n)
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "session")
+	clock := &fakeClock{now: time.Now()}
+	client.userCache.now = clock.Now
+
+	getUser := func() string {
+		t.Helper()
+		user, err := client.GetUser()
+		if err != nil {
+			t.Fatalf("GetUser: %v", err)
+		}
+		return user.Id
+	}
+
+	if got := getUser(); got != "user-1" {
+		t.Fatalf("first GetUser = %q, want user-1", got)
+	}
+	clock.Advance(userCacheTTL / 2)
+	if got := getUser(); got != "user-1" || requests.Load() != 1 {
+		t.Errorf("GetUser within the TTL = %q after %d requests, want the cached user-1 after 1", got, requests.Load())
+	}
+	clock.Advance(userCacheTTL)
+	if got := getUser(); got != "user-2" {
+		t.Errorf("GetUser after the TTL = %q, want a fresh user-2", got)
+	}
+
+	client.UpdateSessionToken("other-session")
+	if got := getUser(); got != "user-3" {
+		t.Errorf("GetUser after the session changed = %q, want a fresh user-3", got)
+	}
+}
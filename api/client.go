@@ -4,7 +4,9 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,10 +18,18 @@ import (
 	"github.com/fosrl/windows/version"
 )
 
+// Sentinel errors an APIError matches with errors.Is, based on its HTTP status
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+)
+
 // APIError represents an error from the API client
 type APIError struct {
 	Type    ErrorType
 	Status  int
+	Code    string // Machine-readable error code from the response body, if any
 	Message string
 	Err     error
 }
@@ -67,6 +77,27 @@ func (e *APIError) Unwrap() error {
 	return e.Err
 }
 
+// Is lets errors.Is match HTTP errors against ErrUnauthorized, ErrForbidden and ErrNotFound
+func (e *APIError) Is(target error) bool {
+	if e.Type != ErrorTypeHTTPError {
+		return false
+	}
+	switch target {
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Status == http.StatusForbidden
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	}
+	return false
+}
+
+// IsAuthError reports whether err is an API error for a missing or rejected session (401 or 403)
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden)
+}
+
 // APIClient handles HTTP requests to the Pangolin API
 type APIClient struct {
 	baseURL           string
@@ -75,6 +106,9 @@ type APIClient struct {
 	csrfToken         string
 	client            *http.Client
 	onUnauthorized    func()
+	retryPolicy       RetryPolicy
+	userCache         *ttlCache[User]           // Keyed by session token
+	orgCache          *ttlCache[GetOrgResponse] // Keyed by org ID
 }
 
 // NewAPIClient creates a new API client instance
@@ -82,7 +116,8 @@ func NewAPIClient(baseURL string, sessionToken string) *APIClient {
 	normalizedURL := normalizeBaseURL(baseURL)
 
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:   30 * time.Second,
+		Transport: transport,
 	}
 
 	apiClient := &APIClient{
@@ -91,6 +126,9 @@ func NewAPIClient(baseURL string, sessionToken string) *APIClient {
 		sessionCookieName: "p_session_token",
 		csrfToken:         "x-csrf-protection",
 		client:            client,
+		retryPolicy:       DefaultRetryPolicy,
+		userCache:         newTTLCache[User](userCacheTTL),
+		orgCache:          newTTLCache[GetOrgResponse](orgCacheTTL),
 	}
 
 	logger.Info("APIClient initialized with baseURL: %s", apiClient.baseURL)
@@ -100,11 +138,13 @@ func NewAPIClient(baseURL string, sessionToken string) *APIClient {
 // UpdateBaseURL updates the base URL for the API client
 func (c *APIClient) UpdateBaseURL(newBaseURL string) {
 	c.baseURL = normalizeBaseURL(newBaseURL)
+	c.InvalidateCache()
 }
 
 // UpdateSessionToken updates the session token
 func (c *APIClient) UpdateSessionToken(token string) {
 	c.sessionToken = token
+	c.InvalidateCache()
 }
 
 // CurrentBaseURL returns the current base URL
@@ -162,6 +202,34 @@ func (c *APIClient) apiURL(path string) (string, error) {
 
 // makeRequest makes an HTTP request and returns the response data and status
 func (c *APIClient) makeRequest(method, path string, body []byte) ([]byte, *http.Response, error) {
+	return c.makeRequestContext(context.Background(), method, path, body)
+}
+
+// makeRequestContext is makeRequest bound to ctx. If ctx is canceled or times out
+// the request is aborted and ctx.Err() is returned. GET requests are retried
+// according to the client's retry policy; other methods aren't, as they may not
+// be safe to repeat.
+func (c *APIClient) makeRequestContext(ctx context.Context, method, path string, body []byte) ([]byte, *http.Response, error) {
+	attempts := 1
+	if method == http.MethodGet && c.retryPolicy.MaxAttempts > 1 {
+		attempts = c.retryPolicy.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		data, resp, err := c.doRequest(ctx, method, path, body)
+		if attempt >= attempts || !shouldRetry(resp, err) {
+			return data, resp, err
+		}
+		delay := c.retryPolicy.delay(attempt)
+		logger.Info("Retrying %s %s in %v (attempt %d of %d)", method, redactURL(path), delay, attempt+1, attempts)
+		if waitErr := waitForRetry(ctx, delay); waitErr != nil {
+			return nil, nil, waitErr
+		}
+	}
+}
+
+// doRequest makes a single attempt at an HTTP request
+func (c *APIClient) doRequest(ctx context.Context, method, path string, body []byte) ([]byte, *http.Response, error) {
 	fullURL, err := c.apiURL(path)
 	if err != nil {
 		return nil, nil, err
@@ -172,7 +240,7 @@ func (c *APIClient) makeRequest(method, path string, body []byte) ([]byte, *http
 		bodyReader = bytes.NewReader(body)
 	}
 
-	req, err := http.NewRequest(method, fullURL, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
 	if err != nil {
 		return nil, nil, &APIError{Type: ErrorTypeInvalidURL, Err: err}
 	}
@@ -188,10 +256,16 @@ func (c *APIClient) makeRequest(method, path string, body []byte) ([]byte, *http
 		req.Header.Set("Cookie", fmt.Sprintf("%s=%s", c.sessionCookieName, c.sessionToken))
 	}
 
-	logger.Info("Making request to: %s", fullURL)
+	logger.Info("Making request to: %s", redactURL(fullURL))
 
+	start := time.Now()
 	resp, err := c.client.Do(req)
 	if err != nil {
+		logRequest(method, fullURL, 0, time.Since(start), err, body, nil)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			logger.Info("Request to %s aborted: %v", redactURL(fullURL), ctxErr)
+			return nil, nil, ctxErr
+		}
 		// Handle network errors with more specific messages
 		if urlErr, ok := err.(*url.Error); ok {
 			if urlErr.Timeout() {
@@ -214,7 +288,11 @@ func (c *APIClient) makeRequest(method, path string, body []byte) ([]byte, *http
 
 	// Read response body
 	data, err := io.ReadAll(resp.Body)
+	logRequest(method, fullURL, resp.StatusCode, time.Since(start), err, body, data)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, resp, ctxErr
+		}
 		logger.Error("Error reading response body: %v", err)
 		return nil, resp, &APIError{Type: ErrorTypeInvalidResponse, Err: err}
 	}
@@ -241,6 +319,7 @@ func (c *APIClient) parseResponse(data []byte, resp *http.Response, result inter
 			return &APIError{
 				Type:    ErrorTypeHTTPError,
 				Status:  resp.StatusCode,
+				Code:    errorResponse.Code,
 				Message: message,
 			}
 		}
@@ -283,6 +362,7 @@ func (c *APIClient) parseResponse(data []byte, resp *http.Response, result inter
 		return &APIError{
 			Type:    ErrorTypeHTTPError,
 			Status:  status,
+			Code:    apiResponse.Code,
 			Message: message,
 		}
 	}
@@ -299,6 +379,7 @@ func (c *APIClient) parseResponse(data []byte, resp *http.Response, result inter
 		return &APIError{
 			Type:    ErrorTypeHTTPError,
 			Status:  status,
+			Code:    apiResponse.Code,
 			Message: message,
 		}
 	}
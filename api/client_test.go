@@ -0,0 +1,231 @@
+//go:build windows
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseResponseErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantSentinel error // nil when no sentinel matches
+		wantStatus   int
+		wantCode     string
+		wantMessage  string
+	}{
+		{
+			name:         "401 with a body",
+			status:       http.StatusUnauthorized,
+			body:         `{"success":false,"error":true,"message":"Session expired","code":"SESSION_EXPIRED"}`,
+			wantSentinel: ErrUnauthorized,
+			wantStatus:   401,
+			wantCode:     "SESSION_EXPIRED",
+			wantMessage:  "Session expired",
+		},
+		{
+			name:         "401 without a body",
+			status:       http.StatusUnauthorized,
+			wantSentinel: ErrUnauthorized,
+			wantStatus:   401,
+			wantMessage:  "Unauthorized",
+		},
+		{
+			name:         "403 with an HTML body",
+			status:       http.StatusForbidden,
+			body:         `<html>Forbidden</html>`,
+			wantSentinel: ErrForbidden,
+			wantStatus:   403,
+			wantMessage:  "Unauthorized",
+		},
+		{
+			name:         "404",
+			status:       http.StatusNotFound,
+			body:         `{"error":true,"message":"Device code not found"}`,
+			wantSentinel: ErrNotFound,
+			wantStatus:   404,
+			wantMessage:  "Device code not found",
+		},
+		{
+			name:        "500",
+			status:      http.StatusInternalServerError,
+			body:        `{}`,
+			wantStatus:  500,
+			wantMessage: "Internal server error",
+		},
+		{
+			name:         "failure reported in a 200 body",
+			status:       http.StatusOK,
+			body:         `{"success":false,"status":401,"message":"Not logged in","code":"UNAUTHENTICATED"}`,
+			wantSentinel: ErrUnauthorized,
+			wantStatus:   401,
+			wantCode:     "UNAUTHENTICATED",
+			wantMessage:  "Not logged in",
+		},
+		{
+			name:        "failure in a 200 body without a status",
+			status:      http.StatusOK,
+			body:        `{"success":false}`,
+			wantStatus:  200,
+			wantMessage: "Request failed",
+		},
+	}
+
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result EmptyResponse
+			err := NewAPIClient("https://example.com", "").parseResponse([]byte(tt.body), &http.Response{StatusCode: tt.status}, &result)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("parseResponse() error = %v, want an APIError", err)
+			}
+			if apiErr.Type != ErrorTypeHTTPError || apiErr.Status != tt.wantStatus || apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMessage {
+				t.Errorf("parseResponse() error = %+v, want status %d, code %q, message %q", apiErr, tt.wantStatus, tt.wantCode, tt.wantMessage)
+			}
+			for _, sentinel := range sentinels {
+				if got, want := errors.Is(err, sentinel), sentinel == tt.wantSentinel; got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", sentinel, got, want)
+				}
+			}
+			if got, want := IsAuthError(err), tt.wantSentinel == ErrUnauthorized || tt.wantSentinel == ErrForbidden; got != want {
+				t.Errorf("IsAuthError() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// Only HTTP errors match the sentinels, whatever their status field says
+func TestAPIErrorIsOnlyHTTPError(t *testing.T) {
+	err := &APIError{Type: ErrorTypeNetworkError, Status: http.StatusUnauthorized, Err: errors.New("connection reset")}
+	if errors.Is(err, ErrUnauthorized) || IsAuthError(err) {
+		t.Errorf("network error %v matches ErrUnauthorized", err)
+	}
+	if IsAuthError(nil) {
+		t.Error("IsAuthError(nil) = true")
+	}
+}
+
+// newHangingServer accepts requests and never answers them, reporting each one
+// on the returned channel
+func newHangingServer(t *testing.T) (*APIClient, <-chan struct{}) {
+	t.Helper()
+	received := make(chan struct{}, 8)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return NewAPIClient(server.URL, "session"), received
+}
+
+func TestRequestsStopWhenCanceled(t *testing.T) {
+	calls := []struct {
+		name string
+		call func(ctx context.Context, c *APIClient) error
+	}{
+		{"LoginContext", func(ctx context.Context, c *APIClient) error {
+			_, _, err := c.LoginContext(ctx, "a@example.com", "password", nil)
+			return err
+		}},
+		{"StartDeviceAuthContext", func(ctx context.Context, c *APIClient) error {
+			_, err := c.StartDeviceAuthContext(ctx, "Pangolin Windows Client", nil)
+			return err
+		}},
+		{"PollDeviceAuthContext", func(ctx context.Context, c *APIClient) error {
+			_, _, err := c.PollDeviceAuthContext(ctx, "ABCD1234")
+			return err
+		}},
+		{"GetUserContext", func(ctx context.Context, c *APIClient) error {
+			_, err := c.GetUserContext(ctx)
+			return err
+		}},
+		{"GetServerInfoContext", func(ctx context.Context, c *APIClient) error {
+			_, err := c.GetServerInfoContext(ctx)
+			return err
+		}},
+	}
+
+	for _, tt := range calls {
+		t.Run(tt.name, func(t *testing.T) {
+			client, received := newHangingServer(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- tt.call(ctx, client) }()
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the request never reached the server")
+			}
+
+			cancel()
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("%s() error = %v, want context.Canceled", tt.name, err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("%s() didn't return after its context was canceled", tt.name)
+			}
+		})
+	}
+}
+
+func TestRequestStopsAtDeadline(t *testing.T) {
+	client, _ := newHangingServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetUserContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetUserContext() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("GetUserContext() returned after %v", elapsed)
+	}
+}
+
+// A canceled context also ends the wait between retries
+func TestRetryWaitStopsWhenCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "")
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := client.makeRequestContext(ctx, http.MethodGet, "/user", nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("makeRequestContext() error = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("makeRequestContext() kept waiting to retry")
+	}
+}
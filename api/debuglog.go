@@ -0,0 +1,169 @@
+//go:build windows
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// RequestLogging selects what API clients log about each request
+type RequestLogging int32
+
+const (
+	RequestLoggingOff     RequestLogging = iota
+	RequestLoggingSummary                // Method, URL, status and timing
+	RequestLoggingBodies                 // The summary plus bodies, with secrets redacted
+)
+
+func (m RequestLogging) String() string {
+	switch m {
+	case RequestLoggingOff:
+		return "off"
+	case RequestLoggingSummary:
+		return "summary"
+	case RequestLoggingBodies:
+		return "bodies"
+	default:
+		return "unknown"
+	}
+}
+
+// RequestLoggingEnv turns request logging on without touching the config:
+// "1" logs a summary of each request and "bodies" adds the bodies
+const RequestLoggingEnv = "PANGOLIN_API_DEBUG"
+
+// maxLoggedBodySize caps how much of each body is logged
+const maxLoggedBodySize = 4096
+
+// redacted replaces the value of a secret field in logged JSON
+const redacted = "[REDACTED]"
+
+// secretPathPrefixes are API paths whose next segment is a secret, such as the
+// device auth code in the poll path
+var secretPathPrefixes = []string{"/auth/device-web-auth/poll/"}
+
+var requestLogging atomic.Int32
+
+// SetRequestLogging sets what every API client logs about its requests. It is
+// off by default, since even redacted bodies carry personal data.
+func SetRequestLogging(mode RequestLogging) {
+	requestLogging.Store(int32(mode))
+}
+
+// RequestLoggingFromEnv returns the mode asked for by RequestLoggingEnv, or
+// RequestLoggingOff if it is unset or unknown
+func RequestLoggingFromEnv() RequestLogging {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(RequestLoggingEnv))) {
+	case "1", "true", "summary":
+		return RequestLoggingSummary
+	case "bodies":
+		return RequestLoggingBodies
+	default:
+		return RequestLoggingOff
+	}
+}
+
+// logRequest logs a finished request according to the logging mode. status is
+// 0 and err is set if no response arrived.
+func logRequest(method, url string, status int, elapsed time.Duration, err error, reqBody, respBody []byte) {
+	mode := RequestLogging(requestLogging.Load())
+	if mode == RequestLoggingOff {
+		return
+	}
+	url = redactURL(url)
+	elapsed = elapsed.Round(time.Millisecond)
+	if err != nil {
+		logger.Info("API %s %s failed after %v: %v", method, url, elapsed, err)
+	} else {
+		logger.Info("API %s %s -> %d in %v", method, url, status, elapsed)
+	}
+	if mode < RequestLoggingBodies {
+		return
+	}
+	if len(reqBody) > 0 {
+		logger.Info("API %s %s request body: %s", method, url, redactBody(reqBody))
+	}
+	if len(respBody) > 0 {
+		logger.Info("API %s %s response body: %s", method, url, redactBody(respBody))
+	}
+}
+
+// redactURL returns url for the log with the secret segments of its path replaced
+func redactURL(url string) string {
+	for _, prefix := range secretPathPrefixes {
+		i := strings.Index(url, prefix)
+		if i < 0 {
+			continue
+		}
+		start := i + len(prefix)
+		end := strings.IndexAny(url[start:], "/?#")
+		if end < 0 {
+			end = len(url)
+		} else {
+			end += start
+		}
+		if end > start {
+			url = url[:start] + redacted + url[end:]
+		}
+	}
+	return url
+}
+
+// redactBody returns a body for the log with the values of secret fields
+// replaced. Bodies that aren't JSON are not logged, as they can't be redacted.
+func redactBody(body []byte) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("<%d bytes, not JSON>", len(body))
+	}
+	out, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+	if len(out) > maxLoggedBodySize {
+		return fmt.Sprintf("%s... (%d bytes)", out[:maxLoggedBodySize], len(out))
+	}
+	return string(out)
+}
+
+// redactValue walks decoded JSON and replaces the value of every secret field
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSecretField(key) {
+				if field != nil {
+					v[key] = redacted
+				}
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// isSecretField reports whether a JSON field holds a secret, going by its name:
+// "password", "token", "secret", "code" and "otp" and names ending in them, such
+// as "sessionToken". Codes include the device auth code and the 2FA code sent
+// at login; error codes are redacted along with them.
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, suffix := range []string{"password", "token", "secret", "code", "otp"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+//go:build windows
+
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// loggedBody marshals v and returns it as redactBody would log it
+func loggedBody(t *testing.T, v any) string {
+	t.Helper()
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return redactBody(body)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    any
+		secrets []string // Must not appear in the log
+		kept    []string // Must still appear in the log
+	}{
+		{
+			name:    "login request",
+			body:    LoginRequest{Email: "user@example.com", Password: "hunter2", Code: ptr("123456")},
+			secrets: []string{"hunter2", "123456"},
+			kept:    []string{"user@example.com"},
+		},
+		{
+			name:    "device auth start response",
+			body:    DeviceAuthStartResponse{Code: "ABCD-EFGH", ExpiresInSeconds: 900},
+			secrets: []string{"ABCD-EFGH"},
+			kept:    []string{`"expiresInSeconds":900`},
+		},
+		{
+			name:    "device auth poll response",
+			body:    DeviceAuthPollResponse{Verified: true, Token: ptr("session-token-value"), Message: ptr("approved")},
+			secrets: []string{"session-token-value"},
+			kept:    []string{`"verified":true`, "approved"},
+		},
+		{
+			name: "nested and in lists",
+			body: map[string]any{
+				"data": map[string]any{
+					"olms":     []any{map[string]any{"olmId": "olm-1", "secret": "olm-secret"}},
+					"totpCode": "654321",
+					"otp":      "112233",
+				},
+			},
+			secrets: []string{"olm-secret", "654321", "112233"},
+			kept:    []string{"olm-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged := loggedBody(t, tt.body)
+			for _, secret := range tt.secrets {
+				if strings.Contains(logged, secret) {
+					t.Errorf("logged body %s contains %q", logged, secret)
+				}
+			}
+			for _, kept := range tt.kept {
+				if !strings.Contains(logged, kept) {
+					t.Errorf("logged body %s is missing %q", logged, kept)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactBodyKeepsMissingSecrets(t *testing.T) {
+	// A 2FA code that wasn't sent stays absent rather than showing up redacted
+	logged := loggedBody(t, DeviceAuthPollResponse{Verified: false})
+	if strings.Contains(logged, redacted) {
+		t.Errorf("logged body %s redacts a field that wasn't set", logged)
+	}
+}
+
+func TestRedactBodyNotJSON(t *testing.T) {
+	if got := redactBody([]byte("password=hunter2")); strings.Contains(got, "hunter2") {
+		t.Errorf("redactBody logged a body that isn't JSON: %s", got)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{
+			url:  "https://app.pangolin.net/api/v1/auth/device-web-auth/poll/ABCD-EFGH",
+			want: "https://app.pangolin.net/api/v1/auth/device-web-auth/poll/" + redacted,
+		},
+		{
+			url:  "https://app.pangolin.net/api/v1/auth/device-web-auth/poll/ABCD-EFGH?x=1",
+			want: "https://app.pangolin.net/api/v1/auth/device-web-auth/poll/" + redacted + "?x=1",
+		},
+		{
+			url:  "https://app.pangolin.net/api/v1/auth/device-web-auth/start",
+			want: "https://app.pangolin.net/api/v1/auth/device-web-auth/start",
+		},
+		{
+			url:  "https://app.pangolin.net/api/v1/my-device?olmId=olm-1",
+			want: "https://app.pangolin.net/api/v1/my-device?olmId=olm-1",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := redactURL(tt.url); got != tt.want {
+			t.Errorf("redactURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
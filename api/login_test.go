@@ -0,0 +1,83 @@
+//go:build windows
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoginFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		cookie     string // The session cookie the server sets, none if empty
+		wantToken  string
+		wantCode   bool
+		wantVerify bool
+		wantErr    bool
+	}{
+		{
+			name:      "logged in",
+			body:      `{"success":true,"data":{"userId":"user-1","email":"a@example.com"}}`,
+			cookie:    "p_session_token=token-1",
+			wantToken: "token-1",
+		},
+		{
+			name:      "older cookie name",
+			body:      `{"success":true,"data":{"userId":"user-1","email":"a@example.com"}}`,
+			cookie:    "p_session=token-2",
+			wantToken: "token-2",
+		},
+		{
+			name:     "code requested",
+			body:     `{"success":true,"data":{"codeRequested":true}}`,
+			wantCode: true,
+		},
+		{
+			name:       "email not verified",
+			body:       `{"success":true,"data":{"emailVerificationRequired":true}}`,
+			wantVerify: true,
+		},
+		{
+			name:    "no session and nothing asked for",
+			body:    `{"success":true,"data":{"userId":"user-1","codeRequested":false}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.cookie != "" {
+					w.Header().Set("Set-Cookie", tt.cookie+"; Path=/; HttpOnly")
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			resp, token, err := NewAPIClient(server.URL, "").Login("a@example.com", "password", nil)
+			if tt.wantErr {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.Type != ErrorTypeInvalidResponse {
+					t.Fatalf("Login() error = %v, want an invalid response", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			if token != tt.wantToken {
+				t.Errorf("session token = %q, want %q", token, tt.wantToken)
+			}
+			gotCode := resp.CodeRequested != nil && *resp.CodeRequested
+			gotVerify := resp.EmailVerificationRequired != nil && *resp.EmailVerificationRequired
+			if gotCode != tt.wantCode || gotVerify != tt.wantVerify {
+				t.Errorf("code requested = %v, verification required = %v, want %v, %v", gotCode, gotVerify, tt.wantCode, tt.wantVerify)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -14,6 +15,11 @@ import (
 
 // Login authenticates a user with email and password
 func (c *APIClient) Login(email, password string, code *string) (*LoginResponse, string, error) {
+	return c.LoginContext(context.Background(), email, password, code)
+}
+
+// LoginContext is Login bound to ctx
+func (c *APIClient) LoginContext(ctx context.Context, email, password string, code *string) (*LoginResponse, string, error) {
 	requestBody := LoginRequest{
 		Email:    email,
 		Password: password,
@@ -25,7 +31,7 @@ func (c *APIClient) Login(email, password string, code *string) (*LoginResponse,
 		return nil, "", &APIError{Type: ErrorTypeDecodingError, Err: err}
 	}
 
-	data, resp, err := c.makeRequest("POST", "/auth/login", bodyData)
+	data, resp, err := c.makeRequestContext(ctx, "POST", "/auth/login", bodyData)
 	if err != nil {
 		return nil, "", err
 	}
@@ -42,6 +48,12 @@ func (c *APIClient) Login(email, password string, code *string) (*LoginResponse,
 	}
 
 	if sessionToken == "" {
+		// No session is issued until a requested 2FA code or email verification is completed
+		codeRequested := loginResponse.CodeRequested != nil && *loginResponse.CodeRequested
+		verificationRequired := loginResponse.EmailVerificationRequired != nil && *loginResponse.EmailVerificationRequired
+		if codeRequested || verificationRequired {
+			return &loginResponse, "", nil
+		}
 		return nil, "", &APIError{Type: ErrorTypeInvalidResponse, Message: "No session token in response"}
 	}
 
@@ -50,6 +62,11 @@ func (c *APIClient) Login(email, password string, code *string) (*LoginResponse,
 
 // StartDeviceAuth starts a device authentication flow
 func (c *APIClient) StartDeviceAuth(applicationName string, deviceName *string) (*DeviceAuthStartResponse, error) {
+	return c.StartDeviceAuthContext(context.Background(), applicationName, deviceName)
+}
+
+// StartDeviceAuthContext is StartDeviceAuth bound to ctx
+func (c *APIClient) StartDeviceAuthContext(ctx context.Context, applicationName string, deviceName *string) (*DeviceAuthStartResponse, error) {
 	requestBody := DeviceAuthStartRequest{
 		ApplicationName: applicationName,
 		DeviceName:      deviceName,
@@ -60,7 +77,7 @@ func (c *APIClient) StartDeviceAuth(applicationName string, deviceName *string)
 		return nil, &APIError{Type: ErrorTypeDecodingError, Err: err}
 	}
 
-	data, resp, err := c.makeRequest("POST", "/auth/device-web-auth/start", bodyData)
+	data, resp, err := c.makeRequestContext(ctx, "POST", "/auth/device-web-auth/start", bodyData)
 	if err != nil {
 		return nil, err
 	}
@@ -75,8 +92,13 @@ func (c *APIClient) StartDeviceAuth(applicationName string, deviceName *string)
 
 // PollDeviceAuth polls for device authentication status
 func (c *APIClient) PollDeviceAuth(code string) (*DeviceAuthPollResponse, *string, error) {
+	return c.PollDeviceAuthContext(context.Background(), code)
+}
+
+// PollDeviceAuthContext is PollDeviceAuth bound to ctx
+func (c *APIClient) PollDeviceAuthContext(ctx context.Context, code string) (*DeviceAuthPollResponse, *string, error) {
 	path := fmt.Sprintf("/auth/device-web-auth/poll/%s", code)
-	data, resp, err := c.makeRequest("GET", path, nil)
+	data, resp, err := c.makeRequestContext(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -114,7 +136,17 @@ func (c *APIClient) Logout() error {
 
 // GetUser gets the current user information
 func (c *APIClient) GetUser() (*User, error) {
-	data, resp, err := c.makeRequest("GET", "/user", nil)
+	return c.GetUserContext(context.Background())
+}
+
+// GetUserContext is GetUser bound to ctx. The user is cached briefly per session.
+func (c *APIClient) GetUserContext(ctx context.Context) (*User, error) {
+	token := c.sessionToken
+	if user, ok := c.userCache.get(token); ok {
+		return &user, nil
+	}
+
+	data, resp, err := c.makeRequestContext(ctx, "GET", "/user", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +156,7 @@ func (c *APIClient) GetUser() (*User, error) {
 		return nil, err
 	}
 
+	c.userCache.set(token, user)
 	return &user, nil
 }
 
@@ -218,8 +251,24 @@ func (c *APIClient) GetUserOlm(userId, olmId string, orgId *string) (*Olm, error
 	return &olm, nil
 }
 
-// GetOrg gets an organization by ID
+// DeleteUserOlm deletes a user's OLM, revoking the credentials of the device it belongs to
+func (c *APIClient) DeleteUserOlm(userId, olmId string) error {
+	path := fmt.Sprintf("/user/%s/olm/%s", userId, olmId)
+	data, resp, err := c.makeRequest("DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	var emptyResponse EmptyResponse
+	return c.parseResponse(data, resp, &emptyResponse)
+}
+
+// GetOrg gets an organization by ID. Organizations are cached for a few minutes.
 func (c *APIClient) GetOrg(orgId string) (*GetOrgResponse, error) {
+	if org, ok := c.orgCache.get(orgId); ok {
+		return &org, nil
+	}
+
 	path := fmt.Sprintf("/org/%s", orgId)
 	data, resp, err := c.makeRequest("GET", path, nil)
 	if err != nil {
@@ -231,6 +280,7 @@ func (c *APIClient) GetOrg(orgId string) (*GetOrgResponse, error) {
 		return nil, err
 	}
 
+	c.orgCache.set(orgId, response)
 	return &response, nil
 }
 
@@ -290,7 +340,8 @@ func (c *APIClient) GetMyDevice(olmId string) (*MyDeviceResponse, error) {
 func (c *APIClient) TestConnection() (bool, error) {
 	// Create a temporary client with shorter timeout for connection test
 	testClient := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout:   10 * time.Second,
+		Transport: transport,
 	}
 
 	// Use HEAD request to test connection
@@ -314,7 +365,12 @@ func (c *APIClient) TestConnection() (bool, error) {
 
 // GetServerInfo gets server information including version, build type, and license status
 func (c *APIClient) GetServerInfo() (*ServerInfo, error) {
-	data, resp, err := c.makeRequest("GET", "/server-info", nil)
+	return c.GetServerInfoContext(context.Background())
+}
+
+// GetServerInfoContext is GetServerInfo bound to ctx
+func (c *APIClient) GetServerInfoContext(ctx context.Context) (*ServerInfo, error) {
+	data, resp, err := c.makeRequestContext(ctx, "GET", "/server-info", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -331,7 +387,12 @@ func (c *APIClient) GetServerInfo() (*ServerInfo, error) {
 // Returns true if status is 200-299, 401, or 403 (server is up)
 // Returns false if server is unreachable or returns other error status
 func (c *APIClient) CheckHealth() (bool, error) {
-	_, resp, err := c.makeRequest("GET", "", nil)
+	return c.CheckHealthContext(context.Background())
+}
+
+// CheckHealthContext is CheckHealth bound to ctx
+func (c *APIClient) CheckHealthContext(ctx context.Context) (bool, error) {
+	_, resp, err := c.makeRequestContext(ctx, "GET", "", nil)
 	if err != nil {
 		// Network error means server is down
 		return false, nil
@@ -0,0 +1,107 @@
+//go:build windows
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer answers every request with body and records the last path asked for
+func newTestServer(t *testing.T, body string, path *string) *APIClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*path = r.URL.RequestURI()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewAPIClient(server.URL, "session")
+}
+
+func TestGetUserOlmParsesName(t *testing.T) {
+	var path string
+	client := newTestServer(t, `{"success":true,"data":{"olmId":"olm-1","userId":"user-1","name":"Windows Laptop"}}`, &path)
+
+	olm, err := client.GetUserOlm("user-1", "olm-1", nil)
+	if err != nil {
+		t.Fatalf("GetUserOlm: %v", err)
+	}
+	if path != "/api/v1/user/user-1/olm/olm-1" {
+		t.Errorf("requested %q", path)
+	}
+	if olm.OlmId != "olm-1" || olm.Name == nil || *olm.Name != "Windows Laptop" {
+		t.Errorf("GetUserOlm = %+v, want olm-1 named Windows Laptop", olm)
+	}
+}
+
+func TestGetMyDeviceParsesOlm(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantName *string // nil when the response has no OLM
+	}{
+		{
+			name:     "named",
+			body:     `{"success":true,"data":{"user":{"userId":"user-1"},"orgs":[],"olm":{"olmId":"olm-1","userId":"user-1","name":"Windows Desktop"}}}`,
+			wantName: ptr("Windows Desktop"),
+		},
+		{
+			name:     "unnamed",
+			body:     `{"success":true,"data":{"user":{"userId":"user-1"},"orgs":[],"olm":{"olmId":"olm-1","userId":"user-1"}}}`,
+			wantName: ptr(""),
+		},
+		{
+			name: "no OLM",
+			body: `{"success":true,"data":{"user":{"userId":"user-1"},"orgs":[]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			client := newTestServer(t, tt.body, &path)
+
+			device, err := client.GetMyDevice("olm-1")
+			if err != nil {
+				t.Fatalf("GetMyDevice: %v", err)
+			}
+			if path != "/api/v1/my-device?olmId=olm-1" {
+				t.Errorf("requested %q", path)
+			}
+			if tt.wantName == nil {
+				if device.Olm != nil {
+					t.Errorf("Olm = %+v, want none", device.Olm)
+				}
+				return
+			}
+			if device.Olm == nil {
+				t.Fatal("Olm = nil")
+			}
+			gotName := ""
+			if device.Olm.Name != nil {
+				gotName = *device.Olm.Name
+			}
+			if gotName != *tt.wantName {
+				t.Errorf("Olm.Name = %q, want %q", gotName, *tt.wantName)
+			}
+		})
+	}
+}
+
+func TestGetClientParsesResponse(t *testing.T) {
+	var path string
+	client := newTestServer(t, `{"success":true,"data":{"id":42,"name":"Windows Laptop","olmId":"olm-1"}}`, &path)
+
+	got, err := client.GetClient(42)
+	if err != nil {
+		t.Fatalf("GetClient: %v", err)
+	}
+	if path != "/api/v1/client/42" {
+		t.Errorf("requested %q", path)
+	}
+	if got.Id != 42 || got.Name != "Windows Laptop" || got.OlmId == nil || *got.OlmId != "olm-1" {
+		t.Errorf("GetClient = %+v", got)
+	}
+}
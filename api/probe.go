@@ -0,0 +1,81 @@
+//go:build windows
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// serverProbeTimeout bounds how long ProbeServer waits for an answer
+const serverProbeTimeout = 10 * time.Second
+
+// ServerProbe is the outcome of checking that a URL points at a Pangolin server
+type ServerProbe struct {
+	Reachable bool
+	Version   string // Server version, if it reported one
+	Message   string // What to show the user
+}
+
+// ProbeServer checks, without a session, that baseURL is a Pangolin server by
+// fetching its server info. It makes a single attempt, so the answer is quick.
+// If ctx is canceled the error is returned; any other failure is described in
+// the probe.
+func ProbeServer(ctx context.Context, baseURL string) (ServerProbe, error) {
+	ctx, cancel := context.WithTimeout(ctx, serverProbeTimeout)
+	defer cancel()
+
+	client := NewAPIClient(baseURL, "")
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	info, err := client.GetServerInfoContext(ctx)
+	if errors.Is(err, context.Canceled) {
+		return ServerProbe{}, err
+	}
+	return interpretServerProbe(info, err), nil
+}
+
+// interpretServerProbe turns the server info request's result into a probe. A
+// server that answers, but not with server info, is reachable but not Pangolin.
+func interpretServerProbe(info *ServerInfo, err error) ServerProbe {
+	if err == nil {
+		if info == nil {
+			return ServerProbe{Message: "Not a Pangolin server: the response was empty"}
+		}
+		version := strings.TrimPrefix(strings.TrimSpace(info.Version), "v")
+		if version == "" {
+			return ServerProbe{Reachable: true, Message: "Reachable — Pangolin"}
+		}
+		return ServerProbe{Reachable: true, Version: version, Message: "Reachable — Pangolin v" + version}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ServerProbe{Message: "The server did not answer in time"}
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return ServerProbe{Message: fmt.Sprintf("Could not reach the server: %v", err)}
+	}
+	switch apiErr.Type {
+	case ErrorTypeInvalidURL:
+		return ServerProbe{Message: "The URL is not valid"}
+	case ErrorTypeNetworkError:
+		return ServerProbe{Message: fmt.Sprintf("Could not reach the server: %v", apiErr)}
+	case ErrorTypeInvalidResponse, ErrorTypeDecodingError:
+		return ServerProbe{Message: "Not a Pangolin server: the response was not understood"}
+	case ErrorTypeHTTPError:
+		switch {
+		case apiErr.Status == 0:
+			// Timeouts are reported as HTTP errors without a status
+			return ServerProbe{Message: fmt.Sprintf("Could not reach the server: %v", apiErr)}
+		case apiErr.Status == http.StatusNotFound:
+			return ServerProbe{Message: "Not a Pangolin server: it has no Pangolin API at this URL"}
+		default:
+			return ServerProbe{Message: fmt.Sprintf("The server answered with an error (HTTP %d): %v", apiErr.Status, apiErr)}
+		}
+	}
+	return ServerProbe{Message: apiErr.Error()}
+}
@@ -0,0 +1,126 @@
+//go:build windows
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInterpretServerProbe(t *testing.T) {
+	tests := []struct {
+		name          string
+		info          *ServerInfo
+		err           error
+		wantReachable bool
+		wantVersion   string
+		wantMessage   string
+	}{
+		{
+			name:          "Pangolin with a version",
+			info:          &ServerInfo{Version: " v1.10.2 "},
+			wantReachable: true,
+			wantVersion:   "1.10.2",
+			wantMessage:   "Reachable — Pangolin v1.10.2",
+		},
+		{
+			name:          "Pangolin without a version",
+			info:          &ServerInfo{},
+			wantReachable: true,
+			wantMessage:   "Reachable — Pangolin",
+		},
+		{name: "empty response", wantMessage: "Not a Pangolin server: the response was empty"},
+		{name: "timed out", err: fmt.Errorf("request: %w", context.DeadlineExceeded), wantMessage: "The server did not answer in time"},
+		{name: "invalid URL", err: &APIError{Type: ErrorTypeInvalidURL}, wantMessage: "The URL is not valid"},
+		{
+			name:        "unreachable",
+			err:         &APIError{Type: ErrorTypeNetworkError, Err: errors.New("connection refused")},
+			wantMessage: "Could not reach the server: connection refused",
+		},
+		{
+			name:        "not JSON",
+			err:         &APIError{Type: ErrorTypeDecodingError, Err: errors.New("invalid character '<'")},
+			wantMessage: "Not a Pangolin server: the response was not understood",
+		},
+		{
+			name:        "no API",
+			err:         &APIError{Type: ErrorTypeHTTPError, Status: http.StatusNotFound},
+			wantMessage: "Not a Pangolin server: it has no Pangolin API at this URL",
+		},
+		{
+			name:        "server error",
+			err:         &APIError{Type: ErrorTypeHTTPError, Status: http.StatusBadGateway, Message: "Bad Gateway"},
+			wantMessage: "The server answered with an error (HTTP 502): Bad Gateway",
+		},
+		{
+			name:        "HTTP error without a status",
+			err:         &APIError{Type: ErrorTypeHTTPError, Message: "request timed out"},
+			wantMessage: "Could not reach the server: request timed out",
+		},
+		{name: "other error", err: errors.New("proxy refused"), wantMessage: "Could not reach the server: proxy refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := interpretServerProbe(tt.info, tt.err)
+			want := ServerProbe{Reachable: tt.wantReachable, Version: tt.wantVersion, Message: tt.wantMessage}
+			if got != want {
+				t.Errorf("interpretServerProbe() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestProbeServer(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantReachable bool
+		wantVersion   string
+	}{
+		{name: "Pangolin", status: http.StatusOK, body: `{"success":true,"data":{"version":"1.10.2","build":"oss"}}`, wantReachable: true, wantVersion: "1.10.2"},
+		{name: "web page", status: http.StatusOK, body: `<html><body>Welcome</body></html>`},
+		{name: "no API", status: http.StatusNotFound, body: `Not Found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			probe, err := ProbeServer(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("ProbeServer() error = %v", err)
+			}
+			if path != "/api/v1/server-info" {
+				t.Errorf("requested %q", path)
+			}
+			if probe.Reachable != tt.wantReachable || probe.Version != tt.wantVersion || probe.Message == "" {
+				t.Errorf("ProbeServer() = %+v, want reachable %v, version %q", probe, tt.wantReachable, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestProbeServerCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	if _, err := ProbeServer(ctx, server.URL); !errors.Is(err, context.Canceled) {
+		t.Errorf("ProbeServer() error = %v, want context.Canceled", err)
+	}
+}
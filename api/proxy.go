@@ -0,0 +1,52 @@
+//go:build windows
+
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/fosrl/windows/config"
+)
+
+var (
+	proxyFunc  func(*url.URL) (*url.URL, error)
+	proxyMutex sync.RWMutex
+)
+
+// transport is shared by every APIClient, including the temporary ones used to
+// log in, so a proxy change reaches all of them
+var transport = newTransport()
+
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxyForRequest
+	return t
+}
+
+// SetProxy sends API requests through the proxy chosen by settings. Until it is
+// called, HTTP_PROXY, HTTPS_PROXY and NO_PROXY decide.
+func SetProxy(settings config.ProxySettings) error {
+	fn, err := settings.ProxyFunc()
+	if err != nil {
+		return err
+	}
+	proxyMutex.Lock()
+	proxyFunc = fn
+	proxyMutex.Unlock()
+	// Connections opened through the old proxy must not be reused
+	transport.CloseIdleConnections()
+	return nil
+}
+
+// proxyForRequest picks the proxy for an API request, or nil for a direct connection
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	proxyMutex.RLock()
+	fn := proxyFunc
+	proxyMutex.RUnlock()
+	if fn == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+	return fn(req.URL)
+}
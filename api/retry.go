@@ -0,0 +1,76 @@
+//go:build windows
+
+package api
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how GET requests are retried after transient failures
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled for each further one
+	MaxDelay    time.Duration // Upper bound for the delay between attempts
+	Jitter      float64       // Fraction of the delay to randomize by, e.g. 0.2 for ±20%
+}
+
+// DefaultRetryPolicy is the policy new clients start with
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.2,
+}
+
+// SetRetryPolicy replaces the client's retry policy
+func (c *APIClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// delay returns how long to wait before retry number attempt (starting at 1)
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// shouldRetry reports whether a request that returned resp and err is worth
+// retrying: connection failures and 5xx responses are, 4xx responses are not
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			return false
+		}
+		// Timeouts are reported as HTTP errors without a status
+		return apiErr.Type == ErrorTypeNetworkError || (apiErr.Type == ErrorTypeHTTPError && apiErr.Status == 0)
+	}
+	return resp != nil && resp.StatusCode >= 500
+}
+
+// waitForRetry sleeps for d, returning early with ctx.Err() if ctx is done
+func waitForRetry(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+//go:build windows
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetryPolicy retries quickly, so the tests don't wait on real backoff
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    5 * time.Millisecond,
+}
+
+func TestMakeRequestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int // Answered in turn; the last repeats
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{"500 then 200 succeeds after one retry", http.MethodGet, []int{500, 200}, 200, 2},
+		{"401 is not retried", http.MethodGet, []int{401, 200}, 401, 1},
+		{"404 is not retried", http.MethodGet, []int{404, 200}, 404, 1},
+		{"503 stops at MaxAttempts", http.MethodGet, []int{503}, 503, 3},
+		{"POST is not retried", http.MethodPost, []int{500, 200}, 500, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer server.Close()
+
+			client := NewAPIClient(server.URL, "")
+			client.SetRetryPolicy(testRetryPolicy)
+			_, resp, err := client.makeRequestContext(context.Background(), tt.method, "/test", nil)
+			if err != nil {
+				t.Fatalf("makeRequestContext: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestMakeRequestRetryPolicyDisabled(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "")
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+	if _, _, err := client.makeRequestContext(context.Background(), http.MethodGet, "/test", nil); err != nil {
+		t.Fatalf("makeRequestContext: %v", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"200", &http.Response{StatusCode: 200}, nil, false},
+		{"401", &http.Response{StatusCode: 401}, nil, false},
+		{"429", &http.Response{StatusCode: 429}, nil, false},
+		{"500", &http.Response{StatusCode: 500}, nil, true},
+		{"503", &http.Response{StatusCode: 503}, nil, true},
+		{"network error", nil, &APIError{Type: ErrorTypeNetworkError}, true},
+		{"timeout", nil, &APIError{Type: ErrorTypeHTTPError}, true},
+		{"invalid URL", nil, &APIError{Type: ErrorTypeInvalidURL}, false},
+		{"canceled", nil, context.Canceled, false},
+		{"deadline exceeded", nil, context.DeadlineExceeded, false},
+	}
+
+	for _, tt := range tests {
+		if got := shouldRetry(tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: shouldRetry = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 300 * time.Millisecond, // Capped from 400ms
+		8: 300 * time.Millisecond,
+	} {
+		if got := policy.delay(attempt); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	policy.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		if got := policy.delay(1); got < 80*time.Millisecond || got > 120*time.Millisecond {
+			t.Fatalf("delay(1) with 20%% jitter = %v, want within 80ms..120ms", got)
+		}
+	}
+}
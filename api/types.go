@@ -8,6 +8,7 @@ type APIResponse[T any] struct {
 	Error   *bool  `json:"error,omitempty"`
 	Status  int    `json:"status,omitempty"`
 	Message string `json:"message,omitempty"`
+	Code    string `json:"code,omitempty"`
 	Data    T      `json:"data,omitempty"`
 }
 
@@ -39,8 +40,9 @@ type DeviceAuthStartRequest struct {
 
 // DeviceAuthStartResponse represents a device auth start response
 type DeviceAuthStartResponse struct {
-	Code             string `json:"code"`
-	ExpiresInSeconds int64  `json:"expiresInSeconds"`
+	Code                string `json:"code"`
+	ExpiresInSeconds    int64  `json:"expiresInSeconds"`
+	PollIntervalSeconds int64  `json:"pollIntervalSeconds,omitempty"` // Zero if the server doesn't say
 }
 
 // DeviceAuthPollResponse represents a device auth poll response
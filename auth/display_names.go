@@ -50,3 +50,13 @@ func AccountDisplayName(account *config.Account) string {
 
 	return "Account"
 }
+
+// DeviceDisplayName returns a display name for the device whose OLM has the ID
+// olmId: the name the server has for olm, falling back to the ID when the
+// lookup failed (olm is nil) or returned no name
+func DeviceDisplayName(olm *api.Olm, olmId string) string {
+	if olm != nil && olm.OlmId == olmId && olm.Name != nil && *olm.Name != "" {
+		return *olm.Name
+	}
+	return olmId
+}
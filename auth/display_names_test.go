@@ -0,0 +1,32 @@
+//go:build windows
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/fosrl/windows/api"
+)
+
+func TestDeviceDisplayName(t *testing.T) {
+	tests := []struct {
+		name  string
+		olm   *api.Olm
+		olmId string
+		want  string
+	}{
+		{"named", &api.Olm{OlmId: "olm-1", Name: ptr("Windows Laptop")}, "olm-1", "Windows Laptop"},
+		{"lookup failed", nil, "olm-1", "olm-1"},
+		{"no name", &api.Olm{OlmId: "olm-1"}, "olm-1", "olm-1"},
+		{"empty name", &api.Olm{OlmId: "olm-1", Name: ptr("")}, "olm-1", "olm-1"},
+		{"another device's OLM", &api.Olm{OlmId: "olm-2", Name: ptr("Other")}, "olm-1", "olm-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeviceDisplayName(tt.olm, tt.olmId); got != tt.want {
+				t.Errorf("DeviceDisplayName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
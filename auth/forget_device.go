@@ -0,0 +1,64 @@
+//go:build windows
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fosrl/windows/api"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// ErrDeviceNotRevoked is returned by ForgetDevice when the local data was
+// removed but the server could not be told to revoke the device
+var ErrDeviceNotRevoked = errors.New("the device was not revoked on the server")
+
+// ForgetDevice revokes this device's OLM on the server for the active account
+// and then removes its OLM credentials and logs the account out. The local data
+// is removed even if the server can't be reached, in which case the returned
+// error wraps ErrDeviceNotRevoked and the device has to be removed on the server.
+// The tunnel must be stopped first.
+func (am *AuthManager) ForgetDevice() error {
+	userID := am.accountManager.ActiveUserID
+	if userID == "" {
+		return errors.New("no active account")
+	}
+	return forgetDevice(am.secretManager, am.apiClient, userID, am.Logout)
+}
+
+// olmRevoker is the part of the API client forgetDevice uses
+type olmRevoker interface {
+	DeleteUserOlm(userId, olmId string) error
+}
+
+// forgetDevice revokes userID's stored OLM with client, then deletes its
+// credentials from store and calls logout, whether or not revoking worked
+func forgetDevice(store olmCredentialStore, client olmRevoker, userID string, logout func() error) error {
+	var revokeErr error
+	if olmID, found := store.GetOlmId(userID); found {
+		revokeErr = client.DeleteUserOlm(userID, olmID)
+		if errors.Is(revokeErr, api.ErrNotFound) {
+			// Already gone from the server, which is what was asked for
+			revokeErr = nil
+		}
+		if revokeErr != nil {
+			logger.Error("Failed to revoke OLM %s on the server: %v", olmID, revokeErr)
+		} else {
+			logger.Info("Revoked OLM %s on the server", olmID)
+		}
+	}
+
+	if !store.DeleteOlmCredentials(userID) {
+		return errors.New("failed to delete the stored device credentials")
+	}
+	if err := logout(); err != nil {
+		return err
+	}
+
+	if revokeErr != nil {
+		return fmt.Errorf("%w: %v", ErrDeviceNotRevoked, revokeErr)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+//go:build windows
+
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/fosrl/windows/api"
+)
+
+// fakeOlmRevoker answers DeleteUserOlm with err and records the OLM asked for
+type fakeOlmRevoker struct {
+	err     error
+	revoked string
+}
+
+func (r *fakeOlmRevoker) DeleteUserOlm(userId, olmId string) error {
+	r.revoked = olmId
+	return r.err
+}
+
+func TestForgetDevice(t *testing.T) {
+	unreachable := &api.APIError{Type: api.ErrorTypeNetworkError, Err: errors.New("connection refused")}
+	logoutFailed := errors.New("logout failed")
+
+	tests := []struct {
+		name        string
+		storedOlmId string
+		revokeErr   error
+		logoutErr   error
+		wantRevoked string
+		wantErr     error
+	}{
+		{name: "revoked", storedOlmId: "olm-1", wantRevoked: "olm-1"},
+		{name: "already gone from the server", storedOlmId: "olm-1", revokeErr: &api.APIError{Type: api.ErrorTypeHTTPError, Status: http.StatusNotFound}, wantRevoked: "olm-1"},
+		{name: "server unreachable", storedOlmId: "olm-1", revokeErr: unreachable, wantRevoked: "olm-1", wantErr: ErrDeviceNotRevoked},
+		{name: "nothing to revoke"},
+		{name: "logout fails", storedOlmId: "olm-1", logoutErr: logoutFailed, wantRevoked: "olm-1", wantErr: logoutFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeOlmStore{olmId: tt.storedOlmId, secret: "secret"}
+			client := &fakeOlmRevoker{err: tt.revokeErr}
+			loggedOut := false
+			logout := func() error {
+				if !store.deleted {
+					t.Error("logged out before the credentials were deleted")
+				}
+				loggedOut = true
+				return tt.logoutErr
+			}
+
+			err := forgetDevice(store, client, "user-1", logout)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("forgetDevice() error = %v, want %v", err, tt.wantErr)
+			}
+			if client.revoked != tt.wantRevoked {
+				t.Errorf("revoked %q, want %q", client.revoked, tt.wantRevoked)
+			}
+			// Local data goes whether or not the server was told
+			if !store.deleted || store.olmId != "" || store.secret != "" {
+				t.Errorf("credentials left behind: %+v", store)
+			}
+			if !loggedOut {
+				t.Error("the account was not logged out")
+			}
+		})
+	}
+}
@@ -18,6 +18,10 @@ import (
 	"github.com/fosrl/newt/logger"
 )
 
+// defaultDeviceAuthPollInterval is how often device auth is polled when the server
+// doesn't ask for a specific interval
+const defaultDeviceAuthPollInterval = 3 * time.Second
+
 // AuthError represents authentication-specific errors
 type AuthError struct {
 	Type AuthErrorType
@@ -30,6 +34,7 @@ const (
 	AuthErrorEmailVerificationRequired
 	AuthErrorDeviceCodeExpired
 	AuthErrorInvalidToken
+	AuthErrorInvalidCredentials
 )
 
 func (e *AuthError) Error() string {
@@ -42,6 +47,8 @@ func (e *AuthError) Error() string {
 		return "Device code expired. Please try again."
 	case AuthErrorInvalidToken:
 		return "Invalid session token"
+	case AuthErrorInvalidCredentials:
+		return "Incorrect email or password"
 	default:
 		return "Authentication error"
 	}
@@ -64,11 +71,22 @@ type AuthManager struct {
 	errorMessage               *string
 	deviceAuthCode             *string
 	deviceAuthLoginURL         *string
+	deviceAuthExpiresAt        time.Time
+	deviceAuthLifetime         time.Duration
 	serverInfo                 *api.ServerInfo
 	isServerDown               bool
 	sessionExpired             bool
 	isDeviceAuthInProgress     bool
 	startDeviceAuthImmediately bool
+	policyBlockReason          *string
+	orgSelectionPending        bool
+	authState                  AuthState
+	loginCancel                context.CancelFunc // Stops the device auth login in progress
+	loginID                    uint64             // Counts device auth logins, so a replaced one leaves the state alone
+	stateCallbacks             map[int]func(AuthState)
+	nextStateCallbackID        int
+	deviceUserID               string // The user deviceName was resolved for
+	deviceName                 string // This device's name, see DeviceDisplayName
 }
 
 // NewAuthManager creates a new AuthManager instance
@@ -104,7 +122,7 @@ func (am *AuthManager) Initialize() error {
 		// Load session token from Keychain
 		token, found := am.secretManager.GetSessionToken(activeAccount.UserID)
 		if found && token != "" {
-			am.apiClient.UpdateBaseURL(activeAccount.Hostname)
+			am.apiClient.UpdateBaseURL(config.ResolveHostname(activeAccount.Hostname))
 			am.apiClient.UpdateSessionToken(token)
 
 			// Health check before fetching user/orgs
@@ -125,8 +143,7 @@ func (am *AuthManager) Initialize() error {
 			// Always fetch the latest user info to verify the user exists and update stored info
 			user, err := am.apiClient.GetUser()
 			if err != nil {
-				var apiErr *api.APIError
-				if errors.As(err, &apiErr) && (apiErr.Status == 401 || apiErr.Status == 403) {
+				if api.IsAuthError(err) {
 					// Session expired; keep user in logged-in UI and show re-auth
 					am.MarkSessionExpired()
 					am.mu.Lock()
@@ -165,14 +182,48 @@ func (am *AuthManager) Initialize() error {
 
 // LoginWithDeviceAuth authenticates using device authentication flow
 // The context can be used to cancel the polling operation
-func (am *AuthManager) LoginWithDeviceAuth(ctx context.Context, hostnameOverride *string) error {
+// A login still running is canceled first, and CancelLogin stops this one.
+func (am *AuthManager) LoginWithDeviceAuth(ctx context.Context, hostnameOverride *string) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	am.mu.Lock()
+	if am.loginCancel != nil {
+		am.loginCancel()
+	}
+	am.loginID++
+	id := am.loginID
+	am.loginCancel = cancel
 	am.isDeviceAuthInProgress = true
+	// Start fresh, without the code or error of an earlier attempt
+	am.errorMessage = nil
+	am.clearDeviceAuthLocked()
 	am.mu.Unlock()
+	am.setState(AuthStateStarting)
 	defer func() {
 		am.mu.Lock()
-		am.isDeviceAuthInProgress = false
+		current := am.loginID == id
+		if current {
+			am.loginCancel = nil
+			am.isDeviceAuthInProgress = false
+			if err != nil {
+				am.clearDeviceAuthLocked()
+			}
+		}
 		am.mu.Unlock()
+		if !current {
+			// A newer login owns the state now
+			return
+		}
+		switch {
+		case err == nil:
+			am.setState(AuthStateSuccess)
+		case ctx.Err() != nil:
+			// Canceled by the caller, which is not a failure
+			am.setState(AuthStateIdle)
+		default:
+			am.setState(AuthStateFailed)
+		}
 	}()
 
 	// Use temporary API client if hostname override is provided
@@ -189,17 +240,14 @@ func (am *AuthManager) LoginWithDeviceAuth(ctx context.Context, hostnameOverride
 	deviceName := config.GetFriendlyDeviceName()
 
 	// Start device auth
-	startResponse, err := loginClient.StartDeviceAuth("Pangolin Windows Client", &deviceName)
+	startResponse, err := loginClient.StartDeviceAuthContext(ctx, "Pangolin Windows Client", &deviceName)
 	if err != nil {
-		am.mu.Lock()
-		if apiErr, ok := err.(*api.APIError); ok {
-			msg := apiErr.Error()
-			am.errorMessage = &msg
-		} else {
+		if ctx.Err() == nil {
+			am.mu.Lock()
 			msg := err.Error()
 			am.errorMessage = &msg
+			am.mu.Unlock()
 		}
-		am.mu.Unlock()
 		return err
 	}
 
@@ -213,26 +261,37 @@ func (am *AuthManager) LoginWithDeviceAuth(ctx context.Context, hostnameOverride
 	am.mu.Unlock()
 
 	// Poll for verification
-	expiresAt := time.Now().Add(time.Duration(startResponse.ExpiresInSeconds) * time.Second)
+	lifetime := time.Duration(startResponse.ExpiresInSeconds) * time.Second
+	expiresAt := time.Now().Add(lifetime)
+	am.mu.Lock()
+	am.deviceAuthExpiresAt = expiresAt
+	am.deviceAuthLifetime = lifetime
+	am.mu.Unlock()
+	am.setState(AuthStateAwaitingVerification)
 	verified := false
 	var sessionToken *string
 
-	ticker := time.NewTicker(3 * time.Second)
+	// Poll as often as the server asks, if it says
+	pollInterval := defaultDeviceAuthPollInterval
+	if startResponse.PollIntervalSeconds > 0 {
+		pollInterval = time.Duration(startResponse.PollIntervalSeconds) * time.Second
+	}
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
 	for !verified && time.Now().Before(expiresAt) {
 		select {
 		case <-ctx.Done():
-			// Context canceled, clear state and return
-			am.mu.Lock()
-			am.deviceAuthCode = nil
-			am.deviceAuthLoginURL = nil
-			am.mu.Unlock()
+			// Canceled; the code is cleared on the way out
 			return ctx.Err()
 		case <-ticker.C:
-			pollResponse, token, err := loginClient.PollDeviceAuth(code)
+			pollResponse, token, err := loginClient.PollDeviceAuthContext(ctx, code)
 			if err != nil {
-				// Continue polling on error
+				if errors.Is(err, api.ErrNotFound) {
+					// The server no longer knows the code
+					return &AuthError{Type: AuthErrorDeviceCodeExpired}
+				}
+				// Continue polling on other errors
 				continue
 			}
 
@@ -244,10 +303,6 @@ func (am *AuthManager) LoginWithDeviceAuth(ctx context.Context, hostnameOverride
 			} else if pollResponse.Message != nil {
 				message := *pollResponse.Message
 				if contains(message, "expired") || contains(message, "not found") {
-					am.mu.Lock()
-					am.deviceAuthCode = nil
-					am.deviceAuthLoginURL = nil
-					am.mu.Unlock()
 					return &AuthError{Type: AuthErrorDeviceCodeExpired}
 				}
 			}
@@ -255,16 +310,13 @@ func (am *AuthManager) LoginWithDeviceAuth(ctx context.Context, hostnameOverride
 	}
 
 	if !verified {
-		am.mu.Lock()
-		am.deviceAuthCode = nil
-		am.deviceAuthLoginURL = nil
-		am.mu.Unlock()
 		return &AuthError{Type: AuthErrorDeviceCodeExpired}
 	}
 
 	if sessionToken == nil {
 		return &AuthError{Type: AuthErrorInvalidToken}
 	}
+	am.setState(AuthStateVerifying)
 
 	// If hostname override was provided, update main API client's base URL
 	if hostnameOverride != nil && *hostnameOverride != "" {
@@ -275,7 +327,7 @@ func (am *AuthManager) LoginWithDeviceAuth(ctx context.Context, hostnameOverride
 	am.apiClient.UpdateSessionToken(*sessionToken)
 
 	// Get user info using main API client (now with updated base URL if override was provided)
-	user, err := am.apiClient.GetUser()
+	user, err := am.apiClient.GetUserContext(ctx)
 	if err != nil {
 		am.mu.Lock()
 		msg := err.Error()
@@ -286,13 +338,81 @@ func (am *AuthManager) LoginWithDeviceAuth(ctx context.Context, hostnameOverride
 
 	// Clear device auth UI state after successful auth
 	am.mu.Lock()
-	am.deviceAuthCode = nil
-	am.deviceAuthLoginURL = nil
+	am.clearDeviceAuthLocked()
 	am.mu.Unlock()
 
 	return am.handleSuccessfulAuth(user, loginClient.CurrentBaseURL(), *sessionToken)
 }
 
+// LoginWithPassword authenticates with email and password. code is the two-factor
+// code and is nil on the first attempt. It returns an AuthError of type
+// AuthErrorTwoFactorRequired when the server asks for a code, in which case the
+// caller retries with one, and AuthErrorEmailVerificationRequired when the
+// account's email address has not been verified yet.
+func (am *AuthManager) LoginWithPassword(ctx context.Context, hostnameOverride *string, email, password string, code *string) error {
+	// Use temporary API client if hostname override is provided
+	var loginClient *api.APIClient
+	if hostnameOverride != nil && *hostnameOverride != "" {
+		loginClient = api.NewAPIClient(*hostnameOverride, "")
+	} else {
+		loginClient = am.apiClient
+	}
+
+	loginResponse, sessionToken, err := loginClient.LoginContext(ctx, email, password, code)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if errors.Is(err, api.ErrUnauthorized) {
+			err = &AuthError{Type: AuthErrorInvalidCredentials}
+		}
+		am.mu.Lock()
+		msg := err.Error()
+		am.errorMessage = &msg
+		am.mu.Unlock()
+		return err
+	}
+
+	if err := loginResponseError(loginResponse); err != nil {
+		return err
+	}
+	if sessionToken == "" {
+		return &AuthError{Type: AuthErrorInvalidToken}
+	}
+
+	// If hostname override was provided, update main API client's base URL
+	if hostnameOverride != nil && *hostnameOverride != "" {
+		am.apiClient.UpdateBaseURL(*hostnameOverride)
+	}
+	am.apiClient.UpdateSessionToken(sessionToken)
+
+	user, err := am.apiClient.GetUserContext(ctx)
+	if err != nil {
+		am.mu.Lock()
+		msg := err.Error()
+		am.errorMessage = &msg
+		am.mu.Unlock()
+		return err
+	}
+
+	return am.handleSuccessfulAuth(user, loginClient.CurrentBaseURL(), sessionToken)
+}
+
+// loginResponseError maps the flags of a login response to the step the user
+// still has to complete, or nil if the login went through
+func loginResponseError(resp *api.LoginResponse) error {
+	if resp == nil {
+		return &AuthError{Type: AuthErrorInvalidToken}
+	}
+	if resp.EmailVerificationRequired != nil && *resp.EmailVerificationRequired {
+		return &AuthError{Type: AuthErrorEmailVerificationRequired}
+	}
+	if resp.CodeRequested != nil && *resp.CodeRequested {
+		return &AuthError{Type: AuthErrorTwoFactorRequired}
+	}
+	return nil
+}
+
 // Select an organization if there isn't one already. This happens
 // only for account login and when switching accounts.
 // Returns the selected organization's ID.
@@ -320,7 +440,7 @@ func (am *AuthManager) ensureOrgIsSelected(account *config.Account) string {
 		am.mu.Unlock()
 
 		// Restore last selected org from config,
-		// or auto-select a random one.
+		// or auto-select the only one.
 		var accountToUse *config.Account
 		if account != nil {
 			// Use the provided account (e.g., when switching accounts)
@@ -332,32 +452,44 @@ func (am *AuthManager) ensureOrgIsSelected(account *config.Account) string {
 			}
 		}
 
-		if accountToUse != nil && accountToUse.OrgID != "" {
-			// Try to restore the last selected org for this account
-			for _, org := range orgsResponse.Orgs {
-				if org.Id == accountToUse.OrgID {
-					am.mu.Lock()
-					am.currentOrg = &org
-					selectedOrgID = am.currentOrg.Id
-					am.mu.Unlock()
-					break
-				}
-			}
+		storedOrgID := ""
+		if accountToUse != nil {
+			storedOrgID = accountToUse.OrgID
 		}
-
-		// If no org was selected (either no stored org ID or stored org no longer exists),
-		// auto-select the first available org
-		if selectedOrgID == "" && len(orgsResponse.Orgs) > 0 {
-			am.mu.Lock()
-			am.currentOrg = &orgsResponse.Orgs[0]
-			selectedOrgID = am.currentOrg.Id
-			am.mu.Unlock()
+		selected, needsChoice := chooseInitialOrg(orgsResponse.Orgs, storedOrgID)
+		am.mu.Lock()
+		am.currentOrg = selected
+		am.orgSelectionPending = needsChoice
+		if selected != nil {
+			selectedOrgID = selected.Id
 		}
+		am.mu.Unlock()
 	}
 
 	return selectedOrgID
 }
 
+// chooseInitialOrg picks the organization to start with: the stored one if the user
+// is still a member, otherwise the only one. With several to choose from and none
+// stored, nothing is picked and needsChoice is set so the user can be asked.
+func chooseInitialOrg(orgs []api.Org, storedOrgID string) (selected *api.Org, needsChoice bool) {
+	if storedOrgID != "" {
+		for i := range orgs {
+			if orgs[i].Id == storedOrgID {
+				return &orgs[i], false
+			}
+		}
+	}
+	switch len(orgs) {
+	case 0:
+		return nil, false
+	case 1:
+		return &orgs[0], false
+	default:
+		return nil, true
+	}
+}
+
 // handleSuccessfulAuth handles successful authentication
 func (am *AuthManager) handleSuccessfulAuth(user *api.User, hostname string, token string) error {
 	am.apiClient.UpdateBaseURL(hostname)
@@ -401,6 +533,12 @@ func (am *AuthManager) handleSuccessfulAuth(user *api.User, hostname string, tok
 
 	_ = am.accountManager.AddAccount(newAccount)
 	_ = am.accountManager.SetActiveUser(user.UserId)
+	// The first login ends onboarding
+	am.configManager.MarkOnboarded()
+	// Offer self-hosted servers in the login dialog next time
+	if hostname := am.apiClient.CurrentBaseURL(); hostname != config.DefaultHostname {
+		am.configManager.AddRecentHostname(hostname)
+	}
 
 	am.mu.Lock()
 	am.isAuthenticated = true
@@ -411,6 +549,17 @@ func (am *AuthManager) handleSuccessfulAuth(user *api.User, hostname string, tok
 	// Fetch server info after successful authentication
 	_ = am.fetchServerInfo()
 
+	// Set up the device's OLM now rather than on the first connect
+	if err := am.EnsureOlmCredentials(user.UserId); err != nil {
+		logger.Warn("Failed to set up OLM credentials, retrying on connect: %v", err)
+	} else {
+		am.resolveDeviceName(user.UserId)
+	}
+
+	if err := am.EnforceOrgPolicies(); err != nil {
+		logger.Warn("Failed to check organization policies: %v", err)
+	}
+
 	return nil
 }
 
@@ -499,7 +648,7 @@ func (am *AuthManager) RefreshFromMyDevice(olmId string) error {
 	if err != nil {
 		logger.Error("Failed to refresh from MyDevice: %v", err)
 		// If we get an unauthorized error, user might be logged out
-		if apiErr, ok := err.(*api.APIError); ok && apiErr.Status == 401 {
+		if errors.Is(err, api.ErrUnauthorized) {
 			logger.Info("Session expired, clearing authentication")
 			am.mu.Lock()
 			am.isAuthenticated = false
@@ -550,6 +699,11 @@ func (am *AuthManager) RefreshFromMyDevice(olmId string) error {
 	// Update organizations list
 	am.organizations = newOrgs
 
+	if myDevice.Olm != nil && am.currentUser != nil {
+		am.deviceUserID = am.currentUser.UserId
+		am.deviceName = DeviceDisplayName(myDevice.Olm, olmId)
+	}
+
 	// Ensure authentication is still set (should be true if we got here)
 	am.isAuthenticated = true
 
@@ -557,6 +711,36 @@ func (am *AuthManager) RefreshFromMyDevice(olmId string) error {
 	return nil
 }
 
+// DeviceName returns this device's name for the signed-in user, or "" until
+// it has been looked up
+func (am *AuthManager) DeviceName() string {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	if am.currentUser == nil || am.currentUser.UserId != am.deviceUserID {
+		return ""
+	}
+	return am.deviceName
+}
+
+// resolveDeviceName looks up the name of the user's OLM on the server and
+// caches it for DeviceName, falling back to the OLM's ID if that fails
+func (am *AuthManager) resolveDeviceName(userId string) {
+	olmId, found := am.secretManager.GetOlmId(userId)
+	if !found || olmId == "" {
+		return
+	}
+	olm, err := am.apiClient.GetUserOlm(userId, olmId, nil)
+	if err != nil {
+		logger.Warn("Failed to look up the device name: %v", err)
+		olm = nil
+	}
+
+	am.mu.Lock()
+	am.deviceUserID = userId
+	am.deviceName = DeviceDisplayName(olm, olmId)
+	am.mu.Unlock()
+}
+
 // GetOlmId gets the OLM ID for the current user
 func (am *AuthManager) GetOlmId() (string, bool) {
 	am.mu.RLock()
@@ -582,12 +766,12 @@ func (am *AuthManager) CheckOrgAccess(orgId string) (bool, error) {
 	}
 
 	// Check if it's an unauthorized error
-	apiErr, ok := err.(*api.APIError)
-	if !ok {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
 		return false, err
 	}
 
-	if apiErr.Type == api.ErrorTypeHTTPError && (apiErr.Status == 401 || apiErr.Status == 403) {
+	if api.IsAuthError(err) {
 		// Try to get org policy to understand why access was denied
 		am.mu.RLock()
 		userId := ""
@@ -604,11 +788,11 @@ func (am *AuthManager) CheckOrgAccess(orgId string) (bool, error) {
 					// Get hostname for the resolution URL
 					var hostname string
 					if activeAccount, _ := am.accountManager.ActiveAccount(); activeAccount != nil {
-						hostname = activeAccount.Hostname
+						hostname = config.ResolveHostname(activeAccount.Hostname)
 					} else {
 						// Ideally this should never happen, but use a safe fallback
 						// just in case.
-						hostname = config.DefaultHostname
+						hostname = config.ResolveHostname("")
 					}
 
 					resolutionURL := fmt.Sprintf("%s/%s", hostname, orgId)
@@ -637,6 +821,9 @@ func (am *AuthManager) CheckOrgAccess(orgId string) (bool, error) {
 
 // SelectOrganization selects an organization
 func (am *AuthManager) SelectOrganization(org *api.Org) error {
+	// Check access against the server rather than what was cached for the previous org
+	am.apiClient.InvalidateCache()
+
 	// First check org access
 	hasAccess, err := am.CheckOrgAccess(org.Id)
 	if err != nil || !hasAccess {
@@ -646,6 +833,7 @@ func (am *AuthManager) SelectOrganization(org *api.Org) error {
 	// If access is granted, proceed with selecting the org
 	am.mu.Lock()
 	am.currentOrg = org
+	am.orgSelectionPending = false
 	am.mu.Unlock()
 
 	// Save selected org to accounts store
@@ -657,17 +845,46 @@ func (am *AuthManager) SelectOrganization(org *api.Org) error {
 		logger.Warn("failed to persist selected account to store: %v", err)
 	}
 
+	if err := am.EnforceOrgPolicies(); err != nil {
+		logger.Warn("Failed to check organization policies: %v", err)
+	}
+
 	return nil
 }
 
 // EnsureOlmCredentials ensures OLM credentials exist for the user
 func (am *AuthManager) EnsureOlmCredentials(userId string) error {
+	return ensureOlmCredentials(am.secretManager, am.apiClient, userId,
+		func() string { return fingerprint.GatherFingerprintInfo().PlatformFingerprint },
+		config.GetFriendlyDeviceName)
+}
+
+// olmCredentialStore is the part of the secret manager ensureOlmCredentials uses
+type olmCredentialStore interface {
+	HasOlmCredentials(userId string) bool
+	GetOlmId(userId string) (string, bool)
+	SaveOlmCredentials(userId, olmId, secret string) bool
+	DeleteOlmCredentials(userId string) bool
+}
+
+// olmCredentialAPI is the part of the API client ensureOlmCredentials uses
+type olmCredentialAPI interface {
+	GetUserOlm(userId, olmId string, orgId *string) (*api.Olm, error)
+	RecoverOlmFromFingerprint(userID string, platformFingerprint string) (*api.RecoverOlmResponse, error)
+	CreateOlm(userId, name string) (*api.CreateOlmResponse, error)
+}
+
+// ensureOlmCredentials reuses the stored OLM credentials if the server still
+// knows them, and otherwise recovers the device's OLM or creates a new one
+// named deviceName, storing its credentials. platformFingerprint and
+// deviceName are only called when needed.
+func ensureOlmCredentials(store olmCredentialStore, client olmCredentialAPI, userId string, platformFingerprint, deviceName func() string) error {
 	// Check if OLM credentials already exist locally
-	if am.secretManager.HasOlmCredentials(userId) {
+	if store.HasOlmCredentials(userId) {
 		// Verify OLM exists on server by getting the OLM directly
-		olmIdString, found := am.secretManager.GetOlmId(userId)
+		olmIdString, found := store.GetOlmId(userId)
 		if found {
-			olm, err := am.apiClient.GetUserOlm(userId, olmIdString, nil)
+			olm, err := client.GetUserOlm(userId, olmIdString, nil)
 			if err == nil && olm != nil {
 				// Verify the olmId matches
 				if olm.OlmId == olmIdString {
@@ -676,24 +893,22 @@ func (am *AuthManager) EnsureOlmCredentials(userId string) error {
 				} else {
 					logger.Error("OLM ID mismatch - olm olmId: %s, stored olmId: %s", olm.OlmId, olmIdString)
 					// Clear invalid credentials
-					am.secretManager.DeleteOlmCredentials(userId)
+					store.DeleteOlmCredentials(userId)
 				}
 			} else {
 				// If getting OLM fails, the OLM might not exist
 				logger.Error("Failed to verify OLM credentials: %v", err)
 				// Clear invalid credentials so we can try to create new ones
-				am.secretManager.DeleteOlmCredentials(userId)
+				store.DeleteOlmCredentials(userId)
 			}
 		}
 	}
 
-	fp := fingerprint.GatherFingerprintInfo()
-
 	// First, attempt to recover the credentials and associate it with
 	// an existing device.
-	recoveredCreds, err := am.apiClient.RecoverOlmFromFingerprint(userId, fp.PlatformFingerprint)
+	recoveredCreds, err := client.RecoverOlmFromFingerprint(userId, platformFingerprint())
 	if err == nil {
-		saved := am.secretManager.SaveOlmCredentials(userId, recoveredCreds.OlmID, recoveredCreds.Secret)
+		saved := store.SaveOlmCredentials(userId, recoveredCreds.OlmID, recoveredCreds.Secret)
 		if !saved {
 			return errors.New("failed to save OLM credentials")
 		}
@@ -703,15 +918,13 @@ func (am *AuthManager) EnsureOlmCredentials(userId string) error {
 
 	// If credentials don't exist or were cleared, create new ones
 	// Get friendly device name (e.g., "Windows Laptop" or "Windows Desktop")
-	deviceName := config.GetFriendlyDeviceName()
-
-	olmResponse, err := am.apiClient.CreateOlm(userId, deviceName)
+	olmResponse, err := client.CreateOlm(userId, deviceName())
 	if err != nil {
 		return fmt.Errorf("failed to create OLM: %w", err)
 	}
 
 	// Save OLM credentials
-	saved := am.secretManager.SaveOlmCredentials(userId, olmResponse.OlmId, olmResponse.Secret)
+	saved := store.SaveOlmCredentials(userId, olmResponse.OlmId, olmResponse.Secret)
 	if !saved {
 		return errors.New("failed to save OLM credentials")
 	}
@@ -732,7 +945,7 @@ func (am *AuthManager) SwitchAccount(userID string) error {
 
 	// Step 1: Switch locally first (optimistic switch)
 	_ = am.accountManager.SetActiveUser(userID)
-	am.apiClient.UpdateBaseURL(accountToSwitchTo.Hostname)
+	am.apiClient.UpdateBaseURL(config.ResolveHostname(accountToSwitchTo.Hostname))
 	am.apiClient.UpdateSessionToken(token)
 
 	// Step 2: Clear user data and per-session state immediately
@@ -763,8 +976,7 @@ func (am *AuthManager) SwitchAccount(userID string) error {
 	// Fetch user data
 	user, err := am.apiClient.GetUser()
 	if err != nil {
-		var apiErr *api.APIError
-		if errors.As(err, &apiErr) && (apiErr.Status == 401 || apiErr.Status == 403) {
+		if api.IsAuthError(err) {
 			am.MarkSessionExpired()
 		} else {
 			am.mu.Lock()
@@ -826,6 +1038,7 @@ func (am *AuthManager) Logout() error {
 	am.errorMessage = nil
 	am.deviceAuthCode = nil
 	am.deviceAuthLoginURL = nil
+	am.deviceAuthExpiresAt = time.Time{}
 	am.mu.Unlock()
 
 	_ = am.secretManager.DeleteSessionToken(userID)
@@ -851,6 +1064,35 @@ func (am *AuthManager) Logout() error {
 	return nil
 }
 
+// Reset forgets the signed-in user, their organizations and any login in
+// progress, without contacting the server. Stored accounts and secrets are
+// left to the caller.
+func (am *AuthManager) Reset() {
+	am.CancelLogin()
+	am.apiClient.UpdateSessionToken("")
+	am.apiClient.UpdateBaseURL(config.ResolveHostname(""))
+	am.setState(AuthStateIdle)
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.isAuthenticated = false
+	am.currentUser = nil
+	am.currentOrg = nil
+	am.organizations = []api.Org{}
+	am.errorMessage = nil
+	am.deviceAuthCode = nil
+	am.deviceAuthLoginURL = nil
+	am.deviceAuthExpiresAt = time.Time{}
+	am.deviceAuthLifetime = 0
+	am.serverInfo = nil
+	am.isServerDown = false
+	am.sessionExpired = false
+	am.isDeviceAuthInProgress = false
+	am.startDeviceAuthImmediately = false
+	am.policyBlockReason = nil
+	am.orgSelectionPending = false
+}
+
 // CheckHealthAndSetState performs a health check and updates the server down state
 func (am *AuthManager) CheckHealthAndSetState() error {
 	healthy, err := am.apiClient.CheckHealth()
@@ -914,6 +1156,14 @@ func (am *AuthManager) CurrentOrg() *api.Org {
 	return am.currentOrg
 }
 
+// NeedsOrgSelection reports whether the user belongs to several organizations and
+// hasn't picked one yet
+func (am *AuthManager) NeedsOrgSelection() bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.orgSelectionPending
+}
+
 func (am *AuthManager) Organizations() []api.Org {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
@@ -944,6 +1194,23 @@ func (am *AuthManager) DeviceAuthLoginURL() *string {
 	return am.deviceAuthLoginURL
 }
 
+// DeviceAuthExpiry returns when the current device auth code expires and how long it
+// was valid for. expiresAt is zero when no code is active.
+func (am *AuthManager) DeviceAuthExpiry() (expiresAt time.Time, lifetime time.Duration) {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.deviceAuthExpiresAt, am.deviceAuthLifetime
+}
+
+// DeviceAuthRemaining returns how long a code expiring at expiresAt stays valid
+// after now, never less than zero
+func DeviceAuthRemaining(expiresAt, now time.Time) time.Duration {
+	if expiresAt.IsZero() || !expiresAt.After(now) {
+		return 0
+	}
+	return expiresAt.Sub(now)
+}
+
 func (am *AuthManager) ServerInfo() *api.ServerInfo {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
@@ -986,12 +1253,37 @@ func (am *AuthManager) ClearStartDeviceAuthImmediately() {
 	am.startDeviceAuthImmediately = false
 }
 
-// ClearDeviceAuth clears the device authentication code and URL
+// ClearDeviceAuth clears the device authentication code and URL and returns to
+// AuthStateIdle, unless a login is still running and will report its own outcome
 func (am *AuthManager) ClearDeviceAuth() {
 	am.mu.Lock()
-	defer am.mu.Unlock()
+	am.clearDeviceAuthLocked()
+	inProgress := am.isDeviceAuthInProgress
+	am.mu.Unlock()
+	if !inProgress {
+		am.setState(AuthStateIdle)
+	}
+}
+
+// CancelLogin stops the device auth login in progress, if any, and clears its
+// code and URL at once. LoginWithDeviceAuth then returns context.Canceled.
+func (am *AuthManager) CancelLogin() {
+	am.mu.Lock()
+	cancel := am.loginCancel
+	am.loginCancel = nil
+	am.clearDeviceAuthLocked()
+	am.mu.Unlock()
+	if cancel != nil {
+		logger.Info("Canceling device auth login")
+		cancel()
+	}
+}
+
+// clearDeviceAuthLocked forgets the device auth code and URL. am.mu must be held.
+func (am *AuthManager) clearDeviceAuthLocked() {
 	am.deviceAuthCode = nil
 	am.deviceAuthLoginURL = nil
+	am.deviceAuthExpiresAt = time.Time{}
 }
 
 // UpdateCurrentUser updates the current user (used for session verification)
@@ -0,0 +1,338 @@
+//go:build windows
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/api"
+)
+
+func TestChooseInitialOrg(t *testing.T) {
+	one := []api.Org{{Id: "org-a", Name: "A"}}
+	several := []api.Org{{Id: "org-a", Name: "A"}, {Id: "org-b", Name: "B"}, {Id: "org-c", Name: "C"}}
+	tests := []struct {
+		name        string
+		orgs        []api.Org
+		storedOrgID string
+		wantID      string // Empty when nothing is selected
+		wantChoice  bool
+	}{
+		{"no orgs", nil, "", "", false},
+		{"no orgs with a stored one", nil, "org-a", "", false},
+		{"only org", one, "", "org-a", false},
+		{"only org replaces a stale stored one", one, "org-gone", "org-a", false},
+		{"stored org", several, "org-b", "org-b", false},
+		{"several without a stored one", several, "", "", true},
+		{"several with a stale stored one", several, "org-gone", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, needsChoice := chooseInitialOrg(tt.orgs, tt.storedOrgID)
+			gotID := ""
+			if selected != nil {
+				gotID = selected.Id
+			}
+			if gotID != tt.wantID {
+				t.Errorf("selected = %q, want %q", gotID, tt.wantID)
+			}
+			if needsChoice != tt.wantChoice {
+				t.Errorf("needsChoice = %v, want %v", needsChoice, tt.wantChoice)
+			}
+		})
+	}
+}
+
+func TestChooseInitialOrgPointsIntoSlice(t *testing.T) {
+	orgs := []api.Org{{Id: "org-a"}, {Id: "org-b"}}
+	selected, _ := chooseInitialOrg(orgs, "org-b")
+	if selected != &orgs[1] {
+		t.Errorf("selected = %p, want the slice element %p", selected, &orgs[1])
+	}
+}
+
+func TestDeviceAuthRemaining(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      time.Duration
+	}{
+		{"no code", time.Time{}, 0},
+		{"valid", now.Add(5 * time.Minute), 5 * time.Minute},
+		{"one nanosecond left", now.Add(time.Nanosecond), time.Nanosecond},
+		{"expiring now", now, 0},
+		{"expired", now.Add(-time.Minute), 0},
+	}
+
+	for _, tt := range tests {
+		if got := DeviceAuthRemaining(tt.expiresAt, now); got != tt.want {
+			t.Errorf("%s: DeviceAuthRemaining = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoginResponseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *api.LoginResponse
+		wantErr  bool
+		wantType AuthErrorType
+	}{
+		{name: "no response", wantErr: true, wantType: AuthErrorInvalidToken},
+		{name: "logged in", resp: &api.LoginResponse{UserId: "user-1"}},
+		{name: "flags cleared", resp: &api.LoginResponse{CodeRequested: ptr(false), EmailVerificationRequired: ptr(false)}},
+		{name: "code requested", resp: &api.LoginResponse{CodeRequested: ptr(true)}, wantErr: true, wantType: AuthErrorTwoFactorRequired},
+		{name: "email not verified", resp: &api.LoginResponse{EmailVerificationRequired: ptr(true)}, wantErr: true, wantType: AuthErrorEmailVerificationRequired},
+		{name: "verification before code", resp: &api.LoginResponse{CodeRequested: ptr(true), EmailVerificationRequired: ptr(true)}, wantErr: true, wantType: AuthErrorEmailVerificationRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loginResponseError(tt.resp)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("loginResponseError() = %v, want nil", err)
+				}
+				return
+			}
+			var authErr *AuthError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("loginResponseError() = %v, want an AuthError", err)
+			}
+			if authErr.Type != tt.wantType {
+				t.Errorf("loginResponseError() type = %v, want %v", authErr.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+// newDeviceAuthServer hands out a device code that is never verified. Polls
+// are answered with pollStatus, and report the code as unverified if it is 200.
+func newDeviceAuthServer(t *testing.T, pollStatus int) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"success":true,"data":{"code":"ABCD1234","expiresInSeconds":600,"pollIntervalSeconds":1}}`))
+			return
+		}
+		if pollStatus != http.StatusOK {
+			w.WriteHeader(pollStatus)
+			w.Write([]byte(`{"error":true,"message":"Device code not found"}`))
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{"verified":false}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestLoginWithDeviceAuthStops(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(am *AuthManager, closeDialog context.CancelFunc)
+	}{
+		{
+			name: "dialog closed",
+			stop: func(am *AuthManager, closeDialog context.CancelFunc) { closeDialog() },
+		},
+		{
+			name: "login canceled",
+			stop: func(am *AuthManager, closeDialog context.CancelFunc) { am.CancelLogin() },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostname := newDeviceAuthServer(t, http.StatusOK)
+			am := NewAuthManager(nil, nil, nil, nil)
+			awaiting := make(chan struct{}, 1)
+			am.RegisterStateChange(func(state AuthState) {
+				if state == AuthStateAwaitingVerification {
+					awaiting <- struct{}{}
+				}
+			})
+
+			dialogCtx, closeDialog := context.WithCancel(context.Background())
+			defer closeDialog()
+			done := make(chan error, 1)
+			go func() { done <- am.LoginWithDeviceAuth(dialogCtx, &hostname) }()
+
+			select {
+			case <-awaiting:
+			case err := <-done:
+				t.Fatalf("LoginWithDeviceAuth() returned %v before showing a code", err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("no device code was handed out")
+			}
+
+			tt.stop(am, closeDialog)
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("LoginWithDeviceAuth() error = %v, want context.Canceled", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("polling didn't stop")
+			}
+			if am.DeviceAuthCode() != nil {
+				t.Error("device code still set")
+			}
+			if got := am.State(); got != AuthStateIdle {
+				t.Errorf("State() = %v, want %v", got, AuthStateIdle)
+			}
+		})
+	}
+}
+
+// TestCancelLoginStopsPolling cancels a login waiting for approval, checks the
+// server hears no more polls, and that the next login starts fresh
+func TestCancelLoginStopsPolling(t *testing.T) {
+	var starts, polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			fmt.Fprintf(w, `{"success":true,"data":{"code":"CODE%d","expiresInSeconds":600,"pollIntervalSeconds":1}}`, starts.Add(1))
+			return
+		}
+		polls.Add(1)
+		w.Write([]byte(`{"success":true,"data":{"verified":false}}`))
+	}))
+	defer server.Close()
+	hostname := server.URL
+
+	am := NewAuthManager(nil, nil, nil, nil)
+	startLogin := func() <-chan error {
+		t.Helper()
+		awaiting := make(chan struct{}, 1)
+		unregister := am.RegisterStateChange(func(state AuthState) {
+			if state == AuthStateAwaitingVerification {
+				awaiting <- struct{}{}
+			}
+		})
+		defer unregister()
+		done := make(chan error, 1)
+		go func() { done <- am.LoginWithDeviceAuth(context.Background(), &hostname) }()
+		select {
+		case <-awaiting:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no device code was handed out")
+		}
+		return done
+	}
+
+	done := startLogin()
+	// Let it poll at least once
+	deadline := time.Now().Add(5 * time.Second)
+	for polls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if polls.Load() == 0 {
+		t.Fatal("the login never polled")
+	}
+
+	am.CancelLogin()
+	if am.DeviceAuthCode() != nil || am.DeviceAuthLoginURL() != nil {
+		t.Error("device code or URL still set after CancelLogin()")
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("LoginWithDeviceAuth() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("LoginWithDeviceAuth() didn't return after CancelLogin()")
+	}
+	pollsAtCancel := polls.Load()
+	time.Sleep(2500 * time.Millisecond) // More than two poll intervals
+	if got := polls.Load(); got != pollsAtCancel {
+		t.Errorf("%d polls after CancelLogin(), want none", got-pollsAtCancel)
+	}
+
+	// The next login gets its own code, with nothing left from the last one
+	done = startLogin()
+	if code := am.DeviceAuthCode(); code == nil || *code != "CODE2" {
+		t.Errorf("DeviceAuthCode() = %v, want the new code CODE2", code)
+	}
+	if msg := am.ErrorMessage(); msg != nil {
+		t.Errorf("ErrorMessage() = %q, want none", *msg)
+	}
+	am.CancelLogin()
+	<-done
+}
+
+func TestLoginWithDeviceAuthUnknownCode(t *testing.T) {
+	hostname := newDeviceAuthServer(t, http.StatusNotFound)
+	am := NewAuthManager(nil, nil, nil, nil)
+
+	done := make(chan error, 1)
+	go func() { done <- am.LoginWithDeviceAuth(context.Background(), &hostname) }()
+
+	// The first poll ends the login rather than polling until the code expires
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("polling didn't stop on an unknown code")
+	}
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.Type != AuthErrorDeviceCodeExpired {
+		t.Fatalf("LoginWithDeviceAuth() error = %v, want the device code expired", err)
+	}
+	if am.DeviceAuthCode() != nil {
+		t.Error("device code still set")
+	}
+	if got := am.State(); got != AuthStateFailed {
+		t.Errorf("State() = %v, want %v", got, AuthStateFailed)
+	}
+}
+
+func TestLoginWithPasswordErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantAuthType *AuthErrorType // nil when the API error is returned as is
+	}{
+		{name: "wrong password", status: http.StatusUnauthorized, wantAuthType: ptr(AuthErrorInvalidCredentials)},
+		{name: "server error", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"error":true,"message":"Login failed"}`))
+			}))
+			defer server.Close()
+			hostname := server.URL
+
+			am := NewAuthManager(nil, nil, nil, nil)
+			err := am.LoginWithPassword(context.Background(), &hostname, "a@example.com", "wrong", nil)
+
+			var authErr *AuthError
+			if tt.wantAuthType != nil {
+				if !errors.As(err, &authErr) || authErr.Type != *tt.wantAuthType {
+					t.Fatalf("LoginWithPassword() error = %v, want AuthError type %v", err, *tt.wantAuthType)
+				}
+			} else {
+				var apiErr *api.APIError
+				if errors.As(err, &authErr) || !errors.As(err, &apiErr) || apiErr.Status != tt.status {
+					t.Fatalf("LoginWithPassword() error = %v, want the API error with status %d", err, tt.status)
+				}
+			}
+			if msg := am.ErrorMessage(); msg == nil || *msg != err.Error() {
+				t.Errorf("ErrorMessage() = %v, want %q", msg, err.Error())
+			}
+		})
+	}
+}
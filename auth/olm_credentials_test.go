@@ -0,0 +1,177 @@
+//go:build windows
+
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fosrl/windows/api"
+)
+
+// fakeOlmStore keeps OLM credentials in memory
+type fakeOlmStore struct {
+	olmId, secret string
+	saveFails     bool
+	deleted       bool
+}
+
+func (s *fakeOlmStore) HasOlmCredentials(userId string) bool { return s.olmId != "" }
+
+func (s *fakeOlmStore) GetOlmId(userId string) (string, bool) { return s.olmId, s.olmId != "" }
+
+func (s *fakeOlmStore) SaveOlmCredentials(userId, olmId, secret string) bool {
+	if s.saveFails {
+		return false
+	}
+	s.olmId, s.secret = olmId, secret
+	return true
+}
+
+func (s *fakeOlmStore) DeleteOlmCredentials(userId string) bool {
+	s.olmId, s.secret, s.deleted = "", "", true
+	return true
+}
+
+// fakeOlmAPI answers the OLM endpoints and records which were called
+type fakeOlmAPI struct {
+	serverOlmId string // The OLM the server returns for the stored ID, none if empty
+	recovered   *api.RecoverOlmResponse
+	created     *api.CreateOlmResponse
+	createdName string
+	calls       []string
+}
+
+func (a *fakeOlmAPI) GetUserOlm(userId, olmId string, orgId *string) (*api.Olm, error) {
+	a.calls = append(a.calls, "get")
+	if a.serverOlmId == "" {
+		return nil, errors.New("not found")
+	}
+	return &api.Olm{OlmId: a.serverOlmId, UserId: userId}, nil
+}
+
+func (a *fakeOlmAPI) RecoverOlmFromFingerprint(userID string, platformFingerprint string) (*api.RecoverOlmResponse, error) {
+	a.calls = append(a.calls, "recover")
+	if a.recovered == nil {
+		return nil, errors.New("no device with this fingerprint")
+	}
+	return a.recovered, nil
+}
+
+func (a *fakeOlmAPI) CreateOlm(userId, name string) (*api.CreateOlmResponse, error) {
+	a.calls = append(a.calls, "create")
+	a.createdName = name
+	if a.created == nil {
+		return nil, errors.New("failed")
+	}
+	return a.created, nil
+}
+
+func TestEnsureOlmCredentials(t *testing.T) {
+	created := &api.CreateOlmResponse{Id: "1", OlmId: "olm-new", Secret: "new-secret", Name: "Windows Laptop"}
+	recovered := &api.RecoverOlmResponse{OlmID: "olm-recovered", Secret: "recovered-secret"}
+
+	tests := []struct {
+		name        string
+		storedOlmId string
+		saveFails   bool
+		api         fakeOlmAPI
+		wantCalls   []string
+		wantOlmId   string
+		wantDeleted bool
+		wantErr     bool
+	}{
+		{
+			name:      "create then store",
+			api:       fakeOlmAPI{created: created},
+			wantCalls: []string{"recover", "create"},
+			wantOlmId: "olm-new",
+		},
+		{
+			name:        "already exists",
+			storedOlmId: "olm-stored",
+			api:         fakeOlmAPI{serverOlmId: "olm-stored", created: created},
+			wantCalls:   []string{"get"},
+			wantOlmId:   "olm-stored",
+		},
+		{
+			name:      "recovered from the fingerprint",
+			api:       fakeOlmAPI{recovered: recovered, created: created},
+			wantCalls: []string{"recover"},
+			wantOlmId: "olm-recovered",
+		},
+		{
+			name:        "stored OLM gone from the server",
+			storedOlmId: "olm-stored",
+			api:         fakeOlmAPI{created: created},
+			wantCalls:   []string{"get", "recover", "create"},
+			wantOlmId:   "olm-new",
+			wantDeleted: true,
+		},
+		{
+			name:        "stored OLM ID mismatch",
+			storedOlmId: "olm-stored",
+			api:         fakeOlmAPI{serverOlmId: "olm-other", created: created},
+			wantCalls:   []string{"get", "recover", "create"},
+			wantOlmId:   "olm-new",
+			wantDeleted: true,
+		},
+		{
+			name:      "create fails",
+			api:       fakeOlmAPI{},
+			wantCalls: []string{"recover", "create"},
+			wantErr:   true,
+		},
+		{
+			name:      "store fails",
+			saveFails: true,
+			api:       fakeOlmAPI{created: created},
+			wantCalls: []string{"recover", "create"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeOlmStore{olmId: tt.storedOlmId, saveFails: tt.saveFails}
+			if tt.storedOlmId != "" {
+				store.secret = "stored-secret"
+			}
+			client := tt.api
+
+			err := ensureOlmCredentials(store, &client, "user-1",
+				func() string { return "fingerprint" },
+				func() string { return "Windows Laptop" })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ensureOlmCredentials() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(client.calls, tt.wantCalls) {
+				t.Errorf("API calls = %v, want %v", client.calls, tt.wantCalls)
+			}
+			if store.olmId != tt.wantOlmId {
+				t.Errorf("stored olmId = %q, want %q", store.olmId, tt.wantOlmId)
+			}
+			if store.deleted != tt.wantDeleted {
+				t.Errorf("credentials deleted = %v, want %v", store.deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestEnsureOlmCredentialsStoresCreatedSecret(t *testing.T) {
+	store := &fakeOlmStore{}
+	client := &fakeOlmAPI{created: &api.CreateOlmResponse{OlmId: "olm-new", Secret: "new-secret"}}
+
+	if err := ensureOlmCredentials(store, client, "user-1",
+		func() string { return "fingerprint" },
+		func() string { return "Windows Desktop" }); err != nil {
+		t.Fatalf("ensureOlmCredentials() error = %v", err)
+	}
+	if client.createdName != "Windows Desktop" {
+		t.Errorf("OLM created with name %q, want %q", client.createdName, "Windows Desktop")
+	}
+	if store.secret != "new-secret" {
+		t.Errorf("stored secret = %q, want %q", store.secret, "new-secret")
+	}
+}
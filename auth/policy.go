@@ -0,0 +1,129 @@
+//go:build windows
+
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/api"
+)
+
+// policyEvaluation is what the organization's policies mean for the current session
+type policyEvaluation struct {
+	// BlockReason explains why connecting is not allowed, empty if it is
+	BlockReason string
+	// ReauthRequired is set when the session is older than the organization allows
+	ReauthRequired bool
+	// SessionExpiresAt is when the session reaches the maximum session length, zero if unlimited
+	SessionExpiresAt time.Time
+}
+
+// evaluateOrgPolicies interprets an org access check at time now. The server
+// evaluates the two-factor policy itself, so a required second factor that isn't
+// set up shows up as access being denied.
+func evaluateOrgPolicies(resp *api.CheckOrgUserAccessResponse, now time.Time) policyEvaluation {
+	var eval policyEvaluation
+	if resp == nil {
+		return eval
+	}
+
+	var policies api.OrgPolicies
+	if resp.Policies != nil {
+		policies = *resp.Policies
+	}
+
+	if maxSession := policies.MaxSessionLength; maxSession != nil {
+		if !maxSession.Compliant {
+			eval.ReauthRequired = true
+		} else if maxSession.MaxSessionLengthHours > 0 {
+			remaining := time.Duration(float64(maxSession.MaxSessionLengthHours-maxSession.SessionAgeHours) * float64(time.Hour))
+			eval.SessionExpiresAt = now.Add(remaining)
+		}
+	}
+
+	if !resp.Allowed && !eval.ReauthRequired {
+		switch {
+		case policies.RequiredTwoFactor != nil && *policies.RequiredTwoFactor:
+			eval.BlockReason = "This organization requires two-factor authentication. Set it up for your account, then log in again."
+		case resp.Error != nil && *resp.Error != "":
+			eval.BlockReason = fmt.Sprintf("Access denied: %s", *resp.Error)
+		default:
+			eval.BlockReason = "Access denied due to organization policy violations."
+		}
+	}
+	return eval
+}
+
+// EnforceOrgPolicies checks the current organization's policies for the current
+// user. A non-compliant session length marks the session expired so the user logs
+// in again; other violations block connecting until they are resolved.
+func (am *AuthManager) EnforceOrgPolicies() error {
+	am.mu.RLock()
+	var userID, orgID string
+	if am.currentUser != nil {
+		userID = am.currentUser.UserId
+	}
+	if am.currentOrg != nil {
+		orgID = am.currentOrg.Id
+	}
+	am.mu.RUnlock()
+
+	if userID == "" || orgID == "" {
+		am.setPolicyState(userID, policyEvaluation{})
+		return nil
+	}
+
+	resp, err := am.apiClient.CheckOrgUserAccess(orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	eval := evaluateOrgPolicies(resp, time.Now())
+	am.setPolicyState(userID, eval)
+
+	if eval.ReauthRequired {
+		logger.Info("Session exceeds the maximum session length of organization %s", orgID)
+		am.MarkSessionExpired()
+	} else if eval.BlockReason != "" {
+		logger.Warn("Organization %s policy blocks connecting: %s", orgID, eval.BlockReason)
+	}
+	return nil
+}
+
+// setPolicyState records the outcome of a policy check and persists the session expiry
+func (am *AuthManager) setPolicyState(userID string, eval policyEvaluation) {
+	am.mu.Lock()
+	if eval.BlockReason != "" {
+		reason := eval.BlockReason
+		am.policyBlockReason = &reason
+		am.errorMessage = &reason
+	} else {
+		// Drop the message shown for a block that has since been lifted
+		if am.policyBlockReason != nil && am.errorMessage != nil && *am.errorMessage == *am.policyBlockReason {
+			am.errorMessage = nil
+		}
+		am.policyBlockReason = nil
+	}
+	am.mu.Unlock()
+
+	if userID == "" {
+		return
+	}
+	var expiresAt *time.Time
+	if !eval.SessionExpiresAt.IsZero() {
+		expiresAt = &eval.SessionExpiresAt
+	}
+	if err := am.accountManager.SetSessionExpiry(userID, expiresAt); err != nil {
+		logger.Warn("Failed to save session expiry: %v", err)
+	}
+}
+
+// PolicyBlockReason returns why the organization's policies don't allow connecting,
+// or nil if they do
+func (am *AuthManager) PolicyBlockReason() *string {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.policyBlockReason
+}
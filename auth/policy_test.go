@@ -0,0 +1,115 @@
+//go:build windows
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/api"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestEvaluateOrgPolicies(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		resp *api.CheckOrgUserAccessResponse
+		want policyEvaluation
+	}{
+		{
+			name: "no response",
+			resp: nil,
+			want: policyEvaluation{},
+		},
+		{
+			name: "allowed without policies",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: true},
+			want: policyEvaluation{},
+		},
+		{
+			name: "allowed with a session length limit",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: true, Policies: &api.OrgPolicies{
+				MaxSessionLength: &api.MaxSessionLength{Compliant: true, MaxSessionLengthHours: 8, SessionAgeHours: 2.5},
+			}},
+			want: policyEvaluation{SessionExpiresAt: now.Add(5*time.Hour + 30*time.Minute)},
+		},
+		{
+			name: "session length limit of zero is unlimited",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: true, Policies: &api.OrgPolicies{
+				MaxSessionLength: &api.MaxSessionLength{Compliant: true},
+			}},
+			want: policyEvaluation{},
+		},
+		{
+			name: "session too old",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: false, Policies: &api.OrgPolicies{
+				MaxSessionLength: &api.MaxSessionLength{Compliant: false, MaxSessionLengthHours: 8, SessionAgeHours: 9},
+			}},
+			want: policyEvaluation{ReauthRequired: true},
+		},
+		{
+			name: "session too old takes precedence over two-factor",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: false, Policies: &api.OrgPolicies{
+				RequiredTwoFactor: ptr(true),
+				MaxSessionLength:  &api.MaxSessionLength{Compliant: false},
+			}},
+			want: policyEvaluation{ReauthRequired: true},
+		},
+		{
+			name: "two-factor required",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: false, Error: ptr("2FA required"), Policies: &api.OrgPolicies{
+				RequiredTwoFactor: ptr(true),
+			}},
+			want: policyEvaluation{BlockReason: "This organization requires two-factor authentication. Set it up for your account, then log in again."},
+		},
+		{
+			name: "two-factor policy met",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: true, Policies: &api.OrgPolicies{
+				RequiredTwoFactor: ptr(true),
+			}},
+			want: policyEvaluation{},
+		},
+		{
+			name: "denied with the server's reason",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: false, Error: ptr("password too old"), Policies: &api.OrgPolicies{
+				RequiredTwoFactor: ptr(false),
+				PasswordAge:       &api.PasswordAge{Compliant: false, MaxPasswordAgeDays: 90, PasswordAgeDays: 120},
+			}},
+			want: policyEvaluation{BlockReason: "Access denied: password too old"},
+		},
+		{
+			name: "denied without a reason",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: false, Error: ptr("")},
+			want: policyEvaluation{BlockReason: "Access denied due to organization policy violations."},
+		},
+		{
+			name: "denied with a session expiry still reported",
+			resp: &api.CheckOrgUserAccessResponse{Allowed: false, Policies: &api.OrgPolicies{
+				MaxSessionLength: &api.MaxSessionLength{Compliant: true, MaxSessionLengthHours: 4, SessionAgeHours: 1},
+			}},
+			want: policyEvaluation{
+				BlockReason:      "Access denied due to organization policy violations.",
+				SessionExpiresAt: now.Add(3 * time.Hour),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateOrgPolicies(tt.resp, now)
+			if got.BlockReason != tt.want.BlockReason {
+				t.Errorf("BlockReason = %q, want %q", got.BlockReason, tt.want.BlockReason)
+			}
+			if got.ReauthRequired != tt.want.ReauthRequired {
+				t.Errorf("ReauthRequired = %v, want %v", got.ReauthRequired, tt.want.ReauthRequired)
+			}
+			if !got.SessionExpiresAt.Equal(tt.want.SessionExpiresAt) {
+				t.Errorf("SessionExpiresAt = %v, want %v", got.SessionExpiresAt, tt.want.SessionExpiresAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+//go:build windows
+
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// sessionExpiryCheckInterval is how often the watcher compares the clock with the
+// session expiry. Checking instead of arming a timer for the exact moment keeps
+// it right across sleep and clock changes.
+const sessionExpiryCheckInterval = 30 * time.Second
+
+// SessionExpiry returns when the active account's session reaches the organization's
+// maximum session length, or the zero time if it has no limit
+func (am *AuthManager) SessionExpiry() time.Time {
+	account, err := am.accountManager.ActiveAccount()
+	if err != nil || account.SessionExpiresAt == nil {
+		return time.Time{}
+	}
+	return *account.SessionExpiresAt
+}
+
+// SessionExpiryWatcher warns ahead of a session's expiry and reports the expiry
+// itself, once each for every expiry time. A new login brings a new expiry time,
+// which re-arms both.
+type SessionExpiryWatcher struct {
+	expiry    func() time.Time
+	lead      func() time.Duration
+	onWarning func(expiresAt time.Time)
+	onExpired func()
+	now       func() time.Time
+
+	mu        sync.Mutex
+	warnedFor time.Time
+	expiredOn time.Time
+	stop      chan struct{}
+}
+
+// NewSessionExpiryWatcher creates a watcher for the auth manager's session. lead
+// returns how long before expiry onWarning is called. Callbacks run on the
+// watcher's goroutine.
+func (am *AuthManager) NewSessionExpiryWatcher(lead func() time.Duration, onWarning func(expiresAt time.Time), onExpired func()) *SessionExpiryWatcher {
+	return &SessionExpiryWatcher{
+		expiry:    am.SessionExpiry,
+		lead:      lead,
+		onWarning: onWarning,
+		onExpired: onExpired,
+		now:       time.Now,
+	}
+}
+
+// Start checks the session expiry periodically until Stop is called
+func (w *SessionExpiryWatcher) Start() {
+	w.mu.Lock()
+	if w.stop != nil {
+		w.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	w.stop = stop
+	w.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(sessionExpiryCheckInterval)
+		defer ticker.Stop()
+		for {
+			w.Check()
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic checks started by Start
+func (w *SessionExpiryWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// Check fires the warning or expiry callback if it is due
+func (w *SessionExpiryWatcher) Check() {
+	expiresAt := w.expiry()
+	if expiresAt.IsZero() {
+		return
+	}
+	now := w.now()
+
+	w.mu.Lock()
+	var warn, expire bool
+	switch {
+	case !now.Before(expiresAt):
+		if !w.expiredOn.Equal(expiresAt) {
+			w.expiredOn = expiresAt
+			// Past the expiry there is nothing left to warn about
+			w.warnedFor = expiresAt
+			expire = true
+		}
+	case !now.Before(expiresAt.Add(-w.lead())):
+		if !w.warnedFor.Equal(expiresAt) {
+			w.warnedFor = expiresAt
+			warn = true
+		}
+	}
+	w.mu.Unlock()
+
+	if warn && w.onWarning != nil {
+		logger.Info("Session expires at %s", expiresAt.Format(time.RFC3339))
+		w.onWarning(expiresAt)
+	}
+	if expire && w.onExpired != nil {
+		logger.Info("Session expired at %s", expiresAt.Format(time.RFC3339))
+		w.onExpired()
+	}
+}
@@ -0,0 +1,120 @@
+//go:build windows
+
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// testExpiryWatcher is a SessionExpiryWatcher over a settable expiry and clock
+// that counts its callbacks
+type testExpiryWatcher struct {
+	*SessionExpiryWatcher
+	expiresAt time.Time
+	now       time.Time
+	warnings  []time.Time
+	expiries  int
+}
+
+func newTestExpiryWatcher(lead time.Duration) *testExpiryWatcher {
+	tw := &testExpiryWatcher{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	tw.SessionExpiryWatcher = &SessionExpiryWatcher{
+		expiry:    func() time.Time { return tw.expiresAt },
+		lead:      func() time.Duration { return lead },
+		onWarning: func(expiresAt time.Time) { tw.warnings = append(tw.warnings, expiresAt) },
+		onExpired: func() { tw.expiries++ },
+		now:       func() time.Time { return tw.now },
+	}
+	return tw
+}
+
+func TestSessionExpiryWatcherCheck(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		expiresAt    time.Time
+		now          time.Time
+		wantWarnings int
+		wantExpiries int
+	}{
+		{"no limit", time.Time{}, base, 0, 0},
+		{"before the warning", base.Add(time.Hour), base, 0, 0},
+		{"just before the warning", base.Add(10*time.Minute + time.Second), base, 0, 0},
+		{"at the warning", base.Add(10 * time.Minute), base, 1, 0},
+		{"inside the warning", base.Add(time.Minute), base, 1, 0},
+		{"at the expiry", base, base, 0, 1},
+		{"past the expiry", base.Add(-time.Hour), base, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestExpiryWatcher(10 * time.Minute)
+			w.expiresAt = tt.expiresAt
+			w.now = tt.now
+			w.Check()
+			if len(w.warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %d, want %d", len(w.warnings), tt.wantWarnings)
+			}
+			if w.expiries != tt.wantExpiries {
+				t.Errorf("expiries = %d, want %d", w.expiries, tt.wantExpiries)
+			}
+		})
+	}
+}
+
+func TestSessionExpiryWatcherFiresOnce(t *testing.T) {
+	w := newTestExpiryWatcher(10 * time.Minute)
+	w.expiresAt = w.now.Add(time.Hour)
+
+	w.Check()
+	w.now = w.now.Add(55 * time.Minute)
+	w.Check()
+	w.now = w.now.Add(time.Minute)
+	w.Check()
+	if len(w.warnings) != 1 || !w.warnings[0].Equal(w.expiresAt) {
+		t.Fatalf("warnings = %v, want one for %v", w.warnings, w.expiresAt)
+	}
+
+	w.now = w.now.Add(5 * time.Minute)
+	w.Check()
+	w.now = w.now.Add(time.Hour)
+	w.Check()
+	if w.expiries != 1 {
+		t.Errorf("expiries = %d, want 1", w.expiries)
+	}
+	if len(w.warnings) != 1 {
+		t.Errorf("warnings after the expiry = %d, want still 1", len(w.warnings))
+	}
+}
+
+func TestSessionExpiryWatcherRearmsOnNewExpiry(t *testing.T) {
+	w := newTestExpiryWatcher(10 * time.Minute)
+	w.expiresAt = w.now.Add(-time.Minute)
+	w.Check()
+	if w.expiries != 1 {
+		t.Fatalf("expiries = %d, want 1", w.expiries)
+	}
+
+	// A new login brings a later expiry
+	w.expiresAt = w.now.Add(5 * time.Minute)
+	w.Check()
+	if len(w.warnings) != 1 || !w.warnings[0].Equal(w.expiresAt) {
+		t.Errorf("warnings = %v, want one for the new expiry %v", w.warnings, w.expiresAt)
+	}
+	w.now = w.expiresAt
+	w.Check()
+	if w.expiries != 2 {
+		t.Errorf("expiries = %d, want 2", w.expiries)
+	}
+}
+
+func TestSessionExpiryWatcherSkipsWarningWhenAlreadyExpired(t *testing.T) {
+	w := newTestExpiryWatcher(10 * time.Minute)
+	w.expiresAt = w.now.Add(-time.Second)
+	w.Check()
+	w.Check()
+	if len(w.warnings) != 0 || w.expiries != 1 {
+		t.Errorf("warnings = %d, expiries = %d, want 0 and 1", len(w.warnings), w.expiries)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build windows
+
+package auth
+
+// AuthState is the progress of a device auth login
+type AuthState int
+
+const (
+	AuthStateIdle                 AuthState = iota // No login is running
+	AuthStateStarting                              // Requesting a code from the server
+	AuthStateAwaitingVerification                  // Code shown, waiting for the user to approve it
+	AuthStateVerifying                             // Code approved, fetching the user and signing in
+	AuthStateSuccess                               // Signed in
+	AuthStateFailed                                // The login ended with an error
+)
+
+func (s AuthState) String() string {
+	switch s {
+	case AuthStateIdle:
+		return "Idle"
+	case AuthStateStarting:
+		return "Starting"
+	case AuthStateAwaitingVerification:
+		return "AwaitingVerification"
+	case AuthStateVerifying:
+		return "Verifying"
+	case AuthStateSuccess:
+		return "Success"
+	case AuthStateFailed:
+		return "Failed"
+	default:
+		return "Unknown"
+	}
+}
+
+// State returns the progress of the current device auth login
+func (am *AuthManager) State() AuthState {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.authState
+}
+
+// RegisterStateChange calls cb with the new state after every state transition,
+// on the goroutine that made it. Calling the returned function unregisters cb.
+func (am *AuthManager) RegisterStateChange(cb func(AuthState)) (unregister func()) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if am.stateCallbacks == nil {
+		am.stateCallbacks = make(map[int]func(AuthState))
+	}
+	id := am.nextStateCallbackID
+	am.nextStateCallbackID++
+	am.stateCallbacks[id] = cb
+	return func() {
+		am.mu.Lock()
+		defer am.mu.Unlock()
+		delete(am.stateCallbacks, id)
+	}
+}
+
+// setState moves to state and notifies the registered callbacks, outside the
+// lock so they can call back into the manager. Setting the current state again
+// is not a transition and notifies no one.
+func (am *AuthManager) setState(state AuthState) {
+	am.mu.Lock()
+	if am.authState == state {
+		am.mu.Unlock()
+		return
+	}
+	am.authState = state
+	callbacks := make([]func(AuthState), 0, len(am.stateCallbacks))
+	for _, cb := range am.stateCallbacks {
+		callbacks = append(callbacks, cb)
+	}
+	am.mu.Unlock()
+
+	for _, cb := range callbacks {
+		cb(state)
+	}
+}
@@ -0,0 +1,162 @@
+//go:build windows
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/api"
+)
+
+// stateRecorder collects the states an AuthManager reports, in order
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []AuthState
+}
+
+func (r *stateRecorder) record(state AuthState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, state)
+}
+
+func (r *stateRecorder) get() []AuthState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]AuthState(nil), r.states...)
+}
+
+func TestSetState(t *testing.T) {
+	am := NewAuthManager(nil, nil, nil, nil)
+	if got := am.State(); got != AuthStateIdle {
+		t.Fatalf("initial State() = %v, want %v", got, AuthStateIdle)
+	}
+
+	var first, second stateRecorder
+	unregisterFirst := am.RegisterStateChange(first.record)
+	am.RegisterStateChange(second.record)
+
+	am.setState(AuthStateStarting)
+	am.setState(AuthStateStarting) // Not a transition
+	am.setState(AuthStateAwaitingVerification)
+	unregisterFirst()
+	am.setState(AuthStateVerifying)
+	am.setState(AuthStateSuccess)
+
+	if want := []AuthState{AuthStateStarting, AuthStateAwaitingVerification}; !reflect.DeepEqual(first.get(), want) {
+		t.Errorf("unregistered callback saw %v, want %v", first.get(), want)
+	}
+	want := []AuthState{AuthStateStarting, AuthStateAwaitingVerification, AuthStateVerifying, AuthStateSuccess}
+	if !reflect.DeepEqual(second.get(), want) {
+		t.Errorf("callback saw %v, want %v", second.get(), want)
+	}
+	if got := am.State(); got != AuthStateSuccess {
+		t.Errorf("State() = %v, want %v", got, AuthStateSuccess)
+	}
+}
+
+// Callbacks run outside the lock, so they can read the manager
+func TestStateCallbackCallsBack(t *testing.T) {
+	am := NewAuthManager(nil, nil, nil, nil)
+	var seen []AuthState
+	am.RegisterStateChange(func(state AuthState) {
+		seen = append(seen, am.State())
+		am.DeviceAuthCode()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		am.setState(AuthStateStarting)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("setState() deadlocked with a callback that reads the manager")
+	}
+	if want := []AuthState{AuthStateStarting}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("callback saw State() = %v, want %v", seen, want)
+	}
+}
+
+// A device auth login runs through its states in order. The code is approved
+// at once, and fetching the user then fails.
+func TestLoginWithDeviceAuthStates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost:
+			w.Write([]byte(`{"success":true,"data":{"code":"ABCD1234","expiresInSeconds":600,"pollIntervalSeconds":1}}`))
+		case strings.Contains(r.URL.Path, "/poll/"):
+			w.Write([]byte(`{"success":true,"data":{"verified":true,"token":"session-token"}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":true,"message":"Forbidden"}`))
+		}
+	}))
+	defer server.Close()
+
+	am := NewAuthManager(api.NewAPIClient(server.URL, ""), nil, nil, nil)
+	var recorder stateRecorder
+	am.RegisterStateChange(recorder.record)
+
+	done := make(chan error, 1)
+	go func() { done <- am.LoginWithDeviceAuth(context.Background(), nil) }()
+	select {
+	case err := <-done:
+		if err == nil || errors.Is(err, context.Canceled) {
+			t.Fatalf("LoginWithDeviceAuth() error = %v, want the user fetch to fail", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("login didn't finish")
+	}
+
+	want := []AuthState{AuthStateStarting, AuthStateAwaitingVerification, AuthStateVerifying, AuthStateFailed}
+	if got := recorder.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("states = %v, want %v", got, want)
+	}
+	if am.DeviceAuthCode() != nil {
+		t.Error("device code still set after the login failed")
+	}
+}
+
+func TestLoginWithDeviceAuthCanceledStates(t *testing.T) {
+	hostname := newDeviceAuthServer(t, http.StatusOK)
+	am := NewAuthManager(nil, nil, nil, nil)
+	var recorder stateRecorder
+	awaiting := make(chan struct{}, 1)
+	am.RegisterStateChange(func(state AuthState) {
+		recorder.record(state)
+		if state == AuthStateAwaitingVerification {
+			awaiting <- struct{}{}
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- am.LoginWithDeviceAuth(context.Background(), &hostname) }()
+	select {
+	case <-awaiting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no device code was handed out")
+	}
+	am.CancelLogin()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("login didn't stop")
+	}
+
+	// Canceling isn't a failure, so the login goes back to Idle
+	want := []AuthState{AuthStateStarting, AuthStateAwaitingVerification, AuthStateIdle}
+	if got := recorder.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("states = %v, want %v", got, want)
+	}
+}
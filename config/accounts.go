@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/fosrl/newt/logger"
 )
@@ -21,6 +22,9 @@ type AccountManager struct {
 
 	path string
 
+	// onChange is called after accounts are changed and saved
+	onChange func()
+
 	ActiveUserID string             `json:"activeUserId"`
 	Accounts     map[string]Account `json:"accounts"`
 }
@@ -32,6 +36,8 @@ type Account struct {
 	Username string `json:"username"`
 	Name     string `json:"name"`
 	Hostname string `json:"hostname"`
+	// SessionExpiresAt is when the organization's maximum session length ends the session, if it has one
+	SessionExpiresAt *time.Time `json:"sessionExpiresAt,omitempty"`
 }
 
 func NewAccountManager() *AccountManager {
@@ -94,12 +100,34 @@ func (m *AccountManager) saveLocked() error {
 	return nil
 }
 
+// commitLocked saves the accounts and notifies the change callback
+// Caller must hold the lock
+func (m *AccountManager) commitLocked() error {
+	if err := m.saveLocked(); err != nil {
+		return err
+	}
+
+	// Run outside the lock, so the callback can read the accounts
+	if cb := m.onChange; cb != nil {
+		go cb()
+	}
+	return nil
+}
+
+// SetOnChange registers a callback run after the accounts are changed, e.g.
+// on login, logout or account switch. It is called on its own goroutine.
+func (m *AccountManager) SetOnChange(cb func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = cb
+}
+
 func (m *AccountManager) AddAccount(account Account) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.Accounts[account.UserID] = account
-	return m.saveLocked()
+	return m.commitLocked()
 }
 
 func (m *AccountManager) RemoveAccount(userID string) error {
@@ -112,7 +140,17 @@ func (m *AccountManager) RemoveAccount(userID string) error {
 		m.ActiveUserID = ""
 	}
 
-	return m.saveLocked()
+	return m.commitLocked()
+}
+
+// Clear removes every account, leaving no active account
+func (m *AccountManager) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Accounts = make(map[string]Account)
+	m.ActiveUserID = ""
+	return m.commitLocked()
 }
 
 func (m *AccountManager) ActiveAccount() (*Account, error) {
@@ -140,7 +178,7 @@ func (m *AccountManager) SetActiveUser(userID string) error {
 	}
 
 	m.ActiveUserID = userID
-	return m.saveLocked()
+	return m.commitLocked()
 }
 
 func (m *AccountManager) SetUserOrganization(userID string, orgID string) error {
@@ -154,7 +192,33 @@ func (m *AccountManager) SetUserOrganization(userID string, orgID string) error
 		return errors.New("account does not exist")
 	}
 
-	return m.saveLocked()
+	return m.commitLocked()
+}
+
+// SetSessionExpiry records when the user's session expires; nil means it doesn't
+func (m *AccountManager) SetSessionExpiry(userID string, expiresAt *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	account, ok := m.Accounts[userID]
+	if !ok {
+		return errors.New("account does not exist")
+	}
+	if timesEqual(account.SessionExpiresAt, expiresAt) {
+		return nil
+	}
+	account.SessionExpiresAt = expiresAt
+	m.Accounts[userID] = account // Put the modified account back in the map
+
+	return m.commitLocked()
+}
+
+// timesEqual compares two optional times
+func timesEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
 
 func (m *AccountManager) UpdateAccountUserInfo(userID, username, name string) error {
@@ -169,5 +233,5 @@ func (m *AccountManager) UpdateAccountUserInfo(userID, username, name string) er
 		return errors.New("account does not exist")
 	}
 
-	return m.saveLocked()
+	return m.commitLocked()
 }
@@ -0,0 +1,51 @@
+//go:build windows
+
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAccountChangesNotify(t *testing.T) {
+	m := &AccountManager{
+		path:     filepath.Join(t.TempDir(), AccountsFileName),
+		Accounts: make(map[string]Account),
+	}
+	changed := make(chan struct{}, 8)
+	m.SetOnChange(func() { changed <- struct{}{} })
+
+	expectChange := func(what string) {
+		t.Helper()
+		select {
+		case <-changed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change notified after %s", what)
+		}
+	}
+
+	if err := m.AddAccount(Account{UserID: "user-1", Email: "milo@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("logging in")
+	if err := m.SetActiveUser("user-1"); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("switching accounts")
+	if err := m.RemoveAccount("user-1"); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("logging out")
+
+	// A failed save isn't a change
+	m.path = t.TempDir()
+	if err := m.AddAccount(Account{UserID: "user-2"}); err == nil {
+		t.Fatal("AddAccount() saved to a directory")
+	}
+	select {
+	case <-changed:
+		t.Error("change notified for an account that wasn't saved")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/fosrl/newt/logger"
@@ -17,18 +18,133 @@ const (
 	AppName            = "Pangolin"
 	DefaultHostname    = "https://app.pangolin.net"
 	ConfigFileName     = "pangolin.json"
-	LogLevel           = "debug" // Centralized log level for the application
 	DefaultPrimaryDNS  = "9.9.9.9"
 	DefaultDNSOverride = true
 	DefaultDNSTunnel   = false
 )
 
+// UpdateChannel selects which release feed the updater follows
+type UpdateChannel string
+
+const (
+	UpdateChannelStable UpdateChannel = "stable"
+	UpdateChannelBeta   UpdateChannel = "beta"
+
+	DefaultUpdateChannel = UpdateChannelStable
+)
+
+const (
+	DefaultAutoUpdateCheck     = true
+	DefaultUpdateCheckInterval = time.Hour
+	// MinUpdateCheckInterval keeps misconfigured clients from hammering the update server
+	MinUpdateCheckInterval = 15 * time.Minute
+)
+
+// DefaultNotifyOnStateChange enables notifications when the tunnel connects or drops
+const DefaultNotifyOnStateChange = true
+
+// DefaultConnectOnLaunch leaves the tunnel down until the user connects
+const DefaultConnectOnLaunch = false
+
+// DefaultCheckEndpointBeforeConnect probes the server before starting the tunnel
+const DefaultCheckEndpointBeforeConnect = true
+
+// DefaultSessionExpiryWarningLead is how long before a session expires the user is warned
+const DefaultSessionExpiryWarningLead = 15 * time.Minute
+
+// DefaultMTU is the tunnel MTU used when none is configured. It leaves room for
+// WireGuard overhead on most links and is the minimum IPv6 allows.
+const DefaultMTU = 1280
+
+// MinMTU and MaxMTU bound a configured tunnel MTU
+const (
+	MinMTU = 576
+	MaxMTU = 1500
+)
+
+// MaxIdleDisconnectMinutes bounds a configured idle disconnect timeout
+const MaxIdleDisconnectMinutes = 24 * 60
+
+// DefaultLogMaxSizeMB is the size pangolin.log may reach before it is rotated
+const DefaultLogMaxSizeMB = 10
+
+// DefaultLogMaxFiles is how many rotated log files are kept next to pangolin.log
+const DefaultLogMaxFiles = 5
+
+// DefaultStatusRefreshInterval is how often the Status tab polls the tunnel
+const DefaultStatusRefreshInterval = time.Second
+
+// DefaultStatusRefreshJitterPercent is how far, as a percentage of the interval,
+// each Status tab refresh may be moved to spread polls out
+const DefaultStatusRefreshJitterPercent = 10
+
+// MaxStatusRefreshJitterPercent caps the configured jitter
+const MaxStatusRefreshJitterPercent = 50
+
+// StatusRefreshIntervals are the refresh intervals offered in the Status tab
+var StatusRefreshIntervals = []time.Duration{
+	1 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
 // Config represents the application configuration
 type Config struct {
-	DNSOverride  *bool   `json:"dnsOverride,omitempty"`
-	DNSTunnel    *bool   `json:"dnsTunnel,omitempty"`
-	PrimaryDNS   *string `json:"primaryDNS,omitempty"`
-	SecondaryDNS *string `json:"secondaryDNS,omitempty"`
+	DNSOverride   *bool          `json:"dnsOverride,omitempty"`
+	DNSTunnel     *bool          `json:"dnsTunnel,omitempty"`
+	PrimaryDNS    *string        `json:"primaryDNS,omitempty"`
+	SecondaryDNS  *string        `json:"secondaryDNS,omitempty"`
+	UpdateChannel *UpdateChannel `json:"updateChannel,omitempty"`
+	// AutoUpdateCheck enables periodic update checks; manual checks are always allowed
+	AutoUpdateCheck *bool `json:"autoUpdateCheck,omitempty"`
+	// UpdateCheckIntervalMinutes is the time between automatic update checks; 0
+	// disables them
+	UpdateCheckIntervalMinutes *int `json:"updateCheckIntervalMinutes,omitempty"`
+	// StatusRefreshIntervalSeconds is how often the Status tab polls the tunnel
+	StatusRefreshIntervalSeconds *int `json:"statusRefreshIntervalSeconds,omitempty"`
+	// StatusRefreshJitterPercent randomizes each Status tab refresh by up to this
+	// percentage of the interval; 0 disables it
+	StatusRefreshJitterPercent *int `json:"statusRefreshJitterPercent,omitempty"`
+	// NotifyOnStateChange shows a notification when the tunnel connects or drops
+	NotifyOnStateChange *bool `json:"notifyOnStateChange,omitempty"`
+	// SessionExpiryWarningMinutes is how long before a session expires the user is warned
+	SessionExpiryWarningMinutes *int `json:"sessionExpiryWarningMinutes,omitempty"`
+	// MTU is the tunnel MTU; unset or 0 uses DefaultMTU
+	MTU *int `json:"mtu,omitempty"`
+	// AllowedIPs limits the site subnets routed through the tunnel to these CIDRs.
+	// Split tunneling only supports IPv4, so every entry must be an IPv4 CIDR
+	AllowedIPs []string `json:"allowedIPs,omitempty"`
+	// ExcludedIPs are IPv4 CIDRs kept off the tunnel even when a site routes them
+	ExcludedIPs []string `json:"excludedIPs,omitempty"`
+	// KillSwitch blocks all traffic outside the tunnel while it is up
+	KillSwitch *bool `json:"killSwitch,omitempty"`
+	// LogMaxSizeMB is the size pangolin.log may reach before it is rotated
+	LogMaxSizeMB *int `json:"logMaxSizeMB,omitempty"`
+	// LogMaxFiles is how many rotated log files are kept
+	LogMaxFiles *int `json:"logMaxFiles,omitempty"`
+	// LogLevel is how much is logged: "error", "info" or "debug"
+	LogLevel *string `json:"logLevel,omitempty"`
+	// LastConnectedAt is when the tunnel last came up
+	LastConnectedAt *time.Time `json:"lastConnectedAt,omitempty"`
+	// CheckEndpointBeforeConnect probes the server before starting the tunnel
+	CheckEndpointBeforeConnect *bool `json:"checkEndpointBeforeConnect,omitempty"`
+	// Proxy is the HTTP proxy for API and update requests; unset falls back to
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	Proxy *string `json:"proxy,omitempty"`
+	// LogAPIRequests logs the method, URL, status and timing of each API request
+	LogAPIRequests *bool `json:"logAPIRequests,omitempty"`
+	// LogAPIBodies also logs API request and response bodies, with secrets
+	// redacted; it has no effect unless LogAPIRequests is set
+	LogAPIBodies *bool `json:"logAPIBodies,omitempty"`
+	// ConnectOnLaunch starts the tunnel when the tray app starts and the user is logged in
+	ConnectOnLaunch *bool `json:"connectOnLaunch,omitempty"`
+	// IdleDisconnectMinutes disconnects the tunnel after this long without traffic; 0 disables it
+	IdleDisconnectMinutes *int `json:"idleDisconnectMinutes,omitempty"`
+	// Onboarded is set once the user has logged in for the first time
+	Onboarded *bool `json:"onboarded,omitempty"`
+	// RecentHostnames are the self-hosted servers logged in to recently, most recent first
+	RecentHostnames []string `json:"recentHostnames,omitempty"`
 }
 
 // ConfigManager manages loading and saving of application configuration
@@ -36,6 +152,8 @@ type ConfigManager struct {
 	config     *Config
 	configPath string
 	mu         sync.RWMutex
+	// configExisted is whether the config file was there when the manager was created
+	configExisted bool
 }
 
 // NewConfigManager creates a new ConfigManager instance
@@ -55,8 +173,10 @@ func NewConfigManager() *ConfigManager {
 		logger.Error("Failed to create config directory: %v", err)
 	}
 
+	_, statErr := os.Stat(configPath)
 	cm := &ConfigManager{
-		configPath: configPath,
+		configPath:    configPath,
+		configExisted: statErr == nil,
 	}
 	cm.config = cm.load()
 	return cm
@@ -143,6 +263,21 @@ func (cm *ConfigManager) Clear() bool {
 	return cm.save(clearedConfig)
 }
 
+// Remove deletes the config file and resets the in-memory config to defaults
+// Returns true if successful, or if there was no file to delete
+func (cm *ConfigManager) Remove() bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if err := os.Remove(cm.configPath); err != nil && !os.IsNotExist(err) {
+		logger.Error("Error removing config: %v", err)
+		return false
+	}
+
+	cm.config = &Config{}
+	return true
+}
+
 // GetDNSOverride returns the DNS override setting from config or the default value
 func (cm *ConfigManager) GetDNSOverride() bool {
 	cm.mu.RLock()
@@ -187,6 +322,262 @@ func (cm *ConfigManager) GetSecondaryDNS() string {
 	return ""
 }
 
+// GetMTU returns the configured tunnel MTU, or 0 to use the default
+func (cm *ConfigManager) GetMTU() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.MTU != nil {
+		return *cm.config.MTU
+	}
+	return 0
+}
+
+// GetAllowedIPs returns the CIDRs the tunnel is limited to, empty for every site subnet
+func (cm *ConfigManager) GetAllowedIPs() []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config == nil {
+		return nil
+	}
+	return append([]string(nil), cm.config.AllowedIPs...)
+}
+
+// GetExcludedIPs returns the CIDRs kept off the tunnel
+func (cm *ConfigManager) GetExcludedIPs() []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config == nil {
+		return nil
+	}
+	return append([]string(nil), cm.config.ExcludedIPs...)
+}
+
+// GetKillSwitch returns whether traffic outside the tunnel is blocked while it is up
+func (cm *ConfigManager) GetKillSwitch() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.KillSwitch != nil {
+		return *cm.config.KillSwitch
+	}
+	return false
+}
+
+// GetUpdateChannel returns the update channel from config or the default value
+func (cm *ConfigManager) GetUpdateChannel() UpdateChannel {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.UpdateChannel != nil && cm.config.UpdateChannel.IsValid() {
+		return *cm.config.UpdateChannel
+	}
+	return DefaultUpdateChannel
+}
+
+// GetAutoUpdateCheck returns whether automatic update checks are enabled. An
+// update check interval of 0 also disables them.
+func (cm *ConfigManager) GetAutoUpdateCheck() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config == nil {
+		return DefaultAutoUpdateCheck
+	}
+	if cm.config.UpdateCheckIntervalMinutes != nil && *cm.config.UpdateCheckIntervalMinutes == 0 {
+		return false
+	}
+	if cm.config.AutoUpdateCheck != nil {
+		return *cm.config.AutoUpdateCheck
+	}
+	return DefaultAutoUpdateCheck
+}
+
+// GetUpdateCheckInterval returns the automatic update check interval, never less than MinUpdateCheckInterval
+func (cm *ConfigManager) GetUpdateCheckInterval() time.Duration {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.UpdateCheckIntervalMinutes != nil {
+		interval := time.Duration(*cm.config.UpdateCheckIntervalMinutes) * time.Minute
+		if interval < MinUpdateCheckInterval {
+			return MinUpdateCheckInterval
+		}
+		return interval
+	}
+	return DefaultUpdateCheckInterval
+}
+
+// GetStatusRefreshInterval returns the Status tab refresh interval, falling back to
+// the default for unset or non-positive values
+func (cm *ConfigManager) GetStatusRefreshInterval() time.Duration {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.StatusRefreshIntervalSeconds != nil && *cm.config.StatusRefreshIntervalSeconds > 0 {
+		return time.Duration(*cm.config.StatusRefreshIntervalSeconds) * time.Second
+	}
+	return DefaultStatusRefreshInterval
+}
+
+// GetStatusRefreshJitter returns how far each Status tab refresh may be moved,
+// as a fraction of the interval
+func (cm *ConfigManager) GetStatusRefreshJitter() float64 {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	percent := DefaultStatusRefreshJitterPercent
+	if cm.config != nil && cm.config.StatusRefreshJitterPercent != nil {
+		percent = min(max(*cm.config.StatusRefreshJitterPercent, 0), MaxStatusRefreshJitterPercent)
+	}
+	return float64(percent) / 100
+}
+
+// GetNotifyOnStateChange returns whether tunnel state notifications are enabled
+func (cm *ConfigManager) GetNotifyOnStateChange() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.NotifyOnStateChange != nil {
+		return *cm.config.NotifyOnStateChange
+	}
+	return DefaultNotifyOnStateChange
+}
+
+// GetCheckEndpointBeforeConnect returns whether the server is probed before connecting
+func (cm *ConfigManager) GetCheckEndpointBeforeConnect() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.CheckEndpointBeforeConnect != nil {
+		return *cm.config.CheckEndpointBeforeConnect
+	}
+	return DefaultCheckEndpointBeforeConnect
+}
+
+// GetConnectOnLaunch returns whether the tunnel is started when the tray app starts
+func (cm *ConfigManager) GetConnectOnLaunch() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.ConnectOnLaunch != nil {
+		return *cm.config.ConnectOnLaunch
+	}
+	return DefaultConnectOnLaunch
+}
+
+// GetIdleDisconnectMinutes returns how long the tunnel may go without traffic
+// before it is disconnected, or 0 if it never is
+func (cm *ConfigManager) GetIdleDisconnectMinutes() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.IdleDisconnectMinutes != nil {
+		return *cm.config.IdleDisconnectMinutes
+	}
+	return 0
+}
+
+// GetLogAPIRequests returns whether API requests are logged
+func (cm *ConfigManager) GetLogAPIRequests() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.LogAPIRequests != nil {
+		return *cm.config.LogAPIRequests
+	}
+	return false
+}
+
+// GetLogAPIBodies returns whether logged API requests include their bodies
+func (cm *ConfigManager) GetLogAPIBodies() bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.LogAPIBodies != nil {
+		return *cm.config.LogAPIBodies
+	}
+	return false
+}
+
+// GetProxy returns the configured HTTP proxy URL or empty string if not set
+func (cm *ConfigManager) GetProxy() string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.Proxy != nil {
+		return *cm.config.Proxy
+	}
+	return ""
+}
+
+// GetSessionExpiryWarningLead returns how long before a session expires the user is warned
+func (cm *ConfigManager) GetSessionExpiryWarningLead() time.Duration {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.SessionExpiryWarningMinutes != nil && *cm.config.SessionExpiryWarningMinutes > 0 {
+		return time.Duration(*cm.config.SessionExpiryWarningMinutes) * time.Minute
+	}
+	return DefaultSessionExpiryWarningLead
+}
+
+// GetLogMaxSize returns the size in bytes pangolin.log may reach before it is rotated
+func (cm *ConfigManager) GetLogMaxSize() int64 {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.LogMaxSizeMB != nil && *cm.config.LogMaxSizeMB > 0 {
+		return int64(*cm.config.LogMaxSizeMB) << 20
+	}
+	return DefaultLogMaxSizeMB << 20
+}
+
+// GetLogMaxFiles returns how many rotated log files are kept
+func (cm *ConfigManager) GetLogMaxFiles() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.LogMaxFiles != nil && *cm.config.LogMaxFiles > 0 {
+		return *cm.config.LogMaxFiles
+	}
+	return DefaultLogMaxFiles
+}
+
+// GetLogLevel returns the configured log level, one of LogLevels
+func (cm *ConfigManager) GetLogLevel() string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.LogLevel != nil {
+		return NormalizeLogLevel(*cm.config.LogLevel)
+	}
+	return DefaultLogLevel
+}
+
+// GetLastConnectedAt returns when the tunnel last came up, or the zero time if it never has
+func (cm *ConfigManager) GetLastConnectedAt() time.Time {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config != nil && cm.config.LastConnectedAt != nil {
+		return *cm.config.LastConnectedAt
+	}
+	return time.Time{}
+}
+
+// SetLastConnectedAt records when the tunnel last came up and saves to config
+func (cm *ConfigManager) SetLastConnectedAt(value time.Time) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	cfg.LastConnectedAt = &value
+	return cm.save(cfg)
+}
+
 // SetDNSOverride sets the DNS override setting and saves to config
 func (cm *ConfigManager) SetDNSOverride(value bool) bool {
 	cm.mu.Lock()
@@ -235,6 +626,174 @@ func (cm *ConfigManager) SetSecondaryDNS(value string) bool {
 	return cm.save(cfg)
 }
 
+// SetMTU sets the tunnel MTU and saves to config. 0 restores the default; other
+// values outside MinMTU..MaxMTU are rejected.
+func (cm *ConfigManager) SetMTU(value int) bool {
+	if value != 0 && (value < MinMTU || value > MaxMTU) {
+		return false
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	if value == 0 {
+		cfg.MTU = nil
+	} else {
+		cfg.MTU = &value
+	}
+	return cm.save(cfg)
+}
+
+// SetIdleDisconnectMinutes sets how long the tunnel may go without traffic before
+// it is disconnected and saves to config. 0 disables it; values outside
+// 0..MaxIdleDisconnectMinutes are rejected.
+func (cm *ConfigManager) SetIdleDisconnectMinutes(value int) bool {
+	if value < 0 || value > MaxIdleDisconnectMinutes {
+		return false
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	if value == 0 {
+		cfg.IdleDisconnectMinutes = nil
+	} else {
+		cfg.IdleDisconnectMinutes = &value
+	}
+	return cm.save(cfg)
+}
+
+// SetUpdateChannel sets the update channel and saves to config
+func (cm *ConfigManager) SetUpdateChannel(value UpdateChannel) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	// Get current config and copy it to preserve all fields
+	cfg := cm.getConfigCopy()
+	cfg.UpdateChannel = &value
+	return cm.save(cfg)
+}
+
+// SetAutoUpdateCheck enables or disables automatic update checks and saves to config
+func (cm *ConfigManager) SetAutoUpdateCheck(value bool) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	// Get current config and copy it to preserve all fields
+	cfg := cm.getConfigCopy()
+	cfg.AutoUpdateCheck = &value
+	return cm.save(cfg)
+}
+
+// SetUpdateCheckInterval sets the automatic update check interval and saves to config
+// Returns false without saving if the interval is below MinUpdateCheckInterval
+func (cm *ConfigManager) SetUpdateCheckInterval(value time.Duration) bool {
+	if value < MinUpdateCheckInterval {
+		logger.Error("Update check interval %v is below the minimum of %v", value, MinUpdateCheckInterval)
+		return false
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	// Get current config and copy it to preserve all fields
+	cfg := cm.getConfigCopy()
+	minutes := int(value / time.Minute)
+	cfg.UpdateCheckIntervalMinutes = &minutes
+	return cm.save(cfg)
+}
+
+// SetStatusRefreshInterval sets the Status tab refresh interval and saves to config
+// Returns false without saving if the interval is shorter than one second
+func (cm *ConfigManager) SetStatusRefreshInterval(value time.Duration) bool {
+	if value < time.Second {
+		logger.Error("Status refresh interval %v is shorter than one second", value)
+		return false
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	// Get current config and copy it to preserve all fields
+	cfg := cm.getConfigCopy()
+	seconds := int(value / time.Second)
+	cfg.StatusRefreshIntervalSeconds = &seconds
+	return cm.save(cfg)
+}
+
+// SetNotifyOnStateChange enables or disables tunnel state notifications and saves to config
+func (cm *ConfigManager) SetNotifyOnStateChange(value bool) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	// Get current config and copy it to preserve all fields
+	cfg := cm.getConfigCopy()
+	cfg.NotifyOnStateChange = &value
+	return cm.save(cfg)
+}
+
+// SetCheckEndpointBeforeConnect enables or disables probing the server before connecting and saves to config
+func (cm *ConfigManager) SetCheckEndpointBeforeConnect(value bool) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	cfg.CheckEndpointBeforeConnect = &value
+	return cm.save(cfg)
+}
+
+// SetConnectOnLaunch enables or disables starting the tunnel at launch and saves to config
+func (cm *ConfigManager) SetConnectOnLaunch(value bool) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	cfg.ConnectOnLaunch = &value
+	return cm.save(cfg)
+}
+
+// SetLogAPIRequests enables or disables logging API requests and saves to config
+func (cm *ConfigManager) SetLogAPIRequests(value bool) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	cfg.LogAPIRequests = &value
+	return cm.save(cfg)
+}
+
+// SetLogLevel sets the log level and saves to config
+func (cm *ConfigManager) SetLogLevel(value string) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	value = NormalizeLogLevel(value)
+	cfg.LogLevel = &value
+	return cm.save(cfg)
+}
+
+// SetProxy sets the HTTP proxy URL and saves to config. An empty value removes it.
+func (cm *ConfigManager) SetProxy(value string) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	if value == "" {
+		cfg.Proxy = nil
+	} else {
+		cfg.Proxy = &value
+	}
+	return cm.save(cfg)
+}
+
+// IsValid reports whether the channel is one of the known update channels
+func (c UpdateChannel) IsValid() bool {
+	return c == UpdateChannelStable || c == UpdateChannelBeta
+}
+
 // getConfigCopy creates a deep copy of the current config
 // Caller must hold the lock
 func (cm *ConfigManager) getConfigCopy() *Config {
@@ -260,25 +819,92 @@ func (cm *ConfigManager) getConfigCopy() *Config {
 		secondaryDNS := *cm.config.SecondaryDNS
 		cfg.SecondaryDNS = &secondaryDNS
 	}
+	if cm.config.UpdateChannel != nil {
+		updateChannel := *cm.config.UpdateChannel
+		cfg.UpdateChannel = &updateChannel
+	}
+	if cm.config.AutoUpdateCheck != nil {
+		autoUpdateCheck := *cm.config.AutoUpdateCheck
+		cfg.AutoUpdateCheck = &autoUpdateCheck
+	}
+	if cm.config.UpdateCheckIntervalMinutes != nil {
+		updateCheckIntervalMinutes := *cm.config.UpdateCheckIntervalMinutes
+		cfg.UpdateCheckIntervalMinutes = &updateCheckIntervalMinutes
+	}
+	if cm.config.StatusRefreshIntervalSeconds != nil {
+		statusRefreshIntervalSeconds := *cm.config.StatusRefreshIntervalSeconds
+		cfg.StatusRefreshIntervalSeconds = &statusRefreshIntervalSeconds
+	}
+	if cm.config.StatusRefreshJitterPercent != nil {
+		statusRefreshJitterPercent := *cm.config.StatusRefreshJitterPercent
+		cfg.StatusRefreshJitterPercent = &statusRefreshJitterPercent
+	}
+	if cm.config.NotifyOnStateChange != nil {
+		notifyOnStateChange := *cm.config.NotifyOnStateChange
+		cfg.NotifyOnStateChange = &notifyOnStateChange
+	}
+	if cm.config.SessionExpiryWarningMinutes != nil {
+		sessionExpiryWarningMinutes := *cm.config.SessionExpiryWarningMinutes
+		cfg.SessionExpiryWarningMinutes = &sessionExpiryWarningMinutes
+	}
+	if cm.config.MTU != nil {
+		mtu := *cm.config.MTU
+		cfg.MTU = &mtu
+	}
+	if cm.config.KillSwitch != nil {
+		killSwitch := *cm.config.KillSwitch
+		cfg.KillSwitch = &killSwitch
+	}
+	cfg.AllowedIPs = append([]string(nil), cm.config.AllowedIPs...)
+	cfg.ExcludedIPs = append([]string(nil), cm.config.ExcludedIPs...)
+	if cm.config.LogMaxSizeMB != nil {
+		logMaxSizeMB := *cm.config.LogMaxSizeMB
+		cfg.LogMaxSizeMB = &logMaxSizeMB
+	}
+	if cm.config.LogMaxFiles != nil {
+		logMaxFiles := *cm.config.LogMaxFiles
+		cfg.LogMaxFiles = &logMaxFiles
+	}
+	if cm.config.LogLevel != nil {
+		logLevel := *cm.config.LogLevel
+		cfg.LogLevel = &logLevel
+	}
+	if cm.config.LastConnectedAt != nil {
+		lastConnectedAt := *cm.config.LastConnectedAt
+		cfg.LastConnectedAt = &lastConnectedAt
+	}
+	if cm.config.CheckEndpointBeforeConnect != nil {
+		checkEndpointBeforeConnect := *cm.config.CheckEndpointBeforeConnect
+		cfg.CheckEndpointBeforeConnect = &checkEndpointBeforeConnect
+	}
+	if cm.config.Proxy != nil {
+		proxy := *cm.config.Proxy
+		cfg.Proxy = &proxy
+	}
+	if cm.config.LogAPIRequests != nil {
+		logAPIRequests := *cm.config.LogAPIRequests
+		cfg.LogAPIRequests = &logAPIRequests
+	}
+	if cm.config.LogAPIBodies != nil {
+		logAPIBodies := *cm.config.LogAPIBodies
+		cfg.LogAPIBodies = &logAPIBodies
+	}
+	if cm.config.ConnectOnLaunch != nil {
+		connectOnLaunch := *cm.config.ConnectOnLaunch
+		cfg.ConnectOnLaunch = &connectOnLaunch
+	}
+	if cm.config.IdleDisconnectMinutes != nil {
+		idleDisconnectMinutes := *cm.config.IdleDisconnectMinutes
+		cfg.IdleDisconnectMinutes = &idleDisconnectMinutes
+	}
+	if cm.config.Onboarded != nil {
+		onboarded := *cm.config.Onboarded
+		cfg.Onboarded = &onboarded
+	}
+	cfg.RecentHostnames = append([]string(nil), cm.config.RecentHostnames...)
 	return cfg
 }
 
-// GetProgramDataDir returns the base ProgramData directory for the application
-// The installer should create this directory and place application files here
-func GetProgramDataDir() string {
-	return filepath.Join(os.Getenv("PROGRAMDATA"), AppName)
-}
-
-// GetLogDir returns the directory path for log files
-func GetLogDir() string {
-	return filepath.Join(GetProgramDataDir(), "logs")
-}
-
-// GetIconsPath returns the directory path for icon files
-func GetIconsPath() string {
-	return filepath.Join(os.Getenv("PROGRAMFILES"), AppName, "icons")
-}
-
 // GetFriendlyDeviceName returns a friendly device name like "Windows Laptop" or "Windows Desktop"
 // It attempts to detect the device type by checking for battery presence
 func GetFriendlyDeviceName() string {
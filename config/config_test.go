@@ -0,0 +1,158 @@
+//go:build windows
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdateCheckSettings(t *testing.T) {
+	minutes := func(n int) *int { return &n }
+	enabled := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name         string
+		config       *Config
+		wantEnabled  bool
+		wantInterval time.Duration
+	}{
+		{name: "not loaded", wantEnabled: true, wantInterval: DefaultUpdateCheckInterval},
+		{name: "unset", config: &Config{}, wantEnabled: true, wantInterval: DefaultUpdateCheckInterval},
+		{name: "once a day", config: &Config{UpdateCheckIntervalMinutes: minutes(24 * 60)}, wantEnabled: true, wantInterval: 24 * time.Hour},
+		{name: "at the minimum", config: &Config{UpdateCheckIntervalMinutes: minutes(15)}, wantEnabled: true, wantInterval: MinUpdateCheckInterval},
+		{name: "below the minimum", config: &Config{UpdateCheckIntervalMinutes: minutes(1)}, wantEnabled: true, wantInterval: MinUpdateCheckInterval},
+		{name: "negative", config: &Config{UpdateCheckIntervalMinutes: minutes(-60)}, wantEnabled: true, wantInterval: MinUpdateCheckInterval},
+		{name: "zero disables", config: &Config{UpdateCheckIntervalMinutes: minutes(0)}, wantInterval: MinUpdateCheckInterval},
+		{name: "disabled", config: &Config{AutoUpdateCheck: enabled(false), UpdateCheckIntervalMinutes: minutes(120)}, wantInterval: 2 * time.Hour},
+		{name: "enabled", config: &Config{AutoUpdateCheck: enabled(true)}, wantEnabled: true, wantInterval: DefaultUpdateCheckInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &ConfigManager{config: tt.config}
+			if got := cm.GetAutoUpdateCheck(); got != tt.wantEnabled {
+				t.Errorf("GetAutoUpdateCheck() = %v, want %v", got, tt.wantEnabled)
+			}
+			if got := cm.GetUpdateCheckInterval(); got != tt.wantInterval {
+				t.Errorf("GetUpdateCheckInterval() = %v, want %v", got, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestSetUpdateCheckInterval(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ConfigFileName)
+	cm := &ConfigManager{config: &Config{}, configPath: configPath}
+
+	if cm.SetUpdateCheckInterval(5 * time.Minute) {
+		t.Error("SetUpdateCheckInterval() accepted an interval below the minimum")
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Errorf("config saved for a rejected interval: %v", err)
+	}
+
+	if !cm.SetUpdateCheckInterval(6 * time.Hour) {
+		t.Fatal("SetUpdateCheckInterval() refused a valid interval")
+	}
+	cm.Load()
+	if got := cm.GetUpdateCheckInterval(); got != 6*time.Hour {
+		t.Errorf("GetUpdateCheckInterval() after reload = %v, want %v", got, 6*time.Hour)
+	}
+}
+
+func TestSetMTU(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  int
+		wantOK bool
+		want   int
+	}{
+		{name: "default", value: 0, wantOK: true, want: 0},
+		{name: "minimum", value: MinMTU, wantOK: true, want: MinMTU},
+		{name: "maximum", value: MaxMTU, wantOK: true, want: MaxMTU},
+		{name: "below the minimum", value: MinMTU - 1, want: 1400},
+		{name: "above the maximum", value: MaxMTU + 1, want: 1400},
+		{name: "negative", value: -1, want: 1400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mtu := 1400
+			cm := &ConfigManager{config: &Config{MTU: &mtu}, configPath: filepath.Join(t.TempDir(), ConfigFileName)}
+
+			if got := cm.SetMTU(tt.value); got != tt.wantOK {
+				t.Fatalf("SetMTU(%d) = %v, want %v", tt.value, got, tt.wantOK)
+			}
+			if got := cm.GetMTU(); got != tt.want {
+				t.Errorf("GetMTU() = %d, want %d", got, tt.want)
+			}
+			if !tt.wantOK {
+				return
+			}
+			cm.Load()
+			if got := cm.GetMTU(); got != tt.want {
+				t.Errorf("GetMTU() after reload = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStatusRefreshJitter(t *testing.T) {
+	percent := func(n int) *int { return &n }
+
+	tests := []struct {
+		name   string
+		config *Config
+		want   float64
+	}{
+		{name: "not loaded", want: 0.1},
+		{name: "unset", config: &Config{}, want: 0.1},
+		{name: "disabled", config: &Config{StatusRefreshJitterPercent: percent(0)}, want: 0},
+		{name: "set", config: &Config{StatusRefreshJitterPercent: percent(25)}, want: 0.25},
+		{name: "at the cap", config: &Config{StatusRefreshJitterPercent: percent(MaxStatusRefreshJitterPercent)}, want: 0.5},
+		{name: "over the cap", config: &Config{StatusRefreshJitterPercent: percent(90)}, want: 0.5},
+		{name: "negative", config: &Config{StatusRefreshJitterPercent: percent(-5)}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &ConfigManager{config: tt.config}
+			if got := cm.GetStatusRefreshJitter(); got != tt.want {
+				t.Errorf("GetStatusRefreshJitter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckEndpointBeforeConnect(t *testing.T) {
+	cm := &ConfigManager{config: &Config{}, configPath: filepath.Join(t.TempDir(), ConfigFileName)}
+	if !cm.GetCheckEndpointBeforeConnect() {
+		t.Error("the endpoint check is off by default")
+	}
+
+	if !cm.SetCheckEndpointBeforeConnect(false) {
+		t.Fatal("SetCheckEndpointBeforeConnect() failed")
+	}
+	cm.Load()
+	if cm.GetCheckEndpointBeforeConnect() {
+		t.Error("opting out of the endpoint check didn't survive a reload")
+	}
+}
+
+func TestConnectOnLaunch(t *testing.T) {
+	cm := &ConfigManager{config: &Config{}, configPath: filepath.Join(t.TempDir(), ConfigFileName)}
+	if cm.GetConnectOnLaunch() {
+		t.Error("connect on launch is on by default")
+	}
+
+	if !cm.SetConnectOnLaunch(true) {
+		t.Fatal("SetConnectOnLaunch() failed")
+	}
+	cm.Load()
+	if !cm.GetConnectOnLaunch() {
+		t.Error("connect on launch didn't survive a reload")
+	}
+}
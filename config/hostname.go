@@ -0,0 +1,87 @@
+//go:build windows
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// HostnameEnv points the app at another server without touching the config or
+// accounts, e.g. a staging server for testing. It takes precedence over the
+// hostname stored with an account and over DefaultHostname.
+const HostnameEnv = "PANGOLIN_HOSTNAME"
+
+// hostnameOverride is read once, as the environment doesn't change while running
+// and the override should only be logged once
+var hostnameOverride = sync.OnceValue(func() string {
+	return parseHostnameOverride(os.Getenv(HostnameEnv))
+})
+
+// parseHostnameOverride returns the server named by value, the HostnameEnv
+// variable, or "" if it is unset or invalid
+func parseHostnameOverride(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	hostname, err := NormalizeHostname(value)
+	if err != nil {
+		logger.Warn("Ignoring %s: %v", HostnameEnv, err)
+		return ""
+	}
+	logger.Warn("%s is set, using server %s instead of the configured one", HostnameEnv, hostname)
+	return hostname
+}
+
+// NormalizeHostname checks a server URL the way one entered at login is handled:
+// surrounding space and trailing slashes are removed and https:// is assumed
+// without a scheme. It fails unless the result is an http or https URL with a host.
+func NormalizeHostname(hostname string) (string, error) {
+	scheme := "https://"
+	hostname = strings.TrimSpace(hostname)
+	if strings.HasPrefix(hostname, "http://") {
+		scheme = "http://"
+	}
+	// Trimmed without the scheme, so a bare "https://" isn't left as "https:"
+	hostname = strings.TrimRight(strings.TrimPrefix(hostname, scheme), "/")
+	if hostname == "" {
+		return "", fmt.Errorf("the server URL is empty")
+	}
+	hostname = scheme + hostname
+	u, err := url.Parse(hostname)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a valid server URL: %w", hostname, err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("%q has no host name", hostname)
+	}
+	return hostname, nil
+}
+
+// HostnameOverride returns the server set by HostnameEnv, or "" if it is unset or invalid
+func HostnameOverride() string {
+	return hostnameOverride()
+}
+
+// ResolveHostname returns the server to talk to for an account stored with
+// hostname: the HostnameEnv override if set, else hostname, else DefaultHostname.
+// The stored hostname is never changed by the override.
+func ResolveHostname(hostname string) string {
+	return resolveHostname(HostnameOverride(), hostname)
+}
+
+// resolveHostname picks override, then hostname, then DefaultHostname
+func resolveHostname(override, hostname string) string {
+	if override != "" {
+		return override
+	}
+	if hostname != "" {
+		return hostname
+	}
+	return DefaultHostname
+}
@@ -0,0 +1,79 @@
+//go:build windows
+
+package config
+
+import "testing"
+
+func TestNormalizeHostname(t *testing.T) {
+	tests := []struct {
+		hostname string
+		want     string
+		wantErr  bool
+	}{
+		{hostname: "https://pangolin.example.com", want: "https://pangolin.example.com"},
+		{hostname: "  staging.example.com//  ", want: "https://staging.example.com"},
+		{hostname: "http://localhost:3000/", want: "http://localhost:3000"},
+		{hostname: "", wantErr: true},
+		{hostname: " / ", wantErr: true},
+		{hostname: "https://", wantErr: true},
+		{hostname: "https://bad host.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeHostname(tt.hostname)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeHostname(%q) error = %v, want error %v", tt.hostname, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeHostname(%q) = %q, want %q", tt.hostname, got, tt.want)
+		}
+	}
+}
+
+func TestParseHostnameOverride(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "", want: ""},
+		{value: "   ", want: ""},
+		{value: "staging.example.com/", want: "https://staging.example.com"},
+		{value: "http://localhost:3000", want: "http://localhost:3000"},
+		// Invalid values are ignored rather than breaking every request
+		{value: "https://", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseHostnameOverride(tt.value); got != tt.want {
+			t.Errorf("parseHostnameOverride(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResolveHostname(t *testing.T) {
+	const (
+		override = "https://staging.example.com"
+		stored   = "https://pangolin.example.com"
+	)
+
+	tests := []struct {
+		name     string
+		override string
+		hostname string
+		want     string
+	}{
+		{name: "override wins over the stored server", override: override, hostname: stored, want: override},
+		{name: "override wins over the default", override: override, want: override},
+		{name: "stored server", hostname: stored, want: stored},
+		{name: "default", want: DefaultHostname},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveHostname(tt.override, tt.hostname); got != tt.want {
+				t.Errorf("resolveHostname(%q, %q) = %q, want %q", tt.override, tt.hostname, got, tt.want)
+			}
+		})
+	}
+}
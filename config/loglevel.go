@@ -0,0 +1,53 @@
+//go:build windows
+
+package config
+
+import (
+	"strings"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// Log levels that can be configured, from quietest to most verbose
+const (
+	LogLevelError = "error"
+	LogLevelInfo  = "info"
+	LogLevelDebug = "debug"
+
+	DefaultLogLevel = LogLevelInfo
+)
+
+// LogLevels are the log levels offered in the preferences
+var LogLevels = []string{LogLevelError, LogLevelInfo, LogLevelDebug}
+
+// ParseLogLevel maps a configured log level to the logger's, ignoring case.
+// Anything that isn't one of LogLevels is DefaultLogLevel.
+func ParseLogLevel(level string) logger.LogLevel {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case LogLevelError:
+		return logger.ERROR
+	case LogLevelInfo:
+		return logger.INFO
+	case LogLevelDebug:
+		return logger.DEBUG
+	default:
+		return logger.INFO
+	}
+}
+
+// NormalizeLogLevel returns level as one of LogLevels, or DefaultLogLevel if
+// it isn't one
+func NormalizeLogLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	for _, known := range LogLevels {
+		if level == known {
+			return level
+		}
+	}
+	return DefaultLogLevel
+}
+
+// ApplyLogLevel makes the logger of this process log at level from now on
+func ApplyLogLevel(level string) {
+	logger.GetLogger().SetLevel(ParseLogLevel(level))
+}
@@ -0,0 +1,97 @@
+//go:build windows
+
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fosrl/newt/logger"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  logger.LogLevel
+	}{
+		{level: "error", want: logger.ERROR},
+		{level: "info", want: logger.INFO},
+		{level: "debug", want: logger.DEBUG},
+		{level: " Debug ", want: logger.DEBUG},
+		{level: "ERROR", want: logger.ERROR},
+		{level: "", want: logger.INFO},
+		{level: "warn", want: logger.INFO},
+		{level: "verbose", want: logger.INFO},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			if got := ParseLogLevel(tt.level); got != tt.want {
+				t.Errorf("ParseLogLevel(%q) = %v, want %v", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLogLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{level: "error", want: LogLevelError},
+		{level: "DEBUG", want: LogLevelDebug},
+		{level: " info\n", want: LogLevelInfo},
+		{level: "", want: DefaultLogLevel},
+		{level: "trace", want: DefaultLogLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			if got := NormalizeLogLevel(tt.level); got != tt.want {
+				t.Errorf("NormalizeLogLevel(%q) = %q, want %q", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogLevelSetting(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	tests := []struct {
+		name  string
+		saved *string // The level in the config file, nil if unset
+		want  string
+	}{
+		{name: "unset", want: DefaultLogLevel},
+		{name: "debug", saved: ptr("debug"), want: LogLevelDebug},
+		{name: "edited by hand", saved: ptr("Error"), want: LogLevelError},
+		{name: "invalid", saved: ptr("loud"), want: DefaultLogLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &ConfigManager{config: &Config{LogLevel: tt.saved}}
+			if got := cm.GetLogLevel(); got != tt.want {
+				t.Errorf("GetLogLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	cm := &ConfigManager{config: &Config{}, configPath: filepath.Join(t.TempDir(), ConfigFileName)}
+	for _, tt := range []struct{ set, want string }{
+		{set: "debug", want: LogLevelDebug},
+		{set: "ERROR", want: LogLevelError},
+		{set: "everything", want: DefaultLogLevel},
+	} {
+		if !cm.SetLogLevel(tt.set) {
+			t.Fatalf("SetLogLevel(%q) failed to save", tt.set)
+		}
+		if got := cm.GetLogLevel(); got != tt.want {
+			t.Errorf("after SetLogLevel(%q), GetLogLevel() = %q, want %q", tt.set, got, tt.want)
+		}
+		if *cm.config.LogLevel != tt.want {
+			t.Errorf("after SetLogLevel(%q), saved %q, want %q", tt.set, *cm.config.LogLevel, tt.want)
+		}
+	}
+}
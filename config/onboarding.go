@@ -0,0 +1,56 @@
+//go:build windows
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// FirstRun reports whether the app runs for the first time on this install, as
+// opposed to a returning user who is logged out. It is true when there was no
+// config file at startup and no account, and thus no stored secret, is saved.
+// Secrets are kept per account, so an install without accounts has none.
+func (cm *ConfigManager) FirstRun() bool {
+	cm.mu.RLock()
+	onboarded := cm.config != nil && cm.config.Onboarded != nil && *cm.config.Onboarded
+	configExisted := cm.configExisted
+	cm.mu.RUnlock()
+
+	if onboarded || configExisted {
+		return false
+	}
+	return !cm.hasSavedAccounts()
+}
+
+// hasSavedAccounts reports whether the accounts file next to the config lists
+// any account. The file itself is not enough, as it is created empty on startup.
+func (cm *ConfigManager) hasSavedAccounts() bool {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(cm.configPath), AccountsFileName))
+	if err != nil {
+		return false
+	}
+	var accounts struct {
+		Accounts map[string]Account `json:"accounts"`
+	}
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		// Can't tell, so don't treat a damaged file as a fresh install
+		return true
+	}
+	return len(accounts.Accounts) > 0
+}
+
+// MarkOnboarded records that the user has logged in, so FirstRun is false from then on
+func (cm *ConfigManager) MarkOnboarded() bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if cm.config != nil && cm.config.Onboarded != nil && *cm.config.Onboarded {
+		return true
+	}
+	cfg := cm.getConfigCopy()
+	onboarded := true
+	cfg.Onboarded = &onboarded
+	return cm.save(cfg)
+}
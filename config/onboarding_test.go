@@ -0,0 +1,68 @@
+//go:build windows
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFirstRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string // Written to the config file before startup when set
+		accounts string // Written to the accounts file when set
+		want     bool
+	}{
+		{name: "fresh install", want: true},
+		{name: "no accounts yet", accounts: `{"accounts":{}}`, want: true},
+		{name: "logged out returning user", config: `{}`},
+		{name: "onboarded", config: `{"onboarded":true}`},
+		{name: "config lost but account saved", accounts: `{"accounts":{"user-1":{"userId":"user-1"}}}`},
+		{name: "damaged accounts file", accounts: `{`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appData := t.TempDir()
+			t.Setenv("LOCALAPPDATA", appData)
+			dir := filepath.Join(appData, AppName)
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.config != "" {
+				if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.accounts != "" {
+				if err := os.WriteFile(filepath.Join(dir, AccountsFileName), []byte(tt.accounts), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := NewConfigManager().FirstRun(); got != tt.want {
+				t.Errorf("FirstRun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkOnboarded(t *testing.T) {
+	t.Setenv("LOCALAPPDATA", t.TempDir())
+
+	cm := NewConfigManager()
+	if !cm.FirstRun() {
+		t.Fatal("FirstRun() = false on a fresh install")
+	}
+	if !cm.MarkOnboarded() {
+		t.Fatal("MarkOnboarded() failed to save")
+	}
+	if cm.FirstRun() {
+		t.Error("FirstRun() = true after the first login")
+	}
+	if NewConfigManager().FirstRun() {
+		t.Error("FirstRun() = true on the next launch")
+	}
+}
@@ -0,0 +1,106 @@
+//go:build windows
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fosrl/newt/logger"
+	"golang.org/x/sys/windows"
+)
+
+// programDataRoot and programFilesRoot are resolved once, as the environment
+// doesn't change while running and a fallback should only be logged once
+var (
+	programDataRoot = sync.OnceValues(func() (string, error) {
+		return knownFolder("PROGRAMDATA", windows.FOLDERID_ProgramData)
+	})
+	programFilesRoot = sync.OnceValues(func() (string, error) {
+		return knownFolder("PROGRAMFILES", windows.FOLDERID_ProgramFiles)
+	})
+)
+
+// knownFolderPath asks the shell for a known folder, replaced in tests
+var knownFolderPath = windows.KnownFolderPath
+
+// knownFolder returns the folder named by envVar, or asks the shell for it when
+// the variable is empty or not an absolute path, as happens in some service
+// contexts. It fails only when neither gives an answer.
+func knownFolder(envVar string, id *windows.KNOWNFOLDERID) (string, error) {
+	value := os.Getenv(envVar)
+	if value != "" && filepath.IsAbs(value) {
+		return value, nil
+	}
+	path, err := knownFolderPath(id, windows.KF_FLAG_DEFAULT)
+	if err != nil {
+		return "", fmt.Errorf("%s is not set and the folder could not be looked up: %w", envVar, err)
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("%s is not set and the folder lookup returned %q", envVar, path)
+	}
+	logger.Warn("%s is %q, using %s instead", envVar, value, path)
+	return path, nil
+}
+
+// ResolveProgramDataDir returns the base ProgramData directory for the
+// application, or an error if the ProgramData folder can't be found
+func ResolveProgramDataDir() (string, error) {
+	root, err := programDataRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, AppName), nil
+}
+
+// ResolveLogDir returns the directory for log files, or an error if the
+// ProgramData folder can't be found
+func ResolveLogDir() (string, error) {
+	dir, err := ResolveProgramDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "logs"), nil
+}
+
+// ResolveIconsPath returns the directory of the installed icon files, or an
+// error if the Program Files folder can't be found
+func ResolveIconsPath() (string, error) {
+	root, err := programFilesRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, AppName, "icons"), nil
+}
+
+// GetProgramDataDir returns the base ProgramData directory for the application
+// The installer should create this directory and place application files here.
+// It is empty if the folder can't be found; callers that write there should use
+// ResolveProgramDataDir to handle that.
+func GetProgramDataDir() string {
+	dir, err := ResolveProgramDataDir()
+	if err != nil {
+		logger.Error("Failed to find the ProgramData directory: %v", err)
+	}
+	return dir
+}
+
+// GetLogDir returns the directory path for log files, empty if it can't be found
+func GetLogDir() string {
+	dir, err := ResolveLogDir()
+	if err != nil {
+		logger.Error("Failed to find the log directory: %v", err)
+	}
+	return dir
+}
+
+// GetIconsPath returns the directory path for icon files, empty if it can't be found
+func GetIconsPath() string {
+	dir, err := ResolveIconsPath()
+	if err != nil {
+		logger.Error("Failed to find the icons directory: %v", err)
+	}
+	return dir
+}
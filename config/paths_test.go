@@ -0,0 +1,87 @@
+//go:build windows
+
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestKnownFolder(t *testing.T) {
+	const shellPath = `C:\ProgramData`
+
+	tests := []struct {
+		name       string
+		env        string
+		shellPath  string
+		shellErr   error
+		want       string
+		wantLookup bool
+		wantErr    bool
+	}{
+		{name: "variable set", env: `D:\Data`, shellPath: shellPath, want: `D:\Data`},
+		{name: "variable empty", shellPath: shellPath, want: shellPath, wantLookup: true},
+		{name: "variable relative", env: `Pangolin`, shellPath: shellPath, want: shellPath, wantLookup: true},
+		{name: "lookup fails", shellErr: windows.ERROR_FILE_NOT_FOUND, wantLookup: true, wantErr: true},
+		{name: "lookup returns a relative path", shellPath: `ProgramData`, wantLookup: true, wantErr: true},
+	}
+
+	saved := knownFolderPath
+	defer func() { knownFolderPath = saved }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROGRAMDATA", tt.env)
+			looked := false
+			knownFolderPath = func(id *windows.KNOWNFOLDERID, flags uint32) (string, error) {
+				looked = true
+				if id != windows.FOLDERID_ProgramData {
+					t.Errorf("looked up folder %v, want ProgramData", id)
+				}
+				return tt.shellPath, tt.shellErr
+			}
+
+			got, err := knownFolder("PROGRAMDATA", windows.FOLDERID_ProgramData)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("knownFolder() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("knownFolder() = %q, want %q", got, tt.want)
+			}
+			if looked != tt.wantLookup {
+				t.Errorf("folder looked up = %v, want %v", looked, tt.wantLookup)
+			}
+			if tt.shellErr != nil && !errors.Is(err, tt.shellErr) {
+				t.Errorf("knownFolder() error = %v, want it to wrap %v", err, tt.shellErr)
+			}
+		})
+	}
+}
+
+func TestResolvedDirsAreAbsolute(t *testing.T) {
+	dataDir, err := ResolveProgramDataDir()
+	if err != nil {
+		t.Fatalf("ResolveProgramDataDir() error = %v", err)
+	}
+	logDir, err := ResolveLogDir()
+	if err != nil {
+		t.Fatalf("ResolveLogDir() error = %v", err)
+	}
+	iconsDir, err := ResolveIconsPath()
+	if err != nil {
+		t.Fatalf("ResolveIconsPath() error = %v", err)
+	}
+
+	for _, dir := range []string{dataDir, logDir, iconsDir} {
+		if !filepath.IsAbs(dir) {
+			t.Errorf("%q is not absolute", dir)
+		}
+	}
+	if !strings.HasPrefix(logDir, dataDir+string(filepath.Separator)) {
+		t.Errorf("log directory %q is outside %q", logDir, dataDir)
+	}
+}
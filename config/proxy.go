@@ -0,0 +1,85 @@
+//go:build windows
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxySettings is how Pangolin's own HTTP requests reach the internet. An empty
+// URL leaves the choice to HTTP_PROXY, HTTPS_PROXY and NO_PROXY. The credentials
+// are kept out of the URL so they can live in the credential store.
+type ProxySettings struct {
+	URL      string
+	Username string
+	Password string
+}
+
+// ValidateProxyURL checks that s names an HTTP proxy, such as
+// "http://proxy.example.com:8080" or "proxy.example.com:3128", and returns it parsed
+func ValidateProxyURL(s string) (*url.URL, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("no proxy is given")
+	}
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid URL", s)
+	}
+	// The updater talks to the proxy through WinHTTP, which only speaks plain HTTP to it
+	if u.Scheme != "http" {
+		return nil, fmt.Errorf("%q: only http:// proxies are supported", s)
+	}
+	if u.User != nil {
+		return nil, errors.New("enter the proxy user name and password separately, not in the URL")
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("%q has no host", s)
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("%q has an invalid port", s)
+		}
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("%q must be just a host and port", s)
+	}
+	u.Path = ""
+	return u, nil
+}
+
+// ProxyFunc returns the function that picks the proxy for a request URL, or nil
+// for a direct connection. The explicit URL, when set, replaces HTTP_PROXY and
+// HTTPS_PROXY, while NO_PROXY is honored either way. The credentials are added
+// to any proxy that doesn't carry its own.
+func (s ProxySettings) ProxyFunc() (func(*url.URL) (*url.URL, error), error) {
+	cfg := httpproxy.FromEnvironment()
+	if strings.TrimSpace(s.URL) != "" {
+		u, err := ValidateProxyURL(s.URL)
+		if err != nil {
+			return nil, err
+		}
+		cfg.HTTPProxy = u.String()
+		cfg.HTTPSProxy = u.String()
+	}
+	proxyFunc := cfg.ProxyFunc()
+
+	return func(reqURL *url.URL) (*url.URL, error) {
+		proxyURL, err := proxyFunc(reqURL)
+		if err != nil || proxyURL == nil || proxyURL.User != nil || s.Username == "" {
+			return proxyURL, err
+		}
+		withUser := *proxyURL
+		withUser.User = url.UserPassword(s.Username, s.Password)
+		return &withUser, nil
+	}, nil
+}
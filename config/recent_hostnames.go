@@ -0,0 +1,51 @@
+//go:build windows
+
+package config
+
+import "strings"
+
+// MaxRecentHostnames is how many servers the login dialog remembers
+const MaxRecentHostnames = 5
+
+// addRecentHostname puts hostname first in recents, dropping an earlier entry
+// for the same server and anything beyond max. Hostnames are compared ignoring
+// case and trailing slashes, as the same server can be typed either way.
+func addRecentHostname(recents []string, hostname string, max int) []string {
+	hostname = strings.TrimRight(strings.TrimSpace(hostname), "/")
+	if hostname == "" {
+		return recents
+	}
+	result := []string{hostname}
+	for _, recent := range recents {
+		if len(result) >= max {
+			break
+		}
+		if strings.EqualFold(strings.TrimRight(recent, "/"), hostname) {
+			continue
+		}
+		result = append(result, recent)
+	}
+	return result
+}
+
+// GetRecentHostnames returns the servers logged in to recently, most recent first
+func (cm *ConfigManager) GetRecentHostnames() []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if cm.config == nil {
+		return nil
+	}
+	return append([]string(nil), cm.config.RecentHostnames...)
+}
+
+// AddRecentHostname records a server that was just logged in to as the most
+// recent one and saves to config
+func (cm *ConfigManager) AddRecentHostname(hostname string) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cfg := cm.getConfigCopy()
+	cfg.RecentHostnames = addRecentHostname(cfg.RecentHostnames, hostname, MaxRecentHostnames)
+	return cm.save(cfg)
+}
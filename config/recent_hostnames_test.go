@@ -0,0 +1,88 @@
+//go:build windows
+
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddRecentHostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		recents  []string
+		hostname string
+		max      int
+		want     []string
+	}{
+		{name: "first server", hostname: "https://vpn.example.com", max: 5, want: []string{"https://vpn.example.com"}},
+		{
+			name:     "new server goes first",
+			recents:  []string{"https://a.example.com", "https://b.example.com"},
+			hostname: "https://c.example.com",
+			max:      5,
+			want:     []string{"https://c.example.com", "https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:     "known server moves to the front",
+			recents:  []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"},
+			hostname: "https://c.example.com",
+			max:      5,
+			want:     []string{"https://c.example.com", "https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:     "same server typed differently",
+			recents:  []string{"https://a.example.com", "https://B.example.com/"},
+			hostname: " https://b.example.com/ ",
+			max:      5,
+			want:     []string{"https://b.example.com", "https://a.example.com"},
+		},
+		{
+			name:     "oldest dropped at the cap",
+			recents:  []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"},
+			hostname: "https://d.example.com",
+			max:      3,
+			want:     []string{"https://d.example.com", "https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:     "reordering at the cap keeps the rest",
+			recents:  []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"},
+			hostname: "https://c.example.com",
+			max:      3,
+			want:     []string{"https://c.example.com", "https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:     "blank ignored",
+			recents:  []string{"https://a.example.com"},
+			hostname: "  ",
+			max:      5,
+			want:     []string{"https://a.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addRecentHostname(tt.recents, tt.hostname, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addRecentHostname() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddRecentHostnameSaves(t *testing.T) {
+	cm := &ConfigManager{config: &Config{}, configPath: filepath.Join(t.TempDir(), ConfigFileName)}
+	for _, hostname := range []string{"https://1.example.com", "https://2.example.com", "https://3.example.com",
+		"https://4.example.com", "https://5.example.com", "https://6.example.com"} {
+		if !cm.AddRecentHostname(hostname) {
+			t.Fatalf("AddRecentHostname(%q) failed to save", hostname)
+		}
+	}
+
+	cm.Load()
+	want := []string{"https://6.example.com", "https://5.example.com", "https://4.example.com",
+		"https://3.example.com", "https://2.example.com"}
+	if got := cm.GetRecentHostnames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetRecentHostnames() after reload = %q, want %q", got, want)
+	}
+}
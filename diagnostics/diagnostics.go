@@ -0,0 +1,145 @@
+//go:build windows
+
+// Package diagnostics runs a set of independent health checks and collects
+// their outcomes into a report that can be shown to the user or logged.
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCheckTimeout bounds how long a single check may run
+const DefaultCheckTimeout = 10 * time.Second
+
+// ErrSkipped is returned by a check that doesn't apply in the current state,
+// for example an adapter check while disconnected
+var ErrSkipped = errors.New("skipped")
+
+// Check is a single named health check. Run returns a short message
+// describing what it found; a non-nil error fails the check.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	StatusPassed Status = iota
+	StatusFailed
+	StatusSkipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPassed:
+		return "Passed"
+	case StatusFailed:
+		return "Failed"
+	case StatusSkipped:
+		return "Skipped"
+	default:
+		return "Unknown"
+	}
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name     string
+	Status   Status
+	Message  string
+	Duration time.Duration
+}
+
+// Report holds the results of a run, in the order the checks were given
+type Report struct {
+	Results []Result
+}
+
+// Passed reports whether no check failed
+func (r Report) Passed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report with one line per check
+func (r Report) String() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", result.Status, result.Name, result.Message)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Run runs checks concurrently, giving each its own timeout so one that hangs
+// doesn't hold up the rest. A check that overruns is reported as failed even if
+// it ignores its context and keeps running in the background.
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	report := Report{Results: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Results[i] = runCheck(ctx, check, timeout)
+		}(i, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// runCheck runs one check and converts its outcome into a Result
+func runCheck(ctx context.Context, check Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		message string
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("check panicked: %v", r)}
+			}
+		}()
+		message, err := check.Run(ctx)
+		done <- outcome{message: message, err: err}
+	}()
+
+	result := Result{Name: check.Name}
+	select {
+	case o := <-done:
+		result.Duration = time.Since(start)
+		switch {
+		case o.err == nil:
+			result.Status = StatusPassed
+			result.Message = o.message
+		case errors.Is(o.err, ErrSkipped):
+			result.Status = StatusSkipped
+			result.Message = o.message
+		default:
+			result.Status = StatusFailed
+			result.Message = o.err.Error()
+		}
+	case <-ctx.Done():
+		result.Duration = time.Since(start)
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("Timed out after %v", timeout)
+	}
+	return result
+}
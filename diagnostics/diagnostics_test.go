@@ -0,0 +1,177 @@
+//go:build windows
+
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func passes(message string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) { return message, nil }
+}
+
+func fails(message string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) { return "", errors.New(message) }
+}
+
+func TestRun(t *testing.T) {
+	hangs := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	ignoresContext := func(context.Context) (string, error) {
+		time.Sleep(time.Second)
+		return "too late", nil
+	}
+	skipped := func(context.Context) (string, error) {
+		return "Not connected", fmt.Errorf("adapter: %w", ErrSkipped)
+	}
+	panics := func(context.Context) (string, error) { panic("boom") }
+
+	tests := []struct {
+		name       string
+		checks     []Check
+		want       []Result
+		wantPassed bool
+	}{
+		{
+			name:       "nothing to check",
+			wantPassed: true,
+		},
+		{
+			name: "all pass",
+			checks: []Check{
+				{Name: "Manager", Run: passes("Reachable")},
+				{Name: "Credentials", Run: passes("Stored")},
+			},
+			want: []Result{
+				{Name: "Manager", Status: StatusPassed, Message: "Reachable"},
+				{Name: "Credentials", Status: StatusPassed, Message: "Stored"},
+			},
+			wantPassed: true,
+		},
+		{
+			name: "skipped doesn't fail",
+			checks: []Check{
+				{Name: "Manager", Run: passes("Reachable")},
+				{Name: "Adapter", Run: skipped},
+			},
+			want: []Result{
+				{Name: "Manager", Status: StatusPassed, Message: "Reachable"},
+				{Name: "Adapter", Status: StatusSkipped, Message: "Not connected"},
+			},
+			wantPassed: true,
+		},
+		{
+			name: "one fails",
+			checks: []Check{
+				{Name: "Hostname", Run: fails("no such host")},
+				{Name: "Manager", Run: passes("Reachable")},
+			},
+			want: []Result{
+				{Name: "Hostname", Status: StatusFailed, Message: "no such host"},
+				{Name: "Manager", Status: StatusPassed, Message: "Reachable"},
+			},
+		},
+		{
+			name: "hangs",
+			checks: []Check{
+				{Name: "API", Run: hangs},
+				{Name: "Manager", Run: passes("Reachable")},
+			},
+			want: []Result{
+				{Name: "API", Status: StatusFailed, Message: "Timed out after 50ms"},
+				{Name: "Manager", Status: StatusPassed, Message: "Reachable"},
+			},
+		},
+		{
+			name: "ignores its context",
+			checks: []Check{
+				{Name: "API", Run: ignoresContext},
+			},
+			want: []Result{
+				{Name: "API", Status: StatusFailed, Message: "Timed out after 50ms"},
+			},
+		},
+		{
+			name: "panics",
+			checks: []Check{
+				{Name: "Adapter", Run: panics},
+				{Name: "Manager", Run: passes("Reachable")},
+			},
+			want: []Result{
+				{Name: "Adapter", Status: StatusFailed, Message: "check panicked: boom"},
+				{Name: "Manager", Status: StatusPassed, Message: "Reachable"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			report := Run(context.Background(), tt.checks, 50*time.Millisecond)
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Run() took %v, want the timeout to bound it", elapsed)
+			}
+
+			if len(report.Results) != len(tt.want) {
+				t.Fatalf("%d results, want %d", len(report.Results), len(tt.want))
+			}
+			for i, got := range report.Results {
+				got.Duration = 0
+				if got != tt.want[i] {
+					t.Errorf("result %d = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+			if got := report.Passed(); got != tt.wantPassed {
+				t.Errorf("Passed() = %v, want %v", got, tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestRunRunsChecksConcurrently(t *testing.T) {
+	slow := func(ctx context.Context) (string, error) {
+		time.Sleep(200 * time.Millisecond)
+		return "OK", nil
+	}
+	checks := []Check{{Name: "A", Run: slow}, {Name: "B", Run: slow}, {Name: "C", Run: slow}}
+
+	start := time.Now()
+	report := Run(context.Background(), checks, time.Second)
+	if elapsed := time.Since(start); elapsed >= 600*time.Millisecond {
+		t.Errorf("Run() took %v, want the checks to run side by side", elapsed)
+	}
+	if !report.Passed() {
+		t.Errorf("report failed:\n%s", report)
+	}
+}
+
+func TestRunStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := Run(ctx, []Check{{Name: "API", Run: func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}}, time.Minute)
+	if report.Passed() {
+		t.Error("a canceled run passed")
+	}
+}
+
+func TestReportString(t *testing.T) {
+	report := Report{Results: []Result{
+		{Name: "Manager", Status: StatusPassed, Message: "Reachable"},
+		{Name: "Adapter", Status: StatusSkipped, Message: "Not connected"},
+		{Name: "API", Status: StatusFailed, Message: "Timed out after 10s"},
+	}}
+	want := "[Passed] Manager: Reachable\n[Skipped] Adapter: Not connected\n[Failed] API: Timed out after 10s"
+	if got := report.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,281 @@
+//go:build windows
+
+package elevate
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/fosrl/newt/logger"
+	"golang.org/x/sys/windows"
+)
+
+// CaptureChildFlag is the switch Pangolin is started with, elevated, to run a
+// program for RunElevatedCaptured; main hands the rest of the arguments to
+// RunCaptureChild
+const CaptureChildFlag = "/elevatedcapture"
+
+// maxCapturedOutput caps how much of each output stream is returned
+const maxCapturedOutput = 1024 * 1024
+
+// RunElevatedCaptured elevates and runs a program like ShellExecuteEx, waits for it
+// to finish and returns what it wrote to stdout and stderr along with its exit code.
+//
+// A "runas" child can't inherit our handles, so Pangolin itself is elevated with
+// CaptureChildFlag. It opens two named pipes this process created, starts the
+// program with them as its output and exits with the program's exit code. No
+// shell is involved, so arguments are passed to the program as they are, and
+// nothing is written to disk.
+// It returns windows.ERROR_CANCELLED if the user declined the UAC prompt.
+func RunElevatedCaptured(program, arguments, directory string) (stdout, stderr string, exitCode uint32, err error) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", "", 0, err
+	}
+	launch := func(childArguments string) (windows.Handle, error) {
+		return ShellExecuteEx(self, childArguments, directory, windows.SW_HIDE)
+	}
+	stdout, stderr, exitCode, err = runCaptured(launch, program, arguments, directory)
+	if err == nil && exitCode != 0 {
+		logger.Warn("Elevate: %s exited with code %d: %s", program, exitCode, strings.TrimSpace(stderr))
+	}
+	return stdout, stderr, exitCode, err
+}
+
+// runCaptured creates the output pipes, starts the capture child with launch,
+// which receives the child's arguments, and collects the program's output
+func runCaptured(launch func(childArguments string) (windows.Handle, error), program, arguments, directory string) (stdout, stderr string, exitCode uint32, err error) {
+	stdoutPipe, err := newCapturePipe()
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer stdoutPipe.close()
+	stderrPipe, err := newCapturePipe()
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer stderrPipe.close()
+
+	process, err := launch(captureChildArguments(stdoutPipe.name, stderrPipe.name, program, arguments, directory))
+	if err != nil {
+		return "", "", 0, err
+	}
+	if process == 0 {
+		return "", "", 0, fmt.Errorf("no process was started")
+	}
+	defer windows.CloseHandle(process)
+
+	// Both pipes are drained at once, or a program filling one would block
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stdout = stdoutPipe.read()
+	}()
+	go func() {
+		defer wg.Done()
+		stderr = stderrPipe.read()
+	}()
+
+	exitCode, err = WaitAndGetExitCode(process)
+
+	// A child that failed before opening a pipe leaves read waiting for it
+	stdoutPipe.release()
+	stderrPipe.release()
+	wg.Wait()
+
+	if err != nil {
+		return "", "", 0, err
+	}
+	return stdout, stderr, exitCode, nil
+}
+
+// captureChildArguments builds the capture child's command line. Everything
+// it passes on is hex encoded, so no quoting is needed, and prefixed with an
+// x, so an empty argument or directory isn't lost.
+func captureChildArguments(stdoutPipe, stderrPipe, program, arguments, directory string) string {
+	args := []string{CaptureChildFlag}
+	for _, arg := range []string{stdoutPipe, stderrPipe, program, arguments, directory} {
+		args = append(args, "x"+hex.EncodeToString([]byte(arg)))
+	}
+	return strings.Join(args, " ")
+}
+
+// parseCaptureChildArguments decodes the arguments following CaptureChildFlag
+func parseCaptureChildArguments(args []string) (stdoutPipe, stderrPipe, program, arguments, directory string, err error) {
+	if len(args) != 5 {
+		return "", "", "", "", "", fmt.Errorf("expected 5 arguments, got %d", len(args))
+	}
+	decoded := make([]string, len(args))
+	for i, arg := range args {
+		encoded, ok := strings.CutPrefix(arg, "x")
+		if !ok {
+			return "", "", "", "", "", fmt.Errorf("argument %d is not encoded", i+1)
+		}
+		b, err := hex.DecodeString(encoded)
+		if err != nil {
+			return "", "", "", "", "", fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		decoded[i] = string(b)
+	}
+	for _, pipe := range decoded[:2] {
+		if !strings.HasPrefix(pipe, capturePipePrefix) {
+			return "", "", "", "", "", fmt.Errorf("%q is not a capture pipe", pipe)
+		}
+	}
+	if decoded[2] == "" {
+		return "", "", "", "", "", errors.New("no program given")
+	}
+	return decoded[0], decoded[1], decoded[2], decoded[3], decoded[4], nil
+}
+
+// RunCaptureChild is the elevated side of RunElevatedCaptured. It runs the
+// program described by args, the arguments after CaptureChildFlag, with its
+// output going to the caller's pipes, and returns the program's exit code.
+func RunCaptureChild(args []string) uint32 {
+	stdoutPipe, stderrPipe, program, arguments, directory, err := parseCaptureChildArguments(args)
+	if err != nil {
+		logger.Error("Elevate: invalid capture arguments: %v", err)
+		return 1
+	}
+
+	stdout, err := openCapturePipe(stdoutPipe)
+	if err != nil {
+		logger.Error("Elevate: failed to open output pipe: %v", err)
+		return 1
+	}
+	defer stdout.Close()
+	stderr, err := openCapturePipe(stderrPipe)
+	if err != nil {
+		logger.Error("Elevate: failed to open error pipe: %v", err)
+		return 1
+	}
+	defer stderr.Close()
+
+	cmd := exec.Command(program)
+	cmdLine := syscall.EscapeArg(program)
+	if arguments != "" {
+		cmdLine += " " + arguments
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: cmdLine, HideWindow: true}
+	cmd.Dir = directory
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return uint32(exitErr.ExitCode())
+		}
+		fmt.Fprintf(stderr, "Failed to run %s: %v\n", program, err)
+		return 1
+	}
+	return 0
+}
+
+// capturePipePrefix starts the name of every capture pipe
+const capturePipePrefix = `\\.\pipe\pangolin-capture-`
+
+// capturePipe is the reading end of a named pipe a capture child writes to
+type capturePipe struct {
+	name   string
+	handle windows.Handle
+}
+
+// newCapturePipe creates a single-instance inbound pipe with an unguessable
+// name. Creating it as the first instance fails if the name is taken, so
+// nothing else can be listening under it.
+func newCapturePipe() (*capturePipe, error) {
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	name := capturePipePrefix + hex.EncodeToString(random[:])
+	name16, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := windows.CreateNamedPipe(name16,
+		windows.PIPE_ACCESS_INBOUND|windows.FILE_FLAG_FIRST_PIPE_INSTANCE,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		1, 0, 64*1024, 0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output pipe: %w", err)
+	}
+	return &capturePipe{name: name, handle: handle}, nil
+}
+
+// read waits for the child to connect and returns what it writes until it
+// closes the pipe. Output past maxCapturedOutput is read but dropped, so the
+// program never blocks on a full pipe.
+func (p *capturePipe) read() string {
+	err := windows.ConnectNamedPipe(p.handle, nil)
+	if err != nil && err != windows.ERROR_PIPE_CONNECTED {
+		return ""
+	}
+	var out []byte
+	buf := make([]byte, 32*1024)
+	for {
+		var n uint32
+		err := windows.ReadFile(p.handle, buf, &n, nil)
+		if n > 0 && len(out) < maxCapturedOutput {
+			out = append(out, buf[:min(int(n), maxCapturedOutput-len(out))]...)
+		}
+		if err != nil || n == 0 {
+			break
+		}
+	}
+	return string(out)
+}
+
+// release connects to the pipe and hangs up if the child never did, which
+// ends a read still waiting for it. It does nothing once the child connected.
+func (p *capturePipe) release() {
+	name16, err := windows.UTF16PtrFromString(p.name)
+	if err != nil {
+		return
+	}
+	handle, err := windows.CreateFile(name16, windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, 0, 0)
+	if err == nil {
+		windows.CloseHandle(handle)
+	}
+}
+
+func (p *capturePipe) close() {
+	windows.CloseHandle(p.handle)
+}
+
+// openCapturePipe opens the caller's pipe for writing. The caller only gets
+// to identify the elevated child, never to impersonate it.
+func openCapturePipe(name string) (*os.File, error) {
+	if !strings.HasPrefix(name, capturePipePrefix) {
+		return nil, fmt.Errorf("%q is not a capture pipe", name)
+	}
+	name16, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := windows.CreateFile(name16, windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING,
+		windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION, 0)
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), name), nil
+}
+
+// SystemProgram returns the full path of a program in the system directory,
+// so one of the same name in the working directory or PATH can't be run instead
+func SystemProgram(name string) string {
+	systemDir, err := windows.GetSystemDirectory()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(systemDir, name)
+}
@@ -0,0 +1,145 @@
+//go:build windows
+
+package elevate
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+// printHelperEnv makes the test binary print its arguments to stdout, a line
+// to stderr and exit with code 3, standing in for the program being run
+const printHelperEnv = "PANGOLIN_CAPTURE_TEST_PRINT"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(printHelperEnv) == "1" {
+		fmt.Fprintln(os.Stdout, strings.Join(os.Args[1:], "|"))
+		fmt.Fprintln(os.Stderr, "to stderr")
+		os.Exit(3)
+	}
+	// The capture child, started below by launchUnelevated
+	if len(os.Args) >= 2 && os.Args[1] == CaptureChildFlag {
+		os.Exit(int(RunCaptureChild(os.Args[2:])))
+	}
+	os.Exit(m.Run())
+}
+
+// launchUnelevated starts the test binary as the capture child without UAC,
+// the way ShellExecuteEx would start Pangolin elevated
+func launchUnelevated() func(string) (windows.Handle, error) {
+	return func(childArguments string) (windows.Handle, error) {
+		self, err := os.Executable()
+		if err != nil {
+			return 0, err
+		}
+		cmd := exec.Command(self)
+		cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: syscall.EscapeArg(self) + " " + childArguments}
+		if err := cmd.Start(); err != nil {
+			return 0, err
+		}
+		process, err := windows.OpenProcess(windows.SYNCHRONIZE|windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(cmd.Process.Pid))
+		cmd.Process.Release()
+		return process, err
+	}
+}
+
+func TestRunCapturedBothStreams(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(printHelperEnv, "1")
+
+	// Shell metacharacters reach the program untouched
+	stdout, stderr, exitCode, err := runCaptured(launchUnelevated(), self, `"a & b" %PATH% >out`, "")
+	if err != nil {
+		t.Fatalf("runCaptured() error = %v", err)
+	}
+	if want := "a & b|%PATH%|>out"; strings.TrimSpace(stdout) != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+	if want := "to stderr"; strings.TrimSpace(stderr) != want {
+		t.Errorf("stderr = %q, want %q", stderr, want)
+	}
+	if exitCode != 3 {
+		t.Errorf("exitCode = %d, want 3", exitCode)
+	}
+}
+
+func TestRunCapturedMissingProgram(t *testing.T) {
+	stdout, stderr, exitCode, err := runCaptured(launchUnelevated(), `C:\does\not\exist.exe`, "", "")
+	if err != nil {
+		t.Fatalf("runCaptured() error = %v", err)
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing", stdout)
+	}
+	if !strings.Contains(stderr, "Failed to run") {
+		t.Errorf("stderr = %q, want the start failure", stderr)
+	}
+	if exitCode != 1 {
+		t.Errorf("exitCode = %d, want 1", exitCode)
+	}
+}
+
+func TestCaptureChildArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		program   string
+		arguments string
+		directory string
+	}{
+		{name: "plain", program: `C:\Windows\System32\net.exe`, arguments: `start "Pangolin"`},
+		{name: "metacharacters", program: `C:\Program Files\a.exe`, arguments: `"x" & y | z > %TEMP%\f ^`, directory: `C:\Temp dir`},
+		{name: "no arguments", program: "a.exe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdoutPipe, stderrPipe := capturePipePrefix+"1", capturePipePrefix+"2"
+			line := captureChildArguments(stdoutPipe, stderrPipe, tt.program, tt.arguments, tt.directory)
+			fields := strings.Fields(line)
+			if fields[0] != CaptureChildFlag {
+				t.Fatalf("first argument = %q, want %q", fields[0], CaptureChildFlag)
+			}
+			gotOut, gotErr, program, arguments, directory, err := parseCaptureChildArguments(fields[1:])
+			if err != nil {
+				t.Fatalf("parseCaptureChildArguments() error = %v", err)
+			}
+			if gotOut != stdoutPipe || gotErr != stderrPipe || program != tt.program || arguments != tt.arguments || directory != tt.directory {
+				t.Errorf("round trip = %q, %q, %q, %q, %q", gotOut, gotErr, program, arguments, directory)
+			}
+		})
+	}
+}
+
+func TestParseCaptureChildArgumentsRejects(t *testing.T) {
+	hex := func(s string) string { return fmt.Sprintf("x%x", s) }
+	pipe := hex(capturePipePrefix + "1")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "too few", args: []string{pipe, pipe, hex("a.exe"), hex("")}},
+		{name: "not encoded", args: []string{pipe, pipe, "a.exe", hex(""), hex("")}},
+		{name: "not hex", args: []string{pipe, pipe, "xa.exe", hex(""), hex("")}},
+		{name: "other pipe", args: []string{hex(`\\.\pipe\other`), pipe, hex("a.exe"), hex(""), hex("")}},
+		{name: "file instead of pipe", args: []string{pipe, hex(`C:\out.txt`), hex("a.exe"), hex(""), hex("")}},
+		{name: "no program", args: []string{pipe, pipe, hex(""), hex(""), hex("")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, _, _, err := parseCaptureChildArguments(tt.args); err == nil {
+				t.Error("parseCaptureChildArguments() accepted the arguments")
+			}
+		})
+	}
+}
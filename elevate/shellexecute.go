@@ -3,15 +3,60 @@
 package elevate
 
 import (
+	"fmt"
+	"unsafe"
+
 	"github.com/fosrl/newt/logger"
 	"golang.org/x/sys/windows"
 )
 
+var (
+	modshell32          = windows.NewLazySystemDLL("shell32.dll")
+	procShellExecuteExW = modshell32.NewProc("ShellExecuteExW")
+)
+
+// seeMaskNoCloseProcess asks ShellExecuteEx to return a handle to the started process
+const seeMaskNoCloseProcess = 0x00000040
+
+// shellExecuteInfo mirrors SHELLEXECUTEINFOW
+type shellExecuteInfo struct {
+	cbSize         uint32
+	fMask          uint32
+	hwnd           windows.HWND
+	lpVerb         *uint16
+	lpFile         *uint16
+	lpParameters   *uint16
+	lpDirectory    *uint16
+	nShow          int32
+	hInstApp       windows.Handle
+	lpIDList       uintptr
+	lpClass        *uint16
+	hkeyClass      windows.Handle
+	dwHotKey       uint32
+	hIconOrMonitor windows.Handle
+	hProcess       windows.Handle
+}
+
 // ShellExecute elevates and runs a program with the specified arguments
-// Uses "runas" verb to show UAC prompt
+// Uses "runas" verb to show UAC prompt. It does not wait for the program to finish.
 func ShellExecute(program, arguments, directory string, show int32) error {
-	logger.Info("Elevate: ShellExecute called - program: %s, args: %s", program, arguments)
-	
+	process, err := ShellExecuteEx(program, arguments, directory, show)
+	if err != nil {
+		return err
+	}
+	if process != 0 {
+		windows.CloseHandle(process)
+	}
+	return nil
+}
+
+// ShellExecuteEx elevates and runs a program like ShellExecute, returning a handle
+// to the started process for use with WaitAndGetExitCode. The handle is zero if no
+// new process was started; otherwise the caller must close it.
+// It returns windows.ERROR_CANCELLED if the user declined the UAC prompt.
+func ShellExecuteEx(program, arguments, directory string, show int32) (windows.Handle, error) {
+	logger.Info("Elevate: ShellExecuteEx called - program: %s, args: %s", program, arguments)
+
 	var program16 *uint16
 	var arguments16 *uint16
 	var directory16 *uint16
@@ -21,7 +66,7 @@ func ShellExecute(program, arguments, directory string, show int32) error {
 		program16, err = windows.UTF16PtrFromString(program)
 		if err != nil {
 			logger.Error("Elevate: Failed to convert program to UTF16: %v", err)
-			return err
+			return 0, err
 		}
 	}
 	if len(arguments) > 0 {
@@ -29,7 +74,7 @@ func ShellExecute(program, arguments, directory string, show int32) error {
 		arguments16, err = windows.UTF16PtrFromString(arguments)
 		if err != nil {
 			logger.Error("Elevate: Failed to convert arguments to UTF16: %v", err)
-			return err
+			return 0, err
 		}
 	}
 	if len(directory) > 0 {
@@ -37,18 +82,51 @@ func ShellExecute(program, arguments, directory string, show int32) error {
 		directory16, err = windows.UTF16PtrFromString(directory)
 		if err != nil {
 			logger.Error("Elevate: Failed to convert directory to UTF16: %v", err)
-			return err
+			return 0, err
 		}
 	}
 
 	// Use "runas" verb to trigger UAC elevation
-	err := windows.ShellExecute(0, windows.StringToUTF16Ptr("runas"), program16, arguments16, directory16, show)
-	if err != nil {
-		logger.Error("Elevate: ShellExecute failed: %v", err)
-		return err
+	info := shellExecuteInfo{
+		fMask:        seeMaskNoCloseProcess,
+		lpVerb:       windows.StringToUTF16Ptr("runas"),
+		lpFile:       program16,
+		lpParameters: arguments16,
+		lpDirectory:  directory16,
+		nShow:        show,
 	}
-	
-	logger.Info("Elevate: ShellExecute succeeded")
-	return nil
+	info.cbSize = uint32(unsafe.Sizeof(info))
+
+	r1, _, err := procShellExecuteExW.Call(uintptr(unsafe.Pointer(&info)))
+	if r1 == 0 {
+		if err == windows.ERROR_SUCCESS {
+			err = windows.ERROR_GEN_FAILURE
+		}
+		logger.Error("Elevate: ShellExecuteEx failed: %v", err)
+		return 0, err
+	}
+
+	logger.Info("Elevate: ShellExecuteEx succeeded")
+	return info.hProcess, nil
 }
 
+// WaitAndGetExitCode waits for a process started by ShellExecuteEx to exit and
+// returns its exit code. It does not close the handle.
+func WaitAndGetExitCode(process windows.Handle) (uint32, error) {
+	if process == 0 {
+		return 0, fmt.Errorf("no process handle")
+	}
+	event, err := windows.WaitForSingleObject(process, windows.INFINITE)
+	if err != nil {
+		return 0, fmt.Errorf("failed to wait for process: %w", err)
+	}
+	if event != windows.WAIT_OBJECT_0 {
+		return 0, fmt.Errorf("unexpected wait result: %d", event)
+	}
+
+	var exitCode uint32
+	if err := windows.GetExitCodeProcess(process, &exitCode); err != nil {
+		return 0, fmt.Errorf("failed to get process exit code: %w", err)
+	}
+	return exitCode, nil
+}
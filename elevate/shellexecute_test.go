@@ -0,0 +1,70 @@
+//go:build windows
+
+package elevate
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// startProcess runs a command without UAC and opens its process the way
+// ShellExecuteEx hands it back
+func startProcess(t *testing.T, name string, args ...string) windows.Handle {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting %s: %v", name, err)
+	}
+	process, err := windows.OpenProcess(windows.SYNCHRONIZE|windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(cmd.Process.Pid))
+	cmd.Process.Release()
+	if err != nil {
+		t.Fatalf("opening process: %v", err)
+	}
+	t.Cleanup(func() { windows.CloseHandle(process) })
+	return process
+}
+
+func TestWaitAndGetExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want uint32
+	}{
+		{name: "success", args: []string{"/c", "exit 0"}, want: 0},
+		{name: "failure", args: []string{"/c", "exit 1603"}, want: 1603},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			process := startProcess(t, "cmd.exe", tt.args...)
+			got, err := WaitAndGetExitCode(process)
+			if err != nil {
+				t.Fatalf("WaitAndGetExitCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("WaitAndGetExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitAndGetExitCodeWithoutProcess(t *testing.T) {
+	if _, err := WaitAndGetExitCode(0); err == nil {
+		t.Error("WaitAndGetExitCode(0) succeeded")
+	}
+}
+
+// ShellExecuteExW rejects a SHELLEXECUTEINFOW of the wrong size
+func TestShellExecuteInfoSize(t *testing.T) {
+	want := map[string]uintptr{"386": 60, "arm": 60, "amd64": 112, "arm64": 112}[runtime.GOARCH]
+	if want == 0 {
+		t.Skipf("no known size on %s", runtime.GOARCH)
+	}
+	if got := unsafe.Sizeof(shellExecuteInfo{}); got != want {
+		t.Errorf("shellExecuteInfo is %d bytes, want %d", got, want)
+	}
+}
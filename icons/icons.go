@@ -0,0 +1,93 @@
+//go:build windows
+
+// Package icons embeds the icons and word marks installed in the icons folder,
+// so the UI still shows them when that folder is missing or incomplete, as in a
+// damaged install or a development run.
+package icons
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fosrl/newt/logger"
+)
+
+//go:embed icon-gray.ico icon-orange.ico word_mark_black.png word_mark_white.png
+var embedded embed.FS
+
+// fallbackDirName is the folder in the user's temp directory that embedded
+// files are written to, as walk loads images from files
+const fallbackDirName = "pangolin-icons"
+
+var (
+	fallbackMutex sync.Mutex
+	// fallbackPaths maps the names already written out to their paths
+	fallbackPaths = make(map[string]string)
+)
+
+// Path returns the path of the file called name in dir. If it isn't there, the
+// copy embedded in the binary is written to the temp directory and its path is
+// returned instead. If that fails too, the path in dir is returned, so the
+// caller's load error names the file that was expected.
+func Path(dir, name string) string {
+	path := filepath.Join(dir, name)
+	if dir != "" {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	fallback, err := writeFallback(name)
+	if err != nil {
+		logger.Error("Failed to use the built-in %s: %v", name, err)
+		return path
+	}
+	return fallback
+}
+
+// writeFallback writes the embedded file called name to the temp directory once
+// per run and returns its path
+func writeFallback(name string) (string, error) {
+	fallbackMutex.Lock()
+	defer fallbackMutex.Unlock()
+
+	if path, ok := fallbackPaths[name]; ok {
+		return path, nil
+	}
+	data, err := embedded.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("no built-in copy: %w", err)
+	}
+
+	dir := filepath.Join(os.TempDir(), fallbackDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	// Another run may have written it already
+	if existing, err := os.ReadFile(path); err != nil || !bytes.Equal(existing, data) {
+		// Write next to it and rename, so a concurrent reader never sees half a file
+		tmp, err := os.CreateTemp(dir, name+".*")
+		if err != nil {
+			return "", err
+		}
+		_, err = tmp.Write(data)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return "", err
+		}
+	}
+
+	logger.Warn("%s is missing from the icons folder, using the built-in copy", name)
+	fallbackPaths[name] = path
+	return path, nil
+}
@@ -0,0 +1,106 @@
+//go:build windows
+
+package icons
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddedWordMarksDecode(t *testing.T) {
+	for _, name := range []string{"word_mark_black.png", "word_mark_white.png"} {
+		data, err := embedded.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if bounds := img.Bounds(); bounds.Empty() {
+			t.Errorf("%s is empty", name)
+		}
+	}
+}
+
+// TestEmbeddedIconsDecode checks the icon directory of each .ico and that
+// every image it lists is a PNG or a bitmap inside the file
+func TestEmbeddedIconsDecode(t *testing.T) {
+	for _, name := range []string{"icon-gray.ico", "icon-orange.ico"} {
+		data, err := embedded.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) < 6 || binary.LittleEndian.Uint16(data[0:]) != 0 || binary.LittleEndian.Uint16(data[2:]) != 1 {
+			t.Errorf("%s has no icon header", name)
+			continue
+		}
+		count := int(binary.LittleEndian.Uint16(data[4:]))
+		if count == 0 || len(data) < 6+16*count {
+			t.Errorf("%s lists %d images in %d bytes", name, count, len(data))
+			continue
+		}
+		for i := 0; i < count; i++ {
+			entry := data[6+16*i:]
+			size := binary.LittleEndian.Uint32(entry[8:])
+			offset := binary.LittleEndian.Uint32(entry[12:])
+			if uint64(offset)+uint64(size) > uint64(len(data)) || size < 40 {
+				t.Errorf("%s image %d at %d+%d is outside the file", name, i, offset, size)
+				continue
+			}
+			image := data[offset : offset+size]
+			switch {
+			case bytes.HasPrefix(image, []byte("\x89PNG")):
+				if _, err := png.Decode(bytes.NewReader(image)); err != nil {
+					t.Errorf("%s image %d: %v", name, i, err)
+				}
+			case binary.LittleEndian.Uint32(image) != 40:
+				t.Errorf("%s image %d is neither a PNG nor a bitmap", name, i)
+			}
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	temp := t.TempDir()
+	for _, env := range []string{"TMP", "TEMP", "TMPDIR"} {
+		t.Setenv(env, temp)
+	}
+	savedPaths := fallbackPaths
+	fallbackPaths = make(map[string]string)
+	defer func() { fallbackPaths = savedPaths }()
+
+	installed := t.TempDir()
+	if err := os.WriteFile(filepath.Join(installed, "icon-gray.ico"), []byte("installed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := Path(installed, "icon-gray.ico"), filepath.Join(installed, "icon-gray.ico"); got != want {
+		t.Errorf("Path() of an installed file = %q, want %q", got, want)
+	}
+
+	for _, dir := range []string{installed, ""} {
+		got := Path(dir, "icon-orange.ico")
+		if filepath.Dir(got) != filepath.Join(temp, fallbackDirName) {
+			t.Errorf("Path(%q) of a missing file = %q, want the built-in copy in %q", dir, got, temp)
+			continue
+		}
+		written, err := os.ReadFile(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := embedded.ReadFile("icon-orange.ico"); !bytes.Equal(written, want) {
+			t.Error("the built-in copy differs from the embedded file")
+		}
+	}
+
+	// Without a built-in copy the caller gets the path it expected
+	if got, want := Path(installed, "missing.ico"), filepath.Join(installed, "missing.ico"); got != want {
+		t.Errorf("Path() of an unknown file = %q, want %q", got, want)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fosrl/windows/config"
@@ -14,37 +15,64 @@ import (
 	"github.com/fosrl/newt/logger"
 )
 
-// stringToLogLevel converts a string log level to logger.LogLevel
-// Returns INFO as default if the string doesn't match any known level
-func stringToLogLevel(levelStr string) logger.LogLevel {
-	switch strings.ToUpper(levelStr) {
-	case "debug":
-		return logger.DEBUG
-	case "info":
-		return logger.INFO
-	case "warn":
-		return logger.WARN
-	case "error":
-		return logger.ERROR
-	case "fatal":
-		return logger.FATAL
-	default:
-		return logger.DEBUG // Default to INFO if unknown
+// hookedLogWriter writes log lines to the log file and additionally hands
+// each formatted line to an optional hook (used to stream logs to the UI)
+type hookedLogWriter struct {
+	output     logger.LogWriter
+	outputLock sync.RWMutex
+	hook       func(line string)
+	hookLock   sync.RWMutex
+}
+
+var logWriter = &hookedLogWriter{output: logger.NewStandardWriter()}
+
+// Write implements logger.LogWriter
+func (w *hookedLogWriter) Write(level logger.LogLevel, timestamp time.Time, message string) {
+	w.outputLock.RLock()
+	output := w.output
+	w.outputLock.RUnlock()
+	output.Write(level, timestamp, message)
+
+	w.hookLock.RLock()
+	hook := w.hook
+	w.hookLock.RUnlock()
+	if hook != nil {
+		hook(fmt.Sprintf("%s: %s %s", level.String(), timestamp.Format("2006/01/02 15:04:05"), message))
 	}
 }
 
+// setOutput replaces the writer that receives log lines
+func (w *hookedLogWriter) setOutput(output logger.LogWriter) {
+	w.outputLock.Lock()
+	w.output = output
+	w.outputLock.Unlock()
+}
+
+// setLogLineHook sets the function that receives every log line after it is written
+func setLogLineHook(hook func(line string)) {
+	logWriter.hookLock.Lock()
+	logWriter.hook = hook
+	logWriter.hookLock.Unlock()
+}
+
 // setupLogging initializes the logger and sets up log file output with rotation
 func setupLogging() {
 	// Initialize the logger and set log level FIRST, before any logging calls
-	logInstance := logger.GetLogger()
+	logInstance := logger.Init(logger.NewLoggerWithWriter(logWriter))
 
-	// Set the log level from centralized config immediately
-	logLevel := stringToLogLevel(config.LogLevel)
-	logInstance.SetLevel(logLevel)
+	// Set the configured log level immediately. The services run as SYSTEM and
+	// read a config of their own, so the UI hands them the user's level.
+	configManager := config.NewConfigManager()
+	logLevel := configManager.GetLogLevel()
+	logInstance.SetLevel(config.ParseLogLevel(logLevel))
 
 	// Create log directory if it doesn't exist
-	logDir := config.GetLogDir()
-	err := os.MkdirAll(logDir, 0755)
+	logDir, err := config.ResolveLogDir()
+	if err != nil {
+		logger.Error("Not writing a log file: %v", err)
+		return
+	}
+	err = os.MkdirAll(logDir, 0755)
 	if err != nil {
 		logger.Error("Failed to create log directory: %v", err)
 		return
@@ -52,58 +80,20 @@ func setupLogging() {
 
 	logFile := filepath.Join(logDir, "pangolin.log")
 
-	// Rotate log file if needed
-	err = rotateLogFile(logDir, logFile)
-	if err != nil {
-		logger.Error("Failed to rotate log file: %v", err)
-		// Continue anyway to create new log file
-	}
+	// Dated files from the old daily rotation are no longer written, but still age out
+	cleanupOldLogFiles(logDir, 30)
 
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	// Roll pangolin.log over by size, keeping a bounded number of old files
+	file, err := newRotatingFileWriter(logFile, configManager.GetLogMaxSize(), configManager.GetLogMaxFiles())
 	if err != nil {
 		logger.Error("Failed to open log file: %v", err)
 		return
 	}
 
 	// Set the custom logger output
-	logInstance.SetOutput(file)
+	logWriter.setOutput(file)
 
-	logger.Info("Pangolin logging initialized - log file: %s, log level: %s", logFile, config.LogLevel)
-}
-
-// rotateLogFile handles daily log rotation
-func rotateLogFile(logDir string, logFile string) error {
-	// Get current log file info
-	info, err := os.Stat(logFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // No current log file to rotate
-		}
-		return fmt.Errorf("failed to stat log file: %v", err)
-	}
-
-	// Check if log file is from today
-	now := time.Now()
-	fileTime := info.ModTime()
-
-	// If the log file is from today, no rotation needed
-	if now.Year() == fileTime.Year() && now.YearDay() == fileTime.YearDay() {
-		return nil
-	}
-
-	// Create rotated filename with date
-	rotatedName := fmt.Sprintf("pangolin-%s.log", fileTime.Format("2006-01-02"))
-	rotatedPath := filepath.Join(logDir, rotatedName)
-
-	// Rename current log file to dated filename
-	err = os.Rename(logFile, rotatedPath)
-	if err != nil {
-		return fmt.Errorf("failed to rotate log file: %v", err)
-	}
-
-	// Clean up old log files (keep last 30 days)
-	cleanupOldLogFiles(logDir, 30)
-	return nil
+	logger.Info("Pangolin logging initialized - log file: %s, log level: %s", logFile, logLevel)
 }
 
 // cleanupOldLogFiles removes log files older than specified days
@@ -0,0 +1,146 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// rotateRetryInterval is how long to wait before retrying a rotation that failed,
+// e.g. because another Pangolin process still has the log file open
+const rotateRetryInterval = time.Minute
+
+// renameFile renames a log file, replaced in tests to make a rename fail
+var renameFile = os.Rename
+
+// rotatingFileWriter writes formatted log lines to a file, rolling it over to
+// path.1 .. path.N once it reaches maxSize and deleting the oldest
+type rotatingFileWriter struct {
+	path       string
+	maxSize    int64
+	maxFiles   int
+	file       *os.File
+	size       int64
+	retryAfter time.Time
+	mu         sync.Mutex
+}
+
+// newRotatingFileWriter opens path for appending, rotating it first if it is already too large
+func newRotatingFileWriter(path string, maxSize int64, maxFiles int) (*rotatingFileWriter, error) {
+	w := &rotatingFileWriter{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	if w.size >= w.maxSize {
+		if err := w.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rotate log file: %v\n", err)
+		}
+	}
+	w.prune()
+	return w, nil
+}
+
+// Write implements logger.LogWriter
+func (w *rotatingFileWriter) Write(level logger.LogLevel, timestamp time.Time, message string) {
+	line := fmt.Sprintf("%s: %s %s\n", level.String(), timestamp.Format("2006/01/02 15:04:05"), message)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size+int64(len(line)) > w.maxSize && w.size > 0 && time.Now().After(w.retryAfter) {
+		if err := w.rotate(); err != nil {
+			// Keep appending to the current file and try again later
+			w.retryAfter = time.Now().Add(rotateRetryInterval)
+			fmt.Fprintf(os.Stderr, "Failed to rotate log file: %v\n", err)
+		}
+	}
+	if w.file == nil {
+		return
+	}
+	n, _ := w.file.WriteString(line)
+	w.size += int64(n)
+}
+
+// open opens the log file for appending and records its current size
+// Caller must hold the lock or own w exclusively
+func (w *rotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate moves the live file aside, then shifts path.i to path.i+1, dropping
+// the oldest, moves the live file to path.1 and starts a new one. The rotated
+// files are only touched once the live file has moved, so if it can't be, for
+// example because another process has it open, it is reopened as is and no
+// history is lost, however often the rotation is retried.
+// Caller must hold the lock or own w exclusively
+func (w *rotatingFileWriter) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+
+	if err := renameFile(w.path, w.pendingPath()); err != nil {
+		if openErr := w.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+
+	os.Remove(w.rotatedPath(w.maxFiles))
+	for i := w.maxFiles - 1; i >= 1; i-- {
+		renameFile(w.rotatedPath(i), w.rotatedPath(i+1))
+	}
+	renameErr := renameFile(w.pendingPath(), w.rotatedPath(1))
+
+	if err := w.open(); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+// pendingPath is where rotate moves the live file before shifting the rotated ones
+func (w *rotatingFileWriter) pendingPath() string {
+	return w.path + ".rotating"
+}
+
+// prune deletes rotated files beyond maxFiles, left over from a larger setting
+func (w *rotatingFileWriter) prune() {
+	matches, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return
+	}
+	for _, match := range matches {
+		i, err := strconv.Atoi(strings.TrimPrefix(match, w.path+"."))
+		if err == nil && i > w.maxFiles {
+			os.Remove(match)
+		}
+	}
+}
+
+// rotatedPath returns the name of the i-th rotated file, 1 being the newest
+func (w *rotatingFileWriter) rotatedPath(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
@@ -0,0 +1,135 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// logLine is what rotatingFileWriter writes for message
+func logLine(message string, timestamp time.Time) string {
+	return fmt.Sprintf("%s: %s %s\n", logger.INFO.String(), timestamp.Format("2006/01/02 15:04:05"), message)
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", filepath.Base(path), err)
+	}
+	return string(data)
+}
+
+func newTestWriter(t *testing.T, maxSize int64, maxFiles int) *rotatingFileWriter {
+	t.Helper()
+	w, err := newRotatingFileWriter(filepath.Join(t.TempDir(), "pangolin.log"), maxSize, maxFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.file.Close() })
+	return w
+}
+
+func TestRotateAtSizeBoundary(t *testing.T) {
+	now := time.Now()
+	lineSize := int64(len(logLine("line 0", now)))
+	// Room for exactly two lines
+	w := newTestWriter(t, 2*lineSize, 3)
+
+	w.Write(logger.INFO, now, "line 0")
+	w.Write(logger.INFO, now, "line 1")
+	if _, err := os.Stat(w.rotatedPath(1)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("rotated at %d bytes, before reaching the limit of %d", w.size, w.maxSize)
+	}
+
+	w.Write(logger.INFO, now, "line 2")
+	if got, want := readLog(t, w.rotatedPath(1)), logLine("line 0", now)+logLine("line 1", now); got != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(w.rotatedPath(1)), got, want)
+	}
+	if got, want := readLog(t, w.path), logLine("line 2", now); got != want {
+		t.Errorf("live log = %q, want %q", got, want)
+	}
+}
+
+func TestRotateKeepsMaxFiles(t *testing.T) {
+	now := time.Now()
+	w := newTestWriter(t, int64(len(logLine("line 0", now))), 2)
+
+	for i := 0; i < 5; i++ {
+		w.Write(logger.INFO, now, fmt.Sprintf("line %d", i))
+	}
+
+	// Each line fills a file, so the newest is live and the two before it rotated
+	for i, want := range []string{"line 3", "line 2"} {
+		if got := readLog(t, w.rotatedPath(i+1)); got != logLine(want, now) {
+			t.Errorf("%s = %q, want %q", filepath.Base(w.rotatedPath(i+1)), got, logLine(want, now))
+		}
+	}
+	if _, err := os.Stat(w.rotatedPath(3)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s exists beyond maxFiles", filepath.Base(w.rotatedPath(3)))
+	}
+}
+
+func TestPruneRemovesFilesBeyondMaxFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pangolin.log")
+	for i := 1; i <= 4; i++ {
+		if err := os.WriteFile(fmt.Sprintf("%s.%d", path, i), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := newRotatingFileWriter(path, 1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.file.Close()
+
+	for i := 1; i <= 4; i++ {
+		_, err := os.Stat(w.rotatedPath(i))
+		if exists := err == nil; exists != (i <= 2) {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(w.rotatedPath(i)), exists, i <= 2)
+		}
+	}
+}
+
+func TestFailedRotateKeepsHistory(t *testing.T) {
+	now := time.Now()
+	w := newTestWriter(t, int64(len(logLine("line 0", now))), 2)
+	w.Write(logger.INFO, now, "line 0")
+	w.Write(logger.INFO, now, "line 1")
+	w.Write(logger.INFO, now, "line 2")
+
+	// The live file can't be moved, as when another process has it open
+	defer func(rename func(string, string) error) { renameFile = rename }(renameFile)
+	renameFile = func(oldpath, newpath string) error {
+		if oldpath == w.path {
+			return errors.New("file is in use")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+
+	for i := 3; i < 6; i++ {
+		w.retryAfter = time.Time{}
+		w.Write(logger.INFO, now, fmt.Sprintf("line %d", i))
+	}
+
+	for i, want := range []string{"line 1", "line 0"} {
+		if got := readLog(t, w.rotatedPath(i+1)); got != logLine(want, now) {
+			t.Errorf("%s = %q, want %q", filepath.Base(w.rotatedPath(i+1)), got, logLine(want, now))
+		}
+	}
+	live := readLog(t, w.path)
+	for i := 2; i < 6; i++ {
+		if !strings.Contains(live, logLine(fmt.Sprintf("line %d", i), now)) {
+			t.Errorf("live log is missing line %d: %q", i, live)
+		}
+	}
+}
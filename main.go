@@ -3,9 +3,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 	"unsafe"
@@ -17,6 +19,7 @@ import (
 	"github.com/fosrl/windows/managers"
 	"github.com/fosrl/windows/secrets"
 	"github.com/fosrl/windows/ui"
+	"github.com/fosrl/windows/ui/preferences"
 	"github.com/fosrl/windows/version"
 
 	"github.com/fosrl/newt/logger"
@@ -60,10 +63,16 @@ func main() {
 	// Log version on startup
 	logger.Info("Pangolin version %s starting", version.Number)
 
+	// Run a program for an unelevated Pangolin that wants its output
+	if len(os.Args) >= 2 && os.Args[1] == elevate.CaptureChildFlag {
+		os.Exit(int(elevate.RunCaptureChild(os.Args[2:])))
+	}
+
 	// Check if we're being run as the manager service
 	if len(os.Args) >= 2 && os.Args[1] == "/managerservice" {
 		// Run as Windows service
 		logger.Info("Starting as manager service")
+		setLogLineHook(managers.IPCServerNotifyLogLine)
 		if err := managers.Run(); err != nil {
 			logger.Fatal("Manager service failed: %v", err)
 		}
@@ -119,6 +128,21 @@ func main() {
 	if len(os.Args) >= 5 && os.Args[1] == "/ui" {
 		// We're being launched by the manager service
 		// Args: [exe, "/ui", readerFd, writerFd, eventsFd]
+
+		// Only one tray UI per session; a duplicate shows the existing menu and exits
+		releaseInstance, err := acquireSingleInstance(singleInstanceMutexName)
+		if err == errAlreadyRunning {
+			logger.Info("UI is already running, signalling the existing instance")
+			if err := ui.SignalRunningInstance(); err != nil {
+				logger.Error("Failed to signal the running UI: %v", err)
+			}
+			return
+		} else if err != nil {
+			logger.Error("Failed to create single instance mutex: %v", err)
+		} else {
+			defer releaseInstance()
+		}
+
 		readerFd, err1 := strconv.ParseUint(os.Args[2], 10, 64)
 		writerFd, err2 := strconv.ParseUint(os.Args[3], 10, 64)
 		eventsFd, err3 := strconv.ParseUint(os.Args[4], 10, 64)
@@ -133,11 +157,37 @@ func main() {
 
 		// Initialize IPC client to connect to manager service
 		managers.InitializeIPCClient(reader, writer, events)
+		err = managers.IPCClientHandshake()
+		if errors.Is(err, managers.ErrIPCTimeout) {
+			// The handshake closed the pipes; ask the manager for fresh ones
+			logger.Warn("IPC handshake with manager service timed out, reconnecting")
+			err = managers.IPCClientReconnect()
+		}
+		if err != nil {
+			logger.Error("IPC handshake with manager service failed: %v", err)
+			if managers.IsProtocolVersionError(err) {
+				showMessageBox("Pangolin was updated while it was running. Please restart to finish updating.", "Pangolin")
+			} else {
+				showMessageBox("Could not connect to the Pangolin service. Please try again or contact your administrator.", "Pangolin")
+			}
+			return
+		}
 
 		logger.Info("Connected to manager service via IPC")
 		// Fall through to run UI
 	} else {
 		// No arguments - normal entry when user double-clicks the .exe.
+		// If the tray is already up in this session, just bring up its menu.
+		if releaseInstance, err := acquireSingleInstance(singleInstanceMutexName); err == errAlreadyRunning {
+			if err := ui.SignalRunningInstance(); err != nil {
+				logger.Error("Failed to signal the running UI: %v", err)
+			}
+			return
+		} else if err == nil {
+			// Don't hold the mutex, or the UI the manager launches would see it as taken
+			releaseInstance()
+		}
+
 		// Try the named pipe first so standard users never need SCM or UAC when the manager is running.
 		if managers.RequestUILaunch() {
 			return
@@ -192,15 +242,18 @@ func main() {
 			if err != nil {
 				if err == windows.ERROR_ACCESS_DENIED {
 					logger.Info("Need admin privileges to start service, requesting elevation...")
-					err = elevate.ShellExecute("cmd.exe", fmt.Sprintf("/c net start \"%s\"", serviceName), "", windows.SW_HIDE)
-					if err != nil && err != windows.ERROR_CANCELLED {
-						logger.Fatal("Failed to start manager service (access denied): %v\nPlease start the service manually or run as administrator.", err)
-					}
+					// net start returns once the service is running or has failed to start
+					stdout, stderr, exitCode, err := elevate.RunElevatedCaptured(elevate.SystemProgram("net.exe"), fmt.Sprintf("start \"%s\"", serviceName), "")
 					if err == windows.ERROR_CANCELLED {
 						logger.Info("User cancelled elevation, cannot start service")
 						return
 					}
-					time.Sleep(2 * time.Second)
+					if err != nil {
+						logger.Fatal("Failed to start manager service (access denied): %v\nPlease start the service manually or run as administrator.", err)
+					}
+					if exitCode != 0 {
+						logger.Warn("Elevated service start exited with code %d: %s", exitCode, strings.TrimSpace(stderr+stdout))
+					}
 					status, err = service.Query()
 					if err != nil {
 						logger.Fatal("Failed to query service status after start: %v", err)
@@ -242,12 +295,17 @@ func main() {
 	configManager := config.NewConfigManager()
 	secretManager := secrets.NewSecretManager()
 
+	// Apply the proxy, log level and request logging before the first API request
+	preferences.ApplyProxySettings(configManager)
+	preferences.ApplyLogLevel(configManager)
+	ui.ApplyRequestLogging(configManager)
+
+	// PANGOLIN_HOSTNAME overrides the active account's server, e.g. for testing against staging
 	var hostname string
 	if activeAccount, _ := accountManager.ActiveAccount(); activeAccount != nil {
 		hostname = activeAccount.Hostname
-	} else {
-		hostname = config.DefaultHostname
 	}
+	hostname = config.ResolveHostname(hostname)
 
 	apiClient := api.NewAPIClient(hostname, "")
 	authManager := auth.NewAuthManager(apiClient, configManager, accountManager, secretManager)
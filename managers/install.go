@@ -11,7 +11,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fosrl/newt/logger"
 	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/tunnel"
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
@@ -215,12 +217,18 @@ func UninstallTunnel(name string) error {
 	service, err := m.OpenService(serviceName)
 	if err != nil {
 		if err == windows.ERROR_SERVICE_DOES_NOT_EXIST {
+			removeLeftoverKillSwitch()
 			return nil // Already uninstalled
 		}
 		return err
 	}
 	defer service.Close()
 
+	// A running tunnel lifts its own kill switch as it stops; one that crashed left it behind
+	if status, err := service.Query(); err == nil && status.State == svc.Stopped {
+		removeLeftoverKillSwitch()
+	}
+
 	service.Control(svc.Stop)
 	err = service.Delete()
 	if err != nil && err != windows.ERROR_SERVICE_MARKED_FOR_DELETE {
@@ -235,6 +243,14 @@ func UninstallTunnel(name string) error {
 	return nil
 }
 
+// removeLeftoverKillSwitch lifts the kill switch of a tunnel service that
+// is no longer running, so disconnecting always restores network access
+func removeLeftoverKillSwitch() {
+	if err := tunnel.RemoveKillSwitchFilters(); err != nil {
+		logger.Error("Failed to remove a leftover kill switch: %v", err)
+	}
+}
+
 // sanitizeServiceName removes invalid characters from service name
 func sanitizeServiceName(name string) string {
 	// Windows service names can only contain: letters, numbers, and: -_()[]{}
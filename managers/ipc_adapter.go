@@ -2,7 +2,11 @@
 
 package managers
 
-import "github.com/fosrl/windows/tunnel"
+import (
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+)
 
 // IPCAdapter implements tunnel.IPCClient interface to avoid circular dependencies
 type IPCAdapter struct{}
@@ -22,6 +26,27 @@ func (a *IPCAdapter) StopTunnel() error {
 	return IPCClientStopTunnel()
 }
 
+// TunnelStatus returns the current tunnel state from the manager
+func (a *IPCAdapter) TunnelStatus() (tunnel.State, error) {
+	state, err := IPCClientTunnelStatus()
+	return tunnel.State(state), err
+}
+
+// PauseTunnel has the manager disconnect the tunnel and reconnect it after d
+func (a *IPCAdapter) PauseTunnel(d time.Duration) error {
+	return IPCClientPauseTunnel(d)
+}
+
+// CancelPause stops the manager from reconnecting a paused tunnel
+func (a *IPCAdapter) CancelPause() error {
+	return IPCClientCancelPause()
+}
+
+// PauseStatus returns the manager's pause state
+func (a *IPCAdapter) PauseStatus() (tunnel.PauseStatus, error) {
+	return IPCClientPauseStatus()
+}
+
 // RegisterStateChangeCallback registers a callback for tunnel state changes
 // Returns an unregister function
 func (a *IPCAdapter) RegisterStateChangeCallback(cb func(tunnel.State)) func() {
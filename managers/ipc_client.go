@@ -5,11 +5,17 @@ package managers
 import (
 	"encoding/gob"
 	"errors"
+	"io"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
 	"github.com/fosrl/windows/tunnel"
 	"github.com/fosrl/windows/updater"
+	"golang.org/x/sys/windows"
 )
 
 // TunnelConfig is exported for use in UI
@@ -22,6 +28,8 @@ const (
 	UpdateFoundNotificationType
 	UpdateProgressNotificationType
 	TunnelStateChangeNotificationType
+	LogLineNotificationType
+	StatsNotificationType
 )
 
 type MethodType int
@@ -33,104 +41,158 @@ const (
 	StartTunnelMethodType
 	StopTunnelMethodType
 	StopAllTunnelsMethodType
+	TunnelStatusMethodType
+	UpdateChannelMethodType
+	SetUpdateChannelMethodType
+	CheckForUpdateMethodType
+	CancelUpdateMethodType
+	UpdateInfoMethodType
+	InstallFromFileMethodType
+	PingMethodType
+	GetRecentLogsMethodType
+	SetProxyMethodType
+	SetLogLevelMethodType
+	PauseTunnelMethodType
+	CancelPauseMethodType
+	PauseStatusMethodType
+	SetSiteConnectedMethodType
+	DisconnectedSitesMethodType
 )
 
+var errIPCNotConnected = errors.New("not connected to manager service")
+
+// ErrIPCTimeout is returned by the IPCClient calls when the manager doesn't
+// answer in time, usually because it is hung or busy
+var ErrIPCTimeout = errors.New("manager service is not responding")
+
+// ErrPingTimeout is returned by IPCClientPing when the manager doesn't answer in time
+var ErrPingTimeout = ErrIPCTimeout
+
+// IsManagerUnreachable reports whether err from an IPCClient call means the
+// manager service couldn't be reached at all, as opposed to the manager
+// answering with an error of its own
+func IsManagerUnreachable(err error) bool {
+	return errors.Is(err, ErrIPCTimeout) || errors.Is(err, ErrIPCReconnecting) ||
+		errors.Is(err, errIPCNotConnected) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, os.ErrClosed) ||
+		errors.Is(err, windows.ERROR_BROKEN_PIPE) || errors.Is(err, windows.ERROR_NO_DATA)
+}
+
+// IPCCallTimeout bounds how long an IPC call waits for the manager before
+// failing with ErrIPCTimeout. Calls that make the manager do real work, such as
+// starting or stopping a tunnel or checking for an update, wait up to
+// IPCSlowCallTimeout instead.
+var (
+	IPCCallTimeout     = 10 * time.Second
+	IPCSlowCallTimeout = 90 * time.Second
+)
+
+// pingTimeout bounds how long IPCClientPing waits for the manager
+var pingTimeout = 5 * time.Second
+
+// PingResult is the manager's answer to a ping
+type PingResult struct {
+	Uptime      time.Duration
+	TunnelState TunnelState
+}
+
+// pingPending is set while a ping is still waiting for the manager, so a hung
+// manager doesn't pile up blocked pings
+var pingPending uint32
+
 var (
 	rpcEncoder *gob.Encoder
 	rpcDecoder *gob.Decoder
 	rpcMutex   sync.Mutex
+
+	// The pipes behind the encoders, closed when the connection is replaced
+	rpcReader, rpcWriter, rpcEvents *os.File
+	// rpcReconnecting is set while reconnectIPCClient is at work
+	rpcReconnecting bool
 )
 
+// managerStopping is set once the manager says it is stopping, so the UI
+// doesn't try to reconnect as it exits
+var managerStopping atomic.Bool
+
 type ManagerStoppingCallback struct {
-	cb func()
+	registration *notificationCallback
 }
 
-var managerStoppingCallbacks = make(map[*ManagerStoppingCallback]bool)
-
 type UpdateFoundCallback struct {
-	cb func(updateState UpdateState)
+	registration *notificationCallback
 }
 
-var updateFoundCallbacks = make(map[*UpdateFoundCallback]bool)
-
 type UpdateProgressCallback struct {
-	cb func(dp updater.DownloadProgress)
+	registration *notificationCallback
 }
 
-var updateProgressCallbacks = make(map[*UpdateProgressCallback]bool)
-
 type TunnelStateChangeCallback struct {
-	cb func(state TunnelState)
+	registration *notificationCallback
+}
+
+type LogLineCallback struct {
+	registration *notificationCallback
 }
 
-var tunnelStateChangeCallbacks = make(map[*TunnelStateChangeCallback]bool)
+type StatsCallback struct {
+	registration *notificationCallback
+}
 
 func InitializeIPCClient(reader, writer, events *os.File) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+	connectIPCClient(reader, writer, events)
+}
+
+// connectIPCClient makes reader, writer and events the connection to the
+// manager. Notifications are read once IPCClientHandshake has told how the
+// manager sends them. Caller must hold rpcMutex.
+func connectIPCClient(reader, writer, events *os.File) {
+	rpcReader, rpcWriter, rpcEvents = reader, writer, events
 	rpcDecoder = gob.NewDecoder(reader)
 	rpcEncoder = gob.NewEncoder(writer)
-	go func() {
-		decoder := gob.NewDecoder(events)
-		for {
-			var notificationType NotificationType
-			err := decoder.Decode(&notificationType)
-			if err != nil {
-				return
-			}
-			switch notificationType {
-			case ManagerStoppingNotificationType:
-				for cb := range managerStoppingCallbacks {
-					cb.cb()
-				}
-			case UpdateFoundNotificationType:
-				var state UpdateState
-				err = decoder.Decode(&state)
-				if err != nil {
-					continue
-				}
-				for cb := range updateFoundCallbacks {
-					cb.cb(state)
-				}
-			case UpdateProgressNotificationType:
-				var dp updater.DownloadProgress
-				err = decoder.Decode(&dp.Activity)
-				if err != nil {
-					continue
-				}
-				err = decoder.Decode(&dp.BytesDownloaded)
-				if err != nil {
-					continue
-				}
-				err = decoder.Decode(&dp.BytesTotal)
-				if err != nil {
-					continue
-				}
-				var errStr string
-				err = decoder.Decode(&errStr)
-				if err != nil {
-					continue
-				}
-				if len(errStr) > 0 {
-					dp.Error = errors.New(errStr)
-				}
-				err = decoder.Decode(&dp.Complete)
-				if err != nil {
-					continue
-				}
-				for cb := range updateProgressCallbacks {
-					cb.cb(dp)
-				}
-			case TunnelStateChangeNotificationType:
-				var state TunnelState
-				err = decoder.Decode(&state)
-				if err != nil {
-					continue
-				}
-				for cb := range tunnelStateChangeCallbacks {
-					cb.cb(state)
-				}
-			}
+}
+
+// closeIPCFiles closes the pipes of the connection to the manager. Caller must
+// hold rpcMutex.
+func closeIPCFiles() {
+	for _, f := range []*os.File{rpcReader, rpcWriter, rpcEvents} {
+		if f != nil {
+			f.Close()
+		}
+	}
+	rpcReader, rpcWriter, rpcEvents = nil, nil, nil
+}
+
+// readIPCEvents dispatches notifications from events, framed or not, to the
+// registered callbacks. When the pipe breaks without the manager saying it is
+// stopping, the manager went away, and the client starts reconnecting. So does
+// a notification that can't be read, since the stream is out of step after it.
+func readIPCEvents(events *os.File, framed bool) {
+	defer func() {
+		rpcMutex.Lock()
+		current := rpcEvents == events && !rpcReconnecting
+		rpcMutex.Unlock()
+		if current && !managerStopping.Load() {
+			go reconnectIPCClient()
 		}
 	}()
+
+	decoder := gob.NewDecoder(events)
+	for {
+		notificationType, payload, err := readNotification(decoder, framed)
+		if err != nil {
+			if !IsManagerUnreachable(err) {
+				logger.Error("Failed to read notification from manager service: %v", err)
+			}
+			return
+		}
+		if notificationType == ManagerStoppingNotificationType {
+			managerStopping.Store(true)
+		}
+		dispatchNotification(notificationType, payload)
+	}
 }
 
 func rpcDecodeError() error {
@@ -145,37 +207,106 @@ func rpcDecodeError() error {
 	return errors.New(str)
 }
 
-func IPCClientQuit(stopTunnelsOnQuit bool) (alreadyQuit bool, err error) {
-	rpcMutex.Lock()
-	defer rpcMutex.Unlock()
-
-	err = rpcEncoder.Encode(QuitMethodType)
-	if err != nil {
-		return
+// rpcCall runs call, which makes one request and reads its reply, with the RPC
+// stream to itself, and waits up to timeout for it. The pipes to the manager are
+// anonymous, so they can't take deadlines; instead, on timeout the exchange is
+// left to finish in the background, keeping the stream in step for the next
+// call, and ErrIPCTimeout is returned. A call still waiting for the stream when
+// the caller gives up is never sent, so a timed-out request doesn't take effect
+// behind the caller's back once the manager recovers.
+func rpcCall[T any](timeout time.Duration, call func() (T, error)) (T, error) {
+	type rpcReply struct {
+		value T
+		err   error
 	}
-	err = rpcEncoder.Encode(stopTunnelsOnQuit)
-	if err != nil {
-		return
+	done := make(chan rpcReply, 1)
+	var abandoned atomic.Bool
+	go func() {
+		rpcMutex.Lock()
+		defer rpcMutex.Unlock()
+
+		if abandoned.Load() {
+			return
+		}
+		if rpcReconnecting {
+			done <- rpcReply{err: ErrIPCReconnecting}
+			return
+		}
+		if rpcEncoder == nil || rpcDecoder == nil {
+			done <- rpcReply{err: errIPCNotConnected}
+			return
+		}
+		value, err := call()
+		done <- rpcReply{value, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-done:
+		return reply.value, reply.err
+	case <-timer.C:
+		abandoned.Store(true)
+		var zero T
+		return zero, ErrIPCTimeout
 	}
-	err = rpcDecoder.Decode(&alreadyQuit)
-	if err != nil {
+}
+
+// rpcCallError is rpcCall for calls whose only reply is an error
+func rpcCallError(timeout time.Duration, call func() error) error {
+	_, err := rpcCall(timeout, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+	return err
+}
+
+func IPCClientQuit(stopTunnelsOnQuit bool) (alreadyQuit bool, err error) {
+	return rpcCall(IPCSlowCallTimeout, func() (alreadyQuit bool, err error) {
+		err = rpcEncoder.Encode(QuitMethodType)
+		if err != nil {
+			return
+		}
+		err = rpcEncoder.Encode(stopTunnelsOnQuit)
+		if err != nil {
+			return
+		}
+		err = rpcDecoder.Decode(&alreadyQuit)
+		if err != nil {
+			return
+		}
+		err = rpcDecodeError()
 		return
-	}
-	err = rpcDecodeError()
-	return
+	})
 }
 
 func IPCClientUpdateState() (updateState UpdateState, err error) {
-	rpcMutex.Lock()
-	defer rpcMutex.Unlock()
-
-	err = rpcEncoder.Encode(UpdateStateMethodType)
-	if err != nil {
+	return rpcCall(IPCCallTimeout, func() (updateState UpdateState, err error) {
+		err = rpcEncoder.Encode(UpdateStateMethodType)
+		if err != nil {
+			return
+		}
+		err = rpcDecoder.Decode(&updateState)
 		return
-	}
-	err = rpcDecoder.Decode(&updateState)
-	if err != nil {
+	})
+}
+
+// IPCClientCheckForUpdate asks the manager to check for an update right away,
+// regardless of whether automatic checks are enabled
+func IPCClientCheckForUpdate() (updateState UpdateState, err error) {
+	updateState, err = rpcCall(IPCSlowCallTimeout, func() (updateState UpdateState, err error) {
+		err = rpcEncoder.Encode(CheckForUpdateMethodType)
+		if err != nil {
+			return
+		}
+		err = rpcDecoder.Decode(&updateState)
+		if err != nil {
+			return
+		}
+		err = rpcDecodeError()
 		return
+	})
+	if errors.Is(err, errIPCNotConnected) || errors.Is(err, ErrIPCTimeout) {
+		updateState = UpdateStateUnknown
 	}
 	return
 }
@@ -185,88 +316,396 @@ func IPCClientUpdate() error {
 	// Ignore errors from StopTunnel as it's safe to call even if no tunnel is running
 	_ = IPCClientStopTunnel()
 
-	rpcMutex.Lock()
-	defer rpcMutex.Unlock()
+	// The manager doesn't reply; it goes on to install the update
+	return rpcCallError(IPCCallTimeout, func() error {
+		return rpcEncoder.Encode(UpdateMethodType)
+	})
+}
+
+// IPCClientPing checks that the manager is alive and responsive, returning
+// ErrPingTimeout if it doesn't answer within pingTimeout
+func IPCClientPing() (PingResult, error) {
+	if !atomic.CompareAndSwapUint32(&pingPending, 0, 1) {
+		return PingResult{}, ErrPingTimeout
+	}
+
+	type pingReply struct {
+		result PingResult
+		err    error
+	}
+	done := make(chan pingReply, 1)
+	go func() {
+		// Cleared only once the manager answers; the ping may outlive the timeout
+		defer atomic.StoreUint32(&pingPending, 0)
+
+		rpcMutex.Lock()
+		defer rpcMutex.Unlock()
+
+		if rpcReconnecting {
+			done <- pingReply{err: ErrIPCReconnecting}
+			return
+		}
+		if rpcEncoder == nil || rpcDecoder == nil {
+			done <- pingReply{err: errIPCNotConnected}
+			return
+		}
+
+		var reply pingReply
+		reply.err = rpcEncoder.Encode(PingMethodType)
+		if reply.err == nil {
+			reply.err = rpcDecoder.Decode(&reply.result)
+		}
+		done <- reply
+	}()
+
+	select {
+	case reply := <-done:
+		return reply.result, reply.err
+	case <-time.After(pingTimeout):
+		return PingResult{}, ErrPingTimeout
+	}
+}
+
+// IPCClientInstallFromFile asks the manager to verify and install an update from a
+// local MSI. Progress is reported through the update progress callbacks.
+func IPCClientInstallFromFile(path string) error {
+	// Stop any running tunnel first, as for an online update
+	_ = IPCClientStopTunnel()
+
+	return rpcCallError(IPCSlowCallTimeout, func() error {
+		err := rpcEncoder.Encode(InstallFromFileMethodType)
+		if err != nil {
+			return err
+		}
+		err = rpcEncoder.Encode(path)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
 
-	return rpcEncoder.Encode(UpdateMethodType)
+// IPCClientUpdateInfo returns details, such as release notes, of the update the manager found
+func IPCClientUpdateInfo() (info UpdateInfo, err error) {
+	return rpcCall(IPCCallTimeout, func() (info UpdateInfo, err error) {
+		err = rpcEncoder.Encode(UpdateInfoMethodType)
+		if err != nil {
+			return
+		}
+		err = rpcDecoder.Decode(&info)
+		return
+	})
+}
+
+func IPCClientCancelUpdate() error {
+	return rpcCallError(IPCCallTimeout, func() error {
+		err := rpcEncoder.Encode(CancelUpdateMethodType)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
 }
 
 func IPCClientRegisterManagerStopping(cb func()) *ManagerStoppingCallback {
-	s := &ManagerStoppingCallback{cb}
-	managerStoppingCallbacks[s] = true
-	return s
+	return &ManagerStoppingCallback{registerNotification(ManagerStoppingNotificationType, func(struct{}) {
+		cb()
+	})}
 }
 
 func (cb *ManagerStoppingCallback) Unregister() {
-	delete(managerStoppingCallbacks, cb)
+	cb.registration.unregister()
 }
 
 func IPCClientRegisterUpdateFound(cb func(updateState UpdateState)) *UpdateFoundCallback {
-	s := &UpdateFoundCallback{cb}
-	updateFoundCallbacks[s] = true
-	return s
+	return &UpdateFoundCallback{registerNotification(UpdateFoundNotificationType, cb)}
 }
 
 func (cb *UpdateFoundCallback) Unregister() {
-	delete(updateFoundCallbacks, cb)
+	cb.registration.unregister()
 }
 
 func IPCClientRegisterUpdateProgress(cb func(dp updater.DownloadProgress)) *UpdateProgressCallback {
-	s := &UpdateProgressCallback{cb}
-	updateProgressCallbacks[s] = true
-	return s
+	return &UpdateProgressCallback{registerNotification(UpdateProgressNotificationType, cb)}
 }
 
 func (cb *UpdateProgressCallback) Unregister() {
-	delete(updateProgressCallbacks, cb)
+	cb.registration.unregister()
 }
 
 func IPCClientStartTunnel(config TunnelConfig) error {
-	rpcMutex.Lock()
-	defer rpcMutex.Unlock()
-
-	err := rpcEncoder.Encode(StartTunnelMethodType)
-	if err != nil {
-		return err
-	}
-	err = rpcEncoder.Encode(config)
-	if err != nil {
-		return err
-	}
-	err = rpcDecodeError()
-	return err
+	return rpcCallError(IPCSlowCallTimeout, func() error {
+		err := rpcEncoder.Encode(StartTunnelMethodType)
+		if err != nil {
+			return err
+		}
+		err = rpcEncoder.Encode(config)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
 }
 
 func IPCClientStopTunnel() error {
-	rpcMutex.Lock()
-	defer rpcMutex.Unlock()
+	return rpcCallError(IPCSlowCallTimeout, func() error {
+		err := rpcEncoder.Encode(StopTunnelMethodType)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
+
+func IPCClientStopAllTunnels() error {
+	return rpcCallError(IPCSlowCallTimeout, func() error {
+		err := rpcEncoder.Encode(StopAllTunnelsMethodType)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
 
-	err := rpcEncoder.Encode(StopTunnelMethodType)
+// IPCClientTunnelStatus returns the manager's current tunnel state.
+// If the UI is not connected to a manager, or it doesn't answer, it reports
+// TunnelStateStopped along with an error.
+func IPCClientTunnelStatus() (TunnelState, error) {
+	state, err := rpcCall(IPCCallTimeout, func() (state TunnelState, err error) {
+		err = rpcEncoder.Encode(TunnelStatusMethodType)
+		if err != nil {
+			return
+		}
+		err = rpcDecoder.Decode(&state)
+		return
+	})
 	if err != nil {
-		return err
+		return TunnelStateStopped, err
 	}
-	err = rpcDecodeError()
-	return err
+	return state, nil
 }
 
-func IPCClientStopAllTunnels() error {
-	rpcMutex.Lock()
-	defer rpcMutex.Unlock()
+// IPCClientGetRecentLogs returns up to n of the most recent lines logged by the
+// manager service, oldest first. n is bounded to the manager's buffer size, and
+// n <= 0 returns the whole buffer.
+func IPCClientGetRecentLogs(n int) ([]string, error) {
+	return rpcCall(IPCCallTimeout, func() ([]string, error) {
+		// An older manager hangs up on a method it doesn't know
+		if !managerSupports(Version{Major: 1, Minor: 1}) {
+			return nil, errMethodNotSupported
+		}
 
-	err := rpcEncoder.Encode(StopAllTunnelsMethodType)
-	if err != nil {
-		return err
+		err := rpcEncoder.Encode(GetRecentLogsMethodType)
+		if err != nil {
+			return nil, err
+		}
+		err = rpcEncoder.Encode(n)
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		err = rpcDecoder.Decode(&lines)
+		if err != nil {
+			return nil, err
+		}
+		return lines, nil
+	})
+}
+
+func IPCClientUpdateChannel() (channel config.UpdateChannel, err error) {
+	channel, err = rpcCall(IPCCallTimeout, func() (channel config.UpdateChannel, err error) {
+		err = rpcEncoder.Encode(UpdateChannelMethodType)
+		if err != nil {
+			return
+		}
+		err = rpcDecoder.Decode(&channel)
+		return
+	})
+	if errors.Is(err, errIPCNotConnected) || errors.Is(err, ErrIPCTimeout) {
+		channel = config.DefaultUpdateChannel
 	}
-	err = rpcDecodeError()
-	return err
+	return
+}
+
+func IPCClientSetUpdateChannel(channel config.UpdateChannel) error {
+	return rpcCallError(IPCCallTimeout, func() error {
+		err := rpcEncoder.Encode(SetUpdateChannelMethodType)
+		if err != nil {
+			return err
+		}
+		err = rpcEncoder.Encode(channel)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
+
+// IPCClientSetProxy hands the UI's proxy settings to the manager, which uses
+// them for update checks and downloads until it restarts
+func IPCClientSetProxy(settings config.ProxySettings) error {
+	return rpcCallError(IPCCallTimeout, func() error {
+		// An older manager hangs up on a method it doesn't know
+		if !managerSupports(Version{Major: 1, Minor: 2}) {
+			return errMethodNotSupported
+		}
+
+		err := rpcEncoder.Encode(SetProxyMethodType)
+		if err != nil {
+			return err
+		}
+		err = rpcEncoder.Encode(settings)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
+
+// IPCClientSetLogLevel has the manager log at level, one of config.LogLevels,
+// until it restarts
+func IPCClientSetLogLevel(level string) error {
+	return rpcCallError(IPCCallTimeout, func() error {
+		// An older manager hangs up on a method it doesn't know
+		if !managerSupports(Version{Major: 1, Minor: 4}) {
+			return errMethodNotSupported
+		}
+
+		err := rpcEncoder.Encode(SetLogLevelMethodType)
+		if err != nil {
+			return err
+		}
+		err = rpcEncoder.Encode(level)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
+
+// IPCClientPauseTunnel has the manager disconnect the tunnel and connect it
+// again with the same settings after d
+func IPCClientPauseTunnel(d time.Duration) error {
+	return rpcCallError(IPCSlowCallTimeout, func() error {
+		// An older manager hangs up on a method it doesn't know
+		if !managerSupports(Version{Major: 1, Minor: 6}) {
+			return errMethodNotSupported
+		}
+
+		err := rpcEncoder.Encode(PauseTunnelMethodType)
+		if err != nil {
+			return err
+		}
+		err = rpcEncoder.Encode(d)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
+
+// IPCClientCancelPause stops the manager from reconnecting a paused tunnel
+func IPCClientCancelPause() error {
+	return rpcCallError(IPCCallTimeout, func() error {
+		if !managerSupports(Version{Major: 1, Minor: 6}) {
+			return errMethodNotSupported
+		}
+
+		err := rpcEncoder.Encode(CancelPauseMethodType)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
+
+// IPCClientPauseStatus returns whether the tunnel is paused and when the
+// manager reconnects it
+func IPCClientPauseStatus() (tunnel.PauseStatus, error) {
+	return rpcCall(IPCCallTimeout, func() (status tunnel.PauseStatus, err error) {
+		if !managerSupports(Version{Major: 1, Minor: 6}) {
+			return status, errMethodNotSupported
+		}
+
+		err = rpcEncoder.Encode(PauseStatusMethodType)
+		if err != nil {
+			return
+		}
+		err = rpcDecoder.Decode(&status)
+		return
+	})
+}
+
+// IPCClientSetSiteConnected connects or disconnects a single site of the running tunnel
+func IPCClientSetSiteConnected(siteID int, connected bool) error {
+	return rpcCallError(IPCSlowCallTimeout, func() error {
+		// An older manager hangs up on a method it doesn't know
+		if !managerSupports(Version{Major: 1, Minor: 7}) {
+			return errMethodNotSupported
+		}
+
+		err := rpcEncoder.Encode(SetSiteConnectedMethodType)
+		if err != nil {
+			return err
+		}
+		err = rpcEncoder.Encode(siteID)
+		if err != nil {
+			return err
+		}
+		err = rpcEncoder.Encode(connected)
+		if err != nil {
+			return err
+		}
+		return rpcDecodeError()
+	})
+}
+
+// IPCClientDisconnectedSites returns the sites disconnected from the running
+// tunnel with IPCClientSetSiteConnected
+func IPCClientDisconnectedSites() ([]int, error) {
+	return rpcCall(IPCCallTimeout, func() ([]int, error) {
+		if !managerSupports(Version{Major: 1, Minor: 7}) {
+			return nil, errMethodNotSupported
+		}
+
+		err := rpcEncoder.Encode(DisconnectedSitesMethodType)
+		if err != nil {
+			return nil, err
+		}
+		var sites []int
+		err = rpcDecoder.Decode(&sites)
+		if err != nil {
+			return nil, err
+		}
+		return sites, nil
+	})
 }
 
 func IPCClientRegisterTunnelStateChange(cb func(state TunnelState)) *TunnelStateChangeCallback {
-	s := &TunnelStateChangeCallback{cb}
-	tunnelStateChangeCallbacks[s] = true
-	return s
+	return &TunnelStateChangeCallback{registerNotification(TunnelStateChangeNotificationType, cb)}
 }
 
 func (cb *TunnelStateChangeCallback) Unregister() {
-	delete(tunnelStateChangeCallbacks, cb)
+	cb.registration.unregister()
+}
+
+// IPCClientRegisterLogLine registers cb for log lines streamed from now on. Use
+// IPCClientGetRecentLogs for the lines logged before.
+func IPCClientRegisterLogLine(cb func(line string)) *LogLineCallback {
+	return &LogLineCallback{registerNotification(LogLineNotificationType, cb)}
+}
+
+func (cb *LogLineCallback) Unregister() {
+	cb.registration.unregister()
+}
+
+// IPCClientRegisterStats registers cb for the stats snapshots the manager
+// pushes every few seconds while a tunnel is up. A manager older than protocol
+// 1.3 sends none.
+func IPCClientRegisterStats(cb func(snapshot StatsSnapshot)) *StatsCallback {
+	return &StatsCallback{registerNotification(StatsNotificationType, cb)}
+}
+
+func (cb *StatsCallback) Unregister() {
+	cb.registration.unregister()
 }
@@ -0,0 +1,158 @@
+//go:build windows
+
+package managers
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// useCallTimeout shortens IPCCallTimeout until the test ends
+func useCallTimeout(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	saved := IPCCallTimeout
+	IPCCallTimeout = timeout
+	t.Cleanup(func() { IPCCallTimeout = saved })
+}
+
+// A manager that takes the request but never answers times the call out
+func TestCallTimesOutOnStalledManager(t *testing.T) {
+	useCallTimeout(t, 50*time.Millisecond)
+	server, client := net.Pipe()
+	go io.Copy(io.Discard, server)
+	connectRPC(t, client)
+
+	start := time.Now()
+	state, err := IPCClientTunnelStatus()
+	if !errors.Is(err, ErrIPCTimeout) {
+		t.Fatalf("IPCClientTunnelStatus() error = %v, want ErrIPCTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the call took %v to give up", elapsed)
+	}
+	if state != TunnelStateStopped {
+		t.Errorf("IPCClientTunnelStatus() = %v, want %v", state, TunnelStateStopped)
+	}
+	if !IsManagerUnreachable(err) {
+		t.Error("a timeout isn't reported as the manager being unreachable")
+	}
+}
+
+// The reply to a timed-out call is read in the background, so the next call
+// gets its own reply rather than the late one
+func TestTimedOutCallKeepsStreamInStep(t *testing.T) {
+	useCallTimeout(t, 50*time.Millisecond)
+	server, client := net.Pipe()
+	connectRPC(t, client)
+
+	release := make(chan struct{})
+	go func() {
+		decoder, encoder := gob.NewDecoder(server), gob.NewEncoder(server)
+		for _, reply := range []TunnelState{TunnelStateStarting, TunnelStateRunning} {
+			var method MethodType
+			if err := decoder.Decode(&method); err != nil {
+				return
+			}
+			if reply == TunnelStateStarting {
+				<-release
+			}
+			if err := encoder.Encode(reply); err != nil {
+				return
+			}
+		}
+	}()
+
+	if _, err := IPCClientTunnelStatus(); !errors.Is(err, ErrIPCTimeout) {
+		t.Fatalf("first IPCClientTunnelStatus() error = %v, want ErrIPCTimeout", err)
+	}
+	close(release)
+
+	useCallTimeout(t, 5*time.Second)
+	state, err := IPCClientTunnelStatus()
+	if err != nil {
+		t.Fatalf("second IPCClientTunnelStatus() error = %v", err)
+	}
+	if state != TunnelStateRunning {
+		t.Errorf("second IPCClientTunnelStatus() = %v, want %v, not the late reply", state, TunnelStateRunning)
+	}
+}
+
+// A call still waiting for the stream when it times out is never sent
+func TestTimedOutCallIsNotSentLater(t *testing.T) {
+	useCallTimeout(t, 50*time.Millisecond)
+	server, client := net.Pipe()
+	connectRPC(t, client)
+
+	methods := make(chan MethodType, 4)
+	go func() {
+		decoder, encoder := gob.NewDecoder(server), gob.NewEncoder(server)
+		for {
+			var method MethodType
+			if err := decoder.Decode(&method); err != nil {
+				return
+			}
+			methods <- method
+			if method == TunnelStatusMethodType {
+				encoder.Encode(TunnelStateRunning)
+			}
+		}
+	}()
+
+	// Another call holds the stream until after this one gives up
+	rpcMutex.Lock()
+	_, err := IPCClientUpdateState()
+	rpcMutex.Unlock()
+	if !errors.Is(err, ErrIPCTimeout) {
+		t.Fatalf("IPCClientUpdateState() error = %v, want ErrIPCTimeout", err)
+	}
+
+	useCallTimeout(t, 5*time.Second)
+	if _, err := IPCClientTunnelStatus(); err != nil {
+		t.Fatalf("IPCClientTunnelStatus() error = %v", err)
+	}
+	if method := <-methods; method != TunnelStatusMethodType {
+		t.Errorf("the manager first received method %v, want only the call that was still waited for", method)
+	}
+}
+
+func TestCallWithoutConnection(t *testing.T) {
+	if _, err := IPCClientTunnelStatus(); !errors.Is(err, errIPCNotConnected) {
+		t.Errorf("IPCClientTunnelStatus() error = %v, want errIPCNotConnected", err)
+	}
+}
+
+func TestIsManagerUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "timeout", err: ErrIPCTimeout, want: true},
+		{name: "reconnecting", err: ErrIPCReconnecting, want: true},
+		{name: "not connected", err: errIPCNotConnected, want: true},
+		{name: "pipe closed by the manager", err: io.EOF, want: true},
+		{name: "reply cut short", err: io.ErrUnexpectedEOF, want: true},
+		{name: "pipe closed by the UI", err: os.ErrClosed, want: true},
+		{name: "broken pipe", err: &os.PathError{Op: "write", Path: "pipe", Err: windows.ERROR_BROKEN_PIPE}, want: true},
+		{name: "pipe being closed", err: &os.PathError{Op: "write", Path: "pipe", Err: windows.ERROR_NO_DATA}, want: true},
+		{name: "wrapped timeout", err: fmt.Errorf("update check: %w", ErrIPCTimeout), want: true},
+		{name: "error from the manager", err: errors.New("failed to download update manifest: 503 Service Unavailable")},
+		{name: "no error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsManagerUnreachable(tt.err); got != tt.want {
+				t.Errorf("IsManagerUnreachable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+//go:build windows
+
+package managers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/fosrl/newt/logger"
+	"golang.org/x/sys/windows"
+)
+
+// uiReconnectPipePath is where a running UI that lost its IPC connection, for
+// example because the manager crashed and was restarted, asks for new pipes
+const uiReconnectPipePath = `\\.\pipe\pangolin-manager-ui-reconnect`
+
+// ipcReconnectMinBackoff is the wait before the first attempt to reach a
+// restarted manager, shortened in tests
+var ipcReconnectMinBackoff = time.Second
+
+const (
+	// ipcReconnectMaxBackoff bounds the wait between later attempts
+	ipcReconnectMaxBackoff = 30 * time.Second
+
+	// ipcReconnectGiveUpAfter bounds how long a UI keeps trying to reach the
+	// manager before it exits
+	ipcReconnectGiveUpAfter = 10 * time.Minute
+)
+
+// ErrIPCReconnecting is returned by the IPCClient calls while the UI is
+// re-establishing its connection to the manager
+var ErrIPCReconnecting = errors.New("reconnecting to manager service")
+
+// runUIReconnectPipeListener accepts connections on the reconnect pipe until
+// listener is closed. adopt tracks a reconnecting UI process in its session, or
+// fails if it can't be tracked.
+func runUIReconnectPipeListener(listener net.Listener, adopt func(session, pid uint32) error) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go handleUIReconnectConn(conn, adopt)
+	}
+}
+
+// handleUIReconnectConn reads a session ID from the client and, if the client is
+// our own executable in that session and can be tracked with adopt, answers with
+// 0 followed by the handles of a fresh set of IPC pipes duplicated into the
+// client, or with 1 on failure.
+func handleUIReconnectConn(conn net.Conn, adopt func(session, pid uint32) error) {
+	defer conn.Close()
+
+	var sessionID uint32
+	if err := binary.Read(conn, binary.LittleEndian, &sessionID); err != nil {
+		logger.Error("UI reconnect pipe: failed to read session ID: %v", err)
+		return
+	}
+
+	pid, err := authenticatePipeClient(conn, sessionID)
+	if err != nil {
+		logger.Warn("UI reconnect pipe: rejected connection from PID %d: %v", pid, err)
+		return
+	}
+
+	if err := adopt(sessionID, pid); err != nil {
+		logger.Warn("UI reconnect pipe: not reconnecting UI process %d: %v", pid, err)
+		_ = binary.Write(conn, binary.LittleEndian, uint32(1))
+		return
+	}
+
+	handles, err := connectUIProcess(pid, sessionID)
+	if err != nil {
+		logger.Error("UI reconnect pipe: unable to reconnect UI process %d: %v", pid, err)
+		_ = binary.Write(conn, binary.LittleEndian, uint32(1))
+		return
+	}
+	logger.Info("Reconnected UI process %d in session %d", pid, sessionID)
+	if err := binary.Write(conn, binary.LittleEndian, uint32(0)); err != nil {
+		logger.Error("UI reconnect pipe: failed to write response: %v", err)
+		return
+	}
+	if err := binary.Write(conn, binary.LittleEndian, handles); err != nil {
+		logger.Error("UI reconnect pipe: failed to write pipe handles: %v", err)
+	}
+}
+
+// connectUIProcess serves a new IPC connection for the UI process pid, returning
+// the reader, writer and events handles as seen from that process
+func connectUIProcess(pid, sessionID uint32) (handles [3]uint64, err error) {
+	process, err := windows.OpenProcess(windows.PROCESS_DUP_HANDLE, false, pid)
+	if err != nil {
+		return handles, fmt.Errorf("unable to open process: %w", err)
+	}
+	defer windows.CloseHandle(process)
+
+	var ours, theirs [3]*os.File
+	closeAll := func() {
+		for _, f := range append(ours[:], theirs[:]...) {
+			if f != nil {
+				f.Close()
+			}
+		}
+	}
+	// As in startProcess: the UI reads what we write and the other way around
+	ours[0], theirs[1], err = os.Pipe()
+	if err == nil {
+		theirs[0], ours[1], err = os.Pipe()
+	}
+	if err == nil {
+		theirs[2], ours[2], err = os.Pipe()
+	}
+	if err != nil {
+		closeAll()
+		return handles, fmt.Errorf("unable to create pipe: %w", err)
+	}
+
+	for i, f := range theirs {
+		var h windows.Handle
+		err = windows.DuplicateHandle(windows.CurrentProcess(), windows.Handle(f.Fd()), process, &h, 0, false, windows.DUPLICATE_SAME_ACCESS)
+		if err != nil {
+			closeAll()
+			return handles, fmt.Errorf("unable to duplicate pipe handle: %w", err)
+		}
+		handles[i] = uint64(h)
+	}
+	for _, f := range theirs {
+		f.Close()
+	}
+
+	elevatedToken := sessionElevatedToken(sessionID)
+	ipcServerListen(ours[0], ours[1], ours[2], elevatedToken, func() {
+		ours[0].Close()
+		ours[1].Close()
+		ours[2].Close()
+		if elevatedToken != 0 {
+			elevatedToken.Close()
+		}
+	})
+	return handles, nil
+}
+
+// sessionElevatedToken returns the elevated token of the user logged in to
+// sessionID, or 0 if the user isn't running elevated, matching the token
+// startProcess gives the IPC server for a UI it launches
+func sessionElevatedToken(sessionID uint32) windows.Token {
+	var userToken windows.Token
+	if err := windows.WTSQueryUserToken(sessionID, &userToken); err != nil {
+		return 0
+	}
+	if userToken.IsElevated() {
+		return userToken
+	}
+	linkedToken, err := userToken.GetLinkedToken()
+	userToken.Close()
+	if err != nil {
+		return 0
+	}
+	if linkedToken.IsElevated() {
+		return linkedToken
+	}
+	linkedToken.Close()
+	return 0
+}
+
+// requestIPCPipes asks the manager for a fresh set of IPC pipes for this process
+func requestIPCPipes() (reader, writer, events *os.File, err error) {
+	var sessionID uint32
+	if err = windows.ProcessIdToSessionId(windows.GetCurrentProcessId(), &sessionID); err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to get current session ID: %w", err)
+	}
+
+	conn, err := winio.DialPipe(uiReconnectPipePath, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer conn.Close()
+
+	if err = binary.Write(conn, binary.LittleEndian, sessionID); err != nil {
+		return nil, nil, nil, err
+	}
+	var response uint32
+	if err = binary.Read(conn, binary.LittleEndian, &response); err != nil {
+		return nil, nil, nil, err
+	}
+	if response != 0 {
+		return nil, nil, nil, errors.New("manager service refused to reconnect")
+	}
+	var handles [3]uint64
+	if err = binary.Read(conn, binary.LittleEndian, &handles); err != nil {
+		return nil, nil, nil, err
+	}
+	return os.NewFile(uintptr(handles[0]), "reader"),
+		os.NewFile(uintptr(handles[1]), "writer"),
+		os.NewFile(uintptr(handles[2]), "events"), nil
+}
+
+// requestPipes asks the manager for new pipes, replaced in tests
+var requestPipes = requestIPCPipes
+
+// reconnectIPCClient re-establishes the connection to the manager after it
+// dropped, retrying with backoff until the manager answers or
+// ipcReconnectGiveUpAfter has passed. Calls made in the meantime fail with
+// ErrIPCReconnecting. Registered callbacks are kept, so they fire again once
+// the new connection is up, and the tunnel state callbacks are handed the
+// current state since changes while disconnected were missed. If it gives up,
+// the manager stopping callbacks are called, so the UI exits as it does when
+// the manager stops.
+func reconnectIPCClient() {
+	rpcMutex.Lock()
+	rpcReconnecting = true
+	rpcEncoder, rpcDecoder = nil, nil
+	closeIPCFiles()
+	rpcMutex.Unlock()
+
+	logger.Warn("Lost connection to manager service, reconnecting")
+	giveUpAt := time.Now().Add(ipcReconnectGiveUpAfter)
+	backoff := ipcReconnectMinBackoff
+	for {
+		time.Sleep(backoff)
+		err := reconnectIPCClientOnce()
+		if err == nil {
+			break
+		}
+		var versionErr *ProtocolVersionError
+		if errors.As(err, &versionErr) && versionErr.Manager != nil {
+			// The manager was updated under us; the new version's UI takes over
+			logger.Error("Not reconnecting to manager service: %v", err)
+			stopReconnecting()
+			return
+		}
+		if time.Now().After(giveUpAt) {
+			logger.Error("Giving up reconnecting to manager service: %v", err)
+			stopReconnecting()
+			return
+		}
+		logger.Debug("Reconnecting to manager service failed: %v", err)
+		backoff = min(backoff*2, ipcReconnectMaxBackoff)
+	}
+	logger.Info("Reconnected to manager service")
+
+	state, err := IPCClientTunnelStatus()
+	if err != nil {
+		logger.Warn("Failed to get tunnel state after reconnecting: %v", err)
+		return
+	}
+	dispatchNotification(TunnelStateChangeNotificationType, state)
+}
+
+// stopReconnecting leaves the UI disconnected from the manager for good and
+// tells the manager stopping callbacks, which exit the UI
+func stopReconnecting() {
+	rpcMutex.Lock()
+	rpcReconnecting = false
+	rpcMutex.Unlock()
+	managerStopping.Store(true)
+	dispatchNotification(ManagerStoppingNotificationType, struct{}{})
+}
+
+// IPCClientReconnect asks the manager for a new connection through the
+// reconnect pipe and makes the handshake on it, for a UI whose connection was
+// closed by a failed IPCClientHandshake
+func IPCClientReconnect() error {
+	return reconnectIPCClientOnce()
+}
+
+// reconnectIPCClientOnce makes one attempt at reconnecting to the manager
+func reconnectIPCClientOnce() error {
+	reader, writer, events, err := requestPipes()
+	if err != nil {
+		return err
+	}
+
+	rpcMutex.Lock()
+	connectIPCClient(reader, writer, events)
+	rpcMutex.Unlock()
+
+	err = IPCClientHandshake()
+
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+	if err != nil {
+		rpcEncoder, rpcDecoder = nil, nil
+		closeIPCFiles()
+		return err
+	}
+	rpcReconnecting = false
+	return nil
+}
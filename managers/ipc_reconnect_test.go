@@ -0,0 +1,166 @@
+//go:build windows
+
+package managers
+
+import (
+	"encoding/gob"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+// newIPCPipes returns the ends of a set of IPC pipes the UI and the manager
+// hold, as connectUIProcess creates them: ui reads what manager writes and the
+// other way around, and the UI reads events the manager writes
+func newIPCPipes(t *testing.T) (ui, manager [3]*os.File) {
+	t.Helper()
+	var err error
+	manager[0], ui[1], err = os.Pipe()
+	if err == nil {
+		ui[0], manager[1], err = os.Pipe()
+	}
+	if err == nil {
+		ui[2], manager[2], err = os.Pipe()
+	}
+	t.Cleanup(func() {
+		for _, f := range append(ui[:], manager[:]...) {
+			if f != nil {
+				f.Close()
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ui, manager
+}
+
+// serveManager serves a manager service on a fresh set of IPC pipes and
+// returns the UI's ends of them
+func serveManager(t *testing.T) (ui, manager [3]*os.File) {
+	t.Helper()
+	ui, manager = newIPCPipes(t)
+	ipcServerListen(manager[0], manager[1], manager[2], 0, nil)
+	return ui, manager
+}
+
+// useRequestPipes replaces requestPipes, and shortens the backoff, until the
+// test ends
+func useRequestPipes(t *testing.T, request func() (reader, writer, events *os.File, err error)) {
+	t.Helper()
+	savedRequest, savedBackoff := requestPipes, ipcReconnectMinBackoff
+	requestPipes, ipcReconnectMinBackoff = request, time.Millisecond
+	t.Cleanup(func() { requestPipes, ipcReconnectMinBackoff = savedRequest, savedBackoff })
+}
+
+// resetIPCClient drops the UI's connection without reconnecting
+func resetIPCClient(t *testing.T) {
+	t.Helper()
+	savedVersion := managerProtocolVersion
+	t.Cleanup(func() {
+		rpcMutex.Lock()
+		rpcEncoder, rpcDecoder = nil, nil
+		rpcReconnecting = false
+		closeIPCFiles()
+		rpcMutex.Unlock()
+		managerProtocolVersion = savedVersion
+		managerStopping.Store(false)
+	})
+}
+
+func TestReconnectAfterManagerRestart(t *testing.T) {
+	defer tunnel.SetState(tunnel.GetState())
+	tunnel.SetState(TunnelStateRunning)
+
+	states := make(chan TunnelState, 4)
+	cb := IPCClientRegisterTunnelStateChange(func(state TunnelState) { states <- state })
+	defer cb.Unregister()
+
+	first, firstManager := serveManager(t)
+	restarted, _ := serveManager(t)
+	resetIPCClient(t)
+
+	InitializeIPCClient(first[0], first[1], first[2])
+	if err := IPCClientHandshake(); err != nil {
+		t.Fatalf("IPCClientHandshake() error = %v", err)
+	}
+
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	attempts := 0
+	useRequestPipes(t, func() (reader, writer, events *os.File, err error) {
+		attempts++
+		if attempts == 1 {
+			return nil, nil, nil, errors.New("the manager isn't running yet")
+		}
+		close(requested)
+		<-release
+		return restarted[0], restarted[1], restarted[2], nil
+	})
+
+	// The manager goes away without saying it is stopping
+	firstManager[2].Close()
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the UI didn't try to reconnect")
+	}
+	if _, err := IPCClientTunnelStatus(); !errors.Is(err, ErrIPCReconnecting) {
+		t.Errorf("IPCClientTunnelStatus() while reconnecting error = %v, want ErrIPCReconnecting", err)
+	}
+	close(release)
+
+	// The callback registered before the drop is told the current state, then
+	// hears notifications on the new connection
+	for _, want := range []TunnelState{TunnelStateRunning, TunnelStateStopped} {
+		select {
+		case state := <-states:
+			if state != want {
+				t.Errorf("callback got %v, want %v", state, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("callback not called with %v after reconnecting", want)
+		}
+		if want == TunnelStateRunning {
+			IPCServerNotifyTunnelStateChange(TunnelStateStopped)
+		}
+	}
+	if attempts != 2 {
+		t.Errorf("reconnected after %d attempts, want 2", attempts)
+	}
+	if state, err := IPCClientTunnelStatus(); err != nil || state != TunnelStateRunning {
+		t.Errorf("IPCClientTunnelStatus() after reconnecting = %v, %v, want %v", state, err, TunnelStateRunning)
+	}
+}
+
+// A manager that was updated to an incompatible version isn't reconnected to;
+// the UI exits as it does when the manager stops
+func TestReconnectStopsForIncompatibleManager(t *testing.T) {
+	ui, manager := newIPCPipes(t)
+	resetIPCClient(t)
+	go func() {
+		var uiVersion Version
+		if gob.NewDecoder(manager[0]).Decode(&uiVersion) != nil {
+			return
+		}
+		gob.NewEncoder(manager[1]).Encode(Version{Major: ProtocolVersion.Major + 1})
+	}()
+	useRequestPipes(t, func() (reader, writer, events *os.File, err error) {
+		return ui[0], ui[1], ui[2], nil
+	})
+
+	stopping := false
+	cb := IPCClientRegisterManagerStopping(func() { stopping = true })
+	defer cb.Unregister()
+
+	reconnectIPCClient()
+	if !stopping || !managerStopping.Load() {
+		t.Error("the UI wasn't told the manager is stopping")
+	}
+	if _, err := IPCClientTunnelStatus(); !errors.Is(err, errIPCNotConnected) {
+		t.Errorf("IPCClientTunnelStatus() error = %v, want errIPCNotConnected", err)
+	}
+}
@@ -3,8 +3,9 @@
 package managers
 
 import (
-	"bytes"
 	"encoding/gob"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"sync"
@@ -14,6 +15,7 @@ import (
 	"github.com/fosrl/newt/logger"
 	"golang.org/x/sys/windows"
 
+	"github.com/fosrl/windows/config"
 	"github.com/fosrl/windows/tunnel"
 	"github.com/fosrl/windows/updater"
 )
@@ -30,12 +32,65 @@ var (
 	quitManagersChan    = make(chan struct{}, 1)
 	activeTunnels       = make(map[string]bool) // Track active tunnel names
 	activeTunnelsLock   sync.RWMutex
+
+	// notifyQueueLength is how many notifications may wait for a client. A
+	// client that falls further behind is dropped rather than left to pile
+	// up notifications in the service. The events pipes are anonymous pipes,
+	// which take no write deadlines, so this is the only limit on a client
+	// that stops reading.
+	notifyQueueLength = 256
+
+	// managerStartTime is when the manager started, reported by Ping
+	managerStartTime = time.Now()
+
+	// uninstallTunnel tears down a tunnel's service, replaced in tests
+	uninstallTunnel = UninstallTunnel
 )
 
+// quitTunnelTeardownTimeout bounds how long Quit waits for tunnels to stop
+const quitTunnelTeardownTimeout = 30 * time.Second
+
 type ManagerService struct {
-	events        *os.File
+	events        io.WriteCloser
 	eventLock     sync.Mutex
 	elevatedToken windows.Token
+	// clientVersion is the protocol version the UI reported, guarded by eventLock
+	clientVersion Version
+	// queue holds the notifications waiting for writeEvents to send them to
+	// the client, in the order they were sent
+	queue chan *notification
+	// gone is closed when the client is detached, stopping writeEvents
+	gone chan struct{}
+	// detached is set once the client is taken off managerServices, so that
+	// notifications already on their way to it are dropped
+	detached atomic.Bool
+}
+
+func newManagerService(events io.WriteCloser, elevatedToken windows.Token) *ManagerService {
+	return &ManagerService{
+		events:        events,
+		elevatedToken: elevatedToken,
+		queue:         make(chan *notification, notifyQueueLength),
+		gone:          make(chan struct{}),
+	}
+}
+
+// notificationsSince lists the notifications added after protocol 1.0, which
+// are only sent to UIs that understand them. An older UI would read the
+// payload as the next notification type.
+var notificationsSince = map[NotificationType]Version{
+	StatsNotificationType: {Major: 1, Minor: 3},
+}
+
+// wants reports whether the client understands notificationType. Nothing is
+// sent before the handshake, which tells how the client reads notifications.
+// Caller must hold s.eventLock.
+func (s *ManagerService) wants(notificationType NotificationType) bool {
+	if s.clientVersion == (Version{}) {
+		return false
+	}
+	since, ok := notificationsSince[notificationType]
+	return !ok || s.clientVersion.supports(since)
 }
 
 func (s *ManagerService) Quit(stopTunnelsOnQuit bool) (alreadyQuit bool, err error) {
@@ -44,35 +99,58 @@ func (s *ManagerService) Quit(stopTunnelsOnQuit bool) (alreadyQuit bool, err err
 	}
 
 	// Work around potential race condition of delivering messages to the wrong process by removing from notifications.
-	managerServicesLock.Lock()
-	s.eventLock.Lock()
-	s.events = nil
-	s.eventLock.Unlock()
-	delete(managerServices, s)
-	managerServicesLock.Unlock()
+	// This mustn't wait for s.eventLock, which a write to a slow client can hold for a while.
+	s.detach()
 
 	if stopTunnelsOnQuit {
-		// Stop all active tunnels before quitting
+		// Stop all active tunnels before quitting. A failure is reported to the caller
+		// but does not prevent the manager from quitting.
 		logger.Info("Quit requested with stopTunnelsOnQuit=true, stopping all tunnels")
-		activeTunnelsLock.Lock()
-		tunnelNames := make([]string, 0, len(activeTunnels))
-		for name := range activeTunnels {
-			tunnelNames = append(tunnelNames, name)
+		err = stopActiveTunnels(quitTunnelTeardownTimeout)
+		if err != nil {
+			logger.Error("Failed to stop all tunnels on quit: %v", err)
+		} else {
+			logger.Info("All tunnels stopped")
 		}
-		activeTunnelsLock.Unlock()
+	}
+
+	quitManagersChan <- struct{}{}
+	return false, err
+}
+
+// stopActiveTunnels uninstalls every active tunnel, giving up after timeout so a stuck
+// teardown can't hang the caller. Tunnels that were stopped are removed from activeTunnels.
+func stopActiveTunnels(timeout time.Duration) error {
+	activeTunnelsLock.Lock()
+	tunnelNames := make([]string, 0, len(activeTunnels))
+	for name := range activeTunnels {
+		tunnelNames = append(tunnelNames, name)
+	}
+	activeTunnelsLock.Unlock()
 
+	done := make(chan error, 1)
+	go func() {
+		var errs []error
 		for _, name := range tunnelNames {
 			logger.Info("Stopping tunnel: %s", name)
-			if err := UninstallTunnel(name); err != nil {
-				logger.Error("Failed to stop tunnel %s: %v", name, err)
+			if err := uninstallTunnel(name); err != nil {
 				// Continue stopping other tunnels even if one fails
+				errs = append(errs, fmt.Errorf("tunnel %s: %w", name, err))
+				continue
 			}
+			activeTunnelsLock.Lock()
+			delete(activeTunnels, name)
+			activeTunnelsLock.Unlock()
 		}
-		logger.Info("All tunnels stopped")
-	}
+		done <- errors.Join(errs...)
+	}()
 
-	quitManagersChan <- struct{}{}
-	return false, nil
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %v stopping tunnels", timeout)
+	}
 }
 
 func (s *ManagerService) UpdateState() UpdateState {
@@ -96,7 +174,44 @@ func (s *ManagerService) Update() {
 	}()
 }
 
+func (s *ManagerService) InstallFromFile(path string) error {
+	if s.elevatedToken == 0 {
+		return errors.New("installing an update requires administrator rights")
+	}
+	progress := updater.InstallFromFile(path, uintptr(s.elevatedToken))
+	go func() {
+		for {
+			dp := <-progress
+			IPCServerNotifyUpdateProgress(dp)
+			if dp.Complete || dp.Error != nil {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *ManagerService) CancelUpdate() error {
+	if s.elevatedToken == 0 {
+		return errors.New("canceling an update requires administrator rights")
+	}
+	if !updater.CancelUpdate() {
+		return errors.New("no update is in progress")
+	}
+	return nil
+}
+
 func (s *ManagerService) StartTunnel(config tunnel.Config) error {
+	// Connecting ends a pause
+	s.CancelPause()
+	return startTunnel(config)
+}
+
+// startTunnel starts a tunnel, for the UI or when a pause is over
+func startTunnel(config tunnel.Config) error {
+	// A new connection starts with all sites
+	tunnelSites.reset()
+
 	// Set up callback to notify on state changes
 	tunnel.SetStateChangeCallback(func(state TunnelState) {
 		IPCServerNotifyTunnelStateChange(state)
@@ -116,6 +231,9 @@ func (s *ManagerService) StartTunnel(config tunnel.Config) error {
 	activeTunnelsLock.Lock()
 	activeTunnels[config.Name] = true
 	activeTunnelsLock.Unlock()
+	startedConfigLock.Lock()
+	startedConfig = &config
+	startedConfigLock.Unlock()
 	// Notify UI of initial state change (starting)
 	state := tunnel.GetState()
 	IPCServerNotifyTunnelStateChange(state)
@@ -123,6 +241,15 @@ func (s *ManagerService) StartTunnel(config tunnel.Config) error {
 }
 
 func (s *ManagerService) StopTunnel() error {
+	// A disconnect must not be undone by a pending resume
+	s.CancelPause()
+	return stopTunnel()
+}
+
+// stopTunnel stops the running tunnel, for the UI or to pause it
+func stopTunnel() error {
+	tunnelSites.reset()
+
 	// Set up callback to notify on state changes
 	tunnel.SetStateChangeCallback(func(state TunnelState) {
 		IPCServerNotifyTunnelStateChange(state)
@@ -134,6 +261,10 @@ func (s *ManagerService) StopTunnel() error {
 		return UninstallTunnel(name)
 	})
 
+	startedConfigLock.Lock()
+	startedConfig = nil
+	startedConfigLock.Unlock()
+
 	err := tunnel.StopTunnel()
 	if err != nil {
 		return err
@@ -153,6 +284,9 @@ func (s *ManagerService) StopTunnel() error {
 }
 
 func (s *ManagerService) StopAllTunnels() error {
+	s.CancelPause()
+	tunnelSites.reset()
+
 	tunnel.SetStateChangeCallback(func(state TunnelState) {
 		IPCServerNotifyTunnelStateChange(state)
 	})
@@ -182,9 +316,93 @@ func (s *ManagerService) StopAllTunnels() error {
 	return nil
 }
 
+func (s *ManagerService) UpdateInfo() UpdateInfo {
+	return getFoundUpdate()
+}
+
+func (s *ManagerService) CheckForUpdate() (UpdateState, error) {
+	return checkForUpdatesNow()
+}
+
+func (s *ManagerService) UpdateChannel() config.UpdateChannel {
+	return updater.Channel()
+}
+
+func (s *ManagerService) SetUpdateChannel(channel config.UpdateChannel) error {
+	if s.elevatedToken == 0 {
+		return errors.New("changing the update channel requires administrator rights")
+	}
+	if !channel.IsValid() {
+		return fmt.Errorf("unknown update channel %q", channel)
+	}
+	if updateConfig != nil && !updateConfig.SetUpdateChannel(channel) {
+		return errors.New("failed to save update channel")
+	}
+	updater.SetChannel(channel)
+	logger.Info("Update channel changed to %s", channel)
+	go checkForUpdatesNow()
+	return nil
+}
+
+func (s *ManagerService) TunnelStatus() TunnelState {
+	return tunnel.GetState()
+}
+
+func (s *ManagerService) Ping() PingResult {
+	return PingResult{
+		Uptime:      time.Since(managerStartTime),
+		TunnelState: tunnel.GetState(),
+	}
+}
+
+// SetProxy applies the UI's proxy settings to the updater. They are kept in
+// memory only, so the credentials never reach the service's own files; the UI
+// sends them again each time it connects. The updater runs installers as
+// SYSTEM, so only an administrator may choose the proxy its downloads go through.
+func (s *ManagerService) SetProxy(settings config.ProxySettings) error {
+	if s.elevatedToken == 0 {
+		return errors.New("changing the updater proxy requires administrator rights")
+	}
+	if err := updater.SetProxy(settings); err != nil {
+		return err
+	}
+	if settings.URL != "" {
+		logger.Info("Updater proxy set to %s", settings.URL)
+	} else {
+		logger.Info("Updater proxy cleared, using the environment and system settings")
+	}
+	return nil
+}
+
+// SetLogLevel makes the manager log at the UI user's level. The service reads
+// a config of its own, so the UI sends its level each time it connects. Its log
+// lines reach every UI, so only an administrator may change it.
+func (s *ManagerService) SetLogLevel(level string) error {
+	if s.elevatedToken == 0 {
+		return errors.New("changing the service log level requires administrator rights")
+	}
+	level = config.NormalizeLogLevel(level)
+	config.ApplyLogLevel(level)
+	logger.Info("Log level set to %s", level)
+	return nil
+}
+
+// RecentLogs returns up to n of the most recently logged lines, oldest first
+func (s *ManagerService) RecentLogs(n int) []string {
+	return recentLogLines.last(n)
+}
+
 func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 	decoder := gob.NewDecoder(reader)
 	encoder := gob.NewEncoder(writer)
+	clientVersion, err := serveHandshake(decoder, encoder)
+	if err != nil {
+		logger.Error("Refusing UI connection: %v", err)
+		return
+	}
+	s.eventLock.Lock()
+	s.clientVersion = clientVersion
+	s.eventLock.Unlock()
 	for {
 		var methodType MethodType
 		err := decoder.Decode(&methodType)
@@ -215,6 +433,23 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 			}
 		case UpdateMethodType:
 			s.Update()
+		case InstallFromFileMethodType:
+			var path string
+			err := decoder.Decode(&path)
+			if err != nil {
+				return
+			}
+			retErr := s.InstallFromFile(path)
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case CancelUpdateMethodType:
+			retErr := s.CancelUpdate()
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
 		case StartTunnelMethodType:
 			var config tunnel.Config
 			err := decoder.Decode(&config)
@@ -238,6 +473,118 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 			if err != nil {
 				return
 			}
+		case UpdateInfoMethodType:
+			err = encoder.Encode(s.UpdateInfo())
+			if err != nil {
+				return
+			}
+		case CheckForUpdateMethodType:
+			state, retErr := s.CheckForUpdate()
+			err = encoder.Encode(state)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case UpdateChannelMethodType:
+			err = encoder.Encode(s.UpdateChannel())
+			if err != nil {
+				return
+			}
+		case SetUpdateChannelMethodType:
+			var channel config.UpdateChannel
+			err := decoder.Decode(&channel)
+			if err != nil {
+				return
+			}
+			retErr := s.SetUpdateChannel(channel)
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case PingMethodType:
+			err = encoder.Encode(s.Ping())
+			if err != nil {
+				return
+			}
+		case TunnelStatusMethodType:
+			err = encoder.Encode(s.TunnelStatus())
+			if err != nil {
+				return
+			}
+		case GetRecentLogsMethodType:
+			var n int
+			err = decoder.Decode(&n)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(s.RecentLogs(n))
+			if err != nil {
+				return
+			}
+		case SetProxyMethodType:
+			var settings config.ProxySettings
+			err = decoder.Decode(&settings)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(s.SetProxy(settings)))
+			if err != nil {
+				return
+			}
+		case SetLogLevelMethodType:
+			var level string
+			err = decoder.Decode(&level)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(s.SetLogLevel(level)))
+			if err != nil {
+				return
+			}
+		case PauseTunnelMethodType:
+			var d time.Duration
+			err = decoder.Decode(&d)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(s.PauseTunnel(d)))
+			if err != nil {
+				return
+			}
+		case CancelPauseMethodType:
+			s.CancelPause()
+			err = encoder.Encode(errToString(nil))
+			if err != nil {
+				return
+			}
+		case PauseStatusMethodType:
+			err = encoder.Encode(s.PauseStatus())
+			if err != nil {
+				return
+			}
+		case SetSiteConnectedMethodType:
+			var siteID int
+			err = decoder.Decode(&siteID)
+			if err != nil {
+				return
+			}
+			var connected bool
+			err = decoder.Decode(&connected)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(s.SetSiteConnected(siteID, connected)))
+			if err != nil {
+				return
+			}
+		case DisconnectedSitesMethodType:
+			err = encoder.Encode(s.DisconnectedSites())
+			if err != nil {
+				return
+			}
 		default:
 			return
 		}
@@ -245,58 +592,146 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 }
 
 func IPCServerListen(reader, writer, events *os.File, elevatedToken windows.Token) {
-	service := &ManagerService{
-		events:        events,
-		elevatedToken: elevatedToken,
-	}
+	ipcServerListen(reader, writer, events, elevatedToken, nil)
+}
+
+// ipcServerListen is IPCServerListen, calling done, if not nil, once the UI
+// has disconnected
+func ipcServerListen(reader, writer, events *os.File, elevatedToken windows.Token, done func()) {
+	service := newManagerService(events, elevatedToken)
 
+	managerServicesLock.Lock()
+	managerServices[service] = true
+	managerServicesLock.Unlock()
+	go service.writeEvents()
 	go func() {
-		managerServicesLock.Lock()
-		managerServices[service] = true
-		managerServicesLock.Unlock()
 		service.ServeConn(reader, writer)
-		managerServicesLock.Lock()
+		service.detach()
 		service.eventLock.Lock()
 		service.events = nil
 		service.eventLock.Unlock()
-		delete(managerServices, service)
-		managerServicesLock.Unlock()
+		if done != nil {
+			done()
+		}
 	}()
 }
 
+// prune removes a client whose events pipe has broken and closes that pipe
+func (s *ManagerService) prune() {
+	s.detach()
+	s.eventLock.Lock()
+	if s.events != nil {
+		s.events.Close()
+		s.events = nil
+	}
+	s.eventLock.Unlock()
+}
+
+// detach takes s off the clients that get notifications. It doesn't wait for a
+// write to s in progress; notifications still queued for s are dropped.
+func (s *ManagerService) detach() {
+	if s.detached.CompareAndSwap(false, true) {
+		close(s.gone)
+	}
+	managerServicesLock.Lock()
+	delete(managerServices, s)
+	managerServicesLock.Unlock()
+}
+
+// connectedServices returns the clients that get notifications. managerServicesLock
+// is only held to copy them, never while taking a client's eventLock, so that
+// a client stuck in a write can't hold up Quit or another client's disconnect.
+func connectedServices() []*ManagerService {
+	managerServicesLock.RLock()
+	defer managerServicesLock.RUnlock()
+	services := make([]*ManagerService, 0, len(managerServices))
+	for m := range managerServices {
+		services = append(services, m)
+	}
+	return services
+}
+
+// notifyAll queues a notification for every connected client that
+// understands it. It never waits for a client: one whose queue is full has
+// stopped reading and is dropped.
 func notifyAll(notificationType NotificationType, adminOnly bool, ifaces ...any) {
-	if len(managerServices) == 0 {
+	services := connectedServices()
+	if len(services) == 0 {
 		return
 	}
 
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-	err := encoder.Encode(notificationType)
+	n, err := newNotification(notificationType, ifaces...)
 	if err != nil {
 		return
 	}
-	for _, iface := range ifaces {
-		err = encoder.Encode(iface)
-		if err != nil {
-			return
-		}
-	}
 
-	managerServicesLock.RLock()
-	for m := range managerServices {
+	for _, m := range services {
 		if m.elevatedToken == 0 && adminOnly {
 			continue
 		}
-		go func(m *ManagerService) {
-			m.eventLock.Lock()
-			defer m.eventLock.Unlock()
-			if m.events != nil {
-				m.events.SetWriteDeadline(time.Now().Add(time.Second))
-				m.events.Write(buf.Bytes())
+		m.eventLock.Lock()
+		wants := m.events != nil && m.wants(notificationType)
+		m.eventLock.Unlock()
+		if wants && !m.enqueue(n) {
+			// Prune before logging, as log lines are themselves broadcast
+			m.prune()
+			logger.Warn("Dropped UI client that stopped reading notifications")
+		}
+	}
+}
+
+// enqueue queues n for writeEvents, reporting false if the queue is full
+func (s *ManagerService) enqueue(n *notification) bool {
+	if s.detached.Load() {
+		return true
+	}
+	select {
+	case s.queue <- n:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeEvents writes the notifications queued for s to its events pipe, one
+// at a time and in order, until s is detached. A client whose pipe fails is
+// pruned. A write to a client that stopped reading blocks until notifyAll
+// prunes it for overflowing its queue, since closing the pipe cancels the
+// write.
+func (s *ManagerService) writeEvents() {
+	// Older clients read the notifications straight off one gob stream
+	var encoder *gob.Encoder
+	for {
+		var n *notification
+		select {
+		case <-s.gone:
+			return
+		case n = <-s.queue:
+		}
+
+		s.eventLock.Lock()
+		events := s.events
+		framed := s.clientVersion.supports(framedNotificationsSince)
+		s.eventLock.Unlock()
+		if events == nil || s.detached.Load() {
+			return
+		}
+		var err error
+		if framed {
+			_, err = events.Write(n.frame)
+		} else {
+			if encoder == nil {
+				encoder = gob.NewEncoder(events)
 			}
-		}(m)
+			err = n.encode(encoder)
+		}
+		if err != nil {
+			// Prune before logging, as log lines are themselves broadcast
+			s.prune()
+			logger.Warn("Dropped UI client after failed event write: %v", err)
+			return
+		}
 	}
-	managerServicesLock.RUnlock()
 }
 
 func errToString(err error) string {
@@ -311,7 +746,11 @@ func IPCServerNotifyUpdateFound(state UpdateState) {
 }
 
 func IPCServerNotifyUpdateProgress(dp updater.DownloadProgress) {
-	notifyAll(UpdateProgressNotificationType, true, dp.Activity, dp.BytesDownloaded, dp.BytesTotal, errToString(dp.Error), dp.Complete)
+	var verification updater.VerificationResult
+	if dp.Verification != nil {
+		verification = *dp.Verification
+	}
+	notifyAll(UpdateProgressNotificationType, true, dp.Activity, dp.BytesDownloaded, dp.BytesTotal, errToString(dp.Error), dp.Complete, verification)
 }
 
 func IPCServerNotifyManagerStopping() {
@@ -319,6 +758,31 @@ func IPCServerNotifyManagerStopping() {
 	time.Sleep(time.Millisecond * 200)
 }
 
+// IPCServerNotifyStats pushes a stats snapshot to the connected UIs that understand it
+func IPCServerNotifyStats(snapshot StatsSnapshot) {
+	notifyAll(StatsNotificationType, false, snapshot)
+}
+
+// haveStatsListeners reports whether any connected UI takes stats snapshots
+func haveStatsListeners() bool {
+	for _, m := range connectedServices() {
+		m.eventLock.Lock()
+		wants := m.events != nil && !m.detached.Load() && m.wants(StatsNotificationType)
+		m.eventLock.Unlock()
+		if wants {
+			return true
+		}
+	}
+	return false
+}
+
 func IPCServerNotifyTunnelStateChange(state TunnelState) {
 	notifyAll(TunnelStateChangeNotificationType, false, state)
 }
+
+// IPCServerNotifyLogLine records a log line and forwards it to connected UIs.
+// It must not log, since it is called from the logger itself.
+func IPCServerNotifyLogLine(line string) {
+	recentLogLines.add(line)
+	notifyAll(LogLineNotificationType, false, line)
+}
@@ -0,0 +1,331 @@
+//go:build windows
+
+package managers
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/config"
+)
+
+// fakeEvents stands in for a client's events pipe. Writes fail with err if it
+// is set, and otherwise block until the pipe is closed, like a client that
+// stopped reading.
+type fakeEvents struct {
+	err       error
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFakeEvents(err error) *fakeEvents {
+	return &fakeEvents{err: err, closed: make(chan struct{})}
+}
+
+func (f *fakeEvents) Write(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	<-f.closed
+	return 0, os.ErrClosed
+}
+
+func (f *fakeEvents) Close() error {
+	f.closeOnce.Do(func() { close(f.closed) })
+	return nil
+}
+
+func (f *fakeEvents) isClosed() bool {
+	select {
+	case <-f.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// connectFakeClient registers a client writing to events, as ipcServerListen
+// does, and completes its handshake. It returns a channel closed once the
+// client's writer has stopped.
+func connectFakeClient(t *testing.T, events *fakeEvents) (*ManagerService, <-chan struct{}) {
+	t.Helper()
+	s := newManagerService(events, 0)
+	s.clientVersion = ProtocolVersion
+	managerServicesLock.Lock()
+	managerServices[s] = true
+	managerServicesLock.Unlock()
+	t.Cleanup(s.prune)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.writeEvents()
+		close(stopped)
+	}()
+	return s, stopped
+}
+
+func isConnected(s *ManagerService) bool {
+	managerServicesLock.RLock()
+	defer managerServicesLock.RUnlock()
+	return managerServices[s]
+}
+
+func waitFor(t *testing.T, what string, ch <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestFailedWritePrunesClient(t *testing.T) {
+	events := newFakeEvents(errors.New("pipe is broken"))
+	s, stopped := connectFakeClient(t, events)
+
+	IPCServerNotifyTunnelStateChange(TunnelStateRunning)
+	waitFor(t, "the writer to stop", stopped)
+
+	if isConnected(s) {
+		t.Error("client is still connected after a failed write")
+	}
+	if !events.isClosed() {
+		t.Error("events pipe was not closed")
+	}
+}
+
+func TestStalledClientIsPruned(t *testing.T) {
+	defer func(n int) { notifyQueueLength = n }(notifyQueueLength)
+	notifyQueueLength = 2
+
+	events := newFakeEvents(nil)
+	s, stopped := connectFakeClient(t, events)
+
+	// One notification blocks in the write, two fill the queue, and the
+	// next overflows it
+	for i := 0; i < notifyQueueLength+2; i++ {
+		IPCServerNotifyTunnelStateChange(TunnelStateRunning)
+	}
+	waitFor(t, "the writer to stop", stopped)
+
+	if isConnected(s) {
+		t.Error("client is still connected after overflowing its queue")
+	}
+	if !events.isClosed() {
+		t.Error("events pipe was not closed")
+	}
+}
+
+func TestNotifyAllSkipsStalledClient(t *testing.T) {
+	defer func(n int) { notifyQueueLength = n }(notifyQueueLength)
+	notifyQueueLength = 2
+
+	stalled := newFakeEvents(nil)
+	connectFakeClient(t, stalled)
+
+	// notifyAll must not wait on a client that stopped reading
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			IPCServerNotifyTunnelStateChange(TunnelStateRunning)
+		}
+		close(done)
+	}()
+	waitFor(t, "notifyAll to return", done)
+}
+
+func TestNothingIsSentBeforeHandshake(t *testing.T) {
+	events := newFakeEvents(errors.New("pipe is broken"))
+	s, _ := connectFakeClient(t, events)
+	s.eventLock.Lock()
+	s.clientVersion = Version{}
+	s.eventLock.Unlock()
+
+	IPCServerNotifyTunnelStateChange(TunnelStateRunning)
+	if len(s.queue) != 0 {
+		t.Errorf("%d notifications queued before the handshake, want none", len(s.queue))
+	}
+	if !isConnected(s) {
+		t.Error("client was dropped before its handshake")
+	}
+}
+
+func TestAdminOnlyMethodsRefuseStandardUsers(t *testing.T) {
+	s := newManagerService(newFakeEvents(nil), 0)
+
+	calls := map[string]func() error{
+		"SetProxy":         func() error { return s.SetProxy(config.ProxySettings{URL: "http://proxy.example.com:8080"}) },
+		"SetLogLevel":      func() error { return s.SetLogLevel(config.LogLevelDebug) },
+		"SetUpdateChannel": func() error { return s.SetUpdateChannel(config.UpdateChannelBeta) },
+		"InstallFromFile":  func() error { return s.InstallFromFile(`C:\update.msi`) },
+		"CancelUpdate":     s.CancelUpdate,
+	}
+	for name, call := range calls {
+		err := call()
+		if err == nil || !strings.Contains(err.Error(), "requires administrator rights") {
+			t.Errorf("%s from a standard user: error = %v, want one requiring administrator rights", name, err)
+		}
+	}
+}
+
+// fakeTunnels stands in for the active tunnels' services, counting how often
+// each is torn down
+type fakeTunnels struct {
+	mu      sync.Mutex
+	err     error
+	block   chan struct{} // Teardowns wait for it to close when set
+	stopped map[string]int
+}
+
+// installFakeTunnels makes names the active tunnels, torn down by the
+// returned fake, until t ends
+func installFakeTunnels(t *testing.T, names ...string) *fakeTunnels {
+	t.Helper()
+	fake := &fakeTunnels{stopped: make(map[string]int)}
+	savedUninstall, savedActive, savedQuit := uninstallTunnel, activeTunnels, atomic.LoadUint32(&haveQuit)
+	t.Cleanup(func() {
+		activeTunnelsLock.Lock()
+		uninstallTunnel, activeTunnels = savedUninstall, savedActive
+		activeTunnelsLock.Unlock()
+		atomic.StoreUint32(&haveQuit, savedQuit)
+		select {
+		case <-quitManagersChan:
+		default:
+		}
+	})
+
+	activeTunnelsLock.Lock()
+	activeTunnels = make(map[string]bool)
+	for _, name := range names {
+		activeTunnels[name] = true
+	}
+	activeTunnelsLock.Unlock()
+	uninstallTunnel = func(name string) error {
+		if fake.block != nil {
+			<-fake.block
+		}
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.stopped[name]++
+		return fake.err
+	}
+	return fake
+}
+
+func (f *fakeTunnels) stopCount(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stopped[name]
+}
+
+func TestQuitStopsTunnelsOnce(t *testing.T) {
+	fake := installFakeTunnels(t, "Pangolin")
+	s := newManagerService(newFakeEvents(nil), 0)
+
+	if alreadyQuit, err := s.Quit(true); alreadyQuit || err != nil {
+		t.Fatalf("Quit() = %v, %v, want false, nil", alreadyQuit, err)
+	}
+	if alreadyQuit, err := s.Quit(true); !alreadyQuit || err != nil {
+		t.Errorf("second Quit() = %v, %v, want true, nil", alreadyQuit, err)
+	}
+	if n := fake.stopCount("Pangolin"); n != 1 {
+		t.Errorf("tunnel torn down %d times, want once", n)
+	}
+	if len(activeTunnels) != 0 {
+		t.Errorf("%d tunnels still tracked as active", len(activeTunnels))
+	}
+	if len(quitManagersChan) != 1 {
+		t.Error("managers were not told to quit")
+	}
+}
+
+func TestQuitKeepsTunnelsUnlessAsked(t *testing.T) {
+	fake := installFakeTunnels(t, "Pangolin")
+	s := newManagerService(newFakeEvents(nil), 0)
+
+	if _, err := s.Quit(false); err != nil {
+		t.Fatalf("Quit() error = %v", err)
+	}
+	if n := fake.stopCount("Pangolin"); n != 0 {
+		t.Errorf("tunnel torn down %d times, want none", n)
+	}
+}
+
+func TestQuitProceedsWhenTeardownFails(t *testing.T) {
+	fake := installFakeTunnels(t, "Pangolin")
+	fake.err = errors.New("service is stuck")
+	s := newManagerService(newFakeEvents(nil), 0)
+
+	alreadyQuit, err := s.Quit(true)
+	if alreadyQuit || err == nil || !strings.Contains(err.Error(), "service is stuck") {
+		t.Errorf("Quit() = %v, %v, want the teardown error", alreadyQuit, err)
+	}
+	if len(quitManagersChan) != 1 {
+		t.Error("managers were not told to quit after a failed teardown")
+	}
+	if !activeTunnels["Pangolin"] {
+		t.Error("a tunnel that failed to stop is no longer tracked")
+	}
+}
+
+func TestStopActiveTunnelsTimesOut(t *testing.T) {
+	fake := installFakeTunnels(t, "Pangolin")
+	fake.block = make(chan struct{})
+	stuck := uninstallTunnel
+	finished := make(chan struct{})
+	uninstallTunnel = func(name string) error {
+		defer close(finished)
+		return stuck(name)
+	}
+
+	err := stopActiveTunnels(10 * time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("stopActiveTunnels() error = %v, want a timeout", err)
+	}
+
+	// Let the abandoned teardown finish before the fakes are removed
+	close(fake.block)
+	waitFor(t, "the teardown to finish", finished)
+}
+
+// A client whose events are stuck, holding its eventLock as a write in
+// progress can, must not hold up Quit from it or from another client
+func TestSlowClientDoesNotDelayQuit(t *testing.T) {
+	tests := []struct {
+		name     string
+		quitSlow bool // The slow client quits, rather than another
+	}{
+		{name: "another client quits"},
+		{name: "the slow client quits", quitSlow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeTunnels(t)
+			slow, _ := connectFakeClient(t, newFakeEvents(nil))
+			IPCServerNotifyTunnelStateChange(TunnelStateRunning)
+			slow.eventLock.Lock()
+			defer slow.eventLock.Unlock()
+
+			s := newManagerService(newFakeEvents(nil), 0)
+			if tt.quitSlow {
+				s = slow
+			}
+			done := make(chan struct{})
+			go func() {
+				s.Quit(false)
+				close(done)
+			}()
+			waitFor(t, "Quit to return", done)
+
+			if isConnected(slow) == tt.quitSlow {
+				t.Errorf("slow client connected = %v after Quit, want %v", isConnected(slow), !tt.quitSlow)
+			}
+		})
+	}
+}
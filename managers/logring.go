@@ -0,0 +1,65 @@
+//go:build windows
+
+package managers
+
+import (
+	"slices"
+	"sync"
+)
+
+// logRingSize is the number of recent log lines kept for UIs that connect late
+const logRingSize = 500
+
+// logRing is a fixed-size buffer of the most recent log lines
+type logRing struct {
+	lines []string
+	next  int
+	full  bool
+	lock  sync.Mutex
+}
+
+var recentLogLines = &logRing{lines: make([]string, logRingSize)}
+
+func (r *logRing) add(line string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot returns the buffered lines, oldest first
+func (r *logRing) snapshot() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.full {
+		return append([]string(nil), r.lines[:r.next]...)
+	}
+	out := make([]string, 0, len(r.lines))
+	out = append(out, r.lines[r.next:]...)
+	return append(out, r.lines[:r.next]...)
+}
+
+// last returns up to n of the most recent buffered lines, oldest first.
+// n is bounded to the buffer size; n <= 0 returns every buffered line.
+func (r *logRing) last(n int) []string {
+	lines := r.snapshot()
+	if n > 0 && n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// NewLogLines returns the streamed lines that are not already in snapshot. Lines
+// streamed while the snapshot was being fetched may also be at its end, so the
+// longest prefix of streamed that matches the end of snapshot is dropped.
+func NewLogLines(snapshot, streamed []string) []string {
+	for k := min(len(snapshot), len(streamed)); k > 0; k-- {
+		if slices.Equal(snapshot[len(snapshot)-k:], streamed[:k]) {
+			return streamed[k:]
+		}
+	}
+	return streamed
+}
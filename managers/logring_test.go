@@ -0,0 +1,132 @@
+//go:build windows
+
+package managers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// newTestLogRing returns a ring of size holding lines "line 1" to "line count"
+func newTestLogRing(size, count int) *logRing {
+	r := &logRing{lines: make([]string, size)}
+	for i := 1; i <= count; i++ {
+		r.add(fmt.Sprintf("line %d", i))
+	}
+	return r
+}
+
+func TestLogRingLast(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		n     int
+		want  []string
+	}{
+		{name: "empty", n: 3, want: []string{}},
+		{name: "fewer than asked", count: 2, n: 3, want: []string{"line 1", "line 2"}},
+		{name: "most recent", count: 4, n: 2, want: []string{"line 3", "line 4"}},
+		{name: "all", count: 4, n: 0, want: []string{"line 1", "line 2", "line 3", "line 4"}},
+		{name: "negative is all", count: 4, n: -1, want: []string{"line 1", "line 2", "line 3", "line 4"}},
+		{name: "wrapped", count: 7, n: 2, want: []string{"line 6", "line 7"}},
+		{name: "bounded to the buffer", count: 7, n: 100, want: []string{"line 3", "line 4", "line 5", "line 6", "line 7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestLogRing(5, tt.count).last(tt.n)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("last(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLogLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot []string
+		streamed []string
+		want     []string
+	}{
+		{name: "nothing streamed", snapshot: []string{"a", "b"}},
+		{name: "no history", streamed: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "no overlap", snapshot: []string{"a", "b"}, streamed: []string{"c", "d"}, want: []string{"c", "d"}},
+		{name: "overlap", snapshot: []string{"a", "b", "c"}, streamed: []string{"b", "c", "d"}, want: []string{"d"}},
+		{name: "all already shown", snapshot: []string{"a", "b", "c"}, streamed: []string{"b", "c"}, want: []string{}},
+		{name: "longest overlap", snapshot: []string{"x", "x", "x"}, streamed: []string{"x", "x", "y"}, want: []string{"y"}},
+		{name: "only the end counts", snapshot: []string{"a", "b", "c"}, streamed: []string{"a", "b", "d"}, want: []string{"a", "b", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewLogLines(tt.snapshot, tt.streamed)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewLogLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRecentLogsOverIPC(t *testing.T) {
+	saved := recentLogLines
+	recentLogLines = newTestLogRing(logRingSize, logRingSize+20)
+	oldManagerVersion := managerProtocolVersion
+	defer func() { recentLogLines, managerProtocolVersion = saved, oldManagerVersion }()
+
+	server, client := net.Pipe()
+	go func() {
+		newManagerService(newFakeEvents(nil), 0).ServeConn(server, server)
+		server.Close()
+	}()
+	connectRPC(t, client)
+	if err := IPCClientHandshake(); err != nil {
+		t.Fatalf("IPCClientHandshake() error = %v", err)
+	}
+
+	tests := []struct {
+		n         int
+		wantCount int
+	}{
+		{n: 3, wantCount: 3},
+		{n: 0, wantCount: logRingSize},
+		{n: logRingSize * 2, wantCount: logRingSize},
+	}
+	for _, tt := range tests {
+		lines, err := IPCClientGetRecentLogs(tt.n)
+		if err != nil {
+			t.Fatalf("IPCClientGetRecentLogs(%d) error = %v", tt.n, err)
+		}
+		if len(lines) != tt.wantCount {
+			t.Fatalf("IPCClientGetRecentLogs(%d) returned %d lines, want %d", tt.n, len(lines), tt.wantCount)
+		}
+		first, last := fmt.Sprintf("line %d", logRingSize+21-tt.wantCount), fmt.Sprintf("line %d", logRingSize+20)
+		if lines[0] != first || lines[len(lines)-1] != last {
+			t.Errorf("IPCClientGetRecentLogs(%d) = %q ... %q, want %q ... %q", tt.n, lines[0], lines[len(lines)-1], first, last)
+		}
+	}
+}
+
+// A manager from before the method hangs up on it, so it isn't sent at all
+func TestGetRecentLogsFromOlderManager(t *testing.T) {
+	oldManagerVersion := managerProtocolVersion
+	managerProtocolVersion = Version{Major: 1, Minor: 0}
+	defer func() { managerProtocolVersion = oldManagerVersion }()
+
+	server, client := net.Pipe()
+	defer server.Close()
+	connectRPC(t, client)
+
+	if _, err := IPCClientGetRecentLogs(10); !errors.Is(err, errMethodNotSupported) {
+		t.Errorf("IPCClientGetRecentLogs() error = %v, want errMethodNotSupported", err)
+	}
+}
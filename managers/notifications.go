@@ -0,0 +1,235 @@
+//go:build windows
+
+package managers
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fosrl/windows/updater"
+)
+
+// notificationKind knows how to read the payload of one type of notification
+// off the events pipe, and which callbacks want it
+type notificationKind struct {
+	decode    func(decoder *gob.Decoder) (any, error)
+	callbacks map[*notificationCallback]bool
+}
+
+// notificationCallback is one registration for a type of notification
+type notificationCallback struct {
+	notificationType NotificationType
+	cb               func(payload any)
+}
+
+// notificationKinds lists the notifications the UI understands. The manager
+// sends a NotificationType followed by the payload, written by notifyAll, that
+// the kind's decode function reads back. Adding a notification takes an entry
+// here and a Register wrapper.
+//
+// From framedNotificationsSince on, each notification is sent as a frame: a
+// []byte holding a gob stream of its own with the NotificationType and the
+// payload. A UI can then skip a frame it doesn't understand, and every frame
+// carries the gob type information its payload needs. Older peers send the
+// NotificationType and payload straight onto the events stream.
+var (
+	notificationKinds = map[NotificationType]*notificationKind{
+		ManagerStoppingNotificationType:   newNotificationKind(decodeNothing),
+		UpdateFoundNotificationType:       newNotificationKind(decodeValue[UpdateState]),
+		UpdateProgressNotificationType:    newNotificationKind(decodeDownloadProgress),
+		TunnelStateChangeNotificationType: newNotificationKind(decodeValue[TunnelState]),
+		LogLineNotificationType:           newNotificationKind(decodeValue[string]),
+		StatsNotificationType:             newNotificationKind(decodeValue[StatsSnapshot]),
+	}
+	notificationKindsLock sync.RWMutex
+)
+
+// framedNotificationsSince is the protocol version from which notifications
+// are sent as frames
+var framedNotificationsSince = Version{Major: 1, Minor: 5}
+
+func newNotificationKind[T any](decode func(decoder *gob.Decoder) (T, error)) *notificationKind {
+	return &notificationKind{
+		decode: func(decoder *gob.Decoder) (any, error) {
+			return decode(decoder)
+		},
+		callbacks: make(map[*notificationCallback]bool),
+	}
+}
+
+// decodeNothing is the decode function for notifications without a payload
+func decodeNothing(*gob.Decoder) (struct{}, error) {
+	return struct{}{}, nil
+}
+
+// decodeValue is the decode function for notifications carrying a single value
+func decodeValue[T any](decoder *gob.Decoder) (value T, err error) {
+	err = decoder.Decode(&value)
+	return
+}
+
+// decodeDownloadProgress reads the fields IPCServerNotifyUpdateProgress sends
+func decodeDownloadProgress(decoder *gob.Decoder) (dp updater.DownloadProgress, err error) {
+	if err = decoder.Decode(&dp.Activity); err != nil {
+		return
+	}
+	if err = decoder.Decode(&dp.BytesDownloaded); err != nil {
+		return
+	}
+	if err = decoder.Decode(&dp.BytesTotal); err != nil {
+		return
+	}
+	var errStr string
+	if err = decoder.Decode(&errStr); err != nil {
+		return
+	}
+	if len(errStr) > 0 {
+		dp.Error = errors.New(errStr)
+	}
+	if err = decoder.Decode(&dp.Complete); err != nil {
+		return
+	}
+	var verification updater.VerificationResult
+	if err = decoder.Decode(&verification); err != nil {
+		return
+	}
+	if verification != (updater.VerificationResult{}) {
+		dp.Verification = &verification
+	}
+	return
+}
+
+// notification is one notification on its way to the clients
+type notification struct {
+	notificationType NotificationType
+	ifaces           []any
+	// frame is the notification encoded for clients that read frames
+	frame []byte
+}
+
+// newNotification builds a notificationType notification carrying ifaces, as
+// notifyAll sends it, encoding its frame once for all clients
+func newNotification(notificationType NotificationType, ifaces ...any) (*notification, error) {
+	n := &notification{notificationType: notificationType, ifaces: ifaces}
+	var payload bytes.Buffer
+	if err := n.encode(gob.NewEncoder(&payload)); err != nil {
+		return nil, err
+	}
+	var frame bytes.Buffer
+	if err := gob.NewEncoder(&frame).Encode(payload.Bytes()); err != nil {
+		return nil, err
+	}
+	n.frame = frame.Bytes()
+	return n, nil
+}
+
+// encode writes the notification type and payload to encoder, which is how
+// older clients read them straight off the events stream
+func (n *notification) encode(encoder *gob.Encoder) error {
+	if err := encoder.Encode(n.notificationType); err != nil {
+		return err
+	}
+	for _, iface := range n.ifaces {
+		if err := encoder.Encode(iface); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerNotification calls cb with the payload of every notification of
+// notificationType from now on. T must be the payload type the kind decodes.
+func registerNotification[T any](notificationType NotificationType, cb func(payload T)) *notificationCallback {
+	c := &notificationCallback{
+		notificationType: notificationType,
+		cb: func(payload any) {
+			cb(payload.(T))
+		},
+	}
+
+	notificationKindsLock.Lock()
+	defer notificationKindsLock.Unlock()
+	kind, ok := notificationKinds[notificationType]
+	if !ok {
+		panic(fmt.Sprintf("managers: unknown notification type %d", notificationType))
+	}
+	kind.callbacks[c] = true
+	return c
+}
+
+func (c *notificationCallback) unregister() {
+	notificationKindsLock.Lock()
+	defer notificationKindsLock.Unlock()
+	delete(notificationKinds[c.notificationType].callbacks, c)
+}
+
+// dispatchNotification hands payload to the callbacks registered for
+// notificationType. Callbacks run without the registry locked, so they may
+// register or unregister.
+func dispatchNotification(notificationType NotificationType, payload any) {
+	notificationKindsLock.RLock()
+	kind, ok := notificationKinds[notificationType]
+	var callbacks []*notificationCallback
+	if ok {
+		for c := range kind.callbacks {
+			callbacks = append(callbacks, c)
+		}
+	}
+	notificationKindsLock.RUnlock()
+
+	for _, c := range callbacks {
+		c.cb(payload)
+	}
+}
+
+// readNotification reads the next notification from decoder, framed if the
+// manager sends frames, and returns its type and payload. The payload of a
+// framed notification the UI doesn't understand is skipped and returned as
+// nil. Any error leaves the stream unusable, as an unframed payload that wasn't
+// read, or was misread, is taken for the notifications that follow.
+func readNotification(decoder *gob.Decoder, framed bool) (NotificationType, any, error) {
+	if !framed {
+		var notificationType NotificationType
+		if err := decoder.Decode(&notificationType); err != nil {
+			return notificationType, nil, err
+		}
+		payload, err := decodePayload(decoder, notificationType)
+		return notificationType, payload, err
+	}
+
+	var frame []byte
+	if err := decoder.Decode(&frame); err != nil {
+		return 0, nil, err
+	}
+	frameDecoder := gob.NewDecoder(bytes.NewReader(frame))
+	var notificationType NotificationType
+	if err := frameDecoder.Decode(&notificationType); err != nil {
+		return notificationType, nil, fmt.Errorf("notification frame: %w", err)
+	}
+	notificationKindsLock.RLock()
+	_, known := notificationKinds[notificationType]
+	notificationKindsLock.RUnlock()
+	if !known {
+		return notificationType, nil, nil
+	}
+	payload, err := decodePayload(frameDecoder, notificationType)
+	return notificationType, payload, err
+}
+
+// decodePayload reads the payload of a notificationType notification from decoder
+func decodePayload(decoder *gob.Decoder, notificationType NotificationType) (any, error) {
+	notificationKindsLock.RLock()
+	kind, ok := notificationKinds[notificationType]
+	notificationKindsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown notification type %d", notificationType)
+	}
+	payload, err := kind.decode(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("notification type %d: %w", notificationType, err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,220 @@
+//go:build windows
+
+package managers
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/fosrl/windows/updater"
+)
+
+// readAll reads the notifications in r the way readIPCEvents does, until r is exhausted
+func readAll(t *testing.T, r io.Reader, framed bool) {
+	t.Helper()
+	decoder := gob.NewDecoder(r)
+	for {
+		notificationType, payload, err := readNotification(decoder, framed)
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("reading notification: %v", err)
+		}
+		dispatchNotification(notificationType, payload)
+	}
+}
+
+// writeNotifications writes notifications to w the way writeEvents does for a
+// client that reads frames, or for an older one if framed is false
+func writeNotifications(t *testing.T, w io.Writer, framed bool, notifications ...*notification) {
+	t.Helper()
+	encoder := gob.NewEncoder(w)
+	for _, n := range notifications {
+		var err error
+		if framed {
+			_, err = w.Write(n.frame)
+		} else {
+			err = n.encode(encoder)
+		}
+		if err != nil {
+			t.Fatalf("writing notification %d: %v", n.notificationType, err)
+		}
+	}
+}
+
+func mustNotification(t *testing.T, notificationType NotificationType, ifaces ...any) *notification {
+	t.Helper()
+	n, err := newNotification(notificationType, ifaces...)
+	if err != nil {
+		t.Fatalf("newNotification(%d): %v", notificationType, err)
+	}
+	return n
+}
+
+func TestNotificationRoundTrip(t *testing.T) {
+	for _, framed := range []bool{true, false} {
+		name := "framed"
+		if !framed {
+			name = "unframed"
+		}
+		t.Run(name, func(t *testing.T) {
+			var lines []string
+			logLine := registerNotification(LogLineNotificationType, func(line string) {
+				lines = append(lines, line)
+			})
+			defer logLine.unregister()
+			var states []TunnelState
+			tunnelState := registerNotification(TunnelStateChangeNotificationType, func(state TunnelState) {
+				states = append(states, state)
+			})
+			defer tunnelState.unregister()
+			var progress []updater.DownloadProgress
+			updateProgress := registerNotification(UpdateProgressNotificationType, func(dp updater.DownloadProgress) {
+				progress = append(progress, dp)
+			})
+			defer updateProgress.unregister()
+
+			// Struct payloads are sent twice, so the second copy must decode
+			// without its gob type information being sent again
+			verification := updater.VerificationResult{Verified: true, Signer: "Fossorial, Inc."}
+			var stream bytes.Buffer
+			writeNotifications(t, &stream, framed,
+				mustNotification(t, LogLineNotificationType, "first"),
+				mustNotification(t, TunnelStateChangeNotificationType, TunnelStateRunning),
+				mustNotification(t, UpdateProgressNotificationType, "Downloading", uint64(10), uint64(20), "disk full", false, updater.VerificationResult{}),
+				mustNotification(t, UpdateProgressNotificationType, "Done", uint64(20), uint64(20), "", true, verification),
+				mustNotification(t, LogLineNotificationType, "second"),
+			)
+
+			readAll(t, &stream, framed)
+
+			if want := []string{"first", "second"}; !reflect.DeepEqual(lines, want) {
+				t.Errorf("log lines = %q, want %q", lines, want)
+			}
+			if want := []TunnelState{TunnelStateRunning}; !reflect.DeepEqual(states, want) {
+				t.Errorf("tunnel states = %v, want %v", states, want)
+			}
+			if len(progress) != 2 {
+				t.Fatalf("got %d update progress notifications, want 2", len(progress))
+			}
+			dp := progress[0]
+			if dp.Activity != "Downloading" || dp.BytesDownloaded != 10 || dp.BytesTotal != 20 || dp.Complete {
+				t.Errorf("update progress = %+v", dp)
+			}
+			if dp.Error == nil || dp.Error.Error() != "disk full" {
+				t.Errorf("update progress error = %v, want disk full", dp.Error)
+			}
+			if dp.Verification != nil {
+				t.Errorf("update progress verification = %+v, want nil", dp.Verification)
+			}
+			dp = progress[1]
+			if !dp.Complete || dp.Error != nil || dp.Verification == nil || *dp.Verification != verification {
+				t.Errorf("completed update progress = %+v", dp)
+			}
+		})
+	}
+}
+
+func TestUnknownFramedNotificationIsSkipped(t *testing.T) {
+	var lines []string
+	logLine := registerNotification(LogLineNotificationType, func(line string) {
+		lines = append(lines, line)
+	})
+	defer logLine.unregister()
+
+	var stream bytes.Buffer
+	writeNotifications(t, &stream, true,
+		mustNotification(t, NotificationType(1000), "from a newer manager", uint64(42), TunnelStateRunning),
+		mustNotification(t, LogLineNotificationType, "after"),
+	)
+	decoder := gob.NewDecoder(&stream)
+
+	notificationType, payload, err := readNotification(decoder, true)
+	if err != nil {
+		t.Fatalf("reading unknown notification: %v", err)
+	}
+	if notificationType != 1000 || payload != nil {
+		t.Errorf("unknown notification = %d, %v, want 1000, nil", notificationType, payload)
+	}
+	readAll(t, &stream, true)
+	if want := []string{"after"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("log lines = %q, want %q", lines, want)
+	}
+}
+
+func TestUnreadableNotificationIsAnError(t *testing.T) {
+	tests := []struct {
+		name   string
+		framed bool
+		n      *notification
+	}{
+		{"unknown unframed type", false, &notification{notificationType: 1000, ifaces: []any{"payload"}}},
+		{"framed payload of the wrong type", true, mustNotification(t, LogLineNotificationType, uint64(42))},
+		{"framed payload missing", true, mustNotification(t, TunnelStateChangeNotificationType)},
+		{"unframed payload of the wrong type", false, &notification{notificationType: LogLineNotificationType, ifaces: []any{uint64(42)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stream bytes.Buffer
+			writeNotifications(t, &stream, tt.framed, tt.n)
+			if _, _, err := readNotification(gob.NewDecoder(&stream), tt.framed); err == nil {
+				t.Error("readNotification succeeded, want a decode error")
+			}
+		})
+	}
+}
+
+func TestWriteEventsKeepsOrder(t *testing.T) {
+	for _, version := range []Version{ProtocolVersion, {Major: 1, Minor: 4}} {
+		t.Run(version.String(), func(t *testing.T) {
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+
+			s := newManagerService(writer, 0)
+			s.clientVersion = version
+			done := make(chan struct{})
+			go func() {
+				s.writeEvents()
+				close(done)
+			}()
+
+			want := []string{"one", "two", "three", "four"}
+			for _, line := range want {
+				if !s.enqueue(mustNotification(t, LogLineNotificationType, line)) {
+					t.Fatalf("enqueue(%q) reported a full queue", line)
+				}
+			}
+
+			var got []string
+			lines := registerNotification(LogLineNotificationType, func(line string) {
+				got = append(got, line)
+			})
+			defer lines.unregister()
+			framed := version.supports(framedNotificationsSince)
+			decoder := gob.NewDecoder(reader)
+			for range want {
+				notificationType, payload, err := readNotification(decoder, framed)
+				if err != nil {
+					t.Fatalf("reading notification: %v", err)
+				}
+				dispatchNotification(notificationType, payload)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("lines = %q, want %q", got, want)
+			}
+
+			s.prune()
+			<-done
+		})
+	}
+}
@@ -0,0 +1,150 @@
+//go:build windows
+
+package managers
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+// tunnelPause reconnects the tunnel once a pause the UI asked for is over. It
+// lives in the manager so the tunnel comes back even if the UI is closed or
+// restarted meanwhile.
+var tunnelPause = newPauseTimer()
+
+var (
+	// startedConfig is the config of the running tunnel, which a pause starts again
+	startedConfig     *tunnel.Config
+	startedConfigLock sync.Mutex
+)
+
+// pauseTimer runs a function once a pause is over, unless it is cancelled first
+type pauseTimer struct {
+	mu sync.Mutex
+	// now and afterFunc are time.Now and time.AfterFunc unless replaced by tests
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func()) (stop func() bool)
+	// stop cancels the pending resume, nil unless paused
+	stop     func() bool
+	resumeAt time.Time
+	// generation tells a resume that fires late whether it is still wanted
+	generation uint64
+	// resumeErr is why the last automatic reconnect failed
+	resumeErr string
+}
+
+func newPauseTimer() *pauseTimer {
+	return &pauseTimer{
+		now: time.Now,
+		afterFunc: func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		},
+	}
+}
+
+// start calls resume after d, replacing a pause that is already pending
+func (p *pauseTimer) start(d time.Duration, resume func() error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stop != nil {
+		p.stop()
+	}
+	p.generation++
+	generation := p.generation
+	p.stop = p.afterFunc(d, func() {
+		p.fire(generation, resume)
+	})
+	p.resumeAt = p.now().Add(d)
+	p.resumeErr = ""
+}
+
+// fire ends the pause and calls resume, unless the pause it was started for
+// was cancelled or replaced meanwhile
+func (p *pauseTimer) fire(generation uint64, resume func() error) {
+	p.mu.Lock()
+	if p.stop == nil || p.generation != generation {
+		p.mu.Unlock()
+		return
+	}
+	p.stop = nil
+	p.resumeAt = time.Time{}
+	p.mu.Unlock()
+
+	logger.Info("Pause elapsed, reconnecting tunnel")
+	if err := resume(); err != nil {
+		logger.Error("Failed to reconnect after pause: %v", err)
+		p.mu.Lock()
+		if p.generation == generation {
+			p.resumeErr = err.Error()
+		}
+		p.mu.Unlock()
+	}
+}
+
+// cancel stops a pending resume and forgets a failed one. It reports whether
+// the tunnel was paused.
+func (p *pauseTimer) cancel() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.generation++
+	p.resumeErr = ""
+	if p.stop == nil {
+		return false
+	}
+	p.stop()
+	p.stop = nil
+	p.resumeAt = time.Time{}
+	return true
+}
+
+func (p *pauseTimer) status() tunnel.PauseStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return tunnel.PauseStatus{
+		Paused:      p.stop != nil,
+		ResumeAt:    p.resumeAt,
+		ResumeError: p.resumeErr,
+	}
+}
+
+// PauseTunnel stops the tunnel and starts it again with the same config after d
+func (s *ManagerService) PauseTunnel(d time.Duration) error {
+	if d <= 0 {
+		return errors.New("the pause duration must be positive")
+	}
+	startedConfigLock.Lock()
+	config := startedConfig
+	startedConfigLock.Unlock()
+	if config == nil || tunnel.GetState() == tunnel.StateStopped {
+		return errors.New("the tunnel is not connected")
+	}
+
+	if err := stopTunnel(); err != nil {
+		return err
+	}
+	resumeConfig := *config
+	tunnelPause.start(d, func() error {
+		return startTunnel(resumeConfig)
+	})
+	logger.Info("Tunnel paused for %s", d)
+	return nil
+}
+
+// CancelPause keeps a paused tunnel disconnected
+func (s *ManagerService) CancelPause() {
+	if tunnelPause.cancel() {
+		logger.Info("Tunnel pause cancelled")
+	}
+}
+
+// PauseStatus reports whether the tunnel is paused and when it reconnects
+func (s *ManagerService) PauseStatus() tunnel.PauseStatus {
+	return tunnelPause.status()
+}
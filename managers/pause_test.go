@@ -0,0 +1,160 @@
+//go:build windows
+
+package managers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock hands out timers that only fire when the test says so
+type fakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	d       time.Duration
+	f       func()
+	stopped bool
+}
+
+func (c *fakeClock) afterFunc(d time.Duration, f func()) func() bool {
+	timer := &fakeTimer{d: d, f: f}
+	c.timers = append(c.timers, timer)
+	return func() bool {
+		wasPending := !timer.stopped
+		timer.stopped = true
+		return wasPending
+	}
+}
+
+// fire runs the i-th timer's function even if it was stopped, as a timer
+// that already fired while being stopped would
+func (c *fakeClock) fire(i int) {
+	c.timers[i].f()
+}
+
+func newFakePauseTimer() (*pauseTimer, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)}
+	p := &pauseTimer{
+		now:       func() time.Time { return clock.now },
+		afterFunc: clock.afterFunc,
+	}
+	return p, clock
+}
+
+func TestPauseTimerSchedules(t *testing.T) {
+	p, clock := newFakePauseTimer()
+	resumed := 0
+	p.start(15*time.Minute, func() error {
+		resumed++
+		return nil
+	})
+
+	if len(clock.timers) != 1 || clock.timers[0].d != 15*time.Minute {
+		t.Fatalf("timers = %+v, want one for 15m", clock.timers)
+	}
+	status := p.status()
+	if !status.Paused || !status.ResumeAt.Equal(clock.now.Add(15*time.Minute)) {
+		t.Errorf("status = %+v, want paused until %v", status, clock.now.Add(15*time.Minute))
+	}
+	if got := status.Remaining(clock.now.Add(5 * time.Minute)); got != 10*time.Minute {
+		t.Errorf("Remaining() = %v, want 10m", got)
+	}
+
+	clock.fire(0)
+	if resumed != 1 {
+		t.Errorf("resumed %d times, want 1", resumed)
+	}
+	if status := p.status(); status.Paused || !status.ResumeAt.IsZero() {
+		t.Errorf("status after resume = %+v, want not paused", status)
+	}
+
+	// A second fire of the same timer does nothing
+	clock.fire(0)
+	if resumed != 1 {
+		t.Errorf("resumed %d times after firing again, want 1", resumed)
+	}
+}
+
+func TestPauseTimerCancel(t *testing.T) {
+	p, clock := newFakePauseTimer()
+	resumed := 0
+	p.start(5*time.Minute, func() error {
+		resumed++
+		return nil
+	})
+
+	if !p.cancel() {
+		t.Error("cancel() = false, want true while paused")
+	}
+	if !clock.timers[0].stopped {
+		t.Error("timer not stopped by cancel")
+	}
+	if p.status().Paused {
+		t.Error("still paused after cancel")
+	}
+
+	// A timer that fired as it was being stopped must not resume
+	clock.fire(0)
+	if resumed != 0 {
+		t.Errorf("resumed %d times after cancel, want 0", resumed)
+	}
+	if p.cancel() {
+		t.Error("cancel() = true, want false when not paused")
+	}
+}
+
+func TestPauseTimerReplace(t *testing.T) {
+	p, clock := newFakePauseTimer()
+	var resumed []string
+	p.start(5*time.Minute, func() error {
+		resumed = append(resumed, "first")
+		return nil
+	})
+	p.start(60*time.Minute, func() error {
+		resumed = append(resumed, "second")
+		return nil
+	})
+
+	if !clock.timers[0].stopped {
+		t.Error("first timer not stopped when replaced")
+	}
+	if got := p.status().ResumeAt; !got.Equal(clock.now.Add(60 * time.Minute)) {
+		t.Errorf("ResumeAt = %v, want the second pause's", got)
+	}
+
+	clock.fire(0)
+	if len(resumed) != 0 || !p.status().Paused {
+		t.Errorf("replaced timer resumed %q, paused = %v", resumed, p.status().Paused)
+	}
+	clock.fire(1)
+	if len(resumed) != 1 || resumed[0] != "second" {
+		t.Errorf("resumed = %q, want only the second pause", resumed)
+	}
+}
+
+func TestPauseTimerResumeError(t *testing.T) {
+	p, clock := newFakePauseTimer()
+	p.start(5*time.Minute, func() error {
+		return errors.New("service failed to start")
+	})
+	clock.fire(0)
+
+	status := p.status()
+	if status.Paused || status.ResumeError != "service failed to start" {
+		t.Errorf("status = %+v, want not paused with the resume error", status)
+	}
+
+	// Connecting or pausing again forgets the failure
+	p.cancel()
+	if got := p.status().ResumeError; got != "" {
+		t.Errorf("ResumeError after cancel = %q, want empty", got)
+	}
+	p.start(5*time.Minute, func() error { return nil })
+	if got := p.status().ResumeError; got != "" {
+		t.Errorf("ResumeError after a new pause = %q, want empty", got)
+	}
+}
@@ -0,0 +1,94 @@
+//go:build windows
+
+package managers
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+// connectRPC points the IPC client at conn until the test ends
+func connectRPC(t *testing.T, conn net.Conn) {
+	t.Helper()
+	rpcMutex.Lock()
+	rpcEncoder, rpcDecoder = gob.NewEncoder(conn), gob.NewDecoder(conn)
+	rpcMutex.Unlock()
+	t.Cleanup(func() {
+		conn.Close()
+		// A ping left waiting gives up once the connection is closed
+		for atomic.LoadUint32(&pingPending) != 0 {
+			time.Sleep(time.Millisecond)
+		}
+		rpcMutex.Lock()
+		rpcEncoder, rpcDecoder = nil, nil
+		rpcMutex.Unlock()
+	})
+}
+
+func TestPingRoundTrip(t *testing.T) {
+	defer tunnel.SetState(tunnel.GetState())
+	tunnel.SetState(TunnelStateRunning)
+
+	server, client := net.Pipe()
+	go func() {
+		newManagerService(newFakeEvents(nil), 0).ServeConn(server, server)
+		server.Close()
+	}()
+	connectRPC(t, client)
+
+	if err := rpcEncoder.Encode(ProtocolVersion); err != nil {
+		t.Fatal(err)
+	}
+	var managerVersion Version
+	if err := rpcDecoder.Decode(&managerVersion); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	result, err := IPCClientPing()
+	if err != nil {
+		t.Fatalf("IPCClientPing() error = %v", err)
+	}
+	if result.TunnelState != TunnelStateRunning {
+		t.Errorf("TunnelState = %v, want %v", result.TunnelState, TunnelStateRunning)
+	}
+	if result.Uptime <= 0 {
+		t.Errorf("Uptime = %v, want the time since the manager started", result.Uptime)
+	}
+}
+
+// A manager that takes the ping but never answers is reported as timed out
+func TestPingTimesOut(t *testing.T) {
+	oldTimeout := pingTimeout
+	pingTimeout = 50 * time.Millisecond
+	defer func() { pingTimeout = oldTimeout }()
+
+	server, client := net.Pipe()
+	go io.Copy(io.Discard, server)
+	connectRPC(t, client)
+
+	if _, err := IPCClientPing(); !errors.Is(err, ErrPingTimeout) {
+		t.Fatalf("IPCClientPing() error = %v, want ErrPingTimeout", err)
+	}
+
+	// The first ping is still waiting, so another one doesn't pile up behind it
+	start := time.Now()
+	if _, err := IPCClientPing(); !errors.Is(err, ErrPingTimeout) {
+		t.Errorf("second IPCClientPing() error = %v, want ErrPingTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed >= pingTimeout {
+		t.Errorf("second ping took %v, want it to fail at once", elapsed)
+	}
+}
+
+func TestPingWithoutConnection(t *testing.T) {
+	if _, err := IPCClientPing(); err == nil {
+		t.Error("IPCClientPing() succeeded without a connection")
+	}
+}
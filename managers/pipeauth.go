@@ -0,0 +1,94 @@
+//go:build windows
+
+package managers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// The IPC pipes served by ServeConn are anonymous. They are only ever inherited by
+// a UI process the manager launched itself, or duplicated into a UI process that
+// asks for them on the named UI reconnect pipe, which the manager then tracks
+// like one it launched. The named UI launch and reconnect pipes are therefore
+// the only IPC entry points other local processes can reach. Clients of those
+// pipes are authenticated here before any request is acted on.
+
+// pipeClientPID returns the process ID of the client connected to a named pipe
+func pipeClientPID(conn net.Conn) (uint32, error) {
+	fdConn, ok := conn.(interface{ Fd() uintptr })
+	if !ok {
+		return 0, errors.New("connection does not expose a pipe handle")
+	}
+	var pid uint32
+	if err := windows.GetNamedPipeClientProcessId(windows.Handle(fdConn.Fd()), &pid); err != nil {
+		return 0, fmt.Errorf("unable to get pipe client process ID: %w", err)
+	}
+	return pid, nil
+}
+
+// processImagePath returns the full path of the executable backing a process
+func processImagePath(pid uint32) (string, error) {
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(process)
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(process, 0, &buf[0], &size); err != nil {
+		return "", err
+	}
+	return windows.UTF16ToString(buf[:size]), nil
+}
+
+// pipeClient is what authenticating a pipe client looks at
+type pipeClient struct {
+	pid       uint32
+	session   uint32
+	imagePath string
+}
+
+// authenticatePipeClient verifies that the client is our own executable running in the
+// session it is asking about. It returns the client PID, which is valid for logging even on error.
+func authenticatePipeClient(conn net.Conn, sessionID uint32) (uint32, error) {
+	pid, err := pipeClientPID(conn)
+	if err != nil {
+		return 0, err
+	}
+
+	client := pipeClient{pid: pid}
+	if err := windows.ProcessIdToSessionId(pid, &client.session); err != nil {
+		return pid, fmt.Errorf("unable to get client session: %w", err)
+	}
+	if client.imagePath, err = processImagePath(pid); err != nil {
+		return pid, fmt.Errorf("unable to get client image path: %w", err)
+	}
+	ourPath, err := os.Executable()
+	if err != nil {
+		return pid, fmt.Errorf("unable to get our image path: %w", err)
+	}
+	return pid, checkPipeClient(client, sessionID, ourPath)
+}
+
+// checkPipeClient accepts a client that is the executable at ourPath running
+// in the session it asks about
+func checkPipeClient(client pipeClient, sessionID uint32, ourPath string) error {
+	if client.pid == 0 {
+		return errors.New("no client process")
+	}
+	if client.session != sessionID {
+		return fmt.Errorf("client in session %d requested session %d", client.session, sessionID)
+	}
+	if client.imagePath == "" || !strings.EqualFold(filepath.Clean(client.imagePath), filepath.Clean(ourPath)) {
+		return fmt.Errorf("client image %q is not %q", client.imagePath, ourPath)
+	}
+	return nil
+}
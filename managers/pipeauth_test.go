@@ -0,0 +1,124 @@
+//go:build windows
+
+package managers
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckPipeClient(t *testing.T) {
+	const ourPath = `C:\Program Files\Pangolin\Pangolin.exe`
+
+	tests := []struct {
+		name      string
+		client    pipeClient
+		sessionID uint32
+		wantErr   bool
+	}{
+		{
+			name:      "our executable in its session",
+			client:    pipeClient{pid: 1234, session: 2, imagePath: ourPath},
+			sessionID: 2,
+		},
+		{
+			name:      "path differs in case and form",
+			client:    pipeClient{pid: 1234, session: 2, imagePath: `c:\program files\pangolin\.\PANGOLIN.EXE`},
+			sessionID: 2,
+		},
+		{
+			name:      "no process",
+			client:    pipeClient{session: 2, imagePath: ourPath},
+			sessionID: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "another session",
+			client:    pipeClient{pid: 1234, session: 3, imagePath: ourPath},
+			sessionID: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "another executable",
+			client:    pipeClient{pid: 1234, session: 2, imagePath: `C:\Users\someone\Downloads\tool.exe`},
+			sessionID: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "same name elsewhere",
+			client:    pipeClient{pid: 1234, session: 2, imagePath: `C:\Users\someone\Pangolin.exe`},
+			sessionID: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "unknown image",
+			client:    pipeClient{pid: 1234, session: 2},
+			sessionID: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPipeClient(tt.client, tt.sessionID, ourPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkPipeClient() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// sendSessionID writes a session ID as a UI launch or reconnect client does
+// and returns what the manager answers before closing the connection
+func sendSessionID(t *testing.T, client net.Conn, sessionID uint32) []byte {
+	t.Helper()
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := binary.Write(client, binary.LittleEndian, sessionID); err != nil {
+		t.Fatalf("writing the session ID: %v", err)
+	}
+	answer, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatalf("reading the answer: %v (the connection was not closed)", err)
+	}
+	return answer
+}
+
+// A net.Pipe connection has no pipe handle, so its client can't be identified
+func TestUIReconnectRejectsUnauthenticatedClient(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	adopted := false
+	go handleUIReconnectConn(server, func(session, pid uint32) error {
+		adopted = true
+		return nil
+	})
+
+	if answer := sendSessionID(t, client, 1); len(answer) != 0 {
+		t.Errorf("manager answered %v", answer)
+	}
+	if adopted {
+		t.Error("an unauthenticated client was adopted")
+	}
+}
+
+func TestUILaunchRejectsUnauthenticatedClient(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	requests := make(chan uint32, 1)
+	go handleUILaunchConn(server, requests, make(map[uint32]*uiProcess), make(map[uint32]bool), &sync.Mutex{})
+
+	if answer := sendSessionID(t, client, 1); len(answer) != 0 {
+		t.Errorf("manager answered %v", answer)
+	}
+	select {
+	case session := <-requests:
+		t.Errorf("launch requested for session %d", session)
+	default:
+	}
+}
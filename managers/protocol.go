@@ -0,0 +1,145 @@
+//go:build windows
+
+package managers
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProtocolVersion is the version of the IPC protocol between the UI and the manager.
+// Bump Major when a change breaks older peers (e.g. reordering method types) and
+// Minor for additions older peers can live without.
+var ProtocolVersion = Version{Major: 1, Minor: 7}
+
+// managerProtocolVersion is the version the manager reported in the handshake
+var managerProtocolVersion Version
+
+// errMethodNotSupported is returned for requests the connected manager is too old to answer
+var errMethodNotSupported = errors.New("the manager service does not support this request; finish updating Pangolin")
+
+// handshakeTimeout bounds how long the UI waits for the manager's version. A
+// manager that predates the handshake never answers.
+const handshakeTimeout = 10 * time.Second
+
+// Version is a protocol version exchanged when a UI connects to the manager
+type Version struct {
+	Major uint32
+	Minor uint32
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Compatible reports whether peers speaking v and other can talk to each other
+func (v Version) Compatible(other Version) bool {
+	return v.Major == other.Major
+}
+
+// ProtocolVersionError is returned when the UI and the manager speak incompatible
+// protocol versions, which usually means an update hasn't finished
+type ProtocolVersionError struct {
+	UI      Version
+	Manager *Version // nil if the manager didn't report a version
+}
+
+func (e *ProtocolVersionError) Error() string {
+	manager := "unknown"
+	if e.Manager != nil {
+		manager = e.Manager.String()
+	}
+	return fmt.Sprintf("UI protocol version %s is not compatible with manager protocol version %s", e.UI, manager)
+}
+
+// IPCClientHandshake exchanges protocol versions with the manager. It must be called
+// right after InitializeIPCClient, before any other request. Once it succeeds,
+// notifications are read in the form the manager's version sends them. If the
+// manager hangs up or doesn't answer, the connection is closed. An error that
+// wraps ErrIPCTimeout means the manager didn't answer in time, and the UI can
+// try again with IPCClientReconnect.
+func IPCClientHandshake() error {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	if rpcEncoder == nil || rpcDecoder == nil {
+		return errIPCNotConnected
+	}
+
+	err := rpcEncoder.Encode(ProtocolVersion)
+	if err != nil {
+		return err
+	}
+
+	type handshakeReply struct {
+		version Version
+		err     error
+	}
+	done := make(chan handshakeReply, 1)
+	go func() {
+		var reply handshakeReply
+		reply.err = rpcDecoder.Decode(&reply.version)
+		done <- reply
+	}()
+
+	select {
+	case reply := <-done:
+		if reply.err != nil {
+			rpcEncoder, rpcDecoder = nil, nil
+			closeIPCFiles()
+			// An older manager hangs up on a message it doesn't understand
+			return &ProtocolVersionError{UI: ProtocolVersion}
+		}
+		if !ProtocolVersion.Compatible(reply.version) {
+			return &ProtocolVersionError{UI: ProtocolVersion, Manager: &reply.version}
+		}
+		managerProtocolVersion = reply.version
+		go readIPCEvents(rpcEvents, managerSupports(framedNotificationsSince))
+		return nil
+	case <-time.After(handshakeTimeout):
+		// The reply is still being waited for on the decoder, so the connection
+		// can't be used again. Closing the pipes ends that wait; the caller
+		// has to reconnect.
+		rpcEncoder, rpcDecoder = nil, nil
+		closeIPCFiles()
+		return fmt.Errorf("%w: %w", ErrIPCTimeout, &ProtocolVersionError{UI: ProtocolVersion})
+	}
+}
+
+// serveHandshake reads the UI's protocol version and answers with ours. It
+// returns the UI's version, or an error if the connection should not be served.
+func serveHandshake(decoder *gob.Decoder, encoder *gob.Encoder) (Version, error) {
+	var clientVersion Version
+	err := decoder.Decode(&clientVersion)
+	if err != nil {
+		return clientVersion, fmt.Errorf("failed to read protocol version: %w", err)
+	}
+	// Always answer so the UI can tell the user what's wrong
+	err = encoder.Encode(ProtocolVersion)
+	if err != nil {
+		return clientVersion, err
+	}
+	if !ProtocolVersion.Compatible(clientVersion) {
+		return clientVersion, &ProtocolVersionError{UI: clientVersion, Manager: &ProtocolVersion}
+	}
+	return clientVersion, nil
+}
+
+// supports reports whether a peer speaking v also speaks at least version since
+func (v Version) supports(since Version) bool {
+	return v.Major == since.Major && v.Minor >= since.Minor
+}
+
+// managerSupports reports whether the connected manager speaks at least version v.
+// Caller must hold rpcMutex.
+func managerSupports(v Version) bool {
+	return managerProtocolVersion.supports(v)
+}
+
+// IsProtocolVersionError reports whether err is a protocol version mismatch
+func IsProtocolVersionError(err error) bool {
+	var versionErr *ProtocolVersionError
+	return errors.As(err, &versionErr)
+}
@@ -0,0 +1,193 @@
+//go:build windows
+
+package managers
+
+import (
+	"encoding/gob"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestVersionCompatible(t *testing.T) {
+	tests := []struct {
+		name  string
+		other Version
+		want  bool
+	}{
+		{name: "same", other: Version{Major: 1, Minor: 7}, want: true},
+		{name: "older minor", other: Version{Major: 1, Minor: 0}, want: true},
+		{name: "newer minor", other: Version{Major: 1, Minor: 9}, want: true},
+		{name: "older major", other: Version{Major: 0, Minor: 7}},
+		{name: "newer major", other: Version{Major: 2, Minor: 0}},
+	}
+
+	v := Version{Major: 1, Minor: 7}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.Compatible(tt.other); got != tt.want {
+				t.Errorf("%s.Compatible(%s) = %v, want %v", v, tt.other, got, tt.want)
+			}
+			if got := tt.other.Compatible(v); got != tt.want {
+				t.Errorf("%s.Compatible(%s) = %v, want %v", tt.other, v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionSupports(t *testing.T) {
+	tests := []struct {
+		v, since Version
+		want     bool
+	}{
+		{Version{1, 7}, Version{1, 7}, true},
+		{Version{1, 7}, Version{1, 3}, true},
+		{Version{1, 3}, Version{1, 7}, false},
+		{Version{2, 9}, Version{1, 3}, false},
+		{Version{}, Version{1, 0}, false}, // No handshake yet
+	}
+
+	for _, tt := range tests {
+		if got := tt.v.supports(tt.since); got != tt.want {
+			t.Errorf("%s.supports(%s) = %v, want %v", tt.v, tt.since, got, tt.want)
+		}
+	}
+}
+
+func TestServeHandshake(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  Version
+		wantErr bool
+	}{
+		{name: "same version", client: ProtocolVersion},
+		{name: "older UI", client: Version{Major: ProtocolVersion.Major}},
+		{name: "newer UI", client: Version{Major: ProtocolVersion.Major, Minor: ProtocolVersion.Minor + 1}},
+		{name: "other major", client: Version{Major: ProtocolVersion.Major + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer server.Close()
+			defer client.Close()
+			client.SetDeadline(time.Now().Add(5 * time.Second))
+
+			replies := make(chan Version, 1)
+			go func() {
+				var reply Version
+				if gob.NewEncoder(client).Encode(tt.client) == nil && gob.NewDecoder(client).Decode(&reply) == nil {
+					replies <- reply
+				}
+				close(replies)
+			}()
+
+			got, err := serveHandshake(gob.NewDecoder(server), gob.NewEncoder(server))
+			if got != tt.client {
+				t.Errorf("serveHandshake() read %s, want %s", got, tt.client)
+			}
+			if IsProtocolVersionError(err) != tt.wantErr || (err != nil && !tt.wantErr) {
+				t.Errorf("serveHandshake() error = %v, want version error %v", err, tt.wantErr)
+			}
+			// The UI always learns the manager's version, even when it's refused
+			if reply, ok := <-replies; !ok || reply != ProtocolVersion {
+				t.Errorf("UI got %s (%v), want %s", reply, ok, ProtocolVersion)
+			}
+		})
+	}
+}
+
+// connectRPCEvents gives the IPC client an events pipe until the test ends
+func connectRPCEvents(t *testing.T) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpcMutex.Lock()
+	rpcEvents = reader
+	rpcMutex.Unlock()
+	t.Cleanup(func() {
+		// Cleared first, so the event reader ends without reconnecting
+		rpcMutex.Lock()
+		if rpcEvents == reader {
+			rpcEvents = nil
+		}
+		rpcMutex.Unlock()
+		writer.Close()
+		reader.Close()
+	})
+}
+
+func TestIPCClientHandshake(t *testing.T) {
+	newerMajor := Version{Major: ProtocolVersion.Major + 1}
+	newerMinor := Version{Major: ProtocolVersion.Major, Minor: ProtocolVersion.Minor + 1}
+	tests := []struct {
+		name        string
+		manager     *Version // nil if the manager hangs up instead of answering
+		wantErr     bool
+		wantManager *Version // The manager version in the error
+	}{
+		{name: "same version", manager: &ProtocolVersion},
+		{name: "newer manager", manager: &newerMinor},
+		{name: "incompatible manager", manager: &newerMajor, wantErr: true, wantManager: &newerMajor},
+		{name: "manager without a handshake", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldManagerVersion := managerProtocolVersion
+			defer func() { managerProtocolVersion = oldManagerVersion }()
+
+			server, client := net.Pipe()
+			defer server.Close()
+			connectRPC(t, client)
+			connectRPCEvents(t)
+
+			received := make(chan Version, 1)
+			go func() {
+				var uiVersion Version
+				if gob.NewDecoder(server).Decode(&uiVersion) != nil {
+					return
+				}
+				received <- uiVersion
+				if tt.manager == nil {
+					// An older manager hangs up on a message it doesn't understand
+					server.Close()
+					return
+				}
+				gob.NewEncoder(server).Encode(*tt.manager)
+			}()
+
+			err := IPCClientHandshake()
+			if got := <-received; got != ProtocolVersion {
+				t.Errorf("manager received %s, want %s", got, ProtocolVersion)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("IPCClientHandshake() error = %v", err)
+				}
+				if managerProtocolVersion != *tt.manager {
+					t.Errorf("manager version = %s, want %s", managerProtocolVersion, *tt.manager)
+				}
+				return
+			}
+
+			var versionErr *ProtocolVersionError
+			if !errors.As(err, &versionErr) {
+				t.Fatalf("IPCClientHandshake() error = %v, want a ProtocolVersionError", err)
+			}
+			if versionErr.UI != ProtocolVersion {
+				t.Errorf("error UI version = %s, want %s", versionErr.UI, ProtocolVersion)
+			}
+			switch {
+			case tt.wantManager == nil && versionErr.Manager != nil:
+				t.Errorf("error manager version = %s, want none", versionErr.Manager)
+			case tt.wantManager != nil && (versionErr.Manager == nil || *versionErr.Manager != *tt.wantManager):
+				t.Errorf("error manager version = %v, want %s", versionErr.Manager, tt.wantManager)
+			}
+		})
+	}
+}
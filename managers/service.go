@@ -5,6 +5,7 @@ package managers
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net"
 	"os"
 	"path/filepath"
@@ -247,8 +248,49 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 			procsGroup.Done()
 		}()
 	}
+	// adoptProcess tracks a UI process that reconnected through the reconnect
+	// pipe as if startProcess had launched it, so that it is killed when its
+	// session logs off or the manager stops. A UI already tracked for the
+	// session is only accepted if it is the same process.
+	adoptProcess := func(session, pid uint32) error {
+		procsLock.Lock()
+		defer procsLock.Unlock()
+		if stoppingManager {
+			return errors.New("manager is stopping")
+		}
+		if proc, ok := procs[session]; ok {
+			if proc.pid != pid {
+				return fmt.Errorf("session already has UI process %d", proc.pid)
+			}
+			return nil
+		}
+		proc, err := openUIProcess(pid)
+		if err != nil {
+			return fmt.Errorf("unable to open process: %w", err)
+		}
+		procs[session] = proc
+		aliveSessions[session] = true
+		procsGroup.Add(1)
+		go func() {
+			defer procsGroup.Done()
+			if exitCode, waitErr := proc.Wait(); waitErr == nil {
+				logger.Info("Exited reconnected UI process %d for session %d with status %x", pid, session, exitCode)
+			} else {
+				logger.Error("Unable to wait for reconnected UI process %d for session %d: %v", pid, session, waitErr)
+			}
+			procsLock.Lock()
+			if procs[session] == proc {
+				delete(procs, session)
+			}
+			procsLock.Unlock()
+		}()
+		return nil
+	}
 
+	loadUpdateChannel()
 	go checkForUpdates()
+	stopStats := make(chan struct{})
+	go runStatsNotifier(stopStats)
 	// TODO: Add driver cleanup when driver package is implemented
 	// go driver.UninstallLegacyWintun()
 
@@ -269,12 +311,26 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 		pipeListener = listener
 		go runUILaunchPipeListener(listener, requestUILaunchChan, procs, aliveSessions, &procsLock)
 	}
+	// Lets a UI that outlived a previous manager, e.g. one that crashed, reconnect
+	var reconnectListener net.Listener
+	listener, listenErr = winio.ListenPipe(uiReconnectPipePath, pipeConfig)
+	if listenErr != nil {
+		logger.Error("Failed to create UI reconnect pipe listener: %v", listenErr)
+	} else {
+		reconnectListener = listener
+		go runUIReconnectPipeListener(listener, adoptProcess)
+	}
 
 	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptSessionChange}
 
 	// If restart-ui-after-update flag exists (written before MSI run), launch UI for active session then remove flag.
 	go func() {
-		flagPath := filepath.Join(config.GetProgramDataDir(), "restart-ui-after-update.flag")
+		programDataDir, err := config.ResolveProgramDataDir()
+		if err != nil {
+			logger.Error("Not checking for the restart-ui flag: %v", err)
+			return
+		}
+		flagPath := filepath.Join(programDataDir, "restart-ui-after-update.flag")
 		if _, statErr := os.Stat(flagPath); statErr != nil {
 			return
 		}
@@ -352,6 +408,7 @@ loop:
 	}
 
 	changes <- svc.Status{State: svc.StopPending}
+	close(stopStats)
 	procsLock.Lock()
 	stoppingManager = true
 	IPCServerNotifyManagerStopping()
@@ -362,6 +419,9 @@ loop:
 	if pipeListener != nil {
 		_ = pipeListener.Close()
 	}
+	if reconnectListener != nil {
+		_ = reconnectListener.Close()
+	}
 	procsGroup.Wait()
 	if uninstall {
 		err = UninstallManager()
@@ -394,6 +454,12 @@ func handleUILaunchConn(conn net.Conn, requestCh chan<- uint32, procs map[uint32
 		return
 	}
 
+	// Reject anything that isn't our own executable asking for its own session
+	if pid, err := authenticatePipeClient(conn, sessionID); err != nil {
+		logger.Warn("UI launch pipe: rejected connection from PID %d: %v", pid, err)
+		return
+	}
+
 	var response uint32
 	procsLock.Lock()
 	if _, ok := procs[sessionID]; ok {
@@ -0,0 +1,212 @@
+//go:build windows
+
+package managers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+// siteEnforceInterval is how often peers OLM put back for disconnected sites
+// are taken off again
+const siteEnforceInterval = 2 * time.Second
+
+// tunnelSites keeps the sites the user disconnected off the tunnel
+var tunnelSites = newSiteSwitch()
+
+// siteSwitch connects and disconnects single sites of the running tunnel.
+// OLM has no way to do that, so a disconnected site's WireGuard peer is taken
+// off the device. OLM puts peers back when it relays or updates them, so they
+// are taken off again until the site is connected or the tunnel stops.
+type siteSwitch struct {
+	mu sync.Mutex
+	// disconnected maps the disconnected sites to the peers taken off for them
+	disconnected map[int]tunnel.WGPeer
+	// stopEnforcing ends the loop taking peers off again, nil while it isn't running
+	stopEnforcing context.CancelFunc
+
+	// getPeers and setConfig talk to the WireGuard device unless replaced by tests
+	getPeers  func() ([]tunnel.WGPeer, error)
+	setConfig func(config string) error
+}
+
+func newSiteSwitch() *siteSwitch {
+	return &siteSwitch{
+		disconnected: make(map[int]tunnel.WGPeer),
+		getPeers:     tunnel.GetWGPeers,
+		setConfig:    tunnel.SetWGConfig,
+	}
+}
+
+// disconnect takes the peer serving the site with the tunnel address peerIP off the device
+func (s *siteSwitch) disconnect(siteID int, peerIP string) error {
+	addr, err := tunnel.ParsePeerAddress(peerIP)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.disconnected[siteID]; ok {
+		return nil
+	}
+
+	peers, err := s.getPeers()
+	if err != nil {
+		return err
+	}
+	peer, ok := tunnel.PeerForAddress(peers, addr)
+	if !ok {
+		return fmt.Errorf("no tunnel peer serves site %d", siteID)
+	}
+	for otherID, other := range s.disconnected {
+		if other.PublicKey == peer.PublicKey {
+			return fmt.Errorf("site %d shares its peer with disconnected site %d", siteID, otherID)
+		}
+	}
+	if err := s.setConfig(peer.RemoveConfig()); err != nil {
+		return err
+	}
+	s.disconnected[siteID] = peer
+	logger.Info("Site %d disconnected", siteID)
+	return nil
+}
+
+// connect puts a disconnected site's peer back on the device
+func (s *siteSwitch) connect(siteID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	peer, ok := s.disconnected[siteID]
+	if !ok {
+		return nil
+	}
+	if err := s.setConfig(peer.AddConfig()); err != nil {
+		return err
+	}
+	delete(s.disconnected, siteID)
+	logger.Info("Site %d connected", siteID)
+	return nil
+}
+
+// enforce takes the peers of disconnected sites off the device again if OLM
+// put them back
+func (s *siteSwitch) enforce() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.disconnected) == 0 {
+		return nil
+	}
+	peers, err := s.getPeers()
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		present[peer.PublicKey] = true
+	}
+
+	var errs []error
+	for siteID, peer := range s.disconnected {
+		if !present[peer.PublicKey] {
+			continue
+		}
+		if err := s.setConfig(peer.RemoveConfig()); err != nil {
+			errs = append(errs, fmt.Errorf("site %d: %w", siteID, err))
+			continue
+		}
+		logger.Info("Site %d: peer was added back, disconnected it again", siteID)
+	}
+	return errors.Join(errs...)
+}
+
+// reset forgets the disconnected sites, as a new connection starts with all of them
+func (s *siteSwitch) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.disconnected)
+	if s.stopEnforcing != nil {
+		s.stopEnforcing()
+		s.stopEnforcing = nil
+	}
+}
+
+// list returns the disconnected sites in order
+func (s *siteSwitch) list() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sites := make([]int, 0, len(s.disconnected))
+	for siteID := range s.disconnected {
+		sites = append(sites, siteID)
+	}
+	sort.Ints(sites)
+	return sites
+}
+
+// startEnforcing runs enforce until reset, unless it already runs
+func (s *siteSwitch) startEnforcing() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopEnforcing != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopEnforcing = cancel
+	go func() {
+		ticker := time.NewTicker(siteEnforceInterval)
+		defer ticker.Stop()
+		var lastErr string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := s.enforce(); err != nil && err.Error() != lastErr {
+				lastErr = err.Error()
+				logger.Error("Failed to keep sites disconnected: %v", err)
+			}
+		}
+	}()
+}
+
+// SetSiteConnected connects or disconnects a single site of the running tunnel
+func (s *ManagerService) SetSiteConnected(siteID int, connected bool) error {
+	if tunnel.GetState() == tunnel.StateStopped {
+		return errors.New("the tunnel is not connected")
+	}
+	if connected {
+		return tunnelSites.connect(siteID)
+	}
+
+	status, err := tunnel.FetchOLMStatus()
+	if err != nil {
+		return err
+	}
+	peer, ok := status.PeerStatuses[siteID]
+	if !ok || peer == nil || peer.PeerIP == "" {
+		return fmt.Errorf("site %d is not part of the tunnel", siteID)
+	}
+	if err := tunnelSites.disconnect(siteID, peer.PeerIP); err != nil {
+		return err
+	}
+	tunnelSites.startEnforcing()
+	return nil
+}
+
+// DisconnectedSites returns the sites the user disconnected from the running tunnel
+func (s *ManagerService) DisconnectedSites() []int {
+	return tunnelSites.list()
+}
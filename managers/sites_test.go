@@ -0,0 +1,224 @@
+//go:build windows
+
+package managers
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+// fakeDevice stands in for the tunnel's WireGuard device
+type fakeDevice struct {
+	peers   map[string]tunnel.WGPeer
+	configs []string
+	getErr  error
+	setErr  error
+}
+
+func newFakeDevice(peers ...tunnel.WGPeer) *fakeDevice {
+	d := &fakeDevice{peers: make(map[string]tunnel.WGPeer)}
+	for _, peer := range peers {
+		d.peers[peer.PublicKey] = peer
+	}
+	return d
+}
+
+func (d *fakeDevice) getPeers() ([]tunnel.WGPeer, error) {
+	if d.getErr != nil {
+		return nil, d.getErr
+	}
+	var peers []tunnel.WGPeer
+	for _, peer := range d.peers {
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+func (d *fakeDevice) setConfig(config string) error {
+	if d.setErr != nil {
+		return d.setErr
+	}
+	d.configs = append(d.configs, config)
+	key := strings.TrimPrefix(strings.SplitN(config, "\n", 2)[0], "public_key=")
+	if strings.Contains(config, "remove=true") {
+		delete(d.peers, key)
+	} else {
+		d.peers[key] = tunnel.WGPeer{PublicKey: key}
+	}
+	return nil
+}
+
+func newTestSiteSwitch(d *fakeDevice) *siteSwitch {
+	s := newSiteSwitch()
+	s.getPeers = d.getPeers
+	s.setConfig = d.setConfig
+	return s
+}
+
+var (
+	siteAPeer = tunnel.WGPeer{
+		PublicKey:  "aa",
+		Endpoint:   "203.0.113.5:51820",
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("100.90.128.1/32"), netip.MustParsePrefix("10.1.0.0/16")},
+	}
+	siteBPeer = tunnel.WGPeer{
+		PublicKey:  "bb",
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("100.90.128.2/32")},
+	}
+)
+
+func TestSiteSwitchDisconnectAndConnect(t *testing.T) {
+	d := newFakeDevice(siteAPeer, siteBPeer)
+	s := newTestSiteSwitch(d)
+
+	if err := s.disconnect(1, "100.90.128.1/32"); err != nil {
+		t.Fatalf("disconnect() error = %v", err)
+	}
+	if _, ok := d.peers["aa"]; ok {
+		t.Error("site 1's peer still on the device")
+	}
+	if _, ok := d.peers["bb"]; !ok {
+		t.Error("site 2's peer was removed")
+	}
+	if got := s.list(); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("list() = %v, want [1]", got)
+	}
+
+	// Disconnecting again changes nothing
+	if err := s.disconnect(1, "100.90.128.1"); err != nil {
+		t.Fatalf("second disconnect() error = %v", err)
+	}
+	if len(d.configs) != 1 {
+		t.Errorf("device configured %d times, want 1", len(d.configs))
+	}
+
+	if err := s.connect(1); err != nil {
+		t.Fatalf("connect() error = %v", err)
+	}
+	if got, want := d.configs[len(d.configs)-1], siteAPeer.AddConfig(); got != want {
+		t.Errorf("connect() applied %q, want %q", got, want)
+	}
+	if got := s.list(); len(got) != 0 {
+		t.Errorf("list() after connect = %v, want none", got)
+	}
+
+	// Connecting a site that wasn't disconnected changes nothing
+	configs := len(d.configs)
+	if err := s.connect(2); err != nil {
+		t.Fatalf("connect() of a connected site error = %v", err)
+	}
+	if len(d.configs) != configs {
+		t.Error("connecting a connected site configured the device")
+	}
+}
+
+func TestSiteSwitchDisconnectFails(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name    string
+		peerIP  string
+		getErr  error
+		setErr  error
+		already int // a site disconnected first, 0 for none
+	}{
+		{name: "bad address", peerIP: "site"},
+		{name: "no peer for the address", peerIP: "192.0.2.1"},
+		{name: "device unreadable", peerIP: "100.90.128.1", getErr: errFailed},
+		{name: "device refuses", peerIP: "100.90.128.1", setErr: errFailed},
+		{name: "peer shared with a disconnected site", peerIP: "10.1.2.3", already: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newFakeDevice(siteAPeer, siteBPeer)
+			s := newTestSiteSwitch(d)
+			if tt.already != 0 {
+				if err := s.disconnect(tt.already, "100.90.128.1"); err != nil {
+					t.Fatalf("disconnect() of site %d error = %v", tt.already, err)
+				}
+				d.peers["aa"] = siteAPeer
+			}
+			d.getErr, d.setErr = tt.getErr, tt.setErr
+
+			if err := s.disconnect(1, tt.peerIP); err == nil {
+				t.Error("disconnect() succeeded")
+			}
+			for _, siteID := range s.list() {
+				if siteID == 1 {
+					t.Error("site recorded as disconnected after a failure")
+				}
+			}
+		})
+	}
+}
+
+func TestSiteSwitchEnforce(t *testing.T) {
+	d := newFakeDevice(siteAPeer, siteBPeer)
+	s := newTestSiteSwitch(d)
+	if err := s.disconnect(1, "100.90.128.1"); err != nil {
+		t.Fatalf("disconnect() error = %v", err)
+	}
+
+	// Nothing to do while the peer stays off
+	configs := len(d.configs)
+	if err := s.enforce(); err != nil {
+		t.Fatalf("enforce() error = %v", err)
+	}
+	if len(d.configs) != configs {
+		t.Error("enforce() configured the device with nothing to undo")
+	}
+
+	// OLM puts the peer back, e.g. when relaying it
+	d.peers["aa"] = siteAPeer
+	if err := s.enforce(); err != nil {
+		t.Fatalf("enforce() error = %v", err)
+	}
+	if _, ok := d.peers["aa"]; ok {
+		t.Error("enforce() left the peer OLM added back")
+	}
+	if _, ok := d.peers["bb"]; !ok {
+		t.Error("enforce() removed a connected site's peer")
+	}
+
+	d.peers["aa"] = siteAPeer
+	d.setErr = errors.New("failed")
+	if err := s.enforce(); err == nil {
+		t.Error("enforce() hid the device's error")
+	}
+}
+
+func TestSiteSwitchReset(t *testing.T) {
+	d := newFakeDevice(siteAPeer, siteBPeer)
+	s := newTestSiteSwitch(d)
+	if err := s.disconnect(1, "100.90.128.1"); err != nil {
+		t.Fatalf("disconnect() error = %v", err)
+	}
+	if err := s.disconnect(2, "100.90.128.2"); err != nil {
+		t.Fatalf("disconnect() error = %v", err)
+	}
+	s.startEnforcing()
+
+	s.reset()
+	if got := s.list(); len(got) != 0 {
+		t.Errorf("list() after reset = %v, want none", got)
+	}
+	if s.stopEnforcing != nil {
+		t.Error("reset() left the enforcing loop running")
+	}
+
+	// A new connection's peers are left alone
+	d.peers["aa"] = siteAPeer
+	configs := len(d.configs)
+	if err := s.enforce(); err != nil {
+		t.Fatalf("enforce() error = %v", err)
+	}
+	if len(d.configs) != configs {
+		t.Error("enforce() after reset configured the device")
+	}
+}
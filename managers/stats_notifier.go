@@ -0,0 +1,49 @@
+//go:build windows
+
+package managers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/tunnel"
+)
+
+// statsNotifyInterval is how often the manager pushes a stats snapshot while a
+// tunnel is up. Snapshots are never sent more often, however many UIs listen.
+const statsNotifyInterval = tunnel.SnapshotInterval
+
+// StatsSnapshot is exported for use in UI
+type StatsSnapshot = tunnel.StatsSnapshot
+
+// runStatsNotifier pushes a snapshot of the OLM status and the adapter's
+// traffic to the connected UIs every statsNotifyInterval until stop is closed.
+// Nothing is read while no UI listens or no tunnel is up.
+func runStatsNotifier(stop <-chan struct{}) {
+	ticker := time.NewTicker(statsNotifyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if !haveStatsListeners() {
+			continue
+		}
+		status, err := tunnel.FetchOLMStatus()
+		if err != nil {
+			if !errors.Is(err, tunnel.ErrTunnelNotRunning) {
+				logger.Debug("Failed to read OLM status for stats: %v", err)
+			}
+			continue
+		}
+		var traffic *tunnel.Stats
+		if stats, err := tunnel.AdapterStats(); err == nil {
+			traffic = &stats
+		}
+		IPCServerNotifyStats(tunnel.NewStatsSnapshot(status, traffic))
+	}
+}
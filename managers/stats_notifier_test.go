@@ -0,0 +1,71 @@
+//go:build windows
+
+package managers
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+func TestStatsNotificationRoundTrip(t *testing.T) {
+	var snapshots []StatsSnapshot
+	stats := registerNotification(StatsNotificationType, func(snapshot StatsSnapshot) {
+		snapshots = append(snapshots, snapshot)
+	})
+	defer stats.unregister()
+
+	status := &tunnel.OLMStatusResponse{
+		Connected:    true,
+		Registered:   true,
+		PeerStatuses: map[int]*tunnel.OLMPeerStatus{1: {SiteID: 1, SiteName: "Office", Connected: true, RTT: 20 * time.Millisecond}},
+	}
+	first := tunnel.NewStatsSnapshot(status, &tunnel.Stats{RxBytes: 4096, TxBytes: 1024})
+	first.Taken = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	second := tunnel.NewStatsSnapshot(status, nil)
+	second.Taken = first.Taken.Add(statsNotifyInterval)
+
+	var stream bytes.Buffer
+	writeNotifications(t, &stream, true,
+		mustNotification(t, StatsNotificationType, first),
+		mustNotification(t, StatsNotificationType, second),
+	)
+	readAll(t, &stream, true)
+
+	if want := []StatsSnapshot{first, second}; !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("snapshots = %+v, want %+v", snapshots, want)
+	}
+}
+
+func TestHaveStatsListeners(t *testing.T) {
+	tests := []struct {
+		name    string
+		clients []Version // The protocol versions of the connected UIs
+		want    bool
+	}{
+		{name: "no UI"},
+		{name: "UI before the handshake", clients: []Version{{}}},
+		{name: "UI older than stats", clients: []Version{{Major: 1, Minor: 2}}},
+		{name: "current UI", clients: []Version{ProtocolVersion}, want: true},
+		{name: "older and current UIs", clients: []Version{{Major: 1, Minor: 2}, ProtocolVersion}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, version := range tt.clients {
+				s := newManagerService(newFakeEvents(nil), 0)
+				s.clientVersion = version
+				managerServicesLock.Lock()
+				managerServices[s] = true
+				managerServicesLock.Unlock()
+				t.Cleanup(s.prune)
+			}
+			if got := haveStatsListeners(); got != tt.want {
+				t.Errorf("haveStatsListeners() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+//go:build windows
+
+package managers
+
+import (
+	"encoding/gob"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+var allTunnelStates = []TunnelState{
+	TunnelStateStopped,
+	TunnelStateStarting,
+	TunnelStateRegistering,
+	TunnelStateRegistered,
+	TunnelStateRunning,
+	TunnelStateReconnecting,
+	tunnel.StateStopping,
+	tunnel.StateInvalid,
+	tunnel.StateError,
+}
+
+func TestTunnelStateGobRoundTrip(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	// The states share one stream, as replies do on the IPC pipe
+	go func() {
+		encoder := gob.NewEncoder(server)
+		for _, state := range allTunnelStates {
+			if err := encoder.Encode(state); err != nil {
+				return
+			}
+		}
+	}()
+
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	decoder := gob.NewDecoder(client)
+	for _, want := range allTunnelStates {
+		var got TunnelState
+		if err := decoder.Decode(&got); err != nil {
+			t.Fatalf("decoding %v: %v", want, err)
+		}
+		if got != want {
+			t.Errorf("decoded %v, want %v", got, want)
+		}
+	}
+}
+
+func TestServeTunnelStatus(t *testing.T) {
+	defer tunnel.SetState(tunnel.GetState())
+
+	server, client := net.Pipe()
+	defer client.Close()
+	go func() {
+		newManagerService(newFakeEvents(nil), 0).ServeConn(server, server)
+		server.Close()
+	}()
+
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	encoder := gob.NewEncoder(client)
+	decoder := gob.NewDecoder(client)
+	if err := encoder.Encode(ProtocolVersion); err != nil {
+		t.Fatal(err)
+	}
+	var managerVersion Version
+	if err := decoder.Decode(&managerVersion); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	for _, want := range allTunnelStates {
+		tunnel.SetState(want)
+		if err := encoder.Encode(TunnelStatusMethodType); err != nil {
+			t.Fatal(err)
+		}
+		var got TunnelState
+		if err := decoder.Decode(&got); err != nil {
+			t.Fatalf("TunnelStatus reply: %v", err)
+		}
+		if got != want {
+			t.Errorf("TunnelStatus = %v, want %v", got, want)
+		}
+	}
+}
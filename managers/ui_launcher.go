@@ -17,10 +17,10 @@ const uiLaunchPipePath = `\\.\pipe\pangolin-manager-ui-launch`
 // a UI launch for the current session. Returns true if the UI was successfully
 // launched (or already running), false otherwise.
 func RequestUILaunch() bool {
-	// Get current session ID
-	sessionID := windows.WTSGetActiveConsoleSessionId()
-	if sessionID == 0 {
-		logger.Error("Failed to get current session ID (returned 0)")
+	// Get the session this process runs in; the manager only accepts requests for the caller's own session
+	var sessionID uint32
+	if err := windows.ProcessIdToSessionId(windows.GetCurrentProcessId(), &sessionID); err != nil || sessionID == 0 {
+		logger.Error("Failed to get current session ID: %v", err)
 		return false
 	}
 
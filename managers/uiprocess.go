@@ -14,6 +14,7 @@ import (
 
 type uiProcess struct {
 	handle uintptr
+	pid    uint32
 }
 
 func launchUIProcess(executable string, args []string, workingDirectory string, handles []windows.Handle, token windows.Token) (*uiProcess, error) {
@@ -54,7 +55,19 @@ func launchUIProcess(executable string, args []string, workingDirectory string,
 		return nil, err
 	}
 	windows.CloseHandle(pi.Thread)
-	uiProc := &uiProcess{handle: uintptr(pi.Process)}
+	uiProc := &uiProcess{handle: uintptr(pi.Process), pid: pi.ProcessId}
+	runtime.SetFinalizer(uiProc, (*uiProcess).release)
+	return uiProc, nil
+}
+
+// openUIProcess opens a running UI process the manager didn't launch, such as one
+// that outlived a previous manager, so that it can be waited for and killed
+func openUIProcess(pid uint32) (*uiProcess, error) {
+	handle, err := windows.OpenProcess(windows.SYNCHRONIZE|windows.PROCESS_TERMINATE|windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return nil, err
+	}
+	uiProc := &uiProcess{handle: uintptr(handle), pid: pid}
 	runtime.SetFinalizer(uiProc, (*uiProcess).release)
 	return uiProc, nil
 }
@@ -3,12 +3,15 @@
 package managers
 
 import (
+	"sync"
 	"time"
 	_ "unsafe"
 
 	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
 	"github.com/fosrl/windows/services"
 	"github.com/fosrl/windows/updater"
+	"github.com/fosrl/windows/version"
 )
 
 //go:linkname fastrandn runtime.fastrandn
@@ -24,27 +27,109 @@ const (
 
 var updateState = UpdateStateUnknown
 
+// UpdateInfo describes the update that was found, for display in the UI
+type UpdateInfo struct {
+	Version        string
+	ReleaseNotes   string // Empty if the release has no notes
+	ReleasePageURL string
+}
+
+var (
+	foundUpdate     UpdateInfo
+	foundUpdateLock sync.RWMutex
+)
+
+func setFoundUpdate(update *updater.UpdateFound) {
+	foundUpdateLock.Lock()
+	defer foundUpdateLock.Unlock()
+	if update == nil {
+		foundUpdate = UpdateInfo{}
+		return
+	}
+	foundUpdate = UpdateInfo{
+		Version:        update.Version(),
+		ReleaseNotes:   update.ReleaseNotes(),
+		ReleasePageURL: update.ReleasePageURL(),
+	}
+}
+
+func getFoundUpdate() UpdateInfo {
+	foundUpdateLock.RLock()
+	defer foundUpdateLock.RUnlock()
+	return foundUpdate
+}
+
+// updateConfig holds the manager's own settings, such as the update channel
+var updateConfig *config.ConfigManager
+
+// loadUpdateChannel applies the persisted update channel to the updater.
+// Must be called before checkForUpdates or any IPC client connects.
+func loadUpdateChannel() {
+	updateConfig = config.NewConfigManager()
+	channel := updateConfig.GetUpdateChannel()
+	updater.SetChannel(channel)
+	logger.Info("Update channel: %s", channel)
+}
+
+// checkForUpdatesNow runs a single update check, e.g. after the channel changed or
+// when the user asks, and notifies clients if the result changed the state
+func checkForUpdatesNow() (UpdateState, error) {
+	if updateState == UpdateStateUpdatesDisabledUnofficialBuild {
+		return updateState, nil
+	}
+	update, err := updater.CheckForUpdate()
+	if err != nil {
+		logger.Error("Update checker: %v", err)
+		return updateState, err
+	}
+	setFoundUpdate(update)
+	newState := UpdateStateUnknown
+	if update != nil {
+		newState = UpdateStateFoundUpdate
+	}
+	if newState != updateState {
+		updateState = newState
+		IPCServerNotifyUpdateFound(updateState)
+	}
+	return updateState, nil
+}
+
+// updateCheckSchedule re-reads the manager config and returns whether automatic
+// checks are enabled and how often they should run
+func updateCheckSchedule() (enabled bool, interval time.Duration) {
+	if updateConfig == nil {
+		return config.DefaultAutoUpdateCheck, config.DefaultUpdateCheckInterval
+	}
+	updateConfig.Load()
+	return updateConfig.GetAutoUpdateCheck(), updateConfig.GetUpdateCheckInterval()
+}
+
+// disabledUpdateCheckPoll is how often the disabled state is re-read from config
+const disabledUpdateCheckPoll = 10 * time.Minute
+
+// updateCheckWait returns the range the automatic checker waits in between two
+// checks, jittered by 5% of interval so machines don't all check at once
+func updateCheckWait(interval time.Duration) (min, max time.Duration) {
+	jitter := interval / 20
+	return interval - jitter, interval + jitter
+}
+
 func jitterSleep(min, max time.Duration) {
 	time.Sleep(min + time.Millisecond*time.Duration(fastrandn(uint32((max-min+1)/time.Millisecond))))
 }
 
 func checkForUpdates() {
 	// Check if running official version, with dev mode support
-	// isOfficial := version.IsRunningOfficialVersion()
-	// if !isOfficial {
-	// 	// Allow dev mode updates via environment variable (same as updater package)
-	// 	devMode := false
-	// 	if os.Getenv("PANGOLIN_ALLOW_DEV_UPDATES") == "1" {
-	// 		devMode = true
-	// 	}
-	// 	if !devMode {
-	// 		logger.Info("Build is not official, so updates are disabled")
-	// 		updateState = UpdateStateUpdatesDisabledUnofficialBuild
-	// 		IPCServerNotifyUpdateFound(updateState)
-	// 		return
-	// 	}
-	// 	logger.Info("Development mode enabled - allowing updates on unsigned build")
-	// }
+	allowed, devMode := version.UpdatesAllowed()
+	if !allowed {
+		logger.Info("Build is not official, so updates are disabled")
+		updateState = UpdateStateUpdatesDisabledUnofficialBuild
+		IPCServerNotifyUpdateFound(updateState)
+		return
+	}
+	if devMode {
+		logger.Info("Development mode enabled - allowing updates on unofficial build")
+	}
 
 	// Initial jitter if started at boot - prevents all machines from checking at once after boot
 	if services.StartedAtBoot() {
@@ -53,9 +138,17 @@ func checkForUpdates() {
 
 	noError, didNotify := true, false
 	for {
+		enabled, interval := updateCheckSchedule()
+		if !enabled {
+			// Manual checks still go through checkForUpdatesNow
+			time.Sleep(disabledUpdateCheckPoll)
+			continue
+		}
+
 		update, err := updater.CheckForUpdate()
 		if err == nil && update != nil && !didNotify {
 			logger.Info("An update is available")
+			setFoundUpdate(update)
 			updateState = UpdateStateFoundUpdate
 			IPCServerNotifyUpdateFound(updateState)
 			didNotify = true
@@ -68,7 +161,7 @@ func checkForUpdates() {
 				jitterSleep(time.Minute*25, time.Minute*30)
 			}
 		} else {
-			jitterSleep(time.Hour-time.Minute*3, time.Hour+time.Minute*3)
+			jitterSleep(updateCheckWait(interval))
 		}
 	}
 }
@@ -0,0 +1,67 @@
+//go:build windows
+
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/config"
+)
+
+func TestUpdateCheckSchedule(t *testing.T) {
+	defer func(cm *config.ConfigManager) { updateConfig = cm }(updateConfig)
+
+	tests := []struct {
+		name         string
+		config       string // The manager's config file, none if empty
+		wantEnabled  bool
+		wantInterval time.Duration
+	}{
+		{name: "no config", wantEnabled: true, wantInterval: config.DefaultUpdateCheckInterval},
+		{name: "daily", config: `{"updateCheckIntervalMinutes": 1440}`, wantEnabled: true, wantInterval: 24 * time.Hour},
+		{name: "too often", config: `{"updateCheckIntervalMinutes": 1}`, wantEnabled: true, wantInterval: config.MinUpdateCheckInterval},
+		{name: "disabled", config: `{"autoUpdateCheck": false}`, wantInterval: config.DefaultUpdateCheckInterval},
+		{name: "zero interval", config: `{"updateCheckIntervalMinutes": 0}`, wantInterval: config.MinUpdateCheckInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appData := t.TempDir()
+			t.Setenv("LOCALAPPDATA", appData)
+			updateConfig = config.NewConfigManager()
+
+			// The schedule is re-read on every pass, so a change needs no restart
+			if tt.config != "" {
+				path := filepath.Join(appData, config.AppName, config.ConfigFileName)
+				if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			enabled, interval := updateCheckSchedule()
+			if enabled != tt.wantEnabled || interval != tt.wantInterval {
+				t.Errorf("updateCheckSchedule() = %v, %v, want %v, %v", enabled, interval, tt.wantEnabled, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestUpdateCheckWait(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		min, max time.Duration
+	}{
+		{interval: time.Hour, min: 57 * time.Minute, max: 63 * time.Minute},
+		{interval: 24 * time.Hour, min: 22*time.Hour + 48*time.Minute, max: 25*time.Hour + 12*time.Minute},
+		{interval: config.MinUpdateCheckInterval, min: 14*time.Minute + 15*time.Second, max: 15*time.Minute + 45*time.Second},
+	}
+
+	for _, tt := range tests {
+		min, max := updateCheckWait(tt.interval)
+		if min != tt.min || max != tt.max {
+			t.Errorf("updateCheckWait(%v) = %v, %v, want %v, %v", tt.interval, min, max, tt.min, tt.max)
+		}
+	}
+}
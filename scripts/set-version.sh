@@ -75,7 +75,7 @@ echo "Next steps:"
 echo "  1. Review the changes:"
 echo "     git diff version/version.go installer/pangolin.wxs"
 echo "  2. Build the application:"
-echo "     make build"
+echo "     make build OFFICIAL=true SIGNER_KEYS=<key hash>"
 echo "  3. Build MSI installer:"
 echo "     scripts/build-msi.bat"
 
@@ -100,6 +100,38 @@ func (sm *SecretManager) DeleteOlmCredentials(userId string) bool {
 	return idDeleted && secretDeleted
 }
 
+// GetProxyCredentials retrieves the user name and password for the HTTP proxy
+// Returns empty strings if none are stored
+func (sm *SecretManager) GetProxyCredentials() (username, password string) {
+	username, _ = sm.getSecret(proxyUsernameKey)
+	password, _ = sm.getSecret(proxyPasswordKey)
+	return username, password
+}
+
+// SaveProxyCredentials saves the user name and password for the HTTP proxy
+// An empty user name deletes the stored credentials instead
+func (sm *SecretManager) SaveProxyCredentials(username, password string) bool {
+	if username == "" {
+		usernameDeleted := sm.deleteSecret(proxyUsernameKey)
+		passwordDeleted := sm.deleteSecret(proxyPasswordKey)
+		return usernameDeleted && passwordDeleted
+	}
+	usernameSaved := sm.saveSecret(proxyUsernameKey, username)
+	passwordSaved := sm.saveSecret(proxyPasswordKey, password)
+	return usernameSaved && passwordSaved
+}
+
+// DeleteAll deletes every secret stored by Pangolin, for all users
+// Returns true if successful or if there was nothing to delete, false on error
+func (sm *SecretManager) DeleteAll() bool {
+	err := keyring.DeleteAll(sm.service)
+	if err != nil && err != keyring.ErrNotFound {
+		logger.Error("Failed to delete secrets: %v", err)
+		return false
+	}
+	return true
+}
+
 // sessionTokenKey returns the key for storing session tokens
 func (sm *SecretManager) sessionTokenKey(userId string) string {
 	return fmt.Sprintf("session-token-%s", userId)
@@ -114,3 +146,9 @@ func (sm *SecretManager) olmIdKey(userId string) string {
 func (sm *SecretManager) olmSecretKey(userId string) string {
 	return fmt.Sprintf("olm-secret-%s", userId)
 }
+
+// Keys for storing the HTTP proxy credentials, which are shared by all users
+const (
+	proxyUsernameKey = "proxy-username"
+	proxyPasswordKey = "proxy-password"
+)
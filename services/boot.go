@@ -12,30 +12,79 @@ import (
 	"golang.org/x/sys/windows/svc"
 )
 
+// startKind is how the service came to be running
+type startKind int
+
+const (
+	startKindUnknown    startKind = iota // The start reason couldn't be determined
+	startKindNotService                  // Not running as a service at all
+	startKindBoot                        // Started automatically at boot
+	startKindManual                      // Started on demand, e.g. by a user or the UI
+)
+
+// bootUptimeThreshold is how soon after boot a service must start to count as
+// started at boot where the start reason isn't available (Windows 7)
+const bootUptimeThreshold = 10 * time.Minute
+
+// The sources of the start reason; variables so the detection can be exercised
+// without a real service host
 var (
-	startedAtBoot     bool
-	startedAtBootOnce sync.Once
+	isWindowsService   = svc.IsWindowsService
+	dynamicStartReason = svc.DynamicStartReason
+	durationSinceBoot  = windows.DurationSinceBoot
 )
 
-// StartedAtBoot returns true if the service was started at boot time (automatically),
-// false if it was started manually by a user.
-func StartedAtBoot() bool {
-	startedAtBootOnce.Do(func() {
-		// If not running as a service, it wasn't started at boot
-		if isService, err := svc.IsWindowsService(); err == nil && !isService {
-			return
+var (
+	serviceStartKind     startKind
+	serviceStartKindOnce sync.Once
+)
+
+// detectStartKind works out how the service was started from the sources above
+func detectStartKind() startKind {
+	if isService, err := isWindowsService(); err == nil && !isService {
+		return startKindNotService
+	}
+
+	// Try to get the dynamic start reason (Windows 8+)
+	reason, err := dynamicStartReason()
+	switch {
+	case err == nil:
+		if reason&(svc.StartReasonAuto|svc.StartReasonDelayedAuto) != 0 {
+			return startKindBoot
+		}
+		if reason&svc.StartReasonDemand != 0 {
+			return startKindManual
 		}
-		
-		// Try to get the dynamic start reason (Windows 8+)
-		if reason, err := svc.DynamicStartReason(); err == nil {
-			startedAtBoot = (reason&svc.StartReasonAuto) != 0 || (reason&svc.StartReasonDelayedAuto) != 0
-		} else if errors.Is(err, windows.ERROR_PROC_NOT_FOUND) {
-			// Windows 7 compatibility: if service started within 10 minutes of boot, assume it started at boot
-			startedAtBoot = windows.DurationSinceBoot() < time.Minute*10
-		} else {
-			logger.Error("Unable to determine service start reason: %v", err)
+		return startKindUnknown
+	case errors.Is(err, windows.ERROR_PROC_NOT_FOUND):
+		// Windows 7 compatibility: if service started within 10 minutes of boot, assume it started at boot
+		if durationSinceBoot() < bootUptimeThreshold {
+			return startKindBoot
 		}
+		return startKindManual
+	default:
+		logger.Error("Unable to determine service start reason: %v", err)
+		return startKindUnknown
+	}
+}
+
+func startKindOfService() startKind {
+	serviceStartKindOnce.Do(func() {
+		serviceStartKind = detectStartKind()
 	})
-	return startedAtBoot
+	return serviceStartKind
+}
+
+// StartedAtBoot returns true if the service was started at boot time (automatically),
+// false if it was started manually by a user.
+func StartedAtBoot() bool {
+	return startKindOfService() == startKindBoot
 }
 
+// StartedManually returns true if the service was started on demand rather than at
+// boot. It is false when not running as a service, and when the start reason (e.g.
+// a trigger or a restart after failure) can't be told, so it is not simply the
+// opposite of StartedAtBoot.
+func StartedManually() bool {
+	return startKindOfService() == startKindManual
+}
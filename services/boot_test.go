@@ -0,0 +1,54 @@
+//go:build windows
+
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestDetectStartKind(t *testing.T) {
+	tests := []struct {
+		name       string
+		isService  bool
+		serviceErr error
+		reason     svc.StartReason
+		reasonErr  error
+		uptime     time.Duration
+		want       startKind
+	}{
+		{name: "auto start", isService: true, reason: svc.StartReasonAuto, want: startKindBoot},
+		{name: "delayed auto start", isService: true, reason: svc.StartReasonDelayedAuto, want: startKindBoot},
+		{name: "demand start", isService: true, reason: svc.StartReasonDemand, want: startKindManual},
+		{name: "auto start with a trigger", isService: true, reason: svc.StartReasonAuto | svc.StartReasonTrigger, want: startKindBoot},
+		{name: "trigger start", isService: true, reason: svc.StartReasonTrigger, want: startKindUnknown},
+		{name: "restarted after failure", isService: true, reason: svc.StartReasonRestartOnFailure, want: startKindUnknown},
+		{name: "Windows 7 soon after boot", isService: true, reasonErr: windows.ERROR_PROC_NOT_FOUND, uptime: 2 * time.Minute, want: startKindBoot},
+		{name: "Windows 7 long after boot", isService: true, reasonErr: windows.ERROR_PROC_NOT_FOUND, uptime: 3 * time.Hour, want: startKindManual},
+		{name: "Windows 7 at the threshold", isService: true, reasonErr: windows.ERROR_PROC_NOT_FOUND, uptime: bootUptimeThreshold, want: startKindManual},
+		{name: "reason unavailable", isService: true, reasonErr: windows.ERROR_ACCESS_DENIED, want: startKindUnknown},
+		{name: "not a service", isService: false, reason: svc.StartReasonAuto, want: startKindNotService},
+		{name: "service check fails", serviceErr: errors.New("no session"), reason: svc.StartReasonDemand, want: startKindManual},
+	}
+
+	oldIsService, oldReason, oldUptime := isWindowsService, dynamicStartReason, durationSinceBoot
+	defer func() {
+		isWindowsService, dynamicStartReason, durationSinceBoot = oldIsService, oldReason, oldUptime
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isWindowsService = func() (bool, error) { return tt.isService, tt.serviceErr }
+			dynamicStartReason = func() (svc.StartReason, error) { return tt.reason, tt.reasonErr }
+			durationSinceBoot = func() time.Duration { return tt.uptime }
+
+			if got := detectStartKind(); got != tt.want {
+				t.Errorf("detectStartKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+//go:build windows
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fosrl/windows/config"
+	"golang.org/x/sys/windows/registry"
+)
+
+// runKeyPath is the per-user key whose values Windows runs at login
+const runKeyPath = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+// runAtLoginRoot is the hive holding runKeyPath; a variable so another key can stand in
+var runAtLoginRoot = registry.CURRENT_USER
+
+// runAtLoginCommand is the command line registered to run at login. Starting the
+// executable without arguments asks the manager service to open the tray UI.
+func runAtLoginCommand() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%s"`, exe), nil
+}
+
+// SetRunAtLogin adds or removes the entry that opens Pangolin when the current user logs in
+func SetRunAtLogin(enable bool) error {
+	key, err := registry.OpenKey(runAtLoginRoot, runKeyPath, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open the Run key: %w", err)
+	}
+	defer key.Close()
+
+	if !enable {
+		err = key.DeleteValue(config.AppName)
+		if err != nil && !errors.Is(err, registry.ErrNotExist) {
+			return fmt.Errorf("failed to remove the Run entry: %w", err)
+		}
+		return nil
+	}
+
+	command, err := runAtLoginCommand()
+	if err != nil {
+		return err
+	}
+	if err := key.SetStringValue(config.AppName, command); err != nil {
+		return fmt.Errorf("failed to write the Run entry: %w", err)
+	}
+	return nil
+}
+
+// IsRunAtLogin reports whether Pangolin is set to open when the current user logs
+// in. An entry pointing at another executable, e.g. an old install location,
+// doesn't count.
+func IsRunAtLogin() (bool, error) {
+	key, err := registry.OpenKey(runAtLoginRoot, runKeyPath, registry.QUERY_VALUE)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer key.Close()
+
+	value, _, err := key.GetStringValue(config.AppName)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	command, err := runAtLoginCommand()
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(value, command), nil
+}
@@ -0,0 +1,102 @@
+//go:build windows
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/config"
+	"golang.org/x/sys/windows/registry"
+)
+
+// useTempRunKey points the Run entry at a scratch key under HKCU until the test ends
+func useTempRunKey(t *testing.T) registry.Key {
+	t.Helper()
+	rootPath := fmt.Sprintf(`Software\PangolinTest-%d`, time.Now().UnixNano())
+	root, _, err := registry.CreateKey(registry.CURRENT_USER, rootPath, registry.ALL_ACCESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, _, err := registry.CreateKey(root, runKeyPath, registry.ALL_ACCESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := runAtLoginRoot
+	runAtLoginRoot = root
+	t.Cleanup(func() {
+		runAtLoginRoot = old
+		run.Close()
+		root.Close()
+		// Keys are deleted from the deepest up
+		path := rootPath + `\` + runKeyPath
+		for path != rootPath {
+			registry.DeleteKey(registry.CURRENT_USER, path)
+			path = path[:strings.LastIndex(path, `\`)]
+		}
+		registry.DeleteKey(registry.CURRENT_USER, rootPath)
+	})
+	return run
+}
+
+func TestRunAtLogin(t *testing.T) {
+	run := useTempRunKey(t)
+	command, err := runAtLoginCommand()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkEnabled := func(want bool) {
+		t.Helper()
+		if got, err := IsRunAtLogin(); err != nil || got != want {
+			t.Errorf("IsRunAtLogin() = %v, %v, want %v", got, err, want)
+		}
+	}
+
+	checkEnabled(false)
+
+	if err := SetRunAtLogin(true); err != nil {
+		t.Fatalf("SetRunAtLogin(true) error = %v", err)
+	}
+	value, _, err := run.GetStringValue(config.AppName)
+	if err != nil || value != command {
+		t.Errorf("Run entry = %q, %v, want %q", value, err, command)
+	}
+	checkEnabled(true)
+
+	if err := SetRunAtLogin(false); err != nil {
+		t.Fatalf("SetRunAtLogin(false) error = %v", err)
+	}
+	if _, _, err := run.GetStringValue(config.AppName); !errors.Is(err, registry.ErrNotExist) {
+		t.Errorf("Run entry still there after disabling: %v", err)
+	}
+	checkEnabled(false)
+
+	// Disabling twice is not an error
+	if err := SetRunAtLogin(false); err != nil {
+		t.Errorf("second SetRunAtLogin(false) error = %v", err)
+	}
+}
+
+// An entry left by an install elsewhere isn't ours
+func TestRunAtLoginOtherExecutable(t *testing.T) {
+	run := useTempRunKey(t)
+	if err := run.SetStringValue(config.AppName, `"C:\Old\Pangolin\Pangolin.exe"`); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := IsRunAtLogin(); err != nil || got {
+		t.Errorf("IsRunAtLogin() = %v, %v, want false", got, err)
+	}
+
+	// Enabling replaces it with ours
+	if err := SetRunAtLogin(true); err != nil {
+		t.Fatalf("SetRunAtLogin(true) error = %v", err)
+	}
+	if got, err := IsRunAtLogin(); err != nil || !got {
+		t.Errorf("IsRunAtLogin() after enabling = %v, %v, want true", got, err)
+	}
+}
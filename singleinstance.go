@@ -0,0 +1,33 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// singleInstanceMutexName guards the tray UI; the Local\ namespace makes it one per session
+const singleInstanceMutexName = `Local\PangolinUI`
+
+var errAlreadyRunning = errors.New("Pangolin is already running in this session")
+
+// acquireSingleInstance creates the named mutex that marks the UI as running.
+// It returns errAlreadyRunning if another process holds it. The returned
+// release function closes the mutex; Windows also does so if the process exits.
+func acquireSingleInstance(name string) (release func(), err error) {
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := windows.CreateMutex(nil, false, namePtr)
+	if err == windows.ERROR_ALREADY_EXISTS {
+		windows.CloseHandle(handle)
+		return nil, errAlreadyRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() { windows.CloseHandle(handle) }, nil
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAcquireSingleInstance(t *testing.T) {
+	// A name of its own, so a Pangolin running on this machine doesn't interfere
+	name := fmt.Sprintf(`Local\PangolinTest-%d`, time.Now().UnixNano())
+
+	release, err := acquireSingleInstance(name)
+	if err != nil {
+		t.Fatalf("first acquireSingleInstance() error = %v", err)
+	}
+
+	if second, err := acquireSingleInstance(name); !errors.Is(err, errAlreadyRunning) {
+		if second != nil {
+			second()
+		}
+		t.Errorf("second acquireSingleInstance() error = %v, want errAlreadyRunning", err)
+	}
+
+	// Once the first instance exits, the next one starts normally
+	release()
+	release, err = acquireSingleInstance(name)
+	if err != nil {
+		t.Fatalf("acquireSingleInstance() after release error = %v", err)
+	}
+	release()
+}
@@ -0,0 +1,151 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"context"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+)
+
+const (
+	// adapterCheckInterval is how often the watchdog checks that the adapter exists
+	adapterCheckInterval = 5 * time.Second
+	// adapterMissingChecks is how many checks in a row must miss the adapter
+	// before the tunnel is rebuilt, so a single failed lookup isn't enough
+	adapterMissingChecks = 2
+)
+
+// AdapterRecovery is a step of the watchdog rebuilding a tunnel whose adapter
+// disappeared
+type AdapterRecovery int
+
+const (
+	// AdapterLost means the adapter is gone and the tunnel is about to be rebuilt
+	AdapterLost AdapterRecovery = iota
+	// AdapterRecovered means the tunnel was rebuilt after the adapter was lost
+	AdapterRecovered
+	// AdapterRecoveryFailed means rebuilding the tunnel failed
+	AdapterRecoveryFailed
+)
+
+// adapterWatchdog decides when the adapter is lost, from a series of checks
+// of whether it exists
+type adapterWatchdog struct {
+	missing int
+}
+
+// observe records a check and reports whether the adapter has now been missing
+// for adapterMissingChecks checks in a row. A check that failed is ignored,
+// since it says nothing either way.
+func (w *adapterWatchdog) observe(present bool, err error) bool {
+	if err != nil {
+		return false
+	}
+	if present {
+		w.missing = 0
+		return false
+	}
+	w.missing++
+	return w.missing >= adapterMissingChecks
+}
+
+// reset forgets the checks, so the adapter must go missing again from scratch
+func (w *adapterWatchdog) reset() {
+	*w = adapterWatchdog{}
+}
+
+// RegisterAdapterRecoveryCallback registers a callback that will be called as
+// the watchdog rebuilds a tunnel whose adapter disappeared. err is set for
+// AdapterRecoveryFailed.
+func (tm *Manager) RegisterAdapterRecoveryCallback(cb func(step AdapterRecovery, err error)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.adapterCallback = cb
+}
+
+// adapterWatchedLocked reports whether the tunnel is in a state that needs the
+// adapter. Caller must hold tm.mu.
+func (tm *Manager) adapterWatchedLocked() bool {
+	if tm.restarting {
+		return false
+	}
+	return tm.currentState == StateRunning || tm.currentState == StateReconnecting
+}
+
+// watchAdapter rebuilds the tunnel if its adapter disappears while it is up,
+// for example when another tool or a driver problem removes it, which leaves
+// OLM reporting a tunnel that carries nothing. It runs alongside status polling
+// until ctx is done, so a Disconnect or Reconnect stops it before tearing the
+// adapter down on purpose.
+func (tm *Manager) watchAdapter(ctx context.Context) {
+	ticker := time.NewTicker(adapterCheckInterval)
+	defer ticker.Stop()
+
+	var watchdog adapterWatchdog
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		tm.mu.RLock()
+		watched := tm.adapterWatchedLocked()
+		present := tm.adapterPresent
+		tm.mu.RUnlock()
+		if !watched {
+			watchdog.reset()
+			continue
+		}
+		if !watchdog.observe(present()) {
+			continue
+		}
+
+		// Check again now that the adapter is known to be gone, in case the
+		// tunnel was stopped on purpose in the meantime
+		tm.mu.Lock()
+		if ctx.Err() != nil || !tm.adapterWatchedLocked() {
+			tm.mu.Unlock()
+			watchdog.reset()
+			continue
+		}
+		logger.Warn("Tunnel adapter %q disappeared, rebuilding the tunnel", InterfaceName)
+		oldState := tm.currentState
+		tm.currentState = StateReconnecting
+		tm.isConnected = false
+		stateCb := tm.stateCallback
+		adapterCb := tm.adapterCallback
+		tm.mu.Unlock()
+
+		SetState(StateReconnecting)
+		if oldState != StateReconnecting && stateCb != nil {
+			stateCb(StateReconnecting)
+		}
+		if adapterCb != nil {
+			adapterCb(AdapterLost, nil)
+		}
+
+		// Reconnect stops this watchdog along with status polling and starts
+		// a new one with the rebuilt tunnel
+		err := tm.Reconnect()
+		if err != nil {
+			logger.Error("Failed to rebuild the tunnel after its adapter disappeared: %v", err)
+			if adapterCb != nil {
+				adapterCb(AdapterRecoveryFailed, err)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			// The tunnel wasn't stopped, so keep watching it
+			watchdog.reset()
+			continue
+		}
+		logger.Info("Rebuilt the tunnel after its adapter disappeared")
+		if adapterCb != nil {
+			adapterCb(AdapterRecovered, nil)
+		}
+		return
+	}
+}
@@ -0,0 +1,74 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAdapterWatchdog(t *testing.T) {
+	errLookup := errors.New("lookup failed")
+	type check struct {
+		present bool
+		err     error
+		want    bool
+	}
+
+	tests := []struct {
+		name   string
+		checks []check
+	}{
+		{
+			name:   "present",
+			checks: []check{{present: true}, {present: true}, {present: true}},
+		},
+		{
+			name:   "missing twice in a row",
+			checks: []check{{present: true}, {present: false}, {present: false, want: true}},
+		},
+		{
+			name:   "missing once",
+			checks: []check{{present: false}, {present: true}, {present: false}, {present: true}},
+		},
+		{
+			name:   "failed lookups say nothing",
+			checks: []check{{err: errLookup}, {err: errLookup}, {err: errLookup}},
+		},
+		{
+			name:   "failed lookup between misses",
+			checks: []check{{present: false}, {err: errLookup}, {present: false, want: true}},
+		},
+		{
+			name:   "failed lookup doesn't clear a miss",
+			checks: []check{{present: false}, {err: errLookup}, {present: true}, {present: false}},
+		},
+		{
+			name:   "stays lost",
+			checks: []check{{present: false}, {present: false, want: true}, {present: false, want: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w adapterWatchdog
+			for i, c := range tt.checks {
+				if got := w.observe(c.present, c.err); got != c.want {
+					t.Errorf("check %d: observe(%v, %v) = %v, want %v", i, c.present, c.err, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAdapterWatchdogReset(t *testing.T) {
+	var w adapterWatchdog
+	w.observe(false, nil)
+	w.reset()
+	if w.observe(false, nil) {
+		t.Error("lost after a single miss following reset")
+	}
+	if !w.observe(false, nil) {
+		t.Error("not lost after two misses")
+	}
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// formatEndpoint writes a peer endpoint in canonical form, so an IPv6 address
+// followed by a port is bracketed and compressed, and an IPv4-mapped one shows
+// as IPv4. Endpoints that aren't an address, such as host names, are returned
+// as they are.
+func formatEndpoint(endpoint string) string {
+	endpoint = strings.TrimSpace(endpoint)
+	if addrPort, err := netip.ParseAddrPort(endpoint); err == nil {
+		return netip.AddrPortFrom(addrPort.Addr().Unmap(), addrPort.Port()).String()
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(endpoint, "["), "]")); err == nil {
+		return addr.Unmap().String()
+	}
+	return endpoint
+}
+
+// formatAddress writes an address or CIDR reported for a peer in canonical
+// form. Anything else is returned as it is.
+func formatAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if prefix, err := netip.ParsePrefix(address); err == nil {
+		return prefix.String()
+	}
+	if addr, err := netip.ParseAddr(address); err == nil {
+		return addr.Unmap().String()
+	}
+	return address
+}
+
+// sortAddresses orders addresses IPv4 first, then IPv6, each family in
+// numeric order, so dual-stack addresses list the same way on every refresh
+func sortAddresses(addrs []netip.Addr) {
+	sort.Slice(addrs, func(i, j int) bool {
+		if addrs[i].Is4() != addrs[j].Is4() {
+			return addrs[i].Is4()
+		}
+		return addrs[i].Less(addrs[j])
+	})
+}
@@ -0,0 +1,92 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestFormatEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		want     string
+	}{
+		{name: "IPv4 with a port", endpoint: "203.0.113.1:51820", want: "203.0.113.1:51820"},
+		{name: "IPv6 with a port", endpoint: "[fd00:0:0::2]:51820", want: "[fd00::2]:51820"},
+		{name: "IPv4-mapped with a port", endpoint: "[::ffff:203.0.113.1]:51820", want: "203.0.113.1:51820"},
+		{name: "IPv6 without a port", endpoint: "FD00::2", want: "fd00::2"},
+		{name: "bracketed IPv6 without a port", endpoint: "[fd00::2]", want: "fd00::2"},
+		{name: "IPv4-mapped without a port", endpoint: "::ffff:203.0.113.1", want: "203.0.113.1"},
+		{name: "spaces", endpoint: " 203.0.113.1:51820 ", want: "203.0.113.1:51820"},
+		{name: "host name", endpoint: "site.example.com:51820", want: "site.example.com:51820"},
+		{name: "empty", endpoint: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatEndpoint(tt.endpoint); got != tt.want {
+				t.Errorf("formatEndpoint(%q) = %q, want %q", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    string
+	}{
+		{name: "IPv4", address: "100.90.0.5", want: "100.90.0.5"},
+		{name: "IPv4 CIDR", address: "100.90.0.5/24", want: "100.90.0.5/24"},
+		{name: "IPv6", address: "FD00:0:0::5", want: "fd00::5"},
+		{name: "IPv6 CIDR", address: "fd00:0::5/64", want: "fd00::5/64"},
+		{name: "IPv4-mapped", address: "::ffff:100.90.0.5", want: "100.90.0.5"},
+		{name: "spaces", address: " fd00::5 ", want: "fd00::5"},
+		{name: "not an address", address: "pending", want: "pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatAddress(tt.address); got != tt.want {
+				t.Errorf("formatAddress(%q) = %q, want %q", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortAddresses(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{name: "IPv4 only", in: []string{"100.90.0.9", "100.90.0.2"}, want: []string{"100.90.0.2", "100.90.0.9"}},
+		{name: "IPv6 only", in: []string{"fd00::9", "fd00::2"}, want: []string{"fd00::2", "fd00::9"}},
+		{
+			name: "dual stack",
+			in:   []string{"fd00::2", "100.90.0.9", "2001:db8::1", "100.90.0.2"},
+			want: []string{"100.90.0.2", "100.90.0.9", "2001:db8::1", "fd00::2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs := make([]netip.Addr, 0, len(tt.in))
+			for _, s := range tt.in {
+				addrs = append(addrs, netip.MustParseAddr(s))
+			}
+			sortAddresses(addrs)
+			got := make([]string, 0, len(addrs))
+			for _, addr := range addrs {
+				got = append(got, addr.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortAddresses(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ package tunnel
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/fosrl/newt/logger"
@@ -14,16 +15,58 @@ import (
 	"github.com/fosrl/windows/version"
 )
 
+// effectiveMTU returns the MTU to configure the adapter with, using the default when mtu is 0 (auto)
+func effectiveMTU(mtu int) int {
+	if mtu <= 0 {
+		return configpkg.DefaultMTU
+	}
+	return mtu
+}
+
+// newOLMTunnelConfig is the configuration OLM connects config with. The DNS
+// settings are passed through for OLM to apply, see Config.
+func newOLMTunnelConfig(config Config, fp, postures map[string]any) olmpkg.TunnelConfig {
+	return olmpkg.TunnelConfig{
+		Endpoint:             config.Endpoint,
+		ID:                   config.ID,
+		Secret:               config.Secret,
+		MTU:                  effectiveMTU(config.MTU),
+		DNS:                  config.DNS,
+		Holepunch:            config.Holepunch,
+		PingIntervalDuration: time.Duration(config.PingIntervalSeconds) * time.Second,
+		PingTimeoutDuration:  time.Duration(config.PingTimeoutSeconds) * time.Second,
+		UserToken:            config.UserToken,
+		OrgID:                config.OrgID,
+		InterfaceName:        config.InterfaceName,
+		UpstreamDNS:          config.UpstreamDNS,
+		OverrideDNS:          config.OverrideDNS,
+		TunnelDNS:            config.TunnelDNS,
+		InitialFingerprint:   fp,
+		InitialPostures:      postures,
+	}
+}
+
 // buildTunnel builds the tunnel
 func (s *tunnelService) buildTunnel(config Config) error {
-	logger.Debug("Build tunnel called: config: %+v", config)
+	logger.Debug("Build tunnel called: config: %+v", config.Redacted())
+
+	if err := ValidateKillSwitch(config.KillSwitch, config.AllowedIPs, config.ExcludedIPs); err != nil {
+		return err
+	}
+
+	// Split tunneling narrows OLM's site routes; invalid settings must not
+	// fall back to routing every site subnet
+	splitTunnel, err := newSplitTunnel(config)
+	if err != nil {
+		return fmt.Errorf("invalid split tunnel settings: %w", err)
+	}
 
 	// Create context for OLM
 	olmContext := context.Background()
 
 	// Create OLM GlobalConfig with hardcoded values from Swift
 	olmInitConfig := olmpkg.OlmConfig{
-		LogLevel:   configpkg.LogLevel,
+		LogLevel:   configpkg.NormalizeLogLevel(config.LogLevel),
 		EnableAPI:  true,
 		SocketPath: OLMNamedPipePath,
 		Version:    version.Number,
@@ -40,7 +83,6 @@ func (s *tunnelService) buildTunnel(config Config) error {
 	}
 
 	// Initialize OLM with context and GlobalConfig
-	var err error
 	s.olm, err = olmpkg.Init(olmContext, olmInitConfig)
 	if err != nil {
 		return err
@@ -49,24 +91,7 @@ func (s *tunnelService) buildTunnel(config Config) error {
 	fp := fingerprint.GatherFingerprintInfo().ToMap()
 	postures := fingerprint.GatherPostureChecks().ToMap()
 
-	olmConfig := olmpkg.TunnelConfig{
-		Endpoint:             config.Endpoint,
-		ID:                   config.ID,
-		Secret:               config.Secret,
-		MTU:                  config.MTU,
-		DNS:                  config.DNS,
-		Holepunch:            config.Holepunch,
-		PingIntervalDuration: time.Duration(config.PingIntervalSeconds) * time.Second,
-		PingTimeoutDuration:  time.Duration(config.PingTimeoutSeconds) * time.Second,
-		UserToken:            config.UserToken,
-		OrgID:                config.OrgID,
-		InterfaceName:        config.InterfaceName,
-		UpstreamDNS:          config.UpstreamDNS,
-		OverrideDNS:          config.OverrideDNS,
-		TunnelDNS:            config.TunnelDNS,
-		InitialFingerprint:   fp,
-		InitialPostures:      postures,
-	}
+	olmConfig := newOLMTunnelConfig(config, fp, postures)
 
 	s.fingerprintCtx, s.fingerprintCancel = context.WithCancel(context.Background())
 
@@ -94,6 +119,21 @@ func (s *tunnelService) buildTunnel(config Config) error {
 		}
 	}()
 
+	// Block traffic outside the tunnel as soon as the adapter is up
+	if config.KillSwitch {
+		var killSwitchCtx context.Context
+		killSwitchCtx, s.killSwitchCancel = context.WithCancel(context.Background())
+		s.killSwitch = newKillSwitch()
+		go s.killSwitch.run(killSwitchCtx)
+	}
+
+	// Narrow OLM's site routes when split tunneling is configured
+	if splitTunnel != nil {
+		var splitTunnelCtx context.Context
+		splitTunnelCtx, s.splitTunnelCancel = context.WithCancel(context.Background())
+		go splitTunnel.run(splitTunnelCtx)
+	}
+
 	s.olm.StartApi()
 
 	logger.Info("Starting OLM tunnel...")
@@ -0,0 +1,123 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+
+	configpkg "github.com/fosrl/windows/config"
+)
+
+func TestNewOLMTunnelConfigDNS(t *testing.T) {
+	dns, upstream, err := upstreamDNS("9.9.9.9, 149.112.112.112", "1.1.1.1")
+	if err != nil {
+		t.Fatalf("upstreamDNS() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		overrideDNS bool
+		tunnelDNS   bool
+	}{
+		{name: "override", overrideDNS: true},
+		{name: "override through the tunnel", overrideDNS: true, tunnelDNS: true},
+		{name: "adapter left alone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{
+				InterfaceName: InterfaceName,
+				DNS:           dns,
+				UpstreamDNS:   upstream,
+				OverrideDNS:   tt.overrideDNS,
+				TunnelDNS:     tt.tunnelDNS,
+			}
+
+			got := newOLMTunnelConfig(config, nil, nil)
+			if got.DNS != "9.9.9.9" {
+				t.Errorf("DNS = %q, want the first primary server", got.DNS)
+			}
+			wantUpstream := []string{"9.9.9.9:53", "149.112.112.112:53", "1.1.1.1:53"}
+			if !reflect.DeepEqual(got.UpstreamDNS, wantUpstream) {
+				t.Errorf("UpstreamDNS = %v, want %v", got.UpstreamDNS, wantUpstream)
+			}
+			if got.OverrideDNS != tt.overrideDNS || got.TunnelDNS != tt.tunnelDNS {
+				t.Errorf("OverrideDNS, TunnelDNS = %v, %v, want %v, %v", got.OverrideDNS, got.TunnelDNS, tt.overrideDNS, tt.tunnelDNS)
+			}
+			// OLM applies and restores DNS on the adapter it is given
+			if got.InterfaceName != InterfaceName {
+				t.Errorf("InterfaceName = %q, want %q", got.InterfaceName, InterfaceName)
+			}
+		})
+	}
+}
+
+func TestNewOLMTunnelConfigDefaultMTU(t *testing.T) {
+	if got := newOLMTunnelConfig(Config{}, nil, nil).MTU; got != configpkg.DefaultMTU {
+		t.Errorf("MTU = %d, want the default %d", got, configpkg.DefaultMTU)
+	}
+	if got := newOLMTunnelConfig(Config{MTU: 1380}, nil, nil).MTU; got != 1380 {
+		t.Errorf("MTU = %d, want 1380", got)
+	}
+}
+
+func TestEffectiveMTU(t *testing.T) {
+	tests := []struct {
+		mtu  int
+		want int
+	}{
+		{mtu: 0, want: configpkg.DefaultMTU},
+		{mtu: -1, want: configpkg.DefaultMTU},
+		{mtu: configpkg.MinMTU, want: configpkg.MinMTU},
+		{mtu: 1380, want: 1380},
+		{mtu: configpkg.MaxMTU, want: configpkg.MaxMTU},
+	}
+
+	for _, tt := range tests {
+		if got := effectiveMTU(tt.mtu); got != tt.want {
+			t.Errorf("effectiveMTU(%d) = %d, want %d", tt.mtu, got, tt.want)
+		}
+	}
+}
+
+func TestConfigKeepsMTUOverIPC(t *testing.T) {
+	for _, mtu := range []int{0, 1380} {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(Config{InterfaceName: InterfaceName, MTU: mtu}); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		var got Config
+		if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if got.MTU != mtu {
+			t.Errorf("decoded MTU = %d, want %d", got.MTU, mtu)
+		}
+	}
+}
+
+// The manager hands the tunnel service its Config over IPC, which gob encodes it
+func TestConfigKeepsDNSOverIPC(t *testing.T) {
+	want := Config{
+		DNS:         "9.9.9.9",
+		UpstreamDNS: []string{"9.9.9.9:53", "1.1.1.1:53"},
+		OverrideDNS: true,
+		TunnelDNS:   true,
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(want); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	var got Config
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+}
@@ -14,10 +14,25 @@ func (s *tunnelService) destroyTunnel(config Config) {
 	s.fingerprintCancel = nil
 	s.fingerprintCtx = nil
 
+	if s.splitTunnelCancel != nil {
+		s.splitTunnelCancel()
+		s.splitTunnelCancel = nil
+	}
+
 	s.olm.StopApi()
 	s.olm.StopTunnel()
 
 	s.olm = nil
 
+	// Lift the kill switch only once the tunnel is down, so nothing leaks while it stops
+	if s.killSwitch != nil {
+		s.killSwitchCancel()
+		if err := s.killSwitch.remove(); err != nil {
+			logger.Error("Kill switch: failed to allow traffic outside the tunnel again: %v", err)
+		}
+		s.killSwitch = nil
+		s.killSwitchCancel = nil
+	}
+
 	logger.Debug("Destroy tunnel completed successfully")
 }
@@ -0,0 +1,86 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// dnsPort is the port upstream DNS servers are queried on
+const dnsPort = 53
+
+// ParseDNS parses a list of DNS servers separated by commas, spaces or newlines.
+// Each entry must be an IP address; host names are rejected, since a resolver
+// can't be used to look up the resolver. Duplicates are dropped, keeping the
+// first occurrence, so the order of preference is preserved.
+func ParseDNS(s string) ([]netip.Addr, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	})
+
+	addrs := make([]netip.Addr, 0, len(fields))
+	seen := make(map[netip.Addr]struct{}, len(fields))
+	for _, field := range fields {
+		addr, err := netip.ParseAddr(field)
+		if err != nil {
+			if looksLikeHostname(field) {
+				return nil, fmt.Errorf("%q is a host name; DNS servers must be IP addresses", field)
+			}
+			return nil, fmt.Errorf("%q is not a valid IP address", field)
+		}
+		// Zones such as "%eth0" have no meaning for the tunnel's resolver
+		if addr.Zone() != "" {
+			return nil, fmt.Errorf("%q has a zone; DNS servers must be plain IP addresses", field)
+		}
+		addr = addr.Unmap()
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// looksLikeHostname reports whether s is shaped like a DNS name rather than a mistyped address
+func looksLikeHostname(s string) bool {
+	if strings.Trim(s, "0123456789.") == "" {
+		return false // Digits and dots only, e.g. "8.8.8" or "300.1.1.1"
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// upstreamDNS parses the primary and secondary DNS settings into the DNS server
+// OLM is given and the upstream servers it forwards to, each with the port appended
+func upstreamDNS(primary, secondary string) (dns string, upstream []string, err error) {
+	primaryAddrs, err := ParseDNS(primary)
+	if err != nil {
+		return "", nil, fmt.Errorf("primary DNS: %w", err)
+	}
+	if len(primaryAddrs) == 0 {
+		return "", nil, errors.New("primary DNS: no server is configured")
+	}
+	secondaryAddrs, err := ParseDNS(secondary)
+	if err != nil {
+		return "", nil, fmt.Errorf("secondary DNS: %w", err)
+	}
+
+	seen := make(map[netip.Addr]struct{})
+	for _, addr := range append(primaryAddrs, secondaryAddrs...) {
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+		// AddrPort brackets IPv6 addresses, which a plain ":53" suffix would not
+		upstream = append(upstream, netip.AddrPortFrom(addr, dnsPort).String())
+	}
+	return primaryAddrs[0].String(), upstream, nil
+}
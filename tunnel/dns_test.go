@@ -0,0 +1,127 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDNS(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr string // Part of the error expected, empty for none
+	}{
+		{name: "empty", in: "", want: []string{}},
+		{name: "blank", in: " \t\r\n", want: []string{}},
+		{name: "single", in: "9.9.9.9", want: []string{"9.9.9.9"}},
+		{name: "commas", in: "9.9.9.9,1.1.1.1", want: []string{"9.9.9.9", "1.1.1.1"}},
+		{name: "mixed separators", in: "9.9.9.9, 1.1.1.1;8.8.8.8\t8.8.4.4\r\n1.0.0.1\n", want: []string{"9.9.9.9", "1.1.1.1", "8.8.8.8", "8.8.4.4", "1.0.0.1"}},
+		{name: "empty entries", in: ",,9.9.9.9,, ;1.1.1.1,", want: []string{"9.9.9.9", "1.1.1.1"}},
+		{name: "IPv6", in: "2620:fe::fe, 2606:4700:4700::1111", want: []string{"2620:fe::fe", "2606:4700:4700::1111"}},
+		{name: "mixed families", in: "9.9.9.9 2620:fe::fe", want: []string{"9.9.9.9", "2620:fe::fe"}},
+		{name: "IPv4-mapped IPv6", in: "::ffff:9.9.9.9", want: []string{"9.9.9.9"}},
+		{name: "duplicates keep the first", in: "1.1.1.1,9.9.9.9,1.1.1.1,::ffff:9.9.9.9", want: []string{"1.1.1.1", "9.9.9.9"}},
+		{name: "host name", in: "9.9.9.9,dns.quad9.net", wantErr: "is a host name"},
+		{name: "short address", in: "8.8.8", wantErr: "is not a valid IP address"},
+		{name: "octet out of range", in: "300.1.1.1", wantErr: "is not a valid IP address"},
+		{name: "with a port", in: "9.9.9.9:53", wantErr: "is not a valid IP address"},
+		{name: "CIDR", in: "9.9.9.0/24", wantErr: "is not a valid IP address"},
+		{name: "zone", in: "fe80::1%eth0", wantErr: "has a zone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs, err := ParseDNS(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDNS(%q) error = %v, want one containing %q", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDNS(%q): %v", tt.in, err)
+			}
+			got := make([]string, 0, len(addrs))
+			for _, addr := range addrs {
+				got = append(got, addr.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDNS(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstreamDNS(t *testing.T) {
+	tests := []struct {
+		name         string
+		primary      string
+		secondary    string
+		wantDNS      string
+		wantUpstream []string
+		wantErr      string
+	}{
+		{
+			name:         "primary only",
+			primary:      "9.9.9.9",
+			wantDNS:      "9.9.9.9",
+			wantUpstream: []string{"9.9.9.9:53"},
+		},
+		{
+			name:         "lists in both",
+			primary:      "9.9.9.9, 149.112.112.112",
+			secondary:    "1.1.1.1;1.0.0.1",
+			wantDNS:      "9.9.9.9",
+			wantUpstream: []string{"9.9.9.9:53", "149.112.112.112:53", "1.1.1.1:53", "1.0.0.1:53"},
+		},
+		{
+			name:         "IPv6 is bracketed",
+			primary:      "2620:fe::fe",
+			secondary:    "9.9.9.9",
+			wantDNS:      "2620:fe::fe",
+			wantUpstream: []string{"[2620:fe::fe]:53", "9.9.9.9:53"},
+		},
+		{
+			name:         "duplicates across settings",
+			primary:      "9.9.9.9",
+			secondary:    "1.1.1.1 9.9.9.9",
+			wantDNS:      "9.9.9.9",
+			wantUpstream: []string{"9.9.9.9:53", "1.1.1.1:53"},
+		},
+		{name: "no primary", primary: " , ", secondary: "1.1.1.1", wantErr: "primary DNS: no server is configured"},
+		{name: "invalid primary", primary: "dns.quad9.net", wantErr: "primary DNS:"},
+		{name: "invalid secondary", primary: "9.9.9.9", secondary: "1.1.1", wantErr: "secondary DNS:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dns, upstream, err := upstreamDNS(tt.primary, tt.secondary)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("upstreamDNS error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("upstreamDNS: %v", err)
+			}
+			if dns != tt.wantDNS {
+				t.Errorf("dns = %q, want %q", dns, tt.wantDNS)
+			}
+			if !reflect.DeepEqual(upstream, tt.wantUpstream) {
+				t.Errorf("upstream = %q, want %q", upstream, tt.wantUpstream)
+			}
+			// Every upstream server must parse back, as OLM will parse it
+			for _, server := range upstream {
+				if _, err := netip.ParseAddrPort(server); err != nil {
+					t.Errorf("upstream server %q doesn't parse: %v", server, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// idleSampleInterval is how often the traffic totals are checked for activity
+const idleSampleInterval = 30 * time.Second
+
+// idleDetector decides when the tunnel has gone without traffic for too long,
+// from a series of traffic totals and the times they were taken
+type idleDetector struct {
+	last       Stats
+	lastActive time.Time
+	seeded     bool
+}
+
+// observe records a sample and reports whether there has been no traffic for at
+// least timeout. The first sample and any change in the totals, including a drop
+// when the adapter was recreated, start the idle period over.
+func (d *idleDetector) observe(stats Stats, now time.Time, timeout time.Duration) bool {
+	if !d.seeded || stats != d.last {
+		d.last = stats
+		d.lastActive = now
+		d.seeded = true
+		return false
+	}
+	return timeout > 0 && now.Sub(d.lastActive) >= timeout
+}
+
+// reset forgets the samples, so the idle period starts over with the next one
+func (d *idleDetector) reset() {
+	*d = idleDetector{}
+}
+
+// RegisterIdleDisconnectCallback registers a callback that will be called when the
+// tunnel has had no traffic for timeout, just before it is disconnected
+func (tm *Manager) RegisterIdleDisconnectCallback(cb func(timeout time.Duration)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.idleCallback = cb
+}
+
+// idleTimeout returns the configured idle timeout, 0 if idle disconnect is off
+func (tm *Manager) idleTimeout() time.Duration {
+	if tm.configManager == nil {
+		return 0
+	}
+	return time.Duration(tm.configManager.GetIdleDisconnectMinutes()) * time.Minute
+}
+
+// watchIdle disconnects the tunnel once it has carried no traffic for the
+// configured idle timeout. It runs alongside status polling until ctx is done.
+// The timeout is read on every sample, so changing it applies to a running tunnel.
+func (tm *Manager) watchIdle(ctx context.Context) {
+	ticker := time.NewTicker(idleSampleInterval)
+	defer ticker.Stop()
+
+	var detector idleDetector
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		timeout := tm.idleTimeout()
+		if timeout <= 0 {
+			detector.reset()
+			continue
+		}
+		stats, err := tm.Stats()
+		if err != nil {
+			if !errors.Is(err, ErrTunnelNotRunning) {
+				logger.Debug("Failed to read tunnel stats for idle check: %v", err)
+			}
+			// Only a running tunnel's idle time counts
+			detector.reset()
+			continue
+		}
+		if !detector.observe(stats, time.Now(), timeout) {
+			continue
+		}
+
+		logger.Info("No tunnel traffic for %s, disconnecting", timeout)
+		tm.mu.RLock()
+		cb := tm.idleCallback
+		tm.mu.RUnlock()
+		if cb != nil {
+			cb(timeout)
+		}
+		if err := tm.Disconnect(); err != nil {
+			logger.Error("Failed to disconnect idle tunnel: %v", err)
+			detector.reset()
+			continue
+		}
+		return
+	}
+}
@@ -0,0 +1,107 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleDetector(t *testing.T) {
+	start := time.Date(2025, 1, 1, 22, 0, 0, 0, time.UTC)
+	type sample struct {
+		after time.Duration // Since start
+		rx    uint64
+		tx    uint64
+		want  bool
+	}
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		samples []sample
+	}{
+		{
+			name:    "idle for the timeout",
+			timeout: 10 * time.Minute,
+			samples: []sample{
+				{after: 0, rx: 100, tx: 50},
+				{after: 5 * time.Minute, rx: 100, tx: 50},
+				{after: 9*time.Minute + 59*time.Second, rx: 100, tx: 50},
+				{after: 10 * time.Minute, rx: 100, tx: 50, want: true},
+			},
+		},
+		{
+			name:    "first sample never idle",
+			timeout: time.Minute,
+			samples: []sample{
+				{after: time.Hour, rx: 100, tx: 50},
+			},
+		},
+		{
+			name:    "received traffic starts over",
+			timeout: 10 * time.Minute,
+			samples: []sample{
+				{after: 0, rx: 100, tx: 50},
+				{after: 8 * time.Minute, rx: 101, tx: 50},
+				{after: 12 * time.Minute, rx: 101, tx: 50},
+				{after: 18 * time.Minute, rx: 101, tx: 50, want: true},
+			},
+		},
+		{
+			name:    "sent traffic starts over",
+			timeout: 10 * time.Minute,
+			samples: []sample{
+				{after: 0, rx: 100, tx: 50},
+				{after: 9 * time.Minute, rx: 100, tx: 51},
+				{after: 15 * time.Minute, rx: 100, tx: 51},
+			},
+		},
+		{
+			name:    "totals dropping when the adapter is recreated count as traffic",
+			timeout: 10 * time.Minute,
+			samples: []sample{
+				{after: 0, rx: 100, tx: 50},
+				{after: 9 * time.Minute, rx: 0, tx: 0},
+				{after: 15 * time.Minute, rx: 0, tx: 0},
+				{after: 19 * time.Minute, rx: 0, tx: 0, want: true},
+			},
+		},
+		{
+			name:    "no timeout",
+			timeout: 0,
+			samples: []sample{
+				{after: 0, rx: 100, tx: 50},
+				{after: 24 * time.Hour, rx: 100, tx: 50},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d idleDetector
+			for i, s := range tt.samples {
+				got := d.observe(Stats{RxBytes: s.rx, TxBytes: s.tx}, start.Add(s.after), tt.timeout)
+				if got != s.want {
+					t.Errorf("sample %d at %s: observe() = %v, want %v", i, s.after, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIdleDetectorReset(t *testing.T) {
+	start := time.Date(2025, 1, 1, 22, 0, 0, 0, time.UTC)
+	stats := Stats{RxBytes: 100, TxBytes: 50}
+
+	var d idleDetector
+	d.observe(stats, start, time.Minute)
+	d.reset()
+	// After a reset the next sample is the first again, however late it is
+	if d.observe(stats, start.Add(time.Hour), time.Minute) {
+		t.Error("idle right after reset")
+	}
+	if !d.observe(stats, start.Add(time.Hour+time.Minute), time.Minute) {
+		t.Error("not idle a timeout after reset")
+	}
+}
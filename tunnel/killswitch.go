@@ -0,0 +1,121 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// killSwitchInterval is how often the kill switch checks the tunnel adapter
+const killSwitchInterval = 500 * time.Millisecond
+
+// ValidateKillSwitch rejects the kill switch together with split tunneling.
+// Split tunneling keeps some traffic off the tunnel on purpose, and the kill
+// switch would silently block all of it.
+func ValidateKillSwitch(killSwitch bool, allowedIPs, excludedIPs []string) error {
+	if killSwitch && RoutingMode(allowedIPs, excludedIPs) == RoutingModeSplit {
+		return errors.New("blocking traffic outside the tunnel can't be combined with split tunneling, as it would block the routes kept off the tunnel")
+	}
+	return nil
+}
+
+// killSwitchFirewall installs and removes the kill switch filters
+type killSwitchFirewall interface {
+	// block permits only the adapter with the given LUID and Pangolin itself,
+	// replacing any kill switch filters already installed
+	block(luid uint64) error
+	// unblock removes the kill switch filters, including ones left behind by
+	// an earlier run; it succeeds if there are none
+	unblock() error
+}
+
+// killSwitch blocks all traffic that doesn't go through the tunnel adapter,
+// apart from Pangolin's own processes, loopback, DHCP and NDP. Pangolin's
+// processes are permitted so OLM can reach the sites, the UI can reach the
+// API and the manager can check for updates.
+//
+// The filters outlive the tunnel service, so a crash leaves the block in
+// place rather than leaking traffic. A tunnel service that starts without the
+// kill switch removes ones left behind, as does disconnecting; one that starts
+// with it leaves them until its own filters replace them.
+//
+// The filters stay in place while OLM reconnects, as they are only tied to
+// the adapter, and are moved over if the adapter is recreated.
+type killSwitch struct {
+	firewall killSwitchFirewall
+
+	mu sync.Mutex
+	// luid is the adapter the filters permit, 0 while they are not installed
+	luid uint64
+	// stopped is set by remove so a late apply can't bring the filters back
+	stopped bool
+}
+
+// newKillSwitch returns a kill switch using the Windows Filtering Platform
+func newKillSwitch() *killSwitch {
+	return &killSwitch{firewall: wfpFirewall{}}
+}
+
+// run installs the filters once the tunnel adapter exists and keeps them on it until ctx is cancelled
+func (ks *killSwitch) run(ctx context.Context) {
+	ticker := time.NewTicker(killSwitchInterval)
+	defer ticker.Stop()
+
+	var lastErr string
+	for {
+		if luid, err := interfaceLUID(InterfaceName); err == nil {
+			if err := ks.apply(luid); err != nil && err.Error() != lastErr {
+				lastErr = err.Error()
+				logger.Error("Kill switch: failed to block traffic outside the tunnel: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// apply installs the filters for the adapter with the given LUID, replacing
+// filters for a previous adapter. It does nothing if they are already in place.
+func (ks *killSwitch) apply(luid uint64) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.stopped || ks.luid == luid {
+		return nil
+	}
+	if ks.luid != 0 {
+		logger.Info("Kill switch: tunnel adapter changed, moving filters")
+	}
+	if err := ks.firewall.block(luid); err != nil {
+		return err
+	}
+	ks.luid = luid
+	logger.Info("Kill switch: blocking traffic outside the tunnel")
+	return nil
+}
+
+// remove takes the filters down, including any left behind by an earlier run.
+// After it, apply does nothing.
+func (ks *killSwitch) remove() error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.stopped = true
+	if err := ks.firewall.unblock(); err != nil {
+		return err
+	}
+	if ks.luid != 0 {
+		ks.luid = 0
+		logger.Info("Kill switch: traffic outside the tunnel allowed again")
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeFirewall records the kill switch filter changes instead of making them
+type fakeFirewall struct {
+	calls      []string
+	blockErr   error
+	unblockErr error
+}
+
+func (f *fakeFirewall) block(luid uint64) error {
+	f.calls = append(f.calls, fmt.Sprintf("block %d", luid))
+	return f.blockErr
+}
+
+func (f *fakeFirewall) unblock() error {
+	f.calls = append(f.calls, "unblock")
+	return f.unblockErr
+}
+
+func TestKillSwitchBookkeeping(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name string
+		// steps are "apply N", "remove", "fail block", "fail unblock" or "fix"
+		steps     []string
+		wantCalls []string
+		wantLUID  uint64
+		wantErrs  int
+	}{
+		{
+			name:      "applies once per adapter",
+			steps:     []string{"apply 1", "apply 1", "apply 1"},
+			wantCalls: []string{"block 1"},
+			wantLUID:  1,
+		},
+		{
+			name:      "moves to a recreated adapter",
+			steps:     []string{"apply 1", "apply 2", "apply 2"},
+			wantCalls: []string{"block 1", "block 2"},
+			wantLUID:  2,
+		},
+		{
+			name:      "retries after a failed block",
+			steps:     []string{"fail block", "apply 1", "fix", "apply 1"},
+			wantCalls: []string{"block 1", "block 1"},
+			wantLUID:  1,
+			wantErrs:  1,
+		},
+		{
+			name:      "keeps the old adapter when moving fails",
+			steps:     []string{"apply 1", "fail block", "apply 2"},
+			wantCalls: []string{"block 1", "block 2"},
+			wantLUID:  1,
+			wantErrs:  1,
+		},
+		{
+			name:      "remove lifts the filters",
+			steps:     []string{"apply 1", "remove"},
+			wantCalls: []string{"block 1", "unblock"},
+			wantLUID:  0,
+		},
+		{
+			name:      "remove lifts leftovers before the adapter is up",
+			steps:     []string{"remove"},
+			wantCalls: []string{"unblock"},
+			wantLUID:  0,
+		},
+		{
+			name:      "apply after remove does nothing",
+			steps:     []string{"apply 1", "remove", "apply 2"},
+			wantCalls: []string{"block 1", "unblock"},
+			wantLUID:  0,
+		},
+		{
+			name:      "failed remove keeps the filters recorded",
+			steps:     []string{"apply 1", "fail unblock", "remove", "apply 2"},
+			wantCalls: []string{"block 1", "unblock"},
+			wantLUID:  1,
+			wantErrs:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fw := &fakeFirewall{}
+			ks := &killSwitch{firewall: fw}
+
+			errs := 0
+			for _, step := range tt.steps {
+				var err error
+				switch {
+				case strings.HasPrefix(step, "apply "):
+					var luid uint64
+					fmt.Sscanf(step, "apply %d", &luid)
+					err = ks.apply(luid)
+				case step == "remove":
+					err = ks.remove()
+				case step == "fail block":
+					fw.blockErr = errFailed
+				case step == "fail unblock":
+					fw.unblockErr = errFailed
+				case step == "fix":
+					fw.blockErr, fw.unblockErr = nil, nil
+				default:
+					t.Fatalf("unknown step %q", step)
+				}
+				if err != nil {
+					errs++
+				}
+			}
+
+			if !reflect.DeepEqual(fw.calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", fw.calls, tt.wantCalls)
+			}
+			if ks.luid != tt.wantLUID {
+				t.Errorf("luid = %d, want %d", ks.luid, tt.wantLUID)
+			}
+			if errs != tt.wantErrs {
+				t.Errorf("got %d errors, want %d", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestValidateKillSwitch(t *testing.T) {
+	tests := []struct {
+		name       string
+		killSwitch bool
+		allowed    []string
+		excluded   []string
+		wantErr    bool
+	}{
+		{name: "off", killSwitch: false},
+		{name: "on without split tunneling", killSwitch: true},
+		{name: "off with split tunneling", killSwitch: false, allowed: []string{"10.0.0.0/8"}},
+		{name: "on with allowed IPs", killSwitch: true, allowed: []string{"10.0.0.0/8"}, wantErr: true},
+		{name: "on with excluded IPs", killSwitch: true, excluded: []string{"10.1.0.0/16"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKillSwitch(tt.killSwitch, tt.allowed, tt.excluded)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateKillSwitch() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +29,10 @@ import (
 type IPCClient interface {
 	StartTunnel(config Config) error
 	StopTunnel() error
+	TunnelStatus() (State, error)
+	PauseTunnel(d time.Duration) error
+	CancelPause() error
+	PauseStatus() (PauseStatus, error)
 	RegisterStateChangeCallback(cb func(State)) func() // Returns unregister function
 }
 
@@ -37,6 +44,14 @@ type Manager struct {
 	isConnected    bool
 	stateCallback  func(State)
 	errorCallback  func(*OLMStatusError)
+	statusCallback func(*OLMStatusResponse)
+	pauseCallback  func(PauseStatus, error)
+	idleCallback   func(time.Duration)
+	// adapterCallback is told as the watchdog rebuilds a tunnel that lost its adapter
+	adapterCallback func(AdapterRecovery, error)
+	// adapterPresent reports whether the tunnel's adapter exists, AdapterPresent
+	// unless replaced
+	adapterPresent func() (bool, error)
 	unregisterCb   func()
 	ipcClient      IPCClient
 	authManager    *auth.AuthManager
@@ -47,6 +62,20 @@ type Manager struct {
 	pollCtx       context.Context
 	pollCancel    context.CancelFunc
 	pollingActive bool
+	// lastOLMError is the latest error OLM reported for the current connection
+	lastOLMError string
+	// routingMode is the RoutingMode of the current connection
+	routingMode string
+	// reconnectAttempt counts OLM's tries to get a dropped tunnel back while Reconnecting
+	reconnectAttempt int
+	// probeCancel cancels the endpoint probe of a Connect in progress, if any
+	probeCancel context.CancelFunc
+	// lastRegistered is whether the previous polled status was registered
+	lastRegistered bool
+	// restarting is set while Reconnect is between stopping and starting the tunnel
+	restarting bool
+	// pause is the pause state the manager service last reported
+	pause PauseStatus
 }
 
 // NewManager creates a new Manager instance
@@ -65,6 +94,7 @@ func NewManager(
 		accountManager: accountManager,
 		secretManager:  secretManager,
 		ipcClient:      ipcClient,
+		adapterPresent: AdapterPresent,
 	}
 
 	// Register for tunnel state change notifications
@@ -79,20 +109,19 @@ func NewManager(
 			if tm.stateCallback != nil {
 				tm.stateCallback(state)
 			}
+
+			// A pause ends with the manager starting the tunnel, or failing to
+			go tm.refreshPause()
 		})
 	}
 
-	// Get initial state
-	go func() {
-		// Initial state will be updated when the first state change notification arrives
-		// For now, we assume stopped
-	}()
-
 	return tm
 }
 
 // Close cleans up resources used by the Manager
 func (tm *Manager) Close() {
+	tm.cancelProbe()
+
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -117,6 +146,25 @@ func (tm *Manager) State() State {
 	return tm.currentState
 }
 
+// ReconnectAttempt returns which attempt OLM is on while the tunnel is
+// Reconnecting, or 0 in any other state
+func (tm *Manager) ReconnectAttempt() int {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	if tm.currentState != StateReconnecting {
+		return 0
+	}
+	return tm.reconnectAttempt
+}
+
+// DeviceName returns this device's name on the server, or "" while unknown
+func (tm *Manager) DeviceName() string {
+	if tm.authManager == nil {
+		return ""
+	}
+	return tm.authManager.DeviceName()
+}
+
 // IsConnected returns whether the tunnel is currently connected
 func (tm *Manager) IsConnected() bool {
 	tm.mu.RLock()
@@ -124,6 +172,40 @@ func (tm *Manager) IsConnected() bool {
 	return tm.isConnected
 }
 
+// SyncState queries the manager for the current tunnel state and applies it as if a
+// state change notification had arrived. This covers a UI that starts while a tunnel
+// is already up. If the manager can't be reached, the state is assumed to be stopped.
+func (tm *Manager) SyncState() State {
+	state := StateStopped
+	if tm.ipcClient != nil {
+		var err error
+		state, err = tm.ipcClient.TunnelStatus()
+		if err != nil {
+			logger.Warn("Failed to get tunnel status from manager: %v", err)
+			state = StateStopped
+		}
+	}
+
+	tm.mu.Lock()
+	tm.currentState = state
+	tm.isConnected = (state == StateRunning)
+	cb := tm.stateCallback
+	tm.mu.Unlock()
+
+	if cb != nil {
+		cb(state)
+	}
+
+	// The tunnel may have been paused before the UI started
+	tm.refreshPause()
+
+	// Keep watching a tunnel that was brought up before the UI started
+	if state != StateStopped {
+		tm.StartStatusPolling()
+	}
+	return state
+}
+
 // RegisterStateChangeCallback registers a callback that will be called when tunnel state changes
 func (tm *Manager) RegisterStateChangeCallback(cb func(State)) {
 	tm.mu.Lock()
@@ -131,6 +213,14 @@ func (tm *Manager) RegisterStateChangeCallback(cb func(State)) {
 	tm.stateCallback = cb
 }
 
+// RegisterStatusCallback registers a callback that will be called with each OLM status
+// fetched while status polling is active
+func (tm *Manager) RegisterStatusCallback(cb func(*OLMStatusResponse)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.statusCallback = cb
+}
+
 // RegisterErrorCallback registers a callback that will be called when an error is detected in OLM status
 func (tm *Manager) RegisterErrorCallback(cb func(*OLMStatusError)) {
 	tm.mu.Lock()
@@ -173,10 +263,21 @@ func (tm *Manager) buildConfig() (Config, error) {
 	dnsOverride := tm.configManager.GetDNSOverride()
 	dnsTunnel := tm.configManager.GetDNSTunnel()
 
-	// Build UpstreamDNS array with :53 appended to each
-	upstreamDNS := []string{primaryDNS + ":53"}
-	if secondaryDNS != "" {
-		upstreamDNS = append(upstreamDNS, secondaryDNS+":53")
+	// Split tunneling narrows the site subnets routed through the tunnel
+	allowedIPs := tm.configManager.GetAllowedIPs()
+	excludedIPs := tm.configManager.GetExcludedIPs()
+	if err := ValidateSplitTunnel(allowedIPs, excludedIPs); err != nil {
+		return Config{}, fmt.Errorf("invalid split tunnel settings: %w", err)
+	}
+	killSwitch := tm.configManager.GetKillSwitch()
+	if err := ValidateKillSwitch(killSwitch, allowedIPs, excludedIPs); err != nil {
+		return Config{}, err
+	}
+
+	// Each DNS setting may list several servers; UpstreamDNS gets them all with :53 appended
+	dns, upstream, err := upstreamDNS(primaryDNS, secondaryDNS)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid DNS settings: %w", err)
 	}
 
 	config := Config{
@@ -184,17 +285,21 @@ func (tm *Manager) buildConfig() (Config, error) {
 		ID:                  olmId,
 		Secret:              olmSecret,
 		UserToken:           userToken,
-		MTU:                 1280,
+		MTU:                 tm.configManager.GetMTU(), // 0 lets the tunnel service pick the default
 		Holepunch:           true,
 		PingIntervalSeconds: 5,
 		PingTimeoutSeconds:  5,
 		Endpoint:            activeAccount.Hostname,
-		DNS:                 primaryDNS, // Use primary DNS without :53
+		DNS:                 dns, // First primary DNS server, without :53
 		OrgID:               currentOrg.Id,
-		InterfaceName:       "Pangolin",
-		UpstreamDNS:         upstreamDNS, // Each value has :53 appended
+		InterfaceName:       InterfaceName,
+		UpstreamDNS:         upstream, // Each value has :53 appended
 		OverrideDNS:         dnsOverride,
 		TunnelDNS:           dnsTunnel,
+		AllowedIPs:          allowedIPs,
+		ExcludedIPs:         excludedIPs,
+		KillSwitch:          killSwitch,
+		LogLevel:            tm.configManager.GetLogLevel(),
 	}
 
 	return config, nil
@@ -225,6 +330,9 @@ func formatConnectionError(title, message string, err error) *ConnectionError {
 
 // Connect starts the tunnel, building the configuration internally
 func (tm *Manager) Connect() error {
+	// Connecting ends any pause
+	tm.cancelPause()
+
 	tm.mu.RLock()
 	currentState := tm.currentState
 	tm.mu.RUnlock()
@@ -258,6 +366,16 @@ func (tm *Manager) Connect() error {
 		)
 	}
 
+	// Organization policies (e.g. required two-factor authentication) may forbid connecting
+	if reason := tm.authManager.PolicyBlockReason(); reason != nil {
+		logger.Error("Organization policy blocks connecting: %s", *reason)
+		return formatConnectionError(
+			"Blocked by Organization Policy",
+			*reason,
+			nil,
+		)
+	}
+
 	// Ensure OLM credentials exist before connecting
 	currentUser := tm.authManager.CurrentUser()
 	if currentUser != nil && currentUser.UserId != "" {
@@ -309,7 +427,12 @@ func (tm *Manager) Connect() error {
 		)
 	}
 
-	logger.Info("Connecting tunnel with config: Name=%s, Endpoint=%s", config.Name, config.Endpoint)
+	routingMode := RoutingMode(config.AllowedIPs, config.ExcludedIPs)
+	tm.mu.Lock()
+	tm.routingMode = routingMode
+	tm.mu.Unlock()
+
+	logger.Info("Connecting tunnel with config: Name=%s, Endpoint=%s, RoutingMode=%s", config.Name, config.Endpoint, routingMode)
 	if tm.ipcClient == nil {
 		return formatConnectionError(
 			"Connection Error",
@@ -317,6 +440,11 @@ func (tm *Manager) Connect() error {
 			nil,
 		)
 	}
+	if tm.configManager.GetCheckEndpointBeforeConnect() {
+		if err := tm.probeEndpoint(config.Endpoint); err != nil {
+			return err
+		}
+	}
 	err = tm.ipcClient.StartTunnel(config)
 	if err != nil {
 		logger.Error("Failed to start tunnel: %v", err)
@@ -333,8 +461,61 @@ func (tm *Manager) Connect() error {
 	return nil
 }
 
+// ErrConnectCanceled is returned by Connect when Disconnect cancels it before the tunnel was started
+var ErrConnectCanceled = errors.New("the connection was canceled")
+
+// probeEndpoint checks the endpoint can be reached before the tunnel is started,
+// so an unreachable server fails fast with a clear message. Disconnect cancels it.
+func (tm *Manager) probeEndpoint(endpoint string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	tm.mu.Lock()
+	tm.probeCancel = cancel
+	tm.mu.Unlock()
+	defer tm.cancelProbe()
+
+	logger.Info("Checking that %s can be reached", endpoint)
+	err := ProbeEndpoint(ctx, endpoint)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) {
+		logger.Info("Endpoint check canceled, not connecting")
+		return ErrConnectCanceled
+	}
+	logger.Error("Endpoint check failed: %v", err)
+	var unreachable *EndpointUnreachableError
+	if errors.As(err, &unreachable) {
+		return formatConnectionError(
+			"Can't Reach Server",
+			fmt.Sprintf("Can't reach %s: %s.\n\nCheck your internet connection and that the server is online. "+
+				"This check can be turned off in the More menu.", unreachable.Address, unreachable.Reason),
+			err,
+		)
+	}
+	return formatConnectionError(
+		"Configuration Error",
+		fmt.Sprintf("Failed to check the server address: %v", err),
+		err,
+	)
+}
+
+// cancelProbe cancels the endpoint probe of a Connect in progress, if any
+func (tm *Manager) cancelProbe() {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.probeCancel != nil {
+		tm.probeCancel()
+		tm.probeCancel = nil
+	}
+}
+
 // Disconnect stops the tunnel
 func (tm *Manager) Disconnect() error {
+	// A manual disconnect (or logout) must not be undone by a pending resume
+	tm.cancelPause()
+	// Nor by a Connect that is still checking the endpoint
+	tm.cancelProbe()
+
 	tm.mu.RLock()
 	currentState := tm.currentState
 	tm.mu.RUnlock()
@@ -365,6 +546,68 @@ func (tm *Manager) Disconnect() error {
 	return nil
 }
 
+// ErrNotConnected is returned by Reconnect when there is no tunnel to restart
+var ErrNotConnected = errors.New("the tunnel is not connected")
+
+// CanReconnect reports whether a tunnel in state is up far enough to restart,
+// which includes one that OLM is trying to get back
+func CanReconnect(state State) bool {
+	switch state {
+	case StateRegistered, StateRunning, StateReconnecting:
+		return true
+	}
+	return false
+}
+
+// Reconnect stops the tunnel and starts it again with a freshly built config, for
+// a tunnel that is up but not working. It returns ErrNotConnected, and does
+// nothing, unless the tunnel is up.
+func (tm *Manager) Reconnect() error {
+	return tm.restart(tm.Disconnect, tm.Connect)
+}
+
+// restart runs stop and then start for Reconnect, guarded the same way
+func (tm *Manager) restart(stop, start func() error) error {
+	tm.mu.Lock()
+	if state := tm.currentState; !CanReconnect(state) {
+		tm.mu.Unlock()
+		logger.Info("Not reconnecting, the tunnel state is %s", state)
+		return ErrNotConnected
+	}
+	if tm.restarting {
+		tm.mu.Unlock()
+		logger.Info("Tunnel is already reconnecting")
+		return nil
+	}
+	tm.restarting = true
+	tm.mu.Unlock()
+	defer func() {
+		tm.mu.Lock()
+		tm.restarting = false
+		tm.mu.Unlock()
+	}()
+
+	logger.Info("Reconnecting tunnel")
+	if err := stop(); err != nil {
+		return err
+	}
+	// StopTunnel returns once the tunnel is down, but the Stopped notification
+	// may still be on its way, and Connect refuses while the tunnel looks up
+	tm.mu.Lock()
+	tm.currentState = StateStopped
+	tm.isConnected = false
+	tm.mu.Unlock()
+
+	return start()
+}
+
+// Restarting reports whether Reconnect is between stopping and starting the tunnel
+func (tm *Manager) Restarting() bool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.restarting
+}
+
 // OLMStatusError represents an error in the OLM status response
 type OLMStatusError struct {
 	Code    string `json:"code"`
@@ -392,6 +635,54 @@ type OLMStatusResponse struct {
 	PeerStatuses    map[int]*OLMPeerStatus `json:"peers,omitempty"`
 	NetworkSettings map[string]interface{} `json:"networkSettings,omitempty"`
 	Error           *OLMStatusError        `json:"error,omitempty"`
+	// StatusReason explains the state beyond Connected, one of the StatusReason constants
+	StatusReason string `json:"statusReason,omitempty"`
+	// LastError is the latest error OLM reported while the tunnel was not connected
+	LastError string `json:"lastError,omitempty"`
+	// RoutingMode is how site subnets are routed, one of the RoutingMode constants
+	RoutingMode string `json:"routingMode,omitempty"`
+	// TunnelAddress lists the addresses of the tunnel adapter while connected
+	TunnelAddress string `json:"tunnelAddress,omitempty"`
+}
+
+// Reasons reported in OLMStatusResponse.StatusReason
+const (
+	StatusReasonConnected        = "connected"
+	StatusReasonHandshakePending = "handshakePending"
+	StatusReasonRegistering      = "registering"
+	StatusReasonAuthRejected     = "authRejected"
+	StatusReasonError            = "error"
+	StatusReasonTerminated       = "terminated"
+)
+
+// statusReason derives StatusReason from the flags and error OLM reported
+func statusReason(status *OLMStatusResponse) string {
+	if status.Error != nil {
+		if _, isSessionExpired := sessionExpiredErrorCodes[status.Error.Code]; isSessionExpired {
+			return StatusReasonAuthRejected
+		}
+		if !status.Connected {
+			return StatusReasonError
+		}
+	}
+	switch {
+	case status.Terminated:
+		return StatusReasonTerminated
+	case status.Connected && status.Registered:
+		return StatusReasonConnected
+	case status.Registered:
+		return StatusReasonHandshakePending
+	default:
+		return StatusReasonRegistering
+	}
+}
+
+// describeOLMError returns the message of an OLM error, falling back to its code
+func describeOLMError(err *OLMStatusError) string {
+	if err.Message != "" {
+		return err.Message
+	}
+	return err.Code
 }
 
 // OLMPeerStatus represents the status of a peer connection
@@ -401,9 +692,20 @@ type OLMPeerStatus struct {
 	Connected bool          `json:"connected"`
 	RTT       time.Duration `json:"rtt"`
 	LastSeen  time.Time     `json:"lastSeen"`
-	Endpoint  string        `json:"endpoint,omitempty"`
-	IsRelay   bool          `json:"isRelay"`
-	PeerIP    string        `json:"peerAddress,omitempty"`
+	// LastHandshake is the time of the latest WireGuard handshake, zero if none yet
+	LastHandshake time.Time `json:"lastHandshake"`
+	Endpoint      string    `json:"endpoint,omitempty"`
+	IsRelay       bool      `json:"isRelay"`
+	PeerIP        string    `json:"peerAddress,omitempty"`
+}
+
+// LastActivity returns the latest handshake time, falling back to LastSeen for
+// OLM versions that don't report handshakes. It is zero if the peer was never seen.
+func (p *OLMPeerStatus) LastActivity() time.Time {
+	if !p.LastHandshake.IsZero() {
+		return p.LastHandshake
+	}
+	return p.LastSeen
 }
 
 // SwitchOrgRequest represents the request body for switching organizations
@@ -439,8 +741,62 @@ func createOLMHTTPClient() (*http.Client, error) {
 	return client, nil
 }
 
-// GetOLMStatus retrieves the status from OLM via the named pipe API
+// ErrTunnelNotRunning is returned by GetOLMStatus when there is no active tunnel to query
+var ErrTunnelNotRunning = errors.New("tunnel is not running")
+
+// normalizePeerStatuses drops empty entries from the peer map, fills in
+// SiteID from the map key when OLM left it unset, and writes endpoints and
+// addresses in canonical form so IPv6 ones display like IPv4 ones
+func normalizePeerStatuses(peers map[int]*OLMPeerStatus) {
+	for siteID, peer := range peers {
+		if peer == nil {
+			delete(peers, siteID)
+			continue
+		}
+		if peer.SiteID == 0 {
+			peer.SiteID = siteID
+		}
+		peer.Endpoint = formatEndpoint(peer.Endpoint)
+		peer.PeerIP = formatAddress(peer.PeerIP)
+	}
+}
+
+// GetOLMStatus retrieves the status from OLM via the named pipe API. It returns
+// ErrTunnelNotRunning when no tunnel is active.
 func (tm *Manager) GetOLMStatus() (*OLMStatusResponse, error) {
+	tm.mu.RLock()
+	currentState := tm.currentState
+	tm.mu.RUnlock()
+	if currentState == StateStopped {
+		return nil, ErrTunnelNotRunning
+	}
+
+	status, err := FetchOLMStatus()
+	if err != nil {
+		return nil, err
+	}
+	tm.annotateStatus(status)
+	return status, nil
+}
+
+// annotateStatus fills in what the Manager tracks across statuses, the latest
+// OLM error and the routing mode
+func (tm *Manager) annotateStatus(status *OLMStatusResponse) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if status.Error != nil {
+		tm.lastOLMError = describeOLMError(status.Error)
+	} else if status.StatusReason == StatusReasonConnected {
+		tm.lastOLMError = ""
+	}
+	status.LastError = tm.lastOLMError
+	status.RoutingMode = tm.routingMode
+}
+
+// FetchOLMStatus reads the status from the OLM API of the running tunnel,
+// returning ErrTunnelNotRunning when the API isn't up. Unlike GetOLMStatus it
+// doesn't need a Manager, so LastError and RoutingMode are left unset.
+func FetchOLMStatus() (*OLMStatusResponse, error) {
 	client, err := createOLMHTTPClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OLM HTTP client: %w", err)
@@ -455,6 +811,10 @@ func (tm *Manager) GetOLMStatus() (*OLMStatusResponse, error) {
 
 	resp, err := client.Do(req)
 	if err != nil {
+		// The OLM API pipe only exists while the tunnel service is up
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrTunnelNotRunning
+		}
 		return nil, fmt.Errorf("failed to connect to OLM: %w", err)
 	}
 	defer resp.Body.Close()
@@ -464,11 +824,27 @@ func (tm *Manager) GetOLMStatus() (*OLMStatusResponse, error) {
 		return nil, fmt.Errorf("OLM API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
+	statusResp, err := decodeOLMStatus(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if statusResp.Connected {
+		if addresses, err := AdapterAddresses(); err == nil {
+			statusResp.TunnelAddress = strings.Join(addresses, ", ")
+		}
+	}
+	return statusResp, nil
+}
+
+// decodeOLMStatus reads a status answer from the OLM API, keying its peers
+// by site ID and working out the status reason
+func decodeOLMStatus(r io.Reader) (*OLMStatusResponse, error) {
 	var statusResp OLMStatusResponse
-	if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
+	if err := json.NewDecoder(r).Decode(&statusResp); err != nil {
 		return nil, fmt.Errorf("failed to decode OLM status response: %w", err)
 	}
-
+	normalizePeerStatuses(statusResp.PeerStatuses)
+	statusResp.StatusReason = statusReason(&statusResp)
 	return &statusResp, nil
 }
 
@@ -538,11 +914,15 @@ func (tm *Manager) StartStatusPolling() {
 
 	// Create new context for polling
 	tm.pollCtx, tm.pollCancel = context.WithCancel(context.Background())
+	tm.lastOLMError = ""
+	tm.lastRegistered = false
 	tm.pollingActive = true
 
 	// Start polling goroutine
 	// Capture context to avoid race conditions
 	pollCtx := tm.pollCtx
+	go tm.watchIdle(pollCtx)
+	go tm.watchAdapter(pollCtx)
 	go func() {
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
@@ -559,10 +939,19 @@ func (tm *Manager) StartStatusPolling() {
 				// Poll the status
 				status, err := tm.GetOLMStatus()
 				if err != nil {
-					logger.Error("Failed to poll OLM status: %v", err)
+					if !errors.Is(err, ErrTunnelNotRunning) {
+						logger.Error("Failed to poll OLM status: %v", err)
+					}
 					continue
 				}
 
+				tm.mu.RLock()
+				statusCb := tm.statusCallback
+				tm.mu.RUnlock()
+				if statusCb != nil {
+					statusCb(status)
+				}
+
 				// This should be checked before checking termination or state updates
 				if status.Error != nil {
 					// Get current state to verify we're still in registration phase
@@ -601,31 +990,27 @@ func (tm *Manager) StartStatusPolling() {
 				}
 
 				// Update tunnel state based on OLM status
-				// Only consider connected if both registered and connected
-				var newState State
-				if status.Connected && status.Registered {
-					newState = StateRunning
-				} else if status.Registered {
-					newState = StateRegistered
-				} else {
-					// If neither connected nor registered, don't update state
-					// (keep current state)
-					continue
-				}
-
-				// Update the global tunnel state (for consistency with GetState())
-				SetState(newState)
-
-				// Update Manager's internal state and trigger callback (this notifies the UI)
 				tm.mu.Lock()
 				oldState := tm.currentState
+				oldAttempt := tm.reconnectAttempt
+				newState, attempt, ok := polledState(oldState, oldAttempt, tm.lastRegistered, status)
+				tm.lastRegistered = status.Registered
+				if !ok {
+					// Neither connected nor registered, keep the current state
+					tm.mu.Unlock()
+					continue
+				}
 				tm.currentState = newState
+				tm.reconnectAttempt = attempt
 				tm.isConnected = (newState == StateRunning)
 				callback := tm.stateCallback
 				tm.mu.Unlock()
 
-				// Only trigger callback if state actually changed
-				if oldState != newState && callback != nil {
+				// Update the global tunnel state (for consistency with GetState())
+				SetState(newState)
+
+				// Only trigger callback if the state or reconnect attempt changed
+				if (oldState != newState || oldAttempt != attempt) && callback != nil {
 					callback(newState)
 				}
 			}
@@ -635,6 +1020,30 @@ func (tm *Manager) StartStatusPolling() {
 	logger.Info("Started OLM status polling (every 1 second)")
 }
 
+// polledState works out the tunnel state from a polled OLM status. Only a
+// status that is both registered and connected is Running. A tunnel that was
+// Running and lost its connection is Reconnecting rather than Registered, and
+// attempt goes up each time OLM drops its registration to start over. ok is
+// false when the status says nothing about the state.
+func polledState(current State, attempt int, wasRegistered bool, status *OLMStatusResponse) (next State, nextAttempt int, ok bool) {
+	if status.Connected && status.Registered {
+		return StateRunning, 0, true
+	}
+	switch current {
+	case StateRunning:
+		return StateReconnecting, 1, true
+	case StateReconnecting:
+		if wasRegistered && !status.Registered {
+			attempt++
+		}
+		return StateReconnecting, attempt, true
+	}
+	if status.Registered {
+		return StateRegistered, 0, true
+	}
+	return current, attempt, false
+}
+
 // StopStatusPolling stops the status polling
 func (tm *Manager) StopStatusPolling() {
 	tm.mu.Lock()
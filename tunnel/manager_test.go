@@ -0,0 +1,258 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeOLMStatus(t *testing.T) {
+	const answer = `{
+		"connected": true,
+		"registered": true,
+		"version": "1.4.2",
+		"agent": "Pangolin Windows",
+		"orgId": "org-1",
+		"peers": {
+			"1": {
+				"siteId": 1,
+				"name": "Office",
+				"connected": true,
+				"rtt": 12000000,
+				"lastSeen": "2026-10-17T12:00:00Z",
+				"endpoint": "[::ffff:203.0.113.5]:51820",
+				"peerAddress": "100.90.128.1"
+			},
+			"7": {"name": "Lab", "isRelay": true, "peerAddress": " 100.90.128.7/32 "},
+			"9": null
+		}
+	}`
+
+	got, err := decodeOLMStatus(strings.NewReader(answer))
+	if err != nil {
+		t.Fatalf("decodeOLMStatus() error = %v", err)
+	}
+	want := &OLMStatusResponse{
+		Connected:  true,
+		Registered: true,
+		Version:    "1.4.2",
+		Agent:      "Pangolin Windows",
+		OrgID:      "org-1",
+		PeerStatuses: map[int]*OLMPeerStatus{
+			1: {
+				SiteID:    1,
+				SiteName:  "Office",
+				Connected: true,
+				RTT:       12 * time.Millisecond,
+				LastSeen:  time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+				Endpoint:  "203.0.113.5:51820",
+				PeerIP:    "100.90.128.1",
+			},
+			7: {SiteID: 7, SiteName: "Lab", IsRelay: true, PeerIP: "100.90.128.7/32"},
+		},
+		StatusReason: StatusReasonConnected,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeOLMStatus() = %+v, want %+v", got, want)
+		for id, peer := range got.PeerStatuses {
+			t.Logf("peer %d: %+v", id, peer)
+		}
+	}
+}
+
+func TestDecodeOLMStatusReason(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{answer: `{"connected": true, "registered": true}`, want: StatusReasonConnected},
+		{answer: `{"registered": true}`, want: StatusReasonHandshakePending},
+		{answer: `{}`, want: StatusReasonRegistering},
+		{answer: `{"terminated": true}`, want: StatusReasonTerminated},
+	}
+
+	for _, tt := range tests {
+		got, err := decodeOLMStatus(strings.NewReader(tt.answer))
+		if err != nil {
+			t.Errorf("decodeOLMStatus(%s) error = %v", tt.answer, err)
+			continue
+		}
+		if got.StatusReason != tt.want {
+			t.Errorf("decodeOLMStatus(%s) reason = %q, want %q", tt.answer, got.StatusReason, tt.want)
+		}
+	}
+}
+
+func TestStatusReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		status OLMStatusResponse
+		want   string
+	}{
+		{name: "connected", status: OLMStatusResponse{Connected: true, Registered: true}, want: StatusReasonConnected},
+		{name: "registered but not connected", status: OLMStatusResponse{Registered: true}, want: StatusReasonHandshakePending},
+		{name: "connected before registering", status: OLMStatusResponse{Connected: true}, want: StatusReasonRegistering},
+		{name: "nothing yet", want: StatusReasonRegistering},
+		{name: "terminated", status: OLMStatusResponse{Terminated: true, Registered: true}, want: StatusReasonTerminated},
+		{
+			name:   "session expired",
+			status: OLMStatusResponse{Registered: true, Error: &OLMStatusError{Code: "SESSION_EXPIRED"}},
+			want:   StatusReasonAuthRejected,
+		},
+		{
+			name:   "session rejected while connected",
+			status: OLMStatusResponse{Connected: true, Registered: true, Error: &OLMStatusError{Code: "UNAUTHORIZED"}},
+			want:   StatusReasonAuthRejected,
+		},
+		{
+			name:   "other error while disconnected",
+			status: OLMStatusResponse{Registered: true, Error: &OLMStatusError{Code: "PEER_UNREACHABLE"}},
+			want:   StatusReasonError,
+		},
+		{
+			name:   "other error while connected",
+			status: OLMStatusResponse{Connected: true, Registered: true, Error: &OLMStatusError{Code: "PEER_UNREACHABLE"}},
+			want:   StatusReasonConnected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusReason(&tt.status); got != tt.want {
+				t.Errorf("statusReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeOLMError(t *testing.T) {
+	if got := describeOLMError(&OLMStatusError{Code: "PEER_UNREACHABLE", Message: "Site is offline"}); got != "Site is offline" {
+		t.Errorf("describeOLMError() = %q, want the message", got)
+	}
+	if got := describeOLMError(&OLMStatusError{Code: "PEER_UNREACHABLE"}); got != "PEER_UNREACHABLE" {
+		t.Errorf("describeOLMError() = %q, want the code", got)
+	}
+}
+
+func TestDecodeOLMStatusRejectsGarbage(t *testing.T) {
+	if _, err := decodeOLMStatus(strings.NewReader("<html>")); err == nil {
+		t.Error("decodeOLMStatus() accepted an answer that isn't JSON")
+	}
+}
+
+func TestGetOLMStatusWithoutTunnel(t *testing.T) {
+	tm := &Manager{currentState: StateStopped}
+	if _, err := tm.GetOLMStatus(); !errors.Is(err, ErrTunnelNotRunning) {
+		t.Errorf("GetOLMStatus() error = %v, want ErrTunnelNotRunning", err)
+	}
+}
+
+func TestPolledState(t *testing.T) {
+	connected := &OLMStatusResponse{Connected: true, Registered: true}
+	registered := &OLMStatusResponse{Registered: true}
+	neither := &OLMStatusResponse{}
+
+	tests := []struct {
+		name          string
+		current       State
+		attempt       int
+		wasRegistered bool
+		status        *OLMStatusResponse
+		want          State
+		wantAttempt   int
+		wantOK        bool
+	}{
+		{name: "comes up", current: StateRegistering, status: connected, want: StateRunning, wantOK: true},
+		{name: "registered while connecting", current: StateRegistering, status: registered, want: StateRegistered, wantOK: true},
+		{name: "nothing yet", current: StateRegistering, status: neither, want: StateRegistering},
+		{name: "stays up", current: StateRunning, wasRegistered: true, status: connected, want: StateRunning, wantOK: true},
+		{name: "drops", current: StateRunning, wasRegistered: true, status: registered, want: StateReconnecting, wantAttempt: 1, wantOK: true},
+		{name: "drops its registration at once", current: StateRunning, wasRegistered: true, status: neither, want: StateReconnecting, wantAttempt: 1, wantOK: true},
+		{name: "still trying", current: StateReconnecting, attempt: 1, wasRegistered: true, status: registered, want: StateReconnecting, wantAttempt: 1, wantOK: true},
+		{name: "starts over", current: StateReconnecting, attempt: 1, wasRegistered: true, status: neither, want: StateReconnecting, wantAttempt: 2, wantOK: true},
+		{name: "registers again", current: StateReconnecting, attempt: 2, status: registered, want: StateReconnecting, wantAttempt: 2, wantOK: true},
+		{name: "still unregistered", current: StateReconnecting, attempt: 2, status: neither, want: StateReconnecting, wantAttempt: 2, wantOK: true},
+		{name: "reconnects", current: StateReconnecting, attempt: 3, wasRegistered: true, status: connected, want: StateRunning, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, attempt, ok := polledState(tt.current, tt.attempt, tt.wasRegistered, tt.status)
+			if got != tt.want || attempt != tt.wantAttempt || ok != tt.wantOK {
+				t.Errorf("polledState() = %v, %d, %v, want %v, %d, %v", got, attempt, ok, tt.want, tt.wantAttempt, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCanReconnect(t *testing.T) {
+	up := map[State]bool{StateRegistered: true, StateRunning: true, StateReconnecting: true}
+	for _, state := range []State{StateStopped, StateStarting, StateRegistering, StateRegistered,
+		StateRunning, StateReconnecting, StateStopping, StateInvalid, StateError} {
+		if got := CanReconnect(state); got != up[state] {
+			t.Errorf("CanReconnect(%v) = %v, want %v", state, got, up[state])
+		}
+	}
+}
+
+func TestRestart(t *testing.T) {
+	stopErr := errors.New("stop failed")
+	startErr := errors.New("start failed")
+
+	tests := []struct {
+		name       string
+		state      State
+		restarting bool
+		stopErr    error
+		startErr   error
+		wantCalls  []string
+		wantErr    error
+	}{
+		{name: "running", state: StateRunning, wantCalls: []string{"stop", "start"}},
+		{name: "registered", state: StateRegistered, wantCalls: []string{"stop", "start"}},
+		{name: "reconnecting", state: StateReconnecting, wantCalls: []string{"stop", "start"}},
+		{name: "start fails", state: StateRunning, startErr: startErr, wantCalls: []string{"stop", "start"}, wantErr: startErr},
+		{name: "stop fails", state: StateRunning, stopErr: stopErr, wantCalls: []string{"stop"}, wantErr: stopErr},
+		{name: "stopped", state: StateStopped, wantErr: ErrNotConnected},
+		{name: "starting", state: StateStarting, wantErr: ErrNotConnected},
+		{name: "stopping", state: StateStopping, wantErr: ErrNotConnected},
+		{name: "already restarting", state: StateRunning, restarting: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := &Manager{currentState: tt.state, isConnected: true, restarting: tt.restarting}
+			var calls []string
+			stop := func() error {
+				calls = append(calls, "stop")
+				if !tm.restarting {
+					t.Error("not marked as restarting while stopping")
+				}
+				return tt.stopErr
+			}
+			start := func() error {
+				calls = append(calls, "start")
+				// Connect must not see the tunnel as still up
+				if tm.currentState != StateStopped || tm.isConnected {
+					t.Errorf("started in state %v, connected %v", tm.currentState, tm.isConnected)
+				}
+				return tt.startErr
+			}
+
+			err := tm.restart(stop, start)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("restart() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if tm.restarting != tt.restarting {
+				t.Errorf("restarting = %v afterwards, want %v", tm.restarting, tt.restarting)
+			}
+		})
+	}
+}
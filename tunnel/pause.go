@@ -0,0 +1,127 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// PauseDurations are the durations offered for pausing the tunnel
+var PauseDurations = []time.Duration{
+	5 * time.Minute,
+	15 * time.Minute,
+	60 * time.Minute,
+}
+
+// PauseStatus describes whether the tunnel is paused and when it reconnects.
+// The manager service keeps the pause, so it survives the UI restarting.
+type PauseStatus struct {
+	Paused   bool
+	ResumeAt time.Time
+	// ResumeError is why the manager failed to reconnect when the last pause ended
+	ResumeError string
+}
+
+// Remaining returns how long until the tunnel reconnects, zero if not paused
+func (s PauseStatus) Remaining(now time.Time) time.Duration {
+	if !s.Paused || !s.ResumeAt.After(now) {
+		return 0
+	}
+	return s.ResumeAt.Sub(now)
+}
+
+// RegisterPauseCallback registers a callback that will be called when the tunnel is
+// paused or the pause ends. err is set when the automatic reconnect failed.
+func (tm *Manager) RegisterPauseCallback(cb func(status PauseStatus, err error)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.pauseCallback = cb
+}
+
+// PauseStatus returns the pause state the manager service last reported
+func (tm *Manager) PauseStatus() PauseStatus {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.pause
+}
+
+// Pause has the manager service disconnect the tunnel and reconnect it
+// automatically after d
+func (tm *Manager) Pause(d time.Duration) error {
+	if d <= 0 {
+		return formatConnectionError("Pause Failed", "The pause duration must be positive.", nil)
+	}
+	if tm.State() == StateStopped {
+		return formatConnectionError("Not Connected", "The tunnel is not connected.", nil)
+	}
+	if tm.ipcClient == nil {
+		return fmt.Errorf("IPC client not initialized")
+	}
+
+	tm.cancelProbe()
+	if err := tm.ipcClient.PauseTunnel(d); err != nil {
+		return err
+	}
+	tm.StopStatusPolling()
+	logger.Info("Tunnel paused for %s", d)
+	tm.refreshPause()
+	return nil
+}
+
+// ResumeNow ends a pause early and reconnects the tunnel
+func (tm *Manager) ResumeNow() error {
+	if !tm.PauseStatus().Paused {
+		return nil
+	}
+	// Connect ends the pause
+	return tm.Connect()
+}
+
+// refreshPause fetches the pause state from the manager service and tells the
+// pause callback if it changed
+func (tm *Manager) refreshPause() {
+	if tm.ipcClient == nil {
+		return
+	}
+	status, err := tm.ipcClient.PauseStatus()
+	if err != nil {
+		logger.Debug("Failed to get pause status from manager: %v", err)
+		status = PauseStatus{}
+	}
+
+	tm.mu.Lock()
+	previous := tm.pause
+	tm.pause = status
+	cb := tm.pauseCallback
+	tm.mu.Unlock()
+
+	changed, resumeErr := pauseChange(previous, status)
+	if changed && cb != nil {
+		cb(status, resumeErr)
+	}
+}
+
+// pauseChange compares two pause states the manager reported. It reports
+// whether they differ and, if the pause ended with a failed reconnect, the error.
+func pauseChange(previous, current PauseStatus) (changed bool, resumeErr error) {
+	if previous.Paused && !current.Paused && current.ResumeError != "" {
+		resumeErr = formatConnectionError("Connection Failed",
+			"The tunnel could not reconnect after the pause: "+current.ResumeError, nil)
+	}
+	changed = previous.Paused != current.Paused || !previous.ResumeAt.Equal(current.ResumeAt)
+	return changed, resumeErr
+}
+
+// cancelPause has the manager service drop a pending reconnect
+func (tm *Manager) cancelPause() {
+	if !tm.PauseStatus().Paused || tm.ipcClient == nil {
+		return
+	}
+	if err := tm.ipcClient.CancelPause(); err != nil {
+		logger.Error("Failed to cancel tunnel pause: %v", err)
+	}
+	tm.refreshPause()
+}
@@ -0,0 +1,56 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauseChange(t *testing.T) {
+	resumeAt := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	paused := PauseStatus{Paused: true, ResumeAt: resumeAt}
+
+	tests := []struct {
+		name        string
+		previous    PauseStatus
+		current     PauseStatus
+		wantChanged bool
+		wantErr     bool
+	}{
+		{name: "still not paused", previous: PauseStatus{}, current: PauseStatus{}},
+		{name: "still paused", previous: paused, current: paused},
+		{name: "paused", previous: PauseStatus{}, current: paused, wantChanged: true},
+		{name: "resumed", previous: paused, current: PauseStatus{}, wantChanged: true},
+		{
+			name:        "paused again for longer",
+			previous:    paused,
+			current:     PauseStatus{Paused: true, ResumeAt: resumeAt.Add(time.Hour)},
+			wantChanged: true,
+		},
+		{
+			name:        "reconnect failed",
+			previous:    paused,
+			current:     PauseStatus{ResumeError: "failed"},
+			wantChanged: true,
+			wantErr:     true,
+		},
+		{
+			name:     "old reconnect failure",
+			previous: PauseStatus{ResumeError: "failed"},
+			current:  PauseStatus{ResumeError: "failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := pauseChange(tt.previous, tt.current)
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
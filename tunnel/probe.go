@@ -0,0 +1,114 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// EndpointProbeTimeout bounds the reachability check made before connecting
+const EndpointProbeTimeout = 3 * time.Second
+
+// dialContextFunc opens a connection, like net.Dialer.DialContext
+type dialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// EndpointUnreachableError is returned when the pre-connect probe can't reach the server
+type EndpointUnreachableError struct {
+	Address string // host:port that was probed
+	Reason  string // Why the probe failed, in words for the user
+	Err     error
+}
+
+func (e *EndpointUnreachableError) Error() string {
+	return fmt.Sprintf("can't reach %s: %s", e.Address, e.Reason)
+}
+
+func (e *EndpointUnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// endpointAddress turns an endpoint such as "https://app.pangolin.net" into the
+// host:port OLM connects to. Endpoints without a scheme are treated as HTTPS.
+func endpointAddress(endpoint string) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return "", errors.New("no endpoint is configured")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return "", fmt.Errorf("invalid endpoint %q: no host", endpoint)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// ProbeEndpoint checks that a TCP connection to the endpoint can be opened within
+// EndpointProbeTimeout. It returns ctx's error if ctx is canceled first, and an
+// *EndpointUnreachableError if the server can't be reached.
+func ProbeEndpoint(ctx context.Context, endpoint string) error {
+	var dialer net.Dialer
+	return probeEndpoint(ctx, endpoint, EndpointProbeTimeout, dialer.DialContext)
+}
+
+func probeEndpoint(ctx context.Context, endpoint string, timeout time.Duration, dial dialContextFunc) error {
+	address, err := endpointAddress(endpoint)
+	if err != nil {
+		return err
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := dial(probeCtx, "tcp", address)
+	if err == nil {
+		conn.Close()
+		return nil
+	}
+	// A cancel from the caller is not a verdict on the server
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return &EndpointUnreachableError{Address: address, Reason: probeFailureReason(err), Err: err}
+}
+
+// probeFailureReason describes why a probe failed
+func probeFailureReason(err error) string {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return "the server name could not be resolved"
+	case errors.Is(err, context.DeadlineExceeded), isTimeout(err):
+		return "the connection timed out"
+	case errors.Is(err, windows.WSAECONNREFUSED):
+		return "the connection was refused"
+	case errors.Is(err, windows.WSAENETUNREACH), errors.Is(err, windows.WSAEHOSTUNREACH):
+		return "the network is unreachable"
+	}
+	return err.Error()
+}
+
+// isTimeout reports whether err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,168 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestEndpointAddress(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+		wantErr  bool
+	}{
+		{endpoint: "https://app.pangolin.net", want: "app.pangolin.net:443"},
+		{endpoint: "https://app.pangolin.net/", want: "app.pangolin.net:443"},
+		{endpoint: "http://pangolin.internal", want: "pangolin.internal:80"},
+		{endpoint: "https://pangolin.internal:8443", want: "pangolin.internal:8443"},
+		{endpoint: "app.pangolin.net", want: "app.pangolin.net:443"},
+		{endpoint: "  app.pangolin.net:3000 ", want: "app.pangolin.net:3000"},
+		{endpoint: "https://10.0.0.5", want: "10.0.0.5:443"},
+		{endpoint: "https://[2001:db8::1]:8443", want: "[2001:db8::1]:8443"},
+		{endpoint: "", wantErr: true},
+		{endpoint: "https://", wantErr: true},
+		{endpoint: "https://app pangolin.net", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := endpointAddress(tt.endpoint)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("endpointAddress(%q) = %q, want an error", tt.endpoint, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("endpointAddress(%q) = %q, %v, want %q", tt.endpoint, got, err, tt.want)
+		}
+	}
+}
+
+// fakeConn is the connection a successful stub dial returns
+type fakeConn struct {
+	net.Conn
+	closed bool
+}
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// dialError wraps err as net.Dialer does
+func dialError(err error) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connectex", err)}
+}
+
+func TestProbeEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		dialErr    error
+		wantReason string // Empty if the probe should pass
+	}{
+		{name: "reachable"},
+		{name: "unknown host", dialErr: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "app.pangolin.net", IsNotFound: true}},
+			wantReason: "the server name could not be resolved"},
+		{name: "timed out", dialErr: &net.OpError{Op: "dial", Net: "tcp", Err: context.DeadlineExceeded}, wantReason: "the connection timed out"},
+		{name: "refused", dialErr: dialError(windows.WSAECONNREFUSED), wantReason: "the connection was refused"},
+		{name: "no route to the network", dialErr: dialError(windows.WSAENETUNREACH), wantReason: "the network is unreachable"},
+		{name: "no route to the host", dialErr: dialError(windows.WSAEHOSTUNREACH), wantReason: "the network is unreachable"},
+		{name: "anything else", dialErr: errors.New("proxy said no"), wantReason: "proxy said no"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{}
+			var dialed string
+			dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+				dialed = network + " " + address
+				if _, ok := ctx.Deadline(); !ok {
+					t.Error("dialed without a timeout")
+				}
+				if tt.dialErr != nil {
+					return nil, tt.dialErr
+				}
+				return conn, nil
+			}
+
+			err := probeEndpoint(context.Background(), "https://app.pangolin.net", time.Second, dial)
+			if dialed != "tcp app.pangolin.net:443" {
+				t.Errorf("dialed %q, want tcp app.pangolin.net:443", dialed)
+			}
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("probeEndpoint() error = %v", err)
+				}
+				if !conn.closed {
+					t.Error("probe connection left open")
+				}
+				return
+			}
+
+			var unreachable *EndpointUnreachableError
+			if !errors.As(err, &unreachable) {
+				t.Fatalf("probeEndpoint() error = %v, want an *EndpointUnreachableError", err)
+			}
+			if unreachable.Address != "app.pangolin.net:443" || unreachable.Reason != tt.wantReason {
+				t.Errorf("unreachable %s: %q, want app.pangolin.net:443: %q", unreachable.Address, unreachable.Reason, tt.wantReason)
+			}
+			if !errors.Is(err, tt.dialErr) {
+				t.Errorf("probeEndpoint() error = %v, want it to wrap %v", err, tt.dialErr)
+			}
+		})
+	}
+}
+
+func TestProbeEndpointTimesOut(t *testing.T) {
+	hang := func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, &net.OpError{Op: "dial", Net: network, Err: ctx.Err()}
+	}
+
+	start := time.Now()
+	err := probeEndpoint(context.Background(), "app.pangolin.net", 50*time.Millisecond, hang)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("probe took %v, want it to give up after the timeout", elapsed)
+	}
+	var unreachable *EndpointUnreachableError
+	if !errors.As(err, &unreachable) || unreachable.Reason != "the connection timed out" {
+		t.Errorf("probeEndpoint() error = %v, want a timeout", err)
+	}
+}
+
+// Canceling the probe is not a verdict on the server
+func TestProbeEndpointCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	hang := func(ctx context.Context, network, address string) (net.Conn, error) {
+		cancel()
+		<-ctx.Done()
+		return nil, &net.OpError{Op: "dial", Net: network, Err: ctx.Err()}
+	}
+
+	err := probeEndpoint(ctx, "app.pangolin.net", time.Minute, hang)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("probeEndpoint() error = %v, want context.Canceled", err)
+	}
+	var unreachable *EndpointUnreachableError
+	if errors.As(err, &unreachable) {
+		t.Error("a canceled probe reported the server unreachable")
+	}
+}
+
+func TestProbeEndpointBadEndpoint(t *testing.T) {
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		t.Errorf("dialed %s for an invalid endpoint", address)
+		return nil, errors.New("unexpected dial")
+	}
+	if err := probeEndpoint(context.Background(), "", time.Second, dial); err == nil {
+		t.Error("probeEndpoint() accepted an empty endpoint")
+	}
+}
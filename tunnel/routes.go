@@ -0,0 +1,154 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// Routing modes reported in OLMStatusResponse.RoutingMode
+const (
+	// RoutingModeSite routes every subnet the sites publish through the tunnel
+	RoutingModeSite = "site"
+	// RoutingModeSplit narrows the site subnets with AllowedIPs and ExcludedIPs
+	RoutingModeSplit = "split"
+)
+
+// overlayPrefix holds Pangolin's own client and utility addressing. Routes
+// inside it are never filtered, or peers and aliases would stop resolving.
+var overlayPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// RoutingMode returns the routing mode for the given split tunnel lists
+func RoutingMode(allowedIPs, excludedIPs []string) string {
+	if len(allowedIPs) == 0 && len(excludedIPs) == 0 {
+		return RoutingModeSite
+	}
+	return RoutingModeSplit
+}
+
+// parseCIDRs parses a list of IPv4 CIDRs, rejecting entries with host bits set
+func parseCIDRs(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid CIDR", entry)
+		}
+		if !prefix.Addr().Is4() {
+			return nil, fmt.Errorf("%q is not an IPv4 CIDR; only IPv4 routes are supported", entry)
+		}
+		if prefix.Masked() != prefix {
+			return nil, fmt.Errorf("%q has host bits set; did you mean %s?", entry, prefix.Masked())
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// ValidateSplitTunnel checks the allowed and excluded lists before connecting.
+// An excluded range that covers an allowed one entirely is rejected, since
+// traffic for it would be neither tunneled nor meant to stay local.
+func ValidateSplitTunnel(allowedIPs, excludedIPs []string) error {
+	allowed, err := parseCIDRs(allowedIPs)
+	if err != nil {
+		return fmt.Errorf("allowed IPs: %w", err)
+	}
+	excluded, err := parseCIDRs(excludedIPs)
+	if err != nil {
+		return fmt.Errorf("excluded IPs: %w", err)
+	}
+	for _, a := range allowed {
+		for _, e := range excluded {
+			if e.Bits() <= a.Bits() && e.Contains(a.Addr()) {
+				return fmt.Errorf("excluded %s covers all of allowed %s", e, a)
+			}
+		}
+	}
+	return nil
+}
+
+// splitRoutes computes the routes to install for a subnet a site publishes.
+// With allowed ranges, only the parts of subnet inside them are kept; excluded
+// ranges are then cut out. The result is sorted and may be empty.
+func splitRoutes(subnet netip.Prefix, allowed, excluded []netip.Prefix) []netip.Prefix {
+	subnet = subnet.Masked()
+	if overlayPrefix.Overlaps(subnet) && overlayPrefix.Bits() <= subnet.Bits() {
+		return []netip.Prefix{subnet}
+	}
+
+	routes := []netip.Prefix{subnet}
+	if len(allowed) > 0 {
+		routes = routes[:0]
+		for _, a := range allowed {
+			if !a.Overlaps(subnet) {
+				continue
+			}
+			// Overlapping prefixes nest, so keep the narrower one
+			if a.Bits() >= subnet.Bits() {
+				routes = append(routes, a)
+			} else {
+				routes = append(routes, subnet)
+			}
+		}
+	}
+
+	for _, e := range excluded {
+		var remaining []netip.Prefix
+		for _, r := range routes {
+			remaining = append(remaining, subtractPrefix(r, e)...)
+		}
+		routes = remaining
+	}
+
+	return uniquePrefixes(routes)
+}
+
+// subtractPrefix returns the prefixes covering p but not e
+func subtractPrefix(p, e netip.Prefix) []netip.Prefix {
+	if !p.Overlaps(e) {
+		return []netip.Prefix{p}
+	}
+	if e.Bits() <= p.Bits() {
+		return nil
+	}
+	// e lies inside p: split p in half and keep the half without e whole
+	lower := netip.PrefixFrom(p.Addr(), p.Bits()+1)
+	upper := netip.PrefixFrom(upperHalf(p), p.Bits()+1)
+	if lower.Contains(e.Addr()) {
+		return append(subtractPrefix(lower, e), upper)
+	}
+	return append([]netip.Prefix{lower}, subtractPrefix(upper, e)...)
+}
+
+// upperHalf returns the first address of the upper half of IPv4 prefix p
+func upperHalf(p netip.Prefix) netip.Addr {
+	addr := p.Addr().As4()
+	bit := p.Bits()
+	addr[bit/8] |= 0x80 >> (bit % 8)
+	return netip.AddrFrom4(addr)
+}
+
+// uniquePrefixes sorts prefixes and drops duplicates and ones nested in another
+func uniquePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sort.Slice(prefixes, func(i, j int) bool {
+		if c := prefixes[i].Addr().Compare(prefixes[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return prefixes[i].Bits() < prefixes[j].Bits()
+	})
+	unique := prefixes[:0]
+	for _, p := range prefixes {
+		if n := len(unique); n > 0 && unique[n-1].Overlaps(p) {
+			continue
+		}
+		unique = append(unique, p)
+	}
+	return unique
+}
@@ -0,0 +1,176 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// prefixes parses a list of CIDRs for test tables
+func prefixes(t *testing.T, list ...string) []netip.Prefix {
+	t.Helper()
+	out := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		out = append(out, netip.MustParsePrefix(s))
+	}
+	return out
+}
+
+func TestRoutingMode(t *testing.T) {
+	if got := RoutingMode(nil, nil); got != RoutingModeSite {
+		t.Errorf("RoutingMode(nil, nil) = %q, want %q", got, RoutingModeSite)
+	}
+	if got := RoutingMode([]string{"10.0.0.0/8"}, nil); got != RoutingModeSplit {
+		t.Errorf("RoutingMode(allowed, nil) = %q, want %q", got, RoutingModeSplit)
+	}
+	if got := RoutingMode(nil, []string{"10.0.0.0/8"}); got != RoutingModeSplit {
+		t.Errorf("RoutingMode(nil, excluded) = %q, want %q", got, RoutingModeSplit)
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", in: nil, want: []string{}},
+		{name: "valid", in: []string{"10.0.0.0/8", "192.168.1.0/24", "192.168.1.7/32"}, want: []string{"10.0.0.0/8", "192.168.1.0/24", "192.168.1.7/32"}},
+		{name: "spaces and blanks", in: []string{" 10.0.0.0/8 ", "", "  "}, want: []string{"10.0.0.0/8"}},
+		{name: "default route", in: []string{"0.0.0.0/0"}, want: []string{"0.0.0.0/0"}},
+		{name: "not a CIDR", in: []string{"10.0.0.0"}, wantErr: "is not a valid CIDR"},
+		{name: "bad prefix length", in: []string{"10.0.0.0/33"}, wantErr: "is not a valid CIDR"},
+		{name: "IPv6", in: []string{"fd00::/8"}, wantErr: "only IPv4 routes are supported"},
+		{name: "host bits", in: []string{"10.1.2.3/16"}, wantErr: "did you mean 10.1.0.0/16?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCIDRs(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseCIDRs(%q) error = %v, want one containing %q", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCIDRs(%q): %v", tt.in, err)
+			}
+			if want := prefixes(t, tt.want...); !reflect.DeepEqual(got, want) {
+				t.Errorf("parseCIDRs(%q) = %v, want %v", tt.in, got, want)
+			}
+		})
+	}
+}
+
+func TestValidateSplitTunnel(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  []string
+		excluded []string
+		wantErr  string
+	}{
+		{name: "empty"},
+		{name: "allowed only", allowed: []string{"10.0.0.0/8"}},
+		{name: "excluded inside allowed", allowed: []string{"10.0.0.0/8"}, excluded: []string{"10.1.0.0/16"}},
+		{name: "disjoint", allowed: []string{"10.0.0.0/8"}, excluded: []string{"192.168.0.0/16"}},
+		{name: "excluded covers allowed", allowed: []string{"10.1.0.0/16"}, excluded: []string{"10.0.0.0/8"}, wantErr: "excluded 10.0.0.0/8 covers all of allowed 10.1.0.0/16"},
+		{name: "excluded equals allowed", allowed: []string{"10.0.0.0/8"}, excluded: []string{"10.0.0.0/8"}, wantErr: "covers all of allowed"},
+		{name: "invalid allowed", allowed: []string{"nope"}, wantErr: "allowed IPs:"},
+		{name: "invalid excluded", excluded: []string{"10.0.0.1/8"}, wantErr: "excluded IPs:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSplitTunnel(tt.allowed, tt.excluded)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateSplitTunnel: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateSplitTunnel error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitRoutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		subnet   string
+		allowed  []string
+		excluded []string
+		want     []string
+	}{
+		{name: "no lists", subnet: "10.0.0.0/16", want: []string{"10.0.0.0/16"}},
+		{name: "allowed covers subnet", subnet: "10.0.0.0/16", allowed: []string{"10.0.0.0/8"}, want: []string{"10.0.0.0/16"}},
+		{name: "allowed inside subnet", subnet: "10.0.0.0/16", allowed: []string{"10.0.1.0/24", "10.0.5.0/24"}, want: []string{"10.0.1.0/24", "10.0.5.0/24"}},
+		{name: "allowed elsewhere", subnet: "10.0.0.0/16", allowed: []string{"192.168.0.0/16"}, want: []string{}},
+		{name: "excluded elsewhere", subnet: "10.0.0.0/16", excluded: []string{"192.168.0.0/16"}, want: []string{"10.0.0.0/16"}},
+		{name: "excluded covers subnet", subnet: "10.0.0.0/16", excluded: []string{"10.0.0.0/8"}, want: []string{}},
+		{
+			name:     "excluded lower quarter",
+			subnet:   "10.0.0.0/24",
+			excluded: []string{"10.0.0.0/26"},
+			want:     []string{"10.0.0.64/26", "10.0.0.128/25"},
+		},
+		{
+			name:     "excluded single host",
+			subnet:   "10.0.0.0/30",
+			excluded: []string{"10.0.0.2/32"},
+			want:     []string{"10.0.0.0/31", "10.0.0.3/32"},
+		},
+		{
+			name:     "allowed then excluded",
+			subnet:   "10.0.0.0/16",
+			allowed:  []string{"10.0.1.0/24"},
+			excluded: []string{"10.0.1.128/25"},
+			want:     []string{"10.0.1.0/25"},
+		},
+		{name: "nested allowed collapse", subnet: "10.0.0.0/16", allowed: []string{"10.0.0.0/20", "10.0.1.0/24"}, want: []string{"10.0.0.0/20"}},
+		{name: "overlay is never narrowed", subnet: "100.90.0.0/16", excluded: []string{"100.90.1.0/24"}, allowed: []string{"10.0.0.0/8"}, want: []string{"100.90.0.0/16"}},
+		{name: "host bits are masked", subnet: "10.0.0.5/24", excluded: []string{"10.0.0.128/25"}, want: []string{"10.0.0.0/25"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subnet := netip.MustParsePrefix(tt.subnet)
+			got := splitRoutes(subnet, prefixes(t, tt.allowed...), prefixes(t, tt.excluded...))
+			if got == nil {
+				got = []netip.Prefix{}
+			}
+			if want := prefixes(t, tt.want...); !reflect.DeepEqual(got, want) {
+				t.Errorf("splitRoutes(%s) = %v, want %v", tt.subnet, got, want)
+			}
+		})
+	}
+}
+
+func TestSubtractPrefixCoversTheRest(t *testing.T) {
+	p := netip.MustParsePrefix("10.0.0.0/24")
+	e := netip.MustParsePrefix("10.0.0.77/32")
+	rest := subtractPrefix(p, e)
+
+	// Every address of p but e is in exactly one remaining prefix
+	for addr := p.Addr(); p.Contains(addr); addr = addr.Next() {
+		n := 0
+		for _, r := range rest {
+			if r.Contains(addr) {
+				n++
+			}
+		}
+		want := 1
+		if addr == e.Addr() {
+			want = 0
+		}
+		if n != want {
+			t.Fatalf("%s is in %d remaining prefixes, want %d (%v)", addr, n, want, rest)
+		}
+	}
+}
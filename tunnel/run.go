@@ -8,6 +8,7 @@ import (
 
 	"github.com/fosrl/newt/logger"
 	"github.com/fosrl/olm/olm"
+	configpkg "github.com/fosrl/windows/config"
 	"golang.org/x/sys/windows/svc"
 )
 
@@ -28,6 +29,11 @@ type tunnelService struct {
 
 	fingerprintCtx    context.Context
 	fingerprintCancel context.CancelFunc
+
+	splitTunnelCancel context.CancelFunc
+
+	killSwitch       *killSwitch
+	killSwitchCancel context.CancelFunc
 }
 
 func (s *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (svcSpecificEC bool, exitCode uint32) {
@@ -40,6 +46,16 @@ func (s *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest, chang
 		logger.Error("Tunnel service: Failed to parse config: %v", err)
 		return false, 1
 	}
+	// Log at the level the user picked, as OLM will once it starts
+	configpkg.ApplyLogLevel(config.LogLevel)
+
+	// A kill switch left behind by a crashed run stays up until this run's
+	// filters replace it, unless this run doesn't block traffic at all
+	if !config.KillSwitch {
+		if err := RemoveKillSwitchFilters(); err != nil {
+			logger.Error("Tunnel service: Failed to remove a leftover kill switch: %v", err)
+		}
+	}
 
 	// Set state to registering when service starts (before OLM initialization)
 	SetState(StateRegistering)
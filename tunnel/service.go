@@ -37,6 +37,9 @@ func notifyStateChange(state State) {
 // OLMNamedPipePath is the Windows named pipe path for OLM API communication
 const OLMNamedPipePath = `\\.\pipe\pangolin-olm`
 
+// InterfaceName is the name of the tunnel's network adapter
+const InterfaceName = "Pangolin"
+
 // State represents the state of a tunnel
 type State int
 
@@ -104,14 +107,18 @@ func (s State) DisplayText() string {
 	}
 }
 
-// Config represents the tunnel configuration
+// Config represents the tunnel configuration.
+//
+// DNS is applied to the adapter by OLM, not here: with OverrideDNS set, OLM
+// points the adapter at its DNS proxy once connected, forwarding to
+// UpstreamDNS, and restores the adapter's settings in StopTunnel.
 type Config struct {
 	Name string `json:"name"` // for Windows service name
 
 	Endpoint            string   `json:"endpoint"`
 	ID                  string   `json:"id"`
 	Secret              string   `json:"secret"`
-	MTU                 int      `json:"mtu"`
+	MTU                 int      `json:"mtu"` // 0 = auto
 	DNS                 string   `json:"dns"`
 	Holepunch           bool     `json:"holepunch"`
 	PingIntervalSeconds int      `json:"pingIntervalSeconds"`
@@ -122,6 +129,26 @@ type Config struct {
 	UpstreamDNS         []string `json:"upstreamDns"`
 	OverrideDNS         bool     `json:"overrideDns"`
 	TunnelDNS           bool     `json:"tunnelDns"`
+	// AllowedIPs limits the site subnets routed through the tunnel to these IPv4 CIDRs; empty allows all
+	AllowedIPs []string `json:"allowedIps,omitempty"`
+	// ExcludedIPs are IPv4 CIDRs kept off the tunnel even when a site routes them
+	ExcludedIPs []string `json:"excludedIps,omitempty"`
+	// KillSwitch blocks all traffic outside the tunnel while it is up
+	KillSwitch bool `json:"killSwitch,omitempty"`
+	// LogLevel is how much the tunnel service and OLM log; empty uses the default
+	LogLevel string `json:"logLevel,omitempty"`
+}
+
+// Redacted returns a copy of the config that is safe to log, with its
+// credentials replaced
+func (c Config) Redacted() Config {
+	if c.Secret != "" {
+		c.Secret = "[REDACTED]"
+	}
+	if c.UserToken != "" {
+		c.UserToken = "[REDACTED]"
+	}
+	return c
 }
 
 func StartTunnel(config Config) error {
@@ -0,0 +1,192 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/newt/network"
+	"golang.org/x/sys/windows"
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+)
+
+// splitTunnel rewrites the routes OLM adds for site subnets on the tunnel
+// adapter so only the allowed, non-excluded parts go through the tunnel.
+// OLM records each subnet it routes in newt's network settings, which is
+// what it is compared against. Only IPv4 routes are narrowed, as OLM only
+// routes IPv4 site subnets.
+type splitTunnel struct {
+	allowed  []netip.Prefix
+	excluded []netip.Prefix
+	// installed are the routes this added in place of OLM's
+	installed map[netip.Prefix]struct{}
+	// replaced are OLM's routes that the installed ones stand in for
+	replaced []netip.Prefix
+	// lastVersion is the network settings version last reconciled
+	lastVersion int
+}
+
+// newSplitTunnel returns nil if config doesn't narrow the routes
+func newSplitTunnel(config Config) (*splitTunnel, error) {
+	if RoutingMode(config.AllowedIPs, config.ExcludedIPs) != RoutingModeSplit {
+		return nil, nil
+	}
+	if err := ValidateSplitTunnel(config.AllowedIPs, config.ExcludedIPs); err != nil {
+		return nil, err
+	}
+	allowed, _ := parseCIDRs(config.AllowedIPs)
+	excluded, _ := parseCIDRs(config.ExcludedIPs)
+	return &splitTunnel{
+		allowed:     allowed,
+		excluded:    excluded,
+		installed:   make(map[netip.Prefix]struct{}),
+		lastVersion: -1,
+	}, nil
+}
+
+// run reconciles the routes each time an IPv4 route is added, until ctx is
+// cancelled. Reacting to the route change rather than polling removes an
+// excluded route as soon as OLM adds it.
+func (st *splitTunnel) run(ctx context.Context) {
+	changed := make(chan struct{}, 1)
+	callback, err := winipcfg.RegisterRouteChangeCallback(func(notificationType winipcfg.MibNotificationType, route *winipcfg.MibIPforwardRow2) {
+		if notificationType != winipcfg.MibAddInstance || route == nil || !route.DestinationPrefix.Prefix().Addr().Is4() {
+			return
+		}
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		logger.Error("Split tunnel: failed to watch route changes: %v", err)
+		return
+	}
+	defer callback.Unregister()
+
+	st.reconcile()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+			st.reconcile()
+		}
+	}
+}
+
+// reconcile replaces OLM's site routes with their split equivalents
+func (st *splitTunnel) reconcile() {
+	luidValue, err := interfaceLUID(InterfaceName)
+	if err != nil {
+		// The adapter isn't up yet; its routes being added will call this again
+		return
+	}
+	luid := winipcfg.LUID(luidValue)
+	nextHop := netip.IPv4Unspecified()
+
+	// OLM records a subnet just before adding its route, so removing the
+	// replaced routes is retried on every route change rather than once per
+	// settings change
+	defer st.removeReplaced(luid, nextHop)
+
+	version := network.GetIncrementor()
+	if version == st.lastVersion {
+		return
+	}
+
+	var subnets []netip.Prefix
+	for _, route := range network.GetSettings().IPv4IncludedRoutes {
+		if subnet, ok := includedRoutePrefix(route); ok {
+			subnets = append(subnets, subnet)
+		}
+	}
+	var wanted map[netip.Prefix]struct{}
+	wanted, st.replaced = planSplitRoutes(subnets, st.allowed, st.excluded)
+
+	// Drop routes installed earlier that are no longer wanted
+	for r := range st.installed {
+		if _, keep := wanted[r]; keep {
+			continue
+		}
+		if err := luid.DeleteRoute(r, nextHop); err != nil {
+			logger.Debug("Split tunnel: failed to remove route %s: %v", r, err)
+		}
+		delete(st.installed, r)
+	}
+
+	for r := range wanted {
+		if _, ok := st.installed[r]; ok {
+			continue
+		}
+		if err := luid.AddRoute(r, nextHop, 1); err != nil && err != windows.ERROR_OBJECT_ALREADY_EXISTS {
+			logger.Error("Split tunnel: failed to add route %s: %v", r, err)
+			continue
+		}
+		logger.Info("Split tunnel: added route %s", r)
+		st.installed[r] = struct{}{}
+	}
+
+	st.lastVersion = version
+}
+
+// planSplitRoutes works out, for the subnets OLM routes, which routes to
+// install and which of OLM's routes they replace. A subnet that needs no
+// narrowing keeps OLM's route and appears in neither; one that is wholly
+// excluded is replaced by nothing.
+func planSplitRoutes(subnets, allowed, excluded []netip.Prefix) (wanted map[netip.Prefix]struct{}, replaced []netip.Prefix) {
+	wanted = make(map[netip.Prefix]struct{})
+	var narrowed []netip.Prefix
+	for _, subnet := range subnets {
+		routes := splitRoutes(subnet, allowed, excluded)
+		if len(routes) == 1 && routes[0] == subnet {
+			continue // Nothing to change, OLM's own route stays
+		}
+		narrowed = append(narrowed, subnet)
+		for _, r := range routes {
+			wanted[r] = struct{}{}
+		}
+	}
+	// A subnet that is also installed as a split route of another stays
+	for _, subnet := range narrowed {
+		if _, keep := wanted[subnet]; !keep {
+			replaced = append(replaced, subnet)
+		}
+	}
+	return wanted, replaced
+}
+
+// removeReplaced deletes OLM's routes that the split routes stand in for.
+// Missing routes are already gone.
+func (st *splitTunnel) removeReplaced(luid winipcfg.LUID, nextHop netip.Addr) {
+	for _, subnet := range st.replaced {
+		if err := luid.DeleteRoute(subnet, nextHop); err == nil {
+			logger.Info("Split tunnel: removed route %s", subnet)
+		}
+	}
+}
+
+// includedRoutePrefix converts a route from newt's network settings to a prefix
+func includedRoutePrefix(route network.IPv4Route) (netip.Prefix, bool) {
+	addr, err := netip.ParseAddr(route.DestinationAddress)
+	if err != nil || !addr.Is4() {
+		return netip.Prefix{}, false
+	}
+	bits := 32
+	if route.SubnetMask != "" {
+		mask, err := netip.ParseAddr(route.SubnetMask)
+		if err != nil || !mask.Is4() {
+			return netip.Prefix{}, false
+		}
+		m := mask.As4()
+		bits = 0
+		for _, b := range m {
+			for ; b&0x80 != 0; b <<= 1 {
+				bits++
+			}
+		}
+	}
+	return netip.PrefixFrom(addr, bits).Masked(), true
+}
@@ -0,0 +1,111 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"net/netip"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/fosrl/newt/network"
+)
+
+func TestIncludedRoutePrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		route  network.IPv4Route
+		want   string
+		wantOK bool
+	}{
+		{name: "with mask", route: network.IPv4Route{DestinationAddress: "10.0.0.0", SubnetMask: "255.255.0.0"}, want: "10.0.0.0/16", wantOK: true},
+		{name: "host bits are masked", route: network.IPv4Route{DestinationAddress: "10.0.3.4", SubnetMask: "255.255.255.0"}, want: "10.0.3.0/24", wantOK: true},
+		{name: "no mask is a host", route: network.IPv4Route{DestinationAddress: "10.0.3.4"}, want: "10.0.3.4/32", wantOK: true},
+		{name: "zero mask", route: network.IPv4Route{DestinationAddress: "0.0.0.0", SubnetMask: "0.0.0.0"}, want: "0.0.0.0/0", wantOK: true},
+		{name: "invalid address", route: network.IPv4Route{DestinationAddress: "10.0.0", SubnetMask: "255.0.0.0"}},
+		{name: "IPv6 address", route: network.IPv4Route{DestinationAddress: "fd00::", SubnetMask: "255.0.0.0"}},
+		{name: "invalid mask", route: network.IPv4Route{DestinationAddress: "10.0.0.0", SubnetMask: "nope"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := includedRoutePrefix(tt.route)
+			if ok != tt.wantOK {
+				t.Fatalf("includedRoutePrefix(%+v) ok = %v, want %v", tt.route, ok, tt.wantOK)
+			}
+			if ok && got != netip.MustParsePrefix(tt.want) {
+				t.Errorf("includedRoutePrefix(%+v) = %s, want %s", tt.route, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanSplitRoutes(t *testing.T) {
+	tests := []struct {
+		name         string
+		subnets      []string
+		allowed      []string
+		excluded     []string
+		wantWanted   []string
+		wantReplaced []string
+	}{
+		{
+			name:     "untouched subnets keep OLM's routes",
+			subnets:  []string{"10.0.0.0/16", "192.168.0.0/24"},
+			excluded: []string{"172.16.0.0/12"},
+		},
+		{
+			name:         "narrowed subnet is replaced",
+			subnets:      []string{"10.0.0.0/24", "192.168.0.0/24"},
+			excluded:     []string{"10.0.0.0/25"},
+			wantWanted:   []string{"10.0.0.128/25"},
+			wantReplaced: []string{"10.0.0.0/24"},
+		},
+		{
+			name:         "excluded subnet is replaced by nothing",
+			subnets:      []string{"10.0.0.0/24"},
+			excluded:     []string{"10.0.0.0/16"},
+			wantReplaced: []string{"10.0.0.0/24"},
+		},
+		{
+			name:         "subnets outside the allowed ranges are dropped",
+			subnets:      []string{"10.0.0.0/16", "192.168.0.0/24"},
+			allowed:      []string{"10.0.1.0/24"},
+			wantWanted:   []string{"10.0.1.0/24"},
+			wantReplaced: []string{"10.0.0.0/16", "192.168.0.0/24"},
+		},
+		{
+			name:         "subnet also wanted for another stays",
+			subnets:      []string{"10.0.0.0/16", "10.0.1.0/24"},
+			allowed:      []string{"10.0.1.0/24"},
+			wantWanted:   []string{"10.0.1.0/24"},
+			wantReplaced: []string{"10.0.0.0/16"},
+		},
+		{
+			name:    "overlay subnet is kept",
+			subnets: []string{"100.96.0.0/16"},
+			allowed: []string{"10.0.0.0/8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wanted, replaced := planSplitRoutes(prefixes(t, tt.subnets...), prefixes(t, tt.allowed...), prefixes(t, tt.excluded...))
+
+			gotWanted := make([]netip.Prefix, 0, len(wanted))
+			for r := range wanted {
+				gotWanted = append(gotWanted, r)
+			}
+			sort.Slice(gotWanted, func(i, j int) bool { return gotWanted[i].String() < gotWanted[j].String() })
+			if want := prefixes(t, tt.wantWanted...); !reflect.DeepEqual(gotWanted, want) {
+				t.Errorf("wanted = %v, want %v", gotWanted, want)
+			}
+			if replaced == nil {
+				replaced = []netip.Prefix{}
+			}
+			if want := prefixes(t, tt.wantReplaced...); !reflect.DeepEqual(replaced, want) {
+				t.Errorf("replaced = %v, want %v", replaced, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// errAdapterNotFound is returned by interfaceLUID when no adapter has the name
+var errAdapterNotFound = errors.New("not found")
+
+// Stats holds traffic totals for the tunnel adapter since it came up
+type Stats struct {
+	RxBytes uint64
+	TxBytes uint64
+}
+
+// Stats returns the traffic totals of the tunnel adapter. It returns
+// ErrTunnelNotRunning when the tunnel is not connected.
+func (tm *Manager) Stats() (Stats, error) {
+	tm.mu.RLock()
+	currentState := tm.currentState
+	tm.mu.RUnlock()
+	if currentState != StateRunning {
+		return Stats{}, ErrTunnelNotRunning
+	}
+	return AdapterStats()
+}
+
+// AdapterStats returns the traffic totals of the tunnel adapter, whatever the
+// state of the tunnel, failing if the adapter doesn't exist
+func AdapterStats() (Stats, error) {
+	luid, err := interfaceLUID(InterfaceName)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	row := windows.MibIfRow2{InterfaceLuid: luid}
+	if err := windows.GetIfEntry2Ex(windows.MibIfEntryNormal, &row); err != nil {
+		return Stats{}, fmt.Errorf("failed to read interface statistics: %w", err)
+	}
+	return Stats{RxBytes: row.InOctets, TxBytes: row.OutOctets}, nil
+}
+
+// AdapterPresent reports whether the Pangolin network adapter exists
+func AdapterPresent() (bool, error) {
+	_, err := interfaceLUID(InterfaceName)
+	if errors.Is(err, errAdapterNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// interfaceLUID looks up a network adapter by its friendly name
+func interfaceLUID(name string) (uint64, error) {
+	const flags = windows.GAA_FLAG_SKIP_UNICAST | windows.GAA_FLAG_SKIP_ANYCAST |
+		windows.GAA_FLAG_SKIP_MULTICAST | windows.GAA_FLAG_SKIP_DNS_SERVER
+
+	var luid uint64
+	err := findAdapter(name, flags, func(adapter *windows.IpAdapterAddresses) {
+		luid = adapter.Luid
+	})
+	return luid, err
+}
+
+// AdapterAddresses returns the addresses assigned to the tunnel adapter, IPv4
+// before IPv6, leaving out IPv6 link-local ones
+func AdapterAddresses() ([]string, error) {
+	const flags = windows.GAA_FLAG_SKIP_ANYCAST | windows.GAA_FLAG_SKIP_MULTICAST |
+		windows.GAA_FLAG_SKIP_DNS_SERVER
+
+	var addrs []netip.Addr
+	err := findAdapter(InterfaceName, flags, func(adapter *windows.IpAdapterAddresses) {
+		for unicast := adapter.FirstUnicastAddress; unicast != nil; unicast = unicast.Next {
+			addr, ok := netip.AddrFromSlice(unicast.Address.IP())
+			if !ok {
+				continue
+			}
+			addr = addr.Unmap()
+			if addr.IsLinkLocalUnicast() {
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+	})
+	sortAddresses(addrs)
+
+	addresses := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		addresses = append(addresses, addr.String())
+	}
+	return addresses, err
+}
+
+// findAdapter calls found with the network adapter whose friendly name is
+// name, as listed by GetAdaptersAddresses with flags
+func findAdapter(name string, flags uint32, found func(adapter *windows.IpAdapterAddresses)) error {
+	size := uint32(15 * 1024)
+	for {
+		buf := make([]byte, size)
+		first := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0]))
+		err := windows.GetAdaptersAddresses(windows.AF_UNSPEC, flags, 0, first, &size)
+		if err == windows.ERROR_BUFFER_OVERFLOW {
+			// size now holds the required length
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list network adapters: %w", err)
+		}
+
+		for adapter := first; adapter != nil; adapter = adapter.Next {
+			if windows.UTF16PtrToString(adapter.FriendlyName) == name {
+				found(adapter)
+				return nil
+			}
+		}
+		return fmt.Errorf("network adapter %q: %w", name, errAdapterNotFound)
+	}
+}
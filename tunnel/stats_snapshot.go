@@ -0,0 +1,126 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// SnapshotInterval is how often the manager service pushes a StatsSnapshot
+// while a tunnel is up
+const SnapshotInterval = 3 * time.Second
+
+// StatsSnapshot is a compact copy of an OLM status and the adapter's traffic
+// totals. The manager service pushes one to the UI every few seconds while a
+// tunnel is up, so the UI doesn't have to poll OLM itself.
+type StatsSnapshot struct {
+	Taken         time.Time
+	Connected     bool
+	Registered    bool
+	Terminated    bool
+	Version       string
+	Agent         string
+	OrgID         string
+	StatusReason  string
+	TunnelAddress string
+	// Error is OLM's error, nil if it reported none
+	Error *OLMStatusError
+	Peers []OLMPeerStatus
+	// NetworkSettings holds OLM's network settings as JSON, since their values
+	// can be of any type and gob only carries registered types
+	NetworkSettings []byte
+	// Traffic holds the adapter's totals if HaveTraffic is set
+	Traffic     Stats
+	HaveTraffic bool
+}
+
+// NewStatsSnapshot takes a snapshot of status and, if not nil, traffic
+func NewStatsSnapshot(status *OLMStatusResponse, traffic *Stats) StatsSnapshot {
+	s := StatsSnapshot{
+		Taken:         time.Now(),
+		Connected:     status.Connected,
+		Registered:    status.Registered,
+		Terminated:    status.Terminated,
+		Version:       status.Version,
+		Agent:         status.Agent,
+		OrgID:         status.OrgID,
+		StatusReason:  status.StatusReason,
+		TunnelAddress: status.TunnelAddress,
+		Error:         status.Error,
+	}
+	for _, peer := range status.PeerStatuses {
+		if peer != nil {
+			s.Peers = append(s.Peers, *peer)
+		}
+	}
+	if len(status.NetworkSettings) > 0 {
+		if settings, err := json.Marshal(status.NetworkSettings); err == nil {
+			s.NetworkSettings = settings
+		}
+	}
+	if traffic != nil {
+		s.Traffic = *traffic
+		s.HaveTraffic = true
+	}
+	return s
+}
+
+// Status rebuilds the OLM status the snapshot was taken of. LastError and
+// RoutingMode are left for the UI's Manager to fill in.
+func (s StatsSnapshot) Status() *OLMStatusResponse {
+	status := &OLMStatusResponse{
+		Connected:     s.Connected,
+		Registered:    s.Registered,
+		Terminated:    s.Terminated,
+		Version:       s.Version,
+		Agent:         s.Agent,
+		OrgID:         s.OrgID,
+		StatusReason:  s.StatusReason,
+		TunnelAddress: s.TunnelAddress,
+		Error:         s.Error,
+	}
+	if len(s.Peers) > 0 {
+		status.PeerStatuses = make(map[int]*OLMPeerStatus, len(s.Peers))
+		for i := range s.Peers {
+			peer := s.Peers[i]
+			status.PeerStatuses[peer.SiteID] = &peer
+		}
+	}
+	if len(s.NetworkSettings) > 0 {
+		_ = json.Unmarshal(s.NetworkSettings, &status.NetworkSettings)
+	}
+	return status
+}
+
+// StatusFromSnapshot is the status a snapshot pushed by the manager describes,
+// annotated as GetOLMStatus annotates a polled one
+func (tm *Manager) StatusFromSnapshot(s StatsSnapshot) *OLMStatusResponse {
+	status := s.Status()
+	tm.annotateStatus(status)
+	return status
+}
+
+// SnapshotFeed remembers when the latest pushed snapshot arrived, so that a
+// consumer can fall back to polling when the manager doesn't push any, as an
+// older manager doesn't
+type SnapshotFeed struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// Received records that a snapshot arrived at
+func (f *SnapshotFeed) Received(at time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last = at
+}
+
+// Stale reports whether the consumer should poll at now, because no snapshot
+// arrived within two intervals, allowing for one delayed push
+func (f *SnapshotFeed) Stale(now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last.IsZero() || now.Sub(f.last) >= 2*SnapshotInterval
+}
@@ -0,0 +1,96 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatsSnapshotRoundTrip(t *testing.T) {
+	seen := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	office := &OLMPeerStatus{SiteID: 1, SiteName: "Office", Connected: true, RTT: 20 * time.Millisecond, LastSeen: seen, Endpoint: "203.0.113.1:51820"}
+	lab := &OLMPeerStatus{SiteID: 7, SiteName: "Lab", IsRelay: true, LastHandshake: seen, PeerIP: "100.90.0.7"}
+	full := &OLMStatusResponse{
+		Connected:     true,
+		Registered:    true,
+		Version:       "1.2.0",
+		Agent:         "olm",
+		OrgID:         "org-1",
+		StatusReason:  "connected",
+		TunnelAddress: "100.90.0.2/24",
+		Error:         &OLMStatusError{Code: "PEER_UNREACHABLE", Message: "Lab is unreachable"},
+		PeerStatuses:  map[int]*OLMPeerStatus{1: office, 7: lab},
+		// Numbers come back as float64, as from OLM's JSON
+		NetworkSettings: map[string]interface{}{"dns": "100.90.0.1", "mtu": float64(1280)},
+	}
+
+	tests := []struct {
+		name    string
+		status  *OLMStatusResponse
+		traffic *Stats
+		want    *OLMStatusResponse
+	}{
+		{name: "full status", status: full, traffic: &Stats{RxBytes: 4096, TxBytes: 1024}, want: full},
+		{name: "without traffic", status: &OLMStatusResponse{Terminated: true}, want: &OLMStatusResponse{Terminated: true}},
+		{
+			name:   "empty peer entry",
+			status: &OLMStatusResponse{Connected: true, PeerStatuses: map[int]*OLMPeerStatus{1: office, 2: nil}},
+			want:   &OLMStatusResponse{Connected: true, PeerStatuses: map[int]*OLMPeerStatus{1: office}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The snapshot crosses the IPC pipe gob encoded
+			var stream bytes.Buffer
+			if err := gob.NewEncoder(&stream).Encode(NewStatsSnapshot(tt.status, tt.traffic)); err != nil {
+				t.Fatalf("encoding: %v", err)
+			}
+			var snapshot StatsSnapshot
+			if err := gob.NewDecoder(&stream).Decode(&snapshot); err != nil {
+				t.Fatalf("decoding: %v", err)
+			}
+
+			if got := snapshot.Status(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Status() = %+v, want %+v", got, tt.want)
+			}
+			if snapshot.HaveTraffic != (tt.traffic != nil) {
+				t.Errorf("HaveTraffic = %v, want %v", snapshot.HaveTraffic, tt.traffic != nil)
+			}
+			if tt.traffic != nil && snapshot.Traffic != *tt.traffic {
+				t.Errorf("Traffic = %+v, want %+v", snapshot.Traffic, *tt.traffic)
+			}
+		})
+	}
+}
+
+func TestSnapshotFeedStale(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		received time.Time // Zero if no snapshot arrived
+		want     bool
+	}{
+		{name: "no snapshot yet", want: true},
+		{name: "just arrived", received: now},
+		{name: "one push delayed", received: now.Add(-SnapshotInterval - time.Second)},
+		{name: "two pushes missed", received: now.Add(-2 * SnapshotInterval), want: true},
+		{name: "long stopped", received: now.Add(-time.Hour), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var feed SnapshotFeed
+			if !tt.received.IsZero() {
+				feed.Received(tt.received)
+			}
+			if got := feed.Stale(now); got != tt.want {
+				t.Errorf("Stale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
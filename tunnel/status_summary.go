@@ -0,0 +1,112 @@
+//go:build windows
+
+package tunnel
+
+import "fmt"
+
+// StatusSummary is the human-readable form of an OLM status, shared by the
+// tray and the Status tab so they describe the connection the same way
+type StatusSummary struct {
+	// Title names the connection state, e.g. "Connected" or "Registering"
+	Title string
+	// Detail explains the state in a sentence, "" if there is nothing to add
+	Detail    string
+	Connected bool
+	// Peers counts the sites OLM reports, ConnectedPeers and RelayedPeers those
+	// of them that are connected, directly or through a relay
+	Peers          int
+	ConnectedPeers int
+	RelayedPeers   int
+}
+
+// SummarizeStatus summarizes status. A nil status, as when the tunnel is not
+// running, is summarized as disconnected.
+func SummarizeStatus(status *OLMStatusResponse) StatusSummary {
+	if status == nil {
+		return StatusSummary{Title: "Disconnected"}
+	}
+
+	summary := StatusSummary{
+		Title:     statusTitle(status),
+		Detail:    statusDetail(status),
+		Connected: status.Connected,
+	}
+	for _, peer := range status.PeerStatuses {
+		if peer == nil {
+			continue
+		}
+		summary.Peers++
+		if peer.Connected {
+			summary.ConnectedPeers++
+			if peer.IsRelay {
+				summary.RelayedPeers++
+			}
+		}
+	}
+	return summary
+}
+
+// PeerCountText describes how many sites are connected, e.g. "2 of 3 sites
+// connected", or returns "" if OLM reported no sites
+func (s StatusSummary) PeerCountText() string {
+	switch {
+	case s.Peers == 0:
+		return ""
+	case s.Peers == 1 && s.ConnectedPeers == 1:
+		return "1 site connected"
+	case s.ConnectedPeers == s.Peers:
+		return fmt.Sprintf("%d sites connected", s.Peers)
+	default:
+		return fmt.Sprintf("%d of %d sites connected", s.ConnectedPeers, s.Peers)
+	}
+}
+
+// statusTitle names the connection state of status
+func statusTitle(status *OLMStatusResponse) string {
+	switch status.StatusReason {
+	case StatusReasonConnected:
+		return "Connected"
+	case StatusReasonHandshakePending:
+		return "Connecting"
+	case StatusReasonRegistering:
+		return "Registering"
+	case StatusReasonAuthRejected:
+		return "Authentication Rejected"
+	case StatusReasonError:
+		return "Error"
+	case StatusReasonTerminated:
+		return "Terminated"
+	}
+	if status.Connected {
+		return "Connected"
+	}
+	return "Disconnected"
+}
+
+// statusDetail explains the state of status in a sentence, or returns "" if
+// there is nothing to add
+func statusDetail(status *OLMStatusResponse) string {
+	var reason string
+	switch status.StatusReason {
+	case StatusReasonHandshakePending:
+		reason = "Registered with the server, waiting for the tunnel handshake"
+	case StatusReasonRegistering:
+		reason = "Waiting for the server to accept the registration"
+	case StatusReasonAuthRejected:
+		return "The server rejected this session. Log in again to reconnect."
+	case StatusReasonError, StatusReasonTerminated:
+		if status.LastError != "" {
+			return status.LastError
+		}
+		if status.StatusReason == StatusReasonTerminated {
+			return "The server ended the connection"
+		}
+		return "The tunnel reported an error"
+	default:
+		return ""
+	}
+	if status.LastError != "" {
+		reason += " (last error: " + status.LastError + ")"
+	}
+	return reason
+}
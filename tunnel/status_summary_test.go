@@ -0,0 +1,155 @@
+//go:build windows
+
+package tunnel
+
+import "testing"
+
+func TestStatusText(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     OLMStatusResponse
+		wantTitle  string
+		wantDetail string
+	}{
+		{
+			name:      "connected",
+			status:    OLMStatusResponse{Connected: true, StatusReason: StatusReasonConnected},
+			wantTitle: "Connected",
+		},
+		{
+			name:       "registered but not connected",
+			status:     OLMStatusResponse{StatusReason: StatusReasonHandshakePending},
+			wantTitle:  "Connecting",
+			wantDetail: "Registered with the server, waiting for the tunnel handshake",
+		},
+		{
+			name:       "handshake failing",
+			status:     OLMStatusResponse{StatusReason: StatusReasonHandshakePending, LastError: "Site is offline"},
+			wantTitle:  "Connecting",
+			wantDetail: "Registered with the server, waiting for the tunnel handshake (last error: Site is offline)",
+		},
+		{
+			name:       "registering",
+			status:     OLMStatusResponse{StatusReason: StatusReasonRegistering},
+			wantTitle:  "Registering",
+			wantDetail: "Waiting for the server to accept the registration",
+		},
+		{
+			name:       "auth rejected",
+			status:     OLMStatusResponse{StatusReason: StatusReasonAuthRejected, LastError: "Session expired"},
+			wantTitle:  "Authentication Rejected",
+			wantDetail: "The server rejected this session. Log in again to reconnect.",
+		},
+		{
+			name:       "error",
+			status:     OLMStatusResponse{StatusReason: StatusReasonError, LastError: "Site is offline"},
+			wantTitle:  "Error",
+			wantDetail: "Site is offline",
+		},
+		{
+			name:       "error without a message",
+			status:     OLMStatusResponse{StatusReason: StatusReasonError},
+			wantTitle:  "Error",
+			wantDetail: "The tunnel reported an error",
+		},
+		{
+			name:       "terminated",
+			status:     OLMStatusResponse{StatusReason: StatusReasonTerminated},
+			wantTitle:  "Terminated",
+			wantDetail: "The server ended the connection",
+		},
+		{
+			name:      "older service without a reason",
+			status:    OLMStatusResponse{Connected: true},
+			wantTitle: "Connected",
+		},
+		{
+			name:      "older service, disconnected",
+			wantTitle: "Disconnected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusTitle(&tt.status); got != tt.wantTitle {
+				t.Errorf("statusTitle() = %q, want %q", got, tt.wantTitle)
+			}
+			if got := statusDetail(&tt.status); got != tt.wantDetail {
+				t.Errorf("statusDetail() = %q, want %q", got, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestSummarizeStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *OLMStatusResponse
+		want   StatusSummary
+	}{
+		{name: "not running", want: StatusSummary{Title: "Disconnected"}},
+		{
+			name:   "connected without sites",
+			status: &OLMStatusResponse{Connected: true, StatusReason: StatusReasonConnected},
+			want:   StatusSummary{Title: "Connected", Connected: true},
+		},
+		{
+			name: "connected to every site",
+			status: &OLMStatusResponse{Connected: true, StatusReason: StatusReasonConnected, PeerStatuses: map[int]*OLMPeerStatus{
+				1: {Connected: true},
+				2: {Connected: true},
+			}},
+			want: StatusSummary{Title: "Connected", Connected: true, Peers: 2, ConnectedPeers: 2},
+		},
+		{
+			name: "some sites down or relayed",
+			status: &OLMStatusResponse{Connected: true, StatusReason: StatusReasonConnected, PeerStatuses: map[int]*OLMPeerStatus{
+				1: {Connected: true},
+				2: {Connected: true, IsRelay: true},
+				3: {},
+				4: {IsRelay: true},
+				5: nil,
+			}},
+			want: StatusSummary{Title: "Connected", Connected: true, Peers: 4, ConnectedPeers: 2, RelayedPeers: 1},
+		},
+		{
+			name: "waiting for the handshake",
+			status: &OLMStatusResponse{StatusReason: StatusReasonHandshakePending, PeerStatuses: map[int]*OLMPeerStatus{
+				1: {},
+			}},
+			want: StatusSummary{Title: "Connecting", Detail: "Registered with the server, waiting for the tunnel handshake", Peers: 1},
+		},
+		{
+			name:   "disconnected",
+			status: &OLMStatusResponse{},
+			want:   StatusSummary{Title: "Disconnected"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SummarizeStatus(tt.status); got != tt.want {
+				t.Errorf("SummarizeStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeerCountText(t *testing.T) {
+	tests := []struct {
+		summary StatusSummary
+		want    string
+	}{
+		{summary: StatusSummary{}, want: ""},
+		{summary: StatusSummary{Peers: 1, ConnectedPeers: 1}, want: "1 site connected"},
+		{summary: StatusSummary{Peers: 1}, want: "0 of 1 sites connected"},
+		{summary: StatusSummary{Peers: 3, ConnectedPeers: 3, RelayedPeers: 1}, want: "3 sites connected"},
+		{summary: StatusSummary{Peers: 3, ConnectedPeers: 2}, want: "2 of 3 sites connected"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.summary.PeerCountText(); got != tt.want {
+			t.Errorf("PeerCountText() for %+v = %q, want %q", tt.summary, got, tt.want)
+		}
+	}
+}
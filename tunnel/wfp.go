@@ -0,0 +1,394 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// The kill switch talks to the Windows Filtering Platform directly rather than
+// through wireguard-windows' firewall package, which only offers a dynamic
+// session: its filters vanish when the process exits, so a crash of the
+// tunnel service would lift the block. The filters here are added from a
+// regular session instead. They outlive the process that added them and stay
+// in place until they are deleted or the machine restarts, and they have
+// fixed keys so a later run can find and remove ones left behind.
+
+var (
+	modfwpuclnt                   = windows.NewLazySystemDLL("fwpuclnt.dll")
+	procFwpmEngineOpen0           = modfwpuclnt.NewProc("FwpmEngineOpen0")
+	procFwpmEngineClose0          = modfwpuclnt.NewProc("FwpmEngineClose0")
+	procFwpmTransactionBegin0     = modfwpuclnt.NewProc("FwpmTransactionBegin0")
+	procFwpmTransactionCommit0    = modfwpuclnt.NewProc("FwpmTransactionCommit0")
+	procFwpmTransactionAbort0     = modfwpuclnt.NewProc("FwpmTransactionAbort0")
+	procFwpmProviderAdd0          = modfwpuclnt.NewProc("FwpmProviderAdd0")
+	procFwpmProviderDeleteByKey0  = modfwpuclnt.NewProc("FwpmProviderDeleteByKey0")
+	procFwpmSubLayerAdd0          = modfwpuclnt.NewProc("FwpmSubLayerAdd0")
+	procFwpmSubLayerDeleteByKey0  = modfwpuclnt.NewProc("FwpmSubLayerDeleteByKey0")
+	procFwpmFilterAdd0            = modfwpuclnt.NewProc("FwpmFilterAdd0")
+	procFwpmFilterDeleteByKey0    = modfwpuclnt.NewProc("FwpmFilterDeleteByKey0")
+	procFwpmGetAppIdFromFileName0 = modfwpuclnt.NewProc("FwpmGetAppIdFromFileName0")
+	procFwpmFreeMemory0           = modfwpuclnt.NewProc("FwpmFreeMemory0")
+)
+
+// Errors returned by the filter engine when deleting an object that isn't there
+const (
+	fwpEFilterNotFound   = syscall.Errno(0x80320003)
+	fwpEProviderNotFound = syscall.Errno(0x80320005)
+	fwpESublayerNotFound = syscall.Errno(0x80320007)
+)
+
+const (
+	rpcCAuthnWinNT = 10
+
+	fwpUint8                   = 1
+	fwpUint16                  = 2
+	fwpUint32                  = 3
+	fwpUint64                  = 4
+	fwpByteBlobType            = 12
+	fwpMatchEqual              = 0
+	fwpMatchFlagsAll           = 6
+	fwpConditionFlagIsLoopback = 0x00000001
+
+	fwpActionBlock  = 0x00000001 | 0x00001000
+	fwpActionPermit = 0x00000002 | 0x00001000
+
+	ipProtoUDP    = 17
+	ipProtoICMPv6 = 58
+)
+
+// killSwitchFilterSlots bounds the filter keys tried when removing filters,
+// above the number any version has installed
+const killSwitchFilterSlots = 64
+
+var (
+	// killSwitchProviderKey identifies Pangolin's WFP provider
+	killSwitchProviderKey = windows.GUID{Data1: 0x6f1c2a40, Data2: 0x8d3e, Data3: 0x4b5a, Data4: [8]byte{0x9c, 0x27, 0x51, 0xe4, 0x0b, 0x86, 0xd3, 0x1f}}
+	// killSwitchSublayerKey identifies the sublayer holding the kill switch filters
+	killSwitchSublayerKey = windows.GUID{Data1: 0x6f1c2a41, Data2: 0x8d3e, Data3: 0x4b5a, Data4: [8]byte{0x9c, 0x27, 0x51, 0xe4, 0x0b, 0x86, 0xd3, 0x1f}}
+)
+
+// Layers filtered by the kill switch, from fwpmu.h
+var (
+	fwpmLayerALEAuthConnectV4    = windows.GUID{Data1: 0xc38d57d1, Data2: 0x05a7, Data3: 0x4c33, Data4: [8]byte{0x90, 0x4f, 0x7f, 0xbc, 0xee, 0xe6, 0x0e, 0x82}}
+	fwpmLayerALEAuthRecvAcceptV4 = windows.GUID{Data1: 0xe1cd9fe7, Data2: 0xf4b5, Data3: 0x4273, Data4: [8]byte{0x96, 0xc0, 0x59, 0x2e, 0x48, 0x7b, 0x86, 0x50}}
+	fwpmLayerALEAuthConnectV6    = windows.GUID{Data1: 0x4a72393b, Data2: 0x319f, Data3: 0x44bc, Data4: [8]byte{0x84, 0xc3, 0xba, 0x54, 0xdc, 0xb3, 0xb6, 0xb4}}
+	fwpmLayerALEAuthRecvAcceptV6 = windows.GUID{Data1: 0xa3b42c97, Data2: 0x9f04, Data3: 0x4672, Data4: [8]byte{0xb8, 0x7e, 0xce, 0xe9, 0xc4, 0x83, 0x25, 0x7f}}
+)
+
+// Filter conditions used by the kill switch, from fwpmu.h
+var (
+	fwpmConditionIPLocalInterface = windows.GUID{Data1: 0x4cd62a49, Data2: 0x59c3, Data3: 0x4969, Data4: [8]byte{0xb7, 0xf3, 0xbd, 0xa5, 0xd3, 0x28, 0x90, 0xa4}}
+	fwpmConditionIPProtocol       = windows.GUID{Data1: 0x3971ef2b, Data2: 0x623e, Data3: 0x4f9a, Data4: [8]byte{0x8c, 0xb1, 0x6e, 0x79, 0xb8, 0x06, 0xb9, 0xa7}}
+	fwpmConditionIPLocalPort      = windows.GUID{Data1: 0x0c1ba1af, Data2: 0x5765, Data3: 0x453f, Data4: [8]byte{0xaf, 0x22, 0xa8, 0xf7, 0x91, 0xac, 0x77, 0x5b}}
+	fwpmConditionIPRemotePort     = windows.GUID{Data1: 0xc35a604d, Data2: 0xd22b, Data3: 0x4e1a, Data4: [8]byte{0x91, 0xb4, 0x68, 0xf6, 0x74, 0xee, 0x67, 0x4b}}
+	fwpmConditionALEAppID         = windows.GUID{Data1: 0xd78e1e87, Data2: 0x8644, Data3: 0x4ea5, Data4: [8]byte{0x94, 0x37, 0xd8, 0x09, 0xec, 0xef, 0xc9, 0x71}}
+	fwpmConditionFlags            = windows.GUID{Data1: 0x632ce23b, Data2: 0x5167, Data3: 0x435c, Data4: [8]byte{0x86, 0xd7, 0xe9, 0x03, 0x68, 0x4a, 0xa8, 0x0c}}
+	// ICMP filters reuse the port fields for the type
+	fwpmConditionICMPType = fwpmConditionIPLocalPort
+)
+
+// fwpByteBlob is FWP_BYTE_BLOB
+type fwpByteBlob struct {
+	size uint32
+	data *byte
+}
+
+// fwpValue0 is FWP_VALUE0 and FWP_CONDITION_VALUE0; value holds small
+// integers directly and a pointer for anything larger
+type fwpValue0 struct {
+	typ   uint32
+	value uintptr
+}
+
+// fwpmDisplayData0 is FWPM_DISPLAY_DATA0
+type fwpmDisplayData0 struct {
+	name        *uint16
+	description *uint16
+}
+
+// fwpmFilterCondition0 is FWPM_FILTER_CONDITION0
+type fwpmFilterCondition0 struct {
+	fieldKey       windows.GUID
+	matchType      uint32
+	conditionValue fwpValue0
+}
+
+// fwpmAction0 is FWPM_ACTION0
+type fwpmAction0 struct {
+	typ        uint32
+	filterType windows.GUID
+}
+
+// fwpmSession0 is FWPM_SESSION0
+type fwpmSession0 struct {
+	sessionKey           windows.GUID
+	displayData          fwpmDisplayData0
+	flags                uint32
+	txnWaitTimeoutInMSec uint32
+	processID            uint32
+	sid                  *windows.SID
+	username             *uint16
+	kernelMode           int32
+}
+
+// fwpmProvider0 is FWPM_PROVIDER0
+type fwpmProvider0 struct {
+	providerKey  windows.GUID
+	displayData  fwpmDisplayData0
+	flags        uint32
+	providerData fwpByteBlob
+	serviceName  *uint16
+}
+
+// fwpmSublayer0 is FWPM_SUBLAYER0
+type fwpmSublayer0 struct {
+	subLayerKey  windows.GUID
+	displayData  fwpmDisplayData0
+	flags        uint32
+	providerKey  *windows.GUID
+	providerData fwpByteBlob
+	weight       uint16
+}
+
+// fwpmCall calls a filter engine function, which return their error directly
+func fwpmCall(proc *windows.LazyProc, args ...uintptr) error {
+	r, _, _ := proc.Call(args...)
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
+
+// displayData builds FWPM_DISPLAY_DATA0 for an object name
+func displayData(name string) fwpmDisplayData0 {
+	namePtr, _ := windows.UTF16PtrFromString(name)
+	return fwpmDisplayData0{name: namePtr}
+}
+
+// killSwitchFilterKey returns the fixed key of the kill switch filter in the given slot
+func killSwitchFilterKey(slot int) windows.GUID {
+	key := windows.GUID{Data1: 0x6f1c2a80, Data2: 0x8d3e, Data3: 0x4b5a, Data4: [8]byte{0x9c, 0x27, 0x51, 0xe4, 0x0b, 0x86, 0xd3, 0x1f}}
+	key.Data1 += uint32(slot)
+	return key
+}
+
+// wfpFirewall installs the kill switch filters with the Windows Filtering Platform
+type wfpFirewall struct{}
+
+func (wfpFirewall) block(luid uint64) error {
+	appID, err := executableAppID()
+	if err != nil {
+		return fmt.Errorf("failed to get the app ID of Pangolin: %w", err)
+	}
+	defer procFwpmFreeMemory0.Call(uintptr(unsafe.Pointer(&appID)))
+
+	return withWFPTransaction(func(engine uintptr) error {
+		// Replacing the filters in the same transaction leaves no gap when the adapter changes
+		if err := deleteKillSwitchObjects(engine); err != nil {
+			return err
+		}
+		if err := addKillSwitchBase(engine); err != nil {
+			return err
+		}
+		return addKillSwitchFilters(engine, luid, appID)
+	})
+}
+
+func (wfpFirewall) unblock() error {
+	return withWFPTransaction(deleteKillSwitchObjects)
+}
+
+// RemoveKillSwitchFilters lifts a kill switch left behind by a tunnel service
+// that stopped without taking its filters down. It does nothing if there are none.
+func RemoveKillSwitchFilters() error {
+	return wfpFirewall{}.unblock()
+}
+
+// withWFPTransaction opens a session to the filter engine and runs fn in a transaction
+func withWFPTransaction(fn func(engine uintptr) error) error {
+	session := fwpmSession0{
+		displayData:          displayData("Pangolin kill switch"),
+		txnWaitTimeoutInMSec: windows.INFINITE,
+	}
+	var engine uintptr
+	if err := fwpmCall(procFwpmEngineOpen0, 0, rpcCAuthnWinNT, 0, uintptr(unsafe.Pointer(&session)), uintptr(unsafe.Pointer(&engine))); err != nil {
+		return fmt.Errorf("failed to open the filter engine: %w", err)
+	}
+	defer fwpmCall(procFwpmEngineClose0, engine)
+
+	if err := fwpmCall(procFwpmTransactionBegin0, engine, 0); err != nil {
+		return fmt.Errorf("failed to begin a filter transaction: %w", err)
+	}
+	if err := fn(engine); err != nil {
+		fwpmCall(procFwpmTransactionAbort0, engine)
+		return err
+	}
+	if err := fwpmCall(procFwpmTransactionCommit0, engine); err != nil {
+		fwpmCall(procFwpmTransactionAbort0, engine)
+		return fmt.Errorf("failed to commit the filter transaction: %w", err)
+	}
+	return nil
+}
+
+// deleteKillSwitchObjects removes every kill switch filter, its sublayer and provider
+func deleteKillSwitchObjects(engine uintptr) error {
+	for slot := 0; slot < killSwitchFilterSlots; slot++ {
+		key := killSwitchFilterKey(slot)
+		err := fwpmCall(procFwpmFilterDeleteByKey0, engine, uintptr(unsafe.Pointer(&key)))
+		if err != nil && !errors.Is(err, fwpEFilterNotFound) {
+			return fmt.Errorf("failed to delete kill switch filter: %w", err)
+		}
+	}
+	err := fwpmCall(procFwpmSubLayerDeleteByKey0, engine, uintptr(unsafe.Pointer(&killSwitchSublayerKey)))
+	if err != nil && !errors.Is(err, fwpESublayerNotFound) {
+		return fmt.Errorf("failed to delete kill switch sublayer: %w", err)
+	}
+	err = fwpmCall(procFwpmProviderDeleteByKey0, engine, uintptr(unsafe.Pointer(&killSwitchProviderKey)))
+	if err != nil && !errors.Is(err, fwpEProviderNotFound) {
+		return fmt.Errorf("failed to delete kill switch provider: %w", err)
+	}
+	return nil
+}
+
+// addKillSwitchBase registers the provider and the sublayer the filters go in
+func addKillSwitchBase(engine uintptr) error {
+	provider := fwpmProvider0{
+		providerKey: killSwitchProviderKey,
+		displayData: displayData("Pangolin"),
+	}
+	if err := fwpmCall(procFwpmProviderAdd0, engine, uintptr(unsafe.Pointer(&provider)), 0); err != nil {
+		return fmt.Errorf("failed to add kill switch provider: %w", err)
+	}
+	sublayer := fwpmSublayer0{
+		subLayerKey: killSwitchSublayerKey,
+		displayData: displayData("Pangolin kill switch"),
+		providerKey: &killSwitchProviderKey,
+		weight:      ^uint16(0),
+	}
+	if err := fwpmCall(procFwpmSubLayerAdd0, engine, uintptr(unsafe.Pointer(&sublayer)), 0); err != nil {
+		return fmt.Errorf("failed to add kill switch sublayer: %w", err)
+	}
+	return nil
+}
+
+// executableAppID returns the WFP app ID of the running executable; free it with FwpmFreeMemory0
+func executableAppID() (*fwpByteBlob, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	var appID *fwpByteBlob
+	if err := fwpmCall(procFwpmGetAppIdFromFileName0, uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&appID))); err != nil {
+		return nil, err
+	}
+	return appID, nil
+}
+
+// wfpRule is a kill switch filter, added once for each of its layers
+type wfpRule struct {
+	name       string
+	layers     []windows.GUID
+	conditions []fwpmFilterCondition0
+	action     uint32
+	weight     uint8
+}
+
+// addKillSwitchFilters permits the tunnel adapter and Pangolin itself and blocks everything else
+func addKillSwitchFilters(engine uintptr, luid uint64, appID *fwpByteBlob) error {
+	// The adapter condition refers to the LUID by address, so it is pinned for the calls
+	luidValue := new(uint64)
+	*luidValue = luid
+	var pinner runtime.Pinner
+	pinner.Pin(luidValue)
+	defer pinner.Unpin()
+
+	allLayers := []windows.GUID{fwpmLayerALEAuthConnectV4, fwpmLayerALEAuthRecvAcceptV4, fwpmLayerALEAuthConnectV6, fwpmLayerALEAuthRecvAcceptV6}
+	v4Layers := []windows.GUID{fwpmLayerALEAuthConnectV4, fwpmLayerALEAuthRecvAcceptV4}
+	v6Layers := []windows.GUID{fwpmLayerALEAuthConnectV6, fwpmLayerALEAuthRecvAcceptV6}
+	udpPorts := func(local, remote uint16) []fwpmFilterCondition0 {
+		return []fwpmFilterCondition0{
+			{fieldKey: fwpmConditionIPProtocol, matchType: fwpMatchEqual, conditionValue: fwpValue0{typ: fwpUint8, value: ipProtoUDP}},
+			{fieldKey: fwpmConditionIPLocalPort, matchType: fwpMatchEqual, conditionValue: fwpValue0{typ: fwpUint16, value: uintptr(local)}},
+			{fieldKey: fwpmConditionIPRemotePort, matchType: fwpMatchEqual, conditionValue: fwpValue0{typ: fwpUint16, value: uintptr(remote)}},
+		}
+	}
+	// Conditions on the same field match if any of them does
+	ndp := []fwpmFilterCondition0{
+		{fieldKey: fwpmConditionIPProtocol, matchType: fwpMatchEqual, conditionValue: fwpValue0{typ: fwpUint8, value: ipProtoICMPv6}},
+	}
+	for icmpType := 133; icmpType <= 137; icmpType++ {
+		ndp = append(ndp, fwpmFilterCondition0{fieldKey: fwpmConditionICMPType, matchType: fwpMatchEqual, conditionValue: fwpValue0{typ: fwpUint16, value: uintptr(icmpType)}})
+	}
+
+	rules := []wfpRule{
+		{
+			// Pangolin's own processes: OLM's connections, the UI's API requests and the manager's update checks
+			name:   "Permit Pangolin",
+			layers: allLayers,
+			conditions: []fwpmFilterCondition0{
+				{fieldKey: fwpmConditionALEAppID, matchType: fwpMatchEqual, conditionValue: fwpValue0{typ: fwpByteBlobType, value: uintptr(unsafe.Pointer(appID))}},
+			},
+			action: fwpActionPermit,
+			weight: 15,
+		},
+		{
+			name:   "Permit loopback",
+			layers: allLayers,
+			conditions: []fwpmFilterCondition0{
+				{fieldKey: fwpmConditionFlags, matchType: fwpMatchFlagsAll, conditionValue: fwpValue0{typ: fwpUint32, value: fwpConditionFlagIsLoopback}},
+			},
+			action: fwpActionPermit,
+			weight: 13,
+		},
+		{
+			name:   "Permit the Pangolin adapter",
+			layers: allLayers,
+			conditions: []fwpmFilterCondition0{
+				{fieldKey: fwpmConditionIPLocalInterface, matchType: fwpMatchEqual, conditionValue: fwpValue0{typ: fwpUint64, value: uintptr(unsafe.Pointer(luidValue))}},
+			},
+			action: fwpActionPermit,
+			weight: 12,
+		},
+		{name: "Permit DHCP", layers: v4Layers, conditions: udpPorts(68, 67), action: fwpActionPermit, weight: 12},
+		{name: "Permit DHCPv6", layers: v6Layers, conditions: udpPorts(546, 547), action: fwpActionPermit, weight: 12},
+		{name: "Permit neighbor discovery", layers: v6Layers, conditions: ndp, action: fwpActionPermit, weight: 12},
+		{name: "Block traffic outside the tunnel", layers: allLayers, action: fwpActionBlock, weight: 0},
+	}
+
+	slot := 0
+	for _, rule := range rules {
+		for _, layer := range rule.layers {
+			filter := fwpmFilter0{
+				filterKey:           killSwitchFilterKey(slot),
+				displayData:         displayData(rule.name),
+				providerKey:         &killSwitchProviderKey,
+				layerKey:            layer,
+				subLayerKey:         killSwitchSublayerKey,
+				weight:              fwpValue0{typ: fwpUint8, value: uintptr(rule.weight)},
+				numFilterConditions: uint32(len(rule.conditions)),
+				action:              fwpmAction0{typ: rule.action},
+			}
+			if len(rule.conditions) > 0 {
+				filter.filterCondition = &rule.conditions[0]
+			}
+			var id uint64
+			if err := fwpmCall(procFwpmFilterAdd0, engine, uintptr(unsafe.Pointer(&filter)), 0, uintptr(unsafe.Pointer(&id))); err != nil {
+				return fmt.Errorf("failed to add kill switch filter %q: %w", rule.name, err)
+			}
+			slot++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build windows && (amd64 || arm64)
+
+package tunnel
+
+import "golang.org/x/sys/windows"
+
+// fwpmFilter0 is FWPM_FILTER0; the padding places providerContextKey where
+// its union with a UINT64 puts it in C
+type fwpmFilter0 struct {
+	filterKey           windows.GUID
+	displayData         fwpmDisplayData0
+	flags               uint32
+	providerKey         *windows.GUID
+	providerData        fwpByteBlob
+	layerKey            windows.GUID
+	subLayerKey         windows.GUID
+	weight              fwpValue0
+	numFilterConditions uint32
+	filterCondition     *fwpmFilterCondition0
+	action              fwpmAction0
+	_                   [4]byte
+	providerContextKey  windows.GUID
+	reserved            *windows.GUID
+	filterID            uint64
+	effectiveWeight     fwpValue0
+}
@@ -0,0 +1,184 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// wgConfigPipePath is where wireguard-go serves the configuration protocol of
+// the tunnel's device. Only SYSTEM and administrators may open it.
+const wgConfigPipePath = `\\.\pipe\ProtectedPrefix\Administrators\WireGuard\` + InterfaceName
+
+// wgConfigTimeout bounds a single exchange with the device
+const wgConfigTimeout = 5 * time.Second
+
+// WGPeer is a peer of the tunnel's WireGuard device, as the configuration
+// protocol describes it. Keys are hex encoded.
+type WGPeer struct {
+	PublicKey           string
+	PresharedKey        string
+	Endpoint            string
+	PersistentKeepalive string
+	AllowedIPs          []netip.Prefix
+}
+
+// RemoveConfig is the configuration that takes the peer off the device
+func (p WGPeer) RemoveConfig() string {
+	return "public_key=" + p.PublicKey + "\nremove=true\n"
+}
+
+// AddConfig is the configuration that puts the peer back as it was
+func (p WGPeer) AddConfig() string {
+	var b strings.Builder
+	b.WriteString("public_key=" + p.PublicKey + "\n")
+	if p.PresharedKey != "" {
+		b.WriteString("preshared_key=" + p.PresharedKey + "\n")
+	}
+	if p.Endpoint != "" {
+		b.WriteString("endpoint=" + p.Endpoint + "\n")
+	}
+	if p.PersistentKeepalive != "" {
+		b.WriteString("persistent_keepalive_interval=" + p.PersistentKeepalive + "\n")
+	}
+	b.WriteString("replace_allowed_ips=true\n")
+	for _, prefix := range p.AllowedIPs {
+		b.WriteString("allowed_ip=" + prefix.String() + "\n")
+	}
+	return b.String()
+}
+
+// PeerForAddress returns the peer the device sends traffic for addr to, the
+// one whose allowed IPs contain it most specifically
+func PeerForAddress(peers []WGPeer, addr netip.Addr) (WGPeer, bool) {
+	addr = addr.Unmap()
+	best, bestBits := WGPeer{}, -1
+	for _, peer := range peers {
+		for _, prefix := range peer.AllowedIPs {
+			if prefix.Contains(addr) && prefix.Bits() > bestBits {
+				best, bestBits = peer, prefix.Bits()
+			}
+		}
+	}
+	return best, bestBits >= 0
+}
+
+// ParsePeerAddress parses the tunnel address OLM reports for a site, which
+// may come with a prefix length
+func ParsePeerAddress(address string) (netip.Addr, error) {
+	address = strings.TrimSpace(address)
+	if prefix, err := netip.ParsePrefix(address); err == nil {
+		return prefix.Addr().Unmap(), nil
+	}
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid site address %q", address)
+	}
+	return addr.Unmap(), nil
+}
+
+// GetWGPeers reads the peers of the tunnel's WireGuard device
+func GetWGPeers() ([]WGPeer, error) {
+	var peers []WGPeer
+	err := wgConfigExchange("get=1\n\n", func(r *bufio.Reader) error {
+		var err error
+		peers, err = parseWGPeers(r)
+		return err
+	})
+	return peers, err
+}
+
+// SetWGConfig applies configuration lines to the tunnel's WireGuard device
+func SetWGConfig(config string) error {
+	return wgConfigExchange("set=1\n"+config+"\n", func(r *bufio.Reader) error {
+		_, err := parseWGPeers(r)
+		return err
+	})
+}
+
+// wgConfigExchange sends request to the device and hands its answer to read
+func wgConfigExchange(request string, read func(*bufio.Reader) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), wgConfigTimeout)
+	defer cancel()
+	conn, err := winio.DialPipeContext(ctx, wgConfigPipePath)
+	if err != nil {
+		return fmt.Errorf("failed to open the WireGuard device: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(wgConfigTimeout))
+
+	if _, err := io.WriteString(conn, request); err != nil {
+		return err
+	}
+	return read(bufio.NewReader(conn))
+}
+
+// parseWGPeers reads a configuration protocol answer up to the blank line that
+// ends it, returning the peers it lists and failing if errno isn't 0
+func parseWGPeers(r *bufio.Reader) ([]WGPeer, error) {
+	var peers []WGPeer
+	var peer *WGPeer
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the WireGuard device's answer: %w", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return nil, fmt.Errorf("the WireGuard device's answer has no errno")
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("unexpected line %q from the WireGuard device", line)
+		}
+
+		switch key {
+		case "errno":
+			if errno, err := strconv.Atoi(value); err != nil || errno != 0 {
+				return nil, fmt.Errorf("the WireGuard device failed with errno %s", value)
+			}
+			if peer != nil {
+				peers = append(peers, *peer)
+			}
+			// errno is the last line before the blank one
+			if _, err := r.ReadString('\n'); err != nil {
+				return nil, fmt.Errorf("failed to read the WireGuard device's answer: %w", err)
+			}
+			return peers, nil
+		case "public_key":
+			if peer != nil {
+				peers = append(peers, *peer)
+			}
+			peer = &WGPeer{PublicKey: value}
+		case "preshared_key":
+			if peer != nil {
+				peer.PresharedKey = value
+			}
+		case "endpoint":
+			if peer != nil {
+				peer.Endpoint = value
+			}
+		case "persistent_keepalive_interval":
+			if peer != nil {
+				peer.PersistentKeepalive = value
+			}
+		case "allowed_ip":
+			if peer != nil {
+				prefix, err := netip.ParsePrefix(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid allowed IP %q from the WireGuard device", value)
+				}
+				peer.AllowedIPs = append(peer.AllowedIPs, prefix)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+//go:build windows
+
+package tunnel
+
+import (
+	"bufio"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testKeyA = "a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1"
+	testKeyB = "b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2"
+)
+
+func TestParseWGPeers(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		want    []WGPeer
+		wantErr bool
+	}{
+		{
+			name: "two peers",
+			answer: "private_key=0c0c\nlisten_port=51820\n" +
+				"public_key=" + testKeyA + "\npreshared_key=00\nendpoint=203.0.113.5:51820\n" +
+				"persistent_keepalive_interval=25\nallowed_ip=100.90.128.1/32\nallowed_ip=10.1.0.0/16\n" +
+				"rx_bytes=10\n" +
+				"public_key=" + testKeyB + "\nallowed_ip=100.90.128.2/32\n" +
+				"errno=0\n\n",
+			want: []WGPeer{
+				{
+					PublicKey:           testKeyA,
+					PresharedKey:        "00",
+					Endpoint:            "203.0.113.5:51820",
+					PersistentKeepalive: "25",
+					AllowedIPs:          []netip.Prefix{netip.MustParsePrefix("100.90.128.1/32"), netip.MustParsePrefix("10.1.0.0/16")},
+				},
+				{PublicKey: testKeyB, AllowedIPs: []netip.Prefix{netip.MustParsePrefix("100.90.128.2/32")}},
+			},
+		},
+		{name: "set answer", answer: "errno=0\n\n"},
+		{name: "failed", answer: "errno=1\n\n", wantErr: true},
+		{name: "cut short", answer: "public_key=" + testKeyA + "\n", wantErr: true},
+		{name: "no errno", answer: "public_key=" + testKeyA + "\n\n", wantErr: true},
+		{name: "bad allowed IP", answer: "public_key=" + testKeyA + "\nallowed_ip=nope\nerrno=0\n\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWGPeers(bufio.NewReader(strings.NewReader(tt.answer)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWGPeers() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWGPeers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeerForAddress(t *testing.T) {
+	peers := []WGPeer{
+		{PublicKey: testKeyA, AllowedIPs: []netip.Prefix{netip.MustParsePrefix("100.90.128.0/24")}},
+		{PublicKey: testKeyB, AllowedIPs: []netip.Prefix{netip.MustParsePrefix("100.90.128.2/32")}},
+	}
+
+	tests := []struct {
+		name    string
+		addr    string
+		wantKey string
+		wantOK  bool
+	}{
+		{name: "most specific wins", addr: "100.90.128.2", wantKey: testKeyB, wantOK: true},
+		{name: "wider prefix", addr: "100.90.128.9", wantKey: testKeyA, wantOK: true},
+		{name: "mapped address", addr: "::ffff:100.90.128.2", wantKey: testKeyB, wantOK: true},
+		{name: "no peer", addr: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peer, ok := PeerForAddress(peers, netip.MustParseAddr(tt.addr))
+			if ok != tt.wantOK || peer.PublicKey != tt.wantKey {
+				t.Errorf("PeerForAddress(%s) = %q, %v, want %q, %v", tt.addr, peer.PublicKey, ok, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParsePeerAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+		wantErr bool
+	}{
+		{address: "100.90.128.2", want: "100.90.128.2"},
+		{address: " 100.90.128.2/32 ", want: "100.90.128.2"},
+		{address: "fd00::2/128", want: "fd00::2"},
+		{address: "", wantErr: true},
+		{address: "site", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, err := ParsePeerAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePeerAddress(%q) error = %v, want error %v", tt.address, err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParsePeerAddress(%q) = %s, want %s", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWGPeerConfig(t *testing.T) {
+	peer := WGPeer{
+		PublicKey:           testKeyA,
+		PresharedKey:        "00",
+		Endpoint:            "203.0.113.5:51820",
+		PersistentKeepalive: "25",
+		AllowedIPs:          []netip.Prefix{netip.MustParsePrefix("100.90.128.1/32"), netip.MustParsePrefix("10.1.0.0/16")},
+	}
+
+	wantAdd := "public_key=" + testKeyA + "\npreshared_key=00\nendpoint=203.0.113.5:51820\n" +
+		"persistent_keepalive_interval=25\nreplace_allowed_ips=true\n" +
+		"allowed_ip=100.90.128.1/32\nallowed_ip=10.1.0.0/16\n"
+	if got := peer.AddConfig(); got != wantAdd {
+		t.Errorf("AddConfig() = %q, want %q", got, wantAdd)
+	}
+	if got, want := peer.RemoveConfig(), "public_key="+testKeyA+"\nremove=true\n"; got != want {
+		t.Errorf("RemoveConfig() = %q, want %q", got, want)
+	}
+	if got, want := (WGPeer{PublicKey: testKeyB}).AddConfig(), "public_key="+testKeyB+"\nreplace_allowed_ips=true\n"; got != want {
+		t.Errorf("AddConfig() of a bare peer = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+//go:build windows
+
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/ui/clipboard"
+	"github.com/fosrl/windows/version"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+const (
+	projectURL            = "https://github.com/fosrl/windows"
+	licenseURL            = "https://github.com/fosrl/windows/blob/main/LICENSE"
+	thirdPartyLicensesURL = "https://github.com/fosrl/windows/network/dependencies"
+)
+
+// showAboutDialog shows the version and build details with a button to copy
+// them for bug reports. Must be called on the UI thread.
+func showAboutDialog() {
+	info := version.Info()
+
+	copyButton := walk.TaskDialogCustomButton{MainText: "Copy"}
+	copyButton.Clicked().Attach(func() bool {
+		if err := clipboard.Copy(info); err != nil {
+			clipboard.ShowError(mainWindow, err)
+		}
+		return true // Keep the dialog open
+	})
+
+	td := walk.NewTaskDialog()
+	td.HyperlinkClicked().Attach(func(url string) bool {
+		openURL(url)
+		return true
+	})
+	_, _ = td.Show(walk.TaskDialogOpts{
+		Owner:       mainWindow,
+		Title:       "About " + config.AppName,
+		Instruction: config.AppName,
+		Content:     fmt.Sprintf("%s\n\n© %d Fossorial, Inc.", info, time.Now().Year()),
+		IconSystem:  walk.TaskDialogSystemIconInformation,
+		Footer: fmt.Sprintf(`<a href="%s">Project</a>  ·  <a href="%s">License</a>  ·  <a href="%s">Third-Party Licenses</a>`,
+			projectURL, licenseURL, thirdPartyLicensesURL),
+		AllowHyperlinks: true,
+		CustomButtons:   []walk.TaskDialogCustomButton{copyButton},
+		CommonButtons:   win.TDCBF_OK_BUTTON,
+		DefaultButton:   walk.TaskDialogDefaultButtonOK,
+	})
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package ui
+
+import (
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+)
+
+// handleAdapterRecovery tells the user when the tunnel manager rebuilds a tunnel
+// whose network adapter disappeared. The stop that is part of the rebuild is
+// not announced as a disconnect.
+func handleAdapterRecovery(step tunnel.AdapterRecovery, err error) {
+	if step == tunnel.AdapterLost {
+		markUserDisconnect()
+		return
+	}
+
+	walk.App().Synchronize(func() {
+		if tray.icon == nil {
+			return
+		}
+		var showErr error
+		if step == tunnel.AdapterRecovered {
+			showErr = tray.icon.ShowInfo("Tunnel Rebuilt",
+				"The tunnel's network adapter disappeared, so "+config.AppName+" set the tunnel up again.")
+		} else {
+			showErr = tray.icon.ShowWarning("Could Not Reconnect",
+				"The tunnel's network adapter disappeared and setting the tunnel up again failed: "+err.Error())
+		}
+		if showErr != nil {
+			logger.Error("Failed to show adapter recovery notification: %v", showErr)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+//go:build windows
+
+// Package clipboard copies text to the Windows clipboard for the tray and the
+// preferences window
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+	"golang.org/x/sys/windows"
+)
+
+// retryDelay is how long Copy waits before trying once more to open a
+// clipboard another application has open
+var retryDelay = 100 * time.Millisecond
+
+// The clipboard calls Copy makes; variables so its error handling can be
+// exercised without touching the real clipboard
+var (
+	openClipboard    = win.OpenClipboard
+	closeClipboard   = win.CloseClipboard
+	emptyClipboard   = win.EmptyClipboard
+	setClipboardData = win.SetClipboardData
+	globalFree       = win.GlobalFree
+)
+
+// errBusy is returned when another application keeps the clipboard open
+var errBusy = errors.New("the clipboard is in use by another application")
+
+// Copy copies text to the Windows clipboard
+func Copy(text string) error {
+	text16, err := windows.UTF16FromString(text)
+	if err != nil {
+		return fmt.Errorf("failed to convert text to UTF16: %w", err)
+	}
+
+	if !openClipboard(0) {
+		time.Sleep(retryDelay)
+		if !openClipboard(0) {
+			return errBusy
+		}
+	}
+	defer closeClipboard()
+
+	if !emptyClipboard() {
+		return errors.New("failed to empty the clipboard")
+	}
+
+	hMem := win.GlobalAlloc(win.GMEM_MOVEABLE, uintptr(len(text16)*2))
+	if hMem == 0 {
+		return errors.New("failed to allocate clipboard memory")
+	}
+	pMem := win.GlobalLock(hMem)
+	if pMem == nil {
+		globalFree(hMem)
+		return errors.New("failed to lock clipboard memory")
+	}
+	copy(unsafe.Slice((*uint16)(pMem), len(text16)), text16)
+	win.GlobalUnlock(hMem)
+
+	// The clipboard owns the memory once SetClipboardData succeeds, so it is only freed on failure
+	if setClipboardData(win.CF_UNICODETEXT, win.HANDLE(hMem)) == 0 {
+		globalFree(hMem)
+		return errors.New("failed to set clipboard data")
+	}
+	return nil
+}
+
+// ShowError tells the user a copy failed. Must be called on the UI thread.
+func ShowError(owner walk.Form, err error) {
+	logger.Error("Failed to copy to clipboard: %v", err)
+	td := walk.NewTaskDialog()
+	_, _ = td.Show(walk.TaskDialogOpts{
+		Owner:         owner,
+		Title:         "Copy Failed",
+		Content:       fmt.Sprintf("Couldn't copy to the clipboard: %v. Try again in a moment.", err),
+		IconSystem:    walk.TaskDialogSystemIconWarning,
+		CommonButtons: win.TDCBF_OK_BUTTON,
+	})
+}
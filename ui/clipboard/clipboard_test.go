@@ -0,0 +1,128 @@
+//go:build windows
+
+package clipboard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tailscale/win"
+	"golang.org/x/sys/windows"
+)
+
+// fakeClipboard stands in for the Windows clipboard, failing the calls it is told to
+type fakeClipboard struct {
+	busyOpens  int // How many opens fail before one succeeds
+	emptyFails bool
+	setFails   bool
+
+	opens, closes int
+	data          win.HGLOBAL // Handed over by SetClipboardData
+	freed         []win.HGLOBAL
+}
+
+// use swaps the fake in for the real clipboard for the rest of the test
+func (c *fakeClipboard) use(t *testing.T) {
+	savedOpen, savedClose, savedEmpty := openClipboard, closeClipboard, emptyClipboard
+	savedSet, savedFree, savedDelay := setClipboardData, globalFree, retryDelay
+	t.Cleanup(func() {
+		openClipboard, closeClipboard, emptyClipboard = savedOpen, savedClose, savedEmpty
+		setClipboardData, globalFree, retryDelay = savedSet, savedFree, savedDelay
+		if c.data != 0 {
+			win.GlobalFree(c.data)
+		}
+	})
+
+	retryDelay = 0
+	openClipboard = func(win.HWND) bool {
+		c.opens++
+		return c.opens > c.busyOpens
+	}
+	closeClipboard = func() bool {
+		c.closes++
+		return true
+	}
+	emptyClipboard = func() bool { return !c.emptyFails }
+	setClipboardData = func(format uint32, hMem win.HANDLE) win.HANDLE {
+		if c.setFails || format != win.CF_UNICODETEXT {
+			return 0
+		}
+		c.data = win.HGLOBAL(hMem)
+		return hMem
+	}
+	globalFree = func(hMem win.HGLOBAL) win.HGLOBAL {
+		c.freed = append(c.freed, hMem)
+		return win.GlobalFree(hMem)
+	}
+}
+
+// text returns the text handed to the clipboard
+func (c *fakeClipboard) text() string {
+	p := win.GlobalLock(c.data)
+	defer win.GlobalUnlock(c.data)
+	return windows.UTF16PtrToString((*uint16)(p))
+}
+
+func TestCopy(t *testing.T) {
+	tests := []struct {
+		name      string
+		clipboard fakeClipboard
+		wantErr   bool
+		wantBusy  bool
+		wantOpens int
+	}{
+		{name: "copied", wantOpens: 1},
+		{name: "busy once", clipboard: fakeClipboard{busyOpens: 1}, wantOpens: 2},
+		{name: "busy", clipboard: fakeClipboard{busyOpens: 2}, wantErr: true, wantBusy: true, wantOpens: 2},
+		{name: "can't empty", clipboard: fakeClipboard{emptyFails: true}, wantErr: true, wantOpens: 1},
+		{name: "can't set", clipboard: fakeClipboard{setFails: true}, wantErr: true, wantOpens: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &tt.clipboard
+			c.use(t)
+
+			err := Copy("ABCD-1234 ✓")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Copy() error = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, errBusy) != tt.wantBusy {
+				t.Errorf("Copy() error = %v, want busy %v", err, tt.wantBusy)
+			}
+			if c.opens != tt.wantOpens {
+				t.Errorf("opened %d times, want %d", c.opens, tt.wantOpens)
+			}
+			// Whatever happens, a clipboard we opened is closed again
+			if wantCloses := tt.wantOpens - c.busyOpens; c.closes != wantCloses {
+				t.Errorf("closed %d times, want %d", c.closes, wantCloses)
+			}
+
+			if tt.wantErr {
+				if c.data != 0 {
+					t.Error("the clipboard was given data")
+				}
+				return
+			}
+			// The clipboard owns the memory once it accepts it
+			if len(c.freed) != 0 {
+				t.Errorf("freed %v after the clipboard took it", c.freed)
+			}
+			if got := c.text(); got != "ABCD-1234 ✓" {
+				t.Errorf("clipboard holds %q, want %q", got, "ABCD-1234 ✓")
+			}
+		})
+	}
+}
+
+func TestCopyFreesRejectedMemory(t *testing.T) {
+	c := &fakeClipboard{setFails: true}
+	c.use(t)
+
+	if err := Copy("ABCD-1234"); err == nil {
+		t.Fatal("Copy() succeeded")
+	}
+	if len(c.freed) != 1 || c.freed[0] == 0 {
+		t.Errorf("freed %v, want the memory the clipboard refused", c.freed)
+	}
+}
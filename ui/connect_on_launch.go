@@ -0,0 +1,127 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+
+	"github.com/fosrl/windows/managers"
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+)
+
+// launchConnectDecision is whether the tray starts the tunnel when it launches,
+// or why it doesn't
+type launchConnectDecision int
+
+const (
+	launchConnect launchConnectDecision = iota
+	launchSkipDisabled
+	launchSkipNoCredentials
+	launchSkipPaused
+	launchSkipAlreadyUp
+	launchSkipManagerUnreachable
+)
+
+func (d launchConnectDecision) String() string {
+	switch d {
+	case launchConnect:
+		return "connecting"
+	case launchSkipDisabled:
+		return "connect on launch is off"
+	case launchSkipNoCredentials:
+		return "not logged in"
+	case launchSkipPaused:
+		return "the tunnel is paused"
+	case launchSkipAlreadyUp:
+		return "the tunnel is already up"
+	case launchSkipManagerUnreachable:
+		return "the manager service is not reachable"
+	default:
+		return "unknown"
+	}
+}
+
+// launchConnectInput is what the launch decision depends on
+type launchConnectInput struct {
+	enabled          bool
+	hasCredentials   bool // Logged in, with a live session and tunnel credentials
+	paused           bool
+	state            tunnel.State
+	managerReachable bool
+}
+
+// decideLaunchConnect decides whether to start the tunnel at launch. A pause
+// is the user's choice and wins over the setting, and a tunnel that is already
+// up, e.g. because only the UI restarted, is left alone.
+func decideLaunchConnect(in launchConnectInput) launchConnectDecision {
+	switch {
+	case !in.enabled:
+		return launchSkipDisabled
+	case !in.hasCredentials:
+		return launchSkipNoCredentials
+	case in.paused:
+		return launchSkipPaused
+	case in.state != tunnel.StateStopped:
+		return launchSkipAlreadyUp
+	case !in.managerReachable:
+		return launchSkipManagerUnreachable
+	}
+	return launchConnect
+}
+
+// hasLaunchCredentials reports whether the active account can start the tunnel
+// without the user logging in first
+func hasLaunchCredentials() bool {
+	if authManager == nil || !authManager.IsAuthenticated() || authManager.SessionExpired() {
+		return false
+	}
+	account, err := accountManager.ActiveAccount()
+	if err != nil || account == nil {
+		return false
+	}
+	return secretManager.HasOlmCredentials(account.UserID)
+}
+
+// connectOnLaunch starts the tunnel if the user asked for it to come up with the
+// tray. Must be called after the tunnel state has been synced with the manager.
+func connectOnLaunch() {
+	in := launchConnectInput{enabled: configManager.GetConnectOnLaunch()}
+	if in.enabled {
+		in.hasCredentials = hasLaunchCredentials()
+		in.paused = tunnelManager.PauseStatus().Paused
+		in.state = tunnelManager.State()
+		_, err := managers.IPCClientPing()
+		in.managerReachable = err == nil
+	}
+	decision := decideLaunchConnect(in)
+	if decision == launchSkipDisabled {
+		return
+	}
+	if decision != launchConnect {
+		logger.Info("Not connecting on launch: %s", decision)
+		return
+	}
+
+	logger.Info("Connecting on launch")
+	err := tunnelManager.Connect()
+	if err == nil || errors.Is(err, tunnel.ErrConnectCanceled) {
+		return
+	}
+	logger.Error("Failed to connect on launch: %v", err)
+	message := err.Error()
+	var connErr *tunnel.ConnectionError
+	if errors.As(err, &connErr) {
+		message = connErr.Message
+	}
+	walk.App().Synchronize(func() {
+		if tray.icon == nil {
+			return
+		}
+		if err := tray.icon.ShowWarning("Could Not Connect", message); err != nil {
+			logger.Error("Failed to show connect on launch notification: %v", err)
+		}
+	})
+}
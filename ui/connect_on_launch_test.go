@@ -0,0 +1,43 @@
+//go:build windows
+
+package ui
+
+import (
+	"testing"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+func TestDecideLaunchConnect(t *testing.T) {
+	ready := launchConnectInput{enabled: true, hasCredentials: true, state: tunnel.StateStopped, managerReachable: true}
+
+	tests := []struct {
+		name   string
+		change func(in *launchConnectInput)
+		want   launchConnectDecision
+	}{
+		{name: "ready", want: launchConnect},
+		{name: "off", change: func(in *launchConnectInput) { in.enabled = false }, want: launchSkipDisabled},
+		{name: "off and logged out", change: func(in *launchConnectInput) { in.enabled, in.hasCredentials = false, false }, want: launchSkipDisabled},
+		{name: "not logged in", change: func(in *launchConnectInput) { in.hasCredentials = false }, want: launchSkipNoCredentials},
+		{name: "paused", change: func(in *launchConnectInput) { in.paused = true }, want: launchSkipPaused},
+		{name: "paused and logged out", change: func(in *launchConnectInput) { in.paused, in.hasCredentials = true, false }, want: launchSkipNoCredentials},
+		{name: "already running", change: func(in *launchConnectInput) { in.state = tunnel.StateRunning }, want: launchSkipAlreadyUp},
+		{name: "already connecting", change: func(in *launchConnectInput) { in.state = tunnel.StateRegistering }, want: launchSkipAlreadyUp},
+		{name: "reconnecting", change: func(in *launchConnectInput) { in.state = tunnel.StateReconnecting }, want: launchSkipAlreadyUp},
+		{name: "paused wins over running", change: func(in *launchConnectInput) { in.paused, in.state = true, tunnel.StateRunning }, want: launchSkipPaused},
+		{name: "manager unreachable", change: func(in *launchConnectInput) { in.managerReachable = false }, want: launchSkipManagerUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := ready
+			if tt.change != nil {
+				tt.change(&in)
+			}
+			if got := decideLaunchConnect(in); got != tt.want {
+				t.Errorf("decideLaunchConnect(%+v) = %v, want %v", in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+//go:build windows
+
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/diagnostics"
+	"github.com/fosrl/windows/managers"
+	"github.com/fosrl/windows/tunnel"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+var (
+	diagnosticsRunning bool
+	diagnosticsMutex   sync.Mutex
+)
+
+// diagnosticChecks returns the checks run by the Run Diagnostics action
+func diagnosticChecks() []diagnostics.Check {
+	return []diagnostics.Check{
+		{Name: "Manager service", Run: checkManagerService},
+		{Name: "Credentials", Run: checkCredentials},
+		{Name: "Server hostname", Run: checkHostnameResolves},
+		{Name: "Server API", Run: checkAPIReachable},
+		{Name: "Tunnel adapter", Run: checkTunnelAdapter},
+	}
+}
+
+// checkManagerService pings the manager service over IPC
+func checkManagerService(ctx context.Context) (string, error) {
+	result, err := managers.IPCClientPing()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Responding (uptime %v, tunnel %s)", result.Uptime.Round(time.Second), result.TunnelState.DisplayText()), nil
+}
+
+// checkCredentials verifies the active account's session token and OLM credentials are stored
+func checkCredentials(ctx context.Context) (string, error) {
+	account, err := accountManager.ActiveAccount()
+	if err != nil {
+		return "", errors.New("no account is logged in")
+	}
+
+	var missing []string
+	if token, ok := secretManager.GetSessionToken(account.UserID); !ok || token == "" {
+		missing = append(missing, "session token")
+	}
+	if id, ok := secretManager.GetOlmId(account.UserID); !ok || id == "" {
+		missing = append(missing, "OLM ID")
+	}
+	if secret, ok := secretManager.GetOlmSecret(account.UserID); !ok || secret == "" {
+		missing = append(missing, "OLM secret")
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return "Session token and OLM credentials are stored", nil
+}
+
+// serverHost returns the host name of the active account's server
+func serverHost() (string, error) {
+	account, err := accountManager.ActiveAccount()
+	if err != nil {
+		return "", errors.New("no account is logged in")
+	}
+	hostname := strings.TrimSpace(account.Hostname)
+	if u, err := url.Parse(hostname); err == nil && u.Hostname() != "" {
+		return u.Hostname(), nil
+	}
+	if hostname == "" {
+		return "", errors.New("the account has no server configured")
+	}
+	return hostname, nil
+}
+
+// checkHostnameResolves looks up the server's host name in DNS
+func checkHostnameResolves(ctx context.Context) (string, error) {
+	host, err := serverHost()
+	if err != nil {
+		return "", err
+	}
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	return fmt.Sprintf("%s resolves to %s", host, strings.Join(addrs, ", ")), nil
+}
+
+// checkAPIReachable sends a lightweight request to the server's API
+func checkAPIReachable(ctx context.Context) (string, error) {
+	healthy, err := apiClient.CheckHealthContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !healthy {
+		return "", fmt.Errorf("%s is not responding", apiClient.CurrentBaseURL())
+	}
+	return fmt.Sprintf("%s is responding", apiClient.CurrentBaseURL()), nil
+}
+
+// checkTunnelAdapter verifies the network adapter exists while connected
+func checkTunnelAdapter(ctx context.Context) (string, error) {
+	if tunnelManager.State() != tunnel.StateRunning {
+		return "Not connected", diagnostics.ErrSkipped
+	}
+	present, err := tunnel.AdapterPresent()
+	if err != nil {
+		return "", err
+	}
+	if !present {
+		return "", errors.New("connected, but the network adapter is missing")
+	}
+	return fmt.Sprintf("Network adapter %q is present", tunnel.InterfaceName), nil
+}
+
+// runDiagnostics runs the diagnostic checks in the background and shows the
+// report in a dialog. A second request while a run is in progress is ignored.
+func runDiagnostics() {
+	diagnosticsMutex.Lock()
+	if diagnosticsRunning {
+		diagnosticsMutex.Unlock()
+		return
+	}
+	diagnosticsRunning = true
+	diagnosticsMutex.Unlock()
+
+	go func() {
+		defer func() {
+			diagnosticsMutex.Lock()
+			diagnosticsRunning = false
+			diagnosticsMutex.Unlock()
+		}()
+
+		report := diagnostics.Run(context.Background(), diagnosticChecks(), diagnostics.DefaultCheckTimeout)
+		logger.Info("Diagnostics report:\n%s", report.String())
+
+		walk.App().Synchronize(func() {
+			showDiagnosticsReport(report)
+		})
+	}()
+}
+
+// showDiagnosticsReport shows the results of a diagnostics run
+func showDiagnosticsReport(report diagnostics.Report) {
+	var content strings.Builder
+	for _, result := range report.Results {
+		fmt.Fprintf(&content, "%s: %s\n%s\n\n", result.Name, result.Status, result.Message)
+	}
+
+	instruction := "All checks passed."
+	icon := walk.TaskDialogSystemIconInformation
+	if !report.Passed() {
+		instruction = "Some checks failed."
+		icon = walk.TaskDialogSystemIconWarning
+	}
+
+	td := walk.NewTaskDialog()
+	_, _ = td.Show(walk.TaskDialogOpts{
+		Owner:         mainWindow,
+		Title:         "Diagnostics",
+		Instruction:   instruction,
+		Content:       strings.TrimSpace(content.String()),
+		IconSystem:    icon,
+		CommonButtons: win.TDCBF_OK_BUTTON,
+	})
+}
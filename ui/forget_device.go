@@ -0,0 +1,100 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fosrl/windows/auth"
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/managers"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+var forgetDeviceAction *walk.Action
+
+// updateForgetDeviceAction adds the "Forget This Device" action after Log Out
+// on first use and shows it while there is an active account
+func updateForgetDeviceAction(actions *walk.ActionList, currentAccount *config.Account) {
+	if forgetDeviceAction == nil {
+		forgetDeviceAction = walk.NewAction()
+		forgetDeviceAction.SetText("Forget This Device…")
+		forgetDeviceAction.Triggered().Attach(forgetDevice)
+		actions.Add(forgetDeviceAction)
+	}
+	forgetDeviceAction.SetVisible(currentAccount != nil)
+}
+
+// forgetDevice asks for confirmation, stops the tunnel and revokes this device
+// for the active account. Must be called on the UI thread.
+func forgetDevice() {
+	currentAccount, _ := accountManager.ActiveAccount()
+	if currentAccount == nil || !confirmForgetDevice(currentAccount) {
+		return
+	}
+	go func() {
+		logger.Info("Stopping tunnel before forgetting this device")
+		markUserDisconnect()
+		var err error
+		if tunnelManager != nil {
+			err = tunnelManager.Disconnect()
+		} else {
+			err = managers.IPCClientStopTunnel()
+		}
+		if err != nil {
+			logger.Error("Failed to stop tunnel before forgetting this device: %v", err)
+		}
+
+		err = authManager.ForgetDevice()
+		updateMenu()
+		if err == nil {
+			return
+		}
+		logger.Error("Failed to forget this device: %v", err)
+		walk.App().Synchronize(func() {
+			opts := walk.TaskDialogOpts{
+				Owner:         mainWindow,
+				Title:         "Forget This Device",
+				CommonButtons: win.TDCBF_OK_BUTTON,
+			}
+			if errors.Is(err, auth.ErrDeviceNotRevoked) {
+				opts.Instruction = "This device was removed from " + config.AppName + " but not revoked on the server."
+				opts.Content = fmt.Sprintf("%v\n\nRemove the device from %s in the Pangolin dashboard to revoke it.",
+					err, currentAccount.Hostname)
+				opts.IconSystem = walk.TaskDialogSystemIconWarning
+			} else {
+				opts.Content = fmt.Sprintf("Failed to forget this device: %v", err)
+				opts.IconSystem = walk.TaskDialogSystemIconError
+			}
+			td := walk.NewTaskDialog()
+			_, _ = td.Show(opts)
+		})
+	}()
+}
+
+// confirmForgetDevice asks the user to confirm revoking this device for account.
+// Must be called on the UI thread.
+func confirmForgetDevice(account *config.Account) bool {
+	confirmed := false
+	td := walk.NewTaskDialog()
+	opts := walk.TaskDialogOpts{
+		Owner:       mainWindow,
+		Title:       "Forget This Device",
+		Instruction: fmt.Sprintf("Forget this device for %s?", auth.AccountDisplayName(account)),
+		Content: "The device is revoked on the server, its stored credentials are deleted and the account " +
+			"is logged out. Any active connection will be disconnected. Logging in again registers the device anew.",
+		IconSystem:    walk.TaskDialogSystemIconWarning,
+		CommonButtons: win.TDCBF_YES_BUTTON | win.TDCBF_NO_BUTTON,
+		DefaultButton: walk.TaskDialogDefaultButtonNo,
+	}
+	opts.CommonButtonClicked(win.TDCBF_YES_BUTTON).Attach(func() bool {
+		confirmed = true
+		return false // Return false to allow dialog to close normally
+	})
+	_, _ = td.Show(opts)
+	return confirmed
+}
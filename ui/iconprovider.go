@@ -3,10 +3,9 @@
 package ui
 
 import (
-	"path/filepath"
-
 	"github.com/fosrl/newt/logger"
 	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/icons"
 	"github.com/fosrl/windows/tunnel"
 	"github.com/tailscale/walk"
 )
@@ -42,7 +41,7 @@ func iconWithOverlayForState(state tunnel.State, size int) (icon walk.Image, err
 		iconName = "icon-gray.ico"
 	}
 
-	iconPath := filepath.Join(config.GetIconsPath(), iconName)
+	iconPath := icons.Path(config.GetIconsPath(), iconName)
 	baseIcon, err = walk.NewIconFromFile(iconPath)
 	if err != nil {
 		logger.Error("Failed to load base icon from %s: %v", iconPath, err)
@@ -145,3 +144,46 @@ func loadSystemIcon(dll string, index int32, size int) (icon *walk.Icon, err err
 	}
 	return
 }
+
+var cachedPausedIconsForWidth = make(map[int]walk.Image)
+
+// pausedIcon returns the gray icon with a pause sign in the corner, used while
+// the tunnel is paused
+func pausedIcon(size int) (icon walk.Image, err error) {
+	// Check cache first
+	icon = cachedPausedIconsForWidth[size]
+	if icon != nil {
+		return
+	}
+
+	iconPath := icons.Path(config.GetIconsPath(), "icon-gray.ico")
+	baseIcon, err := walk.NewIconFromFile(iconPath)
+	if err != nil {
+		return nil, err
+	}
+	brush, err := walk.NewSolidColorBrush(walk.RGB(230, 160, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	icon = walk.NewPaintFuncImage(walk.Size{Width: size, Height: size}, func(canvas *walk.Canvas, bounds walk.Rectangle) error {
+		if err := canvas.DrawImageStretched(baseIcon, bounds); err != nil {
+			return err
+		}
+		// Two bars in the bottom-right corner
+		barWidth := bounds.Width / 6
+		barHeight := bounds.Height / 2
+		if barWidth < 2 {
+			barWidth = 2
+		}
+		top := bounds.Y + bounds.Height - barHeight
+		right := bounds.X + bounds.Width
+		if err := canvas.FillRectangle(brush, walk.Rectangle{X: right - 3*barWidth, Y: top, Width: barWidth, Height: barHeight}); err != nil {
+			return err
+		}
+		return canvas.FillRectangle(brush, walk.Rectangle{X: right - barWidth, Y: top, Width: barWidth, Height: barHeight})
+	})
+
+	cachedPausedIconsForWidth[size] = icon
+	return
+}
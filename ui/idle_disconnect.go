@@ -0,0 +1,79 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+)
+
+// idleStatusText is the tray status after the tunnel was disconnected for being idle
+const idleStatusText = "Disconnected (idle)"
+
+var (
+	idleDisconnectMutex sync.Mutex
+	// idleDisconnected is set from an idle disconnect until the tunnel next starts
+	idleDisconnected bool
+)
+
+// handleIdleDisconnect is called just before the tunnel manager disconnects a
+// tunnel that had no traffic for timeout. The user is told why, and clicking the
+// notification connects again.
+func handleIdleDisconnect(timeout time.Duration) {
+	// The disconnect was on purpose, so the generic "Disconnected" balloon is not shown
+	markUserDisconnect()
+	idleDisconnectMutex.Lock()
+	idleDisconnected = true
+	idleDisconnectMutex.Unlock()
+
+	message := fmt.Sprintf("No traffic went through the tunnel for %s. Click here or choose Connect to reconnect.",
+		strings.ToLower(formatPauseDuration(timeout)))
+	walk.App().Synchronize(func() {
+		if tray.icon == nil {
+			return
+		}
+		if err := tray.icon.ShowInfo("Disconnected While Idle", message); err != nil {
+			logger.Error("Failed to show idle disconnect notification: %v", err)
+		}
+	})
+}
+
+// isIdleDisconnected reports whether the tunnel is down because it was idle
+func isIdleDisconnected() bool {
+	idleDisconnectMutex.Lock()
+	defer idleDisconnectMutex.Unlock()
+	return idleDisconnected
+}
+
+// clearIdleDisconnect forgets an idle disconnect once the tunnel leaves Stopped
+func clearIdleDisconnect(state tunnel.State) {
+	if state == tunnel.StateStopped {
+		return
+	}
+	idleDisconnectMutex.Lock()
+	idleDisconnected = false
+	idleDisconnectMutex.Unlock()
+}
+
+// handleNotificationClicked reconnects when the user clicks a notification while
+// the tunnel is down for being idle. No other notification has a click action.
+func handleNotificationClicked() {
+	if tunnelManager == nil || !isIdleDisconnected() || tunnelManager.State() != tunnel.StateStopped {
+		return
+	}
+	go func() {
+		err := tunnelManager.Connect()
+		if err != nil && !errors.Is(err, tunnel.ErrConnectCanceled) {
+			logger.Error("Failed to reconnect after idle disconnect: %v", err)
+			showConnectionErrorDialog("Connection Failed", err)
+		}
+	}()
+}
@@ -17,11 +17,15 @@ import (
 	"github.com/fosrl/windows/api"
 	"github.com/fosrl/windows/auth"
 	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/icons"
 	"github.com/fosrl/windows/managers"
 	"github.com/fosrl/windows/tunnel"
+	"github.com/fosrl/windows/ui/clipboard"
+	"github.com/fosrl/windows/ui/theme"
 
 	"github.com/fosrl/newt/logger"
 	browser "github.com/pkg/browser"
+	qrcode "github.com/skip2/go-qrcode"
 	"github.com/tailscale/walk"
 	. "github.com/tailscale/walk/declarative"
 	"github.com/tailscale/win"
@@ -42,9 +46,49 @@ const (
 	stateHostingSelection loginState = iota
 	stateReadyToLogin
 	stateDeviceAuthCode
+	statePasswordLogin
 	stateSuccess
 )
 
+// loginForm is what the login dialog's Login button depends on
+type loginForm struct {
+	state              loginState
+	loggingIn          bool
+	hosting            hostingOption
+	selfHostedURL      string
+	email              string
+	password           string
+	twoFactor          string
+	twoFactorRequested bool // The server asked for a 2FA code on the last password attempt
+}
+
+// ready reports whether the form holds everything a login needs
+func (f loginForm) ready() bool {
+	if f.state == statePasswordLogin {
+		if strings.TrimSpace(f.email) == "" || f.password == "" {
+			return false
+		}
+		return !f.twoFactorRequested || strings.TrimSpace(f.twoFactor) != ""
+	}
+	switch f.hosting {
+	case hostingCloud:
+		return true
+	case hostingSelfHosted:
+		return strings.TrimSpace(f.selfHostedURL) != ""
+	default:
+		return false
+	}
+}
+
+// canSubmit reports whether Login may run: on a step that logs in, not while
+// a login is running, and with the form ready
+func (f loginForm) canSubmit() bool {
+	if f.loggingIn || (f.state != stateReadyToLogin && f.state != statePasswordLogin) {
+		return false
+	}
+	return f.ready()
+}
+
 var (
 	openLoginDialog      *walk.Dialog
 	openLoginDialogMutex sync.Mutex
@@ -86,30 +130,6 @@ func getIconsPath() string {
 	return config.GetIconsPath()
 }
 
-// isDarkMode detects if Windows is in dark mode
-func isDarkMode() bool {
-	var key windows.Handle
-	keyPath := windows.StringToUTF16Ptr(`Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`)
-	err := windows.RegOpenKeyEx(windows.HKEY_CURRENT_USER, keyPath, 0, windows.KEY_READ, &key)
-	if err != nil {
-		// Default to light mode if we can't detect
-		return false
-	}
-	defer windows.RegCloseKey(key)
-
-	var value uint32
-	var valueLen uint32 = 4
-	valueName := windows.StringToUTF16Ptr("AppsUseLightTheme")
-	err = windows.RegQueryValueEx(key, valueName, nil, nil, (*byte)(unsafe.Pointer(&value)), &valueLen)
-	if err != nil {
-		// Default to light mode if we can't read the value
-		return false
-	}
-
-	// AppsUseLightTheme: 0 = dark mode, 1 = light mode
-	return value == 0
-}
-
 // ShowLoginDialog shows the login dialog with full authentication flow
 func ShowLoginDialog(
 	parent walk.Form,
@@ -154,22 +174,38 @@ func ShowLoginDialog(
 	hasAutoOpenedBrowser := false
 	loginSucceeded := false
 	includeUsernameInDeviceURL := false // true only when entering from re-auth (start device auth immediately)
+	usePasswordLogin := false           // sign in with email and password instead of device auth
+	twoFactorRequested := false         // the server asked for a 2FA code on the last password attempt
+	codeExpired := false                // the device auth code expired before it was used
 	// Initialize temporary hostname from config (will be used for login flow, only persisted after successful login)
-	temporaryHostname := config.DefaultHostname
+	temporaryHostname := config.ResolveHostname("")
 	if activeAccount != nil {
-		temporaryHostname = activeAccount.Hostname
+		temporaryHostname = config.ResolveHostname(activeAccount.Hostname)
 	}
 
-	// Context for canceling polling goroutine and login operation
+	// Contexts for canceling the dialog's goroutines and the login operation
 	pollCtx, cancelPoll := context.WithCancel(context.Background())
 	loginCtx, cancelLogin := context.WithCancel(context.Background())
+	// cancelDeviceAuth cancels the current device auth attempt (e.g. on Back); only used on the UI thread
+	cancelDeviceAuth := context.CancelFunc(func() {})
 
 	// UI components
 	var cloudButton, selfHostedButton *walk.PushButton
 	var urlLabel, hintLabel *walk.Label
-	var urlLineEdit *walk.LineEdit
-	var codeLabel *walk.Label
-	var copyButton, openBrowserButton *walk.PushButton
+	var urlComboBox *walk.ComboBox
+	recentHostnames := configManager.GetRecentHostnames()
+	var urlRowComposite *walk.Composite
+	var testServerButton *walk.PushButton
+	var testResultLabel *walk.Label
+	testingServer := false
+	// cancelServerTest cancels the running server test, if any; only used on the UI thread
+	cancelServerTest := context.CancelFunc(func() {})
+	var codeLabel, countdownLabel *walk.Label
+	var codeComposite *walk.Composite
+	var qrImageView *walk.ImageView
+	var qrBitmap *walk.Bitmap
+	qrCodeURL := "" // URL shown in qrBitmap
+	var copyButton, openBrowserButton, retryButton *walk.PushButton
 	var manualURLLabel *walk.Label
 	var manualURLComposite *walk.Composite
 	var progressBar *walk.ProgressBar
@@ -178,23 +214,33 @@ func ShowLoginDialog(
 	var termsLabel, andLabel *walk.Label
 	var termsLinkLabel, privacyLinkLabel *walk.LinkLabel
 	var termsComposite *walk.Composite
+	var passwordModeLink *walk.LinkLabel
+	var emailLineEdit, passwordLineEdit, twoFactorLineEdit *walk.LineEdit
+	var passwordHintLabel *walk.Label
 
-	isReadyToLogin := func() bool {
-		switch hostingOpt {
-		case hostingCloud:
-			return true
-		case hostingSelfHosted:
-			return strings.TrimSpace(selfHostedURL) != ""
-		default:
-			return false
+	// canSubmitLogin reports whether Login may run now. Enter presses the Login
+	// button even where it is hidden or disabled, so its handler checks this too.
+	canSubmitLogin := func() bool {
+		form := loginForm{
+			state:              currentState,
+			loggingIn:          isLoggingIn,
+			hosting:            hostingOpt,
+			selfHostedURL:      selfHostedURL,
+			twoFactorRequested: twoFactorRequested,
+		}
+		if emailLineEdit != nil && passwordLineEdit != nil && twoFactorLineEdit != nil {
+			form.email = emailLineEdit.Text()
+			form.password = passwordLineEdit.Text()
+			form.twoFactor = twoFactorLineEdit.Text()
 		}
+		return form.canSubmit()
 	}
 
 	updateButtons := func() {
 		walk.App().Synchronize(func() {
 			showBack := currentState != stateHostingSelection
 			showCancel := true
-			showLogin := currentState == stateReadyToLogin
+			showLogin := currentState == stateReadyToLogin || currentState == statePasswordLogin
 
 			if backButton != nil {
 				backButton.SetVisible(showBack)
@@ -206,17 +252,81 @@ func ShowLoginDialog(
 			}
 			if loginButton != nil {
 				loginButton.SetVisible(showLogin)
-				loginButton.SetEnabled(!isLoggingIn && isReadyToLogin())
+				loginButton.SetEnabled(canSubmitLogin())
 			}
 		})
 	}
 
+	// showTestResult shows the outcome of a server test below the URL, or hides it if text is empty
+	showTestResult := func(text string, color walk.Color) {
+		if testResultLabel == nil {
+			return
+		}
+		testResultLabel.SetText(text)
+		testResultLabel.SetTextColor(color)
+		testResultLabel.SetVisible(text != "" && currentState == stateReadyToLogin)
+	}
+
+	// stopServerTest cancels a running server test and clears its result, which no
+	// longer applies once the URL changes or the user moves on
+	stopServerTest := func() {
+		cancelServerTest()
+		testingServer = false
+		showTestResult("", theme.ThemeColors().Muted)
+	}
+
+	// setSelfHostedURL takes a typed or picked server URL, normalized by trimming
+	// spaces and trailing slashes and adding https:// if no protocol is given
+	setSelfHostedURL := func(text string) {
+		selfHostedURL = text
+		temporaryHostname = normalizeURL(selfHostedURL)
+		stopServerTest()
+		if testServerButton != nil {
+			testServerButton.SetEnabled(temporaryHostname != "")
+		}
+		updateButtons()
+	}
+
+	// startServerTest checks in the background that the URL points at a Pangolin
+	// server. Changing the URL, going back, logging in or closing the dialog cancels it.
+	startServerTest := func() {
+		hostname := normalizeURL(selfHostedURL)
+		if testingServer || hostname == "" {
+			return
+		}
+		ctx, cancel := context.WithCancel(pollCtx)
+		cancelServerTest = cancel
+		testingServer = true
+		testServerButton.SetEnabled(false)
+		showTestResult("Testing...", theme.ThemeColors().Muted)
+
+		go func() {
+			probe, err := api.ProbeServer(ctx, hostname)
+			walk.App().Synchronize(func() {
+				if ctx.Err() != nil || err != nil {
+					// Canceled; whatever canceled it has reset the test
+					return
+				}
+				cancel()
+				testingServer = false
+				testServerButton.SetEnabled(normalizeURL(selfHostedURL) != "")
+				colors := theme.ThemeColors()
+				if probe.Reachable {
+					showTestResult(probe.Message, colors.Success)
+				} else {
+					showTestResult(probe.Message, colors.Error)
+				}
+			})
+		}()
+	}
+
 	updateUI := func() {
 		walk.App().Synchronize(func() {
 			// Show/hide widgets based on state
 			showHostingSelection := currentState == stateHostingSelection
 			showReadyToLogin := currentState == stateReadyToLogin
 			showDeviceAuthCode := currentState == stateDeviceAuthCode
+			showPasswordLogin := currentState == statePasswordLogin
 
 			if cloudButton != nil {
 				cloudButton.SetVisible(showHostingSelection)
@@ -224,12 +334,47 @@ func ShowLoginDialog(
 			if selfHostedButton != nil {
 				selfHostedButton.SetVisible(showHostingSelection)
 			}
+			if passwordModeLink != nil {
+				if usePasswordLogin {
+					passwordModeLink.SetText(`<a>Sign in with your browser instead</a>`)
+				} else {
+					passwordModeLink.SetText(`<a>Sign in with email and password</a>`)
+				}
+				passwordModeLink.SetVisible(showHostingSelection)
+			}
+
+			if emailLineEdit != nil {
+				emailLineEdit.SetVisible(showPasswordLogin)
+				emailLineEdit.SetEnabled(!isLoggingIn && !twoFactorRequested)
+			}
+			if passwordLineEdit != nil {
+				passwordLineEdit.SetVisible(showPasswordLogin)
+				passwordLineEdit.SetEnabled(!isLoggingIn && !twoFactorRequested)
+			}
+			if twoFactorLineEdit != nil {
+				twoFactorLineEdit.SetVisible(showPasswordLogin && twoFactorRequested)
+				twoFactorLineEdit.SetEnabled(!isLoggingIn)
+			}
+			if passwordHintLabel != nil {
+				if twoFactorRequested {
+					passwordHintLabel.SetText("Enter the code from your authenticator app.")
+				} else {
+					passwordHintLabel.SetText("Sign in to " + temporaryHostname)
+				}
+				passwordHintLabel.SetVisible(showPasswordLogin)
+			}
 
 			if urlLabel != nil {
 				urlLabel.SetVisible(showReadyToLogin)
 			}
-			if urlLineEdit != nil {
-				urlLineEdit.SetVisible(showReadyToLogin)
+			if urlRowComposite != nil {
+				urlRowComposite.SetVisible(showReadyToLogin)
+			}
+			if testServerButton != nil {
+				testServerButton.SetEnabled(!testingServer && normalizeURL(selfHostedURL) != "")
+			}
+			if testResultLabel != nil {
+				testResultLabel.SetVisible(showReadyToLogin && testResultLabel.Text() != "")
 			}
 			if hintLabel != nil {
 				hintLabel.SetVisible(showReadyToLogin)
@@ -237,12 +382,28 @@ func ShowLoginDialog(
 
 			if codeLabel != nil {
 				codeLabel.SetVisible(showDeviceAuthCode)
+				codeLabel.SetEnabled(!codeExpired)
+			}
+			if codeComposite != nil {
+				codeComposite.SetVisible(showDeviceAuthCode)
+			}
+			if qrImageView != nil {
+				qrImageView.SetVisible(showDeviceAuthCode && !codeExpired)
+			}
+			if countdownLabel != nil {
+				if codeExpired {
+					countdownLabel.SetText("This code has expired.")
+				}
+				countdownLabel.SetVisible(showDeviceAuthCode)
 			}
 			if copyButton != nil {
-				copyButton.SetVisible(showDeviceAuthCode)
+				copyButton.SetVisible(showDeviceAuthCode && !codeExpired)
 			}
 			if openBrowserButton != nil {
-				openBrowserButton.SetVisible(showDeviceAuthCode)
+				openBrowserButton.SetVisible(showDeviceAuthCode && !codeExpired)
+			}
+			if retryButton != nil {
+				retryButton.SetVisible(showDeviceAuthCode && codeExpired)
 			}
 			if manualURLComposite != nil {
 				manualURLComposite.SetVisible(showDeviceAuthCode)
@@ -251,7 +412,7 @@ func ShowLoginDialog(
 				manualURLLabel.SetVisible(showDeviceAuthCode)
 			}
 			if progressBar != nil {
-				progressBar.SetVisible(showDeviceAuthCode)
+				progressBar.SetVisible(showDeviceAuthCode && !codeExpired)
 			}
 
 			// Show terms notice only on hosting selection page
@@ -266,6 +427,10 @@ func ShowLoginDialog(
 
 	updateCodeDisplay := func() {
 		walk.App().Synchronize(func() {
+			if pollCtx.Err() != nil {
+				// Dialog closed meanwhile
+				return
+			}
 			code := authManager.DeviceAuthCode()
 			if code != nil && codeLabel != nil {
 				// Display code with spaces between characters (PIN style)
@@ -273,22 +438,49 @@ func ShowLoginDialog(
 				displayCode := strings.Join(strings.Split(codeStr, ""), " ")
 				codeLabel.SetText(displayCode)
 
-				// Auto-open browser when code is generated
-				if !hasAutoOpenedBrowser {
-					hasAutoOpenedBrowser = true
-					// Use temporary hostname if set, otherwise fall back to saved hostname
-					if temporaryHostname != "" {
-						// Remove middle hyphen from code (e.g., "XXXX-XXXX" -> "XXXXXXXX")
-						codeWithoutHyphen := strings.ReplaceAll(codeStr, "-", "")
-						autoOpenURL := fmt.Sprintf("%s/auth/login/device?code=%s", temporaryHostname, codeWithoutHyphen)
-						if includeUsernameInDeviceURL {
-							if u := authManager.CurrentUser(); u != nil && u.Email != "" {
-								autoOpenURL += "&user=" + url.QueryEscape(u.Email)
-							} else if activeAccount != nil && activeAccount.Email != "" {
-								autoOpenURL += "&user=" + url.QueryEscape(activeAccount.Email)
+				// Use temporary hostname if set, otherwise fall back to saved hostname
+				if temporaryHostname != "" {
+					var userEmail string
+					if includeUsernameInDeviceURL {
+						if u := authManager.CurrentUser(); u != nil && u.Email != "" {
+							userEmail = u.Email
+						} else if activeAccount != nil && activeAccount.Email != "" {
+							userEmail = activeAccount.Email
+						}
+					}
+					loginURL := deviceAuthURL(temporaryHostname, codeStr, userEmail)
+
+					// Show the same URL as a QR code for signing in from a phone
+					if loginURL != qrCodeURL && qrImageView != nil {
+						if bitmap, err := newQRCodeBitmap(loginURL, qrCodeSize); err != nil {
+							logger.Error("Failed to create QR code: %v", err)
+						} else {
+							qrImageView.SetImage(bitmap)
+							if qrBitmap != nil {
+								qrBitmap.Dispose()
 							}
+							qrBitmap = bitmap
+							qrCodeURL = loginURL
 						}
-						openBrowser(autoOpenURL)
+					}
+
+					// Auto-open browser when code is generated
+					if !hasAutoOpenedBrowser {
+						hasAutoOpenedBrowser = true
+						openBrowser(loginURL)
+					}
+				}
+			}
+			// Update the countdown until the code expires
+			if code != nil && !codeExpired {
+				expiresAt, lifetime := authManager.DeviceAuthExpiry()
+				if !expiresAt.IsZero() {
+					remaining := auth.DeviceAuthRemaining(expiresAt, time.Now())
+					if countdownLabel != nil {
+						countdownLabel.SetText("Code expires in " + formatCountdown(remaining))
+					}
+					if progressBar != nil && lifetime > 0 {
+						progressBar.SetValue(int(remaining * 1000 / lifetime))
 					}
 				}
 			}
@@ -300,7 +492,7 @@ func ShowLoginDialog(
 		})
 	}
 
-	performLogin := func() {
+	performLogin := func(ctx context.Context) {
 		// Ensure server URL is configured (but don't persist yet)
 		if hostingOpt == hostingSelfHosted {
 			url := normalizeURL(selfHostedURL)
@@ -322,11 +514,11 @@ func ShowLoginDialog(
 			}
 			temporaryHostname = url
 		} else if hostingOpt == hostingCloud {
-			temporaryHostname = "https://app.pangolin.net"
+			temporaryHostname = config.ResolveHostname("")
 		}
 
 		// Pass temporary hostname to login (it will use a temporary API client internally)
-		err := authManager.LoginWithDeviceAuth(loginCtx, &temporaryHostname)
+		err := authManager.LoginWithDeviceAuth(ctx, &temporaryHostname)
 		if err != nil {
 			// Don't show error dialog if context was canceled (user closed dialog)
 			if errors.Is(err, context.Canceled) {
@@ -336,6 +528,16 @@ func ShowLoginDialog(
 				})
 				return
 			}
+			// An expired code stays on screen with a button to get a new one
+			var authErr *auth.AuthError
+			if errors.As(err, &authErr) && authErr.Type == auth.AuthErrorDeviceCodeExpired {
+				walk.App().Synchronize(func() {
+					isLoggingIn = false
+					codeExpired = true
+					updateUI()
+				})
+				return
+			}
 			walk.App().Synchronize(func() {
 				isLoggingIn = false
 				errorMsg := err.Error()
@@ -369,6 +571,7 @@ func ShowLoginDialog(
 
 		// Success - always stop any running tunnel after login, then close
 		logger.Info("Stopping tunnel after successful login")
+		markUserDisconnect()
 		if err := managers.IPCClientStopTunnel(); err != nil {
 			logger.Error("Failed to stop tunnel after login: %v", err)
 			// Still close the dialog even if stopping tunnel fails
@@ -381,12 +584,121 @@ func ShowLoginDialog(
 		})
 	}
 
+	// startDeviceAuth starts a device auth attempt that ends with the dialog or
+	// when the user goes back. Must be called on the UI thread.
+	startDeviceAuth := func() {
+		cancelDeviceAuth()
+		var ctx context.Context
+		ctx, cancelDeviceAuth = context.WithCancel(loginCtx)
+		go performLogin(ctx)
+	}
+
+	// resetPasswordLogin clears the password form, e.g. when leaving it
+	resetPasswordLogin := func() {
+		twoFactorRequested = false
+		for _, edit := range []*walk.LineEdit{passwordLineEdit, twoFactorLineEdit} {
+			if edit != nil {
+				edit.SetText("")
+			}
+		}
+	}
+
+	performPasswordLogin := func(email, password string, code *string) {
+		err := authManager.LoginWithPassword(loginCtx, &temporaryHostname, email, password, code)
+		if err != nil {
+			if loginCtx.Err() != nil {
+				// The dialog was closed; nothing to report
+				return
+			}
+			var authErr *auth.AuthError
+			walk.App().Synchronize(func() {
+				isLoggingIn = false
+				switch {
+				case errors.As(err, &authErr) && authErr.Type == auth.AuthErrorTwoFactorRequired:
+					if twoFactorRequested {
+						// A code was sent and not accepted
+						td := walk.NewTaskDialog()
+						td.Show(walk.TaskDialogOpts{
+							Owner:         dlg,
+							Title:         "Login Error",
+							Content:       "The two-factor code is incorrect. Please try again.",
+							IconSystem:    walk.TaskDialogSystemIconError,
+							CommonButtons: win.TDCBF_OK_BUTTON,
+						})
+						if twoFactorLineEdit != nil {
+							twoFactorLineEdit.SetText("")
+						}
+					}
+					twoFactorRequested = true
+					updateUI()
+					if twoFactorLineEdit != nil {
+						twoFactorLineEdit.SetFocus()
+					}
+				case errors.As(err, &authErr) && authErr.Type == auth.AuthErrorEmailVerificationRequired:
+					td := walk.NewTaskDialog()
+					td.Show(walk.TaskDialogOpts{
+						Owner:         dlg,
+						Title:         "Email Verification Required",
+						Content:       "Your email address has not been verified yet. Follow the link in the verification email we sent you, then sign in again.",
+						IconSystem:    walk.TaskDialogSystemIconInformation,
+						CommonButtons: win.TDCBF_OK_BUTTON,
+					})
+					resetPasswordLogin()
+					updateUI()
+				default:
+					td := walk.NewTaskDialog()
+					td.Show(walk.TaskDialogOpts{
+						Owner:         dlg,
+						Title:         "Login Error",
+						Content:       err.Error(),
+						IconSystem:    walk.TaskDialogSystemIconError,
+						CommonButtons: win.TDCBF_OK_BUTTON,
+					})
+					resetPasswordLogin()
+					updateUI()
+				}
+			})
+			return
+		}
+
+		// Success - always stop any running tunnel after login, then close
+		logger.Info("Stopping tunnel after successful login")
+		markUserDisconnect()
+		if err := managers.IPCClientStopTunnel(); err != nil {
+			logger.Error("Failed to stop tunnel after login: %v", err)
+			// Still close the dialog even if stopping tunnel fails
+		}
+
+		walk.App().Synchronize(func() {
+			isLoggingIn = false
+			loginSucceeded = true
+			dlg.Accept()
+		})
+	}
+
+	// startPasswordLogin reads the password form and submits it
+	startPasswordLogin := func() {
+		email := strings.TrimSpace(emailLineEdit.Text())
+		password := passwordLineEdit.Text()
+		var code *string
+		if twoFactorRequested {
+			c := strings.TrimSpace(twoFactorLineEdit.Text())
+			code = &c
+		}
+		isLoggingIn = true
+		updateUI()
+		go performPasswordLogin(email, password, code)
+	}
+
 	Dialog{
 		AssignTo: &dlg,
-		Title:    "Login to Pangolin",
-		MinSize:  Size{Width: 450, Height: 330},
-		MaxSize:  Size{Width: 450, Height: 330},
-		Layout:   VBox{Margins: Margins{Left: 20, Top: 10, Right: 20, Bottom: 10}, Spacing: 5},
+		// Enter logs in when ready and Escape cancels, like other Windows dialogs
+		DefaultButton: &loginButton,
+		CancelButton:  &cancelButton,
+		Title:         "Login to Pangolin",
+		MinSize:       Size{Width: 450, Height: 330},
+		MaxSize:       Size{Width: 450, Height: 330},
+		Layout:        VBox{Margins: Margins{Left: 20, Top: 10, Right: 20, Bottom: 10}, Spacing: 5},
 		Children: []Widget{
 			// Logo container at top
 			Composite{
@@ -408,13 +720,19 @@ func ShowLoginDialog(
 						OnClicked: func() {
 							hostingOpt = hostingCloud
 							// Set temporary hostname for login flow (not persisted until successful login)
-							temporaryHostname = "https://app.pangolin.net"
+							temporaryHostname = config.ResolveHostname("")
+
+							if usePasswordLogin {
+								currentState = statePasswordLogin
+								updateUI()
+								return
+							}
 
 							// Immediately start device auth flow for cloud
 							currentState = stateDeviceAuthCode
 							isLoggingIn = true
 							updateUI()
-							go performLogin()
+							startDeviceAuth()
 						},
 					},
 					PushButton{
@@ -427,6 +745,43 @@ func ShowLoginDialog(
 							updateUI()
 						},
 					},
+					LinkLabel{
+						AssignTo:  &passwordModeLink,
+						Text:      `<a>Sign in with email and password</a>`,
+						Alignment: AlignHCenterVNear,
+						OnLinkActivated: func(link *walk.LinkLabelLink) {
+							usePasswordLogin = !usePasswordLogin
+							updateUI()
+						},
+					},
+					// Email and password sign-in
+					Label{
+						AssignTo:  &passwordHintLabel,
+						Alignment: AlignHCenterVNear,
+						Visible:   false,
+					},
+					LineEdit{
+						AssignTo:      &emailLineEdit,
+						CueBanner:     "Email",
+						MinSize:       Size{Width: 300, Height: 0},
+						Visible:       false,
+						OnTextChanged: updateButtons,
+					},
+					LineEdit{
+						AssignTo:      &passwordLineEdit,
+						CueBanner:     "Password",
+						PasswordMode:  true,
+						MinSize:       Size{Width: 300, Height: 0},
+						Visible:       false,
+						OnTextChanged: updateButtons,
+					},
+					LineEdit{
+						AssignTo:      &twoFactorLineEdit,
+						CueBanner:     "Two-factor code",
+						MinSize:       Size{Width: 300, Height: 0},
+						Visible:       false,
+						OnTextChanged: updateButtons,
+					},
 					// Self-hosted URL input
 					Label{
 						AssignTo:  &urlLabel,
@@ -434,35 +789,84 @@ func ShowLoginDialog(
 						Alignment: AlignHCenterVNear,
 						Visible:   false,
 					},
-					LineEdit{
-						AssignTo:  &urlLineEdit,
-						Text:      selfHostedURL,
-						CueBanner: "https://your-server.com",
-						MinSize:   Size{Width: 300, Height: 0},
-						Visible:   false,
-						OnTextChanged: func() {
-							if urlLineEdit != nil {
-								selfHostedURL = urlLineEdit.Text()
-								// Normalize the URL: trim spaces, remove trailing slashes, and add https:// if no protocol
-								cleanedURL := normalizeURL(selfHostedURL)
-
-								if cleanedURL != "" {
-									temporaryHostname = cleanedURL
-								} else {
-									temporaryHostname = ""
-								}
-								updateButtons()
-							}
+					// Server URL and a button to check it before logging in
+					Composite{
+						AssignTo: &urlRowComposite,
+						Layout:   HBox{MarginsZero: true, Spacing: 6},
+						MaxSize:  Size{Width: 300, Height: 0},
+						Visible:  false,
+						Children: []Widget{
+							// Editable, with the servers logged in to recently in its dropdown
+							ComboBox{
+								AssignTo: &urlComboBox,
+								Editable: true,
+								Model:    recentHostnames,
+								MinSize:  Size{Width: 234, Height: 0},
+								OnTextChanged: func() {
+									if urlComboBox != nil {
+										setSelfHostedURL(urlComboBox.Text())
+									}
+								},
+								OnCurrentIndexChanged: func() {
+									// The edit text isn't updated yet when the selection changes
+									if urlComboBox != nil {
+										if i := urlComboBox.CurrentIndex(); i >= 0 && i < len(recentHostnames) {
+											setSelfHostedURL(recentHostnames[i])
+										}
+									}
+								},
+							},
+							PushButton{
+								AssignTo:  &testServerButton,
+								Text:      "Test",
+								MinSize:   Size{Width: 60, Height: 0},
+								MaxSize:   Size{Width: 60, Height: 0},
+								Enabled:   false,
+								OnClicked: startServerTest,
+							},
 						},
 					},
+					Label{
+						AssignTo:  &testResultLabel,
+						Alignment: AlignHCenterVNear,
+						Visible:   false,
+					},
 					// Device auth code display
+					Composite{
+						AssignTo: &codeComposite,
+						Layout:   HBox{MarginsZero: true, Spacing: 12, Alignment: AlignHCenterVCenter},
+						Visible:  false,
+						Children: []Widget{
+							Label{
+								AssignTo:  &codeLabel,
+								Text:      "",
+								Alignment: AlignHCenterVCenter,
+								Font:      Font{PointSize: 24, Bold: true},
+								Visible:   false,
+							},
+							ImageView{
+								AssignTo: &qrImageView,
+								Mode:     ImageViewModeIdeal,
+								MinSize:  Size{Width: qrCodeSize, Height: qrCodeSize},
+								MaxSize:  Size{Width: qrCodeSize, Height: qrCodeSize},
+								Visible:  false,
+							},
+						},
+					},
 					Label{
-						AssignTo:  &codeLabel,
+						AssignTo:  &countdownLabel,
 						Text:      "",
 						Alignment: AlignHCenterVNear,
-						Font:      Font{PointSize: 24, Bold: true},
 						Visible:   false,
 					},
+					ProgressBar{
+						AssignTo: &progressBar,
+						MinValue: 0,
+						MaxValue: 1000,
+						MinSize:  Size{Width: 300, Height: 4},
+						MaxSize:  Size{Width: 300, Height: 4},
+						Visible:  false,
+					},
 					Composite{
 						Layout: HBox{MarginsZero: true, Spacing: 8, Alignment: AlignHCenterVNear},
 						Children: []Widget{
@@ -473,10 +877,24 @@ func ShowLoginDialog(
 								OnClicked: func() {
 									code := authManager.DeviceAuthCode()
 									if code != nil {
-										copyToClipboard(*code)
+										if err := clipboard.Copy(*code); err != nil {
+											clipboard.ShowError(dlg, err)
+										}
 									}
 								},
 							},
+							PushButton{
+								AssignTo: &retryButton,
+								Text:     "Get New Code",
+								Visible:  false,
+								OnClicked: func() {
+									codeExpired = false
+									hasAutoOpenedBrowser = false
+									isLoggingIn = true
+									updateUI()
+									startDeviceAuth()
+								},
+							},
 							PushButton{
 								AssignTo: &openBrowserButton,
 								Text:     "Open Browser",
@@ -500,7 +918,6 @@ func ShowLoginDialog(
 								Font:      Font{PointSize: 8},
 								Alignment: AlignHCenterVCenter,
 								Visible:   false,
-								TextColor: walk.RGB(0x80, 0x80, 0x80), // Secondary gray color
 							},
 						},
 					},
@@ -516,7 +933,6 @@ func ShowLoginDialog(
 						Text:      "By continuing, you agree to our ",
 						Font:      Font{PointSize: 8},
 						Alignment: AlignHNearVCenter,
-						TextColor: walk.RGB(0x80, 0x80, 0x80), // Secondary gray color
 					},
 					LinkLabel{
 						AssignTo:  &termsLinkLabel,
@@ -532,7 +948,6 @@ func ShowLoginDialog(
 						Text:      " and ",
 						Font:      Font{PointSize: 8},
 						Alignment: AlignHNearVCenter,
-						TextColor: walk.RGB(0x80, 0x80, 0x80), // Secondary gray color
 					},
 					LinkLabel{
 						AssignTo:  &privacyLinkLabel,
@@ -558,7 +973,16 @@ func ShowLoginDialog(
 						MaxSize:  Size{Width: 75, Height: 0},
 						Visible:  false,
 						OnClicked: func() {
-							if currentState == stateDeviceAuthCode {
+							codeExpired = false
+							stopServerTest()
+							// Stop polling for a code the user walked away from
+							cancelDeviceAuth()
+							authManager.CancelLogin()
+							if currentState == statePasswordLogin {
+								resetPasswordLogin()
+								currentState = stateHostingSelection
+								hostingOpt = hostingNone
+							} else if currentState == stateDeviceAuthCode {
 								// Cancel the auth flow
 								currentState = stateHostingSelection
 								hostingOpt = hostingNone
@@ -567,8 +991,8 @@ func ShowLoginDialog(
 								currentState = stateHostingSelection
 								hostingOpt = hostingNone
 								selfHostedURL = ""
-								if urlLineEdit != nil {
-									urlLineEdit.SetText("")
+								if urlComboBox != nil {
+									urlComboBox.SetText("")
 								}
 							}
 							updateUI()
@@ -580,6 +1004,7 @@ func ShowLoginDialog(
 						MinSize:  Size{Width: 75, Height: 0},
 						MaxSize:  Size{Width: 75, Height: 0},
 						OnClicked: func() {
+							authManager.CancelLogin()
 							dlg.Cancel()
 						},
 					},
@@ -590,10 +1015,25 @@ func ShowLoginDialog(
 						MaxSize:  Size{Width: 75, Height: 0},
 						Visible:  false,
 						OnClicked: func() {
+							if !canSubmitLogin() {
+								// Enter on a step without a login to submit, e.g. while the code is shown
+								return
+							}
+							if currentState == statePasswordLogin {
+								startPasswordLogin()
+								return
+							}
+							stopServerTest()
+							if usePasswordLogin {
+								temporaryHostname = normalizeURL(selfHostedURL)
+								currentState = statePasswordLogin
+								updateUI()
+								return
+							}
 							currentState = stateDeviceAuthCode
 							isLoggingIn = true
 							updateUI()
-							go performLogin()
+							startDeviceAuth()
 						},
 					},
 				},
@@ -601,6 +1041,9 @@ func ShowLoginDialog(
 		},
 	}.Create(parent)
 
+	// The declarative ComboBox has no cue banner, so set it on the control
+	setComboBoxCueBanner(urlComboBox, "https://your-server.com")
+
 	// Disable maximize, minimize buttons, and resizing
 	style := win.GetWindowLong(dlg.Handle(), win.GWL_STYLE)
 	style &^= win.WS_MAXIMIZEBOX
@@ -620,7 +1063,7 @@ func ShowLoginDialog(
 
 	// Set window icon
 	iconsPath := getIconsPath()
-	iconPath := filepath.Join(iconsPath, "icon-orange.ico")
+	iconPath := icons.Path(iconsPath, "icon-orange.ico")
 	icon, err := walk.NewIconFromFile(iconPath)
 	if err != nil {
 		logger.Error("Failed to load window icon from %s: %v", iconPath, err)
@@ -630,36 +1073,45 @@ func ShowLoginDialog(
 		}
 	}
 
-	// Set background color (always light mode)
-	bgBrush, _ := walk.NewSolidColorBrush(walk.RGB(0xFC, 0xFC, 0xFC)) // #FCFCFC
-	if bgBrush != nil {
-		dlg.SetBackground(bgBrush)
-		if contentComposite != nil {
-			contentComposite.SetBackground(bgBrush)
+	// Load word mark logo (image is picked by applyTheme)
+	var logoImageView *walk.ImageView
+	if logoContainer != nil {
+		logoImageView, err = walk.NewImageView(logoContainer)
+		if err != nil {
+			logger.Error("Failed to create ImageView: %v", err)
+			logoImageView = nil
 		}
-		if buttonComposite != nil {
-			buttonComposite.SetBackground(bgBrush)
+	}
+
+	// applyTheme colors the dialog for the current light or dark app theme
+	applyTheme := func() {
+		colors := theme.ThemeColors()
+
+		if bgBrush, err := walk.NewSolidColorBrush(colors.Background); err == nil {
+			dlg.SetBackground(bgBrush)
+			for _, composite := range []*walk.Composite{contentComposite, buttonComposite, logoContainer, termsComposite, manualURLComposite, codeComposite, urlRowComposite} {
+				if composite != nil {
+					composite.SetBackground(bgBrush)
+				}
+			}
 		}
-		if logoContainer != nil {
-			logoContainer.SetBackground(bgBrush)
+		for _, label := range []*walk.Label{urlLabel, hintLabel, codeLabel, passwordHintLabel} {
+			if label != nil {
+				label.SetTextColor(colors.Foreground)
+			}
 		}
-		if termsComposite != nil {
-			termsComposite.SetBackground(bgBrush)
+		for _, label := range []*walk.Label{manualURLLabel, termsLabel, andLabel, countdownLabel} {
+			if label != nil {
+				label.SetTextColor(colors.Muted)
+			}
 		}
-	}
-
-	// Load and display word mark logo
-	if logoContainer != nil {
-		// Always use black word mark (light mode)
-		iconsPath := getIconsPath()
-		imagePath := filepath.Join(iconsPath, "word_mark_black.png")
 
-		// Create ImageView widget
-		logoImageView, err := walk.NewImageView(logoContainer)
-		if err != nil {
-			logger.Error("Failed to create ImageView: %v", err)
-		} else {
-			// Load the image
+		if logoImageView != nil {
+			wordMark := "word_mark_black.png"
+			if colors.Dark {
+				wordMark = "word_mark_white.png"
+			}
+			imagePath := icons.Path(getIconsPath(), wordMark)
 			img, err := walk.NewImageFromFile(imagePath)
 			if err != nil {
 				logger.Error("Failed to load word mark image from %s: %v", imagePath, err)
@@ -667,7 +1119,10 @@ func ShowLoginDialog(
 				logoImageView.SetImage(img)
 			}
 		}
+		dlg.Invalidate()
 	}
+	applyTheme()
+	themeHandle := theme.Changed().Attach(applyTheme)
 
 	// Initial UI update
 	updateUI()
@@ -677,14 +1132,72 @@ func ShowLoginDialog(
 	openLoginDialog = dlg
 	openLoginDialogMutex.Unlock()
 
+	// Follow the device auth login as it moves between states. The code is shown
+	// once the server hands it out, and only the countdown needs a timer after that.
+	cancelCountdown := context.CancelFunc(func() {})
+	unregisterAuthState := authManager.RegisterStateChange(func(state auth.AuthState) {
+		walk.App().Synchronize(func() {
+			if pollCtx.Err() != nil {
+				// Dialog closed meanwhile
+				return
+			}
+			cancelCountdown()
+			switch state {
+			case auth.AuthStateAwaitingVerification:
+				var ctx context.Context
+				ctx, cancelCountdown = context.WithCancel(pollCtx)
+				updateCodeDisplay()
+				go func() {
+					ticker := time.NewTicker(time.Second)
+					defer ticker.Stop()
+					for {
+						select {
+						case <-ctx.Done():
+							return
+						case <-ticker.C:
+							updateCodeDisplay()
+						}
+					}
+				}()
+			case auth.AuthStateIdle:
+				if currentState != stateDeviceAuthCode || isLoggingIn || codeExpired {
+					return
+				}
+				// Code was cleared, go back based on hosting option
+				hasAutoOpenedBrowser = false
+				includeUsernameInDeviceURL = false
+				if hostingOpt == hostingCloud {
+					// For cloud, go back to hosting selection
+					currentState = stateHostingSelection
+					hostingOpt = hostingNone
+				} else if hostingOpt == hostingSelfHosted {
+					// For self-hosted, go back to URL input stage so user can try again
+					currentState = stateReadyToLogin
+				} else {
+					// Fallback to hosting selection
+					currentState = stateHostingSelection
+					hostingOpt = hostingNone
+				}
+				updateUI()
+			}
+		})
+	})
+
 	// Clear the dialog reference and cleanup state when it closes
 	defer func() {
-		// Cancel login operation and polling goroutine
+		theme.Changed().Detach(themeHandle)
+		if qrBitmap != nil {
+			qrBitmap.Dispose()
+		}
+
+		// Cancel login operation (including the current device auth attempt) and the countdown
+		unregisterAuthState()
 		cancelLogin()
 		cancelPoll()
 
 		// Clear device auth state and re-auth flag if login didn't succeed
 		if !loginSucceeded {
+			authManager.CancelLogin()
 			authManager.ClearDeviceAuth()
 			if authManager != nil {
 				authManager.ClearStartDeviceAuthImmediately()
@@ -701,50 +1214,14 @@ func ShowLoginDialog(
 		logger.Info("Login dialog closed")
 	}()
 
-	// Poll for device auth code updates
-	go func() {
-		ticker := time.NewTicker(500 * time.Millisecond)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-pollCtx.Done():
-				// Dialog closed, stop polling
-				return
-			case <-ticker.C:
-				if currentState == stateDeviceAuthCode {
-					code := authManager.DeviceAuthCode()
-					if code != nil {
-						updateCodeDisplay()
-					} else if !isLoggingIn {
-						// Code was cleared, go back based on hosting option
-						walk.App().Synchronize(func() {
-							hasAutoOpenedBrowser = false
-							includeUsernameInDeviceURL = false
-							if hostingOpt == hostingCloud {
-								// For cloud, go back to hosting selection
-								currentState = stateHostingSelection
-								hostingOpt = hostingNone
-							} else if hostingOpt == hostingSelfHosted {
-								// For self-hosted, go back to URL input stage so user can try again
-								currentState = stateReadyToLogin
-							} else {
-								// Fallback to hosting selection
-								currentState = stateHostingSelection
-								hostingOpt = hostingNone
-							}
-							updateUI()
-						})
-					}
-				}
-			}
-		}
-	}()
-
 	// When opened from re-auth (session expired), skip hosting selection and start device auth immediately
 	go func() {
 		time.Sleep(150 * time.Millisecond) // Let the dialog become visible
 		walk.App().Synchronize(func() {
+			if pollCtx.Err() != nil {
+				// Dialog already closed
+				return
+			}
 			if authManager != nil && authManager.StartDeviceAuthImmediately() {
 				authManager.ClearStartDeviceAuthImmediately()
 				if activeAccount != nil {
@@ -756,60 +1233,74 @@ func ShowLoginDialog(
 				currentState = stateDeviceAuthCode
 				isLoggingIn = true
 				updateUI()
-				go performLogin()
+				startDeviceAuth()
 			}
 		})
 	}()
 
 	dlg.Run()
-}
 
-// openBrowser opens a URL in the default browser
-func openBrowser(url string) {
-	browser.OpenURL(url)
+	// Let the user pick an organization if they belong to several and haven't chosen one
+	if loginSucceeded && authManager.NeedsOrgSelection() {
+		showOrgSelectionDialog(parent, authManager.Organizations())
+	}
 }
 
-// copyToClipboard copies text to the Windows clipboard
-func copyToClipboard(text string) {
-	// Open clipboard
-	if !win.OpenClipboard(0) {
-		logger.Error("Failed to open clipboard")
+// cbSetCueBanner is CB_SETCUEBANNER, which the win package doesn't define
+const cbSetCueBanner = 0x1703
+
+// setComboBoxCueBanner shows text in an empty editable combo box
+func setComboBoxCueBanner(cb *walk.ComboBox, text string) {
+	if cb == nil {
 		return
 	}
-	defer win.CloseClipboard()
-
-	// Empty clipboard
-	win.EmptyClipboard()
-
-	// Convert text to UTF16
-	text16, err := windows.UTF16FromString(text)
+	textPtr, err := windows.UTF16PtrFromString(text)
 	if err != nil {
-		logger.Error("Failed to convert text to UTF16: %v", err)
 		return
 	}
+	win.SendMessage(cb.Handle(), cbSetCueBanner, 0, uintptr(unsafe.Pointer(textPtr)))
+}
 
-	// Allocate global memory
-	memSize := len(text16) * 2
-	hMem := win.GlobalAlloc(win.GMEM_MOVEABLE, uintptr(memSize))
-	if hMem == 0 {
-		logger.Error("Failed to allocate memory")
-		return
-	}
-	defer win.GlobalFree(hMem)
+// qrCodeSize is the edge length of the device auth QR code in pixels. It has to fit
+// beside the code within the fixed size login dialog.
+const qrCodeSize = 96
 
-	// Lock memory and copy data
-	pMem := win.GlobalLock(hMem)
-	if pMem == nil {
-		logger.Error("Failed to lock memory")
-		return
+// deviceAuthURL builds the device login page URL with the code prefilled, and the
+// account's email when re-authenticating
+func deviceAuthURL(hostname, code, userEmail string) string {
+	// Remove middle hyphen from code (e.g., "XXXX-XXXX" -> "XXXXXXXX")
+	codeWithoutHyphen := strings.ReplaceAll(code, "-", "")
+	loginURL := fmt.Sprintf("%s/auth/login/device?code=%s", hostname, codeWithoutHyphen)
+	if userEmail != "" {
+		loginURL += "&user=" + url.QueryEscape(userEmail)
 	}
-	defer win.GlobalUnlock(hMem)
+	return loginURL
+}
 
-	copy((*[1 << 20]uint16)(pMem)[:len(text16):len(text16)], text16)
+// newQRCode encodes content as the QR code shown in the login dialog
+func newQRCode(content string) (*qrcode.QRCode, error) {
+	return qrcode.New(content, qrcode.Medium)
+}
 
-	// Set clipboard data
-	if win.SetClipboardData(win.CF_UNICODETEXT, win.HANDLE(hMem)) == 0 {
-		logger.Error("Failed to set clipboard data")
-		return
+// newQRCodeBitmap renders content as a QR code bitmap of size×size pixels
+func newQRCodeBitmap(content string, size int) (*walk.Bitmap, error) {
+	qr, err := newQRCode(content)
+	if err != nil {
+		return nil, err
 	}
+	return walk.NewBitmapFromImageForDPI(qr.Image(size), 96)
+}
+
+// formatCountdown formats a remaining duration as minutes and seconds, e.g. "9:05"
+func formatCountdown(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	seconds := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// openBrowser opens a URL in the default browser
+func openBrowser(url string) {
+	browser.OpenURL(url)
 }
@@ -0,0 +1,103 @@
+//go:build windows
+
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeviceAuthURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		hostname  string
+		code      string
+		userEmail string
+		want      string
+	}{
+		{
+			name:     "cloud",
+			hostname: "https://app.pangolin.net",
+			code:     "ABCD-1234",
+			want:     "https://app.pangolin.net/auth/login/device?code=ABCD1234",
+		},
+		{
+			name:     "code without hyphen",
+			hostname: "https://pangolin.example.com",
+			code:     "ABCD1234",
+			want:     "https://pangolin.example.com/auth/login/device?code=ABCD1234",
+		},
+		{
+			name:      "re-authenticating",
+			hostname:  "https://app.pangolin.net",
+			code:      "ABCD-1234",
+			userEmail: "first+last@example.com",
+			want:      "https://app.pangolin.net/auth/login/device?code=ABCD1234&user=first%2Blast%40example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deviceAuthURL(tt.hostname, tt.code, tt.userEmail); got != tt.want {
+				t.Errorf("deviceAuthURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// The QR code holds the URL the browser is opened at, and still fits the dialog
+// for a long self-hosted URL with an email
+func TestDeviceAuthQRCode(t *testing.T) {
+	urls := []string{
+		deviceAuthURL("https://app.pangolin.net", "ABCD-1234", ""),
+		deviceAuthURL("https://"+strings.Repeat("long-subdomain.", 4)+"example.com", "ABCD-1234", "someone.with.a.long.name@example.com"),
+	}
+
+	for _, loginURL := range urls {
+		qr, err := newQRCode(loginURL)
+		if err != nil {
+			t.Fatalf("newQRCode(%q): %v", loginURL, err)
+		}
+		if qr.Content != loginURL {
+			t.Errorf("QR code holds %q, want %q", qr.Content, loginURL)
+		}
+		if size := qr.Image(qrCodeSize).Bounds().Dx(); size != qrCodeSize {
+			t.Errorf("QR code for %q is %d pixels wide, want %d", loginURL, size, qrCodeSize)
+		}
+	}
+}
+
+func TestLoginFormCanSubmit(t *testing.T) {
+	password := loginForm{state: statePasswordLogin, email: "milo@example.com", password: "hunter2"}
+	withCode := password
+	withCode.twoFactorRequested = true
+	withCode.twoFactor = "123456"
+
+	tests := []struct {
+		name string
+		form loginForm
+		want bool
+	}{
+		{name: "cloud", form: loginForm{state: stateReadyToLogin, hosting: hostingCloud}, want: true},
+		{name: "self-hosted with a URL", form: loginForm{state: stateReadyToLogin, hosting: hostingSelfHosted, selfHostedURL: "pangolin.example.com"}, want: true},
+		{name: "self-hosted without a URL", form: loginForm{state: stateReadyToLogin, hosting: hostingSelfHosted, selfHostedURL: "  "}},
+		{name: "no hosting chosen", form: loginForm{state: stateReadyToLogin}},
+		{name: "already logging in", form: loginForm{state: stateReadyToLogin, hosting: hostingCloud, loggingIn: true}},
+		{name: "choosing hosting", form: loginForm{state: stateHostingSelection, hosting: hostingCloud}},
+		{name: "code shown", form: loginForm{state: stateDeviceAuthCode, hosting: hostingCloud}},
+		{name: "logged in", form: loginForm{state: stateSuccess, hosting: hostingCloud}},
+		{name: "password", form: password, want: true},
+		{name: "password without an email", form: loginForm{state: statePasswordLogin, email: " ", password: "hunter2"}},
+		{name: "password left empty", form: loginForm{state: statePasswordLogin, email: "milo@example.com"}},
+		{name: "2FA code given", form: withCode, want: true},
+		{name: "2FA code missing", form: loginForm{state: statePasswordLogin, email: "milo@example.com", password: "hunter2", twoFactorRequested: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.form.canSubmit(); got != tt.want {
+				t.Errorf("canSubmit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
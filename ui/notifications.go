@@ -0,0 +1,100 @@
+//go:build windows
+
+package ui
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/fosrl/newt/logger"
+)
+
+// stateNotification is the balloon shown for a tunnel state transition
+type stateNotification int
+
+const (
+	stateNotificationNone stateNotification = iota
+	stateNotificationConnected
+	stateNotificationDisconnected
+)
+
+var (
+	stateNotifyMutex sync.Mutex
+	// lastNotifyState is the previous state seen by notifyTunnelStateChange
+	lastNotifyState = tunnel.StateStopped
+	// stateNotifySeeded is set once the initial state has been recorded
+	stateNotifySeeded bool
+	// userDisconnectRequested suppresses the notification for a disconnect the user asked for
+	userDisconnectRequested bool
+)
+
+// markUserDisconnect records that the user is about to stop the tunnel, so the
+// resulting transition to Stopped does not raise a "Disconnected" balloon
+func markUserDisconnect() {
+	stateNotifyMutex.Lock()
+	// Nothing will clear the flag if the tunnel is already down
+	userDisconnectRequested = lastNotifyState != tunnel.StateStopped
+	stateNotifyMutex.Unlock()
+}
+
+// stateChangeNotification decides which notification, if any, a transition from
+// prev to next deserves: reaching Running is announced, and so is dropping to
+// Stopped unless the user asked for it. A connection attempt that fails before
+// registering is already reported by the connect flow, so it is not announced.
+func stateChangeNotification(prev, next tunnel.State, userInitiated bool) stateNotification {
+	if prev == next {
+		return stateNotificationNone
+	}
+	switch next {
+	case tunnel.StateRunning:
+		return stateNotificationConnected
+	case tunnel.StateStopped:
+		if userInitiated || prev == tunnel.StateStarting || prev == tunnel.StateRegistering {
+			return stateNotificationNone
+		}
+		return stateNotificationDisconnected
+	}
+	return stateNotificationNone
+}
+
+// notifyTunnelStateChange shows a tray balloon for connect and unexpected disconnect
+// transitions. Must be called on the UI thread.
+func (t *Tray) notifyTunnelStateChange(state tunnel.State) {
+	stateNotifyMutex.Lock()
+	prev := lastNotifyState
+	seeded := stateNotifySeeded
+	userInitiated := userDisconnectRequested
+	lastNotifyState = state
+	stateNotifySeeded = true
+	if state == tunnel.StateStopped {
+		userDisconnectRequested = false
+	}
+	stateNotifyMutex.Unlock()
+
+	// The first state is the one the tunnel was already in when the UI started
+	if !seeded || t.icon == nil {
+		return
+	}
+	if configManager != nil && !configManager.GetNotifyOnStateChange() {
+		return
+	}
+
+	var err error
+	switch stateChangeNotification(prev, state, userInitiated) {
+	case stateNotificationConnected:
+		message := "The tunnel is connected."
+		if authManager != nil {
+			if org := authManager.CurrentOrg(); org != nil && org.Name != "" {
+				message = fmt.Sprintf("Connected to %s.", org.Name)
+			}
+		}
+		err = t.icon.ShowInfo("Connected", message)
+	case stateNotificationDisconnected:
+		err = t.icon.ShowWarning("Disconnected", "The tunnel was disconnected.")
+	}
+	if err != nil {
+		logger.Error("Failed to show tunnel state notification: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package ui
+
+import (
+	"testing"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+func TestStateChangeNotification(t *testing.T) {
+	tests := []struct {
+		name          string
+		prev, next    tunnel.State
+		userInitiated bool
+		want          stateNotification
+	}{
+		{name: "connected", prev: tunnel.StateRegistered, next: tunnel.StateRunning, want: stateNotificationConnected},
+		{name: "reconnected", prev: tunnel.StateReconnecting, next: tunnel.StateRunning, want: stateNotificationConnected},
+		{name: "still running", prev: tunnel.StateRunning, next: tunnel.StateRunning},
+		{name: "dropped", prev: tunnel.StateRunning, next: tunnel.StateStopped, want: stateNotificationDisconnected},
+		{name: "dropped while reconnecting", prev: tunnel.StateReconnecting, next: tunnel.StateStopped, want: stateNotificationDisconnected},
+		{name: "dropped after registering", prev: tunnel.StateRegistered, next: tunnel.StateStopped, want: stateNotificationDisconnected},
+		{name: "user disconnected", prev: tunnel.StateRunning, next: tunnel.StateStopped, userInitiated: true},
+		{name: "user disconnected while stopping", prev: tunnel.StateStopping, next: tunnel.StateStopped, userInitiated: true},
+		{name: "failed to start", prev: tunnel.StateStarting, next: tunnel.StateStopped},
+		{name: "failed to register", prev: tunnel.StateRegistering, next: tunnel.StateStopped},
+		{name: "still stopped", prev: tunnel.StateStopped, next: tunnel.StateStopped},
+		{name: "starting", prev: tunnel.StateStopped, next: tunnel.StateStarting},
+		{name: "reconnecting", prev: tunnel.StateRunning, next: tunnel.StateReconnecting},
+		{name: "error", prev: tunnel.StateRunning, next: tunnel.StateError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stateChangeNotification(tt.prev, tt.next, tt.userInitiated); got != tt.want {
+				t.Errorf("stateChangeNotification(%v, %v, %v) = %v, want %v", tt.prev, tt.next, tt.userInitiated, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkUserDisconnect(t *testing.T) {
+	defer func(state tunnel.State, seeded, requested bool) {
+		lastNotifyState, stateNotifySeeded, userDisconnectRequested = state, seeded, requested
+	}(lastNotifyState, stateNotifySeeded, userDisconnectRequested)
+
+	tray := &Tray{}
+	tray.notifyTunnelStateChange(tunnel.StateRunning)
+
+	markUserDisconnect()
+	if !userDisconnectRequested {
+		t.Fatal("a disconnect from Running was not marked as the user's")
+	}
+	tray.notifyTunnelStateChange(tunnel.StateStopping)
+	if !userDisconnectRequested {
+		t.Error("the mark was cleared before the tunnel stopped")
+	}
+	tray.notifyTunnelStateChange(tunnel.StateStopped)
+	if userDisconnectRequested {
+		t.Error("the mark outlived the disconnect, so a later drop would go unannounced")
+	}
+
+	// Nothing clears a mark made while the tunnel is already down
+	markUserDisconnect()
+	if userDisconnectRequested {
+		t.Error("a disconnect was marked while the tunnel was stopped")
+	}
+}
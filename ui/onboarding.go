@@ -0,0 +1,21 @@
+//go:build windows
+
+package ui
+
+import (
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+)
+
+// showOnboarding opens the login dialog on a fresh install, so a new user doesn't
+// have to find it in the tray menu. A returning user who is logged out is left alone.
+func showOnboarding() {
+	if configManager == nil || authManager == nil || authManager.IsAuthenticated() || !configManager.FirstRun() {
+		return
+	}
+	logger.Info("First run, opening the login dialog")
+	walk.App().Synchronize(func() {
+		ShowLoginDialog(mainWindow, authManager, configManager, accountManager, apiClient, tunnelManager)
+		updateMenu()
+	})
+}
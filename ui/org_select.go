@@ -0,0 +1,155 @@
+//go:build windows
+
+package ui
+
+import (
+	"fmt"
+
+	"github.com/fosrl/windows/api"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+// selectOrganization makes org the current organization, moving a running tunnel
+// over to it. Must not be called on the UI thread.
+func selectOrganization(org api.Org) {
+	if err := authManager.SelectOrganization(&org); err != nil {
+		logger.Error("Failed to select organization: %v", err)
+		// Show error dialog to user
+		walk.App().Synchronize(func() {
+			td := walk.NewTaskDialog()
+			_, _ = td.Show(walk.TaskDialogOpts{
+				Owner:         mainWindow,
+				Title:         "Organization Selection Failed",
+				Content:       fmt.Sprintf("Failed to select organization: %v", err),
+				IconSystem:    walk.TaskDialogSystemIconError,
+				CommonButtons: win.TDCBF_OK_BUTTON,
+			})
+		})
+		return
+	}
+
+	// The sites listed belong to the previous organization
+	walk.App().Synchronize(func() {
+		updateSitesMenu(nil)
+	})
+	updateMenu()
+
+	if tunnelManager.IsConnected() {
+		if err := tunnelManager.SwitchOLMOrg(org.Id); err != nil {
+			logger.Error("Failed to switch tunnel organization: %v", err)
+			// Show error dialog to user
+			walk.App().Synchronize(func() {
+				td := walk.NewTaskDialog()
+				_, _ = td.Show(walk.TaskDialogOpts{
+					Owner:         mainWindow,
+					Title:         "Tunnel Organization Switch Failed",
+					Content:       fmt.Sprintf("Failed to switch tunnel organization: %v", err),
+					IconSystem:    walk.TaskDialogSystemIconError,
+					CommonButtons: win.TDCBF_OK_BUTTON,
+				})
+			})
+		}
+	}
+}
+
+// showOrgSelectionDialog asks which organization to use when the user belongs to
+// several. Canceling leaves none selected; one can still be picked from the
+// Organizations menu. Must be called on the UI thread.
+func showOrgSelectionDialog(owner walk.Form, orgs []api.Org) {
+	if len(orgs) == 0 {
+		return
+	}
+
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	dlg, err := walk.NewDialogWithFixedSize(owner)
+	if err != nil {
+		logger.Error("Failed to create organization selection dialog: %v", err)
+		return
+	}
+	disposables.Add(dlg)
+
+	dlg.SetTitle("Select Organization")
+	layout := walk.NewVBoxLayout()
+	layout.SetMargins(walk.Margins{HNear: 12, VNear: 12, HFar: 12, VFar: 12})
+	layout.SetSpacing(8)
+	dlg.SetLayout(layout)
+
+	label, err := walk.NewLabel(dlg)
+	if err != nil {
+		logger.Error("Failed to create organization selection dialog: %v", err)
+		return
+	}
+	label.SetText("You belong to several organizations. Choose the one to connect to:")
+
+	listBox, err := walk.NewListBox(dlg)
+	if err != nil {
+		logger.Error("Failed to create organization selection dialog: %v", err)
+		return
+	}
+	names := make([]string, len(orgs))
+	for i, org := range orgs {
+		names[i] = org.Name
+		if names[i] == "" {
+			names[i] = org.Id
+		}
+	}
+	if err := listBox.SetModel(names); err != nil {
+		logger.Error("Failed to fill organization list: %v", err)
+		return
+	}
+	listBox.SetCurrentIndex(0)
+
+	buttons, err := walk.NewComposite(dlg)
+	if err != nil {
+		logger.Error("Failed to create organization selection dialog: %v", err)
+		return
+	}
+	buttons.SetLayout(walk.NewHBoxLayout())
+	buttons.Layout().SetMargins(walk.Margins{})
+	walk.NewHSpacer(buttons)
+
+	okButton, err := walk.NewPushButton(buttons)
+	if err != nil {
+		logger.Error("Failed to create organization selection dialog: %v", err)
+		return
+	}
+	okButton.SetText("OK")
+	// Read the choice while the list still exists
+	selected := -1
+	accept := func() {
+		selected = listBox.CurrentIndex()
+		dlg.Accept()
+	}
+	okButton.Clicked().Attach(accept)
+	listBox.ItemActivated().Attach(accept)
+
+	cancelButton, err := walk.NewPushButton(buttons)
+	if err != nil {
+		logger.Error("Failed to create organization selection dialog: %v", err)
+		return
+	}
+	cancelButton.SetText("Cancel")
+	cancelButton.Clicked().Attach(func() {
+		dlg.Cancel()
+	})
+
+	dlg.SetDefaultButton(okButton)
+	dlg.SetCancelButton(cancelButton)
+	dlg.SetSize(walk.Size{Width: 360, Height: 280})
+	disposables.Spare()
+
+	if dlg.Run() != walk.DlgCmdOK {
+		logger.Info("Organization selection canceled")
+		return
+	}
+	if selected < 0 || selected >= len(orgs) {
+		return
+	}
+	go selectOrganization(orgs[selected])
+}
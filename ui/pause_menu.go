@@ -0,0 +1,146 @@
+//go:build windows
+
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+var (
+	pauseMenu       *walk.Menu
+	pauseMenuAction *walk.Action
+	resumeAction    *walk.Action
+)
+
+// formatPauseDuration formats a pause menu entry, e.g. "5 Minutes" or "1 Hour"
+func formatPauseDuration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			return "1 Hour"
+		}
+		return fmt.Sprintf("%d Hours", hours)
+	}
+	minutes := int(d / time.Minute)
+	if minutes == 1 {
+		return "1 Minute"
+	}
+	return fmt.Sprintf("%d Minutes", minutes)
+}
+
+// formatPausedStatus builds the status text for a paused tunnel, rounding the
+// remaining time up so it never reads "0 min" before reconnecting
+func formatPausedStatus(remaining time.Duration) string {
+	minutes := int((remaining + time.Minute - 1) / time.Minute)
+	if minutes <= 1 {
+		return "Paused, resumes in 1 min"
+	}
+	return fmt.Sprintf("Paused, resumes in %d min", minutes)
+}
+
+// setupPauseMenu creates the "Pause for…" submenu and the "Resume Now" action
+func setupPauseMenu(actions *walk.ActionList) error {
+	var err error
+	pauseMenu, err = walk.NewMenu()
+	if err != nil {
+		logger.Error("Failed to create pause menu: %v", err)
+		return err
+	}
+	for _, d := range tunnel.PauseDurations {
+		d := d
+		action := walk.NewAction()
+		action.SetText(formatPauseDuration(d))
+		action.Triggered().Attach(func() {
+			go pauseTunnel(d)
+		})
+		pauseMenu.Actions().Add(action)
+	}
+	pauseMenuAction = walk.NewMenuAction(pauseMenu)
+	pauseMenuAction.SetText("Pause for…")
+	pauseMenuAction.SetVisible(false) // Shown only while connected
+	actions.Add(pauseMenuAction)
+
+	resumeAction = walk.NewAction()
+	resumeAction.SetText("Resume Now")
+	resumeAction.SetVisible(false) // Shown only while paused
+	resumeAction.Triggered().Attach(func() {
+		go func() {
+			if tunnelManager == nil {
+				return
+			}
+			if err := tunnelManager.ResumeNow(); err != nil {
+				logger.Error("Failed to resume tunnel: %v", err)
+				showConnectionErrorDialog("Connection Failed", err)
+			}
+		}()
+	})
+	actions.Add(resumeAction)
+	return nil
+}
+
+// pauseTunnel disconnects the tunnel for d, after which the tunnel manager reconnects it
+func pauseTunnel(d time.Duration) {
+	if tunnelManager == nil {
+		return
+	}
+	markUserDisconnect()
+	if err := tunnelManager.Pause(d); err != nil {
+		logger.Error("Failed to pause tunnel: %v", err)
+		showConnectionErrorDialog("Pause Failed", err)
+	}
+}
+
+// updatePauseActions shows the pause submenu while connected and the resume action
+// while paused. Must be called on the UI thread.
+func updatePauseActions(show bool, state tunnel.State, paused bool) {
+	if pauseMenuAction != nil {
+		pauseMenuAction.SetVisible(show && state == tunnel.StateRunning && !paused)
+	}
+	if resumeAction != nil {
+		resumeAction.SetVisible(show && paused)
+	}
+}
+
+// handlePauseChange refreshes the tray after the tunnel was paused or the pause
+// ended. A failed automatic reconnect is reported to the user.
+func handlePauseChange(status tunnel.PauseStatus, err error) {
+	walk.App().Synchronize(func() {
+		if tunnelManager != nil {
+			state := tunnelManager.State()
+			setTrayIconForState(state)
+			updateTrayTooltip(state)
+		}
+		updateMenu()
+	})
+	if err != nil {
+		showConnectionErrorDialog("Connection Failed", err)
+	}
+}
+
+// showConnectionErrorDialog shows err, using the title and message of a
+// tunnel.ConnectionError when available
+func showConnectionErrorDialog(fallbackTitle string, err error) {
+	walk.App().Synchronize(func() {
+		title, message := fallbackTitle, err.Error()
+		if connErr, ok := err.(*tunnel.ConnectionError); ok {
+			title = connErr.Title
+			message = connErr.Message
+		}
+
+		td := walk.NewTaskDialog()
+		_, _ = td.Show(walk.TaskDialogOpts{
+			Owner:         mainWindow,
+			Title:         title,
+			Content:       message,
+			IconSystem:    walk.TaskDialogSystemIconError,
+			CommonButtons: win.TDCBF_OK_BUTTON,
+		})
+	})
+}
@@ -0,0 +1,81 @@
+//go:build windows
+
+package preferences
+
+import (
+	"github.com/fosrl/windows/tunnel"
+	"github.com/fosrl/windows/ui/clipboard"
+	"github.com/tailscale/walk"
+)
+
+// copyMenuItem is one Copy item of a row's context menu. value picks what it
+// copies from the current status, "" if there is nothing to copy.
+type copyMenuItem struct {
+	text  string
+	value func(status *tunnel.OLMStatusResponse) string
+}
+
+// tunnelAddressValue is what the status row's Copy Tunnel Address item copies
+func tunnelAddressValue(status *tunnel.OLMStatusResponse) string {
+	if status == nil {
+		return ""
+	}
+	return status.TunnelAddress
+}
+
+// peerCopyValues returns what the Copy items of the row showing siteID copy,
+// the site's endpoint and its address in the tunnel. Either is "" if the
+// status doesn't report it or no longer has the site.
+func peerCopyValues(status *tunnel.OLMStatusResponse, siteID int) (endpoint, address string) {
+	peer, ok := peerBySiteID(status, siteID)
+	if !ok {
+		return "", ""
+	}
+	return peer.Endpoint, peer.PeerIP
+}
+
+// addCopyMenu gives each of containers a context menu with items, returning
+// their actions in order. Labels pass their right-clicks through to the
+// composite they sit in, as they do left-clicks.
+func (ost *OLMStatusTab) addCopyMenu(containers []*walk.Composite, items ...copyMenuItem) ([]*walk.Action, error) {
+	menu, err := walk.NewMenu()
+	if err != nil {
+		return nil, err
+	}
+	containers[0].AddDisposable(menu)
+
+	actions := make([]*walk.Action, 0, len(items))
+	for _, item := range items {
+		action := walk.NewAction()
+		if err := action.SetText(item.text); err != nil {
+			return nil, err
+		}
+		action.SetEnabled(false)
+		value := item.value
+		action.Triggered().Attach(func() {
+			ost.copyFromStatus(value)
+		})
+		if err := menu.Actions().Add(action); err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	for _, c := range containers {
+		c.SetContextMenu(menu)
+	}
+	return actions, nil
+}
+
+// copyFromStatus copies what value picks from the current status. Must be
+// called on the UI thread.
+func (ost *OLMStatusTab) copyFromStatus(value func(status *tunnel.OLMStatusResponse) string) {
+	ost.mu.Lock()
+	text := value(ost.currentStatus)
+	ost.mu.Unlock()
+	if text == "" {
+		return
+	}
+	if err := clipboard.Copy(text); err != nil {
+		clipboard.ShowError(ost.tabPage.Form(), err)
+	}
+}
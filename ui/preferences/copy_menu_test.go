@@ -0,0 +1,63 @@
+//go:build windows
+
+package preferences
+
+import (
+	"testing"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+func TestTunnelAddressValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *tunnel.OLMStatusResponse
+		want   string
+	}{
+		{name: "no status"},
+		{name: "not connected", status: &tunnel.OLMStatusResponse{}},
+		{name: "connected", status: &tunnel.OLMStatusResponse{Connected: true, TunnelAddress: "100.90.0.2, fd00::2"}, want: "100.90.0.2, fd00::2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tunnelAddressValue(tt.status); got != tt.want {
+				t.Errorf("tunnelAddressValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeerCopyValues(t *testing.T) {
+	status := &tunnel.OLMStatusResponse{
+		Connected: true,
+		PeerStatuses: map[int]*tunnel.OLMPeerStatus{
+			1: {SiteID: 1, SiteName: "Office", Endpoint: "203.0.113.1:51820", PeerIP: "100.90.0.5"},
+			2: {SiteID: 2, SiteName: "Lab", Endpoint: "198.51.100.7:51820"},
+			3: {SiteID: 3, SiteName: "Relayed", IsRelay: true},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		status       *tunnel.OLMStatusResponse
+		siteID       int
+		wantEndpoint string
+		wantAddress  string
+	}{
+		{name: "both values", status: status, siteID: 1, wantEndpoint: "203.0.113.1:51820", wantAddress: "100.90.0.5"},
+		{name: "picks the row's site", status: status, siteID: 2, wantEndpoint: "198.51.100.7:51820"},
+		{name: "nothing reported", status: status, siteID: 3},
+		{name: "site gone", status: status, siteID: 4},
+		{name: "no status", siteID: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, address := peerCopyValues(tt.status, tt.siteID)
+			if endpoint != tt.wantEndpoint || address != tt.wantAddress {
+				t.Errorf("peerCopyValues(%d) = %q, %q, want %q, %q", tt.siteID, endpoint, address, tt.wantEndpoint, tt.wantAddress)
+			}
+		})
+	}
+}
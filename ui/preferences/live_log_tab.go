@@ -0,0 +1,114 @@
+//go:build windows
+
+package preferences
+
+import (
+	"strings"
+
+	"github.com/fosrl/windows/managers"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+const (
+	// maxLiveLogLines is the number of streamed lines kept in the live log view
+	maxLiveLogLines = 1000
+	// liveLogTrimSlack is how far past maxLiveLogLines the view may grow before it is trimmed
+	liveLogTrimSlack = 100
+)
+
+// LiveLogTab shows log lines streamed from the manager service over IPC
+type LiveLogTab struct {
+	tabPage  *walk.TabPage
+	textEdit *walk.TextEdit
+	lines    []string
+	logLine  *managers.LogLineCallback
+	primed   bool     // Set once the recent history is shown
+	pending  []string // Lines streamed before the history arrived
+}
+
+// NewLiveLogTab creates a new live log tab
+func NewLiveLogTab() *LiveLogTab {
+	return &LiveLogTab{}
+}
+
+// Create creates the live log tab UI
+func (llt *LiveLogTab) Create(parent *walk.TabWidget) (*walk.TabPage, error) {
+	var err error
+	if llt.tabPage, err = walk.NewTabPage(); err != nil {
+		return nil, err
+	}
+
+	llt.tabPage.SetTitle("Live Log")
+	llt.tabPage.SetLayout(walk.NewVBoxLayout())
+
+	if llt.textEdit, err = walk.NewTextEditWithStyle(llt.tabPage, win.ES_MULTILINE|win.ES_AUTOVSCROLL|win.WS_VSCROLL); err != nil {
+		return nil, err
+	}
+	llt.textEdit.SetReadOnly(true)
+	if font, err := walk.NewFont("Consolas", 9, 0); err == nil {
+		llt.textEdit.SetFont(font)
+	}
+
+	return llt.tabPage, nil
+}
+
+// AfterAdd is called after the tab page is added to the tab widget
+func (llt *LiveLogTab) AfterAdd() {
+	// Subscribe before fetching the history so nothing logged in between is missed.
+	// Streamed lines are held back until the history is shown.
+	llt.logLine = managers.IPCClientRegisterLogLine(func(line string) {
+		walk.App().Synchronize(func() {
+			if !llt.primed {
+				llt.pending = append(llt.pending, line)
+				return
+			}
+			llt.appendLine(line)
+		})
+	})
+
+	go func() {
+		recent, err := managers.IPCClientGetRecentLogs(maxLiveLogLines)
+		if err != nil {
+			logger.Warn("Failed to get recent log lines: %v", err)
+		}
+		walk.App().Synchronize(func() {
+			llt.primed = true
+			llt.appendLines(append(recent, managers.NewLogLines(recent, llt.pending)...))
+			llt.pending = nil
+		})
+	}()
+}
+
+// Cleanup cleans up resources when the tab is closed
+func (llt *LiveLogTab) Cleanup() {
+	if llt.logLine != nil {
+		llt.logLine.Unregister()
+		llt.logLine = nil
+	}
+}
+
+// appendLine adds a line to the view. Must be called on the UI thread.
+func (llt *LiveLogTab) appendLine(line string) {
+	llt.appendLines([]string{line})
+}
+
+// appendLines adds lines to the view, trimming the oldest lines once the view grows past maxLiveLogLines.
+// Must be called on the UI thread.
+func (llt *LiveLogTab) appendLines(lines []string) {
+	if llt.textEdit == nil || llt.textEdit.IsDisposed() || len(lines) == 0 {
+		return
+	}
+
+	llt.lines = append(llt.lines, lines...)
+	if len(llt.lines) > maxLiveLogLines+liveLogTrimSlack || len(lines) > 1 {
+		llt.lines = llt.lines[max(0, len(llt.lines)-maxLiveLogLines):]
+		if err := llt.textEdit.SetText(strings.Join(llt.lines, "\r\n") + "\r\n"); err != nil {
+			logger.Error("Failed to set live log text: %v", err)
+		}
+	} else {
+		llt.textEdit.AppendText(lines[0] + "\r\n")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package preferences
+
+import (
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/managers"
+)
+
+// logLevelNames are the names the preferences show for config.LogLevels
+var logLevelNames = map[string]string{
+	config.LogLevelError: "Errors only",
+	config.LogLevelInfo:  "Normal",
+	config.LogLevelDebug: "Debug",
+}
+
+// ApplyLogLevel makes the UI log at the configured level and hands the same
+// level to the manager. Called at startup and after the settings change.
+func ApplyLogLevel(cm *config.ConfigManager) {
+	level := cm.GetLogLevel()
+	config.ApplyLogLevel(level)
+	if err := managers.IPCClientSetLogLevel(level); err != nil {
+		logger.Warn("Failed to pass the log level to the manager service: %v", err)
+	}
+}
@@ -253,7 +253,7 @@ func (lt *LogsTab) onSave() {
 		fd.FilePath = fd.FilePath + ".txt"
 	}
 
-	writeFileWithOverwriteHandling(lt.window, fd.FilePath, func(file *os.File) error {
+	writeFileWithOverwriteHandling(lt.window, fd.FilePath, "Log", func(file *os.File) error {
 		for _, item := range lt.model.items {
 			line := fmt.Sprintf("%s [%s] %s\r\n",
 				item.Stamp.Format("2006-01-02 15:04:05.000"),
@@ -548,8 +548,9 @@ func (mdl *logModel) Items() any {
 	return mdl.items
 }
 
-// writeFileWithOverwriteHandling handles file overwrite confirmation
-func writeFileWithOverwriteHandling(owner walk.Form, filePath string, writeFunc func(*os.File) error) {
+// writeFileWithOverwriteHandling handles file overwrite confirmation. what names
+// the file's contents in the success message, e.g. "Log".
+func writeFileWithOverwriteHandling(owner walk.Form, filePath, what string, writeFunc func(*os.File) error) {
 	if _, err := os.Stat(filePath); err == nil {
 		// File exists, ask for confirmation
 		userAcceptedChan := make(chan bool, 1)
@@ -614,7 +615,7 @@ func writeFileWithOverwriteHandling(owner walk.Form, filePath string, writeFunc
 	_, _ = td.Show(walk.TaskDialogOpts{
 		Owner:         owner,
 		Title:         "Save Successful",
-		Content:       fmt.Sprintf("Log saved to %s", filePath),
+		Content:       fmt.Sprintf("%s saved to %s", what, filePath),
 		IconSystem:    walk.TaskDialogSystemIconInformation,
 		CommonButtons: win.TDCBF_OK_BUTTON,
 	})
@@ -3,15 +3,21 @@
 package preferences
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"runtime/debug"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/managers"
 	"github.com/fosrl/windows/tunnel"
+	"github.com/fosrl/windows/ui/theme"
 
 	"github.com/tailscale/walk"
 	"github.com/tailscale/win"
@@ -29,30 +35,71 @@ const (
 type statusWidgets struct {
 	statusIndicator *walk.Label
 	statusText      *walk.Label
+	reasonLabel     *walk.Label
+	reasonRow       *walk.Composite
+	lastConnLabel   *walk.Label
 	versionLabel    *walk.Label
 	versionRow      *walk.Composite
 	agentLabel      *walk.Label
 	agentRow        *walk.Composite
 	orgLabel        *walk.Label
 	orgRow          *walk.Composite
+	deviceLabel     *walk.Label
+	deviceRow       *walk.Composite
+	// copyAddressAction is the status row's Copy Tunnel Address item
+	copyAddressAction *walk.Action
 }
 
 // peerWidgets holds references to a peer's display widgets
 type peerWidgets struct {
-	row           *walk.Composite
-	nameLabel     *walk.Label
-	endpointLabel *walk.Label
-	indicator     *walk.Label
-	statusLabel   *walk.Label
+	siteID         int // Site ID of the peer shown, updated when the peer's ID changes
+	row            *walk.Composite
+	nameLabel      *walk.Label
+	endpointLabel  *walk.Label
+	handshakeLabel *walk.Label
+	indicator      *walk.Label
+	statusLabel    *walk.Label
+	// Items of the row's context menu
+	copyEndpointAction *walk.Action
+	copyAddressAction  *walk.Action
 }
 
+// themedLabel is a label recolored when the app theme changes
+type themedLabel struct {
+	label *walk.Label
+	muted bool
+}
+
+// staleHandshakeThreshold is how old a peer's last handshake may get before it is
+// flagged. WireGuard re-handshakes every two minutes on an active tunnel.
+const staleHandshakeThreshold = 3 * time.Minute
+
+// serviceUnavailableReason explains the disconnected state shown without a tunnel manager
+const serviceUnavailableReason = "The Pangolin service is unavailable. Status will appear once it can be reached."
+
+// synchronize runs a status update on the UI thread. Tests, which have no
+// message loop, replace it.
+var synchronize = func(update func()) { walk.App().Synchronize(update) }
+
 // OLMStatusTab handles the OLM status viewing tab
 type OLMStatusTab struct {
 	tabPage       *walk.TabPage
-	tunnelManager *tunnel.Manager
+	tunnelManager *tunnel.Manager // May be nil until SetTunnelManager is called (protected by mu)
+	configManager *config.ConfigManager
 	quit          chan bool
+	wake          chan struct{} // Signals an immediate refresh when polling resumes
+	reschedule    chan struct{} // Signals that the refresh interval changed
 	mu            sync.Mutex
 
+	// Snapshots pushed by the manager, which replace polling while they arrive
+	stats     *managers.StatsCallback
+	statsFeed tunnel.SnapshotFeed
+
+	refreshIntervalBox *walk.ComboBox
+
+	// Whether the tab is currently on screen (protected by mu)
+	active bool
+
 	// Inner tab widget for Formatted/JSON views
 	innerTabWidget *walk.TabWidget
 	formattedTab   *walk.TabPage
@@ -66,22 +113,38 @@ type OLMStatusTab struct {
 	statusContainer    *walk.Composite
 	peersContainer     *walk.Composite
 	noSitesLabel       *walk.Label
+	throughputLabel    *walk.Label
+	throughputGraph    *walk.CustomWidget
+	peerFilterEdit     *walk.LineEdit
 
 	// Widget references for updating (protected by mu)
 	statusWidgets *statusWidgets
-	peerWidgets   map[int]*peerWidgets // keyed by siteID
+	peerWidgets   map[peerKey]*peerWidgets
 
 	// Current status (protected by mu)
 	currentStatus *tunnel.OLMStatusResponse
+	unavailable   bool // No tunnel manager to ask for the status
+	throughput    throughputHistory
 	displayMode   DisplayMode
+	peerFilter    string
+
+	// Labels to recolor on theme changes (only accessed on the UI thread)
+	themedLabels []themedLabel
+	themeHandle  int
+
+	// The open site detail dialog, if any (only accessed on the UI thread)
+	peerDetail *peerDetailDialog
 }
 
 // NewOLMStatusTab creates a new OLM status tab
-func NewOLMStatusTab(tm *tunnel.Manager) *OLMStatusTab {
+func NewOLMStatusTab(tm *tunnel.Manager, cm *config.ConfigManager) *OLMStatusTab {
 	return &OLMStatusTab{
 		tunnelManager: tm,
+		configManager: cm,
 		quit:          make(chan bool),
-		peerWidgets:   make(map[int]*peerWidgets),
+		wake:          make(chan struct{}, 1),
+		reschedule:    make(chan struct{}, 1),
+		peerWidgets:   make(map[peerKey]*peerWidgets),
 		displayMode:   DisplayModeFormatted, // Default to formatted view
 	}
 }
@@ -96,6 +159,10 @@ func (ost *OLMStatusTab) Create(parent *walk.TabWidget) (*walk.TabPage, error) {
 	ost.tabPage.SetTitle("Status")
 	ost.tabPage.SetLayout(walk.NewVBoxLayout())
 
+	if err := ost.createRefreshIntervalRow(); err != nil {
+		return nil, err
+	}
+
 	// Create inner tab widget for Formatted/JSON views
 	if ost.innerTabWidget, err = walk.NewTabWidget(ost.tabPage); err != nil {
 		return nil, err
@@ -123,6 +190,7 @@ func (ost *OLMStatusTab) Create(parent *walk.TabWidget) (*walk.TabPage, error) {
 		return nil, err
 	}
 	statusSectionLabel.SetText("Connection Status")
+	ost.themeLabel(statusSectionLabel, false)
 	font, err := walk.NewFont("Segoe UI", 10, walk.FontBold)
 	if err == nil {
 		statusSectionLabel.SetFont(font)
@@ -142,23 +210,40 @@ func (ost *OLMStatusTab) Create(parent *walk.TabWidget) (*walk.TabPage, error) {
 		return nil, err
 	}
 
+	if err := ost.createThroughputWidgets(); err != nil {
+		return nil, err
+	}
+
 	// Peers section
 	peersSectionLabel, err := walk.NewLabel(ost.formattedContainer)
 	if err != nil {
 		return nil, err
 	}
 	peersSectionLabel.SetText("Sites")
+	ost.themeLabel(peersSectionLabel, false)
 	if font, err := walk.NewFont("Segoe UI", 10, walk.FontBold); err == nil {
 		peersSectionLabel.SetFont(font)
 	}
 
+	// Filter for the peers list
+	if ost.peerFilterEdit, err = walk.NewLineEdit(ost.formattedContainer); err != nil {
+		return nil, err
+	}
+	ost.peerFilterEdit.SetCueBanner("Filter sites by name or endpoint")
+	ost.peerFilterEdit.TextChanged().Attach(func() {
+		ost.mu.Lock()
+		ost.peerFilter = ost.peerFilterEdit.Text()
+		ost.mu.Unlock()
+		ost.updateUI()
+	})
+
 	// No sites connected label (initially visible)
 	// Place directly in formattedContainer to align with "Sites" header
 	if ost.noSitesLabel, err = walk.NewLabel(ost.formattedContainer); err != nil {
 		return nil, err
 	}
 	ost.noSitesLabel.SetText("No sites connected")
-	ost.noSitesLabel.SetTextColor(walk.RGB(100, 100, 100))
+	ost.themeLabel(ost.noSitesLabel, true)
 
 	// Peers container
 	if ost.peersContainer, err = walk.NewComposite(ost.formattedContainer); err != nil {
@@ -218,12 +303,160 @@ func (ost *OLMStatusTab) Create(parent *walk.TabWidget) (*walk.TabPage, error) {
 		ost.updateUI()
 	})
 
-	// Start OLM status polling
+	ost.applyTheme()
+	ost.themeHandle = theme.Changed().Attach(ost.applyTheme)
+
+	// Take the snapshots the manager pushes, polling only when they stop coming
+	ost.stats = managers.IPCClientRegisterStats(ost.handleStatsSnapshot)
 	go ost.pollOLMStatus()
 
 	return ost.tabPage, nil
 }
 
+// formatRefreshInterval formats a refresh interval for the interval picker
+func formatRefreshInterval(interval time.Duration) string {
+	seconds := int(interval / time.Second)
+	if seconds == 1 {
+		return "1 second"
+	}
+	return fmt.Sprintf("%d seconds", seconds)
+}
+
+// createRefreshIntervalRow creates the picker for how often the status is
+// refreshed, and the button exporting a status report beside it
+func (ost *OLMStatusTab) createRefreshIntervalRow() error {
+	row, err := walk.NewComposite(ost.tabPage)
+	if err != nil {
+		return err
+	}
+	rowLayout := walk.NewHBoxLayout()
+	rowLayout.SetMargins(walk.Margins{})
+	rowLayout.SetSpacing(8)
+	row.SetLayout(rowLayout)
+
+	label, err := walk.NewLabel(row)
+	if err != nil {
+		return err
+	}
+	label.SetText("Refresh every")
+	ost.themeLabel(label, false)
+
+	if ost.refreshIntervalBox, err = walk.NewDropDownBox(row); err != nil {
+		return err
+	}
+	current := ost.refreshInterval()
+	currentIndex := -1
+	choices := make([]string, len(config.StatusRefreshIntervals))
+	for i, interval := range config.StatusRefreshIntervals {
+		choices[i] = formatRefreshInterval(interval)
+		if interval == current {
+			currentIndex = i
+		}
+	}
+	// Keep a hand-edited value selectable rather than silently replacing it
+	if currentIndex == -1 {
+		choices = append(choices, formatRefreshInterval(current))
+		currentIndex = len(choices) - 1
+	}
+	if err := ost.refreshIntervalBox.SetModel(choices); err != nil {
+		return err
+	}
+	ost.refreshIntervalBox.SetCurrentIndex(currentIndex)
+	ost.refreshIntervalBox.CurrentIndexChanged().Attach(ost.onRefreshIntervalChanged)
+
+	walk.NewHSpacer(row)
+
+	exportButton, err := walk.NewPushButton(row)
+	if err != nil {
+		return err
+	}
+	exportButton.SetText("&Export Status Report…")
+	exportButton.Clicked().Attach(ost.onExportReport)
+	return nil
+}
+
+func (ost *OLMStatusTab) onRefreshIntervalChanged() {
+	index := ost.refreshIntervalBox.CurrentIndex()
+	if ost.configManager == nil || index < 0 || index >= len(config.StatusRefreshIntervals) {
+		return
+	}
+	if !ost.configManager.SetStatusRefreshInterval(config.StatusRefreshIntervals[index]) {
+		logger.Error("Failed to save status refresh interval")
+		return
+	}
+	select {
+	case ost.reschedule <- struct{}{}:
+	default:
+	}
+}
+
+// refreshInterval returns the configured status refresh interval
+func (ost *OLMStatusTab) refreshInterval() time.Duration {
+	if ost.configManager == nil {
+		return config.DefaultStatusRefreshInterval
+	}
+	return ost.configManager.GetStatusRefreshInterval()
+}
+
+// nextRefreshDelay returns interval moved by the configured jitter, so polls
+// from several windows or machines don't line up
+func (ost *OLMStatusTab) nextRefreshDelay(interval time.Duration) time.Duration {
+	jitter := float64(config.DefaultStatusRefreshJitterPercent) / 100
+	if ost.configManager != nil {
+		jitter = ost.configManager.GetStatusRefreshJitter()
+	}
+	return jitteredInterval(interval, jitter, rand.Float64())
+}
+
+// jitteredInterval moves interval by up to jitter (a fraction of it) either
+// way; r in [0, 1) picks where in that range it lands
+func jitteredInterval(interval time.Duration, jitter, r float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	offset := time.Duration((2*r - 1) * jitter * float64(interval))
+	return max(interval+offset, time.Millisecond)
+}
+
+// themeLabel colors a label for the current theme and keeps it up to date on theme changes
+func (ost *OLMStatusTab) themeLabel(label *walk.Label, muted bool) {
+	ost.themedLabels = append(ost.themedLabels, themedLabel{label: label, muted: muted})
+	colors := theme.ThemeColors()
+	if muted {
+		label.SetTextColor(colors.Muted)
+	} else {
+		label.SetTextColor(colors.Foreground)
+	}
+}
+
+// applyTheme recolors the tab for the current theme. In light mode the system
+// background is kept; in dark mode the tab is painted dark to match.
+func (ost *OLMStatusTab) applyTheme() {
+	colors := theme.ThemeColors()
+
+	var bg walk.Brush
+	if colors.Dark {
+		if brush, err := walk.NewSolidColorBrush(colors.Background); err == nil {
+			bg = brush
+		}
+	}
+	for _, wnd := range []walk.Window{ost.tabPage, ost.formattedTab, ost.jsonTab} {
+		wnd.SetBackground(bg)
+	}
+
+	for _, tl := range ost.themedLabels {
+		if tl.muted {
+			tl.label.SetTextColor(colors.Muted)
+		} else {
+			tl.label.SetTextColor(colors.Foreground)
+		}
+	}
+	ost.tabPage.Invalidate()
+
+	// Indicators are colored from the current status
+	ost.updateUI()
+}
+
 // createStatusWidgets creates the status widgets once (they will be updated, not recreated)
 func (ost *OLMStatusTab) createStatusWidgets() error {
 	ost.statusWidgets = &statusWidgets{}
@@ -243,6 +476,7 @@ func (ost *OLMStatusTab) createStatusWidgets() error {
 		return err
 	}
 	statusLabel.SetText("Status")
+	ost.themeLabel(statusLabel, false)
 	statusLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
 
 	valueContainer, err := walk.NewComposite(statusRow)
@@ -267,13 +501,73 @@ func (ost *OLMStatusTab) createStatusWidgets() error {
 	if err != nil {
 		return err
 	}
-	ost.statusWidgets.statusText.SetTextColor(walk.RGB(100, 100, 100))
+	ost.themeLabel(ost.statusWidgets.statusText, true)
 	// Initialize to disconnected state
-	ost.statusWidgets.statusIndicator.SetTextColor(walk.RGB(150, 150, 150))
+	ost.statusWidgets.statusIndicator.SetTextColor(theme.ThemeColors().Disabled)
 	ost.statusWidgets.statusText.SetText("Disconnected")
 
 	walk.NewHSpacer(statusRow)
 
+	copyActions, err := ost.addCopyMenu([]*walk.Composite{statusRow, valueContainer},
+		copyMenuItem{text: "Copy Tunnel &Address", value: tunnelAddressValue})
+	if err != nil {
+		return err
+	}
+	ost.statusWidgets.copyAddressAction = copyActions[0]
+
+	// Reason row under Status, e.g. why a registered tunnel isn't connected (initially hidden)
+	ost.statusWidgets.reasonRow, err = walk.NewComposite(ost.statusContainer)
+	if err != nil {
+		return err
+	}
+	reasonRowLayout := walk.NewHBoxLayout()
+	reasonRowLayout.SetMargins(walk.Margins{})
+	reasonRowLayout.SetSpacing(12)
+	ost.statusWidgets.reasonRow.SetLayout(reasonRowLayout)
+
+	reasonIndent, err := walk.NewLabel(ost.statusWidgets.reasonRow)
+	if err != nil {
+		return err
+	}
+	reasonIndent.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+	ost.statusWidgets.reasonLabel, err = walk.NewLabel(ost.statusWidgets.reasonRow)
+	if err != nil {
+		return err
+	}
+	ost.themeLabel(ost.statusWidgets.reasonLabel, true)
+
+	walk.NewHSpacer(ost.statusWidgets.reasonRow)
+	ost.statusWidgets.reasonRow.SetVisible(false)
+
+	// Last connected row, kept from earlier sessions so it shows while disconnected
+	lastConnRow, err := walk.NewComposite(ost.statusContainer)
+	if err != nil {
+		return err
+	}
+	lastConnRowLayout := walk.NewHBoxLayout()
+	lastConnRowLayout.SetMargins(walk.Margins{})
+	lastConnRowLayout.SetSpacing(12)
+	lastConnRow.SetLayout(lastConnRowLayout)
+
+	lastConnLabel, err := walk.NewLabel(lastConnRow)
+	if err != nil {
+		return err
+	}
+	lastConnLabel.SetText("Last Connected")
+	ost.themeLabel(lastConnLabel, false)
+	lastConnLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+	ost.statusWidgets.lastConnLabel, err = walk.NewLabel(lastConnRow)
+	if err != nil {
+		return err
+	}
+	ost.themeLabel(ost.statusWidgets.lastConnLabel, true)
+	ost.statusWidgets.lastConnLabel.SetText(ost.formatLastConnected())
+	ost.updateThroughput()
+
+	walk.NewHSpacer(lastConnRow)
+
 	// Version row (initially hidden)
 	ost.statusWidgets.versionRow, err = walk.NewComposite(ost.statusContainer)
 	if err != nil {
@@ -289,13 +583,14 @@ func (ost *OLMStatusTab) createStatusWidgets() error {
 		return err
 	}
 	versionLabel.SetText("Version")
+	ost.themeLabel(versionLabel, false)
 	versionLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
 
 	ost.statusWidgets.versionLabel, err = walk.NewLabel(ost.statusWidgets.versionRow)
 	if err != nil {
 		return err
 	}
-	ost.statusWidgets.versionLabel.SetTextColor(walk.RGB(100, 100, 100))
+	ost.themeLabel(ost.statusWidgets.versionLabel, true)
 
 	walk.NewHSpacer(ost.statusWidgets.versionRow)
 	ost.statusWidgets.versionRow.SetVisible(false)
@@ -315,13 +610,14 @@ func (ost *OLMStatusTab) createStatusWidgets() error {
 		return err
 	}
 	agentLabel.SetText("Agent")
+	ost.themeLabel(agentLabel, false)
 	agentLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
 
 	ost.statusWidgets.agentLabel, err = walk.NewLabel(ost.statusWidgets.agentRow)
 	if err != nil {
 		return err
 	}
-	ost.statusWidgets.agentLabel.SetTextColor(walk.RGB(100, 100, 100))
+	ost.themeLabel(ost.statusWidgets.agentLabel, true)
 
 	walk.NewHSpacer(ost.statusWidgets.agentRow)
 	ost.statusWidgets.agentRow.SetVisible(false)
@@ -341,20 +637,99 @@ func (ost *OLMStatusTab) createStatusWidgets() error {
 		return err
 	}
 	orgLabel.SetText("Organization")
+	ost.themeLabel(orgLabel, false)
 	orgLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
 
 	ost.statusWidgets.orgLabel, err = walk.NewLabel(ost.statusWidgets.orgRow)
 	if err != nil {
 		return err
 	}
-	ost.statusWidgets.orgLabel.SetTextColor(walk.RGB(100, 100, 100))
+	ost.themeLabel(ost.statusWidgets.orgLabel, true)
 
 	walk.NewHSpacer(ost.statusWidgets.orgRow)
 	ost.statusWidgets.orgRow.SetVisible(false)
 
+	// Device row (initially hidden)
+	ost.statusWidgets.deviceRow, err = walk.NewComposite(ost.statusContainer)
+	if err != nil {
+		return err
+	}
+	deviceRowLayout := walk.NewHBoxLayout()
+	deviceRowLayout.SetMargins(walk.Margins{})
+	deviceRowLayout.SetSpacing(12)
+	ost.statusWidgets.deviceRow.SetLayout(deviceRowLayout)
+
+	deviceLabel, err := walk.NewLabel(ost.statusWidgets.deviceRow)
+	if err != nil {
+		return err
+	}
+	deviceLabel.SetText("Device")
+	ost.themeLabel(deviceLabel, false)
+	deviceLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+	ost.statusWidgets.deviceLabel, err = walk.NewLabel(ost.statusWidgets.deviceRow)
+	if err != nil {
+		return err
+	}
+	ost.themeLabel(ost.statusWidgets.deviceLabel, true)
+
+	walk.NewHSpacer(ost.statusWidgets.deviceRow)
+	ost.statusWidgets.deviceRow.SetVisible(false)
+
 	return nil
 }
 
+// createThroughputWidgets creates the Throughput section with the current rates
+// and a graph of the recent ones
+func (ost *OLMStatusTab) createThroughputWidgets() error {
+	sectionLabel, err := walk.NewLabel(ost.formattedContainer)
+	if err != nil {
+		return err
+	}
+	sectionLabel.SetText("Throughput")
+	ost.themeLabel(sectionLabel, false)
+	if font, err := walk.NewFont("Segoe UI", 10, walk.FontBold); err == nil {
+		sectionLabel.SetFont(font)
+	}
+
+	if ost.throughputLabel, err = walk.NewLabel(ost.formattedContainer); err != nil {
+		return err
+	}
+	ost.throughputLabel.SetText("No data")
+	ost.themeLabel(ost.throughputLabel, true)
+
+	ost.throughputGraph, err = walk.NewCustomWidgetPixels(ost.formattedContainer, 0, func(canvas *walk.Canvas, bounds walk.Rectangle) error {
+		ost.mu.Lock()
+		rx, tx := ost.throughput.samples()
+		ost.mu.Unlock()
+		return drawSparkline(canvas, ost.throughputGraph.ClientBoundsPixels(), rx, tx, theme.ThemeColors())
+	})
+	if err != nil {
+		return err
+	}
+	ost.throughputGraph.SetClearsBackground(true)
+	ost.throughputGraph.SetInvalidatesOnResize(true)
+	ost.throughputGraph.SetMinMaxSize(walk.Size{Width: 0, Height: 60}, walk.Size{Width: 0, Height: 60})
+	return nil
+}
+
+// updateThroughput shows the latest rates and redraws the graph
+func (ost *OLMStatusTab) updateThroughput() {
+	if ost.throughputLabel == nil || ost.throughputGraph == nil {
+		return
+	}
+	ost.mu.Lock()
+	rx, tx, ok := ost.throughput.latest()
+	ost.mu.Unlock()
+
+	if ok {
+		ost.throughputLabel.SetText(fmt.Sprintf("↓ %s   ↑ %s", formatRate(rx), formatRate(tx)))
+	} else {
+		ost.throughputLabel.SetText("No data")
+	}
+	ost.throughputGraph.Invalidate()
+}
+
 // AfterAdd is called after the tab page is added to the tab widget
 func (ost *OLMStatusTab) AfterAdd() {
 	// Nothing to do for OLM status tab
@@ -362,6 +737,12 @@ func (ost *OLMStatusTab) AfterAdd() {
 
 // Cleanup cleans up resources when the tab is closed
 func (ost *OLMStatusTab) Cleanup() {
+	theme.Changed().Detach(ost.themeHandle)
+	if ost.stats != nil {
+		ost.stats.Unregister()
+		ost.stats = nil
+	}
+
 	ost.mu.Lock()
 	defer ost.mu.Unlock()
 
@@ -375,53 +756,254 @@ func (ost *OLMStatusTab) Cleanup() {
 	}
 }
 
+// refreshTicker schedules the status refreshes. It is a *time.Ticker outside tests.
+type refreshTicker interface {
+	Chan() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// timeTicker adapts *time.Ticker to refreshTicker
+type timeTicker struct{ *time.Ticker }
+
+func (t timeTicker) Chan() <-chan time.Time { return t.C }
+
+// pollOLMStatus refreshes the status on the refresh interval until the tab is
+// closed. It keeps running without a tunnel manager, so the tab recovers once one is set.
+// Scheduled refreshes are skipped while the manager pushes snapshots.
 func (ost *OLMStatusTab) pollOLMStatus() {
-	if ost.tunnelManager == nil {
-		// Just set status to nil and show disconnected state
-		ost.mu.Lock()
-		ost.currentStatus = nil
-		ost.mu.Unlock()
-		walk.App().Synchronize(func() {
-			ost.updateUI()
-		})
-		return
-	}
+	ost.poll(timeTicker{time.NewTicker(ost.refreshInterval())})
+}
 
-	ticker := time.NewTicker(1 * time.Second)
+// poll runs the refresh loop on ticker, re-reading the interval whenever it changes
+func (ost *OLMStatusTab) poll(ticker refreshTicker) {
+	interval := ost.refreshInterval()
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ost.quit:
 			return
-		case <-ticker.C:
-			status, err := ost.tunnelManager.GetOLMStatus()
-			if err != nil {
-				// Show disconnected state instead of error message
-				ost.mu.Lock()
-				// Only update if status changed from non-nil to nil
-				if ost.currentStatus != nil {
-					ost.currentStatus = nil
-					ost.mu.Unlock()
-					walk.App().Synchronize(func() {
-						ost.updateUI()
-					})
-				} else {
-					ost.mu.Unlock()
-				}
+		case <-ost.reschedule:
+			interval = ost.refreshInterval()
+			ticker.Reset(ost.nextRefreshDelay(interval))
+		case <-ost.wake:
+			ost.refreshStatus()
+			// Count the next interval from this refresh
+			ticker.Reset(ost.nextRefreshDelay(interval))
+		case <-ticker.Chan():
+			ticker.Reset(ost.nextRefreshDelay(interval))
+			if !ost.pollDue(time.Now()) {
 				continue
 			}
+			ost.refreshStatus()
+		}
+	}
+}
 
-			// Update current status
-			ost.mu.Lock()
-			ost.currentStatus = status
-			ost.mu.Unlock()
+// SetTunnelManager sets the tunnel manager the status is read from and refreshes
+// the tab. A nil manager shows the tab as disconnected with the service unavailable.
+func (ost *OLMStatusTab) SetTunnelManager(tm *tunnel.Manager) {
+	ost.mu.Lock()
+	ost.tunnelManager = tm
+	ost.mu.Unlock()
+
+	select {
+	case ost.wake <- struct{}{}:
+	default:
+	}
+}
+
+// manager returns the current tunnel manager, which may be nil
+func (ost *OLMStatusTab) manager() *tunnel.Manager {
+	ost.mu.Lock()
+	defer ost.mu.Unlock()
+	return ost.tunnelManager
+}
+
+// SetActive tells the tab whether it is on screen. Polling is paused while the tab
+// is inactive, and becoming active triggers an immediate refresh.
+func (ost *OLMStatusTab) SetActive(active bool) {
+	ost.mu.Lock()
+	wasActive := ost.active
+	ost.active = active
+	ost.mu.Unlock()
+
+	if active && !wasActive {
+		select {
+		case ost.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// pollDue reports whether a scheduled refresh at now should fetch the status. The
+// round trip is skipped while nobody can see the result, or while pushed
+// snapshots keep the tab current.
+func (ost *OLMStatusTab) pollDue(now time.Time) bool {
+	return ost.isActive() && ost.statsFeed.Stale(now)
+}
+
+func (ost *OLMStatusTab) isActive() bool {
+	ost.mu.Lock()
+	defer ost.mu.Unlock()
+	return ost.active
+}
+
+// refreshStatus fetches the OLM status once and updates the UI
+func (ost *OLMStatusTab) refreshStatus() {
+	tm := ost.manager()
+	if tm == nil {
+		ost.showUnavailable()
+		return
+	}
 
-			// Update UI
-			walk.App().Synchronize(func() {
+	status, err := tm.GetOLMStatus()
+	if err != nil {
+		// Show disconnected state instead of error message
+		ost.mu.Lock()
+		// Only update if status changed from non-nil to nil
+		if ost.currentStatus != nil || ost.unavailable {
+			ost.currentStatus = nil
+			ost.unavailable = false
+			ost.throughput.reset()
+			ost.mu.Unlock()
+			synchronize(func() {
 				ost.updateUI()
 			})
+		} else {
+			ost.mu.Unlock()
+		}
+		return
+	}
+
+	// Sample traffic totals for the throughput graph. They are only readable
+	// while the tunnel is Running; a reconnect pauses the graph rather than clearing it.
+	var traffic *tunnel.Stats
+	if stats, err := tm.Stats(); err == nil {
+		traffic = &stats
+	}
+	ost.showStatus(status, traffic)
+}
+
+// handleStatsSnapshot shows a snapshot pushed by the manager in place of a
+// polled status
+func (ost *OLMStatusTab) handleStatsSnapshot(snapshot managers.StatsSnapshot) {
+	ost.statsFeed.Received(time.Now())
+	tm := ost.manager()
+	if tm == nil || !ost.isActive() {
+		return
+	}
+
+	var traffic *tunnel.Stats
+	if snapshot.HaveTraffic && tm.State() == tunnel.StateRunning {
+		traffic = &snapshot.Traffic
+	}
+	ost.showStatus(tm.StatusFromSnapshot(snapshot), traffic)
+}
+
+// showStatus makes status current, adding traffic, if not nil, to the
+// throughput graph, and updates the UI
+func (ost *OLMStatusTab) showStatus(status *tunnel.OLMStatusResponse, traffic *tunnel.Stats) {
+	unchanged := ost.recordStatus(status, traffic, time.Now())
+
+	// Update UI, skipping the full update when the status is the same as last time
+	synchronize(func() {
+		if unchanged {
+			ost.updateTimeDependent()
+			return
+		}
+		ost.updateUI()
+	})
+}
+
+// recordStatus makes status the current one and samples traffic, if known,
+// into the throughput history. It reports whether status renders the same as
+// what is already shown, so the full update can be skipped.
+func (ost *OLMStatusTab) recordStatus(status *tunnel.OLMStatusResponse, traffic *tunnel.Stats, now time.Time) (unchanged bool) {
+	ost.mu.Lock()
+	defer ost.mu.Unlock()
+
+	unchanged = statusEqual(ost.currentStatus, status) && !ost.unavailable
+	ost.currentStatus = status
+	ost.unavailable = false
+	if traffic != nil {
+		ost.throughput.add(*traffic, now)
+	}
+	return unchanged
+}
+
+// showUnavailable switches the tab to the persistent disconnected state shown
+// while there is no tunnel manager. The UI is only updated on the first call.
+func (ost *OLMStatusTab) showUnavailable() {
+	ost.mu.Lock()
+	if ost.unavailable && ost.currentStatus == nil {
+		ost.mu.Unlock()
+		return
+	}
+	ost.currentStatus = nil
+	ost.unavailable = true
+	ost.throughput.reset()
+	ost.mu.Unlock()
+
+	synchronize(func() {
+		ost.updateUI()
+	})
+}
+
+// statusEqual reports whether two statuses would render the same. They are
+// compared in their JSON form, the same form the JSON view shows.
+func statusEqual(a, b *tunnel.OLMStatusResponse) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
+
+// updateTimeDependent refreshes what changes with time alone, the relative
+// times and the throughput, when the status itself hasn't changed
+func (ost *OLMStatusTab) updateTimeDependent() {
+	ost.refreshPeerDetail()
+	if ost.statusWidgets == nil || ost.innerTabWidget == nil || ost.innerTabWidget.CurrentIndex() != 0 {
+		return
+	}
+	ost.mu.Lock()
+	status := ost.currentStatus
+	ost.mu.Unlock()
+
+	ost.statusWidgets.lastConnLabel.SetText(ost.formatLastConnected())
+	ost.updateThroughput()
+	if status == nil {
+		return
+	}
+
+	now := time.Now()
+	colors := theme.ThemeColors()
+	for siteID, peer := range status.PeerStatuses {
+		if peer == nil {
+			continue
 		}
+		ost.mu.Lock()
+		pw := ost.peerWidgets[keyForPeer(siteID, peer)]
+		ost.mu.Unlock()
+		if pw == nil {
+			continue
+		}
+		if pw.handshakeLabel != nil {
+			pw.handshakeLabel.SetText(formatHandshakeAge(peer.LastActivity(), now))
+		}
+		if pw.indicator != nil {
+			setTextColor(pw.indicator, peerIndicatorColor(peer.Connected, peer.LastActivity(), now, colors))
+		}
+	}
+}
+
+// setTextColor changes a label's color only if it differs, as setting it always repaints
+func setTextColor(label *walk.Label, color walk.Color) {
+	if label.TextColor() != color {
+		label.SetTextColor(color)
 	}
 }
 
@@ -432,8 +1014,14 @@ func (ost *OLMStatusTab) updateUI() {
 			logger.Error("OLM status tab panic in updateUI: %v\n%s", r, debug.Stack())
 		}
 	}()
+	// Nothing to update before Create has built the widgets
+	if ost.innerTabWidget == nil {
+		return
+	}
+
 	ost.mu.Lock()
 	status := ost.currentStatus
+	unavailable := ost.unavailable
 	ost.mu.Unlock()
 
 	// Check which tab is actually visible (outside lock)
@@ -442,19 +1030,27 @@ func (ost *OLMStatusTab) updateUI() {
 	// Only update the active tab
 	if currentIndex == 0 {
 		// Formatted tab is active
-		ost.updateFormattedView(status)
+		ost.updateFormattedView(status, unavailable)
 	} else {
 		// JSON tab is active
-		ost.updateJSONView(status)
+		ost.updateJSONView(status, unavailable)
 	}
+	ost.refreshPeerDetail()
 }
 
 // updateJSONView updates the JSON view
-func (ost *OLMStatusTab) updateJSONView(status *tunnel.OLMStatusResponse) {
+func (ost *OLMStatusTab) updateJSONView(status *tunnel.OLMStatusResponse, unavailable bool) {
 	// No need to set visibility - tabs handle that automatically
+	if ost.jsonEdit == nil {
+		return
+	}
 
 	if status == nil {
-		ost.jsonEdit.SetText("Disconnected")
+		if unavailable {
+			ost.jsonEdit.SetText("Disconnected (service unavailable)")
+		} else {
+			ost.jsonEdit.SetText("Disconnected")
+		}
 		return
 	}
 
@@ -471,32 +1067,48 @@ func (ost *OLMStatusTab) updateJSONView(status *tunnel.OLMStatusResponse) {
 }
 
 // updateFormattedView updates the formatted view by updating existing widgets
-func (ost *OLMStatusTab) updateFormattedView(status *tunnel.OLMStatusResponse) {
+func (ost *OLMStatusTab) updateFormattedView(status *tunnel.OLMStatusResponse, unavailable bool) {
 	// No need to set visibility - tabs handle that automatically
 
 	if ost.statusWidgets == nil {
 		return
 	}
 
+	ost.statusWidgets.lastConnLabel.SetText(ost.formatLastConnected())
+
 	if status == nil {
 		// Show disconnected state
-		ost.statusWidgets.statusIndicator.SetTextColor(walk.RGB(150, 150, 150))
-		ost.statusWidgets.statusText.SetText("Disconnected")
+		setTextColor(ost.statusWidgets.statusIndicator, theme.ThemeColors().Disabled)
+		ost.statusWidgets.statusText.SetText(tunnel.SummarizeStatus(nil).Title)
+		if unavailable {
+			ost.statusWidgets.reasonLabel.SetText(serviceUnavailableReason)
+			ost.statusWidgets.reasonRow.SetVisible(true)
+		} else {
+			ost.statusWidgets.reasonRow.SetVisible(false)
+		}
 		ost.statusWidgets.versionRow.SetVisible(false)
 		ost.statusWidgets.agentRow.SetVisible(false)
 		ost.statusWidgets.orgRow.SetVisible(false)
+		ost.statusWidgets.copyAddressAction.SetEnabled(false)
 		ost.updatePeersList(status)
 		return
 	}
 
 	// Update status
-	connected := status.Connected
-	if connected {
-		ost.statusWidgets.statusIndicator.SetTextColor(walk.RGB(0, 200, 0))
+	colors := theme.ThemeColors()
+	if status.Connected {
+		setTextColor(ost.statusWidgets.statusIndicator, colors.Success)
 	} else {
-		ost.statusWidgets.statusIndicator.SetTextColor(walk.RGB(150, 150, 150))
+		setTextColor(ost.statusWidgets.statusIndicator, colors.Disabled)
+	}
+	summary := tunnel.SummarizeStatus(status)
+	ost.statusWidgets.statusText.SetText(summary.Title)
+	if summary.Detail != "" {
+		ost.statusWidgets.reasonLabel.SetText(summary.Detail)
+		ost.statusWidgets.reasonRow.SetVisible(true)
+	} else {
+		ost.statusWidgets.reasonRow.SetVisible(false)
 	}
-	ost.statusWidgets.statusText.SetText(ost.formatStatus(status.Connected, status.Registered))
 
 	// Update version
 	if status.Version != "" {
@@ -522,21 +1134,42 @@ func (ost *OLMStatusTab) updateFormattedView(status *tunnel.OLMStatusResponse) {
 		ost.statusWidgets.orgRow.SetVisible(false)
 	}
 
+	// Update device
+	deviceName := ""
+	if ost.tunnelManager != nil {
+		deviceName = ost.tunnelManager.DeviceName()
+	}
+	if deviceName != "" {
+		ost.statusWidgets.deviceLabel.SetText(deviceName)
+		ost.statusWidgets.deviceRow.SetVisible(true)
+	} else {
+		ost.statusWidgets.deviceRow.SetVisible(false)
+	}
+
+	ost.statusWidgets.copyAddressAction.SetEnabled(tunnelAddressValue(status) != "")
+
 	// Update peers list
 	ost.updatePeersList(status)
 }
 
-// formatStatus formats the connection status text
-func (ost *OLMStatusTab) formatStatus(connected, registered bool) string {
-	if connected {
-		return "Connected"
+// formatLastConnected returns the Last Connected value, without the label's own wording
+func (ost *OLMStatusTab) formatLastConnected() string {
+	if ost.configManager == nil {
+		return "Unknown"
+	}
+	last := ost.configManager.GetLastConnectedAt()
+	if last.IsZero() {
+		return "Never"
 	}
-	return "Disconnected"
+	return fmt.Sprintf("%s (%s)", last.Local().Format("Jan 2, 3:04 PM"), formatAge(last, time.Now()))
 }
 
 // updatePeersList updates the peers container, reusing existing widgets when possible
 func (ost *OLMStatusTab) updatePeersList(status *tunnel.OLMStatusResponse) {
 	if status == nil || status.PeerStatuses == nil || len(status.PeerStatuses) == 0 {
+		if ost.noSitesLabel != nil {
+			ost.noSitesLabel.SetText("No sites connected")
+		}
 		ost.mu.Lock()
 		// Hide all peer widgets
 		for _, pw := range ost.peerWidgets {
@@ -552,94 +1185,231 @@ func (ost *OLMStatusTab) updatePeersList(status *tunnel.OLMStatusResponse) {
 		return
 	}
 
-	// Hide "No sites connected" message when there are peers
-	if ost.noSitesLabel != nil {
-		ost.noSitesLabel.SetVisible(false)
-	}
+	ost.mu.Lock()
+	filter := ost.peerFilter
+	ost.mu.Unlock()
 
-	// Track which peers we've seen and which need to be created
-	seenPeers := make(map[int]bool)
-	peersToCreate := make([]struct {
-		siteID    int
-		name      string
-		endpoint  string
-		connected bool
-	}, 0)
+	entries := sortedPeers(status.PeerStatuses)
+	keys := make([]peerKey, len(entries))
+	for i, entry := range entries {
+		keys[i] = keyForPeer(entry.siteID, entry.peer)
+	}
 
+	// Match the peers to the existing rows, so a row follows its peer even if the
+	// peer's site ID changes, and never shows another peer that reuses the ID
 	ost.mu.Lock()
-	// First pass: update existing widgets and identify new ones
-	for siteID, peer := range status.PeerStatuses {
-		seenPeers[siteID] = true
+	existing := make([]peerKey, 0, len(ost.peerWidgets))
+	for key := range ost.peerWidgets {
+		existing = append(existing, key)
+	}
+	previous := ost.peerWidgets
+	ost.peerWidgets = make(map[peerKey]*peerWidgets, len(entries))
+	for key, previousKey := range reconcilePeerKeys(existing, keys) {
+		pw := previous[previousKey]
+		pw.siteID = key.siteID
+		ost.peerWidgets[key] = pw
+		delete(previous, previousKey)
+	}
+	ost.mu.Unlock()
+
+	// The rows left over belong to peers that are gone
+	for _, pw := range previous {
+		if pw.row != nil {
+			pw.row.Dispose()
+		}
+	}
+
+	matchingPeers := 0
+	now := time.Now()
+	colors := theme.ThemeColors()
 
-		pw, exists := ost.peerWidgets[siteID]
+	// Walk the peers in display order so rows can be laid out to match
+	for i, entry := range entries {
+		peer := entry.peer
+
+		ost.mu.Lock()
+		pw, exists := ost.peerWidgets[keys[i]]
+		ost.mu.Unlock()
 		if !exists {
-			// Mark for creation (outside lock)
-			peersToCreate = append(peersToCreate, struct {
-				siteID    int
-				name      string
-				endpoint  string
-				connected bool
-			}{siteID, peer.SiteName, peer.Endpoint, peer.Connected})
-		} else {
-			// Update existing peer widget
-			if pw.nameLabel != nil {
-				name := peer.SiteName
-				if name == "" {
-					name = "Unknown"
-				}
-				pw.nameLabel.SetText(name)
-			}
-			if pw.endpointLabel != nil {
-				if peer.Endpoint != "" {
-					pw.endpointLabel.SetText(peer.Endpoint)
-					pw.endpointLabel.SetVisible(true)
-				} else {
-					pw.endpointLabel.SetVisible(false)
-				}
-			}
-			if pw.indicator != nil {
-				if peer.Connected {
-					pw.indicator.SetTextColor(walk.RGB(0, 200, 0))
-				} else {
-					pw.indicator.SetTextColor(walk.RGB(150, 150, 150))
-				}
-			}
-			if pw.statusLabel != nil {
-				if peer.Connected {
-					pw.statusLabel.SetText("Connected")
-				} else {
-					pw.statusLabel.SetText("Disconnected")
-				}
-			}
-			if pw.row != nil {
-				pw.row.SetVisible(true)
+			if err := ost.createPeerWidget(keys[i], peer.SiteName, peer.Endpoint, peer.Connected); err != nil {
+				continue
 			}
+			ost.mu.Lock()
+			pw = ost.peerWidgets[keys[i]]
+			ost.mu.Unlock()
 		}
+		if pw == nil || pw.row == nil {
+			continue
+		}
+		ost.updatePeerWidget(pw, peer, now, colors)
+
+		ost.placePeerRow(pw.row, i)
+		matches := peerMatchesFilter(peer.SiteName, peer.Endpoint, filter)
+		if matches {
+			matchingPeers++
+		}
+		pw.row.SetVisible(matches)
 	}
 
-	// Hide peers that are no longer present
-	for siteID, pw := range ost.peerWidgets {
-		if !seenPeers[siteID] && pw.row != nil {
-			pw.row.SetVisible(false)
+	// Explain an empty list when the filter hides every peer
+	if ost.noSitesLabel != nil {
+		if matchingPeers == 0 {
+			ost.noSitesLabel.SetText("No sites match the filter")
 		}
+		ost.noSitesLabel.SetVisible(matchingPeers == 0)
 	}
-	ost.mu.Unlock()
+}
 
-	// Create new peer widgets (outside lock, as it creates UI widgets)
-	for _, peerInfo := range peersToCreate {
-		if err := ost.createPeerWidget(peerInfo.siteID, peerInfo.name, peerInfo.endpoint, peerInfo.connected); err != nil {
-			continue
+// updatePeerWidget refreshes a peer row from the latest status
+func (ost *OLMStatusTab) updatePeerWidget(pw *peerWidgets, peer *tunnel.OLMPeerStatus, now time.Time, colors theme.Colors) {
+	if pw.nameLabel != nil {
+		name := peer.SiteName
+		if name == "" {
+			name = "Unknown"
+		}
+		pw.nameLabel.SetText(name)
+	}
+	if pw.endpointLabel != nil {
+		if peer.Endpoint != "" {
+			pw.endpointLabel.SetText(peer.Endpoint)
+			pw.endpointLabel.SetVisible(true)
+		} else {
+			pw.endpointLabel.SetVisible(false)
 		}
 	}
+	if pw.handshakeLabel != nil {
+		pw.handshakeLabel.SetText(formatHandshakeAge(peer.LastActivity(), now))
+	}
+	if pw.indicator != nil {
+		setTextColor(pw.indicator, peerIndicatorColor(peer.Connected, peer.LastActivity(), now, colors))
+	}
+	if pw.statusLabel != nil {
+		if peer.Connected {
+			pw.statusLabel.SetText("Connected")
+		} else {
+			pw.statusLabel.SetText("Disconnected")
+		}
+	}
+	if pw.copyEndpointAction != nil {
+		pw.copyEndpointAction.SetEnabled(peer.Endpoint != "")
+		pw.copyAddressAction.SetEnabled(peer.PeerIP != "")
+	}
+}
+
+// formatHandshakeAge describes how long ago a peer last completed a handshake
+func formatHandshakeAge(last, now time.Time) string {
+	if last.IsZero() {
+		return "Never seen"
+	}
+	return "Last seen " + formatAge(last, now)
+}
+
+// FormatLastConnected describes how long ago the tunnel last came up, e.g. "Last connected 2h ago"
+func FormatLastConnected(last, now time.Time) string {
+	if last.IsZero() {
+		return "Never connected"
+	}
+	return "Last connected " + formatAge(last, now)
+}
+
+// formatAge describes how long before now t was, e.g. "5m ago"
+func formatAge(t, now time.Time) string {
+	age := now.Sub(t)
+	switch {
+	case age < time.Second:
+		return "just now"
+	case age < time.Minute:
+		return fmt.Sprintf("%ds ago", int(age/time.Second))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(age/(24*time.Hour)))
+	}
+}
+
+// peerIndicatorColor returns green for a connected peer with a recent handshake,
+// amber for a connected peer whose handshake is missing or stale, and grey otherwise
+func peerIndicatorColor(connected bool, last, now time.Time, colors theme.Colors) walk.Color {
+	if !connected {
+		return colors.Disabled
+	}
+	if last.IsZero() || now.Sub(last) > staleHandshakeThreshold {
+		return colors.Warning
+	}
+	return colors.Success
+}
+
+// placePeerRow moves a peer row to the given position in the peers container
+func (ost *OLMStatusTab) placePeerRow(row *walk.Composite, index int) {
+	children := ost.peersContainer.Children()
+	current := children.Index(row)
+	if current == index || current == -1 || index >= children.Len() {
+		return
+	}
+	if err := children.Remove(row); err != nil {
+		logger.Error("Failed to reorder site row: %v", err)
+		return
+	}
+	if err := children.Insert(index, row); err != nil {
+		logger.Error("Failed to reorder site row: %v", err)
+	}
+}
+
+// peerEntry pairs a peer status with the site ID it is keyed by
+type peerEntry struct {
+	siteID int
+	peer   *tunnel.OLMPeerStatus
+}
+
+// sortedPeers returns the peers in display order (see peerLess), skipping nil entries
+func sortedPeers(peers map[int]*tunnel.OLMPeerStatus) []peerEntry {
+	entries := make([]peerEntry, 0, len(peers))
+	for siteID, peer := range peers {
+		if peer != nil {
+			entries = append(entries, peerEntry{siteID: siteID, peer: peer})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return peerLess(entries[i].peer.SiteName, entries[i].siteID, entries[j].peer.SiteName, entries[j].siteID)
+	})
+	return entries
+}
+
+// peerLess orders peers by name, ignoring case, with unnamed peers last and
+// site ID breaking ties so the order is stable between refreshes
+func peerLess(aName string, aID int, bName string, bID int) bool {
+	if (aName == "") != (bName == "") {
+		return bName == ""
+	}
+	if c := strings.Compare(strings.ToLower(aName), strings.ToLower(bName)); c != 0 {
+		return c < 0
+	}
+	if aName != bName {
+		return aName < bName
+	}
+	return aID < bID
+}
+
+// peerMatchesFilter reports whether a peer's name or endpoint contains the filter
+// text, ignoring case. An empty filter matches every peer.
+func peerMatchesFilter(name, endpoint, filter string) bool {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(name), filter) ||
+		strings.Contains(strings.ToLower(endpoint), filter)
 }
 
 // createPeerWidget creates a new peer widget row
-func (ost *OLMStatusTab) createPeerWidget(siteID int, name, endpoint string, connected bool) error {
-	pw := &peerWidgets{}
+func (ost *OLMStatusTab) createPeerWidget(key peerKey, name, endpoint string, connected bool) error {
+	pw := &peerWidgets{siteID: key.siteID}
 
 	ost.mu.Lock()
 	// Check if it was already created by another goroutine
-	if _, exists := ost.peerWidgets[siteID]; exists {
+	if _, exists := ost.peerWidgets[key]; exists {
 		ost.mu.Unlock()
 		return nil
 	}
@@ -675,16 +1445,23 @@ func (ost *OLMStatusTab) createPeerWidget(siteID int, name, endpoint string, con
 		name = "Unknown"
 	}
 	pw.nameLabel.SetText(name)
+	ost.themeLabel(pw.nameLabel, false)
 
 	// Endpoint (if available)
 	if endpoint != "" {
 		pw.endpointLabel, err = walk.NewLabel(nameContainer)
 		if err == nil {
 			pw.endpointLabel.SetText(endpoint)
-			pw.endpointLabel.SetTextColor(walk.RGB(100, 100, 100))
+			ost.themeLabel(pw.endpointLabel, true)
 		}
 	}
 
+	// Last handshake age, filled in on every refresh
+	pw.handshakeLabel, err = walk.NewLabel(nameContainer)
+	if err == nil {
+		ost.themeLabel(pw.handshakeLabel, true)
+	}
+
 	// Status indicator and text - aligned with value column (after 200px label + 12px spacing)
 	statusContainer, err := walk.NewComposite(row)
 	if err != nil {
@@ -702,9 +1479,9 @@ func (ost *OLMStatusTab) createPeerWidget(siteID int, name, endpoint string, con
 	}
 	pw.indicator.SetText("●")
 	if connected {
-		pw.indicator.SetTextColor(walk.RGB(0, 200, 0))
+		pw.indicator.SetTextColor(theme.ThemeColors().Success)
 	} else {
-		pw.indicator.SetTextColor(walk.RGB(150, 150, 150))
+		pw.indicator.SetTextColor(theme.ThemeColors().Disabled)
 	}
 	pw.indicator.SetMinMaxSize(walk.Size{Width: 12, Height: 12}, walk.Size{Width: 12, Height: 12})
 
@@ -718,13 +1495,40 @@ func (ost *OLMStatusTab) createPeerWidget(siteID int, name, endpoint string, con
 		return err
 	}
 	pw.statusLabel.SetText(statusText)
-	pw.statusLabel.SetTextColor(walk.RGB(100, 100, 100))
+	ost.themeLabel(pw.statusLabel, true)
 
 	// Add spacer to match status row structure
 	walk.NewHSpacer(row)
 
+	// Right-clicking the row offers to copy the site's endpoint and address
+	copyActions, err := ost.addCopyMenu([]*walk.Composite{row, nameContainer, statusContainer},
+		copyMenuItem{text: "Copy &Endpoint", value: func(status *tunnel.OLMStatusResponse) string {
+			endpoint, _ := peerCopyValues(status, pw.siteID)
+			return endpoint
+		}},
+		copyMenuItem{text: "Copy &Address", value: func(status *tunnel.OLMStatusResponse) string {
+			_, address := peerCopyValues(status, pw.siteID)
+			return address
+		}})
+	if err != nil {
+		return err
+	}
+	pw.copyEndpointAction, pw.copyAddressAction = copyActions[0], copyActions[1]
+
+	// Clicking anywhere on the row opens the site's details. Labels pass their
+	// clicks through to the composite they sit in.
+	for _, c := range []*walk.Composite{row, nameContainer, statusContainer} {
+		c.SetCursor(walk.CursorHand())
+		c.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
+			if button == walk.LeftButton {
+				// The row follows its peer, whose site ID may have changed since
+				ost.showPeerDetail(pw.siteID)
+			}
+		})
+	}
+
 	ost.mu.Lock()
-	ost.peerWidgets[siteID] = pw
+	ost.peerWidgets[key] = pw
 	ost.mu.Unlock()
 	return nil
 }
@@ -0,0 +1,571 @@
+//go:build windows
+
+package preferences
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/tunnel"
+	"github.com/fosrl/windows/ui/theme"
+
+	"github.com/tailscale/walk"
+)
+
+// fakeTicker hands out ticks on demand and records the periods it is reset to
+type fakeTicker struct {
+	c       chan time.Time
+	resets  chan time.Duration
+	stopped chan struct{}
+}
+
+func newFakeTicker() *fakeTicker {
+	return &fakeTicker{
+		c:       make(chan time.Time),
+		resets:  make(chan time.Duration, 16),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (f *fakeTicker) Chan() <-chan time.Time { return f.c }
+func (f *fakeTicker) Reset(d time.Duration)  { f.resets <- d }
+func (f *fakeTicker) Stop()                  { close(f.stopped) }
+
+// nextReset returns the period the ticker was reset to next
+func (f *fakeTicker) nextReset(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-f.resets:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the ticker to be reset")
+		return 0
+	}
+}
+
+// newTestConfigManager returns a ConfigManager reading contents from a fresh
+// config file
+func newTestConfigManager(t *testing.T, contents string) *config.ConfigManager {
+	t.Helper()
+	appData := t.TempDir()
+	t.Setenv("LOCALAPPDATA", appData)
+	dir := filepath.Join(appData, config.AppName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, config.ConfigFileName), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return config.NewConfigManager()
+}
+
+// runUpdatesInline runs the tab's UI updates where they are made, as tests
+// have no message loop to run them on
+func runUpdatesInline(t *testing.T) {
+	t.Helper()
+	saved := synchronize
+	synchronize = func(update func()) { update() }
+	t.Cleanup(func() { synchronize = saved })
+}
+
+func TestRefreshInterval(t *testing.T) {
+	tests := []struct {
+		config string
+		want   time.Duration
+	}{
+		{config: `{}`, want: config.DefaultStatusRefreshInterval},
+		{config: `{"statusRefreshIntervalSeconds": 10}`, want: 10 * time.Second},
+		{config: `{"statusRefreshIntervalSeconds": 0}`, want: config.DefaultStatusRefreshInterval},
+		{config: `{"statusRefreshIntervalSeconds": -5}`, want: config.DefaultStatusRefreshInterval},
+	}
+
+	for _, tt := range tests {
+		ost := &OLMStatusTab{configManager: newTestConfigManager(t, tt.config)}
+		if got := ost.refreshInterval(); got != tt.want {
+			t.Errorf("refreshInterval() with %s = %v, want %v", tt.config, got, tt.want)
+		}
+	}
+	if got := (&OLMStatusTab{}).refreshInterval(); got != config.DefaultStatusRefreshInterval {
+		t.Errorf("refreshInterval() without a config = %v, want %v", got, config.DefaultStatusRefreshInterval)
+	}
+}
+
+func TestPollFollowsIntervalChanges(t *testing.T) {
+	cm := newTestConfigManager(t, `{"statusRefreshJitterPercent": 0}`)
+	ost := &OLMStatusTab{
+		configManager: cm,
+		quit:          make(chan bool),
+		wake:          make(chan struct{}, 1),
+		reschedule:    make(chan struct{}, 1),
+	}
+	ticker := newFakeTicker()
+	go ost.poll(ticker)
+
+	// The tab is hidden, so ticks only schedule the next one
+	ticker.c <- time.Now()
+	if got := ticker.nextReset(t); got != config.DefaultStatusRefreshInterval {
+		t.Errorf("period = %v, want %v", got, config.DefaultStatusRefreshInterval)
+	}
+
+	if !cm.SetStatusRefreshInterval(30 * time.Second) {
+		t.Fatal("SetStatusRefreshInterval() failed")
+	}
+	ost.reschedule <- struct{}{}
+	if got := ticker.nextReset(t); got != 30*time.Second {
+		t.Errorf("period after the change = %v, want 30s", got)
+	}
+	ticker.c <- time.Now()
+	if got := ticker.nextReset(t); got != 30*time.Second {
+		t.Errorf("period of the next tick = %v, want 30s", got)
+	}
+
+	close(ost.quit)
+	select {
+	case <-ticker.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker not stopped after the tab closed")
+	}
+}
+
+func TestJitteredInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		jitter   float64
+		r        float64
+		want     time.Duration
+	}{
+		{name: "disabled", interval: 10 * time.Second, r: 0.9, want: 10 * time.Second},
+		{name: "negative disables", interval: 10 * time.Second, jitter: -0.1, r: 0.9, want: 10 * time.Second},
+		{name: "earliest", interval: 10 * time.Second, jitter: 0.1, r: 0, want: 9 * time.Second},
+		{name: "middle", interval: 10 * time.Second, jitter: 0.1, r: 0.5, want: 10 * time.Second},
+		{name: "towards the latest", interval: 10 * time.Second, jitter: 0.1, r: 0.75, want: 10500 * time.Millisecond},
+		{name: "at the cap", interval: time.Second, jitter: 0.5, r: 0, want: 500 * time.Millisecond},
+		{name: "never zero", interval: time.Millisecond, jitter: 1, r: 0, want: time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jitteredInterval(tt.interval, tt.jitter, tt.r); got != tt.want {
+				t.Errorf("jitteredInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextRefreshDelayStaysInRange(t *testing.T) {
+	ost := &OLMStatusTab{configManager: newTestConfigManager(t, `{"statusRefreshJitterPercent": 20}`)}
+	for i := 0; i < 100; i++ {
+		if got := ost.nextRefreshDelay(10 * time.Second); got < 8*time.Second || got > 12*time.Second {
+			t.Fatalf("nextRefreshDelay() = %v, want within 20%% of 10s", got)
+		}
+	}
+}
+
+func TestStatusTabWithoutManager(t *testing.T) {
+	runUpdatesInline(t)
+	ost := NewOLMStatusTab(nil, nil)
+
+	// Before Create has built the widgets every update is a no-op
+	ost.updateUI()
+	ost.updateTimeDependent()
+	ost.updateFormattedView(nil, true)
+	ost.updateJSONView(nil, true)
+	ost.updateThroughput()
+	if got := ost.formatLastConnected(); got != "Unknown" {
+		t.Errorf("formatLastConnected() = %q, want %q", got, "Unknown")
+	}
+
+	for i := 0; i < 2; i++ {
+		ost.refreshStatus()
+		ost.mu.Lock()
+		unavailable, status := ost.unavailable, ost.currentStatus
+		ost.mu.Unlock()
+		if !unavailable || status != nil {
+			t.Fatalf("refresh %d: unavailable = %v, status = %v, want the service unavailable state", i+1, unavailable, status)
+		}
+		ost.updateUI()
+	}
+
+	// A status arriving later replaces the unavailable state
+	if ost.recordStatus(&tunnel.OLMStatusResponse{Connected: true}, nil, time.Now()) {
+		t.Error("the first status after the service was unavailable was skipped")
+	}
+	if ost.unavailable {
+		t.Error("still unavailable after a status arrived")
+	}
+}
+
+// Without a manager the tab keeps polling, so it recovers once one is set
+func TestPollWithoutManager(t *testing.T) {
+	runUpdatesInline(t)
+	ost := NewOLMStatusTab(nil, newTestConfigManager(t, `{"statusRefreshJitterPercent": 0}`))
+	ticker := newFakeTicker()
+	go ost.poll(ticker)
+
+	ost.SetActive(true)
+	if got := ticker.nextReset(t); got != config.DefaultStatusRefreshInterval {
+		t.Errorf("period = %v, want %v", got, config.DefaultStatusRefreshInterval)
+	}
+	for i := 0; i < 2; i++ {
+		ticker.c <- time.Now()
+		ticker.nextReset(t)
+	}
+	ost.SetTunnelManager(nil)
+	ticker.nextReset(t)
+
+	close(ost.quit)
+	select {
+	case <-ticker.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker not stopped after the tab closed")
+	}
+	if !ost.unavailable {
+		t.Error("the tab doesn't show the service as unavailable")
+	}
+}
+
+func TestPollDue(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		active       bool
+		lastSnapshot time.Time // Zero if the manager never pushed one
+		want         bool
+	}{
+		{name: "on screen without snapshots", active: true, want: true},
+		{name: "hidden without snapshots"},
+		{name: "on screen with a fresh snapshot", active: true, lastSnapshot: now.Add(-tunnel.SnapshotInterval)},
+		{name: "on screen after snapshots stopped", active: true, lastSnapshot: now.Add(-2 * tunnel.SnapshotInterval), want: true},
+		{name: "hidden after snapshots stopped", lastSnapshot: now.Add(-time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ost := &OLMStatusTab{active: tt.active}
+			if !tt.lastSnapshot.IsZero() {
+				ost.statsFeed.Received(tt.lastSnapshot)
+			}
+			if got := ost.pollDue(now); got != tt.want {
+				t.Errorf("pollDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetActiveRefreshesOnResume(t *testing.T) {
+	ost := &OLMStatusTab{wake: make(chan struct{}, 1)}
+	woken := func() bool {
+		select {
+		case <-ost.wake:
+			return true
+		default:
+			return false
+		}
+	}
+
+	steps := []struct {
+		active   bool
+		wantWake bool
+	}{
+		{active: true, wantWake: true},
+		{active: true},
+		{active: false},
+		{active: false},
+		{active: true, wantWake: true},
+	}
+	for i, step := range steps {
+		ost.SetActive(step.active)
+		if got := woken(); got != step.wantWake {
+			t.Errorf("step %d: SetActive(%v) woke the poller = %v, want %v", i, step.active, got, step.wantWake)
+		}
+		if ost.isActive() != step.active {
+			t.Errorf("step %d: isActive() = %v, want %v", i, ost.isActive(), step.active)
+		}
+	}
+}
+
+func TestStatusEqual(t *testing.T) {
+	handshake := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	newStatus := func() *tunnel.OLMStatusResponse {
+		return &tunnel.OLMStatusResponse{
+			Connected:  true,
+			Registered: true,
+			Version:    "1.2.0",
+			PeerStatuses: map[int]*tunnel.OLMPeerStatus{
+				1: {SiteID: 1, SiteName: "HQ", Connected: true, RTT: 20 * time.Millisecond, LastHandshake: handshake},
+				2: {SiteID: 2, SiteName: "Lab", Connected: true, RTT: 35 * time.Millisecond, LastHandshake: handshake},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		a, b   *tunnel.OLMStatusResponse
+		change func(*tunnel.OLMStatusResponse)
+		want   bool
+	}{
+		{name: "both disconnected", want: true},
+		{name: "connects", b: newStatus()},
+		{name: "disconnects", a: newStatus()},
+		{name: "same", a: newStatus(), b: newStatus(), want: true},
+		{name: "drops", a: newStatus(), b: newStatus(), change: func(s *tunnel.OLMStatusResponse) { s.Connected = false }},
+		{name: "peer drops", a: newStatus(), b: newStatus(), change: func(s *tunnel.OLMStatusResponse) { s.PeerStatuses[2].Connected = false }},
+		{name: "peer added", a: newStatus(), b: newStatus(), change: func(s *tunnel.OLMStatusResponse) {
+			s.PeerStatuses[3] = &tunnel.OLMPeerStatus{SiteID: 3, SiteName: "Branch"}
+		}},
+		{name: "peer removed", a: newStatus(), b: newStatus(), change: func(s *tunnel.OLMStatusResponse) { delete(s.PeerStatuses, 1) }},
+		{name: "latency", a: newStatus(), b: newStatus(), change: func(s *tunnel.OLMStatusResponse) { s.PeerStatuses[1].RTT = 21 * time.Millisecond }},
+		{name: "handshake", a: newStatus(), b: newStatus(), change: func(s *tunnel.OLMStatusResponse) { s.PeerStatuses[1].LastHandshake = handshake.Add(time.Minute) }},
+		{name: "reason", a: newStatus(), b: newStatus(), change: func(s *tunnel.OLMStatusResponse) { s.StatusReason = tunnel.StatusReasonHandshakePending }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.change != nil {
+				tt.change(tt.b)
+			}
+			if got := statusEqual(tt.a, tt.b); got != tt.want {
+				t.Errorf("statusEqual() = %v, want %v", got, tt.want)
+			}
+			if got := statusEqual(tt.b, tt.a); got != tt.want {
+				t.Errorf("statusEqual() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordStatusSkipsUnchanged(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	connected := func() *tunnel.OLMStatusResponse {
+		return &tunnel.OLMStatusResponse{Connected: true, Registered: true, PeerStatuses: map[int]*tunnel.OLMPeerStatus{
+			1: {SiteID: 1, SiteName: "HQ", Connected: true},
+		}}
+	}
+	ost := &OLMStatusTab{}
+
+	steps := []struct {
+		name        string
+		unavailable bool // Set before the step, as showUnavailable does
+		status      *tunnel.OLMStatusResponse
+		want        bool
+	}{
+		{name: "first status", status: connected()},
+		{name: "same again", status: connected(), want: true},
+		{name: "a peer drops", status: func() *tunnel.OLMStatusResponse {
+			s := connected()
+			s.PeerStatuses[1].Connected = false
+			return s
+		}()},
+		{name: "peer back", status: connected()},
+		{name: "unchanged", status: connected(), want: true},
+		{name: "back from unavailable", unavailable: true, status: connected()},
+		{name: "disconnected", status: nil},
+		{name: "still disconnected", status: nil, want: true},
+	}
+
+	for i, step := range steps {
+		ost.unavailable = step.unavailable
+		if got := ost.recordStatus(step.status, &tunnel.Stats{RxBytes: uint64(i) * 1024}, now.Add(time.Duration(i)*time.Second)); got != step.want {
+			t.Errorf("%s: recordStatus() unchanged = %v, want %v", step.name, got, step.want)
+		}
+		if ost.currentStatus != step.status || ost.unavailable {
+			t.Errorf("%s: the status wasn't made current", step.name)
+		}
+	}
+
+	// Skipped updates still sample the traffic
+	if rx, _ := ost.throughput.samples(); len(rx) != len(steps)-1 {
+		t.Errorf("%d throughput samples, want %d", len(rx), len(steps)-1)
+	}
+}
+
+func TestPeerMatchesFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		siteName string
+		endpoint string
+		filter   string
+		want     bool
+	}{
+		{name: "empty filter", siteName: "Office", endpoint: "203.0.113.5:51820", want: true},
+		{name: "blank filter", siteName: "Office", filter: "   ", want: true},
+		{name: "name prefix", siteName: "Office", filter: "off", want: true},
+		{name: "name ignores case", siteName: "Datacenter East", filter: "CENTER e", want: true},
+		{name: "endpoint", siteName: "Office", endpoint: "203.0.113.5:51820", filter: "113.5", want: true},
+		{name: "port", siteName: "Office", endpoint: "203.0.113.5:51820", filter: ":51820", want: true},
+		{name: "surrounding space ignored", siteName: "Lab", filter: " lab ", want: true},
+		{name: "no match", siteName: "Office", endpoint: "203.0.113.5:51820", filter: "lab"},
+		{name: "no endpoint", siteName: "Office", filter: "203."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := peerMatchesFilter(tt.siteName, tt.endpoint, tt.filter); got != tt.want {
+				t.Errorf("peerMatchesFilter(%q, %q, %q) = %v, want %v", tt.siteName, tt.endpoint, tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeerLess(t *testing.T) {
+	tests := []struct {
+		name  string
+		aName string
+		aID   int
+		bName string
+		bID   int
+		want  bool
+	}{
+		{name: "by name", aName: "Lab", aID: 2, bName: "Office", bID: 1, want: true},
+		{name: "by name reversed", aName: "Office", aID: 1, bName: "Lab", bID: 2},
+		{name: "ignoring case", aName: "datacenter", aID: 5, bName: "Lab", bID: 1, want: true},
+		{name: "unnamed last", aName: "Zoo", aID: 9, bName: "", bID: 1, want: true},
+		{name: "unnamed after named", aName: "", aID: 1, bName: "Zoo", bID: 9},
+		{name: "both unnamed by ID", aName: "", aID: 1, bName: "", bID: 2, want: true},
+		{name: "duplicate names by ID", aName: "Office", aID: 3, bName: "Office", bID: 7, want: true},
+		{name: "duplicate names reversed", aName: "Office", aID: 7, bName: "Office", bID: 3},
+		{name: "same name in another case", aName: "OFFICE", aID: 9, bName: "office", bID: 1, want: true},
+		{name: "same peer", aName: "Office", aID: 3, bName: "Office", bID: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := peerLess(tt.aName, tt.aID, tt.bName, tt.bID); got != tt.want {
+				t.Errorf("peerLess(%q, %d, %q, %d) = %v, want %v", tt.aName, tt.aID, tt.bName, tt.bID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortedPeers(t *testing.T) {
+	peers := map[int]*tunnel.OLMPeerStatus{
+		4: {SiteName: "office"},
+		1: {SiteName: ""},
+		7: {SiteName: "Lab"},
+		2: {SiteName: "Office"},
+		3: nil,
+		9: {SiteName: "Office"},
+		5: {SiteName: ""},
+	}
+	wantOrder := []int{7, 2, 9, 4, 1, 5}
+
+	// The map's order changes between runs; the result must not
+	for i := 0; i < 10; i++ {
+		entries := sortedPeers(peers)
+		got := make([]int, len(entries))
+		for j, entry := range entries {
+			got[j] = entry.siteID
+		}
+		if !reflect.DeepEqual(got, wantOrder) {
+			t.Fatalf("sortedPeers() order = %v, want %v", got, wantOrder)
+		}
+	}
+}
+
+func TestFormatHandshakeAge(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		last time.Time
+		want string
+	}{
+		{name: "never", want: "Never seen"},
+		{name: "just now", last: now.Add(-500 * time.Millisecond), want: "Last seen just now"},
+		{name: "seconds", last: now.Add(-12 * time.Second), want: "Last seen 12s ago"},
+		{name: "last second of a minute", last: now.Add(-59*time.Second - 900*time.Millisecond), want: "Last seen 59s ago"},
+		{name: "minutes", last: now.Add(-2*time.Minute - 30*time.Second), want: "Last seen 2m ago"},
+		{name: "hours", last: now.Add(-5*time.Hour - 59*time.Minute), want: "Last seen 5h ago"},
+		{name: "days", last: now.Add(-49 * time.Hour), want: "Last seen 2d ago"},
+		{name: "clock behind the peer", last: now.Add(time.Minute), want: "Last seen just now"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatHandshakeAge(tt.last, now); got != tt.want {
+				t.Errorf("formatHandshakeAge() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLastConnected(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		last time.Time
+		want string
+	}{
+		{name: "never", want: "Never connected"},
+		{name: "just now", last: now, want: "Last connected just now"},
+		{name: "minutes", last: now.Add(-5 * time.Minute), want: "Last connected 5m ago"},
+		{name: "hours", last: now.Add(-2*time.Hour - 10*time.Minute), want: "Last connected 2h ago"},
+		{name: "days", last: now.Add(-72 * time.Hour), want: "Last connected 3d ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatLastConnected(tt.last, now); got != tt.want {
+				t.Errorf("FormatLastConnected() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusTabLastConnected(t *testing.T) {
+	if got := (&OLMStatusTab{}).formatLastConnected(); got != "Unknown" {
+		t.Errorf("without a config: %q, want %q", got, "Unknown")
+	}
+
+	ost := &OLMStatusTab{configManager: newTestConfigManager(t, "{}")}
+	if got := ost.formatLastConnected(); got != "Never" {
+		t.Errorf("never connected: %q, want %q", got, "Never")
+	}
+
+	last := time.Now().Add(-2*time.Hour - time.Minute)
+	if !ost.configManager.SetLastConnectedAt(last) {
+		t.Fatal("SetLastConnectedAt() failed")
+	}
+	want := last.Local().Format("Jan 2, 3:04 PM") + " (2h ago)"
+	if got := ost.formatLastConnected(); got != want {
+		t.Errorf("connected 2h ago: %q, want %q", got, want)
+	}
+}
+
+func TestPeerIndicatorColor(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	colors := theme.Colors{
+		Success:  walk.RGB(0, 200, 0),
+		Warning:  walk.RGB(230, 160, 0),
+		Disabled: walk.RGB(150, 150, 150),
+	}
+
+	tests := []struct {
+		name      string
+		connected bool
+		last      time.Time
+		want      walk.Color
+	}{
+		{name: "recent handshake", connected: true, last: now.Add(-30 * time.Second), want: colors.Success},
+		{name: "at the threshold", connected: true, last: now.Add(-staleHandshakeThreshold), want: colors.Success},
+		{name: "past the threshold", connected: true, last: now.Add(-staleHandshakeThreshold - time.Second), want: colors.Warning},
+		{name: "connected without a handshake", connected: true, want: colors.Warning},
+		{name: "disconnected", last: now.Add(-30 * time.Second), want: colors.Disabled},
+		{name: "disconnected and never seen", want: colors.Disabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := peerIndicatorColor(tt.connected, tt.last, now, colors); got != tt.want {
+				t.Errorf("peerIndicatorColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
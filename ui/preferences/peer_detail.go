@@ -0,0 +1,209 @@
+//go:build windows
+
+package preferences
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/tunnel"
+	"github.com/tailscale/walk"
+)
+
+// peerDetailDialog shows everything the status reports about one site. It is
+// refreshed from the tab's polling while open.
+type peerDetailDialog struct {
+	dlg    *walk.Dialog
+	siteID int
+	values []*walk.Label // One per peerDetailRows row, in order
+	note   *walk.Label
+}
+
+// peerDetailRow is one labeled value in the peer detail dialog
+type peerDetailRow struct {
+	label string
+	value string
+}
+
+// peerDetailLabels are the row labels of the dialog, matching peerDetailRows
+var peerDetailLabels = []string{
+	"Site",
+	"Site ID",
+	"Status",
+	"Endpoint",
+	"Peer Address",
+	"Round-Trip Time",
+	"Last Handshake",
+	"Last Seen",
+	"Tunnel Error",
+}
+
+// peerBySiteID returns the peer keyed by siteID in status, if the status has one
+func peerBySiteID(status *tunnel.OLMStatusResponse, siteID int) (*tunnel.OLMPeerStatus, bool) {
+	if status == nil || status.PeerStatuses == nil {
+		return nil, false
+	}
+	peer := status.PeerStatuses[siteID]
+	return peer, peer != nil
+}
+
+// peerDetailRows formats a peer for the detail dialog, in the order of peerDetailLabels
+func peerDetailRows(siteID int, peer *tunnel.OLMPeerStatus, status *tunnel.OLMStatusResponse, now time.Time) []peerDetailRow {
+	orNone := func(s string) string {
+		if s == "" {
+			return "—"
+		}
+		return s
+	}
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "Never"
+		}
+		return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04:05"), formatAge(t, now))
+	}
+
+	name := peer.SiteName
+	if name == "" {
+		name = "Unknown"
+	}
+	state := "Disconnected"
+	if peer.Connected {
+		state = "Connected"
+	}
+	if peer.IsRelay {
+		state += ", relayed"
+	}
+	rtt := "—"
+	if peer.RTT > 0 {
+		rtt = peer.RTT.Round(time.Millisecond).String()
+	}
+	tunnelError := "None"
+	if status != nil && status.Error != nil {
+		tunnelError = status.Error.Message
+		if tunnelError == "" {
+			tunnelError = status.Error.Code
+		}
+	}
+
+	return []peerDetailRow{
+		{"Site", name},
+		{"Site ID", fmt.Sprint(siteID)},
+		{"Status", state},
+		{"Endpoint", orNone(peer.Endpoint)},
+		{"Peer Address", orNone(peer.PeerIP)},
+		{"Round-Trip Time", rtt},
+		{"Last Handshake", formatTime(peer.LastHandshake)},
+		{"Last Seen", formatTime(peer.LastSeen)},
+		{"Tunnel Error", tunnelError},
+	}
+}
+
+// showPeerDetail opens the detail dialog for a site and blocks until it is
+// closed. Must be called on the UI thread.
+func (ost *OLMStatusTab) showPeerDetail(siteID int) {
+	if ost.peerDetail != nil {
+		return // Already open
+	}
+
+	owner := ost.tabPage.Form()
+	dlg, err := walk.NewDialog(owner)
+	if err != nil {
+		logger.Error("Failed to create site detail dialog: %v", err)
+		return
+	}
+	defer dlg.Dispose()
+	dlg.SetTitle("Site Details")
+	dlg.SetMinMaxSize(walk.Size{Width: 420, Height: 0}, walk.Size{})
+	layout := walk.NewVBoxLayout()
+	layout.SetSpacing(8)
+	dlg.SetLayout(layout)
+
+	pd := &peerDetailDialog{dlg: dlg, siteID: siteID}
+	grid, err := walk.NewComposite(dlg)
+	if err != nil {
+		logger.Error("Failed to create site detail dialog: %v", err)
+		return
+	}
+	gridLayout := walk.NewGridLayout()
+	gridLayout.SetMargins(walk.Margins{})
+	gridLayout.SetSpacing(6)
+	grid.SetLayout(gridLayout)
+	for i, text := range peerDetailLabels {
+		label, err := walk.NewLabel(grid)
+		if err != nil {
+			logger.Error("Failed to create site detail dialog: %v", err)
+			return
+		}
+		label.SetText(text)
+		gridLayout.SetRange(label, walk.Rectangle{X: 0, Y: i, Width: 1, Height: 1})
+
+		value, err := walk.NewLabel(grid)
+		if err != nil {
+			logger.Error("Failed to create site detail dialog: %v", err)
+			return
+		}
+		gridLayout.SetRange(value, walk.Rectangle{X: 1, Y: i, Width: 1, Height: 1})
+		pd.values = append(pd.values, value)
+	}
+
+	// Shown when the site drops out of the status while the dialog is open
+	if pd.note, err = walk.NewLabel(dlg); err != nil {
+		logger.Error("Failed to create site detail dialog: %v", err)
+		return
+	}
+	pd.note.SetText("This site is no longer in the tunnel status. The details are from the last time it was.")
+	pd.note.SetTextColor(walk.RGB(100, 100, 100))
+	pd.note.SetVisible(false)
+
+	buttons, err := walk.NewComposite(dlg)
+	if err != nil {
+		logger.Error("Failed to create site detail dialog: %v", err)
+		return
+	}
+	buttonsLayout := walk.NewHBoxLayout()
+	buttonsLayout.SetMargins(walk.Margins{})
+	buttons.SetLayout(buttonsLayout)
+	walk.NewHSpacer(buttons)
+	closeButton, err := walk.NewPushButton(buttons)
+	if err != nil {
+		logger.Error("Failed to create site detail dialog: %v", err)
+		return
+	}
+	closeButton.SetText("Close")
+	closeButton.Clicked().Attach(func() {
+		dlg.Close(walk.DlgCmdClose)
+	})
+	if err := dlg.SetDefaultButton(closeButton); err != nil {
+		logger.Warn("Failed to set default button: %v", err)
+	}
+	if err := dlg.SetCancelButton(closeButton); err != nil {
+		logger.Warn("Failed to set cancel button: %v", err)
+	}
+
+	ost.peerDetail = pd
+	defer func() { ost.peerDetail = nil }()
+	ost.refreshPeerDetail()
+	dlg.Run()
+}
+
+// refreshPeerDetail updates the open detail dialog, if any, from the latest
+// status. A site that has gone keeps its last values. Must be called on the UI thread.
+func (ost *OLMStatusTab) refreshPeerDetail() {
+	pd := ost.peerDetail
+	if pd == nil {
+		return
+	}
+	ost.mu.Lock()
+	status := ost.currentStatus
+	ost.mu.Unlock()
+
+	peer, ok := peerBySiteID(status, pd.siteID)
+	pd.note.SetVisible(!ok)
+	if !ok {
+		return
+	}
+	for i, row := range peerDetailRows(pd.siteID, peer, status, time.Now()) {
+		pd.values[i].SetText(row.value)
+	}
+}
@@ -0,0 +1,101 @@
+//go:build windows
+
+package preferences
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+func TestPeerBySiteID(t *testing.T) {
+	hq := &tunnel.OLMPeerStatus{SiteID: 1, SiteName: "HQ"}
+	lab := &tunnel.OLMPeerStatus{SiteID: 2, SiteName: "Lab"}
+	status := &tunnel.OLMStatusResponse{PeerStatuses: map[int]*tunnel.OLMPeerStatus{1: hq, 2: lab, 3: nil}}
+
+	tests := []struct {
+		name   string
+		status *tunnel.OLMStatusResponse
+		siteID int
+		want   *tunnel.OLMPeerStatus
+	}{
+		{name: "first site", status: status, siteID: 1, want: hq},
+		{name: "second site", status: status, siteID: 2, want: lab},
+		{name: "unknown site", status: status, siteID: 4},
+		{name: "site without a record", status: status, siteID: 3},
+		{name: "no peers", status: &tunnel.OLMStatusResponse{}, siteID: 1},
+		{name: "disconnected", siteID: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := peerBySiteID(tt.status, tt.siteID)
+			if got != tt.want || ok != (tt.want != nil) {
+				t.Errorf("peerBySiteID(%d) = %+v, %v, want %+v", tt.siteID, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeerDetailRows(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	handshake := now.Add(-90 * time.Second)
+	seen := now.Add(-5 * time.Second)
+	local := func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") }
+
+	tests := []struct {
+		name   string
+		siteID int
+		peer   *tunnel.OLMPeerStatus
+		status *tunnel.OLMStatusResponse
+		want   []string
+	}{
+		{
+			name:   "connected",
+			siteID: 7,
+			peer: &tunnel.OLMPeerStatus{SiteID: 7, SiteName: "HQ", Connected: true, RTT: 23400 * time.Microsecond,
+				LastHandshake: handshake, LastSeen: seen, Endpoint: "203.0.113.5:51820", PeerIP: "100.90.0.7"},
+			status: &tunnel.OLMStatusResponse{},
+			want: []string{"HQ", "7", "Connected", "203.0.113.5:51820", "100.90.0.7", "23ms",
+				local(handshake) + " (1m ago)", local(seen) + " (5s ago)", "None"},
+		},
+		{
+			name:   "relayed",
+			siteID: 7,
+			peer:   &tunnel.OLMPeerStatus{SiteName: "HQ", Connected: true, IsRelay: true},
+			want:   []string{"HQ", "7", "Connected, relayed", "—", "—", "—", "Never", "Never", "None"},
+		},
+		{
+			name:   "never seen",
+			siteID: 3,
+			peer:   &tunnel.OLMPeerStatus{},
+			status: &tunnel.OLMStatusResponse{Error: &tunnel.OLMStatusError{Code: "PEER_TIMEOUT", Message: "Peer timed out"}},
+			want:   []string{"Unknown", "3", "Disconnected", "—", "—", "—", "Never", "Never", "Peer timed out"},
+		},
+		{
+			name:   "error without a message",
+			siteID: 3,
+			peer:   &tunnel.OLMPeerStatus{SiteName: "Lab"},
+			status: &tunnel.OLMStatusResponse{Error: &tunnel.OLMStatusError{Code: "PEER_TIMEOUT"}},
+			want:   []string{"Lab", "3", "Disconnected", "—", "—", "—", "Never", "Never", "PEER_TIMEOUT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := peerDetailRows(tt.siteID, tt.peer, tt.status, now)
+			if len(rows) != len(peerDetailLabels) {
+				t.Fatalf("%d rows, want one per label (%d)", len(rows), len(peerDetailLabels))
+			}
+			for i, row := range rows {
+				if row.label != peerDetailLabels[i] {
+					t.Errorf("row %d is %q, want %q", i, row.label, peerDetailLabels[i])
+				}
+				if row.value != tt.want[i] {
+					t.Errorf("%s = %q, want %q", row.label, row.value, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+//go:build windows
+
+package preferences
+
+import "github.com/fosrl/windows/tunnel"
+
+// peerKey identifies a site row: the site ID OLM keys the peer by, and an
+// identity that stays the same when the site ID changes
+type peerKey struct {
+	siteID   int
+	identity string
+}
+
+// peerIdentity names a peer independently of its site ID: its tunnel address,
+// else its site name. It is "" if OLM reported neither.
+func peerIdentity(peer *tunnel.OLMPeerStatus) string {
+	switch {
+	case peer.PeerIP != "":
+		return "address:" + peer.PeerIP
+	case peer.SiteName != "":
+		return "name:" + peer.SiteName
+	}
+	return ""
+}
+
+// keyForPeer returns the key of the peer OLM reported under siteID
+func keyForPeer(siteID int, peer *tunnel.OLMPeerStatus) peerKey {
+	return peerKey{siteID: siteID, identity: peerIdentity(peer)}
+}
+
+// reconcilePeerKeys matches the keys of the peers in a new status against the
+// keys of the existing rows, and returns for each matched new key the existing
+// key whose row it takes over. A peer keeps the row with its exact key; failing
+// that, it takes a row with the same identity, as when its site ID changed. A
+// site ID reused by another peer doesn't match, so that peer gets a new row
+// rather than the old peer's. Unmatched new keys need new rows, and rows whose
+// keys are left over belong to peers that are gone.
+func reconcilePeerKeys(existing, current []peerKey) map[peerKey]peerKey {
+	matches := make(map[peerKey]peerKey, len(current))
+	taken := make(map[peerKey]bool, len(existing))
+	existingSet := make(map[peerKey]bool, len(existing))
+	for _, key := range existing {
+		existingSet[key] = true
+	}
+
+	for _, key := range current {
+		if existingSet[key] {
+			matches[key] = key
+			taken[key] = true
+		}
+	}
+
+	byIdentity := make(map[string][]peerKey)
+	for _, key := range existing {
+		if !taken[key] && key.identity != "" {
+			byIdentity[key.identity] = append(byIdentity[key.identity], key)
+		}
+	}
+	for _, key := range current {
+		if _, ok := matches[key]; ok || key.identity == "" {
+			continue
+		}
+		candidates := byIdentity[key.identity]
+		if len(candidates) == 0 {
+			continue
+		}
+		matches[key] = candidates[0]
+		byIdentity[key.identity] = candidates[1:]
+	}
+	return matches
+}
@@ -0,0 +1,111 @@
+//go:build windows
+
+package preferences
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+func TestPeerIdentity(t *testing.T) {
+	tests := []struct {
+		peer tunnel.OLMPeerStatus
+		want string
+	}{
+		{peer: tunnel.OLMPeerStatus{PeerIP: "100.90.0.7", SiteName: "HQ"}, want: "address:100.90.0.7"},
+		{peer: tunnel.OLMPeerStatus{SiteName: "HQ"}, want: "name:HQ"},
+		{peer: tunnel.OLMPeerStatus{SiteID: 7}, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := peerIdentity(&tt.peer); got != tt.want {
+			t.Errorf("peerIdentity(%+v) = %q, want %q", tt.peer, got, tt.want)
+		}
+	}
+}
+
+func TestReconcilePeerKeys(t *testing.T) {
+	hq := func(siteID int) peerKey { return peerKey{siteID: siteID, identity: "address:100.90.0.1"} }
+	lab := func(siteID int) peerKey { return peerKey{siteID: siteID, identity: "address:100.90.0.2"} }
+	anonymous := func(siteID int) peerKey { return peerKey{siteID: siteID} }
+
+	tests := []struct {
+		name     string
+		existing []peerKey
+		current  []peerKey
+		want     map[peerKey]peerKey
+	}{
+		{
+			name:     "unchanged",
+			existing: []peerKey{hq(1), lab(2)},
+			current:  []peerKey{hq(1), lab(2)},
+			want:     map[peerKey]peerKey{hq(1): hq(1), lab(2): lab(2)},
+		},
+		{
+			name:    "first status",
+			current: []peerKey{hq(1)},
+			want:    map[peerKey]peerKey{},
+		},
+		{
+			name:     "site added",
+			existing: []peerKey{hq(1)},
+			current:  []peerKey{hq(1), lab(2)},
+			want:     map[peerKey]peerKey{hq(1): hq(1)},
+		},
+		{
+			name:     "site removed",
+			existing: []peerKey{hq(1), lab(2)},
+			current:  []peerKey{lab(2)},
+			want:     map[peerKey]peerKey{lab(2): lab(2)},
+		},
+		{
+			name:     "site ID changed",
+			existing: []peerKey{hq(1), lab(2)},
+			current:  []peerKey{hq(5), lab(2)},
+			want:     map[peerKey]peerKey{hq(5): hq(1), lab(2): lab(2)},
+		},
+		{
+			name:     "site IDs swapped",
+			existing: []peerKey{hq(1), lab(2)},
+			current:  []peerKey{hq(2), lab(1)},
+			want:     map[peerKey]peerKey{hq(2): hq(1), lab(1): lab(2)},
+		},
+		{
+			// The new peer must not take over the old peer's row
+			name:     "site ID reused by another site",
+			existing: []peerKey{hq(1)},
+			current:  []peerKey{lab(1)},
+			want:     map[peerKey]peerKey{},
+		},
+		{
+			name:     "site without an identity keeps its ID",
+			existing: []peerKey{anonymous(3)},
+			current:  []peerKey{anonymous(3)},
+			want:     map[peerKey]peerKey{anonymous(3): anonymous(3)},
+		},
+		{
+			name:     "site without an identity changes ID",
+			existing: []peerKey{anonymous(3)},
+			current:  []peerKey{anonymous(4)},
+			want:     map[peerKey]peerKey{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reconcilePeerKeys(tt.existing, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reconcilePeerKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyForPeer(t *testing.T) {
+	peer := &tunnel.OLMPeerStatus{SiteID: 9, PeerIP: "100.90.0.1"}
+	// The key uses the ID the status map keys the peer by
+	if got, want := keyForPeer(4, peer), (peerKey{siteID: 4, identity: "address:100.90.0.1"}); got != want {
+		t.Errorf("keyForPeer() = %+v, want %+v", got, want)
+	}
+}
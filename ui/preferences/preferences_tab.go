@@ -3,11 +3,15 @@
 package preferences
 
 import (
-	"net"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/fosrl/newt/logger"
 	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/secrets"
+	"github.com/fosrl/windows/tunnel"
 	browser "github.com/pkg/browser"
 	"github.com/tailscale/walk"
 	"github.com/tailscale/win"
@@ -20,6 +24,14 @@ type PreferencesTab struct {
 	dnsTunnelCheckBox          *walk.CheckBox
 	primaryDNSEdit             *walk.LineEdit
 	secondaryDNSEdit           *walk.LineEdit
+	mtuEdit                    *walk.LineEdit
+	killSwitchCheckBox         *walk.CheckBox
+	connectOnLaunchCheckBox    *walk.CheckBox
+	idleDisconnectEdit         *walk.LineEdit
+	proxyEdit                  *walk.LineEdit
+	proxyUsernameEdit          *walk.LineEdit
+	proxyPasswordEdit          *walk.LineEdit
+	logLevelBox                *walk.ComboBox
 	saveButton                 *walk.PushButton
 	configManager              *config.ConfigManager
 	window                     *PreferencesWindow
@@ -42,11 +54,13 @@ func (pt *PreferencesTab) Create(parent *walk.TabWidget) (*walk.TabPage, error)
 	pt.tabPage.SetTitle("Preferences")
 	pt.tabPage.SetLayout(walk.NewVBoxLayout())
 
-	// Content container - match the structure of logs/olm tabs
-	contentContainer, err := walk.NewComposite(pt.tabPage)
+	// Content container - match the structure of logs/olm tabs. It scrolls, since
+	// the settings are taller than the window.
+	contentContainer, err := walk.NewScrollView(pt.tabPage)
 	if err != nil {
 		return nil, err
 	}
+	contentContainer.SetScrollbars(false, true)
 	contentLayout := walk.NewVBoxLayout()
 	contentLayout.SetMargins(walk.Margins{})
 	contentLayout.SetSpacing(16)
@@ -219,6 +233,323 @@ func (pt *PreferencesTab) Create(parent *walk.TabWidget) (*walk.TabPage, error)
 	// Spacer
 	walk.NewHSpacer(secondaryDNSContainer)
 
+	// Tunnel Settings section title
+	tunnelSectionTitle, err := walk.NewLabel(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	tunnelSectionTitle.SetText("Tunnel Settings")
+	if font != nil {
+		tunnelSectionTitle.SetFont(font)
+	}
+
+	// MTU section
+	mtuContainer, err := walk.NewComposite(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	mtuLayout := walk.NewVBoxLayout()
+	mtuLayout.SetMargins(walk.Margins{})
+	mtuLayout.SetSpacing(8)
+	mtuContainer.SetLayout(mtuLayout)
+
+	mtuRow, err := walk.NewComposite(mtuContainer)
+	if err != nil {
+		return nil, err
+	}
+	mtuRowLayout := walk.NewHBoxLayout()
+	mtuRowLayout.SetMargins(walk.Margins{})
+	mtuRowLayout.SetSpacing(12)
+	mtuRow.SetLayout(mtuRowLayout)
+
+	mtuLabel, err := walk.NewLabel(mtuRow)
+	if err != nil {
+		return nil, err
+	}
+	mtuLabel.SetText("MTU")
+	mtuLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+	if pt.mtuEdit, err = walk.NewLineEdit(mtuRow); err != nil {
+		return nil, err
+	}
+	if mtu := pt.configManager.GetMTU(); mtu != 0 {
+		pt.mtuEdit.SetText(strconv.Itoa(mtu)) // Blank means automatic
+	}
+
+	// Spacer
+	walk.NewHSpacer(mtuRow)
+
+	// MTU description label (below the row)
+	mtuDescLabel, err := walk.NewLabel(mtuContainer)
+	if err != nil {
+		return nil, err
+	}
+	mtuDescLabel.SetText(fmt.Sprintf("Leave blank to use the default of %d. Lower it if connections\nstall behind PPPoE or other tunnels. Takes effect on the next connect.", config.DefaultMTU))
+	mtuDescLabel.SetTextColor(walk.RGB(100, 100, 100))
+	mtuDescLabel.SetMinMaxSize(walk.Size{}, walk.Size{Width: 400, Height: 0})
+
+	// Kill switch section
+	killSwitchContainer, err := walk.NewComposite(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	killSwitchLayout := walk.NewVBoxLayout()
+	killSwitchLayout.SetMargins(walk.Margins{})
+	killSwitchLayout.SetSpacing(8)
+	killSwitchContainer.SetLayout(killSwitchLayout)
+
+	killSwitchRow, err := walk.NewComposite(killSwitchContainer)
+	if err != nil {
+		return nil, err
+	}
+	killSwitchRowLayout := walk.NewHBoxLayout()
+	killSwitchRowLayout.SetMargins(walk.Margins{})
+	killSwitchRowLayout.SetSpacing(12)
+	killSwitchRow.SetLayout(killSwitchRowLayout)
+
+	killSwitchLabel, err := walk.NewLabel(killSwitchRow)
+	if err != nil {
+		return nil, err
+	}
+	killSwitchLabel.SetText("Block Traffic Outside Tunnel")
+	killSwitchLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+	if pt.killSwitchCheckBox, err = walk.NewCheckBox(killSwitchRow); err != nil {
+		return nil, err
+	}
+	pt.killSwitchCheckBox.SetChecked(pt.configManager.GetKillSwitch()) // Get value from config
+	pt.killSwitchCheckBox.SetText("")                                  // No text, just the checkbox
+
+	// Spacer
+	walk.NewHSpacer(killSwitchRow)
+
+	// Kill switch description label (below the row)
+	killSwitchDescLabel, err := walk.NewLabel(killSwitchContainer)
+	if err != nil {
+		return nil, err
+	}
+	killSwitchDescLabel.SetText("When enabled, all traffic that doesn't go through Pangolin is blocked\nwhile the tunnel is up, including while it reconnects. This also cuts\noff internet access that isn't routed through a site.")
+	killSwitchDescLabel.SetTextColor(walk.RGB(100, 100, 100))
+	killSwitchDescLabel.SetMinMaxSize(walk.Size{}, walk.Size{Width: 400, Height: 0})
+
+	// Connect on launch section
+	connectOnLaunchContainer, err := walk.NewComposite(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	connectOnLaunchLayout := walk.NewVBoxLayout()
+	connectOnLaunchLayout.SetMargins(walk.Margins{})
+	connectOnLaunchLayout.SetSpacing(8)
+	connectOnLaunchContainer.SetLayout(connectOnLaunchLayout)
+
+	connectOnLaunchRow, err := walk.NewComposite(connectOnLaunchContainer)
+	if err != nil {
+		return nil, err
+	}
+	connectOnLaunchRowLayout := walk.NewHBoxLayout()
+	connectOnLaunchRowLayout.SetMargins(walk.Margins{})
+	connectOnLaunchRowLayout.SetSpacing(12)
+	connectOnLaunchRow.SetLayout(connectOnLaunchRowLayout)
+
+	connectOnLaunchLabel, err := walk.NewLabel(connectOnLaunchRow)
+	if err != nil {
+		return nil, err
+	}
+	connectOnLaunchLabel.SetText("Connect on Launch")
+	connectOnLaunchLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+	if pt.connectOnLaunchCheckBox, err = walk.NewCheckBox(connectOnLaunchRow); err != nil {
+		return nil, err
+	}
+	pt.connectOnLaunchCheckBox.SetChecked(pt.configManager.GetConnectOnLaunch()) // Get value from config
+	pt.connectOnLaunchCheckBox.SetText("")                                       // No text, just the checkbox
+
+	// Spacer
+	walk.NewHSpacer(connectOnLaunchRow)
+
+	// Connect on launch description label (below the row)
+	connectOnLaunchDescLabel, err := walk.NewLabel(connectOnLaunchContainer)
+	if err != nil {
+		return nil, err
+	}
+	connectOnLaunchDescLabel.SetText("When enabled, the tunnel connects when Pangolin starts, as long as\nyou're logged in and the tunnel isn't paused.")
+	connectOnLaunchDescLabel.SetTextColor(walk.RGB(100, 100, 100))
+	connectOnLaunchDescLabel.SetMinMaxSize(walk.Size{}, walk.Size{Width: 400, Height: 0})
+
+	// Idle disconnect section
+	idleDisconnectContainer, err := walk.NewComposite(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	idleDisconnectLayout := walk.NewVBoxLayout()
+	idleDisconnectLayout.SetMargins(walk.Margins{})
+	idleDisconnectLayout.SetSpacing(8)
+	idleDisconnectContainer.SetLayout(idleDisconnectLayout)
+
+	idleDisconnectRow, err := walk.NewComposite(idleDisconnectContainer)
+	if err != nil {
+		return nil, err
+	}
+	idleDisconnectRowLayout := walk.NewHBoxLayout()
+	idleDisconnectRowLayout.SetMargins(walk.Margins{})
+	idleDisconnectRowLayout.SetSpacing(12)
+	idleDisconnectRow.SetLayout(idleDisconnectRowLayout)
+
+	idleDisconnectLabel, err := walk.NewLabel(idleDisconnectRow)
+	if err != nil {
+		return nil, err
+	}
+	idleDisconnectLabel.SetText("Disconnect When Idle (minutes)")
+	idleDisconnectLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+	if pt.idleDisconnectEdit, err = walk.NewLineEdit(idleDisconnectRow); err != nil {
+		return nil, err
+	}
+	if minutes := pt.configManager.GetIdleDisconnectMinutes(); minutes != 0 {
+		pt.idleDisconnectEdit.SetText(strconv.Itoa(minutes)) // Blank means never
+	}
+
+	// Spacer
+	walk.NewHSpacer(idleDisconnectRow)
+
+	// Idle disconnect description label (below the row)
+	idleDisconnectDescLabel, err := walk.NewLabel(idleDisconnectContainer)
+	if err != nil {
+		return nil, err
+	}
+	idleDisconnectDescLabel.SetText("Disconnects the tunnel after this many minutes without any traffic\nthrough it. Leave blank or enter 0 to stay connected.")
+	idleDisconnectDescLabel.SetTextColor(walk.RGB(100, 100, 100))
+	idleDisconnectDescLabel.SetMinMaxSize(walk.Size{}, walk.Size{Width: 400, Height: 0})
+
+	// Network Settings section title
+	networkSectionTitle, err := walk.NewLabel(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	networkSectionTitle.SetText("Network Settings")
+	if font != nil {
+		networkSectionTitle.SetFont(font)
+	}
+
+	// Proxy section
+	proxyContainer, err := walk.NewComposite(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	proxyLayout := walk.NewVBoxLayout()
+	proxyLayout.SetMargins(walk.Margins{})
+	proxyLayout.SetSpacing(8)
+	proxyContainer.SetLayout(proxyLayout)
+
+	proxyUsername, proxyPassword := secrets.NewSecretManager().GetProxyCredentials()
+	for _, row := range []struct {
+		label string
+		edit  **walk.LineEdit
+		value string
+	}{
+		{"HTTP Proxy", &pt.proxyEdit, pt.configManager.GetProxy()},
+		{"Proxy User Name", &pt.proxyUsernameEdit, proxyUsername},
+		{"Proxy Password", &pt.proxyPasswordEdit, proxyPassword},
+	} {
+		proxyRow, err := walk.NewComposite(proxyContainer)
+		if err != nil {
+			return nil, err
+		}
+		proxyRowLayout := walk.NewHBoxLayout()
+		proxyRowLayout.SetMargins(walk.Margins{})
+		proxyRowLayout.SetSpacing(12)
+		proxyRow.SetLayout(proxyRowLayout)
+
+		proxyLabel, err := walk.NewLabel(proxyRow)
+		if err != nil {
+			return nil, err
+		}
+		proxyLabel.SetText(row.label)
+		proxyLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+		if *row.edit, err = walk.NewLineEdit(proxyRow); err != nil {
+			return nil, err
+		}
+		(*row.edit).SetText(row.value)
+
+		// Spacer
+		walk.NewHSpacer(proxyRow)
+	}
+	pt.proxyPasswordEdit.SetPasswordMode(true)
+
+	// Proxy description label (below the rows)
+	proxyDescLabel, err := walk.NewLabel(proxyContainer)
+	if err != nil {
+		return nil, err
+	}
+	proxyDescLabel.SetText("Sign-in and update requests go through this proxy, e.g.\nhttp://proxy.example.com:8080. Leave blank to use the HTTP_PROXY,\nHTTPS_PROXY and NO_PROXY environment variables. The password is\nkept in Windows Credential Manager.")
+	proxyDescLabel.SetTextColor(walk.RGB(100, 100, 100))
+	proxyDescLabel.SetMinMaxSize(walk.Size{}, walk.Size{Width: 400, Height: 0})
+
+	// Logging section title
+	loggingSectionTitle, err := walk.NewLabel(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	loggingSectionTitle.SetText("Logging")
+	if font, err := walk.NewFont("Segoe UI", 10, walk.FontBold); err == nil {
+		loggingSectionTitle.SetFont(font)
+	}
+
+	// Log level section
+	logLevelContainer, err := walk.NewComposite(contentContainer)
+	if err != nil {
+		return nil, err
+	}
+	logLevelLayout := walk.NewVBoxLayout()
+	logLevelLayout.SetMargins(walk.Margins{})
+	logLevelLayout.SetSpacing(8)
+	logLevelContainer.SetLayout(logLevelLayout)
+
+	logLevelRow, err := walk.NewComposite(logLevelContainer)
+	if err != nil {
+		return nil, err
+	}
+	logLevelRowLayout := walk.NewHBoxLayout()
+	logLevelRowLayout.SetMargins(walk.Margins{})
+	logLevelRowLayout.SetSpacing(12)
+	logLevelRow.SetLayout(logLevelRowLayout)
+
+	logLevelLabel, err := walk.NewLabel(logLevelRow)
+	if err != nil {
+		return nil, err
+	}
+	logLevelLabel.SetText("Log Level")
+	logLevelLabel.SetMinMaxSize(walk.Size{Width: 200, Height: 0}, walk.Size{Width: 200, Height: 0})
+
+	if pt.logLevelBox, err = walk.NewDropDownBox(logLevelRow); err != nil {
+		return nil, err
+	}
+	logLevelChoices := make([]string, len(config.LogLevels))
+	currentLogLevel := 0
+	for i, level := range config.LogLevels {
+		logLevelChoices[i] = logLevelNames[level]
+		if level == pt.configManager.GetLogLevel() {
+			currentLogLevel = i
+		}
+	}
+	if err := pt.logLevelBox.SetModel(logLevelChoices); err != nil {
+		return nil, err
+	}
+	pt.logLevelBox.SetCurrentIndex(currentLogLevel)
+
+	// Spacer
+	walk.NewHSpacer(logLevelRow)
+
+	// Log level description label (below the row)
+	logLevelDescLabel, err := walk.NewLabel(logLevelContainer)
+	if err != nil {
+		return nil, err
+	}
+	logLevelDescLabel.SetText("How much Pangolin writes to its log. Debug helps support track down\nproblems but makes the log grow quickly. It never includes passwords\nor tokens.")
+	logLevelDescLabel.SetTextColor(walk.RGB(100, 100, 100))
+	logLevelDescLabel.SetMinMaxSize(walk.Size{}, walk.Size{Width: 400, Height: 0})
+
 	// Add spacer to fill remaining space
 	walk.NewVSpacer(contentContainer)
 
@@ -261,18 +592,24 @@ func (pt *PreferencesTab) Cleanup() {
 	// Nothing to clean up for now
 }
 
-// isValidIPAddress validates if a string is a valid IP address (IPv4 or IPv6)
-func isValidIPAddress(ip string) bool {
-	return net.ParseIP(ip) != nil
-}
-
 // onSave handles the save button click and saves all DNS settings
 func (pt *PreferencesTab) onSave() {
 	// Get current values from UI
 	dnsOverride := pt.dnsOverrideCheckBox.Checked()
 	dnsTunnel := pt.dnsTunnelCheckBox.Checked()
+	killSwitch := pt.killSwitchCheckBox.Checked()
+	connectOnLaunch := pt.connectOnLaunchCheckBox.Checked()
 	primaryDNS := strings.TrimSpace(pt.primaryDNSEdit.Text())
 	secondaryDNS := strings.TrimSpace(pt.secondaryDNSEdit.Text())
+	mtuText := strings.TrimSpace(pt.mtuEdit.Text())
+	idleDisconnectText := strings.TrimSpace(pt.idleDisconnectEdit.Text())
+	proxyText := strings.TrimSpace(pt.proxyEdit.Text())
+	proxyUsername := strings.TrimSpace(pt.proxyUsernameEdit.Text())
+	proxyPassword := pt.proxyPasswordEdit.Text()
+	logLevel := config.DefaultLogLevel
+	if index := pt.logLevelBox.CurrentIndex(); index >= 0 && index < len(config.LogLevels) {
+		logLevel = config.LogLevels[index]
+	}
 
 	// Validate primary DNS (required)
 	if primaryDNS == "" {
@@ -294,8 +631,11 @@ func (pt *PreferencesTab) onSave() {
 		return
 	}
 
-	// Validate primary DNS is a valid IP address
-	if !isValidIPAddress(primaryDNS) {
+	// Validate primary DNS is a list of IP addresses
+	if addrs, err := tunnel.ParseDNS(primaryDNS); err != nil || len(addrs) == 0 {
+		if err == nil {
+			err = errors.New("no IP address is listed")
+		}
 		// Restore to current config value
 		currentValue := pt.configManager.GetPrimaryDNS()
 		pt.primaryDNSEdit.SetText(currentValue)
@@ -307,15 +647,15 @@ func (pt *PreferencesTab) onSave() {
 		_, _ = td.Show(walk.TaskDialogOpts{
 			Owner:         owner,
 			Title:         "Invalid Input",
-			Content:       "Primary DNS Server must be a valid IP address.",
+			Content:       fmt.Sprintf("Primary DNS Server: %v.", err),
 			IconSystem:    walk.TaskDialogSystemIconWarning,
 			CommonButtons: win.TDCBF_OK_BUTTON,
 		})
 		return
 	}
 
-	// Validate secondary DNS is a valid IP address (if provided)
-	if secondaryDNS != "" && !isValidIPAddress(secondaryDNS) {
+	// Validate secondary DNS is a list of IP addresses (if provided)
+	if _, err := tunnel.ParseDNS(secondaryDNS); err != nil {
 		// Restore to current config value
 		currentValue := pt.configManager.GetSecondaryDNS()
 		if currentValue == "" {
@@ -331,15 +671,117 @@ func (pt *PreferencesTab) onSave() {
 		_, _ = td.Show(walk.TaskDialogOpts{
 			Owner:         owner,
 			Title:         "Invalid Input",
-			Content:       "Secondary DNS Server must be a valid IP address.",
+			Content:       fmt.Sprintf("Secondary DNS Server: %v.", err),
 			IconSystem:    walk.TaskDialogSystemIconWarning,
 			CommonButtons: win.TDCBF_OK_BUTTON,
 		})
 		return
 	}
 
-	// Get current config and create a copy to modify
+	// Validate MTU (blank means automatic)
+	mtu := 0
+	if mtuText != "" {
+		value, err := strconv.Atoi(mtuText)
+		if err != nil || value < config.MinMTU || value > config.MaxMTU {
+			// Restore to current config value
+			if currentValue := pt.configManager.GetMTU(); currentValue != 0 {
+				pt.mtuEdit.SetText(strconv.Itoa(currentValue))
+			} else {
+				pt.mtuEdit.SetText("")
+			}
+			var owner walk.Form
+			if pt.window != nil {
+				owner = pt.window
+			}
+			td := walk.NewTaskDialog()
+			_, _ = td.Show(walk.TaskDialogOpts{
+				Owner:         owner,
+				Title:         "Invalid Input",
+				Content:       fmt.Sprintf("MTU must be a number between %d and %d, or blank for the default.", config.MinMTU, config.MaxMTU),
+				IconSystem:    walk.TaskDialogSystemIconWarning,
+				CommonButtons: win.TDCBF_OK_BUTTON,
+			})
+			return
+		}
+		mtu = value
+	}
+
+	// The kill switch would block the routes split tunneling keeps off the tunnel
+	if err := tunnel.ValidateKillSwitch(killSwitch, pt.configManager.GetAllowedIPs(), pt.configManager.GetExcludedIPs()); err != nil {
+		// Restore to current config value
+		pt.killSwitchCheckBox.SetChecked(pt.configManager.GetKillSwitch())
+		var owner walk.Form
+		if pt.window != nil {
+			owner = pt.window
+		}
+		td := walk.NewTaskDialog()
+		_, _ = td.Show(walk.TaskDialogOpts{
+			Owner:         owner,
+			Title:         "Invalid Input",
+			Content:       "Block Traffic Outside Tunnel can't be used while split tunneling is configured, as it would block the routes kept off the tunnel. Remove the allowed and excluded IPs from the configuration first.",
+			IconSystem:    walk.TaskDialogSystemIconWarning,
+			CommonButtons: win.TDCBF_OK_BUTTON,
+		})
+		return
+	}
+
+	// Validate the idle disconnect timeout (blank means never)
+	idleDisconnectMinutes := 0
+	if idleDisconnectText != "" {
+		value, err := strconv.Atoi(idleDisconnectText)
+		if err != nil || value < 0 || value > config.MaxIdleDisconnectMinutes {
+			// Restore to current config value
+			if currentValue := pt.configManager.GetIdleDisconnectMinutes(); currentValue != 0 {
+				pt.idleDisconnectEdit.SetText(strconv.Itoa(currentValue))
+			} else {
+				pt.idleDisconnectEdit.SetText("")
+			}
+			var owner walk.Form
+			if pt.window != nil {
+				owner = pt.window
+			}
+			td := walk.NewTaskDialog()
+			_, _ = td.Show(walk.TaskDialogOpts{
+				Owner:         owner,
+				Title:         "Invalid Input",
+				Content:       fmt.Sprintf("Disconnect When Idle must be a number of minutes up to %d, or blank to stay connected.", config.MaxIdleDisconnectMinutes),
+				IconSystem:    walk.TaskDialogSystemIconWarning,
+				CommonButtons: win.TDCBF_OK_BUTTON,
+			})
+			return
+		}
+		idleDisconnectMinutes = value
+	}
+
+	// Validate proxy (blank means the environment decides)
+	proxy := ""
+	if proxyText != "" {
+		proxyURL, err := config.ValidateProxyURL(proxyText)
+		if err != nil {
+			// Restore to current config value
+			pt.proxyEdit.SetText(pt.configManager.GetProxy())
+			var owner walk.Form
+			if pt.window != nil {
+				owner = pt.window
+			}
+			td := walk.NewTaskDialog()
+			_, _ = td.Show(walk.TaskDialogOpts{
+				Owner:         owner,
+				Title:         "Invalid Input",
+				Content:       fmt.Sprintf("HTTP Proxy: %v.", err),
+				IconSystem:    walk.TaskDialogSystemIconWarning,
+				CommonButtons: win.TDCBF_OK_BUTTON,
+			})
+			return
+		}
+		proxy = proxyURL.String()
+	}
+
+	// Get current config and create a copy to modify, keeping settings not on this tab
 	cfg := &config.Config{}
+	if current := pt.configManager.GetConfig(); current != nil {
+		*cfg = *current
+	}
 
 	// Set DNS settings
 	dnsOverrideVal := dnsOverride
@@ -353,9 +795,34 @@ func (pt *PreferencesTab) onSave() {
 	} else {
 		cfg.SecondaryDNS = nil
 	}
+	if mtu != 0 {
+		cfg.MTU = &mtu
+	} else {
+		cfg.MTU = nil
+	}
+	cfg.KillSwitch = &killSwitch
+	cfg.ConnectOnLaunch = &connectOnLaunch
+	if idleDisconnectMinutes != 0 {
+		cfg.IdleDisconnectMinutes = &idleDisconnectMinutes
+	} else {
+		cfg.IdleDisconnectMinutes = nil
+	}
+	if proxy != "" {
+		cfg.Proxy = &proxy
+	} else {
+		cfg.Proxy = nil
+	}
+	cfg.LogLevel = &logLevel
 
-	// Save all settings at once
+	// Save all settings at once, then the proxy credentials, which live apart from them
 	success := pt.configManager.Save(cfg)
+	if success {
+		if !secrets.NewSecretManager().SaveProxyCredentials(proxyUsername, proxyPassword) {
+			success = false
+		}
+		ApplyProxySettings(pt.configManager)
+		ApplyLogLevel(pt.configManager)
+	}
 
 	if success {
 		// Show system notification for success
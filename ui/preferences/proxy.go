@@ -0,0 +1,32 @@
+//go:build windows
+
+package preferences
+
+import (
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/api"
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/managers"
+	"github.com/fosrl/windows/secrets"
+)
+
+// proxySettings reads the proxy URL from config and its credentials from the
+// credential store
+func proxySettings(cm *config.ConfigManager) config.ProxySettings {
+	username, password := secrets.NewSecretManager().GetProxyCredentials()
+	return config.ProxySettings{URL: cm.GetProxy(), Username: username, Password: password}
+}
+
+// ApplyProxySettings sends API requests through the configured proxy and hands
+// the same settings to the manager for the updater. Called at startup and after
+// the settings change.
+func ApplyProxySettings(cm *config.ConfigManager) {
+	settings := proxySettings(cm)
+	if err := api.SetProxy(settings); err != nil {
+		logger.Error("Failed to apply proxy settings: %v", err)
+		return
+	}
+	if err := managers.IPCClientSetProxy(settings); err != nil {
+		logger.Warn("Failed to pass proxy settings to the manager service: %v", err)
+	}
+}
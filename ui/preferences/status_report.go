@@ -0,0 +1,151 @@
+//go:build windows
+
+package preferences
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/tunnel"
+	"github.com/fosrl/windows/version"
+
+	"github.com/tailscale/walk"
+)
+
+// statusReportTimeFormat is how times are written in a status report
+const statusReportTimeFormat = "2006-01-02 15:04:05 MST"
+
+// statusReport holds what goes into an exported status report
+type statusReport struct {
+	status        *tunnel.OLMStatusResponse // nil while disconnected
+	unavailable   bool                      // No tunnel manager to ask for the status
+	lastConnected string                    // The Last Connected value of the formatted view
+	appVersion    string
+	hostname      string
+	generated     time.Time
+}
+
+// String formats the report as text: a header, the status as the formatted
+// view shows it, then the raw JSON. Lines end in CRLF, like saved logs.
+func (r statusReport) String() string {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\r\n")
+	}
+	field := func(name, value string) {
+		if value != "" {
+			line("%-16s%s", name+":", value)
+		}
+	}
+
+	line("%s Status Report", config.AppName)
+	field("App Version", r.appVersion)
+	field("Computer", r.hostname)
+	field("Generated", r.generated.Format(statusReportTimeFormat))
+	line("")
+
+	line("Connection Status")
+	summary := tunnel.SummarizeStatus(r.status)
+	field("Status", summary.Title)
+	if r.status == nil && r.unavailable {
+		field("Reason", serviceUnavailableReason)
+	} else {
+		field("Reason", summary.Detail)
+	}
+	field("Last Connected", r.lastConnected)
+	if r.status != nil {
+		field("Tunnel Address", r.status.TunnelAddress)
+		field("Version", r.status.Version)
+		field("Agent", r.status.Agent)
+		field("Organization", r.status.OrgID)
+	}
+	line("")
+
+	line("Sites")
+	var peers []peerEntry
+	if r.status != nil {
+		peers = sortedPeers(r.status.PeerStatuses)
+	}
+	if len(peers) == 0 {
+		line("No sites connected")
+	}
+	for _, entry := range peers {
+		peer := entry.peer
+		name := peer.SiteName
+		if name == "" {
+			name = "Unknown"
+		}
+		state := "Disconnected"
+		if peer.Connected {
+			state = "Connected"
+			if peer.IsRelay {
+				state += " (relayed)"
+			}
+		}
+		line("%s (site %d): %s, %s", name, entry.siteID, state, formatHandshakeAge(peer.LastActivity(), r.generated))
+		if peer.Endpoint != "" {
+			line("  Endpoint: %s", peer.Endpoint)
+		}
+		if peer.PeerIP != "" {
+			line("  Address:  %s", peer.PeerIP)
+		}
+	}
+	line("")
+
+	line("JSON")
+	if r.status == nil {
+		if r.unavailable {
+			line("Disconnected (service unavailable)")
+		} else {
+			line("Disconnected")
+		}
+		return b.String()
+	}
+	jsonData, err := json.MarshalIndent(r.status, "", "  ")
+	if err != nil {
+		line("Error formatting JSON: %v", err)
+		return b.String()
+	}
+	line("%s", strings.ReplaceAll(string(jsonData), "\n", "\r\n"))
+	return b.String()
+}
+
+// onExportReport saves a report of the current status to a file the user picks
+func (ost *OLMStatusTab) onExportReport() {
+	now := time.Now()
+	fd := walk.FileDialog{
+		Filter:   "Text Files (*.txt)|*.txt|All Files (*.*)|*.*",
+		FilePath: fmt.Sprintf("pangolin-status-%s.txt", now.Format("2006-01-02T150405")),
+		Title:    "Export status report",
+	}
+	owner := ost.tabPage.Form()
+	if ok, _ := fd.ShowSave(owner); !ok {
+		return
+	}
+	if fd.FilterIndex == 1 && !strings.HasSuffix(fd.FilePath, ".txt") {
+		fd.FilePath = fd.FilePath + ".txt"
+	}
+
+	ost.mu.Lock()
+	report := statusReport{
+		status:      ost.currentStatus,
+		unavailable: ost.unavailable,
+	}
+	ost.mu.Unlock()
+	report.lastConnected = ost.formatLastConnected()
+	report.appVersion = version.Number
+	report.hostname, _ = os.Hostname()
+	report.generated = now
+
+	writeFileWithOverwriteHandling(owner, fd.FilePath, "Status report", func(file *os.File) error {
+		if _, err := file.WriteString(report.String()); err != nil {
+			return fmt.Errorf("failed to write status report: %w", err)
+		}
+		return nil
+	})
+}
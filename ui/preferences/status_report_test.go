@@ -0,0 +1,130 @@
+//go:build windows
+
+package preferences
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+func TestStatusReportString(t *testing.T) {
+	generated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	connected := &tunnel.OLMStatusResponse{
+		Connected:     true,
+		Registered:    true,
+		StatusReason:  tunnel.StatusReasonConnected,
+		Version:       "1.2.0",
+		Agent:         "olm",
+		OrgID:         "org-1",
+		TunnelAddress: "100.90.0.2",
+		PeerStatuses: map[int]*tunnel.OLMPeerStatus{
+			7: {SiteID: 7, SiteName: "lab", Connected: true, IsRelay: true, LastHandshake: generated.Add(-5 * time.Minute)},
+			1: {SiteID: 1, SiteName: "Office", Connected: true, LastHandshake: generated.Add(-30 * time.Second), Endpoint: "203.0.113.1:51820", PeerIP: "100.90.0.5"},
+			3: {SiteID: 3},
+		},
+	}
+	header := []string{
+		"Pangolin Status Report",
+		"App Version:    1.4.0",
+		"Computer:       DESKTOP-1",
+		"Generated:      2026-03-01 12:00:00 UTC",
+		"",
+	}
+
+	tests := []struct {
+		name   string
+		report statusReport
+		want   []string // The lines before the JSON
+		json   string   // The JSON section when the status is nil
+	}{
+		{
+			name:   "connected",
+			report: statusReport{status: connected, lastConnected: "Last connected 2h ago"},
+			want: []string{
+				"Connection Status",
+				"Status:         Connected",
+				"Last Connected: Last connected 2h ago",
+				"Tunnel Address: 100.90.0.2",
+				"Version:        1.2.0",
+				"Agent:          olm",
+				"Organization:   org-1",
+				"",
+				"Sites",
+				"lab (site 7): Connected (relayed), Last seen 5m ago",
+				"Office (site 1): Connected, Last seen 30s ago",
+				"  Endpoint: 203.0.113.1:51820",
+				"  Address:  100.90.0.5",
+				"Unknown (site 3): Disconnected, Never seen",
+				"",
+			},
+		},
+		{
+			name:   "disconnected",
+			report: statusReport{lastConnected: "Never connected"},
+			want: []string{
+				"Connection Status",
+				"Status:         Disconnected",
+				"Last Connected: Never connected",
+				"",
+				"Sites",
+				"No sites connected",
+				"",
+			},
+			json: "Disconnected",
+		},
+		{
+			name:   "service unavailable",
+			report: statusReport{unavailable: true},
+			want: []string{
+				"Connection Status",
+				"Status:         Disconnected",
+				"Reason:         " + serviceUnavailableReason,
+				"",
+				"Sites",
+				"No sites connected",
+				"",
+			},
+			json: "Disconnected (service unavailable)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.report.appVersion = "1.4.0"
+			tt.report.hostname = "DESKTOP-1"
+			tt.report.generated = generated
+			text := tt.report.String()
+			if !strings.HasSuffix(text, "\r\n") || strings.Contains(strings.ReplaceAll(text, "\r\n", ""), "\n") {
+				t.Error("report lines don't all end in CRLF")
+			}
+
+			formatted, jsonText, found := strings.Cut(text, "JSON\r\n")
+			if !found {
+				t.Fatalf("report has no JSON section:\n%s", text)
+			}
+			want := append(append([]string{}, header...), tt.want...)
+			if got := strings.Split(strings.TrimSuffix(formatted, "\r\n"), "\r\n"); !reflect.DeepEqual(got, want) {
+				t.Errorf("formatted report =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+
+			if tt.report.status == nil {
+				if jsonText != tt.json+"\r\n" {
+					t.Errorf("JSON section = %q, want %q", jsonText, tt.json)
+				}
+				return
+			}
+			var decoded tunnel.OLMStatusResponse
+			if err := json.Unmarshal([]byte(jsonText), &decoded); err != nil {
+				t.Fatalf("JSON section doesn't parse: %v", err)
+			}
+			if !reflect.DeepEqual(&decoded, tt.report.status) {
+				t.Errorf("JSON section = %+v, want %+v", decoded, tt.report.status)
+			}
+		})
+	}
+}
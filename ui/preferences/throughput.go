@@ -0,0 +1,160 @@
+//go:build windows
+
+package preferences
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+	"github.com/fosrl/windows/ui/theme"
+
+	"github.com/tailscale/walk"
+)
+
+// throughputSamples is how many rate samples the Status tab keeps for its graph
+const throughputSamples = 60
+
+// throughputHistory keeps the most recent receive and transmit rates, in bytes
+// per second, derived from the deltas between successive tunnel Stats readings
+type throughputHistory struct {
+	rx    [throughputSamples]float64
+	tx    [throughputSamples]float64
+	next  int // Index the next sample is written to
+	count int // Number of samples held, up to throughputSamples
+
+	last     tunnel.Stats
+	lastAt   time.Time
+	haveLast bool
+}
+
+// add records a Stats reading taken at now. The first reading after a reset
+// only sets the baseline. Totals that went backwards mean the adapter was
+// recreated, so the history starts over from the new reading.
+func (h *throughputHistory) add(stats tunnel.Stats, now time.Time) {
+	if !h.haveLast || stats.RxBytes < h.last.RxBytes || stats.TxBytes < h.last.TxBytes {
+		h.reset()
+		h.last, h.lastAt, h.haveLast = stats, now, true
+		return
+	}
+
+	elapsed := now.Sub(h.lastAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	h.rx[h.next] = float64(stats.RxBytes-h.last.RxBytes) / elapsed
+	h.tx[h.next] = float64(stats.TxBytes-h.last.TxBytes) / elapsed
+	h.next = (h.next + 1) % throughputSamples
+	if h.count < throughputSamples {
+		h.count++
+	}
+	h.last, h.lastAt = stats, now
+}
+
+// reset drops all samples and the baseline
+func (h *throughputHistory) reset() {
+	*h = throughputHistory{}
+}
+
+// samples returns copies of the held rates, oldest first
+func (h *throughputHistory) samples() (rx, tx []float64) {
+	rx = make([]float64, h.count)
+	tx = make([]float64, h.count)
+	start := (h.next - h.count + throughputSamples) % throughputSamples
+	for i := 0; i < h.count; i++ {
+		rx[i] = h.rx[(start+i)%throughputSamples]
+		tx[i] = h.tx[(start+i)%throughputSamples]
+	}
+	return rx, tx
+}
+
+// latest returns the most recent rates, and false if there are none yet
+func (h *throughputHistory) latest() (rx, tx float64, ok bool) {
+	if h.count == 0 {
+		return 0, 0, false
+	}
+	i := (h.next - 1 + throughputSamples) % throughputSamples
+	return h.rx[i], h.tx[i], true
+}
+
+// formatRate formats a rate in bytes per second with a binary unit, e.g. "1.2 MB/s"
+func formatRate(bytesPerSecond float64) string {
+	const unit = 1024
+	if bytesPerSecond < unit {
+		return fmt.Sprintf("%.0f B/s", bytesPerSecond)
+	}
+	value, exp := bytesPerSecond/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB/s", value, "KMGT"[exp])
+}
+
+// drawSparkline plots rx and tx as lines scaled to the largest rate in either,
+// with the newest sample at the right edge of bounds
+func drawSparkline(canvas *walk.Canvas, bounds walk.Rectangle, rx, tx []float64, colors theme.Colors) error {
+	background, err := walk.NewSolidColorBrush(colors.Background)
+	if err != nil {
+		return err
+	}
+	defer background.Dispose()
+	if err := canvas.FillRectanglePixels(background, bounds); err != nil {
+		return err
+	}
+
+	border, err := walk.NewCosmeticPen(walk.PenSolid, colors.Disabled)
+	if err != nil {
+		return err
+	}
+	defer border.Dispose()
+	if err := canvas.DrawRectanglePixels(border, bounds); err != nil {
+		return err
+	}
+
+	peak := 0.0
+	for i := range rx {
+		peak = max(peak, rx[i], tx[i])
+	}
+	if len(rx) < 2 || peak == 0 {
+		return nil
+	}
+
+	series := []struct {
+		values []float64
+		color  walk.Color
+	}{
+		{tx, colors.Muted},
+		{rx, colors.Success},
+	}
+	for _, s := range series {
+		pen, err := walk.NewCosmeticPen(walk.PenSolid, s.color)
+		if err != nil {
+			return err
+		}
+		err = canvas.DrawPolylinePixels(pen, sparklinePoints(bounds, s.values, peak))
+		pen.Dispose()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sparklinePoints maps values onto bounds, inset by a pixel so the lines
+// don't cover the border. Slots for samples not yet taken are left empty on the left.
+func sparklinePoints(bounds walk.Rectangle, values []float64, peak float64) []walk.Point {
+	left, top := bounds.X+1, bounds.Y+1
+	width, height := bounds.Width-3, bounds.Height-3
+	step := float64(width) / float64(throughputSamples-1)
+	offset := throughputSamples - len(values)
+
+	points := make([]walk.Point, len(values))
+	for i, v := range values {
+		points[i] = walk.Point{
+			X: left + int(float64(offset+i)*step),
+			Y: top + height - int(v/peak*float64(height)),
+		}
+	}
+	return points
+}
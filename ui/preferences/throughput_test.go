@@ -0,0 +1,173 @@
+//go:build windows
+
+package preferences
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/tailscale/walk"
+)
+
+func TestThroughputHistoryRates(t *testing.T) {
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	type reading struct {
+		rx, tx uint64
+		after  time.Duration
+	}
+
+	tests := []struct {
+		name     string
+		readings []reading
+		wantRx   []float64
+		wantTx   []float64
+	}{
+		{
+			name:     "baseline only",
+			readings: []reading{{rx: 5000, tx: 1000}},
+			wantRx:   []float64{},
+			wantTx:   []float64{},
+		},
+		{
+			name:     "per second",
+			readings: []reading{{rx: 5000, tx: 1000}, {rx: 7048, tx: 1512, after: time.Second}, {rx: 7048, tx: 1512, after: 2 * time.Second}},
+			wantRx:   []float64{2048, 0},
+			wantTx:   []float64{512, 0},
+		},
+		{
+			name:     "longer interval",
+			readings: []reading{{}, {rx: 10240, tx: 4096, after: 4 * time.Second}},
+			wantRx:   []float64{2560},
+			wantTx:   []float64{1024},
+		},
+		{
+			name:     "same instant ignored",
+			readings: []reading{{}, {rx: 100, tx: 100}, {rx: 200, tx: 200, after: time.Second}},
+			wantRx:   []float64{200},
+			wantTx:   []float64{200},
+		},
+		{
+			name:     "adapter recreated",
+			readings: []reading{{rx: 5000, tx: 5000}, {rx: 6000, tx: 6000, after: time.Second}, {rx: 100, tx: 7000, after: 2 * time.Second}, {rx: 600, tx: 7100, after: 3 * time.Second}},
+			wantRx:   []float64{500},
+			wantTx:   []float64{100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h throughputHistory
+			for _, r := range tt.readings {
+				h.add(tunnel.Stats{RxBytes: r.rx, TxBytes: r.tx}, start.Add(r.after))
+			}
+			rx, tx := h.samples()
+			if !reflect.DeepEqual(rx, tt.wantRx) || !reflect.DeepEqual(tx, tt.wantTx) {
+				t.Errorf("samples() = %v, %v, want %v, %v", rx, tx, tt.wantRx, tt.wantTx)
+			}
+
+			latestRx, latestTx, ok := h.latest()
+			if ok != (len(tt.wantRx) > 0) {
+				t.Fatalf("latest() ok = %v with %d samples", ok, len(tt.wantRx))
+			}
+			if ok && (latestRx != tt.wantRx[len(tt.wantRx)-1] || latestTx != tt.wantTx[len(tt.wantTx)-1]) {
+				t.Errorf("latest() = %v, %v, want the last sample", latestRx, latestTx)
+			}
+		})
+	}
+}
+
+func TestThroughputHistoryKeepsNewest(t *testing.T) {
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	var h throughputHistory
+
+	// Each second receives one more byte than the last, so sample n is n
+	var total uint64
+	h.add(tunnel.Stats{}, start)
+	for n := 1; n <= throughputSamples+5; n++ {
+		total += uint64(n)
+		h.add(tunnel.Stats{RxBytes: total}, start.Add(time.Duration(n)*time.Second))
+	}
+
+	rx, tx := h.samples()
+	if len(rx) != throughputSamples || len(tx) != throughputSamples {
+		t.Fatalf("holding %d samples, want %d", len(rx), throughputSamples)
+	}
+	for i, v := range rx {
+		if want := float64(i + 6); v != want {
+			t.Fatalf("sample %d = %v, want %v (oldest first)", i, v, want)
+		}
+	}
+	if latest, _, _ := h.latest(); latest != throughputSamples+5 {
+		t.Errorf("latest() = %v, want %d", latest, throughputSamples+5)
+	}
+}
+
+func TestThroughputHistoryReset(t *testing.T) {
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	var h throughputHistory
+	h.add(tunnel.Stats{}, start)
+	h.add(tunnel.Stats{RxBytes: 1000}, start.Add(time.Second))
+
+	h.reset()
+	if rx, _ := h.samples(); len(rx) != 0 {
+		t.Errorf("%d samples after reset", len(rx))
+	}
+	if _, _, ok := h.latest(); ok {
+		t.Error("latest() found a sample after reset")
+	}
+
+	// The next reading is a fresh baseline rather than a delta from before the reset
+	h.add(tunnel.Stats{RxBytes: 50}, start.Add(2*time.Second))
+	if rx, _ := h.samples(); len(rx) != 0 {
+		t.Errorf("first reading after reset gave samples %v", rx)
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	tests := []struct {
+		rate float64
+		want string
+	}{
+		{rate: 0, want: "0 B/s"},
+		{rate: 1023, want: "1023 B/s"},
+		{rate: 1024, want: "1.0 KB/s"},
+		{rate: 1258291, want: "1.2 MB/s"},
+		{rate: 3 << 30, want: "3.0 GB/s"},
+		{rate: 5 << 50, want: "5120.0 TB/s"},
+	}
+
+	for _, tt := range tests {
+		if got := formatRate(tt.rate); got != tt.want {
+			t.Errorf("formatRate(%v) = %q, want %q", tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestSparklinePoints(t *testing.T) {
+	// One pixel per sample inside a one pixel border
+	bounds := walk.Rectangle{X: 10, Y: 20, Width: throughputSamples + 2, Height: 103}
+	right := bounds.X + bounds.Width - 2
+
+	full := make([]float64, throughputSamples)
+	full[throughputSamples-1] = 100
+	points := sparklinePoints(bounds, full, 100)
+	if len(points) != throughputSamples {
+		t.Fatalf("%d points, want %d", len(points), throughputSamples)
+	}
+	if first := points[0]; first != (walk.Point{X: 11, Y: 121}) {
+		t.Errorf("oldest point = %v, want the bottom left inside the border", first)
+	}
+	if last := points[throughputSamples-1]; last != (walk.Point{X: right, Y: 21}) {
+		t.Errorf("newest point = %v, want the top right inside the border", last)
+	}
+
+	// A short history is drawn against the right edge
+	short := sparklinePoints(bounds, []float64{50, 100}, 100)
+	want := []walk.Point{{X: right - 1, Y: 71}, {X: right, Y: 21}}
+	if !reflect.DeepEqual(short, want) {
+		t.Errorf("short history = %v, want %v", short, want)
+	}
+}
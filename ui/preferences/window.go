@@ -4,10 +4,10 @@ package preferences
 
 import (
 	"fmt"
-	"path/filepath"
 	"sync"
 
 	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/icons"
 	"github.com/fosrl/windows/tunnel"
 
 	"github.com/fosrl/newt/logger"
@@ -21,10 +21,16 @@ type PreferencesWindow struct {
 	tabWidget     *walk.TabWidget
 	tunnelManager *tunnel.Manager
 	configManager *config.ConfigManager
-	trayIcon      *walk.NotifyIcon
+	trayIcon      TrayNotifier
 	tabs          []Tab
 }
 
+// TrayNotifier shows balloon notifications from the tray icon, such as a
+// *walk.NotifyIcon
+type TrayNotifier interface {
+	ShowInfo(title, info string) error
+}
+
 // Tab represents a tab in the preferences window
 type Tab interface {
 	// Create creates the tab UI and returns the tab page
@@ -36,6 +42,17 @@ type Tab interface {
 	Cleanup()
 }
 
+// activityAwareTab is implemented by tabs that want to know whether they are on
+// screen, e.g. to pause background work while hidden
+type activityAwareTab interface {
+	SetActive(active bool)
+}
+
+// tunnelManagerAwareTab is implemented by tabs that read from the tunnel manager
+type tunnelManagerAwareTab interface {
+	SetTunnelManager(tm *tunnel.Manager)
+}
+
 var (
 	preferencesWindowInstance *PreferencesWindow
 	preferencesWindowMutex    sync.Mutex
@@ -43,7 +60,7 @@ var (
 
 // ShowPreferencesWindow shows the preferences window (creates if needed, or brings to front).
 // It accepts a tunnel manager to enable OLM status polling, a config manager for settings, and a tray icon for notifications.
-func ShowPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.ConfigManager, trayIcon *walk.NotifyIcon) error {
+func ShowPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.ConfigManager, trayIcon TrayNotifier) error {
 	preferencesWindowMutex.Lock()
 	defer preferencesWindowMutex.Unlock()
 
@@ -84,11 +101,42 @@ func ShowPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.Confi
 
 	// Show the dialog (non-modal, doesn't block)
 	pw.SetVisible(true)
+	pw.updateTabActivity()
 	return nil
 }
 
+// SetTunnelManager hands a tunnel manager that became available to the open
+// preferences window, if any, so its tabs stop showing the service as unavailable
+func SetTunnelManager(tm *tunnel.Manager) {
+	preferencesWindowMutex.Lock()
+	defer preferencesWindowMutex.Unlock()
+
+	pw := preferencesWindowInstance
+	if pw == nil {
+		return
+	}
+	pw.tunnelManager = tm
+	for _, tab := range pw.tabs {
+		if aware, ok := tab.(tunnelManagerAwareTab); ok {
+			aware.SetTunnelManager(tm)
+		}
+	}
+}
+
+// updateTabActivity tells activity-aware tabs whether they are currently on screen:
+// the selected tab of a visible, non-minimized window
+func (pw *PreferencesWindow) updateTabActivity() {
+	windowShown := pw.Visible() && !win.IsIconic(pw.Handle())
+	currentIndex := pw.tabWidget.CurrentIndex()
+	for i, tab := range pw.tabs {
+		if aware, ok := tab.(activityAwareTab); ok {
+			aware.SetActive(windowShown && i == currentIndex)
+		}
+	}
+}
+
 // NewPreferencesWindow creates a new preferences window with tabs
-func NewPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.ConfigManager, trayIcon *walk.NotifyIcon) (*PreferencesWindow, error) {
+func NewPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.ConfigManager, trayIcon TrayNotifier) (*PreferencesWindow, error) {
 	pw := &PreferencesWindow{
 		tunnelManager: tm,
 		configManager: cm,
@@ -114,7 +162,7 @@ func NewPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.Config
 	}
 
 	// Create and add tabs
-	// Order: Preferences, Status, Logs, About
+	// Order: Preferences, Status, Logs, Live Log, About
 	prefsTab := NewPreferencesTab(cm)
 	if tabPage, err := prefsTab.Create(pw.tabWidget); err != nil {
 		return nil, fmt.Errorf("failed to create preferences tab: %w", err)
@@ -125,7 +173,7 @@ func NewPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.Config
 		pw.tabs = append(pw.tabs, prefsTab)
 	}
 
-	olmTab := NewOLMStatusTab(tm)
+	olmTab := NewOLMStatusTab(tm, cm)
 	if tabPage, err := olmTab.Create(pw.tabWidget); err != nil {
 		return nil, fmt.Errorf("failed to create OLM status tab: %w", err)
 	} else {
@@ -144,6 +192,15 @@ func NewPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.Config
 		pw.tabs = append(pw.tabs, logsTab)
 	}
 
+	liveLogTab := NewLiveLogTab()
+	if tabPage, err := liveLogTab.Create(pw.tabWidget); err != nil {
+		return nil, fmt.Errorf("failed to create live log tab: %w", err)
+	} else {
+		pw.tabWidget.Pages().Add(tabPage)
+		liveLogTab.AfterAdd()
+		pw.tabs = append(pw.tabs, liveLogTab)
+	}
+
 	aboutTab := NewAboutTab()
 	if tabPage, err := aboutTab.Create(pw.tabWidget); err != nil {
 		return nil, fmt.Errorf("failed to create about tab: %w", err)
@@ -155,9 +212,14 @@ func NewPreferencesWindow(owner walk.Form, tm *tunnel.Manager, cm *config.Config
 
 	disposables.Spare()
 
+	// Minimizing moves the window, so bounds changes cover minimize and restore
+	pw.tabWidget.CurrentIndexChanged().Attach(pw.updateTabActivity)
+	pw.VisibleChanged().Attach(pw.updateTabActivity)
+	pw.BoundsChanged().Attach(pw.updateTabActivity)
+
 	// Set window icon
 	iconsPath := config.GetIconsPath()
-	iconPath := filepath.Join(iconsPath, "icon-orange.ico")
+	iconPath := icons.Path(iconsPath, "icon-orange.ico")
 	icon, err := walk.NewIconFromFile(iconPath)
 	if err != nil {
 		logger.Error("Failed to load window icon from %s: %v", iconPath, err)
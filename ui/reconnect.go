@@ -0,0 +1,69 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+var reconnectAction *walk.Action
+
+// restartingStatusText is the tray status while Reconnect stops and starts the tunnel
+const restartingStatusText = "Restarting..."
+
+// setupReconnectAction creates the "Reconnect" action, which restarts a tunnel
+// that is up but not working without having to disconnect and connect
+func setupReconnectAction(actions *walk.ActionList) error {
+	reconnectAction = walk.NewAction()
+	reconnectAction.SetText("Reconnect")
+	reconnectAction.SetVisible(false) // Shown with the connect action
+	reconnectAction.Triggered().Attach(func() {
+		go reconnectTunnel()
+	})
+	return actions.Add(reconnectAction)
+}
+
+// reconnectTunnel restarts the tunnel, or tells the user there is nothing to restart
+func reconnectTunnel() {
+	if tunnelManager == nil {
+		return
+	}
+	// The tunnel goes down on purpose, which is not worth a "Disconnected" balloon
+	markUserDisconnect()
+	err := tunnelManager.Reconnect()
+	// The manager stops restarting after the last state change, so refresh the status
+	updateMenu()
+	switch {
+	case err == nil, errors.Is(err, tunnel.ErrConnectCanceled):
+	case errors.Is(err, tunnel.ErrNotConnected):
+		walk.App().Synchronize(func() {
+			td := walk.NewTaskDialog()
+			_, _ = td.Show(walk.TaskDialogOpts{
+				Owner:         mainWindow,
+				Title:         "Not Connected",
+				Content:       "There is no connection to restart. Use Connect to start the tunnel.",
+				IconSystem:    walk.TaskDialogSystemIconInformation,
+				CommonButtons: win.TDCBF_OK_BUTTON,
+			})
+		})
+	default:
+		logger.Error("Failed to reconnect tunnel: %v", err)
+		showConnectionErrorDialog("Reconnect Failed", err)
+	}
+}
+
+// updateReconnectAction shows the reconnect action with the connect action and
+// enables it only while the tunnel is up. Must be called on the UI thread.
+func updateReconnectAction(show bool, state tunnel.State, restarting bool) {
+	if reconnectAction == nil {
+		return
+	}
+	reconnectAction.SetVisible(show)
+	reconnectAction.SetEnabled(tunnel.CanReconnect(state) && !restarting && !isServiceUnavailable())
+}
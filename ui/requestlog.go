@@ -0,0 +1,28 @@
+//go:build windows
+
+package ui
+
+import (
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/api"
+	"github.com/fosrl/windows/config"
+)
+
+// ApplyRequestLogging sets what the API client logs about its requests from the
+// config, or from api.RequestLoggingEnv when that asks for more
+func ApplyRequestLogging(cm *config.ConfigManager) {
+	mode := api.RequestLoggingOff
+	if cm.GetLogAPIRequests() {
+		mode = api.RequestLoggingSummary
+		if cm.GetLogAPIBodies() {
+			mode = api.RequestLoggingBodies
+		}
+	}
+	if env := api.RequestLoggingFromEnv(); env > mode {
+		mode = env
+	}
+	if mode != api.RequestLoggingOff {
+		logger.Info("API request logging: %s", mode)
+	}
+	api.SetRequestLogging(mode)
+}
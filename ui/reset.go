@@ -0,0 +1,169 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/managers"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+var (
+	resetRunning bool
+	resetMutex   sync.Mutex
+)
+
+// resetStep is one part of a full reset
+type resetStep struct {
+	name string
+	run  func() error
+}
+
+// resetSteps returns the steps of a full reset, in the order they run. The
+// tunnel is stopped first, while the credentials it was started with still exist.
+func resetSteps() []resetStep {
+	return []resetStep{
+		{"Stop tunnel", resetStopTunnel},
+		{"Delete secrets", func() error {
+			if !secretManager.DeleteAll() {
+				return errors.New("failed to delete the stored credentials")
+			}
+			return nil
+		}},
+		{"Remove accounts", accountManager.Clear},
+		{"Remove settings", func() error {
+			if !configManager.Remove() {
+				return errors.New("failed to remove " + config.ConfigFileName)
+			}
+			return nil
+		}},
+		{"Clear cached user data", func() error {
+			authManager.Reset()
+			return nil
+		}},
+	}
+}
+
+// resetStopTunnel stops the tunnel through the manager
+func resetStopTunnel() error {
+	ping := func() error {
+		_, err := managers.IPCClientPing()
+		return err
+	}
+	return stopTunnelIfReachable(ping, func() error {
+		markUserDisconnect()
+		if tunnelManager != nil {
+			return tunnelManager.Disconnect()
+		}
+		return managers.IPCClientStopTunnel()
+	})
+}
+
+// stopTunnelIfReachable runs stop if ping reaches the manager. If it can't be
+// reached there is nothing to stop from here, so the step is skipped.
+func stopTunnelIfReachable(ping, stop func() error) error {
+	if err := ping(); err != nil {
+		logger.Warn("Reset: manager is not reachable, not stopping the tunnel: %v", err)
+		return nil
+	}
+	return stop()
+}
+
+// runResetSteps runs every step even if an earlier one fails, so a reset
+// removes as much as it can, and returns the failures
+func runResetSteps(steps []resetStep) []string {
+	var failures []string
+	for _, step := range steps {
+		logger.Info("Reset: %s", step.name)
+		if err := step.run(); err != nil {
+			logger.Error("Reset: %s failed: %v", step.name, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", step.name, err))
+		}
+	}
+	return failures
+}
+
+// resetPangolin asks for confirmation and then removes all accounts, secrets and
+// settings, returning the app to its first-run state. Must be called on the UI thread.
+func resetPangolin() {
+	resetMutex.Lock()
+	if resetRunning {
+		resetMutex.Unlock()
+		return
+	}
+	resetRunning = true
+	resetMutex.Unlock()
+
+	if !confirmReset() {
+		resetMutex.Lock()
+		resetRunning = false
+		resetMutex.Unlock()
+		return
+	}
+
+	go func() {
+		defer func() {
+			resetMutex.Lock()
+			resetRunning = false
+			resetMutex.Unlock()
+		}()
+
+		logger.Info("Resetting Pangolin to defaults")
+		failures := runResetSteps(resetSteps())
+
+		tray.setLoggedOut(false)
+		updateMenu()
+
+		walk.App().Synchronize(func() {
+			if len(failures) == 0 {
+				return
+			}
+			td := walk.NewTaskDialog()
+			_, _ = td.Show(walk.TaskDialogOpts{
+				Owner:         mainWindow,
+				Title:         "Reset " + config.AppName,
+				Instruction:   "Some data could not be removed.",
+				Content:       strings.Join(failures, "\n"),
+				IconSystem:    walk.TaskDialogSystemIconWarning,
+				CommonButtons: win.TDCBF_OK_BUTTON,
+			})
+		})
+	}()
+}
+
+// confirmReset asks the user to confirm a full reset. The Reset button stays
+// disabled until the user ticks the acknowledgement. Must be called on the UI thread.
+func confirmReset() bool {
+	confirmed := false
+	resetButton := walk.TaskDialogCustomButton{MainText: "Reset", InitiallyDisabled: true}
+	resetButton.Clicked().Attach(func() bool {
+		confirmed = true
+		return false // Close the dialog
+	})
+
+	td := walk.NewTaskDialog()
+	td.VerificationClicked().Attach(func(checked bool) bool {
+		td.EnableCustomButton(0, checked)
+		return true
+	})
+	_, _ = td.Show(walk.TaskDialogOpts{
+		Owner:       mainWindow,
+		Title:       "Reset " + config.AppName,
+		Instruction: "Reset " + config.AppName + " to its defaults?",
+		Content: "This disconnects the tunnel, logs out of every account, deletes all stored credentials " +
+			"and removes all settings. " + config.AppName + " will start over as if it was just installed.",
+		IconSystem:       walk.TaskDialogSystemIconWarning,
+		VerificationText: "I understand this can't be undone",
+		CustomButtons:    []walk.TaskDialogCustomButton{resetButton},
+		CommonButtons:    win.TDCBF_CANCEL_BUTTON,
+		DefaultButton:    walk.TaskDialogDefaultButtonCancel,
+	})
+	return confirmed
+}
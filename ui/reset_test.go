@@ -0,0 +1,77 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResetStepsOrder(t *testing.T) {
+	var names []string
+	for _, step := range resetSteps() {
+		names = append(names, step.name)
+	}
+	// The tunnel stops while the credentials it was started with still exist
+	want := []string{"Stop tunnel", "Delete secrets", "Remove accounts", "Remove settings", "Clear cached user data"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("reset steps = %v, want %v", names, want)
+	}
+}
+
+func TestRunResetSteps(t *testing.T) {
+	var ran []string
+	step := func(name string, err error) resetStep {
+		return resetStep{name, func() error {
+			ran = append(ran, name)
+			return err
+		}}
+	}
+
+	failures := runResetSteps([]resetStep{
+		step("first", nil),
+		step("second", errors.New("locked")),
+		step("third", nil),
+		step("fourth", errors.New("missing")),
+	})
+	if want := []string{"first", "second", "third", "fourth"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+	if want := []string{"second: locked", "fourth: missing"}; !reflect.DeepEqual(failures, want) {
+		t.Errorf("failures = %v, want %v", failures, want)
+	}
+}
+
+func TestStopTunnelIfReachable(t *testing.T) {
+	errStop := errors.New("stop failed")
+	tests := []struct {
+		name        string
+		pingErr     error
+		stopErr     error
+		wantStopped bool
+		wantErr     error
+	}{
+		{name: "manager reachable", wantStopped: true},
+		{name: "stop fails", stopErr: errStop, wantStopped: true, wantErr: errStop},
+		{name: "IPC unavailable", pingErr: errors.New("pipe not found")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stopped := false
+			err := stopTunnelIfReachable(
+				func() error { return tt.pingErr },
+				func() error {
+					stopped = true
+					return tt.stopErr
+				})
+			if stopped != tt.wantStopped {
+				t.Errorf("stopped = %v, want %v", stopped, tt.wantStopped)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/managers"
+
+	"github.com/fosrl/newt/logger"
+)
+
+const (
+	// serviceHealthInterval is how often the tray pings the manager service
+	serviceHealthInterval = 10 * time.Second
+	// serviceUnavailableAfter is how many pings in a row must fail before the
+	// service is reported unavailable
+	serviceUnavailableAfter = 3
+)
+
+var (
+	serviceHealthMutex    sync.Mutex
+	serviceHealthFailures int
+	serviceUnavailable    bool
+)
+
+// isServiceUnavailable reports whether the manager service stopped answering pings
+func isServiceUnavailable() bool {
+	serviceHealthMutex.Lock()
+	defer serviceHealthMutex.Unlock()
+	return serviceUnavailable
+}
+
+// ipcErrorText describes an error from an IPC call for an error dialog, saying
+// plainly that the service is not responding when the call timed out, or that
+// the UI is still reconnecting to it
+func ipcErrorText(err error) string {
+	if errors.Is(err, managers.ErrIPCTimeout) {
+		return "the " + config.AppName + " service is not responding. Try again in a moment."
+	}
+	if errors.Is(err, managers.ErrIPCReconnecting) {
+		return "reconnecting to the " + config.AppName + " service. Try again in a moment."
+	}
+	return err.Error()
+}
+
+// updateCheckFailureHint suggests what to do about a failed update check,
+// telling a service that can't be reached apart from an update server that
+// can't be
+func updateCheckFailureHint(err error) string {
+	if managers.IsManagerUnreachable(err) {
+		return "The " + config.AppName + " service could not be reached. If this keeps happening, restart the service or your computer."
+	}
+	return "The update server could not be reached. Check your network connection and proxy settings."
+}
+
+// recordPingResult counts consecutive ping failures and reports whether the
+// service's availability changed
+func recordPingResult(err error) (changed bool) {
+	serviceHealthMutex.Lock()
+	defer serviceHealthMutex.Unlock()
+
+	if err == nil {
+		serviceHealthFailures = 0
+		changed = serviceUnavailable
+		serviceUnavailable = false
+		return changed
+	}
+	serviceHealthFailures++
+	if serviceHealthFailures >= serviceUnavailableAfter && !serviceUnavailable {
+		serviceUnavailable = true
+		return true
+	}
+	return false
+}
+
+// startServiceHealthMonitor periodically pings the manager service and refreshes
+// the menu when it stops or starts answering
+func startServiceHealthMonitor() {
+	go func() {
+		ticker := time.NewTicker(serviceHealthInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			result, err := managers.IPCClientPing()
+			if err != nil {
+				logger.Debug("Manager service ping failed: %v", err)
+			}
+			if !recordPingResult(err) {
+				continue
+			}
+			if err != nil {
+				logger.Warn("Manager service is unavailable: %v", err)
+			} else {
+				logger.Info("Manager service is available again (uptime %v, tunnel %s)", result.Uptime.Round(time.Second), result.TunnelState.DisplayText())
+			}
+			updateMenu()
+		}
+	}()
+}
@@ -0,0 +1,67 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fosrl/windows/managers"
+)
+
+func TestRecordPingResult(t *testing.T) {
+	defer func() {
+		serviceHealthFailures, serviceUnavailable = 0, false
+	}()
+	errPipe := errors.New("pipe closed")
+
+	steps := []struct {
+		name            string
+		err             error
+		wantChanged     bool
+		wantUnavailable bool
+	}{
+		{name: "answered", err: nil},
+		{name: "first timeout", err: managers.ErrPingTimeout},
+		{name: "second timeout", err: managers.ErrPingTimeout},
+		{name: "third timeout", err: managers.ErrPingTimeout, wantChanged: true, wantUnavailable: true},
+		{name: "still failing", err: errPipe, wantUnavailable: true},
+		{name: "back", err: nil, wantChanged: true},
+		{name: "one failure after coming back", err: errPipe},
+		{name: "answered again", err: nil},
+	}
+
+	serviceHealthFailures, serviceUnavailable = 0, false
+	for _, step := range steps {
+		changed := recordPingResult(step.err)
+		if changed != step.wantChanged {
+			t.Errorf("%s: changed = %v, want %v", step.name, changed, step.wantChanged)
+		}
+		if got := isServiceUnavailable(); got != step.wantUnavailable {
+			t.Errorf("%s: isServiceUnavailable() = %v, want %v", step.name, got, step.wantUnavailable)
+		}
+	}
+}
+
+func TestUpdateCheckFailureHint(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string // Part of the hint
+	}{
+		{name: "service not responding", err: managers.ErrIPCTimeout, want: "service could not be reached"},
+		{name: "reconnecting to the service", err: fmt.Errorf("check for update: %w", managers.ErrIPCReconnecting), want: "service could not be reached"},
+		{name: "update server down", err: errors.New("failed to download update manifest: 503 Service Unavailable"), want: "update server could not be reached"},
+		{name: "no network", err: errors.New("WinHTTP: the server name could not be resolved"), want: "update server could not be reached"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateCheckFailureHint(tt.err); !strings.Contains(got, tt.want) {
+				t.Errorf("updateCheckFailureHint(%v) = %q, want it to say %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
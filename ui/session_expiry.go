@@ -0,0 +1,97 @@
+//go:build windows
+
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fosrl/windows/auth"
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+)
+
+var (
+	sessionExpiryWatcher *auth.SessionExpiryWatcher
+	// sessionExpiryAction shows the upcoming expiry once the user has been warned
+	sessionExpiryAction *walk.Action
+
+	sessionWarningMutex sync.Mutex
+	// sessionWarnedFor is the expiry the user was last warned about
+	sessionWarnedFor time.Time
+)
+
+// setupSessionExpiryAction creates the (initially hidden) menu line announcing the session expiry
+func setupSessionExpiryAction(actions *walk.ActionList) {
+	sessionExpiryAction = walk.NewAction()
+	sessionExpiryAction.SetEnabled(false)
+	sessionExpiryAction.SetVisible(false)
+	actions.Add(sessionExpiryAction)
+}
+
+// startSessionExpiryWatcher warns the user ahead of the organization's maximum
+// session length and asks them to log in again once it is reached
+func startSessionExpiryWatcher() {
+	if authManager == nil || configManager == nil {
+		return
+	}
+	sessionExpiryWatcher = authManager.NewSessionExpiryWatcher(configManager.GetSessionExpiryWarningLead, handleSessionExpiryWarning, handleSessionExpired)
+	sessionExpiryWatcher.Start()
+}
+
+func handleSessionExpiryWarning(expiresAt time.Time) {
+	sessionWarningMutex.Lock()
+	sessionWarnedFor = expiresAt
+	sessionWarningMutex.Unlock()
+
+	walk.App().Synchronize(func() {
+		if tray.icon != nil {
+			message := fmt.Sprintf("Your session expires at %s. Log in again to stay connected.", expiresAt.Format("3:04 PM"))
+			if err := tray.icon.ShowWarning("Session Expiring", message); err != nil {
+				logger.Error("Failed to show session expiry notification: %v", err)
+			}
+		}
+	})
+	updateMenu()
+}
+
+func handleSessionExpired() {
+	authManager.MarkSessionExpired()
+	if tunnelManager != nil && tunnelManager.State() != tunnel.StateStopped {
+		markUserDisconnect()
+		if err := tunnelManager.Disconnect(); err != nil {
+			logger.Error("Failed to disconnect after session expiry: %v", err)
+		}
+	}
+
+	walk.App().Synchronize(func() {
+		if tray.icon != nil {
+			if err := tray.icon.ShowWarning("Session Expired", "Your session has expired. Log in again to reconnect."); err != nil {
+				logger.Error("Failed to show session expiry notification: %v", err)
+			}
+		}
+	})
+	updateMenu()
+}
+
+// updateSessionExpiryAction shows the expiry the user was warned about, until a new
+// login replaces the session. Must be called on the UI thread.
+func updateSessionExpiryAction(show bool) {
+	if sessionExpiryAction == nil {
+		return
+	}
+	sessionWarningMutex.Lock()
+	warnedFor := sessionWarnedFor
+	sessionWarningMutex.Unlock()
+
+	expiresAt := authManager.SessionExpiry()
+	if !show || warnedFor.IsZero() || !expiresAt.Equal(warnedFor) || !time.Now().Before(expiresAt) {
+		sessionExpiryAction.SetVisible(false)
+		return
+	}
+	sessionExpiryAction.SetText(fmt.Sprintf("Session expires at %s", expiresAt.Format("3:04 PM")))
+	sessionExpiryAction.SetVisible(true)
+}
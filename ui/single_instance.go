@@ -0,0 +1,73 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"syscall"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+)
+
+// showMenuMessageName is registered with Windows so a second UI instance can ask
+// the running one to open its tray menu
+const showMenuMessageName = "PangolinShowTrayMenu"
+
+// asfwAny lets any process take the foreground, so the running UI may show its menu
+const asfwAny = ^uintptr(0)
+
+var (
+	user32                       = syscall.NewLazyDLL("user32.dll")
+	procAllowSetForegroundWindow = user32.NewProc("AllowSetForegroundWindow")
+)
+
+// showMenuMessage returns the window message ID for showMenuMessageName
+func showMenuMessage() uint32 {
+	name, _ := syscall.UTF16PtrFromString(showMenuMessageName)
+	return win.RegisterWindowMessage(name)
+}
+
+// SignalRunningInstance asks the UI already running in this session to open its tray menu
+func SignalRunningInstance() error {
+	msg := showMenuMessage()
+	if msg == 0 {
+		return errors.New("failed to register the show menu message")
+	}
+	procAllowSetForegroundWindow.Call(asfwAny)
+	if win.PostMessage(win.HWND_BROADCAST, msg, 0, 0) == 0 {
+		return errors.New("failed to post the show menu message")
+	}
+	return nil
+}
+
+// showMenuHandler opens the tray menu when another instance signals this one
+type showMenuHandler struct {
+	msg uint32
+}
+
+func (h *showMenuHandler) OnPreTranslate(msg *win.MSG) bool {
+	if h.msg == 0 || msg.Message != h.msg {
+		return false
+	}
+	// The broadcast reaches every top-level window; act on it only once
+	if tray.icon == nil || mainWindow == nil || msg.HWnd != mainWindow.Handle() {
+		return true
+	}
+	logger.Info("Another instance was started, showing the tray menu")
+
+	handleMenuOpen()
+	updateMenu()
+
+	// ShowContextMenu moves the point onto the icon, so the cursor is only a hint
+	var pt win.POINT
+	win.GetCursorPos(&pt)
+	tray.icon.ShowContextMenu(int(pt.X), int(pt.Y))
+	return true
+}
+
+// watchForOtherInstances shows the tray menu whenever a second instance signals this one
+func watchForOtherInstances() {
+	walk.App().AddGlobalPreTranslateHandler(&showMenuHandler{msg: showMenuMessage()})
+}
@@ -0,0 +1,200 @@
+//go:build windows
+
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fosrl/windows/managers"
+	"github.com/fosrl/windows/tunnel"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+)
+
+// siteMenuItem is one entry of the tray's Sites submenu
+type siteMenuItem struct {
+	SiteID int
+	Text   string
+	// Connected is set while OLM reports the site connected and the user didn't disconnect it
+	Connected bool
+	// Disconnected is set if the user disconnected the site from the menu
+	Disconnected bool
+}
+
+var (
+	sitesMenu       *walk.Menu
+	sitesMenuAction *walk.Action
+	// sitesMenuItems is the model the submenu was last built from (only accessed on the UI thread)
+	sitesMenuItems []siteMenuItem
+
+	// disconnectedSites are the sites the user disconnected from the running tunnel
+	disconnectedSites     = make(map[int]bool)
+	disconnectedSitesLock sync.Mutex
+)
+
+// buildSiteMenuModel turns the peers of an OLM status into Sites submenu entries,
+// ordered by name and then site ID so the menu doesn't reshuffle between polls.
+// disconnected are the sites the user disconnected.
+func buildSiteMenuModel(peers map[int]*tunnel.OLMPeerStatus, disconnected map[int]bool) []siteMenuItem {
+	items := make([]siteMenuItem, 0, len(peers))
+	for siteID, peer := range peers {
+		if peer == nil {
+			continue
+		}
+		text := peer.SiteName
+		if text == "" {
+			text = fmt.Sprintf("Site %d", siteID)
+		}
+		items = append(items, siteMenuItem{
+			SiteID:       siteID,
+			Text:         text,
+			Connected:    peer.Connected && !disconnected[siteID],
+			Disconnected: disconnected[siteID],
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := strings.ToLower(items[i].Text), strings.ToLower(items[j].Text)
+		if a != b {
+			return a < b
+		}
+		return items[i].SiteID < items[j].SiteID
+	})
+	return items
+}
+
+// siteMenuModelsEqual reports whether two models would produce the same menu
+func siteMenuModelsEqual(a, b []siteMenuItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// setupSitesMenu creates the Sites submenu and adds it to actions
+func setupSitesMenu(actions *walk.ActionList) error {
+	var err error
+	sitesMenu, err = walk.NewMenu()
+	if err != nil {
+		logger.Error("Failed to create sites menu: %v", err)
+		return err
+	}
+	sitesMenuAction = walk.NewMenuAction(sitesMenu)
+	sitesMenuAction.SetText("Sites")
+	sitesMenuAction.SetVisible(false) // Hidden initially
+	actions.Add(sitesMenuAction)
+
+	rebuildSitesMenu(nil)
+	return nil
+}
+
+// updateSitesMenu rebuilds the Sites submenu if the model changed.
+// Must be called on the UI thread.
+func updateSitesMenu(items []siteMenuItem) {
+	if sitesMenu == nil {
+		return
+	}
+	if sitesMenuItems != nil && siteMenuModelsEqual(items, sitesMenuItems) {
+		return
+	}
+	rebuildSitesMenu(items)
+}
+
+// rebuildSitesMenu replaces the submenu entries. Each site is checked while
+// connected; choosing it disconnects it, or connects it again if the user
+// disconnected it.
+func rebuildSitesMenu(items []siteMenuItem) {
+	sitesMenuItems = append([]siteMenuItem{}, items...)
+
+	actions := sitesMenu.Actions()
+	if err := actions.Clear(); err != nil {
+		logger.Error("Failed to clear sites menu: %v", err)
+		return
+	}
+
+	if len(items) == 0 {
+		noSitesAction := walk.NewAction()
+		noSitesAction.SetText("No sites")
+		noSitesAction.SetEnabled(false)
+		actions.Add(noSitesAction)
+		return
+	}
+
+	for _, item := range items {
+		item := item
+		action := walk.NewAction()
+		action.SetText(item.Text)
+		action.SetCheckable(true)
+		action.SetChecked(item.Connected)
+		action.Triggered().Attach(func() {
+			// Keep the check mark until the manager has done it
+			action.SetChecked(item.Connected)
+			go setSiteConnected(item.SiteID, item.Disconnected)
+		})
+		actions.Add(action)
+	}
+}
+
+// setSiteConnected has the manager connect or disconnect a single site
+func setSiteConnected(siteID int, connected bool) {
+	if err := managers.IPCClientSetSiteConnected(siteID, connected); err != nil {
+		logger.Error("Failed to change site %d: %v", siteID, err)
+		title := "Disconnect Site Failed"
+		if connected {
+			title = "Connect Site Failed"
+		}
+		showConnectionErrorDialog(title, err)
+	}
+	refreshDisconnectedSites()
+	if tunnelManager != nil {
+		// Show the change without waiting for the next poll
+		if status, err := tunnelManager.GetOLMStatus(); err == nil {
+			items := buildSiteMenuModel(status.PeerStatuses, currentDisconnectedSites())
+			walk.App().Synchronize(func() {
+				updateSitesMenu(items)
+			})
+		}
+	}
+}
+
+// refreshDisconnectedSites fetches the sites the user disconnected from the manager
+func refreshDisconnectedSites() {
+	sites, err := managers.IPCClientDisconnectedSites()
+	if err != nil {
+		logger.Debug("Failed to get disconnected sites from manager: %v", err)
+		sites = nil
+	}
+	disconnectedSitesLock.Lock()
+	defer disconnectedSitesLock.Unlock()
+	clear(disconnectedSites)
+	for _, siteID := range sites {
+		disconnectedSites[siteID] = true
+	}
+}
+
+// clearDisconnectedSites forgets the disconnected sites once the tunnel is
+// down, as the manager does
+func clearDisconnectedSites() {
+	disconnectedSitesLock.Lock()
+	defer disconnectedSitesLock.Unlock()
+	clear(disconnectedSites)
+}
+
+// currentDisconnectedSites returns a copy of the sites the user disconnected
+func currentDisconnectedSites() map[int]bool {
+	disconnectedSitesLock.Lock()
+	defer disconnectedSitesLock.Unlock()
+	sites := make(map[int]bool, len(disconnectedSites))
+	for siteID := range disconnectedSites {
+		sites[siteID] = true
+	}
+	return sites
+}
@@ -0,0 +1,102 @@
+//go:build windows
+
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fosrl/windows/tunnel"
+)
+
+func TestBuildSiteMenuModel(t *testing.T) {
+	tests := []struct {
+		name         string
+		peers        map[int]*tunnel.OLMPeerStatus
+		disconnected map[int]bool
+		want         []siteMenuItem
+	}{
+		{
+			name: "no peers",
+			want: []siteMenuItem{},
+		},
+		{
+			name: "ordered by name ignoring case",
+			peers: map[int]*tunnel.OLMPeerStatus{
+				1: {SiteName: "office", Connected: true},
+				2: {SiteName: "Datacenter", Connected: false},
+				3: {SiteName: "Lab", Connected: true},
+			},
+			want: []siteMenuItem{
+				{SiteID: 2, Text: "Datacenter"},
+				{SiteID: 3, Text: "Lab", Connected: true},
+				{SiteID: 1, Text: "office", Connected: true},
+			},
+		},
+		{
+			name: "same name ordered by site ID",
+			peers: map[int]*tunnel.OLMPeerStatus{
+				9: {SiteName: "Edge", Connected: true},
+				4: {SiteName: "edge", Connected: true},
+			},
+			want: []siteMenuItem{
+				{SiteID: 4, Text: "edge", Connected: true},
+				{SiteID: 9, Text: "Edge", Connected: true},
+			},
+		},
+		{
+			name: "unnamed site and missing peer",
+			peers: map[int]*tunnel.OLMPeerStatus{
+				7: {Connected: true},
+				8: nil,
+			},
+			want: []siteMenuItem{
+				{SiteID: 7, Text: "Site 7", Connected: true},
+			},
+		},
+		{
+			name: "disconnected by the user",
+			peers: map[int]*tunnel.OLMPeerStatus{
+				1: {SiteName: "A", Connected: true},
+				2: {SiteName: "B", Connected: false},
+			},
+			disconnected: map[int]bool{1: true, 2: true, 5: true},
+			want: []siteMenuItem{
+				{SiteID: 1, Text: "A", Disconnected: true},
+				{SiteID: 2, Text: "B", Disconnected: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildSiteMenuModel(tt.peers, tt.disconnected)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildSiteMenuModel() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSiteMenuModelsEqual(t *testing.T) {
+	a := []siteMenuItem{{SiteID: 1, Text: "A", Connected: true}}
+	tests := []struct {
+		name string
+		b    []siteMenuItem
+		want bool
+	}{
+		{name: "same", b: []siteMenuItem{{SiteID: 1, Text: "A", Connected: true}}, want: true},
+		{name: "connection changed", b: []siteMenuItem{{SiteID: 1, Text: "A"}}},
+		{name: "disconnected by the user", b: []siteMenuItem{{SiteID: 1, Text: "A", Connected: true, Disconnected: true}}},
+		{name: "renamed", b: []siteMenuItem{{SiteID: 1, Text: "B", Connected: true}}},
+		{name: "site added", b: append(append([]siteMenuItem{}, a...), siteMenuItem{SiteID: 2, Text: "B"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := siteMenuModelsEqual(a, tt.b); got != tt.want {
+				t.Errorf("siteMenuModelsEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
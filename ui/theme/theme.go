@@ -0,0 +1,140 @@
+//go:build windows
+
+package theme
+
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+	"golang.org/x/sys/windows"
+)
+
+// Colors is the palette used for custom-colored widgets
+type Colors struct {
+	Dark       bool
+	Background walk.Color
+	Foreground walk.Color
+	Muted      walk.Color // Secondary text such as descriptions and values
+	Success    walk.Color
+	Error      walk.Color
+	Warning    walk.Color
+	Disabled   walk.Color
+}
+
+var lightColors = Colors{
+	Dark:       false,
+	Background: walk.RGB(0xFC, 0xFC, 0xFC),
+	Foreground: walk.RGB(0, 0, 0),
+	Muted:      walk.RGB(100, 100, 100),
+	Success:    walk.RGB(0, 200, 0),
+	Error:      walk.RGB(200, 0, 0),
+	Warning:    walk.RGB(230, 160, 0),
+	Disabled:   walk.RGB(150, 150, 150),
+}
+
+var darkColors = Colors{
+	Dark:       true,
+	Background: walk.RGB(0x20, 0x20, 0x20),
+	Foreground: walk.RGB(0xF0, 0xF0, 0xF0),
+	Muted:      walk.RGB(170, 170, 170),
+	Success:    walk.RGB(80, 220, 80),
+	Error:      walk.RGB(255, 100, 100),
+	Warning:    walk.RGB(255, 190, 60),
+	Disabled:   walk.RGB(120, 120, 120),
+}
+
+// colorsFor returns the palette for the given theme
+func colorsFor(dark bool) Colors {
+	if dark {
+		return darkColors
+	}
+	return lightColors
+}
+
+// ThemeColors returns the palette for the current Windows app theme
+func ThemeColors() Colors {
+	return colorsFor(IsDarkMode())
+}
+
+// IsDarkMode detects if Windows is in dark mode
+func IsDarkMode() bool {
+	var key windows.Handle
+	keyPath := windows.StringToUTF16Ptr(`Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`)
+	err := windows.RegOpenKeyEx(windows.HKEY_CURRENT_USER, keyPath, 0, windows.KEY_READ, &key)
+	if err != nil {
+		// Default to light mode if we can't detect
+		return false
+	}
+	defer windows.RegCloseKey(key)
+
+	var value uint32
+	var valueLen uint32 = 4
+	valueName := windows.StringToUTF16Ptr("AppsUseLightTheme")
+	err = windows.RegQueryValueEx(key, valueName, nil, nil, (*byte)(unsafe.Pointer(&value)), &valueLen)
+	if err != nil {
+		// Default to light mode if we can't read the value
+		return false
+	}
+
+	// AppsUseLightTheme: 0 = dark mode, 1 = light mode
+	return value == 0
+}
+
+var (
+	changedPublisher walk.EventPublisher
+	lastDark         bool
+	lastDarkOnce     sync.Once
+)
+
+// Changed returns the event published on the UI thread when the app theme switches
+// between light and dark. It only fires once Watch has been called.
+func Changed() *walk.Event {
+	return changedPublisher.Event()
+}
+
+// watchingWindow wraps a top-level window to observe theme change broadcasts
+type watchingWindow struct {
+	*walk.MainWindow
+}
+
+func (w *watchingWindow) WndProc(hwnd win.HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	if isThemeChangeMessage(msg, lParam) {
+		checkForChange()
+	}
+	return w.MainWindow.WndProc(hwnd, msg, wParam, lParam)
+}
+
+// Watch starts listening for theme changes on mw, which must be a top-level
+// window so it receives WM_SETTINGCHANGE broadcasts. It may be hidden.
+func Watch(mw *walk.MainWindow) error {
+	lastDarkOnce.Do(func() { lastDark = IsDarkMode() })
+	return walk.InitWrapperWindow(&watchingWindow{mw})
+}
+
+// isThemeChangeMessage reports whether a window message may signal a theme switch.
+// Windows announces app theme changes as WM_SETTINGCHANGE for "ImmersiveColorSet".
+func isThemeChangeMessage(msg uint32, lParam uintptr) bool {
+	switch msg {
+	case win.WM_THEMECHANGED:
+		return true
+	case win.WM_SETTINGCHANGE:
+		if lParam == 0 {
+			return false
+		}
+		return windows.UTF16PtrToString(*(**uint16)(unsafe.Pointer(&lParam))) == "ImmersiveColorSet"
+	}
+	return false
+}
+
+// checkForChange publishes Changed if the theme differs from the last one seen.
+// Only called on the UI thread.
+func checkForChange() {
+	dark := IsDarkMode()
+	if dark == lastDark {
+		return
+	}
+	lastDark = dark
+	changedPublisher.Publish()
+}
@@ -0,0 +1,87 @@
+//go:build windows
+
+package theme
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/tailscale/walk"
+	"github.com/tailscale/win"
+	"golang.org/x/sys/windows"
+)
+
+func TestColorsFor(t *testing.T) {
+	tests := []struct {
+		dark bool
+		want Colors
+	}{
+		{dark: false, want: lightColors},
+		{dark: true, want: darkColors},
+	}
+
+	for _, tt := range tests {
+		got := colorsFor(tt.dark)
+		if got != tt.want {
+			t.Errorf("colorsFor(%v) = %+v, want %+v", tt.dark, got, tt.want)
+		}
+		if got.Dark != tt.dark {
+			t.Errorf("colorsFor(%v).Dark = %v", tt.dark, got.Dark)
+		}
+	}
+}
+
+// luminance approximates how light a color is, from 0 to 255
+func luminance(c walk.Color) int {
+	return (299*int(c.R()) + 587*int(c.G()) + 114*int(c.B())) / 1000
+}
+
+func TestTextStandsOutFromBackground(t *testing.T) {
+	for _, dark := range []bool{false, true} {
+		colors := colorsFor(dark)
+		background := luminance(colors.Background)
+		texts := map[string]walk.Color{
+			"Foreground": colors.Foreground,
+			"Muted":      colors.Muted,
+			"Success":    colors.Success,
+			"Error":      colors.Error,
+			"Warning":    colors.Warning,
+		}
+		for name, color := range texts {
+			diff := luminance(color) - background
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff < 60 {
+				t.Errorf("dark=%v: %s %v is too close to the background %v", dark, name, color, colors.Background)
+			}
+		}
+	}
+}
+
+func TestIsThemeChangeMessage(t *testing.T) {
+	setting := func(s string) uintptr {
+		return uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(s)))
+	}
+
+	tests := []struct {
+		name   string
+		msg    uint32
+		lParam uintptr
+		want   bool
+	}{
+		{name: "theme changed", msg: win.WM_THEMECHANGED, want: true},
+		{name: "app theme setting", msg: win.WM_SETTINGCHANGE, lParam: setting("ImmersiveColorSet"), want: true},
+		{name: "another setting", msg: win.WM_SETTINGCHANGE, lParam: setting("intl")},
+		{name: "setting without a name", msg: win.WM_SETTINGCHANGE},
+		{name: "unrelated message", msg: win.WM_PAINT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isThemeChangeMessage(tt.msg, tt.lParam); got != tt.want {
+				t.Errorf("isThemeChangeMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
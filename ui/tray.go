@@ -3,6 +3,7 @@
 package ui
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -10,15 +11,18 @@ import (
 	"strings"
 	"sync"
 	"time"
-	"unsafe"
+	"unicode/utf8"
 
 	"github.com/fosrl/windows/api"
 	"github.com/fosrl/windows/auth"
 	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/icons"
 	"github.com/fosrl/windows/managers"
 	"github.com/fosrl/windows/secrets"
+	"github.com/fosrl/windows/services"
 	"github.com/fosrl/windows/tunnel"
 	"github.com/fosrl/windows/ui/preferences"
+	"github.com/fosrl/windows/ui/theme"
 	"github.com/fosrl/windows/updater"
 	"github.com/fosrl/windows/version"
 
@@ -28,10 +32,24 @@ import (
 	"github.com/tailscale/win"
 )
 
-var (
-	trayIcon           *walk.NotifyIcon
+// trayNotifyIcon is the part of walk.NotifyIcon the tray changes as the state
+// changes, so a Tray can be driven without a real notification area icon
+type trayNotifyIcon interface {
+	SetIcon(icon walk.Image) error
+	SetToolTip(toolTip string) error
+	ShowInfo(title, info string) error
+	ShowWarning(title, info string) error
+	ShowContextMenu(x, y int)
+}
+
+// Tray is the notification area icon with its menu, and the state they show.
+// The icon, menus, actions and action maps are created and changed only on the
+// UI thread; other goroutines change them through walk.App().Synchronize, usually
+// by calling updateMenu. The remaining flags are shared with other goroutines and
+// have their own mutex.
+type Tray struct {
+	icon               trayNotifyIcon
 	contextMenu        *walk.Menu
-	mainWindow         *walk.MainWindow
 	hasUpdate          bool
 	updateMutex        sync.RWMutex
 	startupDialogShown bool
@@ -54,17 +72,13 @@ var (
 	updateFoundCb      *managers.UpdateFoundCallback
 	updateProgressCb   *managers.UpdateProgressCallback
 	managerStoppingCb  *managers.ManagerStoppingCallback
+	statsCb            *managers.StatsCallback
 	isConnected        bool
 	connectMutex       sync.RWMutex
 	isLoggedOut        bool
 	loggedOutMutex     sync.RWMutex
 	currentTunnelState managers.TunnelState
 	tunnelStateMutex   sync.RWMutex
-	authManager        *auth.AuthManager
-	configManager      *config.ConfigManager
-	accountManager     *config.AccountManager
-	apiClient          *api.APIClient
-	tunnelManager      *tunnel.Manager
 	orgMenu            *walk.Menu
 	accountMenu        *walk.Menu
 	moreMenu           *walk.Menu
@@ -72,28 +86,284 @@ var (
 	accountActions     map[string]*walk.Action
 	noOrgsAction       *walk.Action
 	noAccountsAction   *walk.Action
+	menuUpdatePending  bool // An updateMenu is queued and hasn't started yet
 	menuUpdateMutex    sync.Mutex
+	statsMutex         sync.Mutex
+	statsCancel        context.CancelFunc    // Stops the tooltip's traffic refresh
+	statsCtx           context.Context       // Canceled by statsCancel, guarded by statsMutex
+	statusSummary      *tunnel.StatusSummary // Latest OLM status while connected, guarded by statsMutex
+	statsFeed          tunnel.SnapshotFeed   // Pushed snapshots, which replace polling the traffic
+}
+
+// tray is the app's tray, set up by SetupTray. The package-level functions below
+// that share a name with a Tray method act on it, for the rest of the package.
+var tray = new(Tray)
+
+// The main window and the managers are shared by the tray and the package's
+// dialogs. They are set once in SetupTray before any callback or goroutine that
+// uses them starts.
+var (
+	mainWindow     *walk.MainWindow
+	authManager    *auth.AuthManager
+	configManager  *config.ConfigManager
+	accountManager *config.AccountManager
+	apiClient      *api.APIClient
+	tunnelManager  *tunnel.Manager
+	secretManager  *secrets.SecretManager
 )
 
-// updateTrayTooltip updates the tray icon tooltip to show the current tunnel state
-func updateTrayTooltip(state tunnel.State) {
-	if trayIcon == nil {
+// synchronize runs f on the UI thread for the tray. Tests, which have no
+// message loop, replace it.
+var synchronize = func(f func()) { walk.App().Synchronize(f) }
+
+// updateMenu updates the tray's menu. It may be called from any goroutine.
+func updateMenu() { tray.updateMenu() }
+
+// setTrayIconForState sets the tray's icon for state. Must be called on the UI thread.
+func setTrayIconForState(state tunnel.State) { tray.setTrayIconForState(state) }
+
+// updateTrayTooltip sets the tray's tooltip for state. Must be called on the UI thread.
+func updateTrayTooltip(state tunnel.State) { tray.updateTrayTooltip(state) }
+
+// handleMenuOpen refreshes the session and organizations before the tray's menu opens
+func handleMenuOpen() { tray.handleMenuOpen() }
+
+// setLoggedOut records whether the active account's session was found to be
+// logged out, which hides the connection part of the menu
+func (t *Tray) setLoggedOut(loggedOut bool) {
+	t.loggedOutMutex.Lock()
+	t.isLoggedOut = loggedOut
+	t.loggedOutMutex.Unlock()
+}
+
+// updateTrayTooltip updates the tray icon tooltip to show the current tunnel state.
+// Must be called on the UI thread.
+func (t *Tray) updateTrayTooltip(state tunnel.State) {
+	if t.icon == nil {
 		return
 	}
 
-	stateText := state.DisplayText()
-	tooltipText := fmt.Sprintf("%s: %s", config.AppName, stateText)
-	if err := trayIcon.SetToolTip(tooltipText); err != nil {
+	tooltipText := formatTrayTooltip(state, "", nil, nil)
+	if tunnelManager != nil && state == tunnel.StateReconnecting {
+		tooltipText = fmt.Sprintf("%s: %s", config.AppName, tunnelStatusText(state, tunnelManager.ReconnectAttempt()))
+	}
+	if tunnelManager != nil && state == tunnel.StateStopped {
+		if pause := tunnelManager.PauseStatus(); pause.Paused {
+			tooltipText = fmt.Sprintf("%s: Paused until %s", config.AppName, pause.ResumeAt.Format("3:04 PM"))
+		}
+	}
+	if state == tunnel.StateStopped && configManager != nil {
+		tooltipText += "\n" + preferences.FormatLastConnected(configManager.GetLastConnectedAt(), time.Now())
+	}
+	if err := t.icon.SetToolTip(tooltipText); err != nil {
 		logger.Error("Failed to set tray tooltip: %v", err)
 	}
 }
 
-// setTrayIconForState sets the tray icon based on tunnel state, with overlay for transitional states
-func setTrayIconForState(state tunnel.State) {
-	if trayIcon == nil {
+// recordLastConnected saves the time the tunnel came up when it changes to
+// Running from another state, so repeated Running notifications don't move it
+func recordLastConnected(previous, state tunnel.State, now time.Time) {
+	if state != tunnel.StateRunning || previous == tunnel.StateRunning || configManager == nil {
+		return
+	}
+	if !configManager.SetLastConnectedAt(now) {
+		logger.Error("Failed to save the last connection time")
+	}
+}
+
+// lastConnectedRefreshInterval is how often the tooltip's last connected age is refreshed while disconnected
+const lastConnectedRefreshInterval = time.Minute
+
+// startLastConnectedRefresh keeps the "Last connected" age in the tooltip current while disconnected
+func (t *Tray) startLastConnectedRefresh() {
+	go func() {
+		ticker := time.NewTicker(lastConnectedRefreshInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			t.tunnelStateMutex.RLock()
+			state := tunnel.State(t.currentTunnelState)
+			t.tunnelStateMutex.RUnlock()
+			if state != tunnel.StateStopped {
+				continue
+			}
+			walk.App().Synchronize(func() {
+				t.tunnelStateMutex.RLock()
+				stillStopped := tunnel.State(t.currentTunnelState) == tunnel.StateStopped
+				t.tunnelStateMutex.RUnlock()
+				if stillStopped {
+					t.updateTrayTooltip(tunnel.StateStopped)
+				}
+			})
+		}
+	}()
+}
+
+// trayStatsInterval is how often the tooltip's traffic totals are refreshed while connected
+const trayStatsInterval = 5 * time.Second
+
+// formatTrayTooltip builds the tray tooltip, adding the organization, connected
+// sites and traffic totals when connected and they are known
+func formatTrayTooltip(state tunnel.State, orgName string, summary *tunnel.StatusSummary, stats *tunnel.Stats) string {
+	text := fmt.Sprintf("%s: %s", config.AppName, state.DisplayText())
+	if state != tunnel.StateRunning {
+		return text
+	}
+	if orgName != "" {
+		text += " to " + orgName
+	}
+	if summary != nil {
+		if sites := summary.PeerCountText(); sites != "" {
+			text += ", " + sites
+		}
+	}
+	if stats != nil {
+		text += fmt.Sprintf(", ↓%s ↑%s", formatByteCount(stats.RxBytes), formatByteCount(stats.TxBytes))
+	}
+	return text
+}
+
+// formatByteCount formats a byte count with a binary unit, e.g. "1.2 MB"
+func formatByteCount(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+// startTrayStats periodically refreshes the tooltip with traffic totals until stopTrayStats
+func (t *Tray) startTrayStats() {
+	t.statsMutex.Lock()
+	defer t.statsMutex.Unlock()
+	if t.statsCancel != nil || tunnelManager == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.statsCtx, t.statsCancel = ctx, cancel
+	go func() {
+		ticker := time.NewTicker(trayStatsInterval)
+		defer ticker.Stop()
+
+		for {
+			// Snapshots pushed by the manager keep the tooltip current while they arrive
+			if t.statsFeed.Stale(time.Now()) {
+				t.refreshTrayStats(ctx)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopTrayStats stops the tooltip refresh started by startTrayStats
+func (t *Tray) stopTrayStats() {
+	t.statsMutex.Lock()
+	defer t.statsMutex.Unlock()
+	if t.statsCancel != nil {
+		t.statsCancel()
+		t.statsCancel = nil
+		t.statsCtx = nil
+	}
+	t.statusSummary = nil
+}
+
+// setStatusSummary keeps the summary of the latest OLM status for the tooltip
+// while the traffic refresh runs
+func (t *Tray) setStatusSummary(status *tunnel.OLMStatusResponse) {
+	summary := tunnel.SummarizeStatus(status)
+	t.statsMutex.Lock()
+	defer t.statsMutex.Unlock()
+	if t.statsCancel != nil {
+		t.statusSummary = &summary
+	}
+}
+
+// handleStatsSnapshot refreshes the tooltip from a snapshot pushed by the manager
+func (t *Tray) handleStatsSnapshot(snapshot managers.StatsSnapshot) {
+	t.statsFeed.Received(time.Now())
+	if !snapshot.HaveTraffic {
+		return
+	}
+
+	summary := tunnel.SummarizeStatus(snapshot.Status())
+	t.statsMutex.Lock()
+	ctx := t.statsCtx
+	if ctx != nil {
+		t.statusSummary = &summary
+	}
+	t.statsMutex.Unlock()
+	if ctx == nil {
+		return
+	}
+	t.showTrayStats(ctx, snapshot.Traffic)
+}
+
+func (t *Tray) refreshTrayStats(ctx context.Context) {
+	stats, err := tunnelManager.Stats()
+	if err != nil {
+		if !errors.Is(err, tunnel.ErrTunnelNotRunning) {
+			logger.Debug("Failed to read tunnel stats: %v", err)
+		}
+		return
+	}
+	t.showTrayStats(ctx, stats)
+}
+
+// showTrayStats shows the traffic totals in stats in the tooltip, unless ctx
+// was canceled because the tunnel left Running
+func (t *Tray) showTrayStats(ctx context.Context, stats tunnel.Stats) {
+	var orgName string
+	if authManager != nil {
+		if org := authManager.CurrentOrg(); org != nil {
+			orgName = org.Name
+		}
+	}
+	t.statsMutex.Lock()
+	summary := t.statusSummary
+	t.statsMutex.Unlock()
+	tooltipText := formatTrayTooltip(tunnel.StateRunning, orgName, summary, &stats)
+
+	walk.App().Synchronize(func() {
+		// Don't overwrite the tooltip of a state that replaced Running meanwhile
+		if ctx.Err() != nil || t.icon == nil {
+			return
+		}
+		if err := t.icon.SetToolTip(tooltipText); err != nil {
+			logger.Error("Failed to set tray tooltip: %v", err)
+		}
+	})
+}
+
+// setTrayIconForState sets the tray icon based on tunnel state, with overlay for
+// transitional states. Must be called on the UI thread, which also guards the icon caches.
+func (t *Tray) setTrayIconForState(state tunnel.State) {
+	if t.icon == nil {
 		return
 	}
 
+	// A paused tunnel is stopped but gets its own icon
+	if state == tunnel.StateStopped && tunnelManager != nil && tunnelManager.PauseStatus().Paused {
+		icon, err := pausedIcon(16)
+		if err != nil {
+			logger.Error("Failed to create paused icon: %v", err)
+		} else {
+			if err := t.icon.SetIcon(icon); err != nil {
+				logger.Error("Failed to set tray icon: %v", err)
+			}
+			return
+		}
+	}
+
 	// For simple states (stopped/running), use icon directly to avoid conversion artifacts
 	if state == tunnel.StateStopped || state == tunnel.StateRunning {
 		var iconName string
@@ -102,13 +372,13 @@ func setTrayIconForState(state tunnel.State) {
 		} else {
 			iconName = "icon-gray.ico"
 		}
-		iconPath := filepath.Join(config.GetIconsPath(), iconName)
+		iconPath := icons.Path(config.GetIconsPath(), iconName)
 		icon, err := walk.NewIconFromFile(iconPath)
 		if err != nil {
 			logger.Error("Failed to load icon from %s: %v", iconPath, err)
 			return
 		}
-		if err := trayIcon.SetIcon(icon); err != nil {
+		if err := t.icon.SetIcon(icon); err != nil {
 			logger.Error("Failed to set tray icon: %v", err)
 		}
 		return
@@ -119,20 +389,20 @@ func setTrayIconForState(state tunnel.State) {
 	if err != nil {
 		logger.Error("Failed to create icon for state %s: %v", state.String(), err)
 		// Fallback to gray icon
-		iconPath := filepath.Join(config.GetIconsPath(), "icon-gray.ico")
+		iconPath := icons.Path(config.GetIconsPath(), "icon-gray.ico")
 		fallbackIcon, err := walk.NewIconFromFile(iconPath)
 		if err != nil {
 			logger.Error("Failed to load fallback icon from %s: %v", iconPath, err)
 			return
 		}
-		if err := trayIcon.SetIcon(fallbackIcon); err != nil {
+		if err := t.icon.SetIcon(fallbackIcon); err != nil {
 			logger.Error("Failed to set tray icon: %v", err)
 		}
 		return
 	}
 
 	// SetIcon accepts walk.Image for composite icons with overlay
-	if err := trayIcon.SetIcon(icon); err != nil {
+	if err := t.icon.SetIcon(icon); err != nil {
 		logger.Error("Failed to set tray icon: %v", err)
 	}
 }
@@ -143,7 +413,7 @@ func openURL(url string) {
 }
 
 // handleMenuOpen verifies session and refreshes organizations when menu opens
-func handleMenuOpen() {
+func (t *Tray) handleMenuOpen() {
 	if authManager == nil || apiClient == nil {
 		return
 	}
@@ -161,10 +431,8 @@ func handleMenuOpen() {
 		// Check if server is down
 		if authManager.IsServerDown() {
 			// Server is down, but keep UI visible
-			loggedOutMutex.Lock()
-			isLoggedOut = false
-			loggedOutMutex.Unlock()
-			updateMenu()
+			t.setLoggedOut(false)
+			t.updateMenu()
 			return
 		}
 
@@ -172,13 +440,10 @@ func handleMenuOpen() {
 		user, err := apiClient.GetUser()
 		if err != nil {
 			// 401/403: API callback already set sessionExpired; do not set isLoggedOut so we show "Account Locked" + "Log In"
-			var apiErr *api.APIError
-			if !(errors.As(err, &apiErr) && (apiErr.Status == 401 || apiErr.Status == 403)) {
-				loggedOutMutex.Lock()
-				isLoggedOut = true
-				loggedOutMutex.Unlock()
+			if !api.IsAuthError(err) {
+				t.setLoggedOut(true)
 			}
-			updateMenu()
+			t.updateMenu()
 			return
 		}
 
@@ -201,12 +466,10 @@ func handleMenuOpen() {
 			}
 		}
 
-		loggedOutMutex.Lock()
-		isLoggedOut = false
-		loggedOutMutex.Unlock()
+		t.setLoggedOut(false)
 
 		// Update menu to reflect updated state
-		updateMenu()
+		t.updateMenu()
 
 		// Refresh organizations in background
 		if authManager.IsAuthenticated() {
@@ -214,74 +477,75 @@ func handleMenuOpen() {
 				logger.Error("Failed to refresh organizations: %v", err)
 			} else {
 				// Update menu again after orgs refresh
-				updateMenu()
+				t.updateMenu()
 			}
 		}
 	}()
 }
 
 // setupMenu creates the menu structure once
-func setupMenu() error {
-	if contextMenu == nil {
+func (t *Tray) setupMenu() error {
+	if t.contextMenu == nil {
 		return fmt.Errorf("context menu not initialized")
 	}
 
-	actions := contextMenu.Actions()
+	actions := t.contextMenu.Actions()
 
 	// Create update action (initially hidden)
-	updateAction = walk.NewAction()
-	updateAction.SetText("Update Available")
-	updateAction.SetVisible(false) // Hidden initially
-	updateAction.Triggered().Attach(func() {
+	t.updateAction = walk.NewAction()
+	t.updateAction.SetText("Update Available")
+	t.updateAction.SetVisible(false) // Hidden initially
+	t.updateAction.Triggered().Attach(func() {
 		go triggerUpdate(mainWindow)
 	})
-	actions.Add(updateAction)
+	actions.Add(t.updateAction)
 
 	// Create loading action
-	loadingAction = walk.NewAction()
-	loadingAction.SetText("Loading...")
-	loadingAction.SetEnabled(false)
-	actions.Add(loadingAction)
+	t.loadingAction = walk.NewAction()
+	t.loadingAction.SetText("Loading...")
+	t.loadingAction.SetEnabled(false)
+	actions.Add(t.loadingAction)
 
 	// Create server down action (initially hidden)
-	serverDownAction = walk.NewAction()
-	serverDownAction.SetText("The server appears to be down.")
-	serverDownAction.SetEnabled(false)
-	serverDownAction.SetVisible(false)
-	actions.Add(serverDownAction)
+	t.serverDownAction = walk.NewAction()
+	t.serverDownAction.SetText("The server appears to be down.")
+	t.serverDownAction.SetEnabled(false)
+	t.serverDownAction.SetVisible(false)
+	actions.Add(t.serverDownAction)
 
 	// Create error message action (initially hidden)
-	errorMessageAction = walk.NewAction()
-	errorMessageAction.SetEnabled(false)
-	errorMessageAction.SetVisible(false)
-	actions.Add(errorMessageAction)
+	t.errorMessageAction = walk.NewAction()
+	t.errorMessageAction.SetEnabled(false)
+	t.errorMessageAction.SetVisible(false)
+	actions.Add(t.errorMessageAction)
 
 	// Create status action
-	statusAction = walk.NewAction()
-	statusAction.SetText("Disconnected")
-	statusAction.SetEnabled(false)
-	statusAction.SetVisible(false) // Hidden initially
-	actions.Add(statusAction)
+	t.statusAction = walk.NewAction()
+	t.statusAction.SetText("Disconnected")
+	t.statusAction.SetEnabled(false)
+	t.statusAction.SetVisible(false) // Hidden initially
+	actions.Add(t.statusAction)
+	setupSessionExpiryAction(actions)
 
 	// Create re-auth Log In action (shown when session expired, replaces connect)
-	reAuthLoginAction = walk.NewAction()
-	reAuthLoginAction.SetText("Log In")
-	reAuthLoginAction.SetVisible(false) // Shown only when sessionExpired
-	reAuthLoginAction.Triggered().Attach(func() {
+	t.reAuthLoginAction = walk.NewAction()
+	t.reAuthLoginAction.SetText("Log In")
+	t.reAuthLoginAction.SetVisible(false) // Shown only when sessionExpired
+	t.reAuthLoginAction.Triggered().Attach(func() {
 		if authManager != nil {
 			authManager.SetStartDeviceAuthImmediately(true)
 		}
 		ShowLoginDialog(mainWindow, authManager, configManager, accountManager, apiClient, tunnelManager)
 		time.Sleep(100 * time.Millisecond)
-		updateMenu()
+		t.updateMenu()
 	})
-	actions.Add(reAuthLoginAction)
+	actions.Add(t.reAuthLoginAction)
 
 	// Create connect action
-	connectAction = walk.NewAction()
-	connectAction.SetText("Connect")
-	connectAction.SetVisible(false) // Hidden initially
-	connectAction.Triggered().Attach(func() {
+	t.connectAction = walk.NewAction()
+	t.connectAction.SetText("Connect")
+	t.connectAction.SetVisible(false) // Hidden initially
+	t.connectAction.Triggered().Attach(func() {
 		go func() {
 			if tunnelManager == nil {
 				logger.Error("Tunnel manager not initialized")
@@ -307,6 +571,7 @@ func setupMenu() error {
 			if currentState != tunnel.StateStopped && currentState != tunnel.StateStopping {
 				// Disconnect (or cancel connection)
 				logger.Info("Disconnecting...")
+				markUserDisconnect()
 				err := tunnelManager.Disconnect()
 				if err != nil {
 					logger.Error("Failed to stop tunnel: %v", err)
@@ -321,7 +586,7 @@ func setupMenu() error {
 						} else {
 							// Fallback to generic error
 							title = "Disconnect Failed"
-							message = err.Error()
+							message = "Failed to stop the tunnel: " + ipcErrorText(err)
 						}
 
 						td := walk.NewTaskDialog()
@@ -337,7 +602,10 @@ func setupMenu() error {
 			} else if currentState == tunnel.StateStopped {
 				// Connect
 				err := tunnelManager.Connect()
-				if err != nil {
+				if errors.Is(err, tunnel.ErrConnectCanceled) {
+					// Canceled on purpose, e.g. by logging out; nothing to report
+					logger.Info("Connect canceled")
+				} else if err != nil {
 					logger.Error("Failed to start tunnel: %v", err)
 					// Show error dialog to user
 					walk.App().Synchronize(func() {
@@ -367,52 +635,66 @@ func setupMenu() error {
 			// If state is Stopping, do nothing (button should be disabled)
 		}()
 	})
-	actions.Add(connectAction)
+	actions.Add(t.connectAction)
+
+	if err := setupReconnectAction(actions); err != nil {
+		return err
+	}
+
+	// Create pause submenu and resume action
+	if err := setupPauseMenu(actions); err != nil {
+		return err
+	}
 
 	actions.Add(walk.NewSeparatorAction())
 
 	// Create account selector menu
 	var err error
-	accountMenu, err = walk.NewMenu()
+	t.accountMenu, err = walk.NewMenu()
 	if err != nil {
 		logger.Error("Failed to create org menu: %v", err)
 		return err
 	}
-	accountMenuAction = walk.NewMenuAction(accountMenu)
-	accountMenuAction.SetText("Accounts")
-	accountMenuAction.SetVisible(false) // Hidden initially
-	actions.Add(accountMenuAction)
+	t.accountMenuAction = walk.NewMenuAction(t.accountMenu)
+	t.accountMenuAction.SetText("Accounts")
+	t.accountMenuAction.SetVisible(false) // Hidden initially
+	actions.Add(t.accountMenuAction)
 
 	// Create organizations menu
-	orgMenu, err = walk.NewMenu()
+	t.orgMenu, err = walk.NewMenu()
 	if err != nil {
 		logger.Error("Failed to create org menu: %v", err)
 		return err
 	}
-	orgsMenuAction = walk.NewMenuAction(orgMenu)
-	orgsMenuAction.SetText("Organizations")
-	orgsMenuAction.SetVisible(false) // Hidden initially
-	actions.Add(orgsMenuAction)
+	t.orgsMenuAction = walk.NewMenuAction(t.orgMenu)
+	t.orgsMenuAction.SetText("Organizations")
+	t.orgsMenuAction.SetVisible(false) // Hidden initially
+	actions.Add(t.orgsMenuAction)
+
+	// Create sites menu
+	if err := setupSitesMenu(actions); err != nil {
+		return err
+	}
 
 	// Separator before login
 	actions.Add(walk.NewSeparatorAction())
 
 	// Create login action (only when no accounts are available)
-	loginAction = walk.NewAction()
-	loginAction.SetText("Login to account")
-	loginAction.Triggered().Attach(func() {
+	t.loginAction = walk.NewAction()
+	t.loginAction.SetText("Login to account")
+	t.loginAction.Triggered().Attach(func() {
 		ShowLoginDialog(mainWindow, authManager, configManager, accountManager, apiClient, tunnelManager)
 		// Update menu after dialog closes (login may have succeeded)
 		time.Sleep(100 * time.Millisecond) // Small delay to let auth state update
-		updateMenu()
+		t.updateMenu()
 	})
-	actions.Add(loginAction)
+	actions.Add(t.loginAction)
 
 	// Separator before More
 	actions.Add(walk.NewSeparatorAction())
 
 	// Create More submenu
-	moreMenu, err = walk.NewMenu()
+	t.moreMenu, err = walk.NewMenu()
 	if err != nil {
 		logger.Error("Failed to create more menu: %v", err)
 		return err
@@ -422,52 +704,57 @@ func setupMenu() error {
 	supportLabel := walk.NewAction()
 	supportLabel.SetText("Support")
 	supportLabel.SetEnabled(false)
-	moreMenu.Actions().Add(supportLabel)
+	t.moreMenu.Actions().Add(supportLabel)
 
 	howItWorksAction := walk.NewAction()
 	howItWorksAction.SetText("How Pangolin Works")
 	howItWorksAction.Triggered().Attach(func() {
 		openURL("https://docs.pangolin.net/about/how-pangolin-works")
 	})
-	moreMenu.Actions().Add(howItWorksAction)
+	t.moreMenu.Actions().Add(howItWorksAction)
 
 	docAction := walk.NewAction()
 	docAction.SetText("Documentation")
 	docAction.Triggered().Attach(func() {
 		openURL("https://docs.pangolin.net/")
 	})
-	moreMenu.Actions().Add(docAction)
+	t.moreMenu.Actions().Add(docAction)
 
-	moreMenu.Actions().Add(walk.NewSeparatorAction())
+	t.moreMenu.Actions().Add(walk.NewSeparatorAction())
 
 	// Copyright
 	copyrightText := fmt.Sprintf("© %d Fossorial, Inc.", time.Now().Year())
 	copyrightAction := walk.NewAction()
 	copyrightAction.SetText(copyrightText)
 	copyrightAction.SetEnabled(false)
-	moreMenu.Actions().Add(copyrightAction)
+	t.moreMenu.Actions().Add(copyrightAction)
 
 	termsAction := walk.NewAction()
 	termsAction.SetText("Terms of Service")
 	termsAction.Triggered().Attach(func() {
 		openURL("https://pangolin.net/terms-of-service.html")
 	})
-	moreMenu.Actions().Add(termsAction)
+	t.moreMenu.Actions().Add(termsAction)
 
 	privacyAction := walk.NewAction()
 	privacyAction.SetText("Privacy Policy")
 	privacyAction.Triggered().Attach(func() {
 		openURL("https://pangolin.net/privacy-policy.html")
 	})
-	moreMenu.Actions().Add(privacyAction)
+	t.moreMenu.Actions().Add(privacyAction)
 
-	moreMenu.Actions().Add(walk.NewSeparatorAction())
+	t.moreMenu.Actions().Add(walk.NewSeparatorAction())
 
 	// Version information
 	versionAction := walk.NewAction()
 	versionAction.SetText(fmt.Sprintf("Version: %s", version.Number))
 	versionAction.SetEnabled(false)
-	moreMenu.Actions().Add(versionAction)
+	t.moreMenu.Actions().Add(versionAction)
+
+	aboutAction := walk.NewAction()
+	aboutAction.SetText("About " + config.AppName)
+	aboutAction.Triggered().Attach(showAboutDialog)
+	t.moreMenu.Actions().Add(aboutAction)
 
 	// Check for Updates action
 	checkUpdateAction := walk.NewAction()
@@ -477,21 +764,21 @@ func setupMenu() error {
 			logger.Info("Checking for updates via manager...")
 			logger.Info("Current version: %s", version.Number)
 
-			// Check update state via manager IPC
-			updateState, err := managers.IPCClientUpdateState()
-			if err != nil {
+			// Ask the manager to check now; this works even when automatic checks
+			// are disabled. A failed check is offered again until it succeeds or
+			// the user gives up.
+			var updateState managers.UpdateState
+			for {
+				var err error
+				updateState, err = managers.IPCClientCheckForUpdate()
+				if err == nil {
+					break
+				}
 				logger.Error("Update check failed: %v", err)
-				walk.App().Synchronize(func() {
-					td := walk.NewTaskDialog()
-					_, _ = td.Show(walk.TaskDialogOpts{
-						Owner:         mainWindow,
-						Title:         "Update Check Failed",
-						Content:       fmt.Sprintf("Failed to check for updates: %v", err),
-						IconSystem:    walk.TaskDialogSystemIconError,
-						CommonButtons: win.TDCBF_OK_BUTTON,
-					})
-				})
-				return
+				if !retryUpdateCheck(mainWindow, err) {
+					return
+				}
+				logger.Info("Retrying update check...")
 			}
 
 			switch updateState {
@@ -525,7 +812,134 @@ func setupMenu() error {
 			}
 		}()
 	})
-	moreMenu.Actions().Add(checkUpdateAction)
+	t.moreMenu.Actions().Add(checkUpdateAction)
+
+	// Offline install for machines that can't reach the update server
+	installFromFileAction := walk.NewAction()
+	installFromFileAction.SetText("Install from File…")
+	installFromFileAction.Triggered().Attach(func() {
+		installUpdateFromFile(mainWindow)
+	})
+	t.moreMenu.Actions().Add(installFromFileAction)
+
+	// Update channel toggle; the manager owns the setting
+	betaUpdatesAction := walk.NewAction()
+	betaUpdatesAction.SetText("Receive Beta Updates")
+	betaUpdatesAction.SetCheckable(true)
+	betaUpdatesAction.Triggered().Attach(func() {
+		channel := config.UpdateChannelStable
+		if betaUpdatesAction.Checked() {
+			channel = config.UpdateChannelBeta
+		}
+		go func() {
+			err := managers.IPCClientSetUpdateChannel(channel)
+			walk.App().Synchronize(func() {
+				if err != nil {
+					logger.Error("Failed to set update channel: %v", err)
+					// Revert the checkmark to the previous channel
+					betaUpdatesAction.SetChecked(channel != config.UpdateChannelBeta)
+					td := walk.NewTaskDialog()
+					_, _ = td.Show(walk.TaskDialogOpts{
+						Owner:         mainWindow,
+						Title:         "Update Channel",
+						Content:       fmt.Sprintf("Failed to change the update channel: %s", ipcErrorText(err)),
+						IconSystem:    walk.TaskDialogSystemIconError,
+						CommonButtons: win.TDCBF_OK_BUTTON,
+					})
+				}
+			})
+		}()
+	})
+	t.moreMenu.Actions().Add(betaUpdatesAction)
+
+	// Connect/disconnect notifications toggle
+	notificationsAction := walk.NewAction()
+	notificationsAction.SetText("Show Connection Notifications")
+	notificationsAction.SetCheckable(true)
+	notificationsAction.SetChecked(configManager.GetNotifyOnStateChange())
+	notificationsAction.Triggered().Attach(func() {
+		if !configManager.SetNotifyOnStateChange(notificationsAction.Checked()) {
+			logger.Error("Failed to save notification preference")
+			notificationsAction.SetChecked(!notificationsAction.Checked())
+		}
+	})
+	t.moreMenu.Actions().Add(notificationsAction)
+
+	// Quick reachability check of the server before starting the tunnel
+	endpointCheckAction := walk.NewAction()
+	endpointCheckAction.SetText("Check Server Before Connecting")
+	endpointCheckAction.SetCheckable(true)
+	endpointCheckAction.SetChecked(configManager.GetCheckEndpointBeforeConnect())
+	endpointCheckAction.Triggered().Attach(func() {
+		if !configManager.SetCheckEndpointBeforeConnect(endpointCheckAction.Checked()) {
+			logger.Error("Failed to save server check preference")
+			endpointCheckAction.SetChecked(!endpointCheckAction.Checked())
+		}
+	})
+	t.moreMenu.Actions().Add(endpointCheckAction)
+
+	// Open the tray at login via the user's Run key
+	runAtLoginAction := walk.NewAction()
+	runAtLoginAction.SetText("Start at Login")
+	runAtLoginAction.SetCheckable(true)
+	if enabled, err := services.IsRunAtLogin(); err != nil {
+		logger.Error("Failed to read start at login setting: %v", err)
+	} else {
+		runAtLoginAction.SetChecked(enabled)
+	}
+	runAtLoginAction.Triggered().Attach(func() {
+		enable := runAtLoginAction.Checked()
+		if err := services.SetRunAtLogin(enable); err != nil {
+			logger.Error("Failed to change start at login setting: %v", err)
+			runAtLoginAction.SetChecked(!enable)
+			td := walk.NewTaskDialog()
+			_, _ = td.Show(walk.TaskDialogOpts{
+				Owner:         mainWindow,
+				Title:         "Start at Login",
+				Content:       fmt.Sprintf("Failed to change whether Pangolin starts at login: %v", err),
+				IconSystem:    walk.TaskDialogSystemIconError,
+				CommonButtons: win.TDCBF_OK_BUTTON,
+			})
+		}
+	})
+	t.moreMenu.Actions().Add(runAtLoginAction)
+
+	// Check the service, credentials, server and adapter and show the results
+	diagnosticsAction := walk.NewAction()
+	diagnosticsAction.SetText("Run Diagnostics")
+	diagnosticsAction.Triggered().Attach(runDiagnostics)
+	t.moreMenu.Actions().Add(diagnosticsAction)
+
+	// Log each API request, for debugging problems with the server
+	requestLogAction := walk.NewAction()
+	requestLogAction.SetText("Log Server Requests")
+	requestLogAction.SetCheckable(true)
+	requestLogAction.SetChecked(configManager.GetLogAPIRequests())
+	requestLogAction.Triggered().Attach(func() {
+		if !configManager.SetLogAPIRequests(requestLogAction.Checked()) {
+			logger.Error("Failed to save request logging preference")
+			requestLogAction.SetChecked(!requestLogAction.Checked())
+			return
+		}
+		ApplyRequestLogging(configManager)
+	})
+	t.moreMenu.Actions().Add(requestLogAction)
+
+	// Remove all accounts, secrets and settings, for troubleshooting
+	resetAction := walk.NewAction()
+	resetAction.SetText("Reset " + config.AppName + "…")
+	resetAction.Triggered().Attach(resetPangolin)
+	t.moreMenu.Actions().Add(resetAction)
+	go func() {
+		channel, err := managers.IPCClientUpdateChannel()
+		if err != nil {
+			logger.Error("Failed to get update channel: %v", err)
+			return
+		}
+		walk.App().Synchronize(func() {
+			betaUpdatesAction.SetChecked(channel == config.UpdateChannelBeta)
+		})
+	}()
 
 	// Preferences action
 	preferencesAction := walk.NewAction()
@@ -546,7 +960,7 @@ func setupMenu() error {
 						})
 					}
 				}()
-				if err := preferences.ShowPreferencesWindow(mainWindow, tunnelManager, configManager, trayIcon); err != nil {
+				if err := preferences.ShowPreferencesWindow(mainWindow, tunnelManager, configManager, t.icon); err != nil {
 					logger.Error("Failed to show preferences window: %v", err)
 					td := walk.NewTaskDialog()
 					_, _ = td.Show(walk.TaskDialogOpts{
@@ -560,70 +974,80 @@ func setupMenu() error {
 			})
 		}()
 	})
-	moreMenu.Actions().Add(preferencesAction)
+	t.moreMenu.Actions().Add(preferencesAction)
 
-	moreAction = walk.NewMenuAction(moreMenu)
-	moreAction.SetText("More")
-	actions.Add(moreAction)
+	t.moreAction = walk.NewMenuAction(t.moreMenu)
+	t.moreAction.SetText("More")
+	actions.Add(t.moreAction)
 
 	// Separator before watermark/quit
 	actions.Add(walk.NewSeparatorAction())
 
 	// Create watermark action (initially hidden)
-	watermarkAction = walk.NewAction()
-	watermarkAction.SetEnabled(false)
-	watermarkAction.SetVisible(false)
-	actions.Add(watermarkAction)
+	t.watermarkAction = walk.NewAction()
+	t.watermarkAction.SetEnabled(false)
+	t.watermarkAction.SetVisible(false)
+	actions.Add(t.watermarkAction)
 
 	// Separator before Quit (if watermark is shown, this will be after it)
 	actions.Add(walk.NewSeparatorAction())
 
 	// Create quit action — stops any active tunnels via manager, then closes the UI process; manager service keeps running
-	quitAction = walk.NewAction()
-	quitAction.SetText("Quit")
-	quitAction.Triggered().Attach(func() {
+	t.quitAction = walk.NewAction()
+	t.quitAction.SetText("Quit")
+	t.quitAction.Triggered().Attach(func() {
 		_ = managers.IPCClientStopAllTunnels() // stop tunnels before exiting; ignore errors (e.g. no manager connection)
 		walk.App().Exit(0)
 	})
-	actions.Add(quitAction)
+	actions.Add(t.quitAction)
 
 	// Initialize org actions map
-	orgActions = make(map[string]*walk.Action)
-	accountActions = make(map[string]*walk.Action)
+	t.orgActions = make(map[string]*walk.Action)
+	t.accountActions = make(map[string]*walk.Action)
 
 	// Initial update to set correct visibility and text
-	updateMenu()
+	t.updateMenu()
 
 	return nil
 }
 
-// updateMenu updates all menu items based on current state
-func updateMenu() {
-	if contextMenu == nil {
+// updateMenu updates all menu items based on current state. It may be called from
+// any goroutine; the update itself runs later on the UI thread.
+func (t *Tray) updateMenu() {
+	// Callers on other goroutines may ask at any time, so requests made before
+	// the queued update starts share it
+	t.menuUpdateMutex.Lock()
+	if t.menuUpdatePending {
+		t.menuUpdateMutex.Unlock()
 		return
 	}
+	t.menuUpdatePending = true
+	t.menuUpdateMutex.Unlock()
 
-	app := walk.App()
-	if app == nil {
-		return
-	}
+	synchronize(func() {
+		// Clear first, so a change made during this update queues another one
+		t.menuUpdateMutex.Lock()
+		t.menuUpdatePending = false
+		t.menuUpdateMutex.Unlock()
 
-	menuUpdateMutex.Lock()
-	defer menuUpdateMutex.Unlock()
-
-	app.Synchronize(func() {
 		defer func() {
 			if r := recover(); r != nil {
 				logger.Error("Panic in updateMenu: %v", r)
 			}
 		}()
 
+		// The menu and its actions are only touched on the UI thread, so this
+		// check can't race SetupTray creating them
+		if t.contextMenu == nil {
+			return
+		}
+
 		// Check auth state
 		isInitializing := authManager != nil && authManager.IsInitializing()
 		isAuthenticated := authManager != nil && authManager.IsAuthenticated()
-		loggedOutMutex.RLock()
-		isLoggedOutLocal := isLoggedOut
-		loggedOutMutex.RUnlock()
+		t.loggedOutMutex.RLock()
+		isLoggedOutLocal := t.isLoggedOut
+		t.loggedOutMutex.RUnlock()
 
 		// Check if user info exists locally (from current user or config)
 		hasLocalUserInfo := false
@@ -642,8 +1066,8 @@ func updateMenu() {
 		}
 
 		// Update loading state
-		if loadingAction != nil {
-			loadingAction.SetVisible(isInitializing)
+		if t.loadingAction != nil {
+			t.loadingAction.SetVisible(isInitializing)
 		}
 
 		// Update server status messages
@@ -652,20 +1076,20 @@ func updateMenu() {
 		errorMessage := authManager.ErrorMessage()
 		hasErrorMessage := errorMessage != nil && *errorMessage != "" && !isServerDown && !sessionExpired
 
-		if serverDownAction != nil {
-			serverDownAction.SetVisible(isAuthenticated && isServerDown && !isInitializing)
+		if t.serverDownAction != nil {
+			t.serverDownAction.SetVisible(isAuthenticated && isServerDown && !isInitializing)
 		}
-		if errorMessageAction != nil {
+		if t.errorMessageAction != nil {
 			if hasErrorMessage && isAuthenticated && !isInitializing {
-				errorMessageAction.SetText(*errorMessage)
-				errorMessageAction.SetVisible(true)
+				t.errorMessageAction.SetText(*errorMessage)
+				t.errorMessageAction.SetVisible(true)
 			} else {
-				errorMessageAction.SetVisible(false)
+				t.errorMessageAction.SetVisible(false)
 			}
 		}
 
 		// Update watermark message
-		if watermarkAction != nil {
+		if t.watermarkAction != nil {
 			serverInfo := authManager.ServerInfo()
 			var watermarkText string
 			shouldShow := false
@@ -699,10 +1123,10 @@ func updateMenu() {
 			}
 
 			if shouldShow && !sessionExpired {
-				watermarkAction.SetText(watermarkText)
-				watermarkAction.SetVisible(true)
+				t.watermarkAction.SetText(watermarkText)
+				t.watermarkAction.SetVisible(true)
 			} else {
-				watermarkAction.SetVisible(false)
+				t.watermarkAction.SetVisible(false)
 			}
 		}
 
@@ -711,49 +1135,59 @@ func updateMenu() {
 		// Show even if server is down to allow account switching/logout
 		showAuthSection := isAuthenticated && (!isLoggedOutLocal || sessionExpired) && !isInitializing
 
-		if statusAction != nil {
-			statusAction.SetVisible(showAuthSection)
+		if t.statusAction != nil {
+			t.statusAction.SetVisible(showAuthSection)
+		}
+		if t.connectAction != nil {
+			t.connectAction.SetVisible(showAuthSection && !sessionExpired)
+		}
+		if t.reAuthLoginAction != nil {
+			t.reAuthLoginAction.SetVisible(showAuthSection && sessionExpired)
+			t.reAuthLoginAction.SetEnabled(authManager == nil || !authManager.IsDeviceAuthInProgress())
+			t.reAuthLoginAction.SetText("Log In")
+		}
+		if t.orgsMenuAction != nil {
+			t.orgsMenuAction.SetVisible(showAuthSection && !sessionExpired)
 		}
-		if connectAction != nil {
-			connectAction.SetVisible(showAuthSection && !sessionExpired)
+		if tunnelManager != nil {
+			updatePauseActions(showAuthSection && !sessionExpired, tunnelManager.State(), tunnelManager.PauseStatus().Paused)
+			updateReconnectAction(showAuthSection && !sessionExpired, tunnelManager.State(), tunnelManager.Restarting())
 		}
-		if reAuthLoginAction != nil {
-			reAuthLoginAction.SetVisible(showAuthSection && sessionExpired)
-			reAuthLoginAction.SetEnabled(authManager == nil || !authManager.IsDeviceAuthInProgress())
-			reAuthLoginAction.SetText("Log In")
+		if sitesMenuAction != nil {
+			sitesMenuAction.SetVisible(showAuthSection && !sessionExpired)
 		}
-		if orgsMenuAction != nil {
-			orgsMenuAction.SetVisible(showAuthSection && !sessionExpired)
+		if authManager != nil {
+			updateSessionExpiryAction(showAuthSection && !sessionExpired)
 		}
 
 		// Update tunnel state and organizations only when fully authenticated and not session expired
 		if showAuthSection {
 			if sessionExpired {
-				if statusAction != nil {
-					statusAction.SetText("Account Locked")
+				if t.statusAction != nil {
+					t.statusAction.SetText("Account Locked")
 				}
 			} else {
-				updateTunnelState()
-				updateOrganizations()
+				t.updateTunnelState()
+				t.updateOrganizations()
 			}
 		}
 
-		updateAccountMenu()
-		updateLoginAction()
+		t.updateAccountMenu()
+		t.updateLoginAction()
 
 		// Update update action visibility
-		updateMutex.RLock()
-		hasUpdateLocal := hasUpdate
-		updateMutex.RUnlock()
-		if updateAction != nil {
-			updateAction.SetVisible(hasUpdateLocal)
+		t.updateMutex.RLock()
+		hasUpdateLocal := t.hasUpdate
+		t.updateMutex.RUnlock()
+		if t.updateAction != nil {
+			t.updateAction.SetVisible(hasUpdateLocal)
 		}
 	})
 }
 
 // updateTunnelState updates the tunnel status and connect button
-func updateTunnelState() {
-	if statusAction == nil || connectAction == nil {
+func (t *Tray) updateTunnelState() {
+	if t.statusAction == nil || t.connectAction == nil {
 		return
 	}
 
@@ -761,20 +1195,35 @@ func updateTunnelState() {
 	if tunnelManager != nil {
 		state = tunnelManager.State()
 	} else {
-		tunnelStateMutex.RLock()
-		state = tunnel.State(currentTunnelState)
-		tunnelStateMutex.RUnlock()
+		t.tunnelStateMutex.RLock()
+		state = tunnel.State(t.currentTunnelState)
+		t.tunnelStateMutex.RUnlock()
 	}
 
-	statusAction.SetText(state.DisplayText())
+	var attempt int
+	if tunnelManager != nil {
+		attempt = tunnelManager.ReconnectAttempt()
+	}
+	t.statusAction.SetText(tunnelStatusText(state, attempt))
+	if tunnelManager != nil && state == tunnel.StateStopped {
+		if pause := tunnelManager.PauseStatus(); pause.Paused {
+			t.statusAction.SetText(formatPausedStatus(pause.Remaining(time.Now())))
+		}
+	}
+	if state == tunnel.StateStopped && isIdleDisconnected() {
+		t.statusAction.SetText(idleStatusText)
+	}
+	if tunnelManager != nil && tunnelManager.Restarting() {
+		t.statusAction.SetText(restartingStatusText)
+	}
 
 	var connected bool
 	if tunnelManager != nil {
 		connected = tunnelManager.IsConnected()
 	} else {
-		connectMutex.RLock()
-		connected = isConnected
-		connectMutex.RUnlock()
+		t.connectMutex.RLock()
+		connected = t.isConnected
+		t.connectMutex.RUnlock()
 	}
 
 	// Show "Disconnect" for any state other than Stopped or Stopping
@@ -782,21 +1231,45 @@ func updateTunnelState() {
 	connectText := "Connect"
 	if state == tunnel.StateStopping {
 		connectText = "Disconnecting..."
-		connectAction.SetEnabled(false) // Disable during disconnection
+		t.connectAction.SetEnabled(false) // Disable during disconnection
 	} else if state != tunnel.StateStopped {
 		connectText = "Disconnect"
-		connectAction.SetEnabled(true) // Enable to allow cancellation
+		t.connectAction.SetEnabled(true) // Enable to allow cancellation
 	} else {
 		connectText = "Connect"
-		connectAction.SetEnabled(true)
+		t.connectAction.SetEnabled(true)
+	}
+	// The manager can't start or stop the tunnel while it isn't answering
+	if isServiceUnavailable() {
+		t.statusAction.SetText("Service unavailable")
+		t.connectAction.SetEnabled(false)
+	}
+	t.connectAction.SetText(connectText)
+	// Checked while the tunnel is meant to be up, including while it connects or reconnects
+	t.connectAction.SetChecked(connected || intendsConnected(state))
+}
+
+// tunnelStatusText returns the status line for state, numbering reconnect attempts
+func tunnelStatusText(state tunnel.State, attempt int) string {
+	if state == tunnel.StateReconnecting && attempt > 0 {
+		return fmt.Sprintf("Reconnecting (attempt %d)...", attempt)
 	}
-	connectAction.SetText(connectText)
-	// Set checked state based on whether we're connected or in a connecting state
-	connectAction.SetChecked(state == tunnel.StateRunning || connected)
+	return state.DisplayText()
 }
 
-func updateAccountMenu() {
-	if accountMenu == nil || accountMenuAction == nil || accountManager == nil {
+// intendsConnected reports whether the tunnel is up or on its way up, as
+// opposed to stopped, stopping or failed
+func intendsConnected(state tunnel.State) bool {
+	switch state {
+	case tunnel.StateStarting, tunnel.StateRegistering, tunnel.StateRegistered,
+		tunnel.StateRunning, tunnel.StateReconnecting:
+		return true
+	}
+	return false
+}
+
+func (t *Tray) updateAccountMenu() {
+	if t.accountMenu == nil || t.accountMenuAction == nil || accountManager == nil {
 		return
 	}
 
@@ -807,13 +1280,13 @@ func updateAccountMenu() {
 	if tunnelManager != nil {
 		state = tunnelManager.State()
 	} else {
-		tunnelStateMutex.RLock()
-		state = tunnel.State(currentTunnelState)
-		tunnelStateMutex.RUnlock()
+		t.tunnelStateMutex.RLock()
+		state = tunnel.State(t.currentTunnelState)
+		t.tunnelStateMutex.RUnlock()
 	}
 	shouldDisable := state == tunnel.StateStarting || state == tunnel.StateRegistering || state == tunnel.StateRegistered || state == tunnel.StateStopping
 
-	actions := accountMenu.Actions()
+	actions := t.accountMenu.Actions()
 	hasMenuTitle := false
 	hasSeparator := false
 	if actions.Len() > 0 {
@@ -845,10 +1318,10 @@ func updateAccountMenu() {
 		actions.Insert(1, separator)
 	}
 
-	for accountID, action := range accountActions {
+	for accountID, action := range t.accountActions {
 		if _, ok := accountManager.Accounts[accountID]; !ok {
 			actions.Remove(action)
-			delete(accountActions, accountID)
+			delete(t.accountActions, accountID)
 		}
 	}
 
@@ -857,19 +1330,19 @@ func updateAccountMenu() {
 	// it's handled here.
 	if len(accounts) == 0 {
 		// Add "No organizations" action if it doesn't exist
-		if noAccountsAction == nil {
-			noAccountsAction = walk.NewAction()
-			noAccountsAction.SetText("No accounts")
-			noAccountsAction.SetEnabled(false)
+		if t.noAccountsAction == nil {
+			t.noAccountsAction = walk.NewAction()
+			t.noAccountsAction.SetText("No accounts")
+			t.noAccountsAction.SetEnabled(false)
 			// Insert after separator (index 2: count label at 0, separator at 1)
-			actions.Insert(2, noAccountsAction)
+			actions.Insert(2, t.noAccountsAction)
 		}
-		noAccountsAction.SetVisible(true)
+		t.noAccountsAction.SetVisible(true)
 	} else {
 		// Remove "No accounts" action if it exists
-		if noAccountsAction != nil {
-			actions.Remove(noAccountsAction)
-			noAccountsAction = nil
+		if t.noAccountsAction != nil {
+			actions.Remove(t.noAccountsAction)
+			t.noAccountsAction = nil
 		}
 	}
 
@@ -883,7 +1356,7 @@ func updateAccountMenu() {
 
 	// Update or add orgs
 	for _, account := range accounts {
-		action, exists := accountActions[account.UserID]
+		action, exists := t.accountActions[account.UserID]
 		if !exists {
 			// Create new action
 			action = walk.NewAction()
@@ -906,6 +1379,7 @@ func updateAccountMenu() {
 					// Shut down tunnel here. Switching users requires the tunnel must go
 					// down.
 					logger.Info("Stopping tunnel before switching accounts")
+					markUserDisconnect()
 					if err := managers.IPCClientStopTunnel(); err != nil {
 						logger.Error("Failed to shut down tunnel before switch: %v", err)
 						walk.App().Synchronize(func() {
@@ -913,12 +1387,12 @@ func updateAccountMenu() {
 							_, _ = td.Show(walk.TaskDialogOpts{
 								Owner:         mainWindow,
 								Title:         "Tunnel Shutdown Failed",
-								Content:       fmt.Sprintf("Failed to shut down tunnel before switching accounts: %v", err),
+								Content:       fmt.Sprintf("Failed to shut down tunnel before switching accounts: %s", ipcErrorText(err)),
 								IconSystem:    walk.TaskDialogSystemIconError,
 								CommonButtons: win.TDCBF_OK_BUTTON,
 							})
 						})
-						updateMenu()
+						t.updateMenu()
 						return
 					}
 
@@ -936,14 +1410,14 @@ func updateAccountMenu() {
 								CommonButtons: win.TDCBF_OK_BUTTON,
 							})
 						})
-						updateMenu()
+						t.updateMenu()
 						return
 					}
 
-					updateMenu()
+					t.updateMenu()
 				}()
 			})
-			accountActions[account.UserID] = action
+			t.accountActions[account.UserID] = action
 
 			// Insert after separator (index 2: count label at 0, separator at 1)
 			actions.Insert(2, action)
@@ -964,40 +1438,45 @@ func updateAccountMenu() {
 		action.SetEnabled(!shouldDisable)
 	}
 
-	if addAccountAction == nil {
+	if t.addAccountAction == nil {
 		actions.Add(walk.NewSeparatorAction())
-		addAccountAction = walk.NewAction()
-		addAccountAction.SetText("Add Account")
-		addAccountAction.Triggered().Attach(func() {
+		t.addAccountAction = walk.NewAction()
+		t.addAccountAction.SetText("Add Account")
+		t.addAccountAction.Triggered().Attach(func() {
 			go func() {
 				walk.App().Synchronize(func() {
 					// Show login dialog
 					ShowLoginDialog(mainWindow, authManager, configManager, accountManager, apiClient, tunnelManager)
 					// Small delay to allow state to update
 					time.Sleep(100 * time.Millisecond)
-					updateMenu()
+					t.updateMenu()
 				})
 			}()
 		})
-		actions.Add(addAccountAction)
+		actions.Add(t.addAccountAction)
 	}
-	addAccountAction.SetVisible(true)
+	t.addAccountAction.SetVisible(true)
 
 	// Create logout action
-	if logoutAction == nil {
-		logoutAction = walk.NewAction()
-		logoutAction.SetText("Logout")
-		logoutAction.SetVisible(false) // Initially hidden
-		logoutAction.Triggered().Attach(func() {
+	if t.logoutAction == nil {
+		t.logoutAction = walk.NewAction()
+		t.logoutAction.SetText("Log Out")
+		t.logoutAction.SetVisible(false) // Initially hidden
+		t.logoutAction.Triggered().Attach(func() {
+			currentAccount, _ := accountManager.ActiveAccount()
+			if !confirmLogout(currentAccount) {
+				return
+			}
 			go func() {
-				// Always stop any running tunnel before logout
-				logger.Info("Stopping tunnel before logout")
-				if err := managers.IPCClientStopTunnel(); err != nil {
-					logger.Error("Failed to stop tunnel before logout: %v", err)
-					// Continue with logout even if stopping tunnel fails
+				stopTunnel := func() error {
+					// Disconnect also cancels a pause
+					markUserDisconnect()
+					if tunnelManager != nil {
+						return tunnelManager.Disconnect()
+					}
+					return managers.IPCClientStopTunnel()
 				}
-
-				if err := authManager.Logout(); err != nil {
+				if err := logOut(stopTunnel, authManager.Logout); err != nil {
 					logger.Error("Failed to logout: %v", err)
 					// Show error dialog to user
 					walk.App().Synchronize(func() {
@@ -1011,25 +1490,75 @@ func updateAccountMenu() {
 						})
 					})
 				}
-				updateMenu()
+				t.updateMenu()
 			}()
 		})
-		actions.Add(logoutAction)
+		actions.Add(t.logoutAction)
 	}
-	logoutAction.SetVisible(currentAccount != nil)
+	t.logoutAction.SetVisible(currentAccount != nil)
+	updateForgetDeviceAction(actions, currentAccount)
 
 	// Update accounts menu action text
-	accountMenuActionText := "Select Account"
-	if currentAccount != nil {
-		accountMenuActionText = auth.AccountDisplayName(currentAccount)
+	t.loggedOutMutex.RLock()
+	loggedIn := currentAccount != nil && !t.isLoggedOut
+	t.loggedOutMutex.RUnlock()
+	var currentUser *api.User
+	if authManager != nil {
+		currentUser = authManager.CurrentUser()
+	}
+	t.accountMenuAction.SetText(accountLabelText(currentAccount, currentUser, loggedIn))
+	t.accountMenuAction.SetVisible(len(accounts) > 0)
+}
+
+// logOut stops any running tunnel and then logs out, so the tunnel can't
+// outlive the session it was started with. Logging out goes ahead even if
+// the tunnel can't be stopped.
+func logOut(stopTunnel, logout func() error) error {
+	logger.Info("Stopping tunnel before logout")
+	if err := stopTunnel(); err != nil {
+		logger.Error("Failed to stop tunnel before logout: %v", err)
+	}
+	return logout()
+}
+
+// confirmLogout asks the user to confirm logging out of account.
+// Must be called on the UI thread.
+func confirmLogout(account *config.Account) bool {
+	confirmed := false
+	td := walk.NewTaskDialog()
+	opts := walk.TaskDialogOpts{
+		Owner:         mainWindow,
+		Title:         "Log Out",
+		Content:       fmt.Sprintf("Log out of %s?\n\nAny active connection will be disconnected.", auth.AccountDisplayName(account)),
+		IconSystem:    walk.TaskDialogSystemIconWarning,
+		CommonButtons: win.TDCBF_YES_BUTTON | win.TDCBF_NO_BUTTON,
+		DefaultButton: walk.TaskDialogDefaultButtonNo,
 	}
-	accountMenuAction.SetText(accountMenuActionText)
-	accountMenuAction.SetVisible(len(accounts) > 0)
+	opts.CommonButtonClicked(win.TDCBF_YES_BUTTON).Attach(func() bool {
+		confirmed = true
+		return false // Return false to allow dialog to close normally
+	})
+	_, _ = td.Show(opts)
+	return confirmed
+}
+
+// accountLabelText returns the text for the tray's account menu: the active
+// account's display name, falling back to the signed-in user's details when the
+// stored account has none, or "Not logged in"
+func accountLabelText(account *config.Account, user *api.User, loggedIn bool) string {
+	if !loggedIn || account == nil {
+		return "Not logged in"
+	}
+	if account.Email == "" && account.Name == "" && account.Username == "" &&
+		user != nil && user.UserId == account.UserID {
+		return auth.UserDisplayName(user)
+	}
+	return auth.AccountDisplayName(account)
 }
 
 // updateOrganizations updates the organizations menu
-func updateOrganizations() {
-	if orgMenu == nil || orgsMenuAction == nil || authManager == nil {
+func (t *Tray) updateOrganizations() {
+	if t.orgMenu == nil || t.orgsMenuAction == nil || authManager == nil {
 		return
 	}
 
@@ -1045,14 +1574,14 @@ func updateOrganizations() {
 	if tunnelManager != nil {
 		state = tunnelManager.State()
 	} else {
-		tunnelStateMutex.RLock()
-		state = tunnel.State(currentTunnelState)
-		tunnelStateMutex.RUnlock()
+		t.tunnelStateMutex.RLock()
+		state = tunnel.State(t.currentTunnelState)
+		t.tunnelStateMutex.RUnlock()
 	}
 	shouldDisable := state == tunnel.StateStarting || state == tunnel.StateRegistering || state == tunnel.StateRegistered || state == tunnel.StateStopping
 
 	// Ensure org count label and separator exist
-	actions := orgMenu.Actions()
+	actions := t.orgMenu.Actions()
 	hasCountLabel := false
 	hasSeparator := false
 	if actions.Len() > 0 {
@@ -1097,35 +1626,35 @@ func updateOrganizations() {
 	}
 
 	// Remove orgs that no longer exist (skip count label at 0 and separator at 1)
-	for orgId, action := range orgActions {
+	for orgId, action := range t.orgActions {
 		if !orgSet[orgId] {
 			actions.Remove(action)
-			delete(orgActions, orgId)
+			delete(t.orgActions, orgId)
 		}
 	}
 
 	// Handle "No organizations" message when there are no orgs
 	if len(orgs) == 0 {
 		// Add "No organizations" action if it doesn't exist
-		if noOrgsAction == nil {
-			noOrgsAction = walk.NewAction()
-			noOrgsAction.SetText("No organizations")
-			noOrgsAction.SetEnabled(false)
+		if t.noOrgsAction == nil {
+			t.noOrgsAction = walk.NewAction()
+			t.noOrgsAction.SetText("No organizations")
+			t.noOrgsAction.SetEnabled(false)
 			// Insert after separator (index 2: count label at 0, separator at 1)
-			actions.Insert(2, noOrgsAction)
+			actions.Insert(2, t.noOrgsAction)
 		}
-		noOrgsAction.SetVisible(true)
+		t.noOrgsAction.SetVisible(true)
 	} else {
 		// Remove "No organizations" action if it exists
-		if noOrgsAction != nil {
-			actions.Remove(noOrgsAction)
-			noOrgsAction = nil
+		if t.noOrgsAction != nil {
+			actions.Remove(t.noOrgsAction)
+			t.noOrgsAction = nil
 		}
 	}
 
 	// Update or add orgs
 	for _, org := range orgs {
-		action, exists := orgActions[org.Id]
+		action, exists := t.orgActions[org.Id]
 		if !exists {
 			// Create new action
 			action = walk.NewAction()
@@ -1133,44 +1662,9 @@ func updateOrganizations() {
 			action.SetCheckable(true)
 			action.Triggered().Attach(func() {
 				org := org
-
-				go func() {
-					if err := authManager.SelectOrganization(&org); err != nil {
-						logger.Error("Failed to select organization: %v", err)
-						// Show error dialog to user
-						walk.App().Synchronize(func() {
-							td := walk.NewTaskDialog()
-							_, _ = td.Show(walk.TaskDialogOpts{
-								Owner:         mainWindow,
-								Title:         "Organization Selection Failed",
-								Content:       fmt.Sprintf("Failed to select organization: %v", err),
-								IconSystem:    walk.TaskDialogSystemIconError,
-								CommonButtons: win.TDCBF_OK_BUTTON,
-							})
-						})
-					} else {
-						updateMenu()
-
-						if tunnelManager.IsConnected() {
-							if err := tunnelManager.SwitchOLMOrg(org.Id); err != nil {
-								logger.Error("Failed to switch tunnel organization: %v", err)
-								// Show error dialog to user
-								walk.App().Synchronize(func() {
-									td := walk.NewTaskDialog()
-									_, _ = td.Show(walk.TaskDialogOpts{
-										Owner:         mainWindow,
-										Title:         "Tunnel Organization Switch Failed",
-										Content:       fmt.Sprintf("Failed to switch tunnel organization: %v", err),
-										IconSystem:    walk.TaskDialogSystemIconError,
-										CommonButtons: win.TDCBF_OK_BUTTON,
-									})
-								})
-							}
-						}
-					}
-				}()
+				go selectOrganization(org)
 			})
-			orgActions[org.Id] = action
+			t.orgActions[org.Id] = action
 
 			// Insert after separator (index 2: count label at 0, separator at 1)
 			actions.Insert(2, action)
@@ -1189,13 +1683,13 @@ func updateOrganizations() {
 	if currentOrg != nil {
 		currentOrgName = currentOrg.Name
 	}
-	orgsMenuAction.SetText(currentOrgName)
+	t.orgsMenuAction.SetText(currentOrgName)
 	// Always show menu when authenticated (visibility controlled by updateMenu based on auth state)
 }
 
 // updateLoginAction updates the login button text and enabled state
-func updateLoginAction() {
-	if loginAction == nil || authManager == nil || accountManager == nil {
+func (t *Tray) updateLoginAction() {
+	if t.loginAction == nil || authManager == nil || accountManager == nil {
 		return
 	}
 
@@ -1207,20 +1701,21 @@ func updateLoginAction() {
 			// Use display name helper, but prefer currentUser if available
 			user := authManager.CurrentUser()
 			if user != nil {
-				loginAction.SetText(auth.UserDisplayName(user))
+				t.loginAction.SetText(auth.UserDisplayName(user))
 			} else {
-				loginAction.SetText(auth.AccountDisplayName(activeAccount))
+				t.loginAction.SetText(auth.AccountDisplayName(activeAccount))
 			}
 		} else {
-			loginAction.SetText("Select Account")
+			t.loginAction.SetText("Select Account")
 		}
 	} else {
-		loginAction.SetText("Login to Account")
+		t.loginAction.SetText("Login to Account")
 	}
 
-	loginAction.SetVisible(len(accountManager.Accounts) == 0)
+	t.loginAction.SetVisible(len(accountManager.Accounts) == 0)
 }
 
+// SetupTray stores the managers shared by the UI and sets up the tray
 func SetupTray(
 	mw *walk.MainWindow,
 	am *auth.AuthManager,
@@ -1235,109 +1730,123 @@ func SetupTray(
 	configManager = cm
 	apiClient = ac
 	accountManager = accm
+	secretManager = sm
+
+	// Refresh the account label and menu whenever accounts are added, removed or switched
+	accm.SetOnChange(updateMenu)
+
+	// The hidden main window receives theme change broadcasts for the whole UI
+	if err := theme.Watch(mw); err != nil {
+		logger.Warn("Failed to watch for theme changes: %v", err)
+	}
 
 	// Initialize tunnel manager with IPC adapter
 	ipcAdapter := managers.NewIPCAdapter()
 	tunnelManager = tunnel.NewManager(am, cm, accm, sm, ipcAdapter)
+	// A preferences window opened before now shows the service as unavailable until it has one
+	preferences.SetTunnelManager(tunnelManager)
+
+	return tray.setup(mw)
+}
 
+// setup creates the tray icon and its menu, and registers for the updates they
+// show. Must be called on the UI thread, after the managers are set.
+func (t *Tray) setup(mw *walk.MainWindow) error {
 	// Create NotifyIcon
 	ni, err := walk.NewNotifyIcon()
 	if err != nil {
 		return err
 	}
-	trayIcon = ni // Store reference for icon updates
+	t.icon = ni // Store reference for icon updates
 
 	// Load default gray icon (disconnected state)
-	setTrayIconForState(tunnel.StateStopped)
+	t.setTrayIconForState(tunnel.StateStopped)
 
 	// Set initial tooltip
-	updateTrayTooltip(tunnel.StateStopped)
+	t.updateTrayTooltip(tunnel.StateStopped)
 
 	// Initialize context menu
-	contextMenu = ni.ContextMenu()
+	t.contextMenu = ni.ContextMenu()
 
 	// Setup menu structure once
-	if err := setupMenu(); err != nil {
+	if err := t.setupMenu(); err != nil {
 		logger.Error("Failed to setup menu: %v", err)
 		return err
 	}
 
-	// Handle left-click to show popup menu using Windows API
-	ni.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
+	// Handle left-click to show the same menu as right-click. walk shows the
+	// menu itself only when no mouse handlers are attached, and closes it again
+	// when the icon is clicked while the menu is open.
+	ni.MouseUp().Attach(func(x, y int, button walk.MouseButton) {
 		if button == walk.LeftButton {
 			// Handle menu open - verify session and refresh orgs
-			handleMenuOpen()
-
-			// Get cursor position
-			var pt win.POINT
-			win.GetCursorPos(&pt)
-
-			// Get the menu handle from the context menu using unsafe
-			// The Menu struct should have an hMenu field as the first field
-			menuPtr := (*struct {
-				hMenu win.HMENU
-			})(unsafe.Pointer(contextMenu))
-
-			if menuPtr.hMenu != 0 {
-				// Update menu before showing (in case state changed)
-				updateMenu()
-
-				// Show the menu using TrackPopupMenu
-				// TrackPopupMenu automatically closes when clicking away
-				win.SetForegroundWindow(mw.Handle())
-				win.TrackPopupMenu(
-					menuPtr.hMenu,
-					win.TPM_LEFTALIGN|win.TPM_LEFTBUTTON|win.TPM_RIGHTBUTTON,
-					pt.X,
-					pt.Y,
-					0,
-					mw.Handle(),
-					nil,
-				)
-				// Post a null message to ensure the menu closes properly
-				win.PostMessage(mw.Handle(), win.WM_NULL, 0, 0)
-			}
+			t.handleMenuOpen()
+
+			// Update menu before showing (in case state changed)
+			t.updateMenu()
+
+			ni.ShowContextMenu(x, y)
 		}
 	})
 
 	ni.SetVisible(true)
 
+	// A second launch in this session opens this instance's menu instead
+	watchForOtherInstances()
+
 	// Register for update notifications from manager (if connected via IPC)
 	// These callbacks will be called when the manager finds updates or makes progress
-	updateFoundCb = managers.IPCClientRegisterUpdateFound(func(updateState managers.UpdateState) {
+	t.updateFoundCb = managers.IPCClientRegisterUpdateFound(func(updateState managers.UpdateState) {
 		if updateState == managers.UpdateStateFoundUpdate {
-			updateMutex.Lock()
-			hasUpdate = true
-			updateMutex.Unlock()
-			updateMenu()
+			t.updateMutex.Lock()
+			t.hasUpdate = true
+			t.updateMutex.Unlock()
+			t.updateMenu()
 		} else {
-			updateMutex.Lock()
-			hasUpdate = false
-			updateMutex.Unlock()
-			updateMenu()
+			t.updateMutex.Lock()
+			t.hasUpdate = false
+			t.updateMutex.Unlock()
+			t.updateMenu()
 		}
 	})
 
 	// Register for manager stopping notification
-	managerStoppingCb = managers.IPCClientRegisterManagerStopping(func() {
+	t.statsCb = managers.IPCClientRegisterStats(t.handleStatsSnapshot)
+
+	t.managerStoppingCb = managers.IPCClientRegisterManagerStopping(func() {
 		logger.Info("Manager service is stopping, exiting UI")
 		walk.App().Synchronize(func() {
 			walk.App().Exit(0)
 		})
 	})
 
-	updateProgressCb = managers.IPCClientRegisterUpdateProgress(func(dp updater.DownloadProgress) {
+	t.updateProgressCb = managers.IPCClientRegisterUpdateProgress(func(dp updater.DownloadProgress) {
 		if dp.Error != nil {
+			if dp.Error.Error() == updater.ErrUpdateCanceled.Error() {
+				logger.Info("Update canceled")
+				walk.App().Synchronize(closeUpdateProgress)
+				return
+			}
 			logger.Error("Update error: %v", dp.Error)
 			walk.App().Synchronize(func() {
+				closeUpdateProgress()
 				td := walk.NewTaskDialog()
-				_, _ = td.Show(walk.TaskDialogOpts{
+				opts := walk.TaskDialogOpts{
 					Owner:         mw,
 					Title:         "Update Failed",
 					Content:       fmt.Sprintf("Update failed: %v", dp.Error),
 					IconSystem:    walk.TaskDialogSystemIconError,
 					CommonButtons: win.TDCBF_OK_BUTTON,
-				})
+				}
+				// Verification rejected the installer; say so rather than a generic failure
+				if dp.Verification != nil && !dp.Verification.Verified {
+					opts.Title = "Installer Rejected"
+					opts.Content = fmt.Sprintf("The installer was not installed because it failed verification.\n\n%v", dp.Error)
+					opts.ExpandedInformation = formatVerification(dp.Verification)
+					opts.ExpandLabel = "Show signature details"
+					opts.CollapseLabel = "Hide signature details"
+				}
+				_, _ = td.Show(opts)
 			})
 			return
 		}
@@ -1346,30 +1855,41 @@ func SetupTray(
 			logger.Info("Update: %s", dp.Activity)
 		}
 
-		if dp.BytesTotal > 0 {
-			percent := float64(dp.BytesDownloaded) / float64(dp.BytesTotal) * 100
-			logger.Info("Download progress: %.1f%% (%d/%d bytes)", percent, dp.BytesDownloaded, dp.BytesTotal)
+		if percent, known := updateProgressPercent(dp); known {
+			logger.Debug("Download progress: %d%% (%d/%d bytes)", percent, dp.BytesDownloaded, dp.BytesTotal)
 		}
 
 		if dp.Complete {
 			logger.Info("Update complete! The application will restart.")
 			walk.App().Synchronize(func() {
+				closeUpdateProgress()
 				td := walk.NewTaskDialog()
-				_, _ = td.Show(walk.TaskDialogOpts{
+				opts := walk.TaskDialogOpts{
 					Owner:         mw,
 					Title:         "Update Complete",
 					Content:       "The update has been installed successfully. The application will now restart.",
 					IconSystem:    walk.TaskDialogSystemIconInformation,
 					CommonButtons: win.TDCBF_OK_BUTTON,
-				})
+				}
+				if details := formatVerification(dp.Verification); details != "" {
+					opts.ExpandedInformation = details
+					opts.ExpandLabel = "Show signature details"
+					opts.CollapseLabel = "Hide signature details"
+				}
+				_, _ = td.Show(opts)
 			})
 			// Clear the update after installation starts
-			updateMutex.Lock()
-			hasUpdate = false
-			updateMutex.Unlock()
-			updateMenu()
+			t.updateMutex.Lock()
+			t.hasUpdate = false
+			t.updateMutex.Unlock()
+			t.updateMenu()
 			// The MSI installer will handle the restart
+			return
 		}
+
+		walk.App().Synchronize(func() {
+			showUpdateProgress(mw, dp)
+		})
 	})
 
 	// Check initial update state on startup
@@ -1379,18 +1899,18 @@ func SetupTray(
 		// Check immediately first (in case update was already found)
 		updateState, err := managers.IPCClientUpdateState()
 		if err == nil && updateState == managers.UpdateStateFoundUpdate {
-			updateMutex.Lock()
-			hasUpdate = true
-			updateMutex.Unlock()
-			updateMenu()
+			t.updateMutex.Lock()
+			t.hasUpdate = true
+			t.updateMutex.Unlock()
+			t.updateMenu()
 			// Show dialog on startup if update is available (only once)
-			startupDialogMutex.Lock()
-			if !startupDialogShown {
-				startupDialogShown = true
-				startupDialogMutex.Unlock()
+			t.startupDialogMutex.Lock()
+			if !t.startupDialogShown {
+				t.startupDialogShown = true
+				t.startupDialogMutex.Unlock()
 				triggerUpdate(mainWindow)
 			} else {
-				startupDialogMutex.Unlock()
+				t.startupDialogMutex.Unlock()
 			}
 			return
 		}
@@ -1399,53 +1919,65 @@ func SetupTray(
 		time.Sleep(3 * time.Second)
 		updateState, err = managers.IPCClientUpdateState()
 		if err == nil && updateState == managers.UpdateStateFoundUpdate {
-			updateMutex.Lock()
-			hasUpdate = true
-			updateMutex.Unlock()
-			updateMenu()
+			t.updateMutex.Lock()
+			t.hasUpdate = true
+			t.updateMutex.Unlock()
+			t.updateMenu()
 			// Show dialog on startup if update is available (only once)
-			startupDialogMutex.Lock()
-			if !startupDialogShown {
-				startupDialogShown = true
-				startupDialogMutex.Unlock()
+			t.startupDialogMutex.Lock()
+			if !t.startupDialogShown {
+				t.startupDialogShown = true
+				t.startupDialogMutex.Unlock()
 				triggerUpdate(mainWindow)
 			} else {
-				startupDialogMutex.Unlock()
+				t.startupDialogMutex.Unlock()
 			}
 		}
 	}()
 
 	// Register for tunnel state change notifications via tunnel manager
-	tunnelManager.RegisterStateChangeCallback(func(state tunnel.State) {
-		logger.Info("Tunnel state changed: %s", state.String())
-		tunnelStateMutex.Lock()
-		currentTunnelState = managers.TunnelState(state)
-		tunnelStateMutex.Unlock()
+	tunnelManager.RegisterStateChangeCallback(t.handleTunnelStateChange)
 
-		walk.App().Synchronize(func() {
-			// Update connection state
-			switch state {
-			case tunnel.StateRunning:
-				connectMutex.Lock()
-				isConnected = true
-				connectMutex.Unlock()
-			case tunnel.StateStopped:
-				connectMutex.Lock()
-				isConnected = false
-				connectMutex.Unlock()
-			}
+	// Refresh the icon, status and actions when the tunnel is paused or resumed
+	tunnelManager.RegisterPauseCallback(handlePauseChange)
+
+	// Tell the user when an idle tunnel is disconnected, and reconnect from the notification
+	tunnelManager.RegisterIdleDisconnectCallback(handleIdleDisconnect)
+	// And when a tunnel that lost its network adapter is rebuilt
+	tunnelManager.RegisterAdapterRecoveryCallback(handleAdapterRecovery)
+	ni.MessageClicked().Attach(handleNotificationClicked)
 
-			// Update tray icon for all states (including transitional)
-			setTrayIconForState(state)
+	// Watch for the manager service hanging or going away
+	startServiceHealthMonitor()
 
-			// Update tooltip with current state
-			updateTrayTooltip(state)
+	// Age the tooltip's "Last connected" time while disconnected
+	t.startLastConnectedRefresh()
 
-			// Update menu to update status text and connect button
-			updateMenu()
+	// Warn before the organization's maximum session length runs out
+	startSessionExpiryWatcher()
+
+	// Keep the Sites submenu and the tooltip's site count in step with the polled OLM status
+	tunnelManager.RegisterStatusCallback(func(status *tunnel.OLMStatusResponse) {
+		t.setStatusSummary(status)
+		items := buildSiteMenuModel(status.PeerStatuses, currentDisconnectedSites())
+		walk.App().Synchronize(func() {
+			updateSitesMenu(items)
 		})
 	})
 
+	// Pick up the tunnel's current state in case it was already up before the UI started.
+	// This runs through the state change callback above to set the icon, tooltip and connect action.
+	// Connecting on launch waits for it, so a tunnel that is already up is left alone.
+	go func() {
+		tunnelManager.SyncState()
+		// Sites disconnected while the UI wasn't running
+		refreshDisconnectedSites()
+		connectOnLaunch()
+	}()
+
+	// Welcome a new user with the login dialog
+	showOnboarding()
+
 	// Register for tunnel error notifications via tunnel manager
 	tunnelManager.RegisterErrorCallback(func(err *tunnel.OLMStatusError) {
 		logger.Error("Tunnel error detected: code=%s, message=%s", err.Code, err.Message)
@@ -1483,7 +2015,7 @@ func SetupTray(
 			if currentAuthState != lastAuthState || currentInitializing != lastInitializing {
 				lastAuthState = currentAuthState
 				lastInitializing = currentInitializing
-				updateMenu()
+				t.updateMenu()
 			}
 		}
 	}()
@@ -1512,7 +2044,7 @@ func SetupTray(
 							logger.Error("Failed to refresh from MyDevice: %v", err)
 						} else {
 							// Update menu to reflect updated orgs
-							updateMenu()
+							t.updateMenu()
 						}
 					}
 				}
@@ -1539,10 +2071,168 @@ func SetupTray(
 	return nil
 }
 
+// handleTunnelStateChange records a tunnel state change reported by the tunnel
+// manager and shows it on the UI thread
+func (t *Tray) handleTunnelStateChange(state tunnel.State) {
+	logger.Info("Tunnel state changed: %s", state.String())
+	t.tunnelStateMutex.Lock()
+	previousState := t.currentTunnelState
+	t.currentTunnelState = managers.TunnelState(state)
+	t.tunnelStateMutex.Unlock()
+
+	recordLastConnected(tunnel.State(previousState), state, time.Now())
+	clearIdleDisconnect(state)
+
+	synchronize(func() {
+		t.applyTunnelState(state)
+	})
+}
+
+// applyTunnelState updates the icon, tooltip, notifications and menu for state.
+// Must be called on the UI thread.
+func (t *Tray) applyTunnelState(state tunnel.State) {
+	// Update connection state
+	switch state {
+	case tunnel.StateRunning:
+		t.connectMutex.Lock()
+		t.isConnected = true
+		t.connectMutex.Unlock()
+	case tunnel.StateStopped:
+		t.connectMutex.Lock()
+		t.isConnected = false
+		t.connectMutex.Unlock()
+	}
+
+	// Update tray icon for all states (including transitional)
+	t.setTrayIconForState(state)
+
+	// Update tooltip with current state, plus traffic totals while connected
+	t.updateTrayTooltip(state)
+	if state == tunnel.StateRunning {
+		t.startTrayStats()
+	} else {
+		t.stopTrayStats()
+	}
+
+	// Sites are only known while connected
+	if state == tunnel.StateStopped {
+		clearDisconnectedSites()
+		updateSitesMenu(nil)
+	}
+
+	t.notifyTunnelStateChange(state)
+
+	// Update menu to update status text and connect button
+	t.updateMenu()
+}
+
+// maxReleaseNotesDisplayLength caps the release notes shown in the update dialog
+const maxReleaseNotesDisplayLength = 2000
+
+// formatVerification describes how an installed update was verified, or returns
+// an empty string if no details are available
+func formatVerification(v *updater.VerificationResult) string {
+	if v == nil {
+		return ""
+	}
+	var lines []string
+	if v.Verified {
+		lines = append(lines, "The installer's signature was verified.")
+	} else if v.Reason != "" {
+		lines = append(lines, "The installer's signature could not be verified: "+v.Reason)
+	}
+	if v.Signer != "" {
+		lines = append(lines, "Signed by: "+v.Signer)
+	}
+	if v.Thumbprint != "" {
+		lines = append(lines, "Certificate thumbprint: "+v.Thumbprint)
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// truncateReleaseNotes shortens notes to at most maxLen bytes, cutting at a line
+// break where possible. It reports whether anything was cut.
+func truncateReleaseNotes(notes string, maxLen int) (string, bool) {
+	if len(notes) <= maxLen {
+		return notes, false
+	}
+	cut := notes[:maxLen]
+	if i := strings.LastIndex(cut, "\r\n"); i > 0 {
+		cut = cut[:i]
+	} else {
+		// Don't split a multi-byte character
+		for len(cut) > 0 && !utf8.RuneStart(notes[len(cut)]) {
+			cut = cut[:len(cut)-1]
+		}
+	}
+	return strings.TrimRight(cut, " \t\r\n") + "\r\n...", true
+}
+
+// installUpdateFromFile lets the user pick a local installer and asks the manager
+// to verify and install it
+func installUpdateFromFile(mw *walk.MainWindow) {
+	fd := walk.FileDialog{
+		Filter: "Windows Installer Packages (*.msi)|*.msi",
+		Title:  "Install update from file",
+	}
+	if ok, _ := fd.ShowOpen(mw); !ok {
+		return
+	}
+	path := fd.FilePath
+
+	go func() {
+		logger.Info("Installing update from file: %s", path)
+		err := managers.IPCClientInstallFromFile(path)
+		if err != nil {
+			logger.Error("Failed to install update from file: %v", err)
+			walk.App().Synchronize(func() {
+				td := walk.NewTaskDialog()
+				_, _ = td.Show(walk.TaskDialogOpts{
+					Owner:         mw,
+					Title:         "Update Failed",
+					Content:       fmt.Sprintf("Failed to install %s: %s", filepath.Base(path), ipcErrorText(err)),
+					IconSystem:    walk.TaskDialogSystemIconError,
+					CommonButtons: win.TDCBF_OK_BUTTON,
+				})
+			})
+		}
+	}()
+}
+
+// retryUpdateCheck tells the user that an update check failed with err, with a
+// hint about the likely cause, and reports whether they chose to try again.
+// Must not be called on the UI thread, since it waits for the user.
+func retryUpdateCheck(mw *walk.MainWindow, err error) bool {
+	retryChan := make(chan bool, 1)
+
+	walk.App().Synchronize(func() {
+		td := walk.NewTaskDialog()
+		result, showErr := td.Show(walk.TaskDialogOpts{
+			Owner:               mw,
+			Title:               "Update Check Failed",
+			Content:             fmt.Sprintf("Failed to check for updates: %s\n\n%s", ipcErrorText(err), updateCheckFailureHint(err)),
+			ExpandedInformation: err.Error(),
+			ExpandLabel:         "Show details",
+			CollapseLabel:       "Hide details",
+			IconSystem:          walk.TaskDialogSystemIconError,
+			CommonButtons:       win.TDCBF_RETRY_BUTTON | win.TDCBF_CANCEL_BUTTON,
+			DefaultButton:       walk.TaskDialogDefaultButtonRetry,
+		})
+		retryChan <- showErr == nil && !result.Canceled
+	})
+
+	return <-retryChan
+}
+
 // triggerUpdate asks the user for confirmation and then triggers the update via manager
 func triggerUpdate(mw *walk.MainWindow) {
 	userAcceptedChan := make(chan bool, 1)
 
+	info, err := managers.IPCClientUpdateInfo()
+	if err != nil {
+		logger.Warn("Failed to get update details: %v", err)
+	}
+
 	// Show dialog on UI thread - Show() blocks until dialog is closed
 	walk.App().Synchronize(func() {
 		td := walk.NewTaskDialog()
@@ -1554,6 +2244,26 @@ func triggerUpdate(mw *walk.MainWindow) {
 			CommonButtons: win.TDCBF_YES_BUTTON | win.TDCBF_NO_BUTTON,
 			DefaultButton: walk.TaskDialogDefaultButtonYes,
 		}
+		if info.Version != "" {
+			opts.Content = fmt.Sprintf("Version %s is available.\n\nWould you like to download and install it now?", info.Version)
+		}
+		if info.ReleaseNotes != "" {
+			notes, truncated := truncateReleaseNotes(info.ReleaseNotes, maxReleaseNotesDisplayLength)
+			opts.ExpandedInformation = notes
+			opts.ExpandLabel = "Show release notes"
+			opts.CollapseLabel = "Hide release notes"
+			if truncated && info.ReleasePageURL != "" {
+				opts.Footer = fmt.Sprintf("<a href=\"%s\">View full release notes</a>", info.ReleasePageURL)
+				opts.AllowHyperlinks = true
+				td.HyperlinkClicked().Attach(func(url string) bool {
+					// Only follow our own link, not markup that happens to be in the notes
+					if url == info.ReleasePageURL {
+						openURL(url)
+					}
+					return true
+				})
+			}
+		}
 		opts.CommonButtonClicked(win.TDCBF_YES_BUTTON).Attach(func() bool {
 			select {
 			case userAcceptedChan <- true:
@@ -1580,7 +2290,7 @@ func triggerUpdate(mw *walk.MainWindow) {
 
 	// Trigger update via manager IPC
 	logger.Info("Starting update download via manager...")
-	err := managers.IPCClientUpdate()
+	err = managers.IPCClientUpdate()
 	if err != nil {
 		logger.Error("Failed to trigger update: %v", err)
 		walk.App().Synchronize(func() {
@@ -1588,7 +2298,7 @@ func triggerUpdate(mw *walk.MainWindow) {
 			td.Show(walk.TaskDialogOpts{
 				Owner:         mw,
 				Title:         "Update Failed",
-				Content:       fmt.Sprintf("Failed to start update: %v", err),
+				Content:       fmt.Sprintf("Failed to start update: %s", ipcErrorText(err)),
 				IconSystem:    walk.TaskDialogSystemIconError,
 				CommonButtons: win.TDCBF_OK_BUTTON,
 			})
@@ -0,0 +1,520 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fosrl/windows/api"
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/managers"
+	"github.com/fosrl/windows/tunnel"
+	"github.com/fosrl/windows/updater"
+
+	"github.com/tailscale/walk"
+)
+
+func TestFormatVerification(t *testing.T) {
+	tests := []struct {
+		name string
+		v    *updater.VerificationResult
+		want string
+	}{
+		{name: "no details"},
+		{
+			name: "verified",
+			v:    &updater.VerificationResult{Verified: true, Signer: "Fossorial, Inc.", Thumbprint: "0123ABCD"},
+			want: "The installer's signature was verified.\r\nSigned by: Fossorial, Inc.\r\nCertificate thumbprint: 0123ABCD",
+		},
+		{
+			name: "not trusted",
+			v:    &updater.VerificationResult{Reason: "The signature is not trusted: expired"},
+			want: "The installer's signature could not be verified: The signature is not trusted: expired",
+		},
+		{
+			name: "signer known but not trusted",
+			v:    &updater.VerificationResult{Signer: "Fossorial, Inc.", Reason: "revoked"},
+			want: "The installer's signature could not be verified: revoked\r\nSigned by: Fossorial, Inc.",
+		},
+		{name: "empty result", v: &updater.VerificationResult{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatVerification(tt.v); got != tt.want {
+				t.Errorf("formatVerification() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateReleaseNotes(t *testing.T) {
+	tests := []struct {
+		name          string
+		notes         string
+		maxLen        int
+		want          string
+		wantTruncated bool
+	}{
+		{name: "short", notes: "One fix", maxLen: 20, want: "One fix"},
+		{name: "exactly the limit", notes: "0123456789", maxLen: 10, want: "0123456789"},
+		{name: "cut at a line break", notes: "- First\r\n- Second\r\n- Third", maxLen: 20, want: "- First\r\n- Second\r\n...", wantTruncated: true},
+		{name: "no line break", notes: strings.Repeat("a", 30), maxLen: 10, want: strings.Repeat("a", 10) + "\r\n...", wantTruncated: true},
+		{name: "keeps whole characters", notes: "ééééé", maxLen: 5, want: "éé\r\n...", wantTruncated: true},
+		{name: "trailing space dropped", notes: "Fixes   \r\nMore", maxLen: 9, want: "Fixes\r\n...", wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncateReleaseNotes(tt.notes, tt.maxLen)
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("truncateReleaseNotes(%q, %d) = %q, %v, want %q, %v", tt.notes, tt.maxLen, got, truncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestAccountLabelText(t *testing.T) {
+	name := "Milo Ortiz"
+	username := "milo"
+	user := &api.User{UserId: "user-1", Email: "milo@example.com", Name: &name}
+
+	tests := []struct {
+		name     string
+		account  *config.Account
+		user     *api.User
+		loggedIn bool
+		want     string
+	}{
+		{name: "logged out", account: &config.Account{UserID: "user-1", Email: "milo@example.com"}, want: "Not logged in"},
+		{name: "no account", loggedIn: true, want: "Not logged in"},
+		{name: "account email", account: &config.Account{UserID: "user-1", Email: "milo@example.com"}, loggedIn: true, want: "milo@example.com"},
+		{name: "account name", account: &config.Account{UserID: "user-1", Name: name}, user: user, loggedIn: true, want: name},
+		{name: "account username", account: &config.Account{UserID: "user-1", Username: username}, loggedIn: true, want: username},
+		{name: "empty account falls back to the user", account: &config.Account{UserID: "user-1"}, user: user, loggedIn: true, want: "milo@example.com"},
+		{name: "user of another account ignored", account: &config.Account{UserID: "user-2"}, user: user, loggedIn: true, want: "Account"},
+		{name: "empty account without a user", account: &config.Account{UserID: "user-1"}, loggedIn: true, want: "Account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := accountLabelText(tt.account, tt.user, tt.loggedIn); got != tt.want {
+				t.Errorf("accountLabelText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogOut(t *testing.T) {
+	errStop := errors.New("manager not running")
+	errLogout := errors.New("account store locked")
+	tests := []struct {
+		name      string
+		stopErr   error
+		logoutErr error
+		wantErr   error
+	}{
+		{name: "tunnel running"},
+		{name: "tunnel can't be stopped", stopErr: errStop},
+		{name: "logout fails", logoutErr: errLogout, wantErr: errLogout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			err := logOut(
+				func() error {
+					ran = append(ran, "stop tunnel")
+					return tt.stopErr
+				},
+				func() error {
+					ran = append(ran, "log out")
+					return tt.logoutErr
+				})
+			// The tunnel is stopped while the session it was started with still exists
+			if want := []string{"stop tunnel", "log out"}; !reflect.DeepEqual(ran, want) {
+				t.Errorf("ran %v, want %v", ran, want)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("logOut() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatTrayTooltip(t *testing.T) {
+	summary := &tunnel.StatusSummary{Connected: true, Peers: 3, ConnectedPeers: 2}
+	stats := &tunnel.Stats{RxBytes: 1258291, TxBytes: 348160}
+
+	tests := []struct {
+		name    string
+		state   tunnel.State
+		orgName string
+		summary *tunnel.StatusSummary
+		stats   *tunnel.Stats
+		want    string
+	}{
+		{name: "disconnected", state: tunnel.StateStopped, orgName: "HQ", summary: summary, stats: stats, want: "Pangolin: Disconnected"},
+		{name: "connecting", state: tunnel.StateRegistering, orgName: "HQ", want: "Pangolin: Registering..."},
+		{name: "connected", state: tunnel.StateRunning, want: "Pangolin: Connected"},
+		{name: "connected to an organization", state: tunnel.StateRunning, orgName: "HQ", want: "Pangolin: Connected to HQ"},
+		{name: "with sites", state: tunnel.StateRunning, orgName: "HQ", summary: summary, want: "Pangolin: Connected to HQ, 2 of 3 sites connected"},
+		{name: "without sites", state: tunnel.StateRunning, orgName: "HQ", summary: &tunnel.StatusSummary{Connected: true}, want: "Pangolin: Connected to HQ"},
+		{name: "with traffic", state: tunnel.StateRunning, orgName: "HQ", stats: stats, want: "Pangolin: Connected to HQ, ↓1.2 MB ↑340.0 KB"},
+		{name: "everything", state: tunnel.StateRunning, orgName: "HQ", summary: summary, stats: stats, want: "Pangolin: Connected to HQ, 2 of 3 sites connected, ↓1.2 MB ↑340.0 KB"},
+		{name: "no traffic yet", state: tunnel.StateRunning, stats: &tunnel.Stats{}, want: "Pangolin: Connected, ↓0 B ↑0 B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTrayTooltip(tt.state, tt.orgName, tt.summary, tt.stats); got != tt.want {
+				t.Errorf("formatTrayTooltip() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTunnelStatusText(t *testing.T) {
+	tests := []struct {
+		state   tunnel.State
+		attempt int
+		want    string
+	}{
+		{state: tunnel.StateReconnecting, attempt: 1, want: "Reconnecting (attempt 1)..."},
+		{state: tunnel.StateReconnecting, attempt: 4, want: "Reconnecting (attempt 4)..."},
+		{state: tunnel.StateReconnecting, want: "Reconnecting..."},
+		{state: tunnel.StateRegistered, want: "Connecting..."},
+		{state: tunnel.StateRunning, want: "Connected"},
+		{state: tunnel.StateRunning, attempt: 2, want: "Connected"},
+		{state: tunnel.StateError, attempt: 2, want: "Error"},
+		{state: tunnel.StateStopped, want: "Disconnected"},
+	}
+
+	for _, tt := range tests {
+		if got := tunnelStatusText(tt.state, tt.attempt); got != tt.want {
+			t.Errorf("tunnelStatusText(%v, %d) = %q, want %q", tt.state, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestIntendsConnected(t *testing.T) {
+	tests := []struct {
+		state tunnel.State
+		want  bool
+	}{
+		{state: tunnel.StateStopped},
+		{state: tunnel.StateStarting, want: true},
+		{state: tunnel.StateRegistering, want: true},
+		{state: tunnel.StateRegistered, want: true},
+		{state: tunnel.StateRunning, want: true},
+		{state: tunnel.StateReconnecting, want: true},
+		{state: tunnel.StateStopping},
+		{state: tunnel.StateInvalid},
+		{state: tunnel.StateError},
+	}
+
+	for _, tt := range tests {
+		if got := intendsConnected(tt.state); got != tt.want {
+			t.Errorf("intendsConnected(%v) = %v, want %v", tt.state, got, tt.want)
+		}
+	}
+}
+
+// useTestConfig points configManager at a fresh config for the test
+func useTestConfig(t *testing.T) {
+	t.Helper()
+	t.Setenv("LOCALAPPDATA", t.TempDir())
+	saved := configManager
+	configManager = config.NewConfigManager()
+	t.Cleanup(func() { configManager = saved })
+}
+
+func TestRecordLastConnected(t *testing.T) {
+	earlier := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		previous tunnel.State
+		state    tunnel.State
+		want     time.Time
+	}{
+		{name: "came up", previous: tunnel.StateRegistered, state: tunnel.StateRunning, want: now},
+		{name: "came up from stopped", previous: tunnel.StateStopped, state: tunnel.StateRunning, want: now},
+		{name: "came back after reconnecting", previous: tunnel.StateReconnecting, state: tunnel.StateRunning, want: now},
+		{name: "still running", previous: tunnel.StateRunning, state: tunnel.StateRunning, want: earlier},
+		{name: "connecting", previous: tunnel.StateStopped, state: tunnel.StateRegistering, want: earlier},
+		{name: "went down", previous: tunnel.StateRunning, state: tunnel.StateStopped, want: earlier},
+		{name: "reconnecting", previous: tunnel.StateRunning, state: tunnel.StateReconnecting, want: earlier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestConfig(t)
+			configManager.SetLastConnectedAt(earlier)
+
+			recordLastConnected(tt.previous, tt.state, now)
+			if got := configManager.GetLastConnectedAt(); !got.Equal(tt.want) {
+				t.Errorf("LastConnectedAt = %v, want %v", got, tt.want)
+			}
+			// It is kept for the next run
+			if got := config.NewConfigManager().GetLastConnectedAt(); !got.Equal(tt.want) {
+				t.Errorf("LastConnectedAt after reload = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordLastConnectedWithoutConfig(t *testing.T) {
+	saved := configManager
+	configManager = nil
+	defer func() { configManager = saved }()
+
+	// Before the config is loaded there is nothing to record to
+	recordLastConnected(tunnel.StateRegistered, tunnel.StateRunning, time.Now())
+}
+
+func TestFormatByteCount(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1024, want: "1.0 KB"},
+		{n: 1536, want: "1.5 KB"},
+		{n: 1024*1024 - 1, want: "1024.0 KB"},
+		{n: 5 * 1024 * 1024, want: "5.0 MB"},
+		{n: 3 << 30, want: "3.0 GB"},
+		{n: 2 << 40, want: "2.0 TB"},
+		{n: 4096 << 40, want: "4096.0 TB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatByteCount(tt.n); got != tt.want {
+			t.Errorf("formatByteCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+// isUnsafePointerCall reports whether expr is a call of unsafe.Pointer
+func isUnsafePointerCall(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "unsafe" && sel.Sel.Name == "Pointer"
+}
+
+// The tray menu used to be shown by reading walk.Menu's unexported hMenu field
+// through a struct of the same layout, which breaks silently if walk reorders
+// its fields. No UI code may read another type's fields that way.
+func TestNoStructLayoutAccess(t *testing.T) {
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 || !isUnsafePointerCall(call.Args[0]) {
+				return true
+			}
+			paren, ok := call.Fun.(*ast.ParenExpr)
+			if !ok {
+				return true
+			}
+			if star, ok := paren.X.(*ast.StarExpr); ok {
+				if _, ok := star.X.(*ast.StructType); ok {
+					t.Errorf("%s: memory is read through a struct layout", fset.Position(call.Pos()))
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// useQueuedUIUpdates collects what the tray queues for the UI thread, in place
+// of the message loop tests don't have
+func useQueuedUIUpdates(t *testing.T) chan func() {
+	t.Helper()
+	queued := make(chan func(), 1024)
+	saved := synchronize
+	synchronize = func(f func()) { queued <- f }
+	t.Cleanup(func() { synchronize = saved })
+	return queued
+}
+
+// Run with -race: callbacks from many goroutines ask for menu updates and
+// change the tray's shared flags at the same time
+func TestUpdateMenuConcurrentCallers(t *testing.T) {
+	queued := useQueuedUIUpdates(t)
+	tr := new(Tray)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tr.updateMenu()
+			tr.setLoggedOut(i%2 == 0)
+			tr.setStatusSummary(&tunnel.OLMStatusResponse{Connected: true})
+			tr.handleStatsSnapshot(managers.StatsSnapshot{})
+		}()
+	}
+	wg.Wait()
+
+	// Requests made before the queued update runs share it
+	if n := len(queued); n != 1 {
+		t.Fatalf("%d menu updates queued, want 1", n)
+	}
+
+	// Requests made while an update runs queue exactly one more
+	var wgUI sync.WaitGroup
+	wgUI.Add(1)
+	go func() {
+		defer wgUI.Done()
+		(<-queued)()
+	}()
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tr.updateMenu()
+		}()
+	}
+	wg.Wait()
+	wgUI.Wait()
+	if n := len(queued); n > 1 {
+		t.Errorf("%d menu updates queued while one ran, want at most 1", n)
+	}
+
+	// Once everything queued has run, the next request queues again
+	for len(queued) > 0 {
+		(<-queued)()
+	}
+	tr.updateMenu()
+	if n := len(queued); n != 1 {
+		t.Errorf("%d menu updates queued after the last one ran, want 1", n)
+	}
+}
+
+// fakeNotifyIcon records what the tray shows on its icon
+type fakeNotifyIcon struct {
+	icons    int
+	toolTips []string
+	infos    []string
+	warnings []string
+}
+
+func (f *fakeNotifyIcon) SetIcon(icon walk.Image) error { f.icons++; return nil }
+func (f *fakeNotifyIcon) SetToolTip(toolTip string) error {
+	f.toolTips = append(f.toolTips, toolTip)
+	return nil
+}
+func (f *fakeNotifyIcon) ShowInfo(title, info string) error {
+	f.infos = append(f.infos, title)
+	return nil
+}
+func (f *fakeNotifyIcon) ShowWarning(title, info string) error {
+	f.warnings = append(f.warnings, title)
+	return nil
+}
+func (f *fakeNotifyIcon) ShowContextMenu(x, y int) {}
+
+// runQueued runs what was queued for the UI thread, including anything queued
+// while doing so
+func runQueued(queued chan func()) {
+	for len(queued) > 0 {
+		(<-queued)()
+	}
+}
+
+func TestHandleTunnelStateChange(t *testing.T) {
+	defer func(state tunnel.State, seeded, requested bool) {
+		lastNotifyState, stateNotifySeeded, userDisconnectRequested = state, seeded, requested
+	}(lastNotifyState, stateNotifySeeded, userDisconnectRequested)
+	stateNotifySeeded = false
+	queued := useQueuedUIUpdates(t)
+
+	icon := &fakeNotifyIcon{}
+	tr := &Tray{icon: icon}
+
+	steps := []struct {
+		state         tunnel.State
+		wantConnected bool
+		wantInfos     int
+		wantWarnings  int
+	}{
+		// The state the tunnel is in when the UI starts is not announced
+		{state: tunnel.StateStopped},
+		{state: tunnel.StateStarting},
+		{state: tunnel.StateRegistering},
+		{state: tunnel.StateRunning, wantConnected: true, wantInfos: 1},
+		{state: tunnel.StateReconnecting, wantConnected: true, wantInfos: 1},
+		// Coming back after reconnecting is announced again
+		{state: tunnel.StateRunning, wantConnected: true, wantInfos: 2},
+		{state: tunnel.StateStopped, wantInfos: 2, wantWarnings: 1},
+	}
+
+	for i, step := range steps {
+		tr.handleTunnelStateChange(step.state)
+
+		tr.tunnelStateMutex.RLock()
+		recorded := tunnel.State(tr.currentTunnelState)
+		tr.tunnelStateMutex.RUnlock()
+		if recorded != step.state {
+			t.Errorf("step %d: recorded state %v, want %v", i, recorded, step.state)
+		}
+		// The icon is only changed on the UI thread
+		if len(queued) == 0 {
+			t.Fatalf("step %d: nothing queued for the UI thread", i)
+		}
+		toolTips := len(icon.toolTips)
+		runQueued(queued)
+		if len(icon.toolTips) != toolTips+1 {
+			t.Fatalf("step %d: tooltip set %d times, want once", i, len(icon.toolTips)-toolTips)
+		}
+
+		if want := formatTrayTooltip(step.state, "", nil, nil); icon.toolTips[len(icon.toolTips)-1] != want {
+			t.Errorf("step %d: tooltip %q, want %q", i, icon.toolTips[len(icon.toolTips)-1], want)
+		}
+		tr.connectMutex.RLock()
+		connected := tr.isConnected
+		tr.connectMutex.RUnlock()
+		if connected != step.wantConnected {
+			t.Errorf("step %d: isConnected = %v in %v, want %v", i, connected, step.state, step.wantConnected)
+		}
+		if len(icon.infos) != step.wantInfos || len(icon.warnings) != step.wantWarnings {
+			t.Errorf("step %d: %d infos and %d warnings after %v, want %d and %d",
+				i, len(icon.infos), len(icon.warnings), step.state, step.wantInfos, step.wantWarnings)
+		}
+	}
+
+	if !reflect.DeepEqual(icon.infos, []string{"Connected", "Connected"}) || !reflect.DeepEqual(icon.warnings, []string{"Disconnected"}) {
+		t.Errorf("balloons %q and %q, want Connected and Disconnected", icon.infos, icon.warnings)
+	}
+}
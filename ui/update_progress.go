@@ -0,0 +1,154 @@
+//go:build windows
+
+package ui
+
+import (
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/icons"
+	"github.com/fosrl/windows/managers"
+	"github.com/fosrl/windows/updater"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/tailscale/walk"
+)
+
+// updateProgressWindow is a modeless window showing the progress of an update download
+type updateProgressWindow struct {
+	*walk.Dialog
+	statusLabel  *walk.Label
+	progressBar  *walk.ProgressBar
+	cancelButton *walk.PushButton
+}
+
+// updateProgressInstance is only accessed on the UI thread
+var updateProgressInstance *updateProgressWindow
+
+// updateProgressPercent returns the download percentage, or false if the total size is unknown
+func updateProgressPercent(dp updater.DownloadProgress) (int, bool) {
+	if dp.BytesTotal == 0 {
+		return 0, false
+	}
+	if dp.BytesDownloaded >= dp.BytesTotal {
+		return 100, true
+	}
+	return int(dp.BytesDownloaded * 100 / dp.BytesTotal), true
+}
+
+func newUpdateProgressWindow(owner walk.Form) (*updateProgressWindow, error) {
+	upw := &updateProgressWindow{}
+
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	if upw.Dialog, err = walk.NewDialogWithFixedSize(owner); err != nil {
+		return nil, err
+	}
+	disposables.Add(upw)
+
+	upw.SetTitle("Updating Pangolin")
+	layout := walk.NewVBoxLayout()
+	layout.SetMargins(walk.Margins{HNear: 12, VNear: 12, HFar: 12, VFar: 12})
+	layout.SetSpacing(8)
+	upw.SetLayout(layout)
+
+	if upw.statusLabel, err = walk.NewLabel(upw); err != nil {
+		return nil, err
+	}
+	upw.statusLabel.SetText("Initializing")
+
+	if upw.progressBar, err = walk.NewProgressBar(upw); err != nil {
+		return nil, err
+	}
+	upw.progressBar.SetRange(0, 100)
+	upw.progressBar.SetMarqueeMode(true)
+
+	buttonsContainer, err := walk.NewComposite(upw)
+	if err != nil {
+		return nil, err
+	}
+	buttonsContainer.SetLayout(walk.NewHBoxLayout())
+	buttonsContainer.Layout().SetMargins(walk.Margins{})
+	walk.NewHSpacer(buttonsContainer)
+
+	if upw.cancelButton, err = walk.NewPushButton(buttonsContainer); err != nil {
+		return nil, err
+	}
+	upw.cancelButton.SetText("&Cancel")
+	upw.cancelButton.Clicked().Attach(upw.onCancel)
+
+	disposables.Spare()
+
+	iconPath := icons.Path(config.GetIconsPath(), "icon-orange.ico")
+	if icon, err := walk.NewIconFromFile(iconPath); err == nil {
+		upw.SetIcon(icon)
+	}
+
+	upw.SetSize(walk.Size{Width: 380, Height: 140})
+
+	// Closing the window only hides the progress; the download carries on until canceled
+	upw.Closing().Attach(func(canceled *bool, reason walk.CloseReason) {
+		if updateProgressInstance == upw {
+			updateProgressInstance = nil
+		}
+	})
+
+	return upw, nil
+}
+
+// onCancel asks the manager to abort the update. The window is closed once the
+// resulting error notification arrives.
+func (upw *updateProgressWindow) onCancel() {
+	upw.cancelButton.SetEnabled(false)
+	upw.statusLabel.SetText("Canceling...")
+	go func() {
+		if err := managers.IPCClientCancelUpdate(); err != nil {
+			logger.Error("Failed to cancel update: %v", err)
+			walk.App().Synchronize(func() {
+				if updateProgressInstance == upw {
+					upw.cancelButton.SetEnabled(true)
+				}
+			})
+		}
+	}()
+}
+
+func (upw *updateProgressWindow) update(dp updater.DownloadProgress) {
+	if len(dp.Activity) > 0 && upw.cancelButton.Enabled() {
+		upw.statusLabel.SetText(dp.Activity)
+	}
+
+	percent, known := updateProgressPercent(dp)
+	if upw.progressBar.MarqueeMode() == known {
+		upw.progressBar.SetMarqueeMode(!known)
+	}
+	if known {
+		upw.progressBar.SetValue(percent)
+	}
+}
+
+// showUpdateProgress creates the progress window if needed and applies dp to it.
+// Must be called on the UI thread.
+func showUpdateProgress(owner walk.Form, dp updater.DownloadProgress) {
+	if updateProgressInstance == nil {
+		upw, err := newUpdateProgressWindow(owner)
+		if err != nil {
+			logger.Error("Failed to create update progress window: %v", err)
+			return
+		}
+		updateProgressInstance = upw
+		upw.SetVisible(true)
+	}
+	updateProgressInstance.update(dp)
+}
+
+// closeUpdateProgress closes the progress window if it is open.
+// Must be called on the UI thread.
+func closeUpdateProgress() {
+	if updateProgressInstance == nil {
+		return
+	}
+	upw := updateProgressInstance
+	updateProgressInstance = nil
+	upw.Close(0)
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package ui
+
+import (
+	"testing"
+
+	"github.com/fosrl/windows/updater"
+)
+
+func TestUpdateProgressPercent(t *testing.T) {
+	tests := []struct {
+		name        string
+		downloaded  uint64
+		total       uint64
+		wantPercent int
+		wantKnown   bool
+	}{
+		{name: "size unknown", downloaded: 4096},
+		{name: "nothing yet", total: 1000, wantKnown: true},
+		{name: "part way", downloaded: 250, total: 1000, wantPercent: 25, wantKnown: true},
+		{name: "rounds down", downloaded: 999, total: 1000, wantPercent: 99, wantKnown: true},
+		{name: "done", downloaded: 1000, total: 1000, wantPercent: 100, wantKnown: true},
+		{name: "past the total", downloaded: 1200, total: 1000, wantPercent: 100, wantKnown: true},
+		{name: "large installer", downloaded: 3 << 30, total: 4 << 30, wantPercent: 75, wantKnown: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			percent, known := updateProgressPercent(updater.DownloadProgress{BytesDownloaded: tt.downloaded, BytesTotal: tt.total})
+			if percent != tt.wantPercent || known != tt.wantKnown {
+				t.Errorf("updateProgressPercent() = %d, %v, want %d, %v", percent, known, tt.wantPercent, tt.wantKnown)
+			}
+		})
+	}
+}
@@ -3,20 +3,89 @@
 package updater
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
 )
 
-func verifyAuthenticode(path string) bool {
+var (
+	modwintrust                        = windows.NewLazySystemDLL("wintrust.dll")
+	procWTHelperProvDataFromStateData  = modwintrust.NewProc("WTHelperProvDataFromStateData")
+	procWTHelperGetProvSignerFromChain = modwintrust.NewProc("WTHelperGetProvSignerFromChain")
+)
+
+// cryptProviderSgnr is the start of CRYPT_PROVIDER_SGNR, up to the chain built
+// for the signer
+type cryptProviderSgnr struct {
+	size         uint32
+	verifyAsOf   windows.Filetime
+	certChainLen uint32
+	certChain    *cryptProviderCert
+}
+
+// cryptProviderCert is the start of CRYPT_PROVIDER_CERT, up to its certificate
+type cryptProviderCert struct {
+	size uint32
+	cert *windows.CertContext
+}
+
+// VerificationResult describes how a downloaded update was verified
+type VerificationResult struct {
+	Verified   bool   // The hash and the Authenticode signature both checked out
+	Signer     string // Common name of the signing certificate
+	Thumbprint string // SHA-1 thumbprint of the signing certificate
+	SignerKey  string // SHA-256 hash of the signing certificate's public key
+	Reason     string // Why verification failed, empty if Verified
+}
+
+// verifyUpdateFile checks the Authenticode signature of a downloaded update whose
+// hash already matched the signed manifest, and reports the signing certificate
+func verifyUpdateFile(path string) VerificationResult {
+	// Full revocation checking, as this is called with network connectivity.
+	return verifySignedFile(path, windows.WTD_REVOKE_WHOLECHAIN)
+}
+
+// verifySignedFile checks the Authenticode signature of a file with the given
+// WinVerifyTrust revocation checks and reports the signing certificate
+func verifySignedFile(path string, revocationChecks uint32) VerificationResult {
+	return verificationFromSigner(authenticodeSigner(path, revocationChecks))
+}
+
+// verificationFromSigner reports the signer WinVerifyTrust verified, or why it
+// found none
+func verificationFromSigner(cert *x509.Certificate, err error) VerificationResult {
+	if err != nil {
+		return VerificationResult{Reason: err.Error()}
+	}
+	return VerificationResult{
+		Verified:   true,
+		Signer:     cert.Subject.CommonName,
+		Thumbprint: certificateThumbprint(cert),
+		SignerKey:  publicKeyHash(cert),
+	}
+}
+
+// authenticodeSigner checks the Authenticode signature of a file with
+// WinVerifyTrust and returns the certificate of the signer it verified. The
+// signature's certificate store isn't consulted: anyone can add certificates
+// to it, and only the one the signature was checked with says who signed.
+func authenticodeSigner(path string, revocationChecks uint32) (*x509.Certificate, error) {
 	path16, err := windows.UTF16PtrFromString(path)
 	if err != nil {
-		return false
+		return nil, err
 	}
 	data := &windows.WinTrustData{
 		Size:             uint32(unsafe.Sizeof(windows.WinTrustData{})),
 		UIChoice:         windows.WTD_UI_NONE,
-		RevocationChecks: windows.WTD_REVOKE_WHOLECHAIN, // Full revocation checking, as this is called with network connectivity.
+		RevocationChecks: revocationChecks,
 		UnionChoice:      windows.WTD_CHOICE_FILE,
 		StateAction:      windows.WTD_STATEACTION_VERIFY,
 		FileOrCatalogOrBlobOrSgnrOrCert: unsafe.Pointer(&windows.WinTrustFileInfo{
@@ -24,9 +93,52 @@ func verifyAuthenticode(path string) bool {
 			FilePath: path16,
 		}),
 	}
-	verified := windows.WinVerifyTrustEx(windows.InvalidHWND, &windows.WINTRUST_ACTION_GENERIC_VERIFY_V2, data) == nil
-	data.StateAction = windows.WTD_STATEACTION_CLOSE
-	windows.WinVerifyTrustEx(windows.InvalidHWND, &windows.WINTRUST_ACTION_GENERIC_VERIFY_V2, data)
-	return verified
+	trustErr := windows.WinVerifyTrustEx(windows.InvalidHWND, &windows.WINTRUST_ACTION_GENERIC_VERIFY_V2, data)
+	defer func() {
+		data.StateAction = windows.WTD_STATEACTION_CLOSE
+		windows.WinVerifyTrustEx(windows.InvalidHWND, &windows.WINTRUST_ACTION_GENERIC_VERIFY_V2, data)
+	}()
+	if trustErr != nil {
+		return nil, fmt.Errorf("The signature is not trusted: %w", trustErr)
+	}
+
+	cert, err := verifiedSigner(data.StateData)
+	if err != nil {
+		return nil, fmt.Errorf("The signing certificate could not be read: %w", err)
+	}
+	return cert, nil
+}
+
+// verifiedSigner returns the leaf certificate of the primary signer from the
+// state of a successful WinVerifyTrust call, which must not be closed yet
+func verifiedSigner(stateData windows.Handle) (*x509.Certificate, error) {
+	provData, _, _ := procWTHelperProvDataFromStateData.Call(uintptr(stateData))
+	if provData == 0 {
+		return nil, errors.New("no provider data")
+	}
+	r, _, _ := procWTHelperGetProvSignerFromChain.Call(provData, 0, 0, 0)
+	if r == 0 {
+		return nil, errors.New("no signer")
+	}
+	// Reinterpret the returned address rather than convert it, as it points
+	// into memory Go doesn't manage
+	signer := *(**cryptProviderSgnr)(unsafe.Pointer(&r))
+	if signer.certChainLen == 0 || signer.certChain == nil || signer.certChain.cert == nil {
+		return nil, errors.New("the signer has no certificate chain")
+	}
+	ctx := signer.certChain.cert
+	return x509.ParseCertificate(bytes.Clone(unsafe.Slice(ctx.EncodedCert, ctx.Length)))
 }
 
+// certificateThumbprint formats the SHA-1 hash of a certificate the way Windows shows it
+func certificateThumbprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// publicKeyHash returns the SHA-256 hash of a certificate's SubjectPublicKeyInfo,
+// which stays the same when a certificate is renewed with the same key
+func publicKeyHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
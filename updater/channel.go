@@ -0,0 +1,39 @@
+//go:build windows
+
+package updater
+
+import (
+	"sync"
+
+	"github.com/fosrl/windows/config"
+)
+
+var (
+	updateChannel     = config.DefaultUpdateChannel
+	updateChannelLock sync.RWMutex
+)
+
+// SetChannel selects the release feed used by subsequent update checks
+func SetChannel(channel config.UpdateChannel) {
+	if !channel.IsValid() {
+		channel = config.DefaultUpdateChannel
+	}
+	updateChannelLock.Lock()
+	updateChannel = channel
+	updateChannelLock.Unlock()
+}
+
+// Channel returns the release feed used for update checks
+func Channel() config.UpdateChannel {
+	updateChannelLock.RLock()
+	defer updateChannelLock.RUnlock()
+	return updateChannel
+}
+
+// manifestPath returns the signed manifest path for a channel
+func manifestPath(channel config.UpdateChannel) string {
+	if channel == config.UpdateChannelBeta {
+		return betaLatestVersionPath
+	}
+	return latestVersionPath
+}
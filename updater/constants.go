@@ -14,8 +14,24 @@ const (
 	updateServerUseHttps = true
 	// latestVersionPath is the path to the latest version signature file
 	latestVersionPath = "/windows-client/latest.sig"
+	// betaLatestVersionPath is the path to the latest beta version signature file
+	betaLatestVersionPath = "/windows-client/beta/latest.sig"
+	// releaseNotesPath is the path format for a version's release notes (use %s for version)
+	releaseNotesPath = "/windows-client/notes/%s.txt"
+	// betaReleaseNotesPath is the path format for a beta version's release notes (use %s for version)
+	betaReleaseNotesPath = "/windows-client/beta/notes/%s.txt"
+	// releasePageURL is the public release page for a version (use %s for version)
+	releasePageURL = "https://github.com/fosrl/windows/releases/tag/%s"
 	// msiArchPrefix is the prefix for MSI filenames (use %s for architecture)
 	msiArchPrefix = "pangolin-%s-"
 	// msiSuffix is the suffix for MSI filenames
 	msiSuffix = ".msi"
+	// officialSignerName is the common name of the certificate official installers are signed with
+	officialSignerName = "Fossorial"
 )
+
+// officialSignerKeys lists, comma separated, the hex SHA-256 hashes of the public
+// keys official installers are signed with, as in VerificationResult.SignerKey.
+// Release builds set it with -ldflags "-X"; see BUILD_STEPS.md. Left empty, no
+// local installer is accepted.
+var officialSignerKeys = ""
@@ -4,6 +4,7 @@ package updater
 
 import (
 	"crypto/hmac"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"hash"
@@ -32,6 +33,8 @@ type DownloadProgress struct {
 	BytesTotal      uint64
 	Error           error
 	Complete        bool
+	// Verification is set once the download has been verified, and on completion
+	Verification *VerificationResult
 }
 
 type progressHashWatcher struct {
@@ -41,6 +44,9 @@ type progressHashWatcher struct {
 }
 
 func (pm *progressHashWatcher) Write(p []byte) (int, error) {
+	if atomic.LoadUint32(&updateCanceled) != 0 {
+		return 0, ErrUpdateCanceled
+	}
 	bytes := len(p)
 	pm.dp.BytesDownloaded += uint64(bytes)
 	pm.c <- *pm.dp
@@ -50,8 +56,10 @@ func (pm *progressHashWatcher) Write(p []byte) (int, error) {
 
 type UpdateFound struct {
 	name             string
+	version          string
 	hash             [blake2b.Size256]byte
 	downloadLocation string // Can be empty (use default), a relative path, or a full URL
+	releaseNotes     string // Empty if the release has no notes
 }
 
 // Name returns the filename of the update MSI
@@ -59,6 +67,21 @@ func (u *UpdateFound) Name() string {
 	return u.name
 }
 
+// Version returns the version of the update
+func (u *UpdateFound) Version() string {
+	return u.version
+}
+
+// ReleaseNotes returns the release notes for the update, or an empty string if there are none
+func (u *UpdateFound) ReleaseNotes() string {
+	return u.releaseNotes
+}
+
+// ReleasePageURL returns the public release page for the update
+func (u *UpdateFound) ReleasePageURL() string {
+	return fmt.Sprintf(releasePageURL, u.version)
+}
+
 func CheckForUpdate() (updateFound *UpdateFound, err error) {
 	logger.Info("Updater: CheckForUpdate() called")
 	updateFound, _, _, err = checkForUpdate(false)
@@ -76,23 +99,18 @@ func checkForUpdate(keepSession bool) (*UpdateFound, *winhttp.Session, *winhttp.
 	logger.Info("Updater: checkForUpdate() started (keepSession=%v)", keepSession)
 	logger.Info("Updater: Current version: %s, Architecture: %s", version.Number, version.Arch())
 
-	// // Allow bypassing official version check for development/testing
-	// // Set PANGOLIN_ALLOW_DEV_UPDATES=1 to enable updates on unsigned builds
-	// isOfficial := version.IsRunningOfficialVersion()
-	// logger.Info("Updater: IsRunningOfficialVersion: %v", isOfficial)
-	// if !isOfficial {
-	// 	devMode := os.Getenv("PANGOLIN_ALLOW_DEV_UPDATES") == "1"
-	// 	logger.Info("Updater: PANGOLIN_ALLOW_DEV_UPDATES: %v", devMode)
-	// 	if !devMode {
-	// 		err := errors.New("Build is not official, so updates are disabled")
-	// 		logger.Error("Updater: %v", err)
-	// 		return nil, nil, nil, err
-	// 	}
-	// 	logger.Info("Updater: Development mode enabled - allowing updates on unsigned build")
-	// }
+	// Allow bypassing official version check for development/testing
+	// Set PANGOLIN_ALLOW_DEV_UPDATES=1 to enable updates on unofficial builds
+	allowed, devMode := version.UpdatesAllowed()
+	logger.Info("Updater: updates allowed: %v, development mode: %v", allowed, devMode)
+	if !allowed {
+		err := errors.New("Build is not official, so updates are disabled")
+		logger.Error("Updater: %v", err)
+		return nil, nil, nil, err
+	}
 
 	logger.Info("Updater: Creating WinHTTP session with User-Agent: %s", version.UserAgent())
-	session, err := winhttp.NewSession(version.UserAgent())
+	session, err := newSession(updateServerHost, updateServerUseHttps)
 	if err != nil {
 		logger.Error("Updater: Failed to create WinHTTP session: %v", err)
 		return nil, nil, nil, err
@@ -119,8 +137,10 @@ func checkForUpdate(keepSession bool) (*UpdateFound, *winhttp.Session, *winhttp.
 		}
 	}()
 
-	logger.Info("Updater: Fetching manifest from: %s", latestVersionPath)
-	response, err := connection.Get(latestVersionPath, true)
+	channel := Channel()
+	manifest := manifestPath(channel)
+	logger.Info("Updater: Fetching %s manifest from: %s", channel, manifest)
+	response, err := connection.Get(manifest, true)
 	if err != nil {
 		logger.Error("Updater: Failed to fetch manifest: %v", err)
 		return nil, nil, nil, err
@@ -145,7 +165,7 @@ func checkForUpdate(keepSession bool) (*UpdateFound, *winhttp.Session, *winhttp.
 	logger.Info("Updater: Manifest parsed successfully, found %d files", len(files))
 
 	logger.Info("Updater: Searching for update candidate")
-	updateFound, err := findCandidate(files)
+	updateFound, err := findCandidate(files, channel)
 	if err != nil {
 		logger.Error("Updater: Error finding candidate: %v", err)
 		return nil, nil, nil, err
@@ -153,7 +173,13 @@ func checkForUpdate(keepSession bool) (*UpdateFound, *winhttp.Session, *winhttp.
 	if updateFound == nil {
 		logger.Info("Updater: No update candidate found")
 	} else {
+		// Never hand an older (or the same) build to the downloader
+		if err = checkNotDowngrade(updateFound.version, version.Number, downgradeAllowed()); err != nil {
+			logger.Error("Updater: Refusing update candidate %s: %v", updateFound.name, err)
+			return nil, nil, nil, err
+		}
 		logger.Info("Updater: Update candidate found: %s", updateFound.name)
+		updateFound.releaseNotes = fetchReleaseNotes(connection, channel, updateFound.version)
 	}
 
 	if keepSession {
@@ -163,7 +189,24 @@ func checkForUpdate(keepSession bool) (*UpdateFound, *winhttp.Session, *winhttp.
 	return updateFound, nil, nil, nil
 }
 
-var updateInProgress = uint32(0)
+var (
+	updateInProgress = uint32(0)
+	updateCanceled   = uint32(0)
+)
+
+// ErrUpdateCanceled is reported through DownloadProgress when CancelUpdate aborts an update
+var ErrUpdateCanceled = errors.New("The update was canceled")
+
+// CancelUpdate aborts the in-flight update before the installer is started.
+// Returns false if no update is in progress.
+func CancelUpdate() bool {
+	if atomic.LoadUint32(&updateInProgress) == 0 {
+		return false
+	}
+	logger.Info("Updater: Cancel requested")
+	atomic.StoreUint32(&updateCanceled, 1)
+	return true
+}
 
 func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress) {
 	progress = make(chan DownloadProgress, 128)
@@ -174,6 +217,8 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 		return
 	}
 
+	atomic.StoreUint32(&updateCanceled, 0)
+
 	doIt := func() {
 		defer atomic.StoreUint32(&updateInProgress, 0)
 		logger.Info("Updater: DownloadVerifyAndExecute started (userToken=%v)", userToken != 0)
@@ -215,9 +260,9 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 		dp := DownloadProgress{Activity: "Downloading update"}
 		progress <- dp
 
-		var response *winhttp.Response
-		var downloadConnection *winhttp.Connection
-		var downloadSession *winhttp.Session
+		// The update server connection serves relative paths; full URLs get their own below
+		downloadConnection := connection
+		downloadPath := ""
 
 		// Get download location from manifest (required)
 		downloadLocation := update.downloadLocation
@@ -276,7 +321,7 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 			}
 
 			// Build the path (include query and fragment if present)
-			downloadPath := parsedURL.Path
+			downloadPath = parsedURL.Path
 			if parsedURL.RawQuery != "" {
 				downloadPath += "?" + parsedURL.RawQuery
 			}
@@ -290,7 +335,7 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 			logger.Info("Updater: Connecting to external download server: %s:%d (HTTPS=%v)", host, port, isHTTPS)
 
 			// Create a new session for the external download
-			downloadSession, err = winhttp.NewSession(version.UserAgent())
+			downloadSession, err := newSession(host, isHTTPS)
 			if err != nil {
 				logger.Error("Updater: Failed to create WinHTTP session for external download: %v", err)
 				progress <- DownloadProgress{Error: err}
@@ -308,32 +353,10 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 			defer downloadConnection.Close()
 
 			logger.Info("Updater: Downloading MSI from path: %s", downloadPath)
-			response, err = downloadConnection.Get(downloadPath, false)
-			if err != nil {
-				logger.Error("Updater: Failed to download MSI from external URL: %v", err)
-				progress <- DownloadProgress{Error: err}
-				return
-			}
 		} else {
 			// Relative path - download from update server
 			logger.Info("Updater: Downloading MSI from update server: %s", downloadLocation)
-			response, err = connection.Get(downloadLocation, false)
-			if err != nil {
-				logger.Error("Updater: Failed to download MSI: %v", err)
-				progress <- DownloadProgress{Error: err}
-				return
-			}
-		}
-		defer response.Close()
-		logger.Info("Updater: MSI download response received")
-
-		length, err := response.Length()
-		if err == nil {
-			logger.Info("Updater: MSI file size: %d bytes", length)
-			dp.BytesTotal = length
-			progress <- dp
-		} else {
-			logger.Warn("Updater: Could not determine MSI file size: %v", err)
+			downloadPath = downloadLocation
 		}
 
 		logger.Info("Updater: Initializing BLAKE2b-256 hasher for verification")
@@ -343,67 +366,130 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 			progress <- DownloadProgress{Error: err}
 			return
 		}
-		pm := &progressHashWatcher{&dp, progress, hasher}
-		logger.Info("Updater: Starting download (max 100 MiB)")
-		bytesWritten, err := io.Copy(file, io.TeeReader(io.LimitReader(response, 1024*1024*100 /* 100 MiB */), pm))
+		// Continue an earlier download of the same installer that was interrupted
+		partial, err := openPartialStore()
 		if err != nil {
-			logger.Error("Updater: Download failed: %v (bytes written: %d)", err, bytesWritten)
+			logger.Warn("Updater: Not keeping the download for resuming: %v", err)
+		} else {
+			defer partial.close()
+			offset, size, err := partial.resume(downloadLocation, hex.EncodeToString(update.hash[:]), io.MultiWriter(file, hasher))
+			if err != nil {
+				logger.Warn("Updater: Failed to resume the interrupted download: %v", err)
+				partial.remove()
+				partial = nil
+				hasher.Reset()
+			} else if offset > 0 {
+				logger.Info("Updater: Resuming an interrupted download at %d bytes", offset)
+				dp.BytesDownloaded, dp.BytesTotal = offset, size
+				progress <- dp
+			}
+		}
+
+		logger.Info("Updater: Starting download (max 100 MiB, %d attempts)", maxDownloadAttempts)
+		download := &partialDownload{
+			source:   connectionSource{downloadConnection},
+			path:     downloadPath,
+			file:     file,
+			hasher:   hasher,
+			dp:       &dp,
+			progress: progress,
+			partial:  partial,
+		}
+		if err := download.download(); err != nil {
+			logger.Error("Updater: Download failed: %v (bytes written: %d)", err, dp.BytesDownloaded)
 			progress <- DownloadProgress{Error: err}
 			return
 		}
-		logger.Info("Updater: Download completed: %d bytes written", bytesWritten)
+		logger.Info("Updater: Download completed: %d bytes written", dp.BytesDownloaded)
+
+		// The kept copy is only needed to resume, whether or not the file verifies
+		if partial != nil {
+			if err := partial.remove(); err != nil {
+				logger.Warn("Updater: Failed to remove the kept download: %v", err)
+			}
+		}
 
 		calculatedHash := hasher.Sum(nil)
 		logger.Info("Updater: Verifying hash - calculated: %x, expected: %x", calculatedHash, update.hash)
 		if !hmac.Equal(calculatedHash, update.hash[:]) {
 			logger.Error("Updater: Hash verification failed!")
-			progress <- DownloadProgress{Error: errors.New("The downloaded update has the wrong hash")}
+			hashErr := errors.New("The downloaded update has the wrong hash")
+			progress <- DownloadProgress{Error: hashErr, Verification: &VerificationResult{Reason: hashErr.Error()}}
 			return
 		}
 		logger.Info("Updater: Hash verification passed")
 
-		// // Skip authenticode verification in development mode
-		// devMode := os.Getenv("PANGOLIN_ALLOW_DEV_UPDATES") == "1"
-		// if !devMode {
-		// 	logger.Info("Updater: Verifying Authenticode signature")
-		// 	progress <- DownloadProgress{Activity: "Verifying authenticode signature"}
-		// 	if !verifyAuthenticode(file.ExclusivePath()) {
-		// 		logger.Error("Updater: Authenticode verification failed")
-		// 		progress <- DownloadProgress{Error: errors.New("The downloaded update does not have an authentic authenticode signature")}
-		// 		return
-		// 	}
-		// 	logger.Info("Updater: Authenticode verification passed")
-		// } else {
-		// 	logger.Info("Updater: Skipping Authenticode verification (dev mode)")
-		// }
+		// Check who signed the installer; only development mode may install an unsigned one
+		progress <- DownloadProgress{Activity: "Verifying signature"}
+		verification := verifyUpdateFile(file.ExclusivePath())
+		if verification.Verified {
+			logger.Info("Updater: Signature verified - signer: %s, thumbprint: %s", verification.Signer, verification.Thumbprint)
+		} else if _, devMode := version.UpdatesAllowed(); devMode {
+			logger.Warn("Updater: Signature verification failed, installing anyway (dev mode): %s", verification.Reason)
+		} else {
+			logger.Error("Updater: Signature verification failed: %s", verification.Reason)
+			progress <- DownloadProgress{Error: errors.New("The downloaded update does not have an authentic authenticode signature"), Verification: &verification}
+			return
+		}
+		progress <- DownloadProgress{Activity: "Verified update", Verification: &verification}
+
+		// Last chance to cancel; once msiexec starts the update can't be aborted
+		if atomic.LoadUint32(&updateCanceled) != 0 {
+			logger.Info("Updater: Update canceled before installation")
+			progress <- DownloadProgress{Error: ErrUpdateCanceled}
+			return
+		}
 
 		logger.Info("Updater: Starting MSI installation")
 		progress <- DownloadProgress{Activity: "Installing update"}
 
-		restartUIFlagPath := filepath.Join(config.GetProgramDataDir(), "restart-ui-after-update.flag")
-		if err := os.MkdirAll(config.GetProgramDataDir(), 0o755); err != nil {
-			logger.Error("Updater: Failed to create ProgramData dir for restart flag: %v", err)
-		} else if err := os.WriteFile(restartUIFlagPath, nil, 0o644); err != nil {
+		err = installMsi(file, userToken)
+		if err != nil {
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+		// Flag file left in place so next start can start the UI automatically, then delete the file
+		logger.Info("Updater: MSI installation completed successfully")
+
+		logger.Info("Updater: Update process complete")
+		progress <- DownloadProgress{Complete: true, Verification: &verification}
+	}
+	runUpdateJob(userToken, progress, doIt)
+	return progress
+}
+
+// installMsi runs a verified installer, leaving a flag behind so the UI is
+// restarted once the new version's manager starts
+func installMsi(file *tempFile, userToken uintptr) error {
+	var restartUIFlagPath string
+	if programDataDir, err := config.ResolveProgramDataDir(); err != nil {
+		logger.Error("Updater: Not writing the restart-ui flag: %v", err)
+	} else if err := os.MkdirAll(programDataDir, 0o755); err != nil {
+		logger.Error("Updater: Failed to create ProgramData dir for restart flag: %v", err)
+	} else {
+		restartUIFlagPath = filepath.Join(programDataDir, "restart-ui-after-update.flag")
+		if err := os.WriteFile(restartUIFlagPath, nil, 0o644); err != nil {
 			logger.Error("Updater: Failed to write restart-ui flag file: %v", err)
 		} else {
 			logger.Info("Updater: Wrote restart-ui flag at %s", restartUIFlagPath)
 		}
+	}
 
-		err = runMsi(file, userToken)
-		if err != nil {
-			logger.Error("Updater: MSI installation failed: %v", err)
+	err := runMsi(file, userToken)
+	if err != nil {
+		logger.Error("Updater: MSI installation failed: %v", err)
+		if restartUIFlagPath != "" {
 			if removeErr := os.Remove(restartUIFlagPath); removeErr != nil && !os.IsNotExist(removeErr) {
 				logger.Error("Updater: Failed to remove restart-ui flag after MSI failure: %v", removeErr)
 			}
-			progress <- DownloadProgress{Error: err}
-			return
 		}
-		// Flag file left in place so next start can start the UI automatically, then delete the file
-		logger.Info("Updater: MSI installation completed successfully")
-
-		logger.Info("Updater: Update process complete")
-		progress <- DownloadProgress{Complete: true}
+		return err
 	}
+	return nil
+}
+
+// runUpdateJob runs doIt in the background, as SYSTEM if no user token was given
+func runUpdateJob(userToken uintptr, progress chan DownloadProgress, doIt func()) {
 	if userToken == 0 {
 		logger.Info("Updater: No user token provided, attempting to run as SYSTEM")
 
@@ -416,7 +502,7 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 			if !isElevated {
 				logger.Error("Updater: Process is not running with admin privileges")
 				progress <- DownloadProgress{Error: errors.New("update requires administrator privileges. Please run the application as administrator")}
-				return progress
+				return
 			}
 			logger.Info("Updater: Process is running with admin privileges")
 		}
@@ -436,8 +522,6 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 		logger.Info("Updater: Using provided user token: %v", userToken)
 		go doIt()
 	}
-
-	return progress
 }
 
 // UpdateFoundCallback is a function type that gets called when an update is found
@@ -0,0 +1,49 @@
+//go:build windows
+
+package updater
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestCancelUpdate(t *testing.T) {
+	defer atomic.StoreUint32(&updateInProgress, 0)
+	defer atomic.StoreUint32(&updateCanceled, 0)
+
+	if CancelUpdate() {
+		t.Error("CancelUpdate() = true with no update in progress")
+	}
+	if atomic.LoadUint32(&updateCanceled) != 0 {
+		t.Error("canceled with no update in progress")
+	}
+
+	atomic.StoreUint32(&updateInProgress, 1)
+	hasher, err := blake2b.New256(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := make(chan DownloadProgress, 1)
+	watcher := &progressHashWatcher{dp: &DownloadProgress{BytesTotal: 100}, c: progress, hashState: hasher}
+	if _, err := watcher.Write(make([]byte, 10)); err != nil {
+		t.Fatalf("Write() before canceling: %v", err)
+	}
+	<-progress
+
+	if !CancelUpdate() {
+		t.Fatal("CancelUpdate() = false with an update in progress")
+	}
+	n, err := watcher.Write(make([]byte, 10))
+	if n != 0 || !errors.Is(err, ErrUpdateCanceled) {
+		t.Errorf("Write() after canceling = %d, %v, want 0, ErrUpdateCanceled", n, err)
+	}
+	if watcher.dp.BytesDownloaded != 10 {
+		t.Errorf("BytesDownloaded = %d, want 10", watcher.dp.BytesDownloaded)
+	}
+	if len(progress) != 0 {
+		t.Error("progress reported after canceling")
+	}
+}
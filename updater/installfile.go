@@ -0,0 +1,142 @@
+//go:build windows
+
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fosrl/newt/logger"
+	"golang.org/x/sys/windows"
+)
+
+// maxInstallerFileSize matches the download limit of the online updater
+const maxInstallerFileSize = 1024 * 1024 * 100 /* 100 MiB */
+
+// installerVerifier checks the signature of a copied installer. Revocation can't be
+// checked on machines without network access, so local files skip it.
+var installerVerifier = func(path string) VerificationResult {
+	return verifySignedFile(path, windows.WTD_REVOKE_NONE)
+}
+
+// checkInstallerVerification decides whether a local installer may be run. Without a
+// signed manifest to compare a hash against, the Authenticode signature is all there
+// is, so it must be trusted and made with one of officialSignerKeys. The signer's
+// name isn't enough, as any certificate authority may issue a certificate with it.
+func checkInstallerVerification(v VerificationResult) error {
+	if !v.Verified {
+		return fmt.Errorf("The installer does not have a valid signature: %s", v.Reason)
+	}
+	if !isOfficialSignerKey(v.SignerKey) {
+		return fmt.Errorf("The installer is signed by %q, which is not the official %s signing key", v.Signer, officialSignerName)
+	}
+	return nil
+}
+
+// isOfficialSignerKey reports whether key is one of officialSignerKeys
+func isOfficialSignerKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, official := range strings.Split(officialSignerKeys, ",") {
+		if strings.EqualFold(strings.TrimSpace(official), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// InstallFromFile verifies and installs an update from a local MSI, for machines
+// that can't reach the update server. Progress is reported like DownloadVerifyAndExecute.
+func InstallFromFile(path string, userToken uintptr) (progress chan DownloadProgress) {
+	progress = make(chan DownloadProgress, 128)
+	progress <- DownloadProgress{Activity: "Initializing"}
+
+	if !strings.EqualFold(filepath.Ext(path), msiSuffix) {
+		progress <- DownloadProgress{Error: errors.New("Only .msi installers can be installed")}
+		return
+	}
+
+	if !atomic.CompareAndSwapUint32(&updateInProgress, 0, 1) {
+		progress <- DownloadProgress{Error: errors.New("An update is already in progress")}
+		return
+	}
+
+	atomic.StoreUint32(&updateCanceled, 0)
+
+	doIt := func() {
+		defer atomic.StoreUint32(&updateInProgress, 0)
+		logger.Info("Updater: InstallFromFile started: %s (userToken=%v)", path, userToken != 0)
+
+		source, err := os.Open(path)
+		if err != nil {
+			logger.Error("Updater: Failed to open installer: %v", err)
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+		defer source.Close()
+
+		// Verify and run a private copy so the file can't be swapped after it was checked
+		progress <- DownloadProgress{Activity: "Creating temporary file"}
+		file, err := msiTempFile()
+		if err != nil {
+			logger.Error("Updater: Failed to create temporary file: %v", err)
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+		defer func() {
+			logger.Info("Updater: Cleaning up temporary file: %s", file.Name())
+			file.Delete()
+		}()
+
+		progress <- DownloadProgress{Activity: "Copying installer"}
+		bytesWritten, err := io.Copy(file, io.LimitReader(source, maxInstallerFileSize+1))
+		if err != nil {
+			logger.Error("Updater: Copying installer failed: %v (bytes written: %d)", err, bytesWritten)
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+		if bytesWritten > maxInstallerFileSize {
+			logger.Error("Updater: Installer is larger than %d bytes", maxInstallerFileSize)
+			progress <- DownloadProgress{Error: errors.New("The installer is too large")}
+			return
+		}
+		logger.Info("Updater: Copied installer: %d bytes", bytesWritten)
+
+		progress <- DownloadProgress{Activity: "Verifying signature"}
+		verification := installerVerifier(file.ExclusivePath())
+		if err := checkInstallerVerification(verification); err != nil {
+			logger.Error("Updater: Refusing installer %s: %v", path, err)
+			if verification.Reason == "" {
+				verification.Reason = err.Error()
+			}
+			progress <- DownloadProgress{Error: err, Verification: &verification}
+			return
+		}
+		logger.Info("Updater: Signature verified - signer: %s, thumbprint: %s", verification.Signer, verification.Thumbprint)
+		progress <- DownloadProgress{Activity: "Verified update", Verification: &verification}
+
+		if atomic.LoadUint32(&updateCanceled) != 0 {
+			logger.Info("Updater: Update canceled before installation")
+			progress <- DownloadProgress{Error: ErrUpdateCanceled}
+			return
+		}
+
+		logger.Info("Updater: Starting MSI installation")
+		progress <- DownloadProgress{Activity: "Installing update"}
+		if err := installMsi(file, userToken); err != nil {
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+
+		logger.Info("Updater: Installation from file complete")
+		progress <- DownloadProgress{Complete: true, Verification: &verification}
+	}
+	runUpdateJob(userToken, progress, doIt)
+	return progress
+}
@@ -0,0 +1,177 @@
+//go:build windows
+
+package updater
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCert creates a code signing certificate for cn with a fresh key, issued
+// by parent, or self-signed if parent is nil
+func newTestCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func setOfficialSignerKeys(t *testing.T, keys string) {
+	t.Helper()
+	old := officialSignerKeys
+	officialSignerKeys = keys
+	t.Cleanup(func() { officialSignerKeys = old })
+}
+
+func TestVerificationFromSigner(t *testing.T) {
+	cert, _ := newTestCert(t, "Fossorial", nil, nil)
+
+	v := verificationFromSigner(cert, nil)
+	if !v.Verified || v.Reason != "" {
+		t.Errorf("verification = %+v, want verified", v)
+	}
+	if v.Signer != "Fossorial" {
+		t.Errorf("Signer = %q, want Fossorial", v.Signer)
+	}
+	if v.Thumbprint != certificateThumbprint(cert) || len(v.Thumbprint) != 40 || strings.ToUpper(v.Thumbprint) != v.Thumbprint {
+		t.Errorf("Thumbprint = %q, want the upper case SHA-1 of the certificate", v.Thumbprint)
+	}
+	if v.SignerKey != publicKeyHash(cert) || len(v.SignerKey) != 64 {
+		t.Errorf("SignerKey = %q, want the SHA-256 of the public key", v.SignerKey)
+	}
+
+	v = verificationFromSigner(nil, errors.New("The signature is not trusted: bad digest"))
+	if v.Verified || v.Signer != "" || v.SignerKey != "" || v.Reason != "The signature is not trusted: bad digest" {
+		t.Errorf("verification of an untrusted file = %+v", v)
+	}
+}
+
+func TestPublicKeyHashSurvivesRenewal(t *testing.T) {
+	ca, caKey := newTestCert(t, "Test CA", nil, nil)
+	cert, key := newTestCert(t, "Fossorial", ca, caKey)
+
+	// Renew with the same key
+	template := *cert
+	template.SerialNumber = big.NewInt(cert.SerialNumber.Int64() + 1)
+	template.NotAfter = cert.NotAfter.Add(365 * 24 * time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, &template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if publicKeyHash(renewed) != publicKeyHash(cert) {
+		t.Error("the key hash changed when the certificate was renewed with the same key")
+	}
+	if certificateThumbprint(renewed) == certificateThumbprint(cert) {
+		t.Error("the renewed certificate has the same thumbprint")
+	}
+}
+
+func TestCheckInstallerVerification(t *testing.T) {
+	ca, caKey := newTestCert(t, "Test CA", nil, nil)
+	official, _ := newTestCert(t, "Fossorial", ca, caKey)
+	foreign, _ := newTestCert(t, "Other Corp", ca, caKey)
+	// Another key issued under the official name, as any authority could
+	impostor, _ := newTestCert(t, "Fossorial", ca, caKey)
+	setOfficialSignerKeys(t, publicKeyHash(official))
+
+	tests := []struct {
+		name    string
+		v       VerificationResult
+		wantErr string // Part of the error expected, empty for none
+	}{
+		{name: "official signer", v: verificationFromSigner(official, nil)},
+		{
+			// The fake certificate in the signature's store is never looked at:
+			// the signer is the one WinVerifyTrust checked the signature with
+			name:    "foreign signer with a fake Fossorial certificate attached",
+			v:       verificationFromSigner(foreign, nil),
+			wantErr: `signed by "Other Corp"`,
+		},
+		{name: "official name with another key", v: verificationFromSigner(impostor, nil), wantErr: "not the official"},
+		{name: "official name without a key", v: VerificationResult{Verified: true, Signer: "Fossorial"}, wantErr: "not the official"},
+		{name: "untrusted", v: verificationFromSigner(nil, errors.New("The signature is not trusted: expired")), wantErr: "does not have a valid signature: The signature is not trusted: expired"},
+		{
+			name:    "untrusted with the official key",
+			v:       VerificationResult{Signer: "Fossorial", SignerKey: publicKeyHash(official), Reason: "revoked"},
+			wantErr: "does not have a valid signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkInstallerVerification(tt.v)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkInstallerVerification: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkInstallerVerification error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsOfficialSignerKey(t *testing.T) {
+	const a = "aa00000000000000000000000000000000000000000000000000000000000000"
+	const b = "bb00000000000000000000000000000000000000000000000000000000000000"
+	tests := []struct {
+		keys string
+		key  string
+		want bool
+	}{
+		{"", a, false},
+		{"", "", false},
+		{a, "", false},
+		{a, a, true},
+		{a, strings.ToUpper(a), true},
+		{a, b, false},
+		{a + "," + b, b, true},
+		{" " + a + " , " + b + " ", a, true},
+		{a + ",", "", false},
+	}
+
+	for _, tt := range tests {
+		setOfficialSignerKeys(t, tt.keys)
+		if got := isOfficialSignerKey(tt.key); got != tt.want {
+			t.Errorf("officialSignerKeys %q: isOfficialSignerKey(%q) = %v, want %v", tt.keys, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+//go:build windows
+
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
+)
+
+const (
+	// partialDirName is the directory in ProgramData an interrupted download is kept in
+	partialDirName = "updates"
+	// partialFileName holds the bytes of the interrupted download
+	partialFileName = "installer.partial"
+	// partialSidecarName describes the download partialFileName belongs to
+	partialSidecarName = partialFileName + ".json"
+)
+
+// partialSidecar describes the download a .partial file belongs to, so its
+// bytes are only resumed for the same installer
+type partialSidecar struct {
+	URL  string `json:"url"`
+	Size uint64 `json:"size"` // The installer's size, 0 if the server hasn't said
+	Hash string `json:"hash"` // The manifest's BLAKE2b-256 of the installer, hex encoded
+}
+
+// resumeOffset returns how many bytes of a .partial file of fileSize bytes,
+// described by sidecar, can be kept when downloading url with hash. It is 0
+// when the file belongs to another download or can't be the start of this one.
+func resumeOffset(sidecar partialSidecar, url, hash string, fileSize uint64) uint64 {
+	if sidecar.URL != url || sidecar.Hash != hash {
+		return 0
+	}
+	if fileSize > maxDownloadSize {
+		return 0
+	}
+	// A whole file that is still here failed verification
+	if sidecar.Size != 0 && fileSize >= sidecar.Size {
+		return 0
+	}
+	return fileSize
+}
+
+// partialStore keeps a copy of an installer download as it arrives, so an
+// update that is interrupted or canceled continues where it stopped next time.
+// The installer itself is still downloaded to, and verified in, a fresh
+// temporary file.
+type partialStore struct {
+	dir     string
+	file    *os.File
+	sidecar partialSidecar
+}
+
+// openPartialStore opens the store in ProgramData
+func openPartialStore() (*partialStore, error) {
+	root, err := config.ResolveProgramDataDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(root, partialDirName)
+	if err := createProtectedDir(dir); err != nil {
+		return nil, err
+	}
+	return newPartialStore(dir)
+}
+
+// createProtectedDir creates dir for SYSTEM and administrators only. An
+// existing one owned by anyone else is refused, as it could hold links
+// planted for SYSTEM to write through.
+func createProtectedDir(dir string) error {
+	sd, err := windows.SecurityDescriptorFromString("D:PAI(A;OICI;FA;;;SY)(A;OICI;FA;;;BA)")
+	if err != nil {
+		return err
+	}
+	sa := &windows.SecurityAttributes{
+		Length:             uint32(unsafe.Sizeof(windows.SecurityAttributes{})),
+		SecurityDescriptor: sd,
+	}
+	err = windows.CreateDirectory(windows.StringToUTF16Ptr(dir), sa)
+	runtime.KeepAlive(sd)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, windows.ERROR_ALREADY_EXISTS) {
+		return err
+	}
+
+	existing, err := windows.GetNamedSecurityInfo(dir, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return err
+	}
+	owner, _, err := existing.Owner()
+	if err != nil {
+		return err
+	}
+	if !owner.IsWellKnown(windows.WinLocalSystemSid) && !owner.IsWellKnown(windows.WinBuiltinAdministratorsSid) {
+		return fmt.Errorf("%s is owned by %s", dir, owner)
+	}
+	return nil
+}
+
+// newPartialStore opens the store kept in dir
+func newPartialStore(dir string) (*partialStore, error) {
+	file, err := os.OpenFile(filepath.Join(dir, partialFileName), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &partialStore{dir: dir, file: file}, nil
+}
+
+// resume checks the kept bytes against the download of url with hash and
+// copies the usable ones to dst, returning how many there are and the
+// installer's size if it is known. Bytes of another download are dropped.
+func (s *partialStore) resume(url, hash string, dst io.Writer) (offset, size uint64, err error) {
+	var kept partialSidecar
+	data, err := os.ReadFile(filepath.Join(s.dir, partialSidecarName))
+	if err == nil {
+		err = json.Unmarshal(data, &kept)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("Updater: Ignoring the interrupted download: %v", err)
+	}
+	if err == nil {
+		info, err := s.file.Stat()
+		if err != nil {
+			return 0, 0, err
+		}
+		offset = resumeOffset(kept, url, hash, uint64(info.Size()))
+	}
+
+	s.sidecar = partialSidecar{URL: url, Hash: hash}
+	if offset > 0 {
+		s.sidecar.Size = kept.Size
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return 0, 0, err
+		}
+		if _, err := io.CopyN(dst, s.file, int64(offset)); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := s.rewind(offset); err != nil {
+		return 0, 0, err
+	}
+	return offset, s.sidecar.Size, s.writeSidecar()
+}
+
+// Write appends downloaded bytes to the kept copy
+func (s *partialStore) Write(p []byte) (int, error) {
+	return s.file.Write(p)
+}
+
+// rewind cuts the kept copy back to size bytes
+func (s *partialStore) rewind(size uint64) error {
+	if err := s.file.Truncate(int64(size)); err != nil {
+		return err
+	}
+	_, err := s.file.Seek(int64(size), io.SeekStart)
+	return err
+}
+
+// setSize records the installer's size in the sidecar
+func (s *partialStore) setSize(size uint64) error {
+	if s.sidecar.Size == size {
+		return nil
+	}
+	s.sidecar.Size = size
+	return s.writeSidecar()
+}
+
+func (s *partialStore) writeSidecar() error {
+	data, err := json.Marshal(s.sidecar)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.dir, partialSidecarName), data, 0o600)
+}
+
+// close keeps the download for the next update
+func (s *partialStore) close() error {
+	return s.file.Close()
+}
+
+// remove drops the download once it is no longer needed
+func (s *partialStore) remove() error {
+	s.file.Close()
+	err := os.Remove(filepath.Join(s.dir, partialFileName))
+	if sidecarErr := os.Remove(filepath.Join(s.dir, partialSidecarName)); sidecarErr != nil && !errors.Is(sidecarErr, fs.ErrNotExist) {
+		err = errors.Join(err, sidecarErr)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+//go:build windows
+
+package updater
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/updater/winhttp"
+	"github.com/fosrl/windows/version"
+)
+
+var (
+	proxySettings     config.ProxySettings
+	proxySettingsLock sync.RWMutex
+)
+
+// SetProxy selects the proxy used by subsequent update checks and downloads.
+// Without an explicit proxy, HTTPS_PROXY and NO_PROXY decide, and failing those
+// the system proxy settings.
+func SetProxy(settings config.ProxySettings) error {
+	if _, err := settings.ProxyFunc(); err != nil {
+		return err
+	}
+	proxySettingsLock.Lock()
+	proxySettings = settings
+	proxySettingsLock.Unlock()
+	return nil
+}
+
+// newSession opens a WinHTTP session for requests to host, through the proxy
+// the settings pick for it
+func newSession(host string, https bool) (*winhttp.Session, error) {
+	proxySettingsLock.RLock()
+	settings := proxySettings
+	proxySettingsLock.RUnlock()
+
+	proxyFunc, err := settings.ProxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	scheme := "http"
+	if https {
+		scheme = "https"
+	}
+	proxyURL, err := proxyFunc(&url.URL{Scheme: scheme, Host: host})
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return winhttp.NewSession(version.UserAgent())
+	}
+	logger.Info("Updater: Using proxy %s for %s", proxyURL.Host, host)
+	password, _ := proxyURL.User.Password()
+	return winhttp.NewProxySession(version.UserAgent(), proxyURL.Host, proxyURL.User.Username(), password)
+}
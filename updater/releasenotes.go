@@ -0,0 +1,62 @@
+//go:build windows
+
+package updater
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
+	"github.com/fosrl/windows/updater/winhttp"
+)
+
+// maxReleaseNotesSize caps how much of a release notes file is read
+const maxReleaseNotesSize = 64 * 1024
+
+// fetchReleaseNotes downloads the release notes for a version. Release notes are
+// informational only, so any failure just yields empty notes.
+func fetchReleaseNotes(connection *winhttp.Connection, channel config.UpdateChannel, candidateVersion string) string {
+	pathFormat := releaseNotesPath
+	if channel == config.UpdateChannelBeta {
+		pathFormat = betaReleaseNotesPath
+	}
+	path := fmt.Sprintf(pathFormat, candidateVersion)
+
+	logger.Info("Updater: Fetching release notes from: %s", path)
+	response, err := connection.Get(path, true)
+	if err != nil {
+		logger.Warn("Updater: Failed to fetch release notes: %v", err)
+		return ""
+	}
+	defer response.Close()
+
+	if status, err := response.StatusCode(); err != nil || status != 200 {
+		logger.Warn("Updater: Release notes not available (status %d, err %v)", status, err)
+		return ""
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(response, maxReleaseNotesSize))
+	if err != nil {
+		logger.Warn("Updater: Failed to read release notes: %v", err)
+		return ""
+	}
+	return parseReleaseNotes(raw)
+}
+
+// parseReleaseNotes turns a raw release notes file into display text: it drops a
+// UTF-8 BOM, rejects non-UTF-8 content, normalizes line endings to CRLF and trims
+// surrounding blank space.
+func parseReleaseNotes(raw []byte) string {
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(raw) {
+		logger.Warn("Updater: Release notes are not valid UTF-8, ignoring")
+		return ""
+	}
+	notes := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	notes = strings.TrimSpace(notes)
+	return strings.ReplaceAll(notes, "\n", "\r\n")
+}
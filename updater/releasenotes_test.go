@@ -0,0 +1,32 @@
+//go:build windows
+
+package updater
+
+import "testing"
+
+func TestParseReleaseNotes(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "empty", raw: "", want: ""},
+		{name: "blank", raw: " \n\r\n\t", want: ""},
+		{name: "single line", raw: "Fixes reconnecting after sleep", want: "Fixes reconnecting after sleep"},
+		{name: "LF endings", raw: "## 1.2.0\n- Faster startup\n- Fewer crashes\n", want: "## 1.2.0\r\n- Faster startup\r\n- Fewer crashes"},
+		{name: "CRLF endings", raw: "## 1.2.0\r\n- Faster startup\r\n", want: "## 1.2.0\r\n- Faster startup"},
+		{name: "mixed endings", raw: "a\r\nb\nc", want: "a\r\nb\r\nc"},
+		{name: "BOM", raw: "\xef\xbb\xbfRelease notes", want: "Release notes"},
+		{name: "surrounding space", raw: "\n\n  Notes  \n\n", want: "Notes"},
+		{name: "non-ASCII", raw: "Überarbeitete Oberfläche ✓", want: "Überarbeitete Oberfläche ✓"},
+		{name: "not UTF-8", raw: "Release \xff\xfe notes", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseReleaseNotes([]byte(tt.raw)); got != tt.want {
+				t.Errorf("parseReleaseNotes(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
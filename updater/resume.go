@@ -0,0 +1,283 @@
+//go:build windows
+
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/updater/winhttp"
+)
+
+const (
+	// maxDownloadSize is the largest installer the updater will download
+	maxDownloadSize = 1024 * 1024 * 100 /* 100 MiB */
+	// maxDownloadAttempts caps the requests made for one download, including the first
+	maxDownloadAttempts = 5
+	// downloadRetryDelay is the wait before the first retry; each later retry waits one step longer
+	downloadRetryDelay = 2 * time.Second
+)
+
+// errShortDownload means the server closed the connection before sending the whole file
+var errShortDownload = errors.New("the download ended early")
+
+// httpStatusError is an unexpected HTTP status for an installer request
+type httpStatusError uint64
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("the update server answered with HTTP status %d", uint64(e))
+}
+
+// retryableDownloadError reports whether a failed download attempt is worth resuming.
+// Cancellation and client errors such as 404 won't go away by asking again.
+func retryableDownloadError(err error) bool {
+	if errors.Is(err, ErrUpdateCanceled) {
+		return false
+	}
+	var status httpStatusError
+	if errors.As(err, &status) {
+		return status >= 500
+	}
+	return true
+}
+
+// errStalePartial means the installer changed on the server since the kept
+// bytes were downloaded, so the download starts over
+var errStalePartial = errors.New("the update file changed since the download was interrupted")
+
+// downloadResponse is the part of a WinHTTP response a download reads
+type downloadResponse interface {
+	io.ReadCloser
+	StatusCode() (uint64, error)
+	Length() (uint64, error)
+	ContentRange() (string, error)
+}
+
+// downloadSource requests the installer, whole or from an offset
+type downloadSource interface {
+	get(path string) (downloadResponse, error)
+	getRange(path string, offset uint64) (downloadResponse, error)
+}
+
+// connectionSource requests the installer over a WinHTTP connection
+type connectionSource struct {
+	connection *winhttp.Connection
+}
+
+func (s connectionSource) get(path string) (downloadResponse, error) {
+	response, err := s.connection.Get(path, false)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (s connectionSource) getRange(path string, offset uint64) (downloadResponse, error) {
+	response, err := s.connection.GetRange(path, offset)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// downloadFile is the file the installer is downloaded into
+type downloadFile interface {
+	io.WriteSeeker
+	Truncate(size int64) error
+}
+
+// partialDownload is the state of an installer download that is kept across
+// attempts: the temporary file, the running hash of what it holds and the
+// expected size, if the server sent one
+type partialDownload struct {
+	source   downloadSource
+	path     string
+	file     downloadFile
+	hasher   hash.Hash
+	dp       *DownloadProgress
+	progress chan DownloadProgress
+	// partial keeps a copy of the bytes for a later update to resume from,
+	// nil if they aren't kept
+	partial *partialStore
+}
+
+// download fetches the installer into the file, resuming with a Range request
+// after an interrupted attempt. The hash covers exactly the bytes in the file,
+// so it is only complete, and only checked by the caller, once the whole file is in.
+func (d *partialDownload) download() error {
+	for attempt := 1; ; attempt++ {
+		err := d.attempt()
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxDownloadAttempts || !retryableDownloadError(err) {
+			return err
+		}
+
+		logger.Warn("Updater: Download attempt %d of %d failed at %d bytes: %v", attempt, maxDownloadAttempts, d.dp.BytesDownloaded, err)
+		d.dp.Activity = fmt.Sprintf("Download interrupted, retrying (attempt %d of %d)", attempt+1, maxDownloadAttempts)
+		d.progress <- *d.dp
+
+		deadline := time.Now().Add(time.Duration(attempt) * downloadRetryDelay)
+		for time.Now().Before(deadline) {
+			if atomic.LoadUint32(&updateCanceled) != 0 {
+				return ErrUpdateCanceled
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		d.dp.Activity = "Downloading update"
+	}
+}
+
+// attempt makes one request, continuing from the bytes already in the file
+func (d *partialDownload) attempt() error {
+	// A failed write can leave bytes in the file that never reached the hash
+	offset := d.dp.BytesDownloaded
+	if err := d.rewind(offset); err != nil {
+		return err
+	}
+
+	var response downloadResponse
+	var err error
+	if offset == 0 {
+		response, err = d.source.get(d.path)
+	} else {
+		logger.Info("Updater: Resuming download at %d bytes", offset)
+		response, err = d.source.getRange(d.path, offset)
+	}
+	if err != nil {
+		return err
+	}
+	defer response.Close()
+
+	status, err := response.StatusCode()
+	if err != nil {
+		return err
+	}
+	switch {
+	case status == 206 && offset > 0:
+		start, total, err := parseContentRange(response)
+		if err != nil {
+			return err
+		}
+		if start != offset {
+			return fmt.Errorf("the update server resumed at byte %d instead of %d", start, offset)
+		}
+		if total != 0 && d.dp.BytesTotal != 0 && total != d.dp.BytesTotal {
+			logger.Warn("Updater: The update is now %d bytes instead of %d, downloading again from the start", total, d.dp.BytesTotal)
+			if err := d.startOver(); err != nil {
+				return err
+			}
+			d.dp.BytesTotal = 0
+			return errStalePartial
+		}
+		if total != 0 {
+			d.setTotal(total)
+		}
+	case status == 200:
+		if offset > 0 {
+			// The server ignored the Range header and is sending the whole file again
+			logger.Warn("Updater: Server does not support resuming, downloading again from the start")
+			if err := d.startOver(); err != nil {
+				return err
+			}
+			offset = 0
+		}
+		if length, err := response.Length(); err == nil {
+			logger.Info("Updater: MSI file size: %d bytes", length)
+			d.setTotal(length)
+		} else {
+			logger.Warn("Updater: Could not determine MSI file size: %v", err)
+		}
+	default:
+		return httpStatusError(status)
+	}
+	d.progress <- *d.dp
+
+	pm := &progressHashWatcher{d.dp, d.progress, d.hasher}
+	destination := io.MultiWriter(d.file, pm)
+	if d.partial != nil {
+		destination = io.MultiWriter(d.file, d.partial, pm)
+	}
+	bytesWritten, err := io.Copy(destination, io.LimitReader(response, int64(maxDownloadSize-offset)))
+	if err != nil {
+		return err
+	}
+	logger.Info("Updater: Received %d bytes, %d in total", bytesWritten, d.dp.BytesDownloaded)
+	if d.dp.BytesTotal != 0 && d.dp.BytesDownloaded < d.dp.BytesTotal && d.dp.BytesDownloaded < maxDownloadSize {
+		return errShortDownload
+	}
+	return nil
+}
+
+// rewind cuts the file, and the kept copy, back to size bytes and moves the
+// write position to its end
+func (d *partialDownload) rewind(size uint64) error {
+	if err := d.file.Truncate(int64(size)); err != nil {
+		return err
+	}
+	if _, err := d.file.Seek(int64(size), io.SeekStart); err != nil {
+		return err
+	}
+	if d.partial != nil {
+		return d.partial.rewind(size)
+	}
+	return nil
+}
+
+// startOver drops everything downloaded so far
+func (d *partialDownload) startOver() error {
+	if err := d.rewind(0); err != nil {
+		return err
+	}
+	d.hasher.Reset()
+	d.dp.BytesDownloaded = 0
+	return nil
+}
+
+// setTotal records the installer's size, also for a later update resuming the kept bytes
+func (d *partialDownload) setTotal(total uint64) {
+	d.dp.BytesTotal = total
+	if d.partial != nil {
+		if err := d.partial.setSize(total); err != nil {
+			logger.Warn("Updater: Failed to record the update's size for resuming: %v", err)
+		}
+	}
+}
+
+// parseContentRange reads the start and total size from a Content-Range header
+// such as "bytes 100-999/1000". The total is 0 if the server sent "*".
+func parseContentRange(response downloadResponse) (start, total uint64, err error) {
+	value, err := response.ContentRange()
+	if err != nil {
+		return 0, 0, err
+	}
+	spec, ok := strings.CutPrefix(strings.TrimSpace(value), "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q", value)
+	}
+	span, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q", value)
+	}
+	first, _, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q", value)
+	}
+	if start, err = strconv.ParseUint(first, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q", value)
+	}
+	if size != "*" {
+		if total, err = strconv.ParseUint(size, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("malformed Content-Range %q", value)
+		}
+	}
+	return start, total, nil
+}
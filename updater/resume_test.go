@@ -0,0 +1,305 @@
+//go:build windows
+
+package updater
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// fakeResponse is a canned answer to an installer request
+type fakeResponse struct {
+	*strings.Reader
+	status       uint64
+	length       uint64
+	contentRange string
+}
+
+func (r *fakeResponse) Close() error                  { return nil }
+func (r *fakeResponse) StatusCode() (uint64, error)   { return r.status, nil }
+func (r *fakeResponse) Length() (uint64, error)       { return r.length, nil }
+func (r *fakeResponse) ContentRange() (string, error) { return r.contentRange, nil }
+
+// fakeSource serves installer, answering Range requests with 206 only if
+// honorRange is set, and records the offsets asked for, -1 for a request
+// without a range
+type fakeSource struct {
+	installer  string
+	honorRange bool
+	total      uint64 // The size a 206 reports, the installer's if 0
+	offsets    []int64
+}
+
+func (s *fakeSource) get(path string) (downloadResponse, error) {
+	s.offsets = append(s.offsets, -1)
+	return &fakeResponse{Reader: strings.NewReader(s.installer), status: 200, length: uint64(len(s.installer))}, nil
+}
+
+func (s *fakeSource) getRange(path string, offset uint64) (downloadResponse, error) {
+	s.offsets = append(s.offsets, int64(offset))
+	if !s.honorRange {
+		return &fakeResponse{Reader: strings.NewReader(s.installer), status: 200, length: uint64(len(s.installer))}, nil
+	}
+	total := s.total
+	if total == 0 {
+		total = uint64(len(s.installer))
+	}
+	return &fakeResponse{
+		Reader:       strings.NewReader(s.installer[offset:]),
+		status:       206,
+		contentRange: fmt.Sprintf("bytes %d-%d/%d", offset, len(s.installer)-1, total),
+	}, nil
+}
+
+// newTestDownload returns a download of source's installer into a fresh file
+// that already holds its first kept bytes
+func newTestDownload(t *testing.T, source *fakeSource, kept int) (*partialDownload, *os.File) {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "installer.msi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	hasher, err := blake2b.New256(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.MultiWriter(file, hasher).Write([]byte(source.installer[:kept]))
+
+	progress := make(chan DownloadProgress, 1024)
+	return &partialDownload{
+		source:   source,
+		path:     "/installer.msi",
+		file:     file,
+		hasher:   hasher,
+		dp:       &DownloadProgress{BytesDownloaded: uint64(kept), BytesTotal: uint64(len(source.installer))},
+		progress: progress,
+	}, file
+}
+
+// checkDownloaded fails t unless file and the download's hash hold exactly installer
+func checkDownloaded(t *testing.T, d *partialDownload, file *os.File, installer string) {
+	t.Helper()
+	got, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != installer {
+		t.Errorf("file holds %q, want %q", got, installer)
+	}
+	want := blake2b.Sum256([]byte(installer))
+	if !bytes.Equal(d.hasher.Sum(nil), want[:]) {
+		t.Error("hash does not cover exactly the installer")
+	}
+	if d.dp.BytesDownloaded != uint64(len(installer)) {
+		t.Errorf("BytesDownloaded = %d, want %d", d.dp.BytesDownloaded, len(installer))
+	}
+}
+
+const testInstaller = "MSI installer bytes, long enough to be cut in two"
+
+func TestAttemptResumesWithRange(t *testing.T) {
+	source := &fakeSource{installer: testInstaller, honorRange: true}
+	d, file := newTestDownload(t, source, 20)
+
+	if err := d.attempt(); err != nil {
+		t.Fatalf("attempt() error = %v", err)
+	}
+	if len(source.offsets) != 1 || source.offsets[0] != 20 {
+		t.Errorf("requested offsets %v, want [20]", source.offsets)
+	}
+	checkDownloaded(t, d, file, testInstaller)
+}
+
+func TestAttemptFallsBackWithoutRange(t *testing.T) {
+	source := &fakeSource{installer: testInstaller}
+	d, file := newTestDownload(t, source, 20)
+
+	if err := d.attempt(); err != nil {
+		t.Fatalf("attempt() error = %v", err)
+	}
+	if len(source.offsets) != 1 || source.offsets[0] != 20 {
+		t.Errorf("requested offsets %v, want [20]", source.offsets)
+	}
+	checkDownloaded(t, d, file, testInstaller)
+}
+
+func TestAttemptStartsOverWhenInstallerChanged(t *testing.T) {
+	source := &fakeSource{installer: testInstaller, honorRange: true, total: 4096}
+	d, file := newTestDownload(t, source, 20)
+
+	if err := d.attempt(); !errors.Is(err, errStalePartial) {
+		t.Fatalf("attempt() error = %v, want errStalePartial", err)
+	}
+	if !retryableDownloadError(errStalePartial) {
+		t.Error("a changed installer isn't retried")
+	}
+	if info, err := file.Stat(); err != nil || info.Size() != 0 {
+		t.Errorf("file not emptied: %v, %v", info.Size(), err)
+	}
+
+	// The retry asks for the whole file
+	source.total = 0
+	if err := d.attempt(); err != nil {
+		t.Fatalf("second attempt() error = %v", err)
+	}
+	if len(source.offsets) != 2 || source.offsets[1] != -1 {
+		t.Errorf("requested offsets %v, want [20 -1]", source.offsets)
+	}
+	checkDownloaded(t, d, file, testInstaller)
+}
+
+func TestAttemptKeepsCopyInPartialStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := newPartialStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.close()
+	if _, _, err := store.resume("/installer.msi", "hash", io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first run is cut short
+	source := &fakeSource{installer: testInstaller[:20]}
+	d, _ := newTestDownload(t, source, 0)
+	d.partial = store
+	if err := d.attempt(); err != nil {
+		t.Fatalf("attempt() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, partialFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != testInstaller[:20] {
+		t.Errorf("kept %q, want %q", got, testInstaller[:20])
+	}
+	if store.sidecar.Size != 20 {
+		t.Errorf("sidecar size = %d, want 20", store.sidecar.Size)
+	}
+}
+
+func TestResumeOffset(t *testing.T) {
+	sidecar := partialSidecar{URL: "/installer.msi", Size: 1000, Hash: "aa"}
+	tests := []struct {
+		name     string
+		sidecar  partialSidecar
+		url      string
+		hash     string
+		fileSize uint64
+		want     uint64
+	}{
+		{name: "same download", sidecar: sidecar, url: "/installer.msi", hash: "aa", fileSize: 400, want: 400},
+		{name: "size not known yet", sidecar: partialSidecar{URL: "/installer.msi", Hash: "aa"}, url: "/installer.msi", hash: "aa", fileSize: 400, want: 400},
+		{name: "nothing kept", sidecar: sidecar, url: "/installer.msi", hash: "aa"},
+		{name: "another installer", sidecar: sidecar, url: "/installer.msi", hash: "bb", fileSize: 400},
+		{name: "moved", sidecar: sidecar, url: "https://downloads.example.com/installer.msi", hash: "aa", fileSize: 400},
+		{name: "whole file kept", sidecar: sidecar, url: "/installer.msi", hash: "aa", fileSize: 1000},
+		{name: "longer than the installer", sidecar: sidecar, url: "/installer.msi", hash: "aa", fileSize: 1200},
+		{name: "over the download limit", sidecar: partialSidecar{URL: "/installer.msi", Hash: "aa"}, url: "/installer.msi", hash: "aa", fileSize: maxDownloadSize + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumeOffset(tt.sidecar, tt.url, tt.hash, tt.fileSize); got != tt.want {
+				t.Errorf("resumeOffset() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// keepDownload leaves a .partial file holding kept and a sidecar in dir
+func keepDownload(t *testing.T, dir string, sidecar partialSidecar, kept string) {
+	t.Helper()
+	store, err := newPartialStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.close()
+	if _, _, err := store.resume(sidecar.URL, sidecar.Hash, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Write([]byte(kept)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.setSize(sidecar.Size); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPartialStoreResume(t *testing.T) {
+	hash := hex.EncodeToString(make([]byte, blake2b.Size256))
+	kept := partialSidecar{URL: "/installer.msi", Size: uint64(len(testInstaller)), Hash: hash}
+
+	tests := []struct {
+		name     string
+		sidecar  string // Replaces the sidecar when set
+		url      string
+		hash     string
+		want     string
+		wantSize uint64
+	}{
+		{name: "same download", url: kept.URL, hash: hash, want: testInstaller[:20], wantSize: kept.Size},
+		{name: "stale sidecar", url: kept.URL, hash: strings.Repeat("1", len(hash))},
+		{name: "corrupt sidecar", sidecar: "{", url: kept.URL, hash: hash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			keepDownload(t, dir, kept, testInstaller[:20])
+			if tt.sidecar != "" {
+				if err := os.WriteFile(filepath.Join(dir, partialSidecarName), []byte(tt.sidecar), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			store, err := newPartialStore(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.close()
+			var resumed bytes.Buffer
+			offset, size, err := store.resume(tt.url, tt.hash, &resumed)
+			if err != nil {
+				t.Fatalf("resume() error = %v", err)
+			}
+			if offset != uint64(len(tt.want)) || resumed.String() != tt.want || size != tt.wantSize {
+				t.Errorf("resume() = %d bytes %q of %d, want %d bytes %q of %d", offset, resumed.String(), size, len(tt.want), tt.want, tt.wantSize)
+			}
+
+			// A dropped download leaves nothing behind, and the sidecar now describes this one
+			if info, err := store.file.Stat(); err != nil || uint64(info.Size()) != offset {
+				t.Errorf(".partial file is %d bytes, want %d", info.Size(), offset)
+			}
+			if store.sidecar.URL != tt.url || store.sidecar.Hash != tt.hash {
+				t.Errorf("sidecar = %+v, want the new download", store.sidecar)
+			}
+		})
+	}
+}
+
+func TestPartialStoreRemove(t *testing.T) {
+	dir := t.TempDir()
+	keepDownload(t, dir, partialSidecar{URL: "/installer.msi", Hash: "aa"}, "kept")
+	store, err := newPartialStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.remove(); err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("%d files left behind", len(entries))
+	}
+}
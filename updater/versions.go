@@ -5,67 +5,85 @@ package updater
 import (
 	"errors"
 	"fmt"
-	"strconv"
+	"os"
 	"strings"
 
 	"github.com/fosrl/newt/logger"
+	"github.com/fosrl/windows/config"
 	"github.com/fosrl/windows/version"
 )
 
-func versionNewerThanUs(candidate string) (bool, error) {
-	logger.Info("Updater: Comparing versions - candidate: %s, current: %s", candidate, version.Number)
-	candidateParts := strings.Split(candidate, ".")
-	ourParts := strings.Split(version.Number, ".")
-	logger.Info("Updater: Candidate parts: %v, Current parts: %v", candidateParts, ourParts)
+// onChannel reports whether candidate is published on channel. Pre-releases are
+// only offered on the beta channel. A candidate that doesn't parse is left for the
+// version comparison to reject.
+func onChannel(candidate string, channel config.UpdateChannel) bool {
+	parsed, err := version.Parse(candidate)
+	return err != nil || !parsed.IsPrerelease() || channel == config.UpdateChannelBeta
+}
 
-	if len(candidateParts) == 0 || len(ourParts) == 0 {
-		return false, errors.New("Empty version")
+// versionNewerThan reports whether candidate is newer than current.
+// A release is newer than any pre-release of the same version, so a beta build is
+// only ever replaced by something newer and switching channels never downgrades.
+func versionNewerThan(candidate, current string) (bool, error) {
+	logger.Info("Updater: Comparing versions - candidate: %s, current: %s", candidate, current)
+	candidateVersion, err := version.Parse(candidate)
+	if err != nil {
+		return false, err
 	}
-	l := len(candidateParts)
-	if len(ourParts) > l {
-		l = len(ourParts)
+	ourVersion, err := version.Parse(current)
+	if err != nil {
+		return false, err
 	}
-	logger.Info("Updater: Comparing %d version components", l)
-
-	for i := 0; i < l; i++ {
-		var err error
-		cP, oP := uint64(0), uint64(0)
-		if i < len(candidateParts) {
-			if len(candidateParts[i]) == 0 {
-				return false, errors.New("Empty version part")
-			}
-			cP, err = strconv.ParseUint(candidateParts[i], 10, 16)
-			if err != nil {
-				return false, errors.New("Invalid version integer part")
-			}
-		}
-		if i < len(ourParts) {
-			if len(ourParts[i]) == 0 {
-				return false, errors.New("Empty version part")
-			}
-			oP, err = strconv.ParseUint(ourParts[i], 10, 16)
-			if err != nil {
-				return false, errors.New("Invalid version integer part")
-			}
-		}
-		logger.Info("Updater: Component %d - candidate: %d, current: %d", i, cP, oP)
-		if cP == oP {
-			logger.Info("Updater: Component %d matches, continuing", i)
-			continue
-		}
-		newer := cP > oP
-		logger.Info("Updater: Component %d differs - candidate is newer: %v", i, newer)
-		return newer, nil
+	newer := ourVersion.Less(candidateVersion)
+	logger.Info("Updater: Candidate %s is newer than %s: %v", candidateVersion, ourVersion, newer)
+	return newer, nil
+}
+
+// allowDowngradeEnv, when set to "1" in the manager service's environment, lets the
+// updater install a build older than the running one. It is meant for support
+// scenarios such as rolling back a bad release.
+const allowDowngradeEnv = "PANGOLIN_ALLOW_UPDATE_DOWNGRADE"
+
+// ErrNotNewer is returned when an update candidate is not newer than the running version
+var ErrNotNewer = errors.New("The available version is not newer than the installed version")
+
+// downgradeAllowed reports whether the downgrade override is set
+func downgradeAllowed() bool {
+	return os.Getenv(allowDowngradeEnv) == "1"
+}
+
+// checkNotDowngrade returns ErrNotNewer unless candidate is strictly newer than
+// current. With allowDowngrade, older versions are accepted too, but the current
+// version itself never is, so an override can't cause an endless reinstall loop.
+func checkNotDowngrade(candidate, current string, allowDowngrade bool) error {
+	candidateVersion, err := version.Parse(candidate)
+	if err != nil {
+		return err
+	}
+	currentVersion, err := version.Parse(current)
+	if err != nil {
+		return err
+	}
+	switch c := candidateVersion.Compare(currentVersion); {
+	case c > 0:
+		return nil
+	case c < 0 && allowDowngrade:
+		logger.Warn("Updater: Allowing downgrade from %s to %s (%s is set)", currentVersion, candidateVersion, allowDowngradeEnv)
+		return nil
+	default:
+		return fmt.Errorf("%w (available %s, installed %s)", ErrNotNewer, candidateVersion, currentVersion)
 	}
-	logger.Info("Updater: All components match - candidate is not newer")
-	return false, nil
 }
 
-func findCandidate(candidates fileList) (*UpdateFound, error) {
-	prefix := fmt.Sprintf(msiArchPrefix, version.Arch())
+func findCandidate(candidates fileList, channel config.UpdateChannel) (*UpdateFound, error) {
+	arch, err := version.ArchOrError()
+	if err != nil {
+		return nil, fmt.Errorf("no installers for this build: %w", err)
+	}
+	prefix := fmt.Sprintf(msiArchPrefix, arch)
 	suffix := msiSuffix
 	currentVersion := version.Number
-	logger.Info("Updater: findCandidate() - Current version: %s, Architecture: %s", currentVersion, version.Arch())
+	logger.Info("Updater: findCandidate() - Current version: %s, Architecture: %s", currentVersion, arch)
 	logger.Info("Updater: Looking for files matching prefix: %s, suffix: %s", prefix, suffix)
 	logger.Info("Updater: Total files in manifest: %d", len(candidates))
 
@@ -90,18 +108,28 @@ func findCandidate(candidates fileList) (*UpdateFound, error) {
 				return nil, errors.New("Version length is too long")
 			}
 
+			if !onChannel(candidateVersion, channel) {
+				logger.Info("Updater: Candidate version %s is a pre-release and channel is %s, skipping", candidateVersion, channel)
+				continue
+			}
+
 			logger.Info("Updater: Comparing candidate version %s with current version %s", candidateVersion, currentVersion)
-			newer, err := versionNewerThanUs(candidateVersion)
+			newer, err := versionNewerThan(candidateVersion, currentVersion)
 			if err != nil {
 				logger.Error("Updater: Version comparison error: %v", err)
 				return nil, fmt.Errorf("error comparing version %s: %w", candidateVersion, err)
 			}
 			logger.Info("Updater: Version comparison result - %s is newer than %s: %v", candidateVersion, currentVersion, newer)
 
+			if !newer && downgradeAllowed() && checkNotDowngrade(candidateVersion, currentVersion, true) == nil {
+				newer = true
+			}
+
 			if newer {
 				logger.Info("Updater: ✓ Update candidate found: %s (hash: %x, location: %s)", name, entry.hash, entry.downloadLocation)
 				return &UpdateFound{
 					name:             name,
+					version:          candidateVersion,
 					hash:             entry.hash,
 					downloadLocation: entry.downloadLocation,
 				}, nil
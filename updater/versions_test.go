@@ -0,0 +1,102 @@
+//go:build windows
+
+package updater
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fosrl/windows/config"
+)
+
+func TestChannelAwareUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string
+		candidate string
+		channel   config.UpdateChannel
+		want      bool
+		wantErr   bool
+	}{
+		{name: "newer release on stable", current: "1.2.0", candidate: "1.3.0", channel: config.UpdateChannelStable, want: true},
+		{name: "newer release on beta", current: "1.2.0", candidate: "1.3.0", channel: config.UpdateChannelBeta, want: true},
+		{name: "same release", current: "1.2.0", candidate: "1.2.0", channel: config.UpdateChannelStable},
+		{name: "beta hidden on stable", current: "1.2.0", candidate: "1.3.0-beta.1", channel: config.UpdateChannelStable},
+		{name: "beta offered on beta", current: "1.2.0", candidate: "1.3.0-beta.1", channel: config.UpdateChannelBeta, want: true},
+		{name: "newer beta on beta", current: "1.3.0-beta.1", candidate: "1.3.0-beta.2", channel: config.UpdateChannelBeta, want: true},
+		{name: "older beta on beta", current: "1.3.0-beta.2", candidate: "1.3.0-beta.1", channel: config.UpdateChannelBeta},
+		{name: "release of the beta", current: "1.3.0-beta.2", candidate: "1.3.0", channel: config.UpdateChannelStable, want: true},
+		{name: "back to stable doesn't downgrade", current: "1.3.0-beta.1", candidate: "1.2.0", channel: config.UpdateChannelStable},
+		{name: "unparseable candidate", current: "1.2.0", candidate: "1.3", channel: config.UpdateChannelBeta, wantErr: true},
+		{name: "unparseable current", current: "dev", candidate: "1.3.0", channel: config.UpdateChannelStable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// As findCandidate does, a candidate off the channel is never compared
+			if !onChannel(tt.candidate, tt.channel) {
+				if tt.want || tt.wantErr {
+					t.Errorf("onChannel(%q, %s) = false, want the candidate considered", tt.candidate, tt.channel)
+				}
+				return
+			}
+			got, err := versionNewerThan(tt.candidate, tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("versionNewerThan(%q, %q) error = %v, want error %v", tt.candidate, tt.current, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("update from %s to %s on %s offered = %v, want %v", tt.current, tt.candidate, tt.channel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNotDowngrade(t *testing.T) {
+	tests := []struct {
+		name           string
+		candidate      string
+		allowDowngrade bool
+		wantNotNewer   bool
+		wantErr        bool
+	}{
+		{name: "newer", candidate: "1.3.0"},
+		{name: "equal", candidate: "1.2.0", wantNotNewer: true, wantErr: true},
+		{name: "older", candidate: "1.1.9", wantNotNewer: true, wantErr: true},
+		{name: "pre-release of the installed version", candidate: "1.2.0-beta.1", wantNotNewer: true, wantErr: true},
+		{name: "older with the override", candidate: "1.1.9", allowDowngrade: true},
+		{name: "equal with the override", candidate: "1.2.0", allowDowngrade: true, wantNotNewer: true, wantErr: true},
+		{name: "newer with the override", candidate: "1.3.0", allowDowngrade: true},
+		{name: "unparseable", candidate: "1.3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkNotDowngrade(tt.candidate, "1.2.0", tt.allowDowngrade)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkNotDowngrade(%q) error = %v, want error %v", tt.candidate, err, tt.wantErr)
+			}
+			if errors.Is(err, ErrNotNewer) != tt.wantNotNewer {
+				t.Errorf("checkNotDowngrade(%q) error = %v, want ErrNotNewer %v", tt.candidate, err, tt.wantNotNewer)
+			}
+		})
+	}
+}
+
+func TestDowngradeAllowed(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{"0", false},
+		{"true", false},
+		{"1", true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(allowDowngradeEnv, tt.value)
+		if got := downgradeAllowed(); got != tt.want {
+			t.Errorf("downgradeAllowed() with %s=%q = %v, want %v", allowDowngradeEnv, tt.value, got, tt.want)
+		}
+	}
+}
@@ -25,9 +25,13 @@ const (
 	_WINHTTP_FLAG_REFRESH              = _WINHTTP_FLAG_BYPASS_PROXY_CACHE
 
 	_WINHTTP_QUERY_CONTENT_LENGTH = 5
+	_WINHTTP_QUERY_STATUS_CODE    = 19
+	_WINHTTP_QUERY_CUSTOM         = 65535
 
 	_WINHTTP_OPTION_ENABLE_HTTP_PROTOCOL = 133
 	_WINHTTP_OPTION_SECURE_PROTOCOLS     = 84
+	_WINHTTP_OPTION_PROXY_USERNAME       = 0x1002
+	_WINHTTP_OPTION_PROXY_PASSWORD       = 0x1003
 
 	_WINHTTP_PROTOCOL_FLAG_HTTP2 = 0x1
 
@@ -20,7 +20,9 @@ var (
 )
 
 type Session struct {
-	handle _HINTERNET
+	handle        _HINTERNET
+	proxyUsername string
+	proxyPassword string
 }
 
 type Connection struct {
@@ -57,7 +59,17 @@ func isWin8DotZeroOrBelow() bool {
 }
 
 func NewSession(userAgent string) (session *Session, err error) {
-	session = new(Session)
+	return newSession(userAgent, "", "", "")
+}
+
+// NewProxySession opens a session that sends every request through the HTTP
+// proxy at host:port, authenticating with username and password if they are set
+func NewProxySession(userAgent, proxy, username, password string) (session *Session, err error) {
+	return newSession(userAgent, proxy, username, password)
+}
+
+func newSession(userAgent, proxy, username, password string) (session *Session, err error) {
+	session = &Session{proxyUsername: username, proxyPassword: password}
 	defer convertError(&err)
 	defer func() {
 		if err != nil {
@@ -73,7 +85,15 @@ func NewSession(userAgent string) (session *Session, err error) {
 	if isWin7() {
 		proxyFlag = _WINHTTP_ACCESS_TYPE_DEFAULT_PROXY
 	}
-	session.handle, err = winHttpOpen(userAgent16, proxyFlag, nil, nil, 0)
+	var proxy16 *uint16
+	if proxy != "" {
+		proxyFlag = _WINHTTP_ACCESS_TYPE_NAMED_PROXY
+		proxy16, err = windows.UTF16PtrFromString(proxy)
+		if err != nil {
+			return
+		}
+	}
+	session.handle, err = winHttpOpen(userAgent16, proxyFlag, proxy16, nil, 0)
 	if err != nil {
 		return
 	}
@@ -138,6 +158,16 @@ func (connection *Connection) Close() (err error) {
 }
 
 func (connection *Connection) Get(path string, refresh bool) (response *Response, err error) {
+	return connection.get(path, refresh, "")
+}
+
+// GetRange requests path starting at byte offset. Servers that don't support
+// ranges answer with the whole file and status 200 instead of 206.
+func (connection *Connection) GetRange(path string, offset uint64) (response *Response, err error) {
+	return connection.get(path, false, fmt.Sprintf("Range: bytes=%d-", offset))
+}
+
+func (connection *Connection) get(path string, refresh bool, headers string) (response *Response, err error) {
 	response = &Response{connection: connection}
 	defer convertError(&err)
 	defer func() {
@@ -165,7 +195,26 @@ func (connection *Connection) Get(path string, refresh bool) (response *Response
 	if err != nil {
 		return
 	}
-	err = winHttpSendRequest(response.handle, nil, 0, nil, 0, 0, 0)
+	if session := connection.session; session.proxyUsername != "" {
+		err = setStringOption(response.handle, _WINHTTP_OPTION_PROXY_USERNAME, session.proxyUsername)
+		if err != nil {
+			return
+		}
+		err = setStringOption(response.handle, _WINHTTP_OPTION_PROXY_PASSWORD, session.proxyPassword)
+		if err != nil {
+			return
+		}
+	}
+	var headers16 *uint16
+	var headersLen uint32
+	if headers != "" {
+		headers16, err = windows.UTF16PtrFromString(headers)
+		if err != nil {
+			return
+		}
+		headersLen = ^uint32(0) // Null terminated
+	}
+	err = winHttpSendRequest(response.handle, headers16, headersLen, nil, 0, 0, 0)
 	if err != nil {
 		return
 	}
@@ -180,6 +229,15 @@ func (connection *Connection) Get(path string, refresh bool) (response *Response
 	return
 }
 
+// setStringOption sets an option that takes a string, whose length is given in characters
+func setStringOption(handle _HINTERNET, option uint32, value string) error {
+	value16, err := windows.UTF16FromString(value)
+	if err != nil {
+		return err
+	}
+	return winHttpSetOption(handle, option, unsafe.Pointer(&value16[0]), uint32(len(value16)-1))
+}
+
 func (response *Response) Length() (length uint64, err error) {
 	defer convertError(&err)
 	numBuf := make([]uint16, 22)
@@ -195,6 +253,37 @@ func (response *Response) Length() (length uint64, err error) {
 	return
 }
 
+func (response *Response) StatusCode() (code uint64, err error) {
+	defer convertError(&err)
+	numBuf := make([]uint16, 8)
+	numLen := uint32(len(numBuf) * 2)
+	err = winHttpQueryHeaders(response.handle, _WINHTTP_QUERY_STATUS_CODE, nil, unsafe.Pointer(&numBuf[0]), &numLen, nil)
+	if err != nil {
+		return
+	}
+	code, err = strconv.ParseUint(windows.UTF16ToString(numBuf[:numLen/2]), 10, 64)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// ContentRange returns the raw Content-Range header of a partial response
+func (response *Response) ContentRange() (value string, err error) {
+	defer convertError(&err)
+	name16, err := windows.UTF16PtrFromString("Content-Range")
+	if err != nil {
+		return
+	}
+	buf := make([]uint16, 128)
+	bufLen := uint32(len(buf) * 2)
+	err = winHttpQueryHeaders(response.handle, _WINHTTP_QUERY_CUSTOM, name16, unsafe.Pointer(&buf[0]), &bufLen, nil)
+	if err != nil {
+		return
+	}
+	return windows.UTF16ToString(buf[:bufLen/2]), nil
+}
+
 func (response *Response) Read(p []byte) (n int, err error) {
 	defer convertError(&err)
 	if len(p) == 0 {
@@ -203,7 +292,7 @@ func (response *Response) Read(p []byte) (n int, err error) {
 	var bytesRead uint32
 	err = winHttpReadData(response.handle, &p[0], uint32(len(p)), &bytesRead)
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 	if bytesRead == 0 || int(bytesRead) < 0 {
 		return 0, io.EOF
@@ -0,0 +1,42 @@
+//go:build windows
+
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Commit and BuildDate are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/fosrl/windows/version.Commit=abc1234 -X github.com/fosrl/windows/version.BuildDate=2025-01-02T15:04:05Z"
+//
+// They are empty in builds that don't set them.
+var (
+	Commit    string
+	BuildDate string
+)
+
+// Official is set to "true" at build time in release builds, e.g.
+//
+//	go build -ldflags "-X github.com/fosrl/windows/version.Official=true"
+//
+// A build without it is never official, see IsRunningOfficialVersion.
+var Official string
+
+// Info describes this build for bug reports, one "Key: value" per line
+func Info() string {
+	return buildInfo(Number, Commit, BuildDate, UserAgent())
+}
+
+// buildInfo assembles the text returned by Info, using "unknown" for unset fields
+func buildInfo(number, commit, buildDate, userAgent string) string {
+	orUnknown := func(s string) string {
+		if s = strings.TrimSpace(s); s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	return fmt.Sprintf("Version: %s\nCommit: %s\nBuild Date: %s\nUser-Agent: %s",
+		orUnknown(number), orUnknown(commit), orUnknown(buildDate), orUnknown(userAgent))
+}
@@ -0,0 +1,59 @@
+//go:build windows
+
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	const userAgent = "pangolin-windows-1.2.3 (Windows 10.0.19045; amd64)"
+
+	tests := []struct {
+		name      string
+		number    string
+		commit    string
+		buildDate string
+		userAgent string
+		want      string
+	}{
+		{
+			name:      "release build",
+			number:    "1.2.3",
+			commit:    "abc1234",
+			buildDate: "2026-10-17T12:00:00Z",
+			userAgent: userAgent,
+			want:      "Version: 1.2.3\nCommit: abc1234\nBuild Date: 2026-10-17T12:00:00Z\nUser-Agent: " + userAgent,
+		},
+		{
+			name:      "without ldflags",
+			number:    "1.2.3",
+			userAgent: userAgent,
+			want:      "Version: 1.2.3\nCommit: unknown\nBuild Date: unknown\nUser-Agent: " + userAgent,
+		},
+		{
+			name:   "padded",
+			number: " 1.2.3\n",
+			commit: "  ",
+			want:   "Version: 1.2.3\nCommit: unknown\nBuild Date: unknown\nUser-Agent: unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildInfo(tt.number, tt.commit, tt.buildDate, tt.userAgent); got != tt.want {
+				t.Errorf("buildInfo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfo(t *testing.T) {
+	info := Info()
+	for _, want := range []string{"Version: " + Number + "\n", "\nUser-Agent: " + UserAgent()} {
+		if !strings.Contains(info, want) {
+			t.Errorf("Info() = %q, want it to contain %q", info, want)
+		}
+	}
+}
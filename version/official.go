@@ -5,6 +5,7 @@ package version
 import (
 	"errors"
 	"os"
+	"strings"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -14,10 +15,53 @@ const (
 	officialCommonName = "Fossorial"
 )
 
-// IsRunningOfficialVersion checks if the current executable is signed with the official certificate.
+// DevUpdatesEnv names the environment variable that, set to "1", allows updates
+// on a build that isn't official, for testing the updater
+const DevUpdatesEnv = "PANGOLIN_ALLOW_DEV_UPDATES"
+
+// IsRunningOfficialVersion checks if the current executable is an official build:
+// built with Official set to "true", carrying a well-formed version number, and
+// signed with the official certificate.
 // This is an easily by-passable check, which does not serve security purposes.
 // DO NOT PLACE SECURITY-SENSITIVE FUNCTIONS IN THIS FILE
 func IsRunningOfficialVersion() bool {
+	return isOfficialBuild(Official, Number, isSignedOfficially)
+}
+
+// UpdatesAllowed reports whether this build may update itself, and whether it
+// does so in development mode, see updatePolicy
+func UpdatesAllowed() (allowed, devMode bool) {
+	return updatePolicy(IsRunningOfficialVersion(), os.Getenv(DevUpdatesEnv))
+}
+
+// isOfficialBuild decides whether a build is official from its Official flag
+// and version number, only then asking signed whether the executable carries
+// the official signature, since checking that is comparatively slow
+func isOfficialBuild(officialFlag, number string, signed func() bool) bool {
+	if strings.TrimSpace(officialFlag) != "true" {
+		return false
+	}
+	if _, err := Parse(number); err != nil {
+		return false
+	}
+	return signed()
+}
+
+// updatePolicy decides whether a build may update itself. An official build
+// always may and never runs in development mode, whatever devUpdates says, so
+// the environment can't relax checks on a release. An unofficial build may only
+// in development mode, which devUpdates set to "1" turns on.
+func updatePolicy(official bool, devUpdates string) (allowed, devMode bool) {
+	if official {
+		return true, false
+	}
+	devMode = strings.TrimSpace(devUpdates) == "1"
+	return devMode, devMode
+}
+
+// isSignedOfficially reports whether the running executable is signed with the
+// official certificate
+func isSignedOfficially() bool {
 	path, err := os.Executable()
 	if err != nil {
 		return false
@@ -0,0 +1,70 @@
+//go:build windows
+
+package version
+
+import "testing"
+
+func TestIsOfficialBuild(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		number     string
+		signed     bool
+		want       bool
+		wantSigned bool // Whether the signature has to be checked
+	}{
+		{name: "official", flag: "true", number: "1.2.3", signed: true, want: true, wantSigned: true},
+		{name: "flag with spaces", flag: " true\n", number: "1.2.3", signed: true, want: true, wantSigned: true},
+		{name: "prerelease", flag: "true", number: "1.3.0-beta.1", signed: true, want: true, wantSigned: true},
+		{name: "not signed", flag: "true", number: "1.2.3", signed: false, want: false, wantSigned: true},
+		{name: "flag false", flag: "false", number: "1.2.3", signed: true},
+		{name: "flag unset", flag: "", number: "1.2.3", signed: true},
+		{name: "flag not exactly true", flag: "True", number: "1.2.3", signed: true},
+		{name: "flag yes", flag: "1", number: "1.2.3", signed: true},
+		{name: "development version", flag: "true", number: "dev", signed: true},
+		{name: "empty version", flag: "true", number: "", signed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checked := false
+			signed := func() bool {
+				checked = true
+				return tt.signed
+			}
+			if got := isOfficialBuild(tt.flag, tt.number, signed); got != tt.want {
+				t.Errorf("isOfficialBuild(%q, %q) = %v, want %v", tt.flag, tt.number, got, tt.want)
+			}
+			if checked != tt.wantSigned {
+				t.Errorf("signature checked = %v, want %v", checked, tt.wantSigned)
+			}
+		})
+	}
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		official    bool
+		devUpdates  string
+		wantAllowed bool
+		wantDevMode bool
+	}{
+		{name: "official", official: true, wantAllowed: true},
+		{name: "official ignores dev updates", official: true, devUpdates: "1", wantAllowed: true},
+		{name: "unofficial", official: false},
+		{name: "unofficial with dev updates", official: false, devUpdates: "1", wantAllowed: true, wantDevMode: true},
+		{name: "dev updates with spaces", official: false, devUpdates: " 1 ", wantAllowed: true, wantDevMode: true},
+		{name: "dev updates off", official: false, devUpdates: "0"},
+		{name: "dev updates true", official: false, devUpdates: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, devMode := updatePolicy(tt.official, tt.devUpdates)
+			if allowed != tt.wantAllowed || devMode != tt.wantDevMode {
+				t.Errorf("updatePolicy(%v, %q) = %v, %v, want %v, %v", tt.official, tt.devUpdates, allowed, devMode, tt.wantAllowed, tt.wantDevMode)
+			}
+		})
+	}
+}
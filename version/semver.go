@@ -0,0 +1,141 @@
+//go:build windows
+
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed major.minor.patch version with an optional pre-release tag,
+// e.g. "1.2.0" or "1.2.0-beta.1". Build metadata ("+...") is accepted and ignored.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease string
+}
+
+// Parse parses a version string such as "1.2.0" or "1.2.0-beta.1"
+func Parse(s string) (Version, error) {
+	var v Version
+	core, _, _ := strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(core, "-")
+	if hasPre {
+		if pre == "" {
+			return v, fmt.Errorf("invalid version %q: empty pre-release tag", s)
+		}
+		for _, ident := range strings.Split(pre, ".") {
+			if ident == "" {
+				return v, fmt.Errorf("invalid version %q: empty pre-release identifier", s)
+			}
+		}
+		v.Prerelease = pre
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version %q: expected major.minor.patch", s)
+	}
+	nums := [3]*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid version %q: component %q is not a number", s, part)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// Current returns the parsed version of the running build
+func Current() (Version, error) {
+	return Parse(Number)
+}
+
+// String formats the version without build metadata
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// IsPrerelease reports whether the version carries a pre-release tag
+func (v Version) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is lower than, equal to or
+// higher than other. A pre-release sorts before its release (1.2.0-beta < 1.2.0).
+func (v Version) Compare(other Version) int {
+	if c := compareUint(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// Less reports whether v is lower than other
+func (v Version) Less(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// Equal reports whether v and other have the same precedence
+func (v Version) Equal(other Version) bool {
+	return v.Compare(other) == 0
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrerelease orders pre-release tags: no tag is highest, then identifiers are compared
+// left to right, numerically when both are numbers, with numbers lower than words
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	aIdents := strings.Split(a, ".")
+	bIdents := strings.Split(b, ".")
+	for i := 0; i < len(aIdents) && i < len(bIdents); i++ {
+		aNum, aErr := strconv.ParseUint(aIdents[i], 10, 64)
+		bNum, bErr := strconv.ParseUint(bIdents[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareUint(aNum, bNum); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aIdents[i], bIdents[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareUint(uint64(len(aIdents)), uint64(len(bIdents)))
+}
@@ -0,0 +1,136 @@
+//go:build windows
+
+package version
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantErr bool
+	}{
+		{in: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{in: "0.0.0", want: Version{}},
+		{in: "1.2.0-beta.1", want: Version{Major: 1, Minor: 2, Prerelease: "beta.1"}},
+		{in: "1.2.0-rc.1+build.5", want: Version{Major: 1, Minor: 2, Prerelease: "rc.1"}},
+		{in: "1.2.0+build.5", want: Version{Major: 1, Minor: 2}},
+		{in: "1.2", wantErr: true},
+		{in: "1.2.3.4", wantErr: true},
+		{in: "v1.2.3", wantErr: true},
+		{in: "1.x.3", wantErr: true},
+		{in: "1.2.3-", wantErr: true},
+		{in: "1.2.3-beta..1", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.3.0", "1.2.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.10.0", "1.9.0", 1},
+		{"1.2.0+build.1", "1.2.0+build.2", 0},
+
+		// Pre-releases sort before their release
+		{"1.2.0-beta", "1.2.0", -1},
+		{"1.2.0", "1.2.0-rc.1", 1},
+		{"1.2.0-rc.1", "1.1.9", 1},
+
+		// Identifiers compare left to right
+		{"1.2.0-alpha", "1.2.0-beta", -1},
+		{"1.2.0-beta", "1.2.0-rc", -1},
+		{"1.2.0-beta.2", "1.2.0-beta.11", -1},
+		{"1.2.0-beta.1", "1.2.0-beta.1", 0},
+
+		// Numbers are lower than words, and more identifiers are higher
+		{"1.2.0-1", "1.2.0-alpha", -1},
+		{"1.2.0-alpha.1", "1.2.0-alpha.beta", -1},
+		{"1.2.0-alpha", "1.2.0-alpha.1", -1},
+	}
+
+	for _, tt := range tests {
+		a, err := Parse(tt.a)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.a, err)
+		}
+		b, err := Parse(tt.b)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.b, err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.Compare(a); got != -tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+		if got := a.Less(b); got != (tt.want < 0) {
+			t.Errorf("%s.Less(%s) = %v, want %v", tt.a, tt.b, got, tt.want < 0)
+		}
+		if got := a.Equal(b); got != (tt.want == 0) {
+			t.Errorf("%s.Equal(%s) = %v, want %v", tt.a, tt.b, got, tt.want == 0)
+		}
+	}
+}
+
+func TestPrereleaseOrdering(t *testing.T) {
+	// The example ordering from the SemVer spec
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		lower, _ := Parse(ordered[i-1])
+		higher, _ := Parse(ordered[i])
+		if !lower.Less(higher) {
+			t.Errorf("%s is not lower than %s", ordered[i-1], ordered[i])
+		}
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	for in, want := range map[string]string{
+		"1.2.3":              "1.2.3",
+		"1.2.3-beta.1":       "1.2.3-beta.1",
+		"1.2.3-beta.1+build": "1.2.3-beta.1",
+	} {
+		v, err := Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", in, err)
+		}
+		if got := v.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+		if got := v.IsPrerelease(); got != (v.Prerelease != "") {
+			t.Errorf("Parse(%q).IsPrerelease() = %v", in, got)
+		}
+	}
+}
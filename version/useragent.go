@@ -5,19 +5,50 @@ package version
 import (
 	"fmt"
 	"runtime"
+	"sync"
+
+	"github.com/fosrl/newt/logger"
 )
 
-func Arch() string {
-	switch runtime.GOARCH {
+// UnknownArch is returned by Arch for a GOARCH with no installer; it matches no update
+const UnknownArch = "unknown"
+
+// archName maps a GOARCH to the architecture in installer names (pangolin-<arch>-<version>.msi)
+func archName(goarch string) (string, error) {
+	switch goarch {
 	case "arm", "arm64", "amd64":
-		return runtime.GOARCH
+		return goarch, nil
 	case "386":
-		return "x86"
+		return "x86", nil
 	default:
-		panic("Unrecognized GOARCH")
+		return "", fmt.Errorf("unrecognized GOARCH %q", goarch)
 	}
 }
 
+// ArchOrError returns the architecture this build's installers are named with
+func ArchOrError() (string, error) {
+	return archName(runtime.GOARCH)
+}
+
+// Arch returns the architecture this build's installers are named with, or
+// UnknownArch for an unrecognized GOARCH
+func Arch() string {
+	arch, err := ArchOrError()
+	if err != nil {
+		logger.Error("Failed to determine architecture: %v", err)
+		return UnknownArch
+	}
+	return arch
+}
+
+var (
+	osNameOnce   sync.Once
+	cachedOsName string
+)
+
+// UserAgent identifies the app to servers, e.g. "pangolin-windows-1.2.3 (Windows 10.0.19045; amd64)".
+// The leading token is kept stable for servers that parse it.
 func UserAgent() string {
-	return fmt.Sprintf("pangolin-windows-%s", Number)
+	osNameOnce.Do(func() { cachedOsName = OsName() })
+	return fmt.Sprintf("pangolin-windows-%s (%s; %s)", Number, cachedOsName, Arch())
 }
@@ -0,0 +1,69 @@
+//go:build windows
+
+package version
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestArchName(t *testing.T) {
+	tests := []struct {
+		goarch  string
+		want    string
+		wantErr bool
+	}{
+		{goarch: "amd64", want: "amd64"},
+		{goarch: "arm64", want: "arm64"},
+		{goarch: "arm", want: "arm"},
+		{goarch: "386", want: "x86"},
+		{goarch: "riscv64", wantErr: true},
+		{goarch: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := archName(tt.goarch)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("archName(%q) = %q, want an error", tt.goarch, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("archName(%q): %v", tt.goarch, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("archName(%q) = %q, want %q", tt.goarch, got, tt.want)
+		}
+	}
+}
+
+func TestArch(t *testing.T) {
+	want, err := archName(runtime.GOARCH)
+	if err != nil {
+		t.Fatalf("no installer architecture for GOARCH %q: %v", runtime.GOARCH, err)
+	}
+	if got, err := ArchOrError(); got != want || err != nil {
+		t.Errorf("ArchOrError() = %q, %v, want %q", got, err, want)
+	}
+	if got := Arch(); got != want {
+		t.Errorf("Arch() = %q, want %q", got, want)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	got := UserAgent()
+	if prefix := "pangolin-windows-" + Number + " ("; !strings.HasPrefix(got, prefix) {
+		t.Fatalf("UserAgent() = %q, want it to start with %q", got, prefix)
+	}
+	format := regexp.MustCompile(`^pangolin-windows-\S+ \(Windows( Server| Controller)? [1-9]\d*\.\d+\.[1-9]\d*; ` + regexp.QuoteMeta(Arch()) + `\)$`)
+	if !format.MatchString(got) {
+		t.Errorf("UserAgent() = %q, want it to match %s", got, format)
+	}
+	if again := UserAgent(); again != got {
+		t.Errorf("second UserAgent() = %q, want %q", again, got)
+	}
+}